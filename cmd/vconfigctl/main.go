@@ -0,0 +1,15 @@
+// vconfigctl 初始化、校验和比较配置文件的命令行工具
+//
+// 未注册配置结构体时 validate 只检查文件能否解析，diff 按原始键值比较；
+// 需要按结构体校验或生成默认配置时，在自己的命令中调用 vconfig.Register 后调用 vconfig.RunCtl
+package main
+
+import (
+	"os"
+
+	"github.com/constructorvirgil/virlog/vconfig"
+)
+
+func main() {
+	os.Exit(vconfig.RunCtl(os.Args[1:], os.Stdout, os.Stderr))
+}
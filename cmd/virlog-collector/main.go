@@ -0,0 +1,62 @@
+// virlog-collector 通过gRPC接收各进程转发的日志，按日志配置写入自身的输出
+//
+//	virlog-collector -listen :7070 -config collector.yaml
+//
+// 未指定 -config 时使用默认配置和 VIRLOG_* 环境变量
+package main
+
+import (
+	"flag"
+	"fmt"
+	"net"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/constructorvirgil/virlog/config"
+	"github.com/constructorvirgil/virlog/logger"
+	"github.com/constructorvirgil/virlog/logger/remote"
+	"google.golang.org/grpc"
+)
+
+func main() {
+	listen := flag.String("listen", ":7070", "监听地址")
+	configFile := flag.String("config", "", "日志配置文件（yaml或json）")
+	flag.Parse()
+
+	if err := run(*listen, *configFile); err != nil {
+		fmt.Fprintf(os.Stderr, "virlog-collector: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func run(listen, configFile string) error {
+	cfg := config.GetConfig()
+	if configFile != "" {
+		var err error
+		if cfg, err = config.LoadFromFile(configFile); err != nil {
+			return err
+		}
+	}
+	log, err := logger.NewLogger(cfg)
+	if err != nil {
+		return fmt.Errorf("创建Logger失败: %w", err)
+	}
+	defer log.Sync()
+
+	lis, err := net.Listen("tcp", listen)
+	if err != nil {
+		return fmt.Errorf("监听 %s 失败: %w", listen, err)
+	}
+	server := grpc.NewServer()
+	remote.NewServer(log).Register(server)
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-signals
+		// 等待正在发送的日志写入后退出
+		server.GracefulStop()
+	}()
+	return server.Serve(lis)
+}
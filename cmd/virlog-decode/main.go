@@ -0,0 +1,95 @@
+// virlog-decode 将 msgpack 或 protobuf 格式的日志文件转换为JSON，每行一条日志
+//
+//	virlog-decode -format protobuf app.log
+//	virlog-decode -f app.log       # 持续读取追加的日志，类似 tail -f
+//
+// 未指定文件时读取标准输入；未指定 -format 时根据扩展名推断（.pb 为protobuf，其他为msgpack）
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/constructorvirgil/virlog/logger"
+)
+
+// followInterval 持续读取时检查文件追加内容的间隔
+const followInterval = 200 * time.Millisecond
+
+func main() {
+	format := flag.String("format", "", "日志格式：msgpack 或 protobuf")
+	follow := flag.Bool("f", false, "读到文件末尾后等待追加的日志")
+	flag.Parse()
+
+	if err := run(flag.Arg(0), *format, *follow); err != nil {
+		fmt.Fprintf(os.Stderr, "virlog-decode: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func run(filename, format string, follow bool) error {
+	var r io.Reader = os.Stdin
+	if filename != "" {
+		f, err := os.Open(filename)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		r = f
+		if follow {
+			r = &followReader{r: f}
+		}
+	}
+	if format == "" {
+		format = "msgpack"
+		if filepath.Ext(filename) == ".pb" {
+			format = "protobuf"
+		}
+	}
+
+	dec, err := logger.NewBinaryDecoder(r, format)
+	if err != nil {
+		return err
+	}
+	out := bufio.NewWriter(os.Stdout)
+	defer out.Flush()
+	enc := json.NewEncoder(out)
+	for {
+		record, err := dec.Decode()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if err := enc.Encode(record); err != nil {
+			return err
+		}
+		if follow {
+			// 持续读取时及时输出
+			out.Flush()
+		}
+	}
+}
+
+// followReader 读到文件末尾时等待追加的内容，而不是返回 io.EOF
+type followReader struct {
+	r io.Reader
+}
+
+func (f *followReader) Read(p []byte) (int, error) {
+	for {
+		n, err := f.r.Read(p)
+		if n > 0 || !errors.Is(err, io.EOF) {
+			return n, err
+		}
+		time.Sleep(followInterval)
+	}
+}
@@ -0,0 +1,117 @@
+// virlog-pretty 将JSON格式的日志转换为带颜色的易读文本，每行一条日志
+//
+//	virlog-pretty app.log
+//	virlog-pretty -f -level warn app.log                    # 持续读取追加的日志，只显示warn及以上级别
+//	kubectl logs app | virlog-pretty -fields request_id,user -since 30m
+//
+// 未指定文件时读取标准输入；无法解析为JSON的行原样输出。输出到终端时默认使用颜色
+package main
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+)
+
+// followInterval 持续读取时检查文件追加内容的间隔
+const followInterval = 200 * time.Millisecond
+
+func main() {
+	var opts options
+	follow := flag.Bool("f", false, "读到文件末尾后等待追加的日志")
+	level := flag.String("level", "", "只显示不低于该级别的日志，如 warn")
+	fields := flag.String("fields", "", "只显示这些字段，逗号分隔，默认显示全部字段")
+	since := flag.String("since", "", "只显示该时间之后的日志，RFC3339时间或时长（如 30m 表示30分钟前）")
+	until := flag.String("until", "", "只显示该时间之前的日志，格式同 -since")
+	noColor := flag.Bool("no-color", false, "不使用颜色")
+	color := flag.Bool("color", false, "输出不是终端时也使用颜色")
+	flag.StringVar(&opts.timeKey, "time-key", "time", "时间字段名")
+	flag.StringVar(&opts.levelKey, "level-key", "level", "级别字段名")
+	flag.StringVar(&opts.messageKey, "message-key", "msg", "消息字段名")
+	flag.Parse()
+
+	if err := opts.parse(*level, *fields, *since, *until, time.Now()); err != nil {
+		fmt.Fprintf(os.Stderr, "virlog-pretty: %v\n", err)
+		os.Exit(2)
+	}
+	opts.color = *color || (!*noColor && isTerminal(os.Stdout))
+
+	if err := run(flag.Arg(0), *follow, &opts); err != nil {
+		fmt.Fprintf(os.Stderr, "virlog-pretty: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func run(filename string, follow bool, opts *options) error {
+	var r io.Reader = os.Stdin
+	if filename != "" {
+		f, err := os.Open(filename)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		r = f
+		if follow {
+			r = &followReader{r: f}
+		}
+	}
+
+	out := bufio.NewWriter(os.Stdout)
+	defer out.Flush()
+	reader := bufio.NewReader(r)
+	for {
+		line, err := reader.ReadBytes('\n')
+		if len(line) > 0 {
+			if text, ok := opts.render(line); ok {
+				out.WriteString(text)
+			}
+			if follow {
+				// 持续读取时及时输出
+				out.Flush()
+			}
+		}
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// followReader 读到文件末尾时等待追加的内容，而不是返回 io.EOF
+type followReader struct {
+	r io.Reader
+}
+
+func (f *followReader) Read(p []byte) (int, error) {
+	for {
+		n, err := f.r.Read(p)
+		if n > 0 || !errors.Is(err, io.EOF) {
+			return n, err
+		}
+		time.Sleep(followInterval)
+	}
+}
+
+// isTerminal 判断文件是否为终端
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// splitList 拆分逗号分隔的列表，忽略空项
+func splitList(s string) []string {
+	var items []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
@@ -0,0 +1,316 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// 固定的Logger名称、调用者和调用栈字段名，与 logger 包的默认编码器配置一致
+	nameKey       = "logger"
+	callerKey     = "caller"
+	stacktraceKey = "stacktrace"
+
+	colorReset   = "\x1b[0m"
+	colorBold    = "\x1b[1m"
+	colorRed     = "\x1b[31m"
+	colorYellow  = "\x1b[33m"
+	colorBlue    = "\x1b[34m"
+	colorMagenta = "\x1b[35m"
+	colorCyan    = "\x1b[36m"
+	colorGray    = "\x1b[90m"
+
+	// outputTimeFormat 输出的时间格式
+	outputTimeFormat = "2006-01-02 15:04:05.000"
+)
+
+// levelRanks 级别名称及其高低，数值越大级别越高
+var levelRanks = map[string]int{
+	"trace":   -2,
+	"debug":   -1,
+	"info":    0,
+	"warn":    1,
+	"warning": 1,
+	"error":   2,
+	"dpanic":  3,
+	"panic":   4,
+	"fatal":   5,
+}
+
+// inputTimeLayouts 日志中时间字符串可能使用的格式，依次尝试
+var inputTimeLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05.999999999Z0700",
+	"2006-01-02 15:04:05.999999999Z07:00",
+	"2006-01-02 15:04:05.999999999",
+	"2006-01-02T15:04:05.999999999",
+}
+
+// options 过滤和输出日志的选项
+type options struct {
+	timeKey    string
+	levelKey   string
+	messageKey string
+	// minLevel 最低级别，hasMinLevel 为false时不按级别过滤
+	minLevel    int
+	hasMinLevel bool
+	// fields 只显示的字段，为空时显示全部字段
+	fields []string
+	since  time.Time
+	until  time.Time
+	color  bool
+}
+
+// parse 解析命令行参数中的级别、字段和时间范围，now 用于计算 -since 30m 这样的相对时间
+func (o *options) parse(level, fields, since, until string, now time.Time) error {
+	if level != "" {
+		rank, ok := levelRanks[strings.ToLower(level)]
+		if !ok {
+			return fmt.Errorf("未知的日志级别: %s", level)
+		}
+		o.minLevel, o.hasMinLevel = rank, true
+	}
+	o.fields = splitList(fields)
+
+	var err error
+	if o.since, err = parseTimeArg(since, now); err != nil {
+		return fmt.Errorf("-since: %w", err)
+	}
+	if o.until, err = parseTimeArg(until, now); err != nil {
+		return fmt.Errorf("-until: %w", err)
+	}
+	return nil
+}
+
+// parseTimeArg 解析时间参数：时长表示 now 之前的时间，也可以是RFC3339时间或本地时间 2006-01-02 15:04:05
+func parseTimeArg(s string, now time.Time) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+	if d, err := time.ParseDuration(s); err == nil {
+		return now.Add(-d), nil
+	}
+	if t, err := time.Parse(time.RFC3339Nano, s); err == nil {
+		return t, nil
+	}
+	for _, layout := range []string{"2006-01-02 15:04:05", "2006-01-02T15:04:05", "2006-01-02"} {
+		if t, err := time.ParseInLocation(layout, s, time.Local); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("无法解析时间: %s", s)
+}
+
+// field JSON日志中的一个字段
+type field struct {
+	key   string
+	value json.RawMessage
+}
+
+// render 将一行日志转换为输出的文本，被过滤的日志返回false
+//
+// 无法解析为JSON对象的行原样输出；指定了时间范围时，没有可解析时间的日志被过滤
+func (o *options) render(line []byte) (string, bool) {
+	line = bytes.TrimRight(line, "\r\n")
+	fields, ok := parseObject(line)
+	if !ok {
+		return string(line) + "\n", true
+	}
+
+	var (
+		ts, level, message, name, caller, stack string
+		tsTime                                  time.Time
+		tsOK                                    bool
+		extra                                   []field
+	)
+	for _, f := range fields {
+		switch f.key {
+		case o.timeKey:
+			ts = rawText(f.value)
+			tsTime, tsOK = parseLogTime(f.value)
+		case o.levelKey:
+			level = rawText(f.value)
+		case o.messageKey:
+			message = rawText(f.value)
+		case nameKey:
+			name = rawText(f.value)
+		case callerKey:
+			caller = rawText(f.value)
+		case stacktraceKey:
+			stack = rawText(f.value)
+		default:
+			extra = append(extra, f)
+		}
+	}
+
+	if o.hasMinLevel {
+		if rank, ok := levelRanks[strings.ToLower(level)]; ok && rank < o.minLevel {
+			return "", false
+		}
+	}
+	if !o.since.IsZero() || !o.until.IsZero() {
+		if !tsOK || (!o.since.IsZero() && tsTime.Before(o.since)) || (!o.until.IsZero() && tsTime.After(o.until)) {
+			return "", false
+		}
+	}
+
+	var b strings.Builder
+	if tsOK {
+		ts = tsTime.Format(outputTimeFormat)
+	}
+	if ts != "" {
+		b.WriteString(o.paint(colorGray, ts))
+		b.WriteByte(' ')
+	}
+	b.WriteString(o.paint(levelColor(level), fmt.Sprintf("%-5s", strings.ToUpper(level))))
+	if name != "" {
+		b.WriteByte(' ')
+		b.WriteString(o.paint(colorCyan, name))
+	}
+	b.WriteByte(' ')
+	b.WriteString(o.paint(colorBold, message))
+
+	for _, f := range o.selectFields(extra) {
+		b.WriteString("  ")
+		b.WriteString(o.paint(colorGray, f.key+"="))
+		b.WriteString(formatValue(f.value))
+	}
+	if caller != "" {
+		b.WriteString("  ")
+		b.WriteString(o.paint(colorGray, caller))
+	}
+	b.WriteByte('\n')
+	if stack != "" {
+		for _, frame := range strings.Split(stack, "\n") {
+			b.WriteString("    ")
+			b.WriteString(frame)
+			b.WriteByte('\n')
+		}
+	}
+	return b.String(), true
+}
+
+// selectFields 返回要显示的字段，指定了 -fields 时按指定的顺序返回
+func (o *options) selectFields(extra []field) []field {
+	if len(o.fields) == 0 {
+		return extra
+	}
+	selected := make([]field, 0, len(o.fields))
+	for _, key := range o.fields {
+		for _, f := range extra {
+			if f.key == key {
+				selected = append(selected, f)
+				break
+			}
+		}
+	}
+	return selected
+}
+
+// paint 使用颜色输出文本
+func (o *options) paint(color, s string) string {
+	if !o.color || s == "" {
+		return s
+	}
+	return color + s + colorReset
+}
+
+// levelColor 返回级别对应的颜色，与 zap 的彩色级别一致
+func levelColor(level string) string {
+	rank, ok := levelRanks[strings.ToLower(level)]
+	switch {
+	case !ok:
+		return colorReset
+	case rank < 0:
+		return colorMagenta
+	case rank == 0:
+		return colorBlue
+	case rank == 1:
+		return colorYellow
+	default:
+		return colorRed
+	}
+}
+
+// parseObject 按顺序解析JSON对象的字段，不是JSON对象时返回false
+func parseObject(line []byte) ([]field, bool) {
+	dec := json.NewDecoder(bytes.NewReader(line))
+	if tok, err := dec.Token(); err != nil || tok != json.Delim('{') {
+		return nil, false
+	}
+	var fields []field
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return nil, false
+		}
+		key, _ := tok.(string)
+		var value json.RawMessage
+		if err := dec.Decode(&value); err != nil {
+			return nil, false
+		}
+		fields = append(fields, field{key: key, value: value})
+	}
+	if _, err := dec.Token(); err != nil {
+		return nil, false
+	}
+	return fields, true
+}
+
+// rawText 返回字段的文本，字符串去掉引号，其他类型返回JSON
+func rawText(value json.RawMessage) string {
+	var s string
+	if json.Unmarshal(value, &s) == nil {
+		return s
+	}
+	return string(value)
+}
+
+// formatValue 格式化字段值：简单的字符串不加引号，包含空白、引号或等号的字符串加引号，对象和数组输出紧凑的JSON
+func formatValue(value json.RawMessage) string {
+	var s string
+	if json.Unmarshal(value, &s) == nil {
+		if s == "" || strings.ContainsAny(s, " \t\r\n\"=") {
+			return strconv.Quote(s)
+		}
+		return s
+	}
+	var buf bytes.Buffer
+	if json.Compact(&buf, value) == nil {
+		return buf.String()
+	}
+	return string(value)
+}
+
+// parseLogTime 解析日志中的时间，支持常见的时间字符串以及秒、毫秒、微秒、纳秒时间戳
+func parseLogTime(value json.RawMessage) (time.Time, bool) {
+	var s string
+	if json.Unmarshal(value, &s) == nil {
+		for _, layout := range inputTimeLayouts {
+			if t, err := time.Parse(layout, s); err == nil {
+				return t, true
+			}
+		}
+		return time.Time{}, false
+	}
+
+	var n float64
+	if json.Unmarshal(value, &n) != nil {
+		return time.Time{}, false
+	}
+	switch {
+	case n >= 1e17:
+		return time.Unix(0, int64(n)), true
+	case n >= 1e14:
+		return time.UnixMicro(int64(n)), true
+	case n >= 1e11:
+		return time.UnixMilli(int64(n)), true
+	default:
+		sec := int64(n)
+		return time.Unix(sec, int64((n-float64(sec))*1e9)), true
+	}
+}
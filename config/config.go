@@ -45,22 +45,58 @@ var (
 type Config struct {
 	// 日志级别
 	Level string `json:"level" yaml:"level" mapstructure:"level"`
-	// 日志格式 "json" 或 "console"
+	// 按Logger名称前缀设置的级别，如 {"http": "warn", "http.client": "debug"}，最长的前缀优先
+	NamedLevels map[string]string `json:"named_levels" yaml:"named_levels" mapstructure:"named_levels"`
+	// 日志格式 "json"、"console"、"logfmt"、"cef"，或二进制格式 "msgpack"、"protobuf"
 	Format string `json:"format" yaml:"format" mapstructure:"format"`
-	// 输出位置，支持 "stdout", "stderr", "file"
+	// CEF格式的日志头配置，仅在 Format 为 "cef" 时生效
+	CEF *CEFConfig `json:"cef" yaml:"cef" mapstructure:"cef"`
+	// 控制台格式的颜色主题和布局，仅在 Format 为 "console" 时生效，为空时使用zap的控制台格式
+	Console *ConsoleConfig `json:"console" yaml:"console" mapstructure:"console"`
+	// 编码器配置，用于调整时间、时长、级别的格式以及字段名
+	Encoder *EncoderConfig `json:"encoder" yaml:"encoder" mapstructure:"encoder"`
+	// 输出位置，支持 "stdout", "stderr", "file", "gelf", "fluent", "elasticsearch", "journald"（Linux）, "eventlog"（Windows）
 	Output string `json:"output" yaml:"output" mapstructure:"output"`
+	// 输出参数，传给通过 logger.RegisterOutputFactory 注册的输出工厂
+	OutputOptions map[string]interface{} `json:"output_options" yaml:"output_options" mapstructure:"output_options"`
 	// 文件输出配置
 	FileConfig *FileConfig `json:"file_config" yaml:"file_config" mapstructure:"file_config"`
+	// 多个输出目标，每个输出可以使用独立的格式，不为空时忽略 Output 和 OutputOptions
+	Outputs []OutputConfig `json:"outputs" yaml:"outputs" mapstructure:"outputs"`
+	// 输出写入失败时的备用输出，为空时不启用
+	Fallback *FallbackConfig `json:"fallback" yaml:"fallback" mapstructure:"fallback"`
+	// Windows事件日志配置，仅在 Output 为 "eventlog" 时生效
+	EventLog *EventLogConfig `json:"event_log" yaml:"event_log" mapstructure:"event_log"`
+	// GELF（Graylog）输出配置，仅在 Output 为 "gelf" 时生效
+	GELF *GELFConfig `json:"gelf" yaml:"gelf" mapstructure:"gelf"`
+	// Fluentd/Fluent Bit输出配置，仅在 Output 为 "fluent" 时生效
+	Fluent *FluentConfig `json:"fluent" yaml:"fluent" mapstructure:"fluent"`
+	// Elasticsearch输出配置，仅在 Output 为 "elasticsearch" 时生效
+	Elasticsearch *ElasticsearchConfig `json:"elasticsearch" yaml:"elasticsearch" mapstructure:"elasticsearch"`
 	// 开发模式
 	Development bool `json:"development" yaml:"development" mapstructure:"development"`
 	// 是否添加调用者信息
 	EnableCaller bool `json:"enable_caller" yaml:"enable_caller" mapstructure:"enable_caller"`
 	// 调用栈
 	EnableStacktrace bool `json:"enable_stacktrace" yaml:"enable_stacktrace" mapstructure:"enable_stacktrace"`
-	// 采样配置
+	// 记录调用栈的最低级别，如开发环境为 "warn"、生产环境为 "error"，"disabled" 表示不记录，默认 "error"
+	StacktraceLevel string `json:"stacktrace_level" yaml:"stacktrace_level" mapstructure:"stacktrace_level"`
+	// 是否启用采样
 	EnableSampling bool `json:"enable_sampling" yaml:"enable_sampling" mapstructure:"enable_sampling"`
+	// 采样配置，仅在 EnableSampling 为 true 时生效
+	Sampling *SamplingConfig `json:"sampling" yaml:"sampling" mapstructure:"sampling"`
+	// 按消息限流配置
+	RateLimit *RateLimitConfig `json:"rate_limit" yaml:"rate_limit" mapstructure:"rate_limit"`
+	// 错误触发的上下文日志回放配置，为空时不启用
+	FlightRecorder *FlightRecorderConfig `json:"flight_recorder" yaml:"flight_recorder" mapstructure:"flight_recorder"`
+	// log:"hash" 字段计算HMAC-SHA256使用的密钥，为空时使用进程启动时生成的随机密钥
+	HashKey string `json:"hash_key" yaml:"hash_key" mapstructure:"hash_key"`
 	// 日志字段配置
 	DefaultFields map[string]interface{} `json:"default_fields" yaml:"default_fields" mapstructure:"default_fields"`
+	// 自动附加到每条日志的进程信息字段，为空时不附加
+	RuntimeFields *RuntimeFieldsConfig `json:"runtime_fields" yaml:"runtime_fields" mapstructure:"runtime_fields"`
+	// 错误上报配置
+	ErrorReporting *ErrorReportingConfig `json:"error_reporting" yaml:"error_reporting" mapstructure:"error_reporting"`
 }
 
 // FileConfig 包含文件输出的配置
@@ -75,6 +111,309 @@ type FileConfig struct {
 	MaxAge int `json:"max_age" yaml:"max_age" mapstructure:"max_age"`
 	// 是否压缩旧日志
 	Compress bool `json:"compress" yaml:"compress" mapstructure:"compress"`
+	// 写缓冲区大小（字节），为0时不启用缓冲，每条日志直接写入文件
+	BufferSize int `json:"buffer_size" yaml:"buffer_size" mapstructure:"buffer_size"`
+	// 缓冲区定时刷新间隔，仅在 BufferSize 大于0时生效，默认为1秒
+	FlushInterval time.Duration `json:"flush_interval" yaml:"flush_interval" mapstructure:"flush_interval"`
+	// 切割后的旧日志文件归档到对象存储的配置，为空时不归档
+	Archive *ArchiveConfig `json:"archive" yaml:"archive" mapstructure:"archive"`
+}
+
+// ArchiveConfig 包含旧日志文件归档的配置
+//
+// 切割后的旧日志文件（开启 Compress 时为压缩完成的 .gz 文件）上传成功后删除本地文件
+type ArchiveConfig struct {
+	// 对象存储类型，"s3" 为兼容S3协议的存储（包括阿里云OSS、GCS的互操作接口），
+	// 也可以是通过 logger.RegisterArchiveStore 注册的名称，默认 "s3"
+	Store string `json:"store" yaml:"store" mapstructure:"store"`
+	// 对象存储地址，默认 https://s3.<region>.amazonaws.com
+	Endpoint string `json:"endpoint" yaml:"endpoint" mapstructure:"endpoint"`
+	// 区域，默认 us-east-1
+	Region string `json:"region" yaml:"region" mapstructure:"region"`
+	// 存储桶名称
+	Bucket string `json:"bucket" yaml:"bucket" mapstructure:"bucket"`
+	// 访问密钥
+	AccessKeyID     string `json:"access_key_id" yaml:"access_key_id" mapstructure:"access_key_id"`
+	SecretAccessKey string `json:"secret_access_key" yaml:"secret_access_key" mapstructure:"secret_access_key"`
+	// 是否使用路径形式的地址（<endpoint>/<bucket>/<key>），默认使用虚拟主机形式（<bucket>.<endpoint>/<key>）
+	PathStyle bool `json:"path_style" yaml:"path_style" mapstructure:"path_style"`
+	// 对象名前缀，如 "logs/app/"
+	Prefix string `json:"prefix" yaml:"prefix" mapstructure:"prefix"`
+	// 检查旧日志文件的间隔，默认 1 分钟
+	Interval time.Duration `json:"interval" yaml:"interval" mapstructure:"interval"`
+	// 对象存储中归档文件的保留天数，超过后删除，为0时永久保留
+	RetentionDays int `json:"retention_days" yaml:"retention_days" mapstructure:"retention_days"`
+}
+
+// OutputConfig 包含单个输出目标的配置
+type OutputConfig struct {
+	// 输出位置，取值与 Config.Output 相同，默认为 "stdout"
+	Type string `json:"type" yaml:"type" mapstructure:"type"`
+	// 日志格式，为空时使用 Config.Format
+	Format string `json:"format" yaml:"format" mapstructure:"format"`
+	// 该输出的最低日志级别，为空时与 Config.Level 相同，只能比全局级别更高
+	Level string `json:"level" yaml:"level" mapstructure:"level"`
+	// 输出参数，传给输出工厂
+	Options map[string]interface{} `json:"options" yaml:"options" mapstructure:"options"`
+	// 文件输出配置，仅在 Type 为 "file" 时生效，为空时使用 Config.FileConfig
+	FileConfig *FileConfig `json:"file_config" yaml:"file_config" mapstructure:"file_config"`
+	// 该输出的编码器配置，不为空的选项覆盖 Config.Encoder 中的同名选项
+	Encoder *EncoderConfig `json:"encoder" yaml:"encoder" mapstructure:"encoder"`
+	// 该输出的CEF日志头配置，仅在格式为 "cef" 时生效，为空时使用 Config.CEF
+	CEF *CEFConfig `json:"cef" yaml:"cef" mapstructure:"cef"`
+}
+
+// FallbackConfig 包含备用输出的配置
+//
+// 输出连续写入失败达到 ErrorThreshold 次时切换到备用输出，之后每隔 RetryInterval 尝试写入原输出，成功后切换回来
+type FallbackConfig struct {
+	// 备用输出位置，取值与 Output 相同，默认 "stderr"
+	Output string `json:"output" yaml:"output" mapstructure:"output"`
+	// 切换到备用输出前允许的连续写入失败次数，默认 3
+	ErrorThreshold int `json:"error_threshold" yaml:"error_threshold" mapstructure:"error_threshold"`
+	// 使用备用输出期间尝试恢复原输出的间隔，默认 10 秒
+	RetryInterval time.Duration `json:"retry_interval" yaml:"retry_interval" mapstructure:"retry_interval"`
+}
+
+// EventLogConfig 包含Windows事件日志输出的配置
+type EventLogConfig struct {
+	// 事件来源名称，默认为可执行文件名
+	Source string `json:"source" yaml:"source" mapstructure:"source"`
+}
+
+// GELFConfig 包含GELF（Graylog）输出的配置
+type GELFConfig struct {
+	// Graylog地址，默认 "localhost"
+	Host string `json:"host" yaml:"host" mapstructure:"host"`
+	// Graylog端口，默认 12201
+	Port int `json:"port" yaml:"port" mapstructure:"port"`
+	// 传输协议 "udp"（默认）或 "tcp"
+	Protocol string `json:"protocol" yaml:"protocol" mapstructure:"protocol"`
+	// 是否使用TLS，仅在 Protocol 为 "tcp" 时生效
+	TLS bool `json:"tls" yaml:"tls" mapstructure:"tls"`
+	// 是否跳过TLS证书校验
+	TLSInsecureSkipVerify bool `json:"tls_insecure_skip_verify" yaml:"tls_insecure_skip_verify" mapstructure:"tls_insecure_skip_verify"`
+	// 压缩方式 "gzip"（默认）、"zlib" 或 "none"，仅在 Protocol 为 "udp" 时生效
+	Compression string `json:"compression" yaml:"compression" mapstructure:"compression"`
+	// UDP分块大小（字节），默认 1420
+	ChunkSize int `json:"chunk_size" yaml:"chunk_size" mapstructure:"chunk_size"`
+	// 消息中的host字段，默认为主机名
+	Source string `json:"source" yaml:"source" mapstructure:"source"`
+}
+
+// FluentConfig 包含Fluent forward协议输出的配置
+type FluentConfig struct {
+	// Fluentd/Fluent Bit地址，默认 "localhost"
+	Host string `json:"host" yaml:"host" mapstructure:"host"`
+	// 端口，默认 24224
+	Port int `json:"port" yaml:"port" mapstructure:"port"`
+	// 日志的tag，默认 "virlog"
+	Tag string `json:"tag" yaml:"tag" mapstructure:"tag"`
+	// 是否要求服务端确认，开启后未确认的批次会在重连后重新发送
+	RequireAck bool `json:"require_ack" yaml:"require_ack" mapstructure:"require_ack"`
+	// 内存中缓冲的最大日志条数，缓冲区满时丢弃新日志，默认 8192
+	BufferSize int `json:"buffer_size" yaml:"buffer_size" mapstructure:"buffer_size"`
+	// 连接、写入和等待确认的超时时间，默认 5 秒
+	Timeout time.Duration `json:"timeout" yaml:"timeout" mapstructure:"timeout"`
+	// 批次的压缩配置，Fluent forward协议只支持 gzip
+	Compression CompressionConfig `json:"compression" yaml:"compression" mapstructure:"compression"`
+}
+
+// CompressionConfig 网络输出批量发送日志时的压缩配置
+type CompressionConfig struct {
+	// 压缩算法："gzip"、"zstd"，为空时不压缩，各输出支持的算法见输出的配置
+	Algorithm string `json:"algorithm" yaml:"algorithm" mapstructure:"algorithm"`
+	// 压缩级别，0 使用算法的默认级别；gzip 为 1-9，zstd 为 1-22
+	Level int `json:"level" yaml:"level" mapstructure:"level"`
+	// 小于该字节数的批次不压缩，默认 1024，小于 0 时总是压缩
+	MinBytes int `json:"min_bytes" yaml:"min_bytes" mapstructure:"min_bytes"`
+}
+
+// ElasticsearchConfig 包含Elasticsearch输出的配置
+type ElasticsearchConfig struct {
+	// Elasticsearch地址，默认 "http://localhost:9200"
+	URL string `json:"url" yaml:"url" mapstructure:"url"`
+	// 索引名前缀，按天写入 <Index>-2006.01.02 索引；使用数据流时为数据流名称，默认 "logs-virlog"
+	Index string `json:"index" yaml:"index" mapstructure:"index"`
+	// 是否写入数据流，数据流需要匹配的索引模板，可以通过 Template 创建
+	DataStream bool `json:"data_stream" yaml:"data_stream" mapstructure:"data_stream"`
+	// 是否在启动时创建或更新索引模板，模板名与 Index 相同
+	Template bool `json:"template" yaml:"template" mapstructure:"template"`
+	// Basic认证的用户名和密码
+	Username string `json:"username" yaml:"username" mapstructure:"username"`
+	Password string `json:"password" yaml:"password" mapstructure:"password"`
+	// API Key认证，设置后忽略用户名和密码
+	APIKey string `json:"api_key" yaml:"api_key" mapstructure:"api_key"`
+	// 每个_bulk请求包含的最大日志条数，默认 500
+	BatchSize int `json:"batch_size" yaml:"batch_size" mapstructure:"batch_size"`
+	// 不足一批的日志最多等待的时长，默认 1 秒
+	FlushInterval time.Duration `json:"flush_interval" yaml:"flush_interval" mapstructure:"flush_interval"`
+	// 内存中缓冲的最大日志条数，缓冲区满时丢弃新日志，默认 8192
+	BufferSize int `json:"buffer_size" yaml:"buffer_size" mapstructure:"buffer_size"`
+	// 返回429时的最大重试次数，默认 3
+	MaxRetries int `json:"max_retries" yaml:"max_retries" mapstructure:"max_retries"`
+	// 单个请求的超时时间，默认 10 秒
+	Timeout time.Duration `json:"timeout" yaml:"timeout" mapstructure:"timeout"`
+	// _bulk请求体的压缩配置，Elasticsearch只支持 gzip，需要开启 http.compression（默认开启）
+	Compression CompressionConfig `json:"compression" yaml:"compression" mapstructure:"compression"`
+}
+
+// CEFConfig 包含CEF（Common Event Format）日志头的配置
+type CEFConfig struct {
+	// 设备厂商
+	DeviceVendor string `json:"device_vendor" yaml:"device_vendor" mapstructure:"device_vendor"`
+	// 设备产品
+	DeviceProduct string `json:"device_product" yaml:"device_product" mapstructure:"device_product"`
+	// 设备版本
+	DeviceVersion string `json:"device_version" yaml:"device_version" mapstructure:"device_version"`
+}
+
+// ConsoleConfig 包含控制台格式的配置
+//
+// 每条日志输出为一行：时间、级别、Logger名称、调用者、消息，之后是 key=value 形式的字段
+type ConsoleConfig struct {
+	// 颜色主题："default"（默认）、"dark"、"light"、"none"，也可以是通过 logger.RegisterConsoleTheme 注册的名称
+	Theme string `json:"theme" yaml:"theme" mapstructure:"theme"`
+	// 是否使用颜色："auto"（默认，输出到终端且未设置 NO_COLOR 环境变量时使用）、"always"、"never"
+	Color string `json:"color" yaml:"color" mapstructure:"color"`
+	// 优先输出的字段名，按列出的顺序排在其他字段之前
+	FieldOrder []string `json:"field_order" yaml:"field_order" mapstructure:"field_order"`
+	// 其他字段是否按名称排序，默认按添加的顺序输出
+	SortFields bool `json:"sort_fields" yaml:"sort_fields" mapstructure:"sort_fields"`
+	// 字段值的最大字符数，超出的部分截断为 …，为0时不截断
+	MaxValueLength int `json:"max_value_length" yaml:"max_value_length" mapstructure:"max_value_length"`
+	// 是否将错误的详细信息（如 errors.Join 合并的多个错误、%+v 输出的调用栈）缩进输出在日志之后的多行中
+	MultilineErrors bool `json:"multiline_errors" yaml:"multiline_errors" mapstructure:"multiline_errors"`
+	// 是否按固定宽度对齐级别、Logger名称、调用者和消息列
+	AlignColumns bool `json:"align_columns" yaml:"align_columns" mapstructure:"align_columns"`
+	// 对齐时消息列的宽度，默认 40，超出时不截断
+	MessageWidth int `json:"message_width" yaml:"message_width" mapstructure:"message_width"`
+}
+
+// EncoderConfig 包含日志编码的配置，为空的选项使用默认值
+type EncoderConfig struct {
+	// 时间格式："iso8601"（默认）、"rfc3339"、"rfc3339nano"、"epoch"（秒）、"epoch_millis"、"epoch_nanos"，
+	// 其他值作为Go时间布局，如 "2006-01-02 15:04:05.000"
+	TimeFormat string `json:"time_format" yaml:"time_format" mapstructure:"time_format"`
+	// 时长格式："seconds"（默认，浮点秒数）、"millis"、"nanos"、"string"（如 "1.5s"）
+	DurationFormat string `json:"duration_format" yaml:"duration_format" mapstructure:"duration_format"`
+	// 级别格式："lowercase"（默认）、"uppercase"、"lowercase_color"、"uppercase_color"，
+	// 开发模式下默认为 "uppercase_color"
+	LevelFormat string `json:"level_format" yaml:"level_format" mapstructure:"level_format"`
+	// 时间字段名，默认 "time"，"-" 表示不输出
+	TimeKey string `json:"time_key" yaml:"time_key" mapstructure:"time_key"`
+	// 级别字段名，默认 "level"，"-" 表示不输出
+	LevelKey string `json:"level_key" yaml:"level_key" mapstructure:"level_key"`
+	// Logger名称字段名，默认 "logger"，"-" 表示不输出
+	NameKey string `json:"name_key" yaml:"name_key" mapstructure:"name_key"`
+	// 调用者字段名，默认 "caller"，"-" 表示不输出
+	CallerKey string `json:"caller_key" yaml:"caller_key" mapstructure:"caller_key"`
+	// 消息字段名，默认 "msg"，"-" 表示不输出
+	MessageKey string `json:"message_key" yaml:"message_key" mapstructure:"message_key"`
+	// 调用栈字段名，默认 "stacktrace"，"-" 表示不输出
+	StacktraceKey string `json:"stacktrace_key" yaml:"stacktrace_key" mapstructure:"stacktrace_key"`
+}
+
+// ErrorReportingConfig 包含错误上报的配置
+type ErrorReportingConfig struct {
+	// Sentry上报配置，需要导入 logger/sinks/sentry 包
+	Sentry *SentryConfig `json:"sentry" yaml:"sentry" mapstructure:"sentry"`
+}
+
+// SentryConfig 包含Sentry上报的配置
+type SentryConfig struct {
+	// Sentry DSN，为空时不上报
+	DSN string `json:"dsn" yaml:"dsn" mapstructure:"dsn"`
+	// 环境名称，如 "production"
+	Environment string `json:"environment" yaml:"environment" mapstructure:"environment"`
+	// 版本号
+	Release string `json:"release" yaml:"release" mapstructure:"release"`
+	// 上报的最低日志级别，默认 "error"
+	Level string `json:"level" yaml:"level" mapstructure:"level"`
+	// 作为tag上报的字段名，其余字段作为extra上报
+	Tags []string `json:"tags" yaml:"tags" mapstructure:"tags"`
+}
+
+// SamplingConfig 包含日志采样的配置
+//
+// 在每个 Tick 周期内，相同级别、相同消息的日志先完整输出 Initial 条，
+// 之后每 Thereafter 条输出一条
+type SamplingConfig struct {
+	// 每个周期内完整输出的条数
+	Initial int `json:"initial" yaml:"initial" mapstructure:"initial"`
+	// 超过 Initial 之后每隔多少条输出一条
+	Thereafter int `json:"thereafter" yaml:"thereafter" mapstructure:"thereafter"`
+	// 采样周期
+	Tick time.Duration `json:"tick" yaml:"tick" mapstructure:"tick"`
+	// 按日志级别覆盖采样参数，key 为级别名称，如 "debug"、"error"
+	LevelOverrides map[string]LevelSamplingConfig `json:"level_overrides" yaml:"level_overrides" mapstructure:"level_overrides"`
+}
+
+// LevelSamplingConfig 包含单个日志级别的采样配置
+type LevelSamplingConfig struct {
+	// 每个周期内完整输出的条数
+	Initial int `json:"initial" yaml:"initial" mapstructure:"initial"`
+	// 超过 Initial 之后每隔多少条输出一条
+	Thereafter int `json:"thereafter" yaml:"thereafter" mapstructure:"thereafter"`
+	// 为 true 时该级别不采样，所有日志都会输出
+	Disabled bool `json:"disabled" yaml:"disabled" mapstructure:"disabled"`
+}
+
+// RateLimitConfig 包含按消息限流的配置
+//
+// 在每个 Interval 周期内，相同级别、相同消息的日志最多输出 Limit 条，超出的日志被丢弃，
+// 之后输出的第一条同类日志带有 suppressed_count 字段，记录被丢弃的条数
+type RateLimitConfig struct {
+	// 每个周期内最多输出的条数，为0时只对 Rules 中的消息限流
+	Limit int `json:"limit" yaml:"limit" mapstructure:"limit"`
+	// 限流周期，默认为1秒
+	Interval time.Duration `json:"interval" yaml:"interval" mapstructure:"interval"`
+	// 按消息设置的限流规则，优先于 Limit
+	Rules []RateLimitRule `json:"rules" yaml:"rules" mapstructure:"rules"`
+}
+
+// RateLimitRule 单条消息的限流规则
+type RateLimitRule struct {
+	// 日志消息，完全匹配
+	Message string `json:"message" yaml:"message" mapstructure:"message"`
+	// 每个周期内最多输出的条数
+	Limit int `json:"limit" yaml:"limit" mapstructure:"limit"`
+	// 限流周期，默认为1秒
+	Interval time.Duration `json:"interval" yaml:"interval" mapstructure:"interval"`
+}
+
+// FlightRecorderConfig 包含错误触发的上下文日志回放配置
+//
+// 低于日志级别的日志不直接丢弃，而是保留最近的 Size 条在内存中，
+// 输出不低于 TriggerLevel 的日志时先输出这些日志，二者带有相同的 trigger_id 字段
+type FlightRecorderConfig struct {
+	// 内存中保留的日志条数，默认为100
+	Size int `json:"size" yaml:"size" mapstructure:"size"`
+	// 触发输出的日志级别，默认为 "error"
+	TriggerLevel string `json:"trigger_level" yaml:"trigger_level" mapstructure:"trigger_level"`
+}
+
+// RuntimeFieldsConfig 包含自动附加到每条日志的进程信息字段的配置，每个字段单独开启
+type RuntimeFieldsConfig struct {
+	// 主机名，字段名 hostname
+	Hostname bool `json:"hostname" yaml:"hostname" mapstructure:"hostname"`
+	// 进程ID，字段名 pid
+	PID bool `json:"pid" yaml:"pid" mapstructure:"pid"`
+	// 编译使用的Go版本，字段名 go_version
+	GoVersion bool `json:"go_version" yaml:"go_version" mapstructure:"go_version"`
+	// 主模块版本和VCS修订，字段名 build_version、vcs_revision，来自 debug.ReadBuildInfo，没有时不输出
+	Build bool `json:"build" yaml:"build" mapstructure:"build"`
+	// 容器ID，字段名 container_id，从 /proc/self/cgroup 和 /proc/self/mountinfo 识别，不在容器中时不输出
+	ContainerID bool `json:"container_id" yaml:"container_id" mapstructure:"container_id"`
+}
+
+// DefaultSamplingConfig 返回默认采样配置
+func DefaultSamplingConfig() *SamplingConfig {
+	return &SamplingConfig{
+		Initial:    100,
+		Thereafter: 100,
+		Tick:       time.Second,
+	}
 }
 
 // DefaultConfig 返回默认配置
@@ -88,6 +427,7 @@ func DefaultConfig() *Config {
 		EnableStacktrace: true,
 		EnableSampling:   false,
 		DefaultFields:    make(map[string]interface{}),
+		Sampling:         DefaultSamplingConfig(),
 		FileConfig: &FileConfig{
 			Filename:   "./logs/app.log",
 			MaxSize:    100,
@@ -129,18 +469,18 @@ func initConfig() {
 			v.WatchConfig()
 			v.OnConfigChange(func(e fsnotify.Event) {
 				// 配置文件发生变化，重新加载
-				fmt.Printf("配置文件已变更: %s\n", e.Name)
+				getInternalLogger().Infow("配置文件已变更", "file", e.Name)
 
 				// 重新加载配置文件
 				if err := v.ReadInConfig(); err != nil {
-					fmt.Printf("读取配置文件失败: %v\n", err)
+					getInternalLogger().Errorw("读取配置文件失败", "file", e.Name, "error", err)
 					return
 				}
 
 				// 更新全局配置
 				newConfig := DefaultConfig()
 				if err := v.Unmarshal(newConfig); err != nil {
-					fmt.Printf("解析配置失败: %v\n", err)
+					getInternalLogger().Errorw("解析配置失败", "file", e.Name, "error", err)
 					return
 				}
 
@@ -168,13 +508,13 @@ func loadConfigFile(filePath string) {
 
 	// 尝试读取配置文件
 	if err := v.ReadInConfig(); err != nil {
-		fmt.Printf("读取配置文件失败，使用默认配置: %v\n", err)
+		getInternalLogger().Errorw("读取配置文件失败，使用默认配置", "file", filePath, "error", err)
 		return
 	}
 
 	// 解析配置
 	if err := v.Unmarshal(globalConfig); err != nil {
-		fmt.Printf("解析配置失败，使用默认配置: %v\n", err)
+		getInternalLogger().Errorw("解析配置失败，使用默认配置", "file", filePath, "error", err)
 		globalConfig = DefaultConfig()
 	}
 }
@@ -222,6 +562,13 @@ func overrideWithEnv(cfg *Config) {
 	} else if stacktrace == "false" {
 		cfg.EnableStacktrace = false
 	}
+	if level := getEnv("STACKTRACE_LEVEL"); level != "" {
+		cfg.StacktraceLevel = level
+	}
+
+	if key := getEnv("HASH_KEY"); key != "" {
+		cfg.HashKey = key
+	}
 
 	// 采样
 	if sampling := getEnv("ENABLE_SAMPLING"); sampling == "true" {
@@ -230,6 +577,152 @@ func overrideWithEnv(cfg *Config) {
 		cfg.EnableSampling = false
 	}
 
+	// 采样参数
+	if initial := getEnv("SAMPLING_INITIAL"); initial != "" {
+		if n, err := parseInt(initial); err == nil && n >= 0 {
+			ensureSampling(cfg).Initial = n
+		}
+	}
+
+	if thereafter := getEnv("SAMPLING_THEREAFTER"); thereafter != "" {
+		if n, err := parseInt(thereafter); err == nil && n >= 0 {
+			ensureSampling(cfg).Thereafter = n
+		}
+	}
+
+	if tick := getEnv("SAMPLING_TICK"); tick != "" {
+		if d, err := time.ParseDuration(tick); err == nil && d > 0 {
+			ensureSampling(cfg).Tick = d
+		}
+	}
+
+	// 编码格式
+	if timeFormat := getEnv("TIME_FORMAT"); timeFormat != "" {
+		ensureEncoder(cfg).TimeFormat = timeFormat
+	}
+
+	if durationFormat := getEnv("DURATION_FORMAT"); durationFormat != "" {
+		ensureEncoder(cfg).DurationFormat = durationFormat
+	}
+
+	if levelFormat := getEnv("LEVEL_FORMAT"); levelFormat != "" {
+		ensureEncoder(cfg).LevelFormat = levelFormat
+	}
+
+	if messageKey := getEnv("MESSAGE_KEY"); messageKey != "" {
+		ensureEncoder(cfg).MessageKey = messageKey
+	}
+
+	// 控制台格式
+	if theme := getEnv("CONSOLE_THEME"); theme != "" {
+		ensureConsole(cfg).Theme = theme
+	}
+
+	if color := getEnv("CONSOLE_COLOR"); color != "" {
+		ensureConsole(cfg).Color = color
+	}
+
+	// 限流参数
+	if limit := getEnv("RATE_LIMIT"); limit != "" {
+		if n, err := parseInt(limit); err == nil && n >= 0 {
+			ensureRateLimit(cfg).Limit = n
+		}
+	}
+
+	if interval := getEnv("RATE_LIMIT_INTERVAL"); interval != "" {
+		if d, err := time.ParseDuration(interval); err == nil && d > 0 {
+			ensureRateLimit(cfg).Interval = d
+		}
+	}
+
+	// 错误触发的上下文日志回放
+	if size := getEnv("FLIGHT_RECORDER_SIZE"); size != "" {
+		if n, err := parseInt(size); err == nil && n > 0 {
+			ensureFlightRecorder(cfg).Size = n
+		}
+	}
+
+	if level := getEnv("FLIGHT_RECORDER_TRIGGER_LEVEL"); level != "" {
+		ensureFlightRecorder(cfg).TriggerLevel = level
+	}
+
+	// 进程信息字段，逗号分隔的字段名，如 "hostname,pid"，"all" 表示全部开启
+	if names := getEnv("RUNTIME_FIELDS"); names != "" {
+		rf := ensureRuntimeFields(cfg)
+		for _, name := range strings.Split(names, ",") {
+			switch strings.TrimSpace(name) {
+			case "all":
+				*rf = RuntimeFieldsConfig{Hostname: true, PID: true, GoVersion: true, Build: true, ContainerID: true}
+			case "hostname":
+				rf.Hostname = true
+			case "pid":
+				rf.PID = true
+			case "go_version":
+				rf.GoVersion = true
+			case "build":
+				rf.Build = true
+			case "container_id":
+				rf.ContainerID = true
+			}
+		}
+	}
+
+	// GELF输出
+	if host := getEnv("GELF_HOST"); host != "" {
+		ensureGELF(cfg).Host = host
+	}
+
+	if port := getEnv("GELF_PORT"); port != "" {
+		if n, err := parseInt(port); err == nil && n > 0 {
+			ensureGELF(cfg).Port = n
+		}
+	}
+
+	if protocol := getEnv("GELF_PROTOCOL"); protocol != "" {
+		ensureGELF(cfg).Protocol = protocol
+	}
+
+	// Fluent输出
+	if host := getEnv("FLUENT_HOST"); host != "" {
+		ensureFluent(cfg).Host = host
+	}
+
+	if port := getEnv("FLUENT_PORT"); port != "" {
+		if n, err := parseInt(port); err == nil && n > 0 {
+			ensureFluent(cfg).Port = n
+		}
+	}
+
+	if tag := getEnv("FLUENT_TAG"); tag != "" {
+		ensureFluent(cfg).Tag = tag
+	}
+
+	// Elasticsearch输出
+	if url := getEnv("ELASTICSEARCH_URL"); url != "" {
+		ensureElasticsearch(cfg).URL = url
+	}
+
+	if index := getEnv("ELASTICSEARCH_INDEX"); index != "" {
+		ensureElasticsearch(cfg).Index = index
+	}
+
+	if apiKey := getEnv("ELASTICSEARCH_API_KEY"); apiKey != "" {
+		ensureElasticsearch(cfg).APIKey = apiKey
+	}
+
+	// Sentry上报
+	if dsn := getEnv("SENTRY_DSN"); dsn != "" {
+		ensureSentry(cfg).DSN = dsn
+	}
+
+	if environment := getEnv("SENTRY_ENVIRONMENT"); environment != "" {
+		ensureSentry(cfg).Environment = environment
+	}
+
+	if release := getEnv("SENTRY_RELEASE"); release != "" {
+		ensureSentry(cfg).Release = release
+	}
+
 	// 文件配置
 	if filename := getEnv("FILE_PATH"); filename != "" {
 		cfg.FileConfig.Filename = filename
@@ -258,6 +751,122 @@ func overrideWithEnv(cfg *Config) {
 	} else if compress == "false" {
 		cfg.FileConfig.Compress = false
 	}
+
+	if bufferSize := getEnv("FILE_BUFFER_SIZE"); bufferSize != "" {
+		if size, err := parseInt(bufferSize); err == nil && size >= 0 {
+			cfg.FileConfig.BufferSize = size
+		}
+	}
+
+	if flushInterval := getEnv("FILE_FLUSH_INTERVAL"); flushInterval != "" {
+		if d, err := time.ParseDuration(flushInterval); err == nil && d > 0 {
+			cfg.FileConfig.FlushInterval = d
+		}
+	}
+
+	// 旧日志文件归档
+	if bucket := getEnv("FILE_ARCHIVE_BUCKET"); bucket != "" {
+		ensureArchive(cfg).Bucket = bucket
+	}
+
+	if accessKeyID := getEnv("FILE_ARCHIVE_ACCESS_KEY_ID"); accessKeyID != "" {
+		ensureArchive(cfg).AccessKeyID = accessKeyID
+	}
+
+	if secret := getEnv("FILE_ARCHIVE_SECRET_ACCESS_KEY"); secret != "" {
+		ensureArchive(cfg).SecretAccessKey = secret
+	}
+}
+
+// 确保归档配置存在
+func ensureArchive(cfg *Config) *ArchiveConfig {
+	if cfg.FileConfig.Archive == nil {
+		cfg.FileConfig.Archive = &ArchiveConfig{}
+	}
+	return cfg.FileConfig.Archive
+}
+
+// 确保采样配置存在
+func ensureSampling(cfg *Config) *SamplingConfig {
+	if cfg.Sampling == nil {
+		cfg.Sampling = DefaultSamplingConfig()
+	}
+	return cfg.Sampling
+}
+
+// 确保编码器配置存在
+func ensureEncoder(cfg *Config) *EncoderConfig {
+	if cfg.Encoder == nil {
+		cfg.Encoder = &EncoderConfig{}
+	}
+	return cfg.Encoder
+}
+
+// 确保控制台格式配置存在
+func ensureConsole(cfg *Config) *ConsoleConfig {
+	if cfg.Console == nil {
+		cfg.Console = &ConsoleConfig{}
+	}
+	return cfg.Console
+}
+
+// 确保限流配置存在
+func ensureRateLimit(cfg *Config) *RateLimitConfig {
+	if cfg.RateLimit == nil {
+		cfg.RateLimit = &RateLimitConfig{}
+	}
+	return cfg.RateLimit
+}
+
+// 确保上下文日志回放配置存在
+func ensureFlightRecorder(cfg *Config) *FlightRecorderConfig {
+	if cfg.FlightRecorder == nil {
+		cfg.FlightRecorder = &FlightRecorderConfig{}
+	}
+	return cfg.FlightRecorder
+}
+
+// 确保进程信息字段配置存在
+func ensureRuntimeFields(cfg *Config) *RuntimeFieldsConfig {
+	if cfg.RuntimeFields == nil {
+		cfg.RuntimeFields = &RuntimeFieldsConfig{}
+	}
+	return cfg.RuntimeFields
+}
+
+// 确保GELF配置存在
+func ensureGELF(cfg *Config) *GELFConfig {
+	if cfg.GELF == nil {
+		cfg.GELF = &GELFConfig{}
+	}
+	return cfg.GELF
+}
+
+// 确保Fluent配置存在
+func ensureFluent(cfg *Config) *FluentConfig {
+	if cfg.Fluent == nil {
+		cfg.Fluent = &FluentConfig{}
+	}
+	return cfg.Fluent
+}
+
+// 确保Elasticsearch配置存在
+func ensureElasticsearch(cfg *Config) *ElasticsearchConfig {
+	if cfg.Elasticsearch == nil {
+		cfg.Elasticsearch = &ElasticsearchConfig{}
+	}
+	return cfg.Elasticsearch
+}
+
+// 确保Sentry配置存在
+func ensureSentry(cfg *Config) *SentryConfig {
+	if cfg.ErrorReporting == nil {
+		cfg.ErrorReporting = &ErrorReportingConfig{}
+	}
+	if cfg.ErrorReporting.Sentry == nil {
+		cfg.ErrorReporting.Sentry = &SentryConfig{}
+	}
+	return cfg.ErrorReporting.Sentry
 }
 
 // 从环境变量中获取配置
@@ -306,7 +915,7 @@ func notifyListeners(cfg *Config) {
 			// 发送成功
 		case <-time.After(100 * time.Millisecond):
 			// 超时，监听器可能已被阻塞，跳过
-			fmt.Println("监听器接收超时")
+			getInternalLogger().Errorw("监听器接收超时")
 		}
 	}
 }
@@ -389,8 +998,21 @@ func GetConfig() *Config {
 	// 返回深拷贝，避免外部修改影响内部配置
 	configCopy := *globalConfig
 	fileConfigCopy := *globalConfig.FileConfig
+	if fileConfigCopy.Archive != nil {
+		archiveCopy := *fileConfigCopy.Archive
+		fileConfigCopy.Archive = &archiveCopy
+	}
 	configCopy.FileConfig = &fileConfigCopy
 
+	// 拷贝按名称设置的级别
+	if globalConfig.NamedLevels != nil {
+		namedLevels := make(map[string]string, len(globalConfig.NamedLevels))
+		for k, v := range globalConfig.NamedLevels {
+			namedLevels[k] = v
+		}
+		configCopy.NamedLevels = namedLevels
+	}
+
 	// 拷贝默认字段
 	defaultFields := make(map[string]interface{})
 	for k, v := range globalConfig.DefaultFields {
@@ -398,6 +1020,137 @@ func GetConfig() *Config {
 	}
 	configCopy.DefaultFields = defaultFields
 
+	// 拷贝进程信息字段配置
+	if globalConfig.RuntimeFields != nil {
+		runtimeFieldsCopy := *globalConfig.RuntimeFields
+		configCopy.RuntimeFields = &runtimeFieldsCopy
+	}
+
+	// 拷贝事件日志配置
+	if globalConfig.EventLog != nil {
+		eventLogCopy := *globalConfig.EventLog
+		configCopy.EventLog = &eventLogCopy
+	}
+
+	// 拷贝GELF配置
+	if globalConfig.GELF != nil {
+		gelfCopy := *globalConfig.GELF
+		configCopy.GELF = &gelfCopy
+	}
+
+	// 拷贝Fluent配置
+	if globalConfig.Fluent != nil {
+		fluentCopy := *globalConfig.Fluent
+		configCopy.Fluent = &fluentCopy
+	}
+
+	// 拷贝备用输出配置
+	if globalConfig.Fallback != nil {
+		fallbackCopy := *globalConfig.Fallback
+		configCopy.Fallback = &fallbackCopy
+	}
+
+	// 拷贝Elasticsearch配置
+	if globalConfig.Elasticsearch != nil {
+		elasticsearchCopy := *globalConfig.Elasticsearch
+		configCopy.Elasticsearch = &elasticsearchCopy
+	}
+
+	// 拷贝输出参数
+	if globalConfig.OutputOptions != nil {
+		outputOptions := make(map[string]interface{}, len(globalConfig.OutputOptions))
+		for k, v := range globalConfig.OutputOptions {
+			outputOptions[k] = v
+		}
+		configCopy.OutputOptions = outputOptions
+	}
+
+	// 拷贝多输出配置
+	if globalConfig.Outputs != nil {
+		configCopy.Outputs = make([]OutputConfig, len(globalConfig.Outputs))
+		for i, output := range globalConfig.Outputs {
+			if output.Options != nil {
+				options := make(map[string]interface{}, len(output.Options))
+				for k, v := range output.Options {
+					options[k] = v
+				}
+				output.Options = options
+			}
+			if output.FileConfig != nil {
+				fileConfig := *output.FileConfig
+				if fileConfig.Archive != nil {
+					archive := *fileConfig.Archive
+					fileConfig.Archive = &archive
+				}
+				output.FileConfig = &fileConfig
+			}
+			if output.Encoder != nil {
+				encoder := *output.Encoder
+				output.Encoder = &encoder
+			}
+			if output.CEF != nil {
+				cef := *output.CEF
+				output.CEF = &cef
+			}
+			configCopy.Outputs[i] = output
+		}
+	}
+
+	// 拷贝编码器配置
+	if globalConfig.Encoder != nil {
+		encoderCopy := *globalConfig.Encoder
+		configCopy.Encoder = &encoderCopy
+	}
+
+	// 拷贝CEF配置
+	if globalConfig.CEF != nil {
+		cefCopy := *globalConfig.CEF
+		configCopy.CEF = &cefCopy
+	}
+
+	// 拷贝控制台格式配置
+	if globalConfig.Console != nil {
+		consoleCopy := *globalConfig.Console
+		consoleCopy.FieldOrder = append([]string(nil), globalConfig.Console.FieldOrder...)
+		configCopy.Console = &consoleCopy
+	}
+
+	// 拷贝采样配置
+	if globalConfig.Sampling != nil {
+		samplingCopy := *globalConfig.Sampling
+		if globalConfig.Sampling.LevelOverrides != nil {
+			samplingCopy.LevelOverrides = make(map[string]LevelSamplingConfig, len(globalConfig.Sampling.LevelOverrides))
+			for k, v := range globalConfig.Sampling.LevelOverrides {
+				samplingCopy.LevelOverrides[k] = v
+			}
+		}
+		configCopy.Sampling = &samplingCopy
+	}
+
+	// 拷贝限流配置
+	if globalConfig.RateLimit != nil {
+		rateLimitCopy := *globalConfig.RateLimit
+		rateLimitCopy.Rules = append([]RateLimitRule(nil), globalConfig.RateLimit.Rules...)
+		configCopy.RateLimit = &rateLimitCopy
+	}
+
+	// 拷贝上下文日志回放配置
+	if globalConfig.FlightRecorder != nil {
+		flightRecorderCopy := *globalConfig.FlightRecorder
+		configCopy.FlightRecorder = &flightRecorderCopy
+	}
+
+	// 拷贝错误上报配置
+	if globalConfig.ErrorReporting != nil {
+		reportingCopy := *globalConfig.ErrorReporting
+		if globalConfig.ErrorReporting.Sentry != nil {
+			sentryCopy := *globalConfig.ErrorReporting.Sentry
+			sentryCopy.Tags = append([]string(nil), globalConfig.ErrorReporting.Sentry.Tags...)
+			reportingCopy.Sentry = &sentryCopy
+		}
+		configCopy.ErrorReporting = &reportingCopy
+	}
+
 	return &configCopy
 }
 
@@ -116,6 +116,31 @@ file_config:
 	assert.False(t, config.FileConfig.Compress)
 }
 
+// 测试从YAML加载多输出配置
+func TestLoadOutputsFromYAML(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "config.yaml")
+	yamlContent := `
+outputs:
+  - type: stdout
+    format: console
+  - type: file
+    format: json
+    level: warn
+    file_config:
+      filename: app.log
+`
+	require.NoError(t, os.WriteFile(configPath, []byte(yamlContent), 0644))
+
+	config, err := LoadFromFile(configPath)
+	require.NoError(t, err)
+
+	require.Len(t, config.Outputs, 2)
+	assert.Equal(t, OutputConfig{Type: "stdout", Format: "console"}, config.Outputs[0])
+	assert.Equal(t, "file", config.Outputs[1].Type)
+	assert.Equal(t, "warn", config.Outputs[1].Level)
+	assert.Equal(t, "app.log", config.Outputs[1].FileConfig.Filename)
+}
+
 // 测试保存配置到文件
 func TestSaveToFile(t *testing.T) {
 	// 创建配置对象
@@ -186,6 +211,9 @@ func TestFromEnv(t *testing.T) {
 	os.Setenv("VIRLOG_DEVELOPMENT", "true")
 	os.Setenv("VIRLOG_ENABLE_CALLER", "false")
 	os.Setenv("VIRLOG_FILE_PATH", "/var/log/app.log")
+	os.Setenv("VIRLOG_FILE_ARCHIVE_BUCKET", "app-logs")
+	os.Setenv("VIRLOG_RUNTIME_FIELDS", "hostname, pid")
+	os.Setenv("VIRLOG_CONSOLE_THEME", "dark")
 
 	// 测试完成后清理环境变量
 	defer func() {
@@ -195,6 +223,9 @@ func TestFromEnv(t *testing.T) {
 		os.Unsetenv("VIRLOG_DEVELOPMENT")
 		os.Unsetenv("VIRLOG_ENABLE_CALLER")
 		os.Unsetenv("VIRLOG_FILE_PATH")
+		os.Unsetenv("VIRLOG_FILE_ARCHIVE_BUCKET")
+		os.Unsetenv("VIRLOG_RUNTIME_FIELDS")
+		os.Unsetenv("VIRLOG_CONSOLE_THEME")
 	}()
 
 	// 从环境变量加载配置
@@ -207,4 +238,13 @@ func TestFromEnv(t *testing.T) {
 	assert.True(t, config.Development)
 	assert.False(t, config.EnableCaller)
 	assert.Equal(t, "/var/log/app.log", config.FileConfig.Filename)
+	require.NotNil(t, config.FileConfig.Archive)
+	assert.Equal(t, "app-logs", config.FileConfig.Archive.Bucket)
+	assert.Equal(t, &RuntimeFieldsConfig{Hostname: true, PID: true}, config.RuntimeFields)
+	require.NotNil(t, config.Console)
+	assert.Equal(t, "dark", config.Console.Theme)
+
+	// 返回的配置是深拷贝
+	config.FileConfig.Archive.Bucket = "changed"
+	assert.Equal(t, "app-logs", GetConfig().FileConfig.Archive.Bucket)
 }
@@ -0,0 +1,72 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+)
+
+// InternalLogger 记录库内部诊断信息的接口，keysAndValues 为成对出现的键和值
+//
+// *zap.SugaredLogger 实现了该接口；导入logger包后默认输出到virlog的全局Logger
+type InternalLogger interface {
+	Infow(msg string, keysAndValues ...interface{})
+	Errorw(msg string, keysAndValues ...interface{})
+}
+
+var (
+	internalLogger   InternalLogger = stderrLogger{}
+	internalLoggerMu sync.RWMutex
+)
+
+// SetInternalLogger 设置记录内部诊断信息的Logger，传入nil时丢弃所有内部日志
+func SetInternalLogger(l InternalLogger) {
+	if l == nil {
+		l = nopLogger{}
+	}
+	internalLoggerMu.Lock()
+	defer internalLoggerMu.Unlock()
+	internalLogger = l
+}
+
+// getInternalLogger 获取当前的内部Logger
+func getInternalLogger() InternalLogger {
+	internalLoggerMu.RLock()
+	defer internalLoggerMu.RUnlock()
+	return internalLogger
+}
+
+// stderrLogger 在logger包初始化之前使用，将内部日志写到标准错误输出
+type stderrLogger struct{}
+
+func (stderrLogger) Infow(msg string, keysAndValues ...interface{}) {
+	writeStderr("INFO", msg, keysAndValues)
+}
+
+func (stderrLogger) Errorw(msg string, keysAndValues ...interface{}) {
+	writeStderr("ERROR", msg, keysAndValues)
+}
+
+func writeStderr(level, msg string, keysAndValues []interface{}) {
+	var b strings.Builder
+	b.WriteString("virlog/config ")
+	b.WriteString(level)
+	b.WriteByte(' ')
+	b.WriteString(msg)
+	for i := 0; i < len(keysAndValues); i += 2 {
+		if i+1 < len(keysAndValues) {
+			fmt.Fprintf(&b, " %v=%v", keysAndValues[i], keysAndValues[i+1])
+		} else {
+			fmt.Fprintf(&b, " %v", keysAndValues[i])
+		}
+	}
+	b.WriteByte('\n')
+	os.Stderr.WriteString(b.String())
+}
+
+// nopLogger 丢弃所有内部日志
+type nopLogger struct{}
+
+func (nopLogger) Infow(string, ...interface{})  {}
+func (nopLogger) Errorw(string, ...interface{}) {}
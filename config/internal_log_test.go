@@ -0,0 +1,73 @@
+package config
+
+import (
+	"fmt"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// recordLogger 记录内部日志的测试Logger
+type recordLogger struct {
+	mu      sync.Mutex
+	entries []string
+}
+
+func (r *recordLogger) Infow(msg string, keysAndValues ...interface{}) {
+	r.record("info", msg, keysAndValues)
+}
+
+func (r *recordLogger) Errorw(msg string, keysAndValues ...interface{}) {
+	r.record("error", msg, keysAndValues)
+}
+
+func (r *recordLogger) record(level, msg string, keysAndValues []interface{}) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.entries = append(r.entries, fmt.Sprintf("%s %s %v", level, msg, keysAndValues))
+}
+
+// 测试内部错误通过InternalLogger输出
+func TestSetInternalLogger(t *testing.T) {
+	// 确保全局viper实例已初始化
+	GetConfig()
+
+	rec := &recordLogger{}
+	old := getInternalLogger()
+	SetInternalLogger(rec)
+	defer SetInternalLogger(old)
+
+	missing := filepath.Join(t.TempDir(), "missing.yaml")
+	loadConfigFile(missing)
+
+	require.Len(t, rec.entries, 1)
+	assert.Contains(t, rec.entries[0], "error 读取配置文件失败，使用默认配置")
+	assert.Contains(t, rec.entries[0], missing)
+
+	// 传入nil时丢弃内部日志
+	SetInternalLogger(nil)
+	assert.NotPanics(t, func() { loadConfigFile(missing) })
+	assert.Len(t, rec.entries, 1)
+}
+
+// 测试监听器接收超时通过InternalLogger输出
+func TestListenerTimeoutLogged(t *testing.T) {
+	rec := &recordLogger{}
+	old := getInternalLogger()
+	SetInternalLogger(rec)
+	defer SetInternalLogger(old)
+
+	listener := make(chan *Config, 1)
+	AddListener(listener)
+	defer RemoveListener(listener)
+
+	// 通道已满，通知超时
+	notifyListeners(DefaultConfig())
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+	require.Len(t, rec.entries, 1)
+	assert.Equal(t, "error 监听器接收超时 []", rec.entries[0])
+}
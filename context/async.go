@@ -0,0 +1,35 @@
+package context
+
+import (
+	"context"
+	"fmt"
+	"runtime/debug"
+
+	"github.com/constructorvirgil/virlog/logger"
+)
+
+// CopyToDetachedContext 返回一个只携带原上下文Logger的新上下文
+//
+// 新上下文不会随原上下文取消或超时，适合在请求结束后仍需继续运行的后台任务中使用，
+// 原上下文Logger上累积的字段（如request_id、user_id）会被保留
+func CopyToDetachedContext(ctx context.Context) context.Context {
+	return SaveToContext(context.Background(), GetFromContext(ctx))
+}
+
+// Go 在新的goroutine中运行fn，fn收到的上下文由 CopyToDetachedContext 创建
+//
+// fn中发生的panic会被恢复，并使用上下文中的Logger以Error级别记录
+func Go(ctx context.Context, fn func(ctx context.Context)) {
+	detached := CopyToDetachedContext(ctx)
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				GetFromContext(detached).Error("后台任务发生panic",
+					logger.String("panic", fmt.Sprint(r)),
+					logger.String("stack", string(debug.Stack())),
+				)
+			}
+		}()
+		fn(detached)
+	}()
+}
@@ -0,0 +1,88 @@
+package context
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/constructorvirgil/virlog/config"
+	"github.com/constructorvirgil/virlog/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zapcore"
+)
+
+// 线程安全的缓冲区
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+// 测试分离上下文保留Logger但不继承取消
+func TestCopyToDetachedContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	ctx, log := WithFields(ctx, logger.String("request_id", "req-1"))
+
+	detached := CopyToDetachedContext(ctx)
+	cancel()
+
+	assert.Error(t, ctx.Err())
+	assert.NoError(t, detached.Err(), "分离的上下文不应被取消")
+	assert.Equal(t, log, GetFromContext(detached))
+}
+
+// 测试后台goroutine携带上下文Logger并恢复panic
+func TestGo(t *testing.T) {
+	buf := &syncBuffer{}
+	cfg := config.DefaultConfig()
+	baseLogger, err := logger.NewLogger(cfg, logger.WithSyncTarget(zapcore.AddSync(buf)))
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(SaveToContext(context.Background(), baseLogger))
+	ctx, _ = WithFields(ctx, logger.String("request_id", "req-2"))
+
+	done := make(chan struct{})
+	Go(ctx, func(ctx context.Context) {
+		defer close(done)
+		assert.NoError(t, ctx.Err())
+		GetFromContext(ctx).Info("后台任务执行")
+	})
+	cancel()
+	<-done
+
+	panicked := make(chan struct{})
+	Go(ctx, func(ctx context.Context) {
+		defer close(panicked)
+		panic("boom")
+	})
+	<-panicked
+
+	// 等待panic日志写入
+	require.Eventually(t, func() bool {
+		return strings.Count(buf.String(), "\n") == 2
+	}, time.Second, 10*time.Millisecond)
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	for _, line := range lines {
+		var entry map[string]interface{}
+		require.NoError(t, json.Unmarshal([]byte(line), &entry))
+		assert.Equal(t, "req-2", entry["request_id"])
+	}
+	assert.Contains(t, lines[1], "boom")
+}
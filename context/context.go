@@ -6,15 +6,23 @@ import (
 	"github.com/constructorvirgil/virlog/logger"
 )
 
-// 定义上下文key类型，用于从上下文提取日志字段
-type loggerKey struct{}
-
-// GetFromContext 从上下文中提取Logger，如果没有则返回默认Logger
+// GetFromContext 从上下文中提取Logger，如果没有则返回默认Logger，
+// 返回的Logger带有通过 RegisterExtractor 注册的提取函数从上下文中提取的字段
+//
+// 与 logger.HTTPMiddleware 使用同一个key，可以直接获取中间件为请求创建的Logger
 func GetFromContext(ctx context.Context) logger.Logger {
 	if ctx == nil {
 		return logger.DefaultLogger()
 	}
-	if ctxLogger, ok := ctx.Value(loggerKey{}).(logger.Logger); ok {
+	return withExtracted(ctx, savedLogger(ctx))
+}
+
+// savedLogger 返回上下文中保存的Logger，不含提取的字段，如果没有则返回默认Logger
+func savedLogger(ctx context.Context) logger.Logger {
+	if ctx == nil {
+		return logger.DefaultLogger()
+	}
+	if ctxLogger, ok := logger.FromContext(ctx); ok {
 		return ctxLogger
 	}
 	return logger.DefaultLogger()
@@ -28,11 +36,28 @@ func SaveToContext(ctx context.Context, log logger.Logger) context.Context {
 	if log == nil {
 		log = logger.DefaultLogger()
 	}
-	return context.WithValue(ctx, loggerKey{}, log)
+	return logger.NewContext(ctx, log)
 }
 
 // WithFields 向上下文中的Logger添加字段
 func WithFields(ctx context.Context, fields ...logger.Field) (context.Context, logger.Logger) {
-	log := GetFromContext(ctx).With(fields...)
-	return SaveToContext(ctx, log), log
+	// 保存的Logger不含提取的字段，避免再次获取时重复添加
+	log := savedLogger(ctx).With(fields...)
+	return SaveToContext(ctx, log), withExtracted(ctx, log)
+}
+
+// WithMinLevel 使上下文中的Logger及其派生的Logger输出不低于 level 的日志，即使全局级别更高
+func WithMinLevel(ctx context.Context, level logger.Level) (context.Context, logger.Logger) {
+	log := savedLogger(ctx).WithOptions(logger.WithMinLevel(level))
+	return SaveToContext(ctx, log), withExtracted(ctx, log)
+}
+
+// WithLogBudget 使上下文中的Logger及其派生的Logger最多输出 max 条日志，超出的日志被丢弃，见 logger.LogBudget
+//
+// 返回的 summarize 应在请求结束时调用，存在被丢弃的日志时输出一条 log_budget_exceeded 汇总日志
+func WithLogBudget(ctx context.Context, max int) (context.Context, logger.Logger, func()) {
+	base := savedLogger(ctx)
+	budget := logger.NewLogBudget(max)
+	log := base.WithOptions(logger.Budget(budget))
+	return SaveToContext(ctx, log), withExtracted(ctx, log), func() { budget.Summarize(withExtracted(ctx, base)) }
 }
@@ -1,11 +1,18 @@
 package context
 
 import (
+	"bytes"
 	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
 	"testing"
 
+	"github.com/constructorvirgil/virlog/config"
 	"github.com/constructorvirgil/virlog/logger"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zapcore"
 )
 
 // 测试GetFromContext函数
@@ -94,3 +101,121 @@ func TestCombinedUsage(t *testing.T) {
 	// 验证log3是从log2派生的，而不是从log1
 	assert.NotEqual(t, log1, log3, "WithFields应该从当前上下文中的Logger派生")
 }
+
+// 测试WithMinLevel对上下文中的Logger放宽级别
+func TestWithMinLevel(t *testing.T) {
+	buf := &bytes.Buffer{}
+	cfg := config.DefaultConfig()
+	cfg.Level = "info"
+	baseLogger, err := logger.NewLogger(cfg, logger.WithSyncTarget(zapcore.AddSync(buf)))
+	require.NoError(t, err)
+
+	ctx := SaveToContext(context.Background(), baseLogger)
+	debugCtx, _ := WithMinLevel(ctx, logger.DebugLevel)
+	debugCtx, _ = WithFields(debugCtx, logger.String("request_id", "req-1"))
+
+	GetFromContext(ctx).Debug("hidden")
+	GetFromContext(debugCtx).Debug("visible")
+	assert.NotContains(t, buf.String(), "hidden")
+	assert.Contains(t, buf.String(), "visible")
+	assert.Contains(t, buf.String(), "req-1")
+}
+
+// 测试为上下文中的Logger设置日志预算
+func TestWithLogBudget(t *testing.T) {
+	buf := &bytes.Buffer{}
+	cfg := config.DefaultConfig()
+	cfg.Format = "json"
+	base, err := logger.NewLogger(cfg, logger.WithSyncTarget(zapcore.AddSync(buf)))
+	require.NoError(t, err)
+
+	ctx := SaveToContext(context.Background(), base)
+	ctx, log, summarize := WithLogBudget(ctx, 1)
+	log.Info("kept")
+	GetFromContext(ctx).Info("noisy")
+	summarize()
+
+	out := buf.String()
+	assert.Contains(t, out, "kept")
+	assert.NotContains(t, out, "noisy")
+	assert.Contains(t, out, "log_budget_exceeded")
+}
+
+// tenantKey 测试用的上下文key
+type tenantKey struct{}
+
+// 测试注册的提取函数从上下文中提取字段
+func TestRegisterExtractor(t *testing.T) {
+	t.Cleanup(func() {
+		extractorsMu.Lock()
+		extractors = nil
+		extractorsMu.Unlock()
+	})
+	RegisterExtractor(func(ctx context.Context) []logger.Field {
+		if tenant, ok := ctx.Value(tenantKey{}).(string); ok {
+			return []logger.Field{logger.String("tenant", tenant)}
+		}
+		return nil
+	})
+	RegisterExtractor(nil)
+
+	buf := &bytes.Buffer{}
+	cfg := config.DefaultConfig()
+	cfg.Format = "json"
+	base, err := logger.NewLogger(cfg, logger.WithSyncTarget(zapcore.AddSync(buf)))
+	require.NoError(t, err)
+
+	ctx := SaveToContext(context.Background(), base)
+	GetFromContext(ctx).Info("no tenant")
+	assert.NotContains(t, buf.String(), `"tenant"`)
+
+	// 提取函数在获取Logger时调用，可以读取之后写入上下文的值
+	ctx = context.WithValue(ctx, tenantKey{}, "acme")
+	buf.Reset()
+	GetFromContext(ctx).Info("with tenant")
+	assert.Contains(t, buf.String(), `"tenant":"acme"`)
+
+	// WithFields 之后再次获取，提取的字段不会重复
+	ctx, log := WithFields(ctx, logger.String("user", "u1"))
+	buf.Reset()
+	log.Info("returned")
+	GetFromContext(ctx).Info("again")
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Len(t, lines, 2)
+	for _, line := range lines {
+		assert.Equal(t, 1, strings.Count(line, `"tenant"`), line)
+		assert.Contains(t, line, `"user":"u1"`)
+	}
+}
+
+// 测试获取 logger.HTTPMiddleware 为请求创建的Logger，并与 logger 包读写同一个Logger
+func TestGetFromContextWithMiddleware(t *testing.T) {
+	buf := &bytes.Buffer{}
+	cfg := config.DefaultConfig()
+	cfg.Format = "json"
+	base, err := logger.NewLogger(cfg, logger.WithSyncTarget(zapcore.AddSync(buf)))
+	require.NoError(t, err)
+
+	handler := logger.HTTPMiddleware(base)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		GetFromContext(r.Context()).Info("in handler")
+	}))
+	req := httptest.NewRequest(http.MethodGet, "/orders", nil)
+	req.Header.Set("X-Request-ID", "req-42")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	var handlerLine string
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		if strings.Contains(line, "in handler") {
+			handlerLine = line
+		}
+	}
+	assert.Contains(t, handlerLine, `"request_id":"req-42"`)
+	assert.Contains(t, handlerLine, `"path":"/orders"`)
+
+	// SaveToContext 保存的Logger可以通过 logger.FromContext 读取
+	ctx := SaveToContext(context.Background(), base)
+	saved, ok := logger.FromContext(ctx)
+	assert.True(t, ok)
+	assert.Equal(t, base, saved)
+	assert.Equal(t, base, GetFromContext(logger.NewContext(context.Background(), base)))
+}
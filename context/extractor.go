@@ -0,0 +1,48 @@
+package context
+
+import (
+	"context"
+	"sync"
+
+	"github.com/constructorvirgil/virlog/logger"
+)
+
+// Extractor 从上下文的值中提取日志字段，如其他中间件写入的租户ID、用户ID、追踪ID，
+// 上下文中没有对应的值时返回nil
+type Extractor func(ctx context.Context) []logger.Field
+
+var (
+	// 已注册的字段提取函数
+	extractors   []Extractor
+	extractorsMu sync.RWMutex
+)
+
+// RegisterExtractor 注册字段提取函数，GetFromContext 返回的Logger会带上所有提取函数返回的字段，
+// 按注册顺序添加。通常在程序启动时注册，提取函数会在每次获取Logger时调用，应避免耗时操作
+func RegisterExtractor(extractor Extractor) {
+	if extractor == nil {
+		return
+	}
+	extractorsMu.Lock()
+	defer extractorsMu.Unlock()
+	extractors = append(extractors, extractor)
+}
+
+// extractFields 返回当前span的字段以及所有已注册的提取函数提取到的字段
+func extractFields(ctx context.Context) []logger.Field {
+	extractorsMu.RLock()
+	defer extractorsMu.RUnlock()
+	fields := spanFields(ctx)
+	for _, extract := range extractors {
+		fields = append(fields, extract(ctx)...)
+	}
+	return fields
+}
+
+// withExtracted 为Logger添加从上下文中提取的字段
+func withExtracted(ctx context.Context, log logger.Logger) logger.Logger {
+	if fields := extractFields(ctx); len(fields) > 0 {
+		return log.With(fields...)
+	}
+	return log
+}
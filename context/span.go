@@ -0,0 +1,82 @@
+package context
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"sync/atomic"
+	"time"
+
+	"github.com/constructorvirgil/virlog/logger"
+)
+
+// spanKey 上下文中保存当前span的key
+type spanKey struct{}
+
+// span 当前正在计时的操作
+type span struct {
+	name     string
+	id       string
+	parentID string
+}
+
+// StartSpan 开始一个计时的操作，如 StartSpan(ctx, "load_user")，输出Debug级别的 span started 日志，
+// 返回的上下文中的Logger带有 span、span_id 字段，嵌套调用时带有外层的 parent_span_id，fields 也会添加到该Logger
+//
+// 操作结束时调用返回的 end：err 为nil时输出Info级别、status 为 ok 的 span finished 日志，
+// 否则输出Error级别、status 为 error 的日志，两者都带有 duration 字段。end 只有第一次调用生效：
+//
+//	ctx, end := logctx.StartSpan(ctx, "load_user")
+//	user, err := loadUser(ctx, id)
+//	end(err)
+func StartSpan(ctx context.Context, name string, fields ...logger.Field) (context.Context, func(err error)) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	s := &span{name: name, id: newSpanID()}
+	if parent, ok := ctx.Value(spanKey{}).(*span); ok {
+		s.parentID = parent.id
+	}
+	ctx = context.WithValue(ctx, spanKey{}, s)
+	if len(fields) > 0 {
+		ctx, _ = WithFields(ctx, fields...)
+	}
+
+	// 日志的调用者指向 StartSpan 和 end 的调用方
+	log := GetFromContext(ctx).WithOptions(logger.WithCallerSkip(1))
+	log.Debug("span started")
+	start := time.Now()
+
+	var ended atomic.Bool
+	return ctx, func(err error) {
+		if !ended.CompareAndSwap(false, true) {
+			return
+		}
+		duration := logger.Duration("duration", time.Since(start))
+		if err != nil {
+			log.Error("span finished", duration, logger.String("status", "error"), logger.Err(err))
+			return
+		}
+		log.Info("span finished", duration, logger.String("status", "ok"))
+	}
+}
+
+// spanFields 返回上下文中当前span的字段，嵌套的span只保留最内层的字段
+func spanFields(ctx context.Context) []logger.Field {
+	s, ok := ctx.Value(spanKey{}).(*span)
+	if !ok {
+		return nil
+	}
+	fields := []logger.Field{logger.String("span", s.name), logger.String("span_id", s.id)}
+	if s.parentID != "" {
+		fields = append(fields, logger.String("parent_span_id", s.parentID))
+	}
+	return fields
+}
+
+// newSpanID 生成16位十六进制的span ID
+func newSpanID() string {
+	var b [8]byte
+	_, _ = rand.Read(b[:])
+	return hex.EncodeToString(b[:])
+}
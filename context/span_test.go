@@ -0,0 +1,75 @@
+package context
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/constructorvirgil/virlog/config"
+	"github.com/constructorvirgil/virlog/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zapcore"
+)
+
+// 测试span输出开始、结束日志并为上下文Logger添加span字段
+func TestStartSpan(t *testing.T) {
+	buf := &syncBuffer{}
+	cfg := config.DefaultConfig()
+	cfg.Level = "debug"
+	baseLogger, err := logger.NewLogger(cfg, logger.WithSyncTarget(zapcore.AddSync(buf)))
+	require.NoError(t, err)
+	ctx, _ := WithFields(SaveToContext(context.Background(), baseLogger), logger.String("request_id", "req-1"))
+
+	ctx, endOuter := StartSpan(ctx, "handle")
+	innerCtx, endInner := StartSpan(ctx, "load_user", logger.String("user_id", "42"))
+	GetFromContext(innerCtx).Info("querying")
+	endInner(errors.New("not found"))
+	endInner(nil)
+	GetFromContext(ctx).Info("after inner")
+	endOuter(nil)
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Len(t, lines, 6)
+	entries := make([]map[string]interface{}, len(lines))
+	for i, line := range lines {
+		require.NoError(t, json.Unmarshal([]byte(line), &entries[i]))
+		assert.Equal(t, "req-1", entries[i]["request_id"])
+	}
+
+	outerID := entries[0]["span_id"]
+	assert.Equal(t, "span started", entries[0]["msg"])
+	assert.Equal(t, "debug", entries[0]["level"])
+	assert.Equal(t, "handle", entries[0]["span"])
+	assert.Len(t, outerID, 16)
+	assert.Contains(t, entries[0]["caller"], "span_test.go")
+
+	// 嵌套的span带有外层的span ID，不重复添加外层的span字段
+	assert.Equal(t, "load_user", entries[1]["span"])
+	assert.Equal(t, outerID, entries[1]["parent_span_id"])
+	assert.NotEqual(t, outerID, entries[1]["span_id"])
+	assert.Equal(t, "42", entries[1]["user_id"])
+	assert.Equal(t, 1, strings.Count(lines[1], `"span":`))
+	assert.Equal(t, "querying", entries[2]["msg"])
+	assert.Equal(t, entries[1]["span_id"], entries[2]["span_id"])
+
+	assert.Equal(t, "span finished", entries[3]["msg"])
+	assert.Equal(t, "error", entries[3]["level"])
+	assert.Equal(t, "error", entries[3]["status"])
+	assert.Equal(t, "not found", entries[3]["error"])
+	assert.Contains(t, entries[3], "duration")
+	assert.Contains(t, entries[3]["caller"], "span_test.go")
+
+	// 结束内层span后外层上下文不带内层的字段
+	assert.Equal(t, "after inner", entries[4]["msg"])
+	assert.Equal(t, "handle", entries[4]["span"])
+	assert.NotContains(t, entries[4], "user_id")
+	assert.NotContains(t, entries[4], "parent_span_id")
+
+	assert.Equal(t, "span finished", entries[5]["msg"])
+	assert.Equal(t, "info", entries[5]["level"])
+	assert.Equal(t, "ok", entries[5]["status"])
+	assert.Equal(t, outerID, entries[5]["span_id"])
+}
@@ -0,0 +1,110 @@
+// Package compress 压缩批量发送的日志，供网络输出使用
+package compress
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+const (
+	// Gzip gzip压缩，同时作为 Content-Encoding 的值
+	Gzip = "gzip"
+	// Zstd zstd压缩，同时作为 Content-Encoding 的值
+	Zstd = "zstd"
+	// DefaultMinBytes 默认的最小压缩字节数，更小的批次压缩收益有限
+	DefaultMinBytes = 1024
+)
+
+// Compressor 按配置的算法和级别压缩数据，nil 表示不压缩
+type Compressor struct {
+	algorithm string
+	level     int
+	minBytes  int
+	zstd      *zstd.Encoder
+}
+
+// New 创建压缩器，algorithm 为空时返回nil；supported 为输出支持的算法
+//
+// level 为0时使用算法的默认级别，gzip 为1-9，zstd 为1-22（按 zstd 命令行的级别映射到编码器的速度档位）；
+// minBytes 为0时使用 DefaultMinBytes，小于0时总是压缩
+func New(algorithm string, level, minBytes int, supported ...string) (*Compressor, error) {
+	if algorithm == "" {
+		return nil, nil
+	}
+	allowed := false
+	for _, s := range supported {
+		if s == algorithm {
+			allowed = true
+			break
+		}
+	}
+	if !allowed {
+		return nil, fmt.Errorf("不支持的压缩算法: %s，支持 %v", algorithm, supported)
+	}
+	if minBytes == 0 {
+		minBytes = DefaultMinBytes
+	}
+
+	c := &Compressor{algorithm: algorithm, level: level, minBytes: minBytes}
+	switch algorithm {
+	case Gzip:
+		if level == 0 {
+			c.level = gzip.DefaultCompression
+		} else if level < gzip.BestSpeed || level > gzip.BestCompression {
+			return nil, fmt.Errorf("无效的gzip压缩级别: %d", level)
+		}
+	case Zstd:
+		opts := []zstd.EOption{zstd.WithEncoderConcurrency(1)}
+		if level != 0 {
+			if level < 1 || level > 22 {
+				return nil, fmt.Errorf("无效的zstd压缩级别: %d", level)
+			}
+			opts = append(opts, zstd.WithEncoderLevel(zstd.EncoderLevelFromZstd(level)))
+		}
+		enc, err := zstd.NewWriter(nil, opts...)
+		if err != nil {
+			return nil, fmt.Errorf("创建zstd编码器失败: %w", err)
+		}
+		c.zstd = enc
+	default:
+		return nil, fmt.Errorf("不支持的压缩算法: %s", algorithm)
+	}
+	return c, nil
+}
+
+// Algorithm 返回压缩算法，c 为nil时返回空字符串
+func (c *Compressor) Algorithm() string {
+	if c == nil {
+		return ""
+	}
+	return c.algorithm
+}
+
+// Compress 压缩数据，返回压缩后的数据和 Content-Encoding；
+// c 为nil或数据小于最小压缩字节数时返回原数据和空的 Content-Encoding
+func (c *Compressor) Compress(data []byte) ([]byte, string, error) {
+	if c == nil || len(data) < c.minBytes {
+		return data, "", nil
+	}
+
+	switch c.algorithm {
+	case Zstd:
+		return c.zstd.EncodeAll(data, make([]byte, 0, len(data)/2)), Zstd, nil
+	default:
+		var buf bytes.Buffer
+		w, err := gzip.NewWriterLevel(&buf, c.level)
+		if err != nil {
+			return nil, "", fmt.Errorf("创建gzip编码器失败: %w", err)
+		}
+		if _, err := w.Write(data); err != nil {
+			return nil, "", fmt.Errorf("gzip压缩失败: %w", err)
+		}
+		if err := w.Close(); err != nil {
+			return nil, "", fmt.Errorf("gzip压缩失败: %w", err)
+		}
+		return buf.Bytes(), Gzip, nil
+	}
+}
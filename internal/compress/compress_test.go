@@ -0,0 +1,79 @@
+package compress
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"testing"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// 测试gzip和zstd压缩后可以解压出原数据
+func TestCompressRoundTrip(t *testing.T) {
+	data := bytes.Repeat([]byte(`{"level":"info","msg":"hello"}`+"\n"), 100)
+
+	c, err := New(Gzip, 9, 0, Gzip, Zstd)
+	require.NoError(t, err)
+	out, encoding, err := c.Compress(data)
+	require.NoError(t, err)
+	assert.Equal(t, Gzip, encoding)
+	assert.Less(t, len(out), len(data))
+	gr, err := gzip.NewReader(bytes.NewReader(out))
+	require.NoError(t, err)
+	plain, err := io.ReadAll(gr)
+	require.NoError(t, err)
+	assert.Equal(t, data, plain)
+
+	c, err = New(Zstd, 3, 0, Gzip, Zstd)
+	require.NoError(t, err)
+	out, encoding, err = c.Compress(data)
+	require.NoError(t, err)
+	assert.Equal(t, Zstd, encoding)
+	dec, err := zstd.NewReader(nil)
+	require.NoError(t, err)
+	defer dec.Close()
+	plain, err = dec.DecodeAll(out, nil)
+	require.NoError(t, err)
+	assert.Equal(t, data, plain)
+}
+
+// 测试小于最小压缩字节数的数据不压缩
+func TestCompressMinBytes(t *testing.T) {
+	c, err := New(Gzip, 0, 0, Gzip)
+	require.NoError(t, err)
+	out, encoding, err := c.Compress([]byte("small"))
+	require.NoError(t, err)
+	assert.Empty(t, encoding)
+	assert.Equal(t, []byte("small"), out)
+
+	c, err = New(Gzip, 0, -1, Gzip)
+	require.NoError(t, err)
+	_, encoding, err = c.Compress([]byte("small"))
+	require.NoError(t, err)
+	assert.Equal(t, Gzip, encoding)
+
+	// 未配置压缩时返回nil，nil压缩器返回原数据
+	c, err = New("", 0, 0, Gzip)
+	require.NoError(t, err)
+	assert.Nil(t, c)
+	assert.Empty(t, c.Algorithm())
+	out, encoding, err = c.Compress([]byte("data"))
+	require.NoError(t, err)
+	assert.Empty(t, encoding)
+	assert.Equal(t, []byte("data"), out)
+}
+
+// 测试无效的算法和级别
+func TestNewErrors(t *testing.T) {
+	_, err := New(Zstd, 0, 0, Gzip)
+	assert.ErrorContains(t, err, "不支持的压缩算法")
+	_, err = New("lz4", 0, 0, "lz4")
+	assert.ErrorContains(t, err, "不支持的压缩算法")
+	_, err = New(Gzip, 10, 0, Gzip)
+	assert.ErrorContains(t, err, "无效的gzip压缩级别")
+	_, err = New(Zstd, 23, 0, Zstd)
+	assert.ErrorContains(t, err, "无效的zstd压缩级别")
+}
@@ -0,0 +1,301 @@
+package logger
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+const (
+	// defaultAdaptiveLatency 默认视为输出饱和的平均写入耗时
+	defaultAdaptiveLatency = 20 * time.Millisecond
+	// defaultAdaptiveQueueUsage 默认视为输出饱和的队列使用率
+	defaultAdaptiveQueueUsage = 0.8
+	// defaultAdaptiveInterval 默认评估输出压力的间隔
+	defaultAdaptiveInterval = time.Second
+	// defaultAdaptiveMaxRate 默认的最大采样倍数
+	defaultAdaptiveMaxRate = 16
+)
+
+// AdaptiveSamplingOptions 自适应采样的配置
+type AdaptiveSamplingOptions struct {
+	// 平均写入耗时超过该值时视为输出饱和，默认20ms
+	LatencyThreshold time.Duration
+	// 输出队列（Fluentd、Elasticsearch）使用率超过该值时视为输出饱和，默认0.8
+	QueueThreshold float64
+	// 评估输出压力的间隔，默认1秒
+	Interval time.Duration
+	// 最大采样倍数，每N条保留1条，默认16
+	MaxSampleRate int
+	// 采样倍数达到上限后最多将最低级别提高到该级别，默认 "warn"；Error及以上级别的日志不会被丢弃
+	MaxLevel string
+}
+
+// AdaptiveSamplingState 自适应采样的当前状态
+type AdaptiveSamplingState struct {
+	// 压力等级，0表示未采样
+	Stage int
+	// 当前的采样倍数，Warn以下级别的日志每N条保留1条，1表示不采样
+	SampleRate int
+	// 当前的最低级别，低于该级别的日志被丢弃
+	MinLevel Level
+	// 上一个评估周期的平均写入耗时
+	Latency time.Duration
+	// 上一个评估周期结束时的最大队列使用率
+	QueueUsage float64
+	// 因采样或提高级别被丢弃的日志总数
+	Dropped uint64
+}
+
+// AdaptiveSampler 根据输出的写入耗时和队列深度自动调整采样
+//
+// 输出饱和时逐级加倍采样倍数，达到 MaxSampleRate 后逐级提高最低级别；
+// 压力降到阈值的一半以下时按相反的顺序逐级恢复。压力在日志写入时按 Interval 评估
+type AdaptiveSampler struct {
+	opts      AdaptiveSamplingOptions
+	maxLevel  Level
+	rateSteps int
+
+	// 当前评估周期的写入次数和总耗时
+	writes  atomic.Int64
+	elapsed atomic.Int64
+	// counter 采样计数
+	counter atomic.Uint64
+	dropped atomic.Uint64
+	// stage 当前的压力等级
+	stage atomic.Int32
+	// lastEval 上次评估的时间（UnixNano）
+	lastEval atomic.Int64
+
+	mu        sync.Mutex
+	queues    []queueReporter
+	state     AdaptiveSamplingState
+	callbacks []func(AdaptiveSamplingState)
+}
+
+// queueReporter 带有发送队列的输出
+type queueReporter interface {
+	// queueUsage 返回队列使用率，0到1之间
+	queueUsage() float64
+}
+
+// NewAdaptiveSampler 创建自适应采样器，通过 AdaptiveSampling 选项应用到Logger
+func NewAdaptiveSampler(opts AdaptiveSamplingOptions) *AdaptiveSampler {
+	if opts.LatencyThreshold <= 0 {
+		opts.LatencyThreshold = defaultAdaptiveLatency
+	}
+	if opts.QueueThreshold <= 0 {
+		opts.QueueThreshold = defaultAdaptiveQueueUsage
+	}
+	if opts.Interval <= 0 {
+		opts.Interval = defaultAdaptiveInterval
+	}
+	if opts.MaxSampleRate <= 1 {
+		opts.MaxSampleRate = defaultAdaptiveMaxRate
+	}
+	maxLevel, ok := parseLevel(opts.MaxLevel)
+	if !ok || maxLevel > WarnLevel {
+		maxLevel = WarnLevel
+	}
+
+	s := &AdaptiveSampler{opts: opts, maxLevel: maxLevel}
+	for rate := 1; rate*2 <= opts.MaxSampleRate; rate *= 2 {
+		s.rateSteps++
+	}
+	s.state = s.stateAt(0)
+	s.lastEval.Store(time.Now().UnixNano())
+	return s
+}
+
+// AdaptiveSampling 使Logger按 s 的状态采样，多个Logger可以共享同一个采样器，s 为nil时不生效
+func AdaptiveSampling(s *AdaptiveSampler) Option {
+	return func(l *zapLogger) {
+		l.adaptive = s
+	}
+}
+
+// State 返回当前的采样状态
+func (s *AdaptiveSampler) State() AdaptiveSamplingState {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	state := s.state
+	state.Dropped = s.dropped.Load()
+	return state
+}
+
+// OnChange 注册采样状态变化时的回调，回调在写入日志的goroutine中执行，不应使用受该采样器控制的Logger输出日志
+func (s *AdaptiveSampler) OnChange(callback func(AdaptiveSamplingState)) {
+	if callback == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.callbacks = append(s.callbacks, callback)
+}
+
+// stateAt 返回压力等级对应的采样倍数和最低级别
+func (s *AdaptiveSampler) stateAt(stage int) AdaptiveSamplingState {
+	state := AdaptiveSamplingState{Stage: stage, SampleRate: 1, MinLevel: TraceLevel}
+	for i := 0; i < stage && i < s.rateSteps; i++ {
+		state.SampleRate *= 2
+	}
+	if stage > s.rateSteps {
+		// 从丢弃Trace级别开始逐级提高
+		state.MinLevel = TraceLevel + Level(stage-s.rateSteps)
+	}
+	return state
+}
+
+// maxStage 返回最高的压力等级
+func (s *AdaptiveSampler) maxStage() int {
+	return s.rateSteps + int(s.maxLevel-TraceLevel)
+}
+
+// allow 判断日志是否保留
+func (s *AdaptiveSampler) allow(level Level) bool {
+	s.maybeEvaluate()
+	if level >= ErrorLevel || s.stage.Load() == 0 {
+		return true
+	}
+
+	s.mu.Lock()
+	state := s.state
+	s.mu.Unlock()
+	if level < state.MinLevel {
+		s.dropped.Add(1)
+		return false
+	}
+	if level >= WarnLevel || state.SampleRate <= 1 {
+		return true
+	}
+	if s.counter.Add(1)%uint64(state.SampleRate) != 0 {
+		s.dropped.Add(1)
+		return false
+	}
+	return true
+}
+
+// record 记录一次写入的耗时
+func (s *AdaptiveSampler) record(d time.Duration) {
+	s.writes.Add(1)
+	s.elapsed.Add(int64(d))
+}
+
+// maybeEvaluate 距上次评估超过 Interval 时评估输出压力
+func (s *AdaptiveSampler) maybeEvaluate() {
+	now := time.Now().UnixNano()
+	last := s.lastEval.Load()
+	if now-last < int64(s.opts.Interval) || !s.lastEval.CompareAndSwap(last, now) {
+		return
+	}
+	s.evaluate()
+}
+
+// evaluate 根据上一个周期的平均写入耗时和当前的队列使用率调整压力等级
+func (s *AdaptiveSampler) evaluate() {
+	var latency time.Duration
+	if writes := s.writes.Swap(0); writes > 0 {
+		latency = time.Duration(s.elapsed.Swap(0) / writes)
+	}
+
+	s.mu.Lock()
+	var usage float64
+	for _, q := range s.queues {
+		if u := q.queueUsage(); u > usage {
+			usage = u
+		}
+	}
+	stage := s.state.Stage
+	switch {
+	case latency > s.opts.LatencyThreshold || usage > s.opts.QueueThreshold:
+		if stage < s.maxStage() {
+			stage++
+		}
+	case latency < s.opts.LatencyThreshold/2 && usage < s.opts.QueueThreshold/2:
+		if stage > 0 {
+			stage--
+		}
+	}
+	changed := stage != s.state.Stage
+	s.state = s.stateAt(stage)
+	s.state.Latency = latency
+	s.state.QueueUsage = usage
+	s.stage.Store(int32(stage))
+	state := s.state
+	state.Dropped = s.dropped.Load()
+	callbacks := s.callbacks
+	s.mu.Unlock()
+
+	if changed {
+		for _, callback := range callbacks {
+			callback(state)
+		}
+	}
+}
+
+// measure 包装输出core以统计写入耗时，输出带有发送队列时同时统计队列使用率
+func (s *AdaptiveSampler) measure(core zapcore.Core) zapcore.Core {
+	if q, ok := core.(queueReporter); ok {
+		s.mu.Lock()
+		s.queues = append(s.queues, q)
+		s.mu.Unlock()
+	}
+	return &measuredCore{Core: core, sampler: s}
+}
+
+// measuredCore 统计写入耗时的core
+type measuredCore struct {
+	zapcore.Core
+	sampler *AdaptiveSampler
+}
+
+// With 实现zapcore.Core接口
+func (c *measuredCore) With(fields []Field) zapcore.Core {
+	return &measuredCore{Core: c.Core.With(fields), sampler: c.sampler}
+}
+
+// Check 实现zapcore.Core接口
+func (c *measuredCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(ent.Level) {
+		return ce.AddCore(ent, c)
+	}
+	return ce
+}
+
+// Write 实现zapcore.Core接口
+func (c *measuredCore) Write(ent zapcore.Entry, fields []Field) error {
+	start := time.Now()
+	err := c.Core.Write(ent, fields)
+	c.sampler.record(time.Since(start))
+	return err
+}
+
+// adaptiveOption 返回在最外层按采样器状态丢弃日志的选项
+func adaptiveOption(s *AdaptiveSampler) zap.Option {
+	return zap.WrapCore(func(core zapcore.Core) zapcore.Core {
+		return &adaptiveCore{Core: core, sampler: s}
+	})
+}
+
+// adaptiveCore 在最外层按采样器状态丢弃日志
+type adaptiveCore struct {
+	zapcore.Core
+	sampler *AdaptiveSampler
+}
+
+// With 实现zapcore.Core接口
+func (c *adaptiveCore) With(fields []Field) zapcore.Core {
+	return &adaptiveCore{Core: c.Core.With(fields), sampler: c.sampler}
+}
+
+// Check 实现zapcore.Core接口，只有会输出的日志才参与采样
+func (c *adaptiveCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	checked := c.Core.Check(ent, ce)
+	if checked == ce || c.sampler.allow(ent.Level) {
+		return checked
+	}
+	return ce
+}
@@ -0,0 +1,140 @@
+package logger
+
+import (
+	"bytes"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/constructorvirgil/virlog/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zapcore"
+)
+
+// slowWriter 按设置的延迟写入的输出
+type slowWriter struct {
+	mu    sync.Mutex
+	buf   bytes.Buffer
+	delay time.Duration
+}
+
+func (w *slowWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	time.Sleep(w.delay)
+	return w.buf.Write(p)
+}
+
+func (w *slowWriter) setDelay(d time.Duration) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.delay = d
+}
+
+func (w *slowWriter) count(msg string) int {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return strings.Count(w.buf.String(), `"msg":"`+msg+`"`)
+}
+
+// fakeQueue 固定使用率的队列
+type fakeQueue float64
+
+func (q fakeQueue) queueUsage() float64 {
+	return float64(q)
+}
+
+// queuedCore 带有队列的输出
+type queuedCore struct {
+	zapcore.Core
+	fakeQueue
+}
+
+// 测试输出变慢时逐级采样和提高级别，恢复后逐级放宽
+func TestAdaptiveSampling(t *testing.T) {
+	sampler := NewAdaptiveSampler(AdaptiveSamplingOptions{
+		LatencyThreshold: 5 * time.Millisecond,
+		Interval:         time.Hour,
+		MaxSampleRate:    4,
+		MaxLevel:         "info",
+	})
+	var changes []AdaptiveSamplingState
+	sampler.OnChange(func(state AdaptiveSamplingState) {
+		changes = append(changes, state)
+	})
+
+	w := &slowWriter{delay: 10 * time.Millisecond}
+	cfg := config.DefaultConfig()
+	cfg.Level = "trace"
+	cfg.Format = "json"
+	log, err := NewLogger(cfg, WithSyncTarget(zapcore.AddSync(w)), AdaptiveSampling(sampler))
+	require.NoError(t, err)
+
+	// 每个周期写入一条慢日志，压力等级逐级升高直到上限
+	for i := 0; i < 6; i++ {
+		log.Error("slow")
+		sampler.evaluate()
+	}
+	require.Len(t, changes, 4)
+	assert.Equal(t, []int{2, 4, 4, 4}, []int{changes[0].SampleRate, changes[1].SampleRate, changes[2].SampleRate, changes[3].SampleRate})
+	assert.Equal(t, DebugLevel, changes[2].MinLevel)
+	state := sampler.State()
+	assert.Equal(t, 4, state.Stage)
+	assert.Equal(t, InfoLevel, state.MinLevel)
+	assert.Greater(t, state.Latency, 5*time.Millisecond)
+
+	w.setDelay(0)
+	for i := 0; i < 8; i++ {
+		log.Debug("debug")
+		log.Info("info")
+		log.Error("error")
+	}
+	assert.Equal(t, 0, w.count("debug"))
+	assert.Equal(t, 2, w.count("info"))
+	assert.Equal(t, 8, w.count("error"))
+	assert.Equal(t, uint64(14), sampler.State().Dropped)
+
+	// 没有写入时压力低于阈值的一半，逐级恢复
+	for i := 0; i < 4; i++ {
+		sampler.evaluate()
+	}
+	assert.Len(t, changes, 8)
+	assert.Equal(t, AdaptiveSamplingState{SampleRate: 1, MinLevel: TraceLevel}, sampler.stateAt(0))
+	assert.Equal(t, 0, sampler.State().Stage)
+	log.Trace("trace")
+	assert.Equal(t, 1, w.count("trace"))
+}
+
+// 测试队列使用率超过阈值时视为输出饱和
+func TestAdaptiveSamplingQueue(t *testing.T) {
+	sampler := NewAdaptiveSampler(AdaptiveSamplingOptions{Interval: time.Hour})
+	assert.Equal(t, 16, sampler.stateAt(4).SampleRate)
+	assert.Equal(t, 7, sampler.maxStage())
+
+	queue := fakeQueue(0.9)
+	sampler.measure(zapcore.NewNopCore())
+	sampler.queues = append(sampler.queues, queue)
+	sampler.evaluate()
+	state := sampler.State()
+	assert.Equal(t, 1, state.Stage)
+	assert.Equal(t, 0.9, state.QueueUsage)
+
+	// 介于阈值的一半和阈值之间时保持不变
+	sampler.queues[0] = fakeQueue(0.5)
+	sampler.evaluate()
+	assert.Equal(t, 1, sampler.State().Stage)
+
+	sampler.queues[0] = fakeQueue(0.1)
+	sampler.evaluate()
+	assert.Equal(t, 0, sampler.State().Stage)
+
+	// 多个输出时取最大的队列使用率
+	tee := &teeOutputCore{Core: zapcore.NewNopCore(), outputs: []zapcore.Core{
+		zapcore.NewNopCore(),
+		queuedCore{Core: zapcore.NewNopCore(), fakeQueue: 0.3},
+		queuedCore{Core: zapcore.NewNopCore(), fakeQueue: 0.6},
+	}}
+	assert.Equal(t, 0.6, tee.queueUsage())
+}
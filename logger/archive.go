@@ -0,0 +1,254 @@
+package logger
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/constructorvirgil/virlog/config"
+)
+
+const (
+	// archiveDefaultStore 默认的对象存储类型
+	archiveDefaultStore = "s3"
+	// archiveDefaultInterval 默认检查旧日志文件的间隔
+	archiveDefaultInterval = time.Minute
+	// archiveExpireInterval 清理过期归档文件的间隔
+	archiveExpireInterval = time.Hour
+	// archiveUploadTimeout 上传单个文件的超时时间
+	archiveUploadTimeout = 10 * time.Minute
+	// backupTimeFormat 切割后的旧日志文件名中的时间格式，与 lumberjack 一致
+	backupTimeFormat = "2006-01-02T15-04-05.000"
+)
+
+// ArchiveObject 对象存储中的归档文件
+type ArchiveObject struct {
+	Key          string
+	LastModified time.Time
+}
+
+// ArchiveStore 保存归档日志文件的对象存储
+type ArchiveStore interface {
+	// Upload 上传文件，body 可以重复读取（如计算签名）
+	Upload(ctx context.Context, key string, body io.ReadSeeker, size int64) error
+	// List 列出名称以 prefix 开头的对象
+	List(ctx context.Context, prefix string) ([]ArchiveObject, error)
+	// Delete 删除对象
+	Delete(ctx context.Context, key string) error
+}
+
+// ArchiveStoreFactory 根据归档配置创建对象存储
+type ArchiveStoreFactory func(cfg *config.ArchiveConfig) (ArchiveStore, error)
+
+var (
+	// 已注册的对象存储，内置兼容S3协议的存储
+	archiveStores = map[string]ArchiveStoreFactory{
+		archiveDefaultStore: newS3Store,
+	}
+	archiveStoresMu sync.RWMutex
+)
+
+// RegisterArchiveStore 注册对象存储，之后可以在 FileConfig.Archive.Store 中使用，重复注册同名存储会覆盖之前的注册
+func RegisterArchiveStore(name string, factory ArchiveStoreFactory) error {
+	if name == "" {
+		return fmt.Errorf("对象存储名称不能为空")
+	}
+	if factory == nil {
+		return fmt.Errorf("对象存储工厂不能为nil: %s", name)
+	}
+
+	archiveStoresMu.Lock()
+	defer archiveStoresMu.Unlock()
+	archiveStores[name] = factory
+	return nil
+}
+
+// UnregisterArchiveStore 注销对象存储
+func UnregisterArchiveStore(name string) {
+	archiveStoresMu.Lock()
+	defer archiveStoresMu.Unlock()
+	delete(archiveStores, name)
+}
+
+// archiver 定期上传切割后的旧日志文件，上传成功后删除本地文件，并清理对象存储中过期的归档文件
+type archiver struct {
+	filename  string
+	compress  bool
+	store     ArchiveStore
+	prefix    string
+	interval  time.Duration
+	retention time.Duration
+	log       config.InternalLogger
+
+	stop chan struct{}
+}
+
+// newArchiver 根据配置创建归档任务，由创建它的文件输出启动和停止
+func newArchiver(filename string, compress bool, ac *config.ArchiveConfig) (*archiver, error) {
+	if abs, err := filepath.Abs(filename); err == nil {
+		filename = abs
+	}
+	name := ac.Store
+	if name == "" {
+		name = archiveDefaultStore
+	}
+	archiveStoresMu.RLock()
+	factory, ok := archiveStores[name]
+	archiveStoresMu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("未注册的对象存储: %s", name)
+	}
+	store, err := factory(ac)
+	if err != nil {
+		return nil, fmt.Errorf("创建对象存储 %s 失败: %w", name, err)
+	}
+
+	interval := ac.Interval
+	if interval <= 0 {
+		interval = archiveDefaultInterval
+	}
+	return &archiver{
+		filename:  filename,
+		compress:  compress,
+		store:     store,
+		prefix:    ac.Prefix,
+		interval:  interval,
+		retention: time.Duration(ac.RetentionDays) * 24 * time.Hour,
+		log:       InternalLogger{Component: "archive"},
+		stop:      make(chan struct{}),
+	}, nil
+}
+
+// run 每隔 interval 上传旧日志文件，每隔 archiveExpireInterval 清理过期的归档文件
+func (a *archiver) run() {
+	ticker := time.NewTicker(a.interval)
+	defer ticker.Stop()
+
+	var lastExpire time.Time
+	for {
+		a.archive()
+		if a.retention > 0 && time.Since(lastExpire) >= archiveExpireInterval {
+			a.expire()
+			lastExpire = time.Now()
+		}
+		select {
+		case <-ticker.C:
+		case <-a.stop:
+			return
+		}
+	}
+}
+
+// close 停止归档任务，不等待正在进行的上传完成，避免重新创建Logger时阻塞
+func (a *archiver) close() {
+	close(a.stop)
+}
+
+// archive 上传所有旧日志文件，上传成功后删除本地文件
+func (a *archiver) archive() {
+	files, err := a.backups()
+	if err != nil {
+		a.log.Errorw("读取旧日志文件失败", "file", a.filename, "error", err)
+		return
+	}
+	for _, path := range files {
+		if err := a.upload(path); err != nil {
+			// 配置变更后新旧Logger的归档任务短暂并存，文件可能已被另一个任务上传并删除
+			if os.IsNotExist(err) {
+				continue
+			}
+			a.log.Errorw("上传旧日志文件失败", "file", path, "error", err)
+			continue
+		}
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			a.log.Errorw("删除已归档的日志文件失败", "file", path, "error", err)
+		}
+	}
+}
+
+// upload 上传一个文件
+func (a *archiver) upload(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), archiveUploadTimeout)
+	defer cancel()
+	return a.store.Upload(ctx, a.prefix+filepath.Base(path), f, info.Size())
+}
+
+// expire 删除对象存储中超过保留时间的归档文件
+func (a *archiver) expire() {
+	ctx, cancel := context.WithTimeout(context.Background(), archiveUploadTimeout)
+	defer cancel()
+	objects, err := a.store.List(ctx, a.prefix)
+	if err != nil {
+		a.log.Errorw("列出归档文件失败", "prefix", a.prefix, "error", err)
+		return
+	}
+	deadline := time.Now().Add(-a.retention)
+	for _, obj := range objects {
+		if !a.isBackup(strings.TrimPrefix(obj.Key, a.prefix)) || !obj.LastModified.Before(deadline) {
+			continue
+		}
+		if err := a.store.Delete(ctx, obj.Key); err != nil {
+			a.log.Errorw("删除过期归档文件失败", "key", obj.Key, "error", err)
+		}
+	}
+}
+
+// backups 返回可以上传的旧日志文件
+//
+// 开启压缩时只上传压缩完成的 .gz 文件：lumberjack 压缩完成后才删除未压缩的文件，
+// 未压缩的文件仍存在时说明压缩尚未完成
+func (a *archiver) backups() ([]string, error) {
+	entries, err := os.ReadDir(filepath.Dir(a.filename))
+	if err != nil {
+		return nil, err
+	}
+	names := make(map[string]bool, len(entries))
+	for _, e := range entries {
+		names[e.Name()] = true
+	}
+
+	var files []string
+	for _, e := range entries {
+		name := e.Name()
+		if e.IsDir() || !a.isBackup(name) {
+			continue
+		}
+		if a.compress {
+			plain, ok := strings.CutSuffix(name, ".gz")
+			if !ok || names[plain] {
+				continue
+			}
+		}
+		files = append(files, filepath.Join(filepath.Dir(a.filename), name))
+	}
+	return files, nil
+}
+
+// isBackup 判断文件名是否为 lumberjack 切割后的旧日志文件，如 app-2024-06-01T10-00-00.000.log(.gz)
+func (a *archiver) isBackup(name string) bool {
+	base := filepath.Base(a.filename)
+	ext := filepath.Ext(base)
+	prefix := strings.TrimSuffix(base, ext) + "-"
+
+	name = strings.TrimSuffix(name, ".gz")
+	if !strings.HasPrefix(name, prefix) || !strings.HasSuffix(name, ext) || len(name) < len(prefix)+len(ext) {
+		return false
+	}
+	_, err := time.Parse(backupTimeFormat, name[len(prefix):len(name)-len(ext)])
+	return err == nil
+}
@@ -0,0 +1,254 @@
+// Package audit 提供防篡改的审计日志
+//
+// 每条审计记录带有单调递增的序号，并使用HMAC将当前记录与上一条记录串联，
+// 任何一条记录被修改、删除或重排都会导致后续校验失败
+package audit
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/constructorvirgil/virlog/logger"
+
+	"go.uber.org/zap/zapcore"
+)
+
+var (
+	// ErrEmptyKey HMAC密钥为空
+	ErrEmptyKey = errors.New("审计日志HMAC密钥不能为空")
+	// ErrClosed 审计日志已关闭
+	ErrClosed = errors.New("审计日志已关闭")
+)
+
+// Record 一条审计记录
+type Record struct {
+	// 序号，从1开始连续递增
+	Seq uint64 `json:"seq"`
+	// 记录时间
+	Time time.Time `json:"time"`
+	// 事件名称
+	Event string `json:"event"`
+	// 结构化字段
+	Fields map[string]interface{} `json:"fields,omitempty"`
+	// 上一条记录的HMAC，第一条记录为空
+	PrevHMAC string `json:"prev_hmac"`
+	// 当前记录的HMAC
+	HMAC string `json:"hmac"`
+}
+
+// Checkpoint 审计链的检查点，保存在审计日志之外可用于检测尾部截断
+type Checkpoint struct {
+	// 最后一条记录的序号
+	Seq uint64 `json:"seq"`
+	// 最后一条记录的HMAC
+	HMAC string `json:"hmac"`
+}
+
+// AuditLogger 审计日志记录器
+type AuditLogger struct {
+	out    zapcore.WriteSyncer
+	key    []byte
+	seq    uint64
+	last   string
+	now    func() time.Time
+	closer func() error
+	closed bool
+	mu     sync.Mutex
+}
+
+// Option 定义审计日志选项的函数类型
+type Option func(*AuditLogger)
+
+// WithCheckpoint 从指定检查点继续审计链，用于向已有的审计日志追加记录
+func WithCheckpoint(cp Checkpoint) Option {
+	return func(a *AuditLogger) {
+		a.seq = cp.Seq
+		a.last = cp.HMAC
+	}
+}
+
+// WithClock 设置时间来源，主要用于测试
+func WithClock(now func() time.Time) Option {
+	return func(a *AuditLogger) {
+		a.now = now
+	}
+}
+
+// New 创建写入指定输出的审计日志记录器
+func New(out zapcore.WriteSyncer, key []byte, opts ...Option) (*AuditLogger, error) {
+	if len(key) == 0 {
+		return nil, ErrEmptyKey
+	}
+
+	a := &AuditLogger{
+		out: out,
+		key: append([]byte(nil), key...),
+		now: time.Now,
+	}
+
+	for _, opt := range opts {
+		opt(a)
+	}
+
+	return a, nil
+}
+
+// Open 以追加模式打开审计日志文件
+//
+// 如果文件已存在，会先校验已有记录并从最后一条记录继续审计链，
+// 已有记录校验失败时返回错误，避免在被篡改的日志上继续追加
+func Open(filename string, key []byte, opts ...Option) (*AuditLogger, error) {
+	if len(key) == 0 {
+		return nil, ErrEmptyKey
+	}
+
+	var cp Checkpoint
+	if existing, err := os.Open(filename); err == nil {
+		result, verr := Verify(existing, key)
+		existing.Close()
+		if verr != nil {
+			return nil, fmt.Errorf("校验已有审计日志失败: %w", verr)
+		}
+		cp = result.Checkpoint
+	} else if !os.IsNotExist(err) {
+		return nil, fmt.Errorf("打开审计日志失败: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(filename), 0755); err != nil {
+		return nil, fmt.Errorf("创建目录失败: %w", err)
+	}
+
+	file, err := os.OpenFile(filename, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("打开审计日志失败: %w", err)
+	}
+
+	a, err := New(zapcore.AddSync(file), key, append([]Option{WithCheckpoint(cp)}, opts...)...)
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	a.closer = file.Close
+
+	return a, nil
+}
+
+// Log 写入一条审计记录，写入成功后立即同步到输出
+func (a *AuditLogger) Log(event string, fields ...logger.Field) error {
+	enc := zapcore.NewMapObjectEncoder()
+	for _, f := range fields {
+		f.AddTo(enc)
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.closed {
+		return ErrClosed
+	}
+
+	record := Record{
+		Seq:      a.seq + 1,
+		Time:     a.now().UTC(),
+		Event:    event,
+		PrevHMAC: a.last,
+	}
+	if len(enc.Fields) > 0 {
+		record.Fields = enc.Fields
+	}
+
+	// 字段先经过一次JSON往返，保证写入的内容与校验时解析出的内容一致
+	if err := normalizeFields(&record); err != nil {
+		return err
+	}
+
+	mac, err := computeHMAC(a.key, &record)
+	if err != nil {
+		return err
+	}
+	record.HMAC = mac
+
+	line, err := json.Marshal(&record)
+	if err != nil {
+		return fmt.Errorf("序列化审计记录失败: %w", err)
+	}
+	line = append(line, '\n')
+
+	if _, err := a.out.Write(line); err != nil {
+		return fmt.Errorf("写入审计记录失败: %w", err)
+	}
+	if err := a.out.Sync(); err != nil {
+		return fmt.Errorf("同步审计记录失败: %w", err)
+	}
+
+	a.seq = record.Seq
+	a.last = record.HMAC
+
+	return nil
+}
+
+// Checkpoint 返回当前审计链的检查点
+func (a *AuditLogger) Checkpoint() Checkpoint {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return Checkpoint{Seq: a.seq, HMAC: a.last}
+}
+
+// Close 关闭审计日志，由 Open 打开的文件会被关闭
+func (a *AuditLogger) Close() error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.closed {
+		return nil
+	}
+	a.closed = true
+
+	if err := a.out.Sync(); err != nil {
+		return err
+	}
+	if a.closer != nil {
+		return a.closer()
+	}
+	return nil
+}
+
+// normalizeFields 将字段规范化为JSON可表示的形式
+func normalizeFields(record *Record) error {
+	if record.Fields == nil {
+		return nil
+	}
+	data, err := json.Marshal(record.Fields)
+	if err != nil {
+		return fmt.Errorf("序列化审计字段失败: %w", err)
+	}
+	fields := make(map[string]interface{})
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return fmt.Errorf("解析审计字段失败: %w", err)
+	}
+	record.Fields = fields
+	return nil
+}
+
+// computeHMAC 计算记录的HMAC，计算时不包含记录自身的HMAC字段
+func computeHMAC(key []byte, record *Record) (string, error) {
+	unsigned := *record
+	unsigned.HMAC = ""
+
+	payload, err := json.Marshal(&unsigned)
+	if err != nil {
+		return "", fmt.Errorf("序列化审计记录失败: %w", err)
+	}
+
+	h := hmac.New(sha256.New, key)
+	h.Write(payload)
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
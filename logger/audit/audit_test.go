@@ -0,0 +1,113 @@
+package audit
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/constructorvirgil/virlog/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zapcore"
+)
+
+var testKey = []byte("test-audit-key")
+
+// 写入若干条审计记录
+func writeRecords(t *testing.T, buf *bytes.Buffer, n int) *AuditLogger {
+	a, err := New(zapcore.AddSync(buf), testKey)
+	require.NoError(t, err)
+	for i := 0; i < n; i++ {
+		require.NoError(t, a.Log("user.login",
+			logger.String("user_id", "u-1"),
+			logger.Int("attempt", i),
+			logger.Duration("elapsed", time.Millisecond)))
+	}
+	return a
+}
+
+// 测试正常写入与校验
+func TestAuditLogAndVerify(t *testing.T) {
+	buf := &bytes.Buffer{}
+	a := writeRecords(t, buf, 5)
+
+	result, err := Verify(bytes.NewReader(buf.Bytes()), testKey)
+	require.NoError(t, err)
+	assert.Equal(t, 5, result.Count)
+	assert.Equal(t, a.Checkpoint(), result.Checkpoint)
+	assert.Equal(t, uint64(5), result.Checkpoint.Seq)
+
+	_, err = VerifyCheckpoint(bytes.NewReader(buf.Bytes()), testKey, a.Checkpoint())
+	assert.NoError(t, err)
+
+	// 密钥错误
+	_, err = Verify(bytes.NewReader(buf.Bytes()), []byte("wrong"))
+	assert.ErrorIs(t, err, ErrTampered)
+}
+
+// 测试篡改检测
+func TestAuditDetectTampering(t *testing.T) {
+	buf := &bytes.Buffer{}
+	a := writeRecords(t, buf, 5)
+	lines := strings.SplitAfter(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	require.Len(t, lines, 5)
+
+	// 修改记录内容
+	modified := strings.Join(lines[:2], "") + strings.Replace(lines[2], "u-1", "u-2", 1) + strings.Join(lines[3:], "")
+	_, err := Verify(strings.NewReader(modified), testKey)
+	assert.ErrorIs(t, err, ErrTampered)
+
+	// 删除中间记录
+	removed := strings.Join(lines[:2], "") + strings.Join(lines[3:], "")
+	_, err = Verify(strings.NewReader(removed), testKey)
+	assert.ErrorIs(t, err, ErrSequenceGap)
+
+	// 删除头部记录
+	_, err = Verify(strings.NewReader(strings.Join(lines[1:], "")), testKey)
+	assert.ErrorIs(t, err, ErrTruncated)
+
+	// 尾部截断只能通过检查点发现
+	truncated := strings.Join(lines[:4], "")
+	_, err = Verify(strings.NewReader(truncated), testKey)
+	assert.NoError(t, err)
+	_, err = VerifyCheckpoint(strings.NewReader(truncated), testKey, a.Checkpoint())
+	assert.ErrorIs(t, err, ErrTruncated)
+}
+
+// 测试以追加模式打开审计日志文件
+func TestAuditOpenAppend(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "audit", "audit.log")
+
+	a, err := Open(filename, testKey)
+	require.NoError(t, err)
+	require.NoError(t, a.Log("first"))
+	require.NoError(t, a.Close())
+	assert.ErrorIs(t, a.Log("after close"), ErrClosed)
+
+	// 重新打开后继续审计链
+	a, err = Open(filename, testKey)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(1), a.Checkpoint().Seq)
+	require.NoError(t, a.Log("second"))
+	require.NoError(t, a.Close())
+
+	f, err := os.Open(filename)
+	require.NoError(t, err)
+	defer f.Close()
+	result, err := Verify(f, testKey)
+	require.NoError(t, err)
+	assert.Equal(t, 2, result.Count)
+
+	// 篡改后拒绝继续追加
+	content, err := os.ReadFile(filename)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filename, bytes.Replace(content, []byte("first"), []byte("frist"), 1), 0600))
+	_, err = Open(filename, testKey)
+	assert.ErrorIs(t, err, ErrTampered)
+
+	_, err = New(zapcore.AddSync(&bytes.Buffer{}), nil)
+	assert.ErrorIs(t, err, ErrEmptyKey)
+}
@@ -0,0 +1,116 @@
+package audit
+
+import (
+	"bufio"
+	"crypto/hmac"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+)
+
+var (
+	// ErrTampered 审计记录被修改
+	ErrTampered = errors.New("审计记录已被篡改")
+	// ErrSequenceGap 审计记录序号不连续，记录被删除或重排
+	ErrSequenceGap = errors.New("审计记录序号不连续")
+	// ErrTruncated 审计日志被截断
+	ErrTruncated = errors.New("审计日志已被截断")
+)
+
+// VerifyResult 审计日志的校验结果
+type VerifyResult struct {
+	// 校验通过的记录数
+	Count int
+	// 最后一条记录对应的检查点
+	Checkpoint Checkpoint
+}
+
+// Verify 校验审计日志的完整性
+//
+// 校验内容包括每条记录的HMAC、序号连续性以及HMAC链，
+// 返回的检查点可与外部保存的检查点比较以发现尾部截断（见 VerifyCheckpoint）
+func Verify(r io.Reader, key []byte) (VerifyResult, error) {
+	return verify(r, key, nil)
+}
+
+// verify 校验审计日志，每条校验通过的记录都会传给visit
+func verify(r io.Reader, key []byte, visit func(*Record)) (VerifyResult, error) {
+	var result VerifyResult
+
+	if len(key) == 0 {
+		return result, ErrEmptyKey
+	}
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+
+	line := 0
+	for scanner.Scan() {
+		line++
+		data := scanner.Bytes()
+		if len(data) == 0 {
+			continue
+		}
+
+		var record Record
+		if err := json.Unmarshal(data, &record); err != nil {
+			return result, fmt.Errorf("第%d行: %w: 无法解析记录: %v", line, ErrTampered, err)
+		}
+
+		expectedSeq := result.Checkpoint.Seq + 1
+		if record.Seq != expectedSeq {
+			if result.Count == 0 && record.Seq > 1 {
+				return result, fmt.Errorf("第%d行: %w: 首条记录序号为%d", line, ErrTruncated, record.Seq)
+			}
+			return result, fmt.Errorf("第%d行: %w: 期望%d，实际%d", line, ErrSequenceGap, expectedSeq, record.Seq)
+		}
+
+		if record.PrevHMAC != result.Checkpoint.HMAC {
+			return result, fmt.Errorf("第%d行: %w: HMAC链断裂", line, ErrTampered)
+		}
+
+		mac, err := computeHMAC(key, &record)
+		if err != nil {
+			return result, err
+		}
+		if !hmac.Equal([]byte(mac), []byte(record.HMAC)) {
+			return result, fmt.Errorf("第%d行: %w: HMAC不匹配", line, ErrTampered)
+		}
+
+		if visit != nil {
+			visit(&record)
+		}
+
+		result.Count++
+		result.Checkpoint = Checkpoint{Seq: record.Seq, HMAC: record.HMAC}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return result, fmt.Errorf("读取审计日志失败: %w", err)
+	}
+
+	return result, nil
+}
+
+// VerifyCheckpoint 校验审计日志，并确认日志至少包含到指定检查点为止的全部记录
+func VerifyCheckpoint(r io.Reader, key []byte, cp Checkpoint) (VerifyResult, error) {
+	var cpHMAC string
+	result, err := verify(r, key, func(record *Record) {
+		if record.Seq == cp.Seq {
+			cpHMAC = record.HMAC
+		}
+	})
+	if err != nil {
+		return result, err
+	}
+
+	if result.Checkpoint.Seq < cp.Seq {
+		return result, fmt.Errorf("%w: 期望至少%d条记录，实际%d条", ErrTruncated, cp.Seq, result.Checkpoint.Seq)
+	}
+	if cp.Seq > 0 && cpHMAC != cp.HMAC {
+		return result, fmt.Errorf("%w: 检查点HMAC不匹配", ErrTampered)
+	}
+
+	return result, nil
+}
@@ -0,0 +1,137 @@
+package logger
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// maxBinaryEntrySize 单条protobuf日志的最大长度，避免损坏的长度前缀导致分配过多内存
+const maxBinaryEntrySize = 64 << 20
+
+// BinaryDecoder 读取 msgpack 或 protobuf 格式的日志
+type BinaryDecoder struct {
+	r      *bufio.Reader
+	format string
+}
+
+// NewBinaryDecoder 创建读取 format（"msgpack" 或 "protobuf"）格式日志的解码器
+func NewBinaryDecoder(r io.Reader, format string) (*BinaryDecoder, error) {
+	switch format {
+	case "msgpack", "protobuf":
+	default:
+		return nil, fmt.Errorf("不支持的二进制日志格式: %s", format)
+	}
+	return &BinaryDecoder{r: bufio.NewReader(r), format: format}, nil
+}
+
+// Decode 读取下一条日志，字段名与JSON格式相同，时间为 time.Time；没有更多日志时返回 io.EOF
+//
+// protobuf 格式的日志字段与 time、level、logger、msg、caller、stacktrace 一起输出在同一层
+func (d *BinaryDecoder) Decode() (map[string]interface{}, error) {
+	if d.format == "msgpack" {
+		return d.decodeMsgpack()
+	}
+	return d.decodeProtobuf()
+}
+
+// decodeMsgpack 读取一条msgpack格式的日志
+func (d *BinaryDecoder) decodeMsgpack() (map[string]interface{}, error) {
+	v, err := readMsgpack(d.r)
+	if err != nil {
+		return nil, err
+	}
+	record, ok := v.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("msgpack日志应为map，实际为 %T", v)
+	}
+	return record, nil
+}
+
+// decodeProtobuf 读取一条带长度前缀的 LogEntry 消息
+func (d *BinaryDecoder) decodeProtobuf() (map[string]interface{}, error) {
+	size, err := binary.ReadUvarint(d.r)
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, io.EOF
+		}
+		return nil, err
+	}
+	if size > maxBinaryEntrySize {
+		return nil, fmt.Errorf("protobuf日志长度无效: %d", size)
+	}
+	msg := make([]byte, size)
+	if _, err := io.ReadFull(d.r, msg); err != nil {
+		if errors.Is(err, io.EOF) {
+			err = io.ErrUnexpectedEOF
+		}
+		return nil, err
+	}
+
+	record := make(map[string]interface{})
+	var fields map[string]interface{}
+	for len(msg) > 0 {
+		num, typ, n := protowire.ConsumeTag(msg)
+		if n < 0 {
+			return nil, protowire.ParseError(n)
+		}
+		msg = msg[n:]
+
+		switch {
+		case num == logEntryTime && typ == protowire.VarintType:
+			v, n := protowire.ConsumeVarint(msg)
+			if n < 0 {
+				return nil, protowire.ParseError(n)
+			}
+			record["time"] = time.Unix(0, int64(v))
+			msg = msg[n:]
+		case num == logEntryFields && typ == protowire.BytesType:
+			b, n := protowire.ConsumeBytes(msg)
+			if n < 0 {
+				return nil, protowire.ParseError(n)
+			}
+			var s structpb.Struct
+			if err := proto.Unmarshal(b, &s); err != nil {
+				return nil, fmt.Errorf("解析日志字段失败: %w", err)
+			}
+			fields = s.AsMap()
+			msg = msg[n:]
+		case typ == protowire.BytesType && num >= logEntryLevel && num <= logEntryStacktrace:
+			s, n := protowire.ConsumeString(msg)
+			if n < 0 {
+				return nil, protowire.ParseError(n)
+			}
+			record[logEntryKeys[num]] = s
+			msg = msg[n:]
+		default:
+			// 忽略新版本中增加的字段
+			n := protowire.ConsumeFieldValue(num, typ, msg)
+			if n < 0 {
+				return nil, protowire.ParseError(n)
+			}
+			msg = msg[n:]
+		}
+	}
+	for k, v := range fields {
+		if _, ok := record[k]; !ok {
+			record[k] = v
+		}
+	}
+	return record, nil
+}
+
+// logEntryKeys LogEntry 字符串字段解码后的字段名
+var logEntryKeys = map[protowire.Number]string{
+	logEntryLevel:      "level",
+	logEntryLogger:     "logger",
+	logEntryMessage:    "msg",
+	logEntryCaller:     "caller",
+	logEntryStacktrace: "stacktrace",
+}
@@ -0,0 +1,115 @@
+package logger
+
+import (
+	"sync"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// logBudgetExceededMessage 超出日志预算时汇总日志的消息
+const logBudgetExceededMessage = "log_budget_exceeded"
+
+// LogBudget 限制一个请求内输出的日志条数，防止单个请求产生大量日志
+//
+// 通过 Budget 选项应用到Logger后，该Logger及其派生的Logger共享同一预算，
+// 超出预算的日志被丢弃并计数，最后由 Summarize 输出一条汇总日志。
+// Error及以上级别的日志计入条数但不会被丢弃
+type LogBudget struct {
+	max int
+
+	mu      sync.Mutex
+	used    int
+	dropped map[zapcore.Level]int
+}
+
+// NewLogBudget 创建最多允许输出 max 条日志的预算
+func NewLogBudget(max int) *LogBudget {
+	return &LogBudget{max: max}
+}
+
+// Budget 使Logger及其派生的Logger受预算 b 限制，b 为nil时不生效
+func Budget(b *LogBudget) Option {
+	return func(l *zapLogger) {
+		l.budget = b
+	}
+}
+
+// allow 记录一条日志，超出预算时返回false
+func (b *LogBudget) allow(level zapcore.Level) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.used++
+	if b.used <= b.max || level >= ErrorLevel {
+		return true
+	}
+	if b.dropped == nil {
+		b.dropped = make(map[zapcore.Level]int)
+	}
+	b.dropped[level]++
+	return false
+}
+
+// Dropped 返回因超出预算被丢弃的日志条数
+func (b *LogBudget) Dropped() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	n := 0
+	for _, count := range b.dropped {
+		n += count
+	}
+	return n
+}
+
+// Summarize 存在被丢弃的日志时，使用 log 以Warn级别输出一条 log_budget_exceeded 日志，
+// 记录预算、被丢弃的总条数和各级别的条数
+//
+// log 应为应用预算之前的Logger，否则汇总日志本身也会被丢弃
+func (b *LogBudget) Summarize(log Logger) {
+	b.mu.Lock()
+	total := 0
+	var byLevel []Field
+	for level := TraceLevel; level < ErrorLevel; level++ {
+		if n := b.dropped[level]; n > 0 {
+			total += n
+			byLevel = append(byLevel, Int(levelName(level), n))
+		}
+	}
+	b.mu.Unlock()
+
+	if total == 0 {
+		return
+	}
+	log.Warn(logBudgetExceededMessage,
+		Int("log_budget", b.max),
+		Int("dropped", total),
+		Dict("dropped_by_level", byLevel...),
+	)
+}
+
+// budgetOption 返回按预算丢弃日志的选项
+func budgetOption(b *LogBudget) zap.Option {
+	return zap.WrapCore(func(core zapcore.Core) zapcore.Core {
+		return &budgetCore{Core: core, budget: b}
+	})
+}
+
+// budgetCore 在最外层按预算丢弃日志
+type budgetCore struct {
+	zapcore.Core
+	budget *LogBudget
+}
+
+// With 实现zapcore.Core接口
+func (c *budgetCore) With(fields []Field) zapcore.Core {
+	return &budgetCore{Core: c.Core.With(fields), budget: c.budget}
+}
+
+// Check 实现zapcore.Core接口，只有会输出的日志才计入预算
+func (c *budgetCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	checked := c.Core.Check(ent, ce)
+	if checked == ce || c.budget.allow(ent.Level) {
+		return checked
+	}
+	return ce
+}
@@ -0,0 +1,73 @@
+package logger
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// 测试超出预算的日志被丢弃并汇总
+func TestLogBudget(t *testing.T) {
+	base, buf := newBufferLogger(InfoLevel)
+	budget := NewLogBudget(2)
+	log := base.WithOptions(Budget(budget))
+	derived := log.With(String("component", "db"))
+
+	log.Info("first")
+	derived.Info("second")
+	log.Debug("disabled")
+	log.Info("dropped 1")
+	derived.Warn("dropped 2")
+	log.Error("always kept")
+	base.Info("not budgeted")
+
+	out := buf.String()
+	assert.Contains(t, out, "first")
+	assert.Contains(t, out, "second")
+	assert.NotContains(t, out, "dropped")
+	assert.Contains(t, out, "always kept")
+	assert.Contains(t, out, "not budgeted")
+	assert.Equal(t, 2, budget.Dropped())
+
+	budget.Summarize(base)
+	entry := findLogEntry(t, buf.String(), "log_budget_exceeded")
+	require.NotNil(t, entry)
+	assert.Equal(t, "warn", entry["level"])
+	assert.Equal(t, float64(2), entry["log_budget"])
+	assert.Equal(t, float64(2), entry["dropped"])
+	assert.Equal(t, map[string]interface{}{"info": float64(1), "warn": float64(1)}, entry["dropped_by_level"])
+
+	// 没有丢弃日志时不输出汇总
+	buf.Reset()
+	NewLogBudget(1).Summarize(base)
+	assert.Empty(t, buf.String())
+}
+
+// 测试中间件为每个请求设置独立的日志预算
+func TestHTTPMiddlewareLogBudget(t *testing.T) {
+	log, buf := newBufferLogger(InfoLevel)
+	handler := HTTPMiddleware(log, WithLogBudget(3))(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		reqLog := GetLoggerFromContext(r.Context())
+		for i := 0; i < 10; i++ {
+			reqLog.Info("noisy")
+		}
+	}))
+
+	for i := 0; i < 2; i++ {
+		buf.Reset()
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/api", nil))
+
+		out := buf.String()
+		assert.Equal(t, 3, strings.Count(out, `"msg":"noisy"`))
+		assert.Contains(t, out, "HTTP request started")
+		assert.Contains(t, out, "HTTP request completed")
+		entry := findLogEntry(t, out, "log_budget_exceeded")
+		require.NotNil(t, entry)
+		assert.Equal(t, float64(7), entry["dropped"])
+		assert.NotEmpty(t, entry["request_id"])
+	}
+}
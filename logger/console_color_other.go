@@ -0,0 +1,10 @@
+//go:build !windows
+
+package logger
+
+import "os"
+
+// enableVirtualTerminal 类Unix系统的终端都支持ANSI转义序列
+func enableVirtualTerminal(*os.File) bool {
+	return true
+}
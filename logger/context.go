@@ -0,0 +1,33 @@
+package logger
+
+import "context"
+
+// loggerContextKey 上下文中Logger的key，HTTPMiddleware 和 context 包使用同一个key
+type loggerContextKey struct{}
+
+// NewContext 返回保存了 log 的上下文
+//
+// 通常使用 context 包的 SaveToContext 和 GetFromContext，它们与 HTTPMiddleware 读写同一个Logger
+func NewContext(ctx context.Context, log Logger) context.Context {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return context.WithValue(ctx, loggerContextKey{}, log)
+}
+
+// FromContext 返回上下文中保存的Logger，没有时返回false
+func FromContext(ctx context.Context) (Logger, bool) {
+	if ctx == nil {
+		return nil, false
+	}
+	log, ok := ctx.Value(loggerContextKey{}).(Logger)
+	return log, ok
+}
+
+// contextLogger 返回上下文中保存的Logger，没有时返回默认Logger
+func contextLogger(ctx context.Context) Logger {
+	if log, ok := FromContext(ctx); ok {
+		return log
+	}
+	return DefaultLogger()
+}
@@ -0,0 +1,25 @@
+package logger
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// 测试在上下文中保存和读取Logger
+func TestNewContext(t *testing.T) {
+	log, _ := newBufferLogger(InfoLevel)
+
+	_, ok := FromContext(nil)
+	assert.False(t, ok)
+	_, ok = FromContext(context.Background())
+	assert.False(t, ok)
+
+	ctx := NewContext(nil, log)
+	saved, ok := FromContext(ctx)
+	assert.True(t, ok)
+	assert.Equal(t, Logger(log), saved)
+	assert.Equal(t, Logger(log), GetLoggerFromContext(ctx), "旧函数读取同一个Logger")
+	assert.Equal(t, DefaultLogger(), GetLoggerFromContext(context.Background()))
+}
@@ -0,0 +1,29 @@
+//go:build !virlog_nodebug
+
+package logger
+
+// debugCompiled 是否编译Debug和Trace日志，使用 virlog_nodebug 构建标签编译时为false
+const debugCompiled = true
+
+// DebugEnabled 返回默认Logger是否会输出Debug级别日志，
+// 用于在构造字段开销较大时跳过日志调用：
+//
+//	if logger.DebugEnabled() {
+//		logger.Debug("请求详情", logger.Any("body", dump(req)))
+//	}
+//
+// 使用 virlog_nodebug 构建标签编译时恒为false，编译器会移除整个代码块
+func DebugEnabled() bool {
+	return levelEnabled(DebugLevel)
+}
+
+// TraceEnabled 返回默认Logger是否会输出Trace级别日志，用法同 DebugEnabled
+func TraceEnabled() bool {
+	return levelEnabled(TraceLevel)
+}
+
+// levelEnabled 返回默认Logger是否会输出指定级别的日志
+func levelEnabled(level Level) bool {
+	raw := loadDefaults().std.GetRawZapLogger()
+	return raw != nil && raw.Core().Enabled(level)
+}
@@ -0,0 +1,16 @@
+//go:build virlog_nodebug
+
+package logger
+
+// debugCompiled 是否编译Debug和Trace日志，使用 virlog_nodebug 构建标签编译时为false
+const debugCompiled = false
+
+// DebugEnabled 使用 virlog_nodebug 构建标签编译时恒为false
+func DebugEnabled() bool {
+	return false
+}
+
+// TraceEnabled 使用 virlog_nodebug 构建标签编译时恒为false
+func TraceEnabled() bool {
+	return false
+}
@@ -0,0 +1,29 @@
+//go:build virlog_nodebug
+
+package logger
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// 测试使用 virlog_nodebug 构建标签时不输出Debug和Trace日志
+func TestDebugCompiledOut(t *testing.T) {
+	log, buf := newBufferLogger(TraceLevel)
+	old := DefaultLogger()
+	SetDefault(log)
+	defer SetDefault(old)
+
+	assert.False(t, DebugEnabled())
+	assert.False(t, TraceEnabled())
+
+	Debug("debug message")
+	Trace("trace message")
+	log.Debug("debug message")
+	log.Trace("trace message")
+	Info("info message")
+	assert.NotContains(t, buf.String(), "debug message")
+	assert.NotContains(t, buf.String(), "trace message")
+	assert.Contains(t, buf.String(), "info message")
+}
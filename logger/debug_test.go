@@ -0,0 +1,29 @@
+//go:build !virlog_nodebug
+
+package logger
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// 测试DebugEnabled和TraceEnabled跟随默认Logger的级别
+func TestDebugEnabled(t *testing.T) {
+	log, buf := newBufferLogger(InfoLevel)
+	old := DefaultLogger()
+	SetDefault(log)
+	defer SetDefault(old)
+
+	assert.False(t, DebugEnabled())
+	assert.False(t, TraceEnabled())
+
+	SetLevel(DebugLevel)
+	assert.True(t, DebugEnabled())
+	assert.False(t, TraceEnabled())
+
+	SetLevel(TraceLevel)
+	assert.True(t, TraceEnabled())
+	Trace("trace message")
+	assert.Contains(t, buf.String(), "trace message")
+}
@@ -0,0 +1,58 @@
+package logger
+
+import (
+	"reflect"
+	"sync"
+)
+
+// defaultFieldsCache 最近一次转换的 DefaultFields，配置未变化时重新创建Logger直接复用转换结果
+var defaultFieldsCache struct {
+	mu     sync.Mutex
+	source map[string]interface{}
+	fields []Field
+}
+
+// defaultFields 将配置中的 DefaultFields 转换为字段，规则同 Fields：嵌套的map输出为子对象，而不是字符串
+//
+// 返回的切片在调用方之间共享，不能修改
+func defaultFields(m map[string]interface{}) []Field {
+	if len(m) == 0 {
+		return nil
+	}
+
+	defaultFieldsCache.mu.Lock()
+	defer defaultFieldsCache.mu.Unlock()
+	if reflect.DeepEqual(defaultFieldsCache.source, m) {
+		return defaultFieldsCache.fields
+	}
+
+	fields := Fields(m)
+	defaultFieldsCache.source = copyDefaultFields(m)
+	defaultFieldsCache.fields = fields
+	return fields
+}
+
+// copyDefaultFields 复制 DefaultFields 及其中嵌套的map，避免调用方原地修改后命中过期的缓存
+func copyDefaultFields(m map[string]interface{}) map[string]interface{} {
+	copied := make(map[string]interface{}, len(m))
+	for k, v := range m {
+		copied[k] = copyDefaultValue(v)
+	}
+	return copied
+}
+
+// copyDefaultValue 复制嵌套的map，其他值原样返回
+func copyDefaultValue(v interface{}) interface{} {
+	switch val := v.(type) {
+	case map[string]interface{}:
+		return copyDefaultFields(val)
+	case map[interface{}]interface{}:
+		copied := make(map[interface{}]interface{}, len(val))
+		for k, item := range val {
+			copied[k] = copyDefaultValue(item)
+		}
+		return copied
+	default:
+		return v
+	}
+}
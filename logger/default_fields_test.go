@@ -0,0 +1,61 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/constructorvirgil/virlog/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zapcore"
+)
+
+// 测试嵌套的 DefaultFields 输出为子对象，配置未变化时复用转换结果
+func TestDefaultFields(t *testing.T) {
+	buf := &bytes.Buffer{}
+	cfg := config.DefaultConfig()
+	cfg.Format = "json"
+	cfg.DefaultFields = map[string]interface{}{
+		"service": "api",
+		"replica": 3,
+		"deploy": map[string]interface{}{
+			"region": "cn-east",
+			"labels": map[interface{}]interface{}{"team": "infra"},
+		},
+	}
+
+	log, err := NewLogger(cfg, WithSyncTarget(zapcore.AddSync(buf)))
+	require.NoError(t, err)
+	log.Info("hello")
+
+	entry := make(map[string]interface{})
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &entry))
+	assert.Equal(t, "api", entry["service"])
+	assert.Equal(t, float64(3), entry["replica"])
+	assert.Equal(t, map[string]interface{}{
+		"region": "cn-east",
+		"labels": map[string]interface{}{"team": "infra"},
+	}, entry["deploy"])
+
+	// 相同的配置复用缓存的字段
+	first := defaultFields(cfg.DefaultFields)
+	same := defaultFields(map[string]interface{}{
+		"service": "api",
+		"replica": 3,
+		"deploy": map[string]interface{}{
+			"region": "cn-east",
+			"labels": map[interface{}]interface{}{"team": "infra"},
+		},
+	})
+	assert.Same(t, &first[0], &same[0])
+
+	// 原地修改嵌套的map后重新转换
+	cfg.DefaultFields["deploy"].(map[string]interface{})["region"] = "cn-north"
+	buf.Reset()
+	log, err = NewLogger(cfg, WithSyncTarget(zapcore.AddSync(buf)))
+	require.NoError(t, err)
+	log.Info("hello")
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &entry))
+	assert.Equal(t, "cn-north", entry["deploy"].(map[string]interface{})["region"])
+}
@@ -0,0 +1,103 @@
+package logger
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"go.uber.org/zap/buffer"
+	"go.uber.org/zap/zapcore"
+)
+
+// binaryBufferPool 二进制编码器使用的缓冲区池
+var binaryBufferPool = buffer.NewPool()
+
+// mapEncoder 将字段收集为map的编码器，供msgpack和protobuf编码器使用
+type mapEncoder struct {
+	*zapcore.MapObjectEncoder
+	// namespaces 通过 Namespace 打开的命名空间，之后的字段写入最内层的命名空间
+	namespaces []string
+}
+
+// newMapEncoder 创建空的mapEncoder
+func newMapEncoder() *mapEncoder {
+	return &mapEncoder{MapObjectEncoder: zapcore.NewMapObjectEncoder()}
+}
+
+// OpenNamespace 实现zapcore.ObjectEncoder接口
+func (m *mapEncoder) OpenNamespace(key string) {
+	m.MapObjectEncoder.OpenNamespace(key)
+	m.namespaces = append(m.namespaces[:len(m.namespaces):len(m.namespaces)], key)
+}
+
+// AddReflected 实现zapcore.ObjectEncoder接口，立即转换值，避免之后对原值的修改影响日志
+func (m *mapEncoder) AddReflected(key string, v interface{}) error {
+	return m.MapObjectEncoder.AddReflected(key, binaryValue(v))
+}
+
+// clone 复制已收集的字段，命名空间中的字段同样被复制，之后写入的字段不影响原编码器
+func (m *mapEncoder) clone() *mapEncoder {
+	c := newMapEncoder()
+	for k, v := range m.Fields {
+		c.Fields[k] = v
+	}
+	cur := m.Fields
+	for _, ns := range m.namespaces {
+		inner, _ := cur[ns].(map[string]interface{})
+		// OpenNamespace 会以空map替换同名的值，再逐个写回
+		c.MapObjectEncoder.OpenNamespace(ns)
+		for k, v := range inner {
+			_ = c.MapObjectEncoder.AddReflected(k, v)
+		}
+		cur = inner
+	}
+	c.namespaces = m.namespaces[:len(m.namespaces):len(m.namespaces)]
+	return c
+}
+
+// binaryValue 将字段值转换为二进制格式支持的类型：时间转换为RFC3339字符串，时长转换为纳秒，
+// 其他不支持的类型按JSON序列化后转换为map、切片或基本类型
+func binaryValue(v interface{}) interface{} {
+	switch val := v.(type) {
+	case nil, bool, string, []byte,
+		int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, uintptr, float32, float64:
+		return val
+	case time.Time:
+		return val.Format(time.RFC3339Nano)
+	case time.Duration:
+		return int64(val)
+	case map[string]interface{}:
+		m := make(map[string]interface{}, len(val))
+		for k, item := range val {
+			m[k] = binaryValue(item)
+		}
+		return m
+	case []interface{}:
+		arr := make([]interface{}, len(val))
+		for i, item := range val {
+			arr[i] = binaryValue(item)
+		}
+		return arr
+	case error:
+		return val.Error()
+	}
+
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	var decoded interface{}
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return fmt.Sprint(v)
+	}
+	return decoded
+}
+
+// entryFields 返回日志的字段，按 binaryValue 转换
+func (m *mapEncoder) entryFields(fields []zapcore.Field) map[string]interface{} {
+	final := m.clone()
+	for _, f := range fields {
+		f.AddTo(final)
+	}
+	return binaryValue(final.Fields).(map[string]interface{})
+}
@@ -0,0 +1,87 @@
+package logger
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/constructorvirgil/virlog/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// binaryPayload 测试二进制编码器使用的结构体
+type binaryPayload struct {
+	ID   int      `json:"id"`
+	Tags []string `json:"tags"`
+}
+
+// 测试二进制编码器输出的日志可以被 BinaryDecoder 解码
+func TestBinaryEncoders(t *testing.T) {
+	for _, format := range []string{"msgpack", "protobuf"} {
+		t.Run(format, func(t *testing.T) {
+			cfg := config.DefaultConfig()
+			cfg.Format = format
+			log, buf := newFormatLogger(t, cfg)
+
+			start := time.Now()
+			base := log.Named("api").With(String("request_id", "req-1"), Namespace("http"), Int("status", 200))
+			base.Info("first", String("path", "/users"), Duration("latency", time.Second), Err(errors.New("boom")))
+			log.Warn("second",
+				Dict("db", Int("rows", 3)),
+				Reflect("payload", binaryPayload{ID: 7, Tags: []string{"a"}}),
+				Float64("ratio", 0.5),
+				Bool("ok", true),
+			)
+
+			dec, err := NewBinaryDecoder(buf, format)
+			require.NoError(t, err)
+
+			first, err := dec.Decode()
+			require.NoError(t, err)
+			assert.Equal(t, "info", first["level"])
+			assert.Equal(t, "first", first["msg"])
+			assert.Equal(t, "api", first["logger"])
+			assert.Equal(t, "req-1", first["request_id"])
+			assert.WithinDuration(t, start, first["time"].(time.Time), time.Second)
+			http, ok := first["http"].(map[string]interface{})
+			require.True(t, ok, "%#v", first["http"])
+			assert.EqualValues(t, 200, http["status"])
+			assert.Equal(t, "/users", http["path"])
+			assert.EqualValues(t, time.Second, http["latency"])
+			assert.Equal(t, "boom", http["error"])
+
+			second, err := dec.Decode()
+			require.NoError(t, err)
+			assert.Equal(t, "warn", second["level"])
+			assert.EqualValues(t, 3, second["db"].(map[string]interface{})["rows"])
+			assert.EqualValues(t, 7, second["payload"].(map[string]interface{})["id"])
+			assert.Equal(t, []interface{}{"a"}, second["payload"].(map[string]interface{})["tags"])
+			assert.Equal(t, 0.5, second["ratio"])
+			assert.Equal(t, true, second["ok"])
+			assert.NotContains(t, second, "request_id")
+
+			_, err = dec.Decode()
+			assert.ErrorIs(t, err, io.EOF)
+		})
+	}
+
+	_, err := NewBinaryDecoder(nil, "json")
+	assert.Error(t, err)
+}
+
+// 测试读取被截断的protobuf日志时返回错误
+func TestBinaryDecoderTruncated(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.Format = "protobuf"
+	log, buf := newFormatLogger(t, cfg)
+	log.Info("truncated")
+
+	data := buf.Bytes()
+	dec, err := NewBinaryDecoder(bytes.NewReader(data[:len(data)-2]), "protobuf")
+	require.NoError(t, err)
+	_, err = dec.Decode()
+	assert.ErrorIs(t, err, io.ErrUnexpectedEOF)
+}
@@ -0,0 +1,127 @@
+package logger
+
+import (
+	"strconv"
+	"strings"
+
+	"go.uber.org/zap/buffer"
+	"go.uber.org/zap/zapcore"
+)
+
+// CEFHeader CEF日志头中的设备信息
+type CEFHeader struct {
+	// 设备厂商
+	DeviceVendor string
+	// 设备产品
+	DeviceProduct string
+	// 设备版本
+	DeviceVersion string
+}
+
+// cefEncoder Common Event Format编码器
+//
+// 输出格式为 CEF:0|厂商|产品|版本|签名ID|名称|严重级别|扩展字段，
+// 签名ID使用日志级别，名称使用日志消息，字段写入扩展部分
+type cefEncoder struct {
+	*kvEncoder
+	header CEFHeader
+}
+
+// NewCEFEncoder 创建CEF格式的编码器
+func NewCEFEncoder(cfg zapcore.EncoderConfig, header CEFHeader) zapcore.Encoder {
+	if header.DeviceVendor == "" {
+		header.DeviceVendor = "virlog"
+	}
+	if header.DeviceProduct == "" {
+		header.DeviceProduct = "virlog"
+	}
+	if header.DeviceVersion == "" {
+		header.DeviceVersion = "1.0"
+	}
+	return &cefEncoder{kvEncoder: newKVEncoder(&cfg, cefEscape, cefKey), header: header}
+}
+
+// Clone 实现zapcore.Encoder接口
+func (enc *cefEncoder) Clone() zapcore.Encoder {
+	return &cefEncoder{kvEncoder: enc.clone(), header: enc.header}
+}
+
+// EncodeEntry 实现zapcore.Encoder接口
+func (enc *cefEncoder) EncodeEntry(ent zapcore.Entry, fields []zapcore.Field) (*buffer.Buffer, error) {
+	ext := enc.cloneEmpty()
+
+	// rt 为CEF标准的事件时间字段，单位毫秒
+	ext.addRaw("rt", strconv.FormatInt(ent.Time.UnixMilli(), 10))
+	if ent.LoggerName != "" {
+		ext.AddString("cs1Label", "logger")
+		ext.AddString("cs1", ent.LoggerName)
+	}
+	if ent.Caller.Defined {
+		ext.AddString("cs2Label", "caller")
+		ext.AddString("cs2", ent.Caller.TrimmedPath())
+	}
+
+	ext.appendContext(enc.kvEncoder, fields)
+
+	if ent.Stack != "" {
+		ext.prefix = ""
+		ext.AddString("cs3Label", "stacktrace")
+		ext.AddString("cs3", ent.Stack)
+	}
+
+	final := kvBufferPool.Get()
+	final.AppendString("CEF:0|")
+	final.AppendString(cefHeaderEscape(enc.header.DeviceVendor))
+	final.AppendByte('|')
+	final.AppendString(cefHeaderEscape(enc.header.DeviceProduct))
+	final.AppendByte('|')
+	final.AppendString(cefHeaderEscape(enc.header.DeviceVersion))
+	final.AppendByte('|')
+	final.AppendString(cefHeaderEscape(levelName(ent.Level)))
+	final.AppendByte('|')
+	final.AppendString(cefHeaderEscape(ent.Message))
+	final.AppendByte('|')
+	final.AppendInt(int64(cefSeverity(ent.Level)))
+	final.AppendByte('|')
+	final.Write(ext.buf.Bytes())
+	ext.buf.Free()
+
+	if enc.cfg.LineEnding != "" {
+		final.AppendString(enc.cfg.LineEnding)
+	} else {
+		final.AppendString(zapcore.DefaultLineEnding)
+	}
+	return final, nil
+}
+
+// cefSeverity 将日志级别映射为CEF严重级别（0-10）
+func cefSeverity(level zapcore.Level) int {
+	switch level {
+	case DebugLevel:
+		return 1
+	case InfoLevel:
+		return 3
+	case WarnLevel:
+		return 5
+	case ErrorLevel:
+		return 7
+	case DPanicLevel:
+		return 8
+	case PanicLevel:
+		return 9
+	case FatalLevel:
+		return 10
+	default:
+		if level < DebugLevel {
+			return 0
+		}
+		return 10
+	}
+}
+
+var cefHeaderEscaper = strings.NewReplacer(`\`, `\\`, `|`, `\|`, "\n", " ", "\r", " ")
+
+// cefHeaderEscape 按CEF日志头规则转义
+func cefHeaderEscape(value string) string {
+	return cefHeaderEscaper.Replace(value)
+}
@@ -0,0 +1,166 @@
+package logger
+
+import (
+	"time"
+
+	"github.com/constructorvirgil/virlog/config"
+	"go.uber.org/zap/zapcore"
+)
+
+// applyEncoderConfig 按配置调整编码器的时间、时长、级别格式以及字段名
+func applyEncoderConfig(encoderConfig *zapcore.EncoderConfig, cfg *config.Config) {
+	ec := cfg.Encoder
+	if ec == nil {
+		return
+	}
+
+	if ec.TimeFormat != "" {
+		encoderConfig.EncodeTime = timeEncoder(ec.TimeFormat)
+	}
+	if ec.DurationFormat != "" {
+		encoderConfig.EncodeDuration = durationEncoder(ec.DurationFormat)
+	}
+	if ec.LevelFormat != "" {
+		encoderConfig.EncodeLevel = levelEncoder(ec.LevelFormat)
+	}
+
+	renameKey(&encoderConfig.TimeKey, ec.TimeKey)
+	renameKey(&encoderConfig.LevelKey, ec.LevelKey)
+	renameKey(&encoderConfig.NameKey, ec.NameKey)
+	renameKey(&encoderConfig.CallerKey, ec.CallerKey)
+	renameKey(&encoderConfig.MessageKey, ec.MessageKey)
+	renameKey(&encoderConfig.StacktraceKey, ec.StacktraceKey)
+}
+
+// mergeEncoderConfig 合并全局和单个输出的编码器配置，override 中不为空的选项优先
+func mergeEncoderConfig(base, override *config.EncoderConfig) *config.EncoderConfig {
+	merged := config.EncoderConfig{}
+	if base != nil {
+		merged = *base
+	}
+	mergeString(&merged.TimeFormat, override.TimeFormat)
+	mergeString(&merged.DurationFormat, override.DurationFormat)
+	mergeString(&merged.LevelFormat, override.LevelFormat)
+	mergeString(&merged.TimeKey, override.TimeKey)
+	mergeString(&merged.LevelKey, override.LevelKey)
+	mergeString(&merged.NameKey, override.NameKey)
+	mergeString(&merged.CallerKey, override.CallerKey)
+	mergeString(&merged.MessageKey, override.MessageKey)
+	mergeString(&merged.StacktraceKey, override.StacktraceKey)
+	return &merged
+}
+
+// mergeString value 不为空时覆盖 dst
+func mergeString(dst *string, value string) {
+	if value != "" {
+		*dst = value
+	}
+}
+
+// renameKey 修改字段名，"-" 表示不输出该字段
+func renameKey(key *string, name string) {
+	switch name {
+	case "":
+	case "-":
+		*key = zapcore.OmitKey
+	default:
+		*key = name
+	}
+}
+
+// timeEncoder 返回时间格式对应的编码函数，未知的格式作为Go时间布局
+func timeEncoder(format string) zapcore.TimeEncoder {
+	switch format {
+	case "iso8601":
+		return zapcore.ISO8601TimeEncoder
+	case "rfc3339":
+		return zapcore.RFC3339TimeEncoder
+	case "rfc3339nano":
+		return zapcore.RFC3339NanoTimeEncoder
+	case "epoch":
+		return zapcore.EpochTimeEncoder
+	case "epoch_millis":
+		return func(t time.Time, enc zapcore.PrimitiveArrayEncoder) {
+			enc.AppendInt64(t.UnixMilli())
+		}
+	case "epoch_nanos":
+		return zapcore.EpochNanosTimeEncoder
+	default:
+		return zapcore.TimeEncoderOfLayout(format)
+	}
+}
+
+// durationEncoder 返回时长格式对应的编码函数，未知的格式使用秒
+func durationEncoder(format string) zapcore.DurationEncoder {
+	switch format {
+	case "millis":
+		return zapcore.MillisDurationEncoder
+	case "nanos":
+		return zapcore.NanosDurationEncoder
+	case "string":
+		return zapcore.StringDurationEncoder
+	default:
+		return zapcore.SecondsDurationEncoder
+	}
+}
+
+// levelEncoder 返回级别格式对应的编码函数，未知的格式使用小写
+func levelEncoder(format string) zapcore.LevelEncoder {
+	switch format {
+	case "uppercase":
+		return capitalLevelEncoder
+	case "lowercase_color":
+		return lowercaseColorLevelEncoder
+	case "uppercase_color":
+		return capitalColorLevelEncoder
+	default:
+		return lowercaseLevelEncoder
+	}
+}
+
+// traceColor TraceLevel 在终端中的颜色（蓝色），zap为Debug使用品红色
+const traceColor = "\x1b[34m"
+
+// 支持 TraceLevel 的级别编码函数，zap的编码函数会将其输出为 Level(-2)
+var (
+	lowercaseLevelEncoder      = withTraceLevel(zapcore.LowercaseLevelEncoder, "trace")
+	capitalLevelEncoder        = withTraceLevel(zapcore.CapitalLevelEncoder, "TRACE")
+	lowercaseColorLevelEncoder = withTraceLevel(zapcore.LowercaseColorLevelEncoder, traceColor+"trace\x1b[0m")
+	capitalColorLevelEncoder   = withTraceLevel(zapcore.CapitalColorLevelEncoder, traceColor+"TRACE\x1b[0m")
+)
+
+// withTraceLevel 返回将 TraceLevel 输出为 name、其余级别使用 encode 的编码函数
+func withTraceLevel(encode zapcore.LevelEncoder, name string) zapcore.LevelEncoder {
+	return func(level zapcore.Level, enc zapcore.PrimitiveArrayEncoder) {
+		if level == TraceLevel {
+			enc.AppendString(name)
+			return
+		}
+		encode(level, enc)
+	}
+}
+
+// levelName 返回级别的小写名称
+func levelName(level zapcore.Level) string {
+	if level == TraceLevel {
+		return "trace"
+	}
+	return level.String()
+}
+
+// plainLevelEncoder 返回不带颜色的级别编码函数，用于写入文件等非终端输出
+func plainLevelEncoder(cfg *config.Config) zapcore.LevelEncoder {
+	format := ""
+	if cfg.Encoder != nil {
+		format = cfg.Encoder.LevelFormat
+	}
+	switch format {
+	case "lowercase", "lowercase_color":
+		return lowercaseLevelEncoder
+	case "":
+		if !cfg.Development {
+			return lowercaseLevelEncoder
+		}
+	}
+	return capitalLevelEncoder
+}
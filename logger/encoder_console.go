@@ -0,0 +1,550 @@
+package logger
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/constructorvirgil/virlog/config"
+	"go.uber.org/zap/buffer"
+	"go.uber.org/zap/zapcore"
+	"golang.org/x/term"
+	"golang.org/x/text/width"
+)
+
+const (
+	// colorReset 结束颜色的ANSI转义序列
+	colorReset = "\x1b[0m"
+	// truncatedSuffix 截断的值末尾追加的省略号
+	truncatedSuffix = "…"
+	// detailIndent 多行值的缩进
+	detailIndent = "    "
+
+	// 对齐时各列的宽度
+	consoleLevelWidth          = 5
+	consoleNameWidth           = 16
+	consoleCallerWidth         = 24
+	defaultConsoleMessageWidth = 40
+)
+
+// ConsoleTheme 控制台格式的颜色主题，每项为ANSI转义序列，为空时不着色
+type ConsoleTheme struct {
+	// 各级别的颜色，Fatal 同时用于 DPanic 和 Panic
+	Trace string
+	Debug string
+	Info  string
+	Warn  string
+	Error string
+	Fatal string
+	// 时间、Logger名称、调用者和消息的颜色
+	Time    string
+	Name    string
+	Caller  string
+	Message string
+	// 字段名的颜色
+	Key string
+	// 多行输出的错误详情的颜色
+	Detail string
+}
+
+var (
+	// consoleThemes 按名称注册的颜色主题
+	consoleThemes = map[string]ConsoleTheme{
+		"default": {
+			Trace: traceColor, Debug: "\x1b[35m", Info: "\x1b[34m", Warn: "\x1b[33m", Error: "\x1b[31m", Fatal: "\x1b[31m",
+			Time: "\x1b[90m", Caller: "\x1b[90m", Key: "\x1b[36m", Detail: "\x1b[31m",
+		},
+		// 深色背景的终端，使用高亮色
+		"dark": {
+			Trace: "\x1b[94m", Debug: "\x1b[95m", Info: "\x1b[92m", Warn: "\x1b[93m", Error: "\x1b[91m", Fatal: "\x1b[1;91m",
+			Time: "\x1b[37m", Name: "\x1b[96m", Caller: "\x1b[37m", Message: "\x1b[1m", Key: "\x1b[96m", Detail: "\x1b[91m",
+		},
+		// 浅色背景的终端，避免黄色、青色等难以辨认的颜色
+		"light": {
+			Trace: "\x1b[34m", Debug: "\x1b[35m", Info: "\x1b[32m", Warn: "\x1b[1;33m", Error: "\x1b[31m", Fatal: "\x1b[1;31m",
+			Time: "\x1b[90m", Name: "\x1b[34m", Caller: "\x1b[90m", Message: "\x1b[1m", Key: "\x1b[34m", Detail: "\x1b[31m",
+		},
+		"none": {},
+	}
+	consoleThemesMu sync.RWMutex
+)
+
+// RegisterConsoleTheme 注册颜色主题，之后可以在 Console.Theme 中使用，重复注册同名主题会覆盖之前的注册
+func RegisterConsoleTheme(name string, theme ConsoleTheme) error {
+	if name == "" {
+		return fmt.Errorf("颜色主题名称不能为空")
+	}
+	consoleThemesMu.Lock()
+	defer consoleThemesMu.Unlock()
+	consoleThemes[name] = theme
+	return nil
+}
+
+// lookupConsoleTheme 返回名称对应的颜色主题，未知的名称使用默认主题
+func lookupConsoleTheme(name string) ConsoleTheme {
+	consoleThemesMu.RLock()
+	defer consoleThemesMu.RUnlock()
+	if theme, ok := consoleThemes[name]; ok {
+		return theme
+	}
+	return consoleThemes["default"]
+}
+
+// consoleColorEnabled 判断写入 w 的控制台日志是否使用颜色
+//
+// "auto" 时仅在 w 为终端、未设置 NO_COLOR 环境变量且 TERM 不为 dumb 时使用颜色，
+// Windows控制台需要能开启ANSI转义序列处理
+func consoleColorEnabled(cc *config.ConsoleConfig, w zapcore.WriteSyncer) bool {
+	f, isFile := w.(*os.File)
+	switch cc.Color {
+	case "never":
+		return false
+	case "always":
+		if isFile {
+			enableVirtualTerminal(f)
+		}
+		return true
+	}
+	if os.Getenv("NO_COLOR") != "" || os.Getenv("TERM") == "dumb" {
+		return false
+	}
+	if !isFile || !term.IsTerminal(int(f.Fd())) {
+		return false
+	}
+	return enableVirtualTerminal(f)
+}
+
+// consoleOptions 控制台编码器的选项，由同一编码器复制出的编码器共用
+type consoleOptions struct {
+	theme           ConsoleTheme
+	encodeLevel     zapcore.LevelEncoder
+	fieldOrder      map[string]int
+	sortFields      bool
+	maxValueLength  int
+	multilineErrors bool
+	align           bool
+	messageWidth    int
+}
+
+// consolePair 控制台格式中的一个字段
+type consolePair struct {
+	key   string
+	value string
+	// quote 值为字符串，输出时按需加引号
+	quote bool
+}
+
+// consoleEncoder 支持颜色主题、字段排序、截断、多行错误和对齐列的控制台格式编码器
+//
+// 每条日志输出为一行：时间、级别、Logger名称、调用者、消息，之后是 key=value 形式的字段，
+// 嵌套对象和命名空间展开为以点号连接的key，数组和反射类型编码为JSON
+type consoleEncoder struct {
+	cfg    *zapcore.EncoderConfig
+	opts   *consoleOptions
+	pairs  []consolePair
+	prefix string
+}
+
+// newConsoleEncoder 按 cfg.Console 创建控制台格式编码器，colored 为false时不使用颜色
+func newConsoleEncoder(encoderConfig zapcore.EncoderConfig, cfg *config.Config, colored bool) zapcore.Encoder {
+	cc := cfg.Console
+	opts := &consoleOptions{
+		encodeLevel:     plainLevelEncoder(cfg),
+		fieldOrder:      make(map[string]int, len(cc.FieldOrder)),
+		sortFields:      cc.SortFields,
+		maxValueLength:  cc.MaxValueLength,
+		multilineErrors: cc.MultilineErrors,
+		align:           cc.AlignColumns,
+		messageWidth:    cc.MessageWidth,
+	}
+	if colored {
+		opts.theme = lookupConsoleTheme(cc.Theme)
+	}
+	for i, key := range cc.FieldOrder {
+		if _, ok := opts.fieldOrder[key]; !ok {
+			opts.fieldOrder[key] = i
+		}
+	}
+	if opts.messageWidth <= 0 {
+		opts.messageWidth = defaultConsoleMessageWidth
+	}
+	return &consoleEncoder{cfg: &encoderConfig, opts: opts}
+}
+
+// Clone 实现zapcore.Encoder接口
+func (enc *consoleEncoder) Clone() zapcore.Encoder {
+	return enc.clone()
+}
+
+func (enc *consoleEncoder) clone() *consoleEncoder {
+	return &consoleEncoder{
+		cfg:    enc.cfg,
+		opts:   enc.opts,
+		pairs:  append([]consolePair(nil), enc.pairs...),
+		prefix: enc.prefix,
+	}
+}
+
+// EncodeEntry 实现zapcore.Encoder接口
+func (enc *consoleEncoder) EncodeEntry(ent zapcore.Entry, fields []zapcore.Field) (*buffer.Buffer, error) {
+	line := enc.clone()
+	for i := range fields {
+		fields[i].AddTo(line)
+	}
+
+	buf := kvBufferPool.Get()
+	w := consoleWriter{buf: buf, align: enc.opts.align}
+	theme := enc.opts.theme
+
+	if enc.cfg.TimeKey != "" {
+		timeText := ent.Time.Format(time.RFC3339Nano)
+		if enc.cfg.EncodeTime != nil {
+			timeText = encodePrimitive(func(pae zapcore.PrimitiveArrayEncoder) {
+				enc.cfg.EncodeTime(ent.Time, pae)
+			}, timeText)
+		}
+		w.column(timeText, theme.Time, 0)
+	}
+	if enc.cfg.LevelKey != "" {
+		w.column(encodePrimitive(func(pae zapcore.PrimitiveArrayEncoder) {
+			enc.opts.encodeLevel(ent.Level, pae)
+		}, levelName(ent.Level)), theme.level(ent.Level), consoleLevelWidth)
+	}
+	if enc.cfg.NameKey != "" && (ent.LoggerName != "" || enc.opts.align) {
+		w.column(ent.LoggerName, theme.Name, consoleNameWidth)
+	}
+	if enc.cfg.CallerKey != "" && ent.Caller.Defined {
+		caller := ent.Caller.TrimmedPath()
+		if enc.cfg.EncodeCaller != nil {
+			caller = encodePrimitive(func(pae zapcore.PrimitiveArrayEncoder) {
+				enc.cfg.EncodeCaller(ent.Caller, pae)
+			}, caller)
+		}
+		w.column(caller, theme.Caller, consoleCallerWidth)
+	}
+
+	pairs, details := line.arrange()
+	if enc.cfg.MessageKey != "" {
+		messageWidth := 0
+		if len(pairs) > 0 {
+			messageWidth = enc.opts.messageWidth
+		}
+		w.column(ent.Message, theme.Message, messageWidth)
+	}
+
+	for i, p := range pairs {
+		if i == 0 {
+			w.separate()
+		} else {
+			buf.AppendByte(' ')
+		}
+		appendColored(buf, p.key, theme.Key)
+		buf.AppendByte('=')
+		buf.AppendString(enc.formatValue(p))
+	}
+
+	for _, p := range details {
+		buf.AppendString(zapcore.DefaultLineEnding)
+		appendColored(buf, detailIndent+p.key+":", theme.Key)
+		for _, l := range strings.Split(strings.TrimRight(p.value, "\n"), "\n") {
+			buf.AppendString(zapcore.DefaultLineEnding)
+			appendColored(buf, detailIndent+detailIndent+l, theme.Detail)
+		}
+	}
+
+	if ent.Stack != "" && enc.cfg.StacktraceKey != "" {
+		buf.AppendString(zapcore.DefaultLineEnding)
+		buf.AppendString(ent.Stack)
+	}
+
+	if enc.cfg.LineEnding != "" {
+		buf.AppendString(enc.cfg.LineEnding)
+	} else {
+		buf.AppendString(zapcore.DefaultLineEnding)
+	}
+	return buf, nil
+}
+
+// arrange 按 FieldOrder 和 SortFields 排列字段，开启多行错误时分离出包含换行的值
+func (enc *consoleEncoder) arrange() (pairs, details []consolePair) {
+	pairs = make([]consolePair, 0, len(enc.pairs))
+	for _, p := range enc.pairs {
+		if enc.opts.multilineErrors && strings.Contains(p.value, "\n") {
+			details = append(details, p)
+			continue
+		}
+		pairs = append(pairs, p)
+	}
+
+	order := enc.opts.fieldOrder
+	if len(order) == 0 && !enc.opts.sortFields {
+		return pairs, details
+	}
+	sort.SliceStable(pairs, func(i, j int) bool {
+		oi, iListed := order[pairs[i].key]
+		oj, jListed := order[pairs[j].key]
+		switch {
+		case iListed && jListed:
+			return oi < oj
+		case iListed != jListed:
+			return iListed
+		case enc.opts.sortFields:
+			return pairs[i].key < pairs[j].key
+		}
+		return false
+	})
+	return pairs, details
+}
+
+// formatValue 截断并转义字段值
+func (enc *consoleEncoder) formatValue(p consolePair) string {
+	value := truncateValue(p.value, enc.opts.maxValueLength)
+	if p.quote {
+		return logfmtEscape(value)
+	}
+	return value
+}
+
+// truncateValue 将超过 max 个字符的值截断并追加省略号，max 不大于0时不截断
+func truncateValue(value string, max int) string {
+	if max <= 0 || utf8.RuneCountInString(value) <= max {
+		return value
+	}
+	n := 0
+	for i := range value {
+		if n == max {
+			return value[:i] + truncatedSuffix
+		}
+		n++
+	}
+	return value
+}
+
+// level 返回级别的颜色
+func (t ConsoleTheme) level(level zapcore.Level) string {
+	switch {
+	case level <= TraceLevel:
+		return t.Trace
+	case level == zapcore.DebugLevel:
+		return t.Debug
+	case level == zapcore.InfoLevel:
+		return t.Info
+	case level == zapcore.WarnLevel:
+		return t.Warn
+	case level == zapcore.ErrorLevel:
+		return t.Error
+	default:
+		return t.Fatal
+	}
+}
+
+// consoleWriter 写入日志行开头的各列
+type consoleWriter struct {
+	buf   *buffer.Buffer
+	align bool
+	// written 已写入的列数
+	written int
+}
+
+// separate 写入列之间的分隔符，对齐时为空格，否则与zap的控制台格式一致使用制表符
+func (w *consoleWriter) separate() {
+	if w.written == 0 {
+		return
+	}
+	if w.align {
+		w.buf.AppendByte(' ')
+	} else {
+		w.buf.AppendByte('\t')
+	}
+}
+
+// column 写入一列，对齐时按显示宽度补齐到 minWidth
+func (w *consoleWriter) column(text, color string, minWidth int) {
+	w.separate()
+	w.written++
+	appendColored(w.buf, text, color)
+	if w.align {
+		for n := displayWidth(text); n < minWidth; n++ {
+			w.buf.AppendByte(' ')
+		}
+	}
+}
+
+// appendColored 写入带颜色的文本，color 为空时不着色
+func appendColored(buf *buffer.Buffer, text, color string) {
+	if color == "" || text == "" {
+		buf.AppendString(text)
+		return
+	}
+	buf.AppendString(color)
+	buf.AppendString(text)
+	buf.AppendString(colorReset)
+}
+
+// displayWidth 返回文本在终端中的显示宽度，中日韩等全角字符占两列
+func displayWidth(text string) int {
+	n := 0
+	for _, r := range text {
+		switch width.LookupRune(r).Kind() {
+		case width.EastAsianWide, width.EastAsianFullwidth:
+			n += 2
+		default:
+			n++
+		}
+	}
+	return n
+}
+
+// encodePrimitive 使用zap的编码函数（如EncodeTime、EncodeLevel）编码值，编码函数没有输出时返回 fallback
+func encodePrimitive(encode func(zapcore.PrimitiveArrayEncoder), fallback string) string {
+	arr := &sliceArrayEncoder{}
+	encode(arr)
+	if len(arr.elems) == 0 {
+		return fallback
+	}
+	return fmt.Sprint(arr.elems[0])
+}
+
+func (enc *consoleEncoder) add(key, value string, quote bool) {
+	enc.pairs = append(enc.pairs, consolePair{key: enc.prefix + key, value: value, quote: quote})
+}
+
+// AddArray 实现zapcore.ObjectEncoder接口
+func (enc *consoleEncoder) AddArray(key string, marshaler zapcore.ArrayMarshaler) error {
+	arr := &sliceArrayEncoder{}
+	err := marshaler.MarshalLogArray(arr)
+	if reflectErr := enc.AddReflected(key, arr.elems); err == nil {
+		err = reflectErr
+	}
+	return err
+}
+
+// AddObject 实现zapcore.ObjectEncoder接口
+func (enc *consoleEncoder) AddObject(key string, marshaler zapcore.ObjectMarshaler) error {
+	old := enc.prefix
+	enc.prefix = old + key + "."
+	err := marshaler.MarshalLogObject(enc)
+	enc.prefix = old
+	return err
+}
+
+// AddBinary 实现zapcore.ObjectEncoder接口
+func (enc *consoleEncoder) AddBinary(key string, value []byte) {
+	enc.add(key, base64.StdEncoding.EncodeToString(value), false)
+}
+
+// AddByteString 实现zapcore.ObjectEncoder接口
+func (enc *consoleEncoder) AddByteString(key string, value []byte) {
+	enc.AddString(key, string(value))
+}
+
+// AddBool 实现zapcore.ObjectEncoder接口
+func (enc *consoleEncoder) AddBool(key string, value bool) {
+	enc.add(key, strconv.FormatBool(value), false)
+}
+
+// AddComplex128 实现zapcore.ObjectEncoder接口
+func (enc *consoleEncoder) AddComplex128(key string, value complex128) {
+	enc.add(key, strconv.FormatComplex(value, 'f', -1, 128), false)
+}
+
+// AddComplex64 实现zapcore.ObjectEncoder接口
+func (enc *consoleEncoder) AddComplex64(key string, value complex64) {
+	enc.add(key, strconv.FormatComplex(complex128(value), 'f', -1, 64), false)
+}
+
+// AddDuration 实现zapcore.ObjectEncoder接口
+func (enc *consoleEncoder) AddDuration(key string, value time.Duration) {
+	if enc.cfg.EncodeDuration == nil {
+		enc.add(key, value.String(), false)
+		return
+	}
+	enc.add(key, encodePrimitive(func(pae zapcore.PrimitiveArrayEncoder) {
+		enc.cfg.EncodeDuration(value, pae)
+	}, value.String()), false)
+}
+
+// AddFloat64 实现zapcore.ObjectEncoder接口
+func (enc *consoleEncoder) AddFloat64(key string, value float64) {
+	enc.add(key, formatFloat(value, 64), false)
+}
+
+// AddFloat32 实现zapcore.ObjectEncoder接口
+func (enc *consoleEncoder) AddFloat32(key string, value float32) {
+	enc.add(key, formatFloat(float64(value), 32), false)
+}
+
+// AddInt 实现zapcore.ObjectEncoder接口
+func (enc *consoleEncoder) AddInt(key string, value int) { enc.AddInt64(key, int64(value)) }
+
+// AddInt64 实现zapcore.ObjectEncoder接口
+func (enc *consoleEncoder) AddInt64(key string, value int64) {
+	enc.add(key, strconv.FormatInt(value, 10), false)
+}
+
+// AddInt32 实现zapcore.ObjectEncoder接口
+func (enc *consoleEncoder) AddInt32(key string, value int32) { enc.AddInt64(key, int64(value)) }
+
+// AddInt16 实现zapcore.ObjectEncoder接口
+func (enc *consoleEncoder) AddInt16(key string, value int16) { enc.AddInt64(key, int64(value)) }
+
+// AddInt8 实现zapcore.ObjectEncoder接口
+func (enc *consoleEncoder) AddInt8(key string, value int8) { enc.AddInt64(key, int64(value)) }
+
+// AddString 实现zapcore.ObjectEncoder接口
+func (enc *consoleEncoder) AddString(key, value string) {
+	enc.add(key, value, true)
+}
+
+// AddTime 实现zapcore.ObjectEncoder接口
+func (enc *consoleEncoder) AddTime(key string, value time.Time) {
+	if enc.cfg.EncodeTime == nil {
+		enc.add(key, value.Format(time.RFC3339Nano), false)
+		return
+	}
+	enc.add(key, encodePrimitive(func(pae zapcore.PrimitiveArrayEncoder) {
+		enc.cfg.EncodeTime(value, pae)
+	}, value.Format(time.RFC3339Nano)), false)
+}
+
+// AddUint 实现zapcore.ObjectEncoder接口
+func (enc *consoleEncoder) AddUint(key string, value uint) { enc.AddUint64(key, uint64(value)) }
+
+// AddUint64 实现zapcore.ObjectEncoder接口
+func (enc *consoleEncoder) AddUint64(key string, value uint64) {
+	enc.add(key, strconv.FormatUint(value, 10), false)
+}
+
+// AddUint32 实现zapcore.ObjectEncoder接口
+func (enc *consoleEncoder) AddUint32(key string, value uint32) { enc.AddUint64(key, uint64(value)) }
+
+// AddUint16 实现zapcore.ObjectEncoder接口
+func (enc *consoleEncoder) AddUint16(key string, value uint16) { enc.AddUint64(key, uint64(value)) }
+
+// AddUint8 实现zapcore.ObjectEncoder接口
+func (enc *consoleEncoder) AddUint8(key string, value uint8) { enc.AddUint64(key, uint64(value)) }
+
+// AddUintptr 实现zapcore.ObjectEncoder接口
+func (enc *consoleEncoder) AddUintptr(key string, value uintptr) { enc.AddUint64(key, uint64(value)) }
+
+// AddReflected 实现zapcore.ObjectEncoder接口
+func (enc *consoleEncoder) AddReflected(key string, value interface{}) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	enc.add(key, string(data), false)
+	return nil
+}
+
+// OpenNamespace 实现zapcore.ObjectEncoder接口，之后的字段都带上该命名空间前缀
+func (enc *consoleEncoder) OpenNamespace(key string) {
+	enc.prefix = enc.prefix + key + "."
+}
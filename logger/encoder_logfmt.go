@@ -0,0 +1,424 @@
+package logger
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"go.uber.org/zap/buffer"
+	"go.uber.org/zap/zapcore"
+)
+
+var kvBufferPool = buffer.NewPool()
+
+// kvEncoder 以 key=value 形式输出字段的编码器，logfmt 和 CEF 编码器共用
+//
+// 嵌套对象和命名空间展开为以点号连接的key，如 http.status=200；
+// 数组和反射类型编码为JSON字符串
+type kvEncoder struct {
+	cfg    *zapcore.EncoderConfig
+	buf    *buffer.Buffer
+	prefix string
+	// escape 将值转换为可直接写入的文本
+	escape func(string) string
+	// escapeKey 将key转换为可直接写入的文本，key不能加引号，不允许的字符替换为下划线
+	escapeKey func(string) string
+	// sep 字段之间的分隔符
+	sep byte
+}
+
+// NewLogfmtEncoder 创建logfmt格式的编码器
+func NewLogfmtEncoder(cfg zapcore.EncoderConfig) zapcore.Encoder {
+	return &logfmtEncoder{kvEncoder: newKVEncoder(&cfg, logfmtEscape, logfmtKey)}
+}
+
+func newKVEncoder(cfg *zapcore.EncoderConfig, escape, escapeKey func(string) string) *kvEncoder {
+	return &kvEncoder{
+		cfg:       cfg,
+		buf:       kvBufferPool.Get(),
+		escape:    escape,
+		escapeKey: escapeKey,
+		sep:       ' ',
+	}
+}
+
+// logfmtEncoder logfmt格式编码器
+type logfmtEncoder struct {
+	*kvEncoder
+}
+
+// Clone 实现zapcore.Encoder接口
+func (enc *logfmtEncoder) Clone() zapcore.Encoder {
+	return &logfmtEncoder{kvEncoder: enc.clone()}
+}
+
+// EncodeEntry 实现zapcore.Encoder接口
+func (enc *logfmtEncoder) EncodeEntry(ent zapcore.Entry, fields []zapcore.Field) (*buffer.Buffer, error) {
+	final := enc.cloneEmpty()
+
+	if final.cfg.TimeKey != "" {
+		final.AddTime(final.cfg.TimeKey, ent.Time)
+	}
+	if final.cfg.LevelKey != "" {
+		final.addEncoded(final.cfg.LevelKey, func(pae zapcore.PrimitiveArrayEncoder) {
+			if final.cfg.EncodeLevel != nil {
+				final.cfg.EncodeLevel(ent.Level, pae)
+			} else {
+				pae.AppendString(levelName(ent.Level))
+			}
+		})
+	}
+	if ent.LoggerName != "" && final.cfg.NameKey != "" {
+		final.AddString(final.cfg.NameKey, ent.LoggerName)
+	}
+	if ent.Caller.Defined {
+		if final.cfg.CallerKey != "" {
+			final.addEncoded(final.cfg.CallerKey, func(pae zapcore.PrimitiveArrayEncoder) {
+				if final.cfg.EncodeCaller != nil {
+					final.cfg.EncodeCaller(ent.Caller, pae)
+				} else {
+					pae.AppendString(ent.Caller.TrimmedPath())
+				}
+			})
+		}
+		if final.cfg.FunctionKey != "" {
+			final.AddString(final.cfg.FunctionKey, ent.Caller.Function)
+		}
+	}
+	if final.cfg.MessageKey != "" {
+		final.AddString(final.cfg.MessageKey, ent.Message)
+	}
+
+	final.appendContext(enc.kvEncoder, fields)
+
+	if ent.Stack != "" && final.cfg.StacktraceKey != "" {
+		final.AddString(final.cfg.StacktraceKey, ent.Stack)
+	}
+
+	final.appendLineEnding()
+	return final.buf, nil
+}
+
+// clone 复制编码器及已编码的上下文字段
+func (enc *kvEncoder) clone() *kvEncoder {
+	c := enc.cloneEmpty()
+	c.buf.Write(enc.buf.Bytes())
+	c.prefix = enc.prefix
+	return c
+}
+
+// cloneEmpty 复制编码器配置，不包含已编码的字段
+func (enc *kvEncoder) cloneEmpty() *kvEncoder {
+	return &kvEncoder{
+		cfg:       enc.cfg,
+		buf:       kvBufferPool.Get(),
+		escape:    enc.escape,
+		escapeKey: enc.escapeKey,
+		sep:       enc.sep,
+	}
+}
+
+// appendContext 追加With添加的上下文字段以及本条日志的字段
+func (enc *kvEncoder) appendContext(context *kvEncoder, fields []zapcore.Field) {
+	if context.buf.Len() > 0 {
+		enc.addSeparator()
+		enc.buf.Write(context.buf.Bytes())
+	}
+	enc.prefix = context.prefix
+	for i := range fields {
+		fields[i].AddTo(enc)
+	}
+}
+
+// appendLineEnding 追加行结束符
+func (enc *kvEncoder) appendLineEnding() {
+	if enc.cfg.LineEnding != "" {
+		enc.buf.AppendString(enc.cfg.LineEnding)
+	} else {
+		enc.buf.AppendString(zapcore.DefaultLineEnding)
+	}
+}
+
+func (enc *kvEncoder) addSeparator() {
+	if enc.buf.Len() > 0 {
+		enc.buf.AppendByte(enc.sep)
+	}
+}
+
+func (enc *kvEncoder) addKey(key string) {
+	enc.addSeparator()
+	enc.buf.AppendString(enc.prefix)
+	enc.buf.AppendString(enc.escapeKey(key))
+	enc.buf.AppendByte('=')
+}
+
+// addRaw 写入无需转义的值
+func (enc *kvEncoder) addRaw(key, value string) {
+	enc.addKey(key)
+	enc.buf.AppendString(value)
+}
+
+// addEncoded 使用zap的编码函数（如EncodeTime、EncodeLevel）编码值
+func (enc *kvEncoder) addEncoded(key string, encode func(zapcore.PrimitiveArrayEncoder)) {
+	arr := &sliceArrayEncoder{}
+	encode(arr)
+	if len(arr.elems) == 0 {
+		return
+	}
+	switch v := arr.elems[0].(type) {
+	case string:
+		enc.AddString(key, v)
+	default:
+		enc.addRaw(key, enc.escape(fmt.Sprint(v)))
+	}
+}
+
+// AddArray 实现zapcore.ObjectEncoder接口
+func (enc *kvEncoder) AddArray(key string, marshaler zapcore.ArrayMarshaler) error {
+	arr := &sliceArrayEncoder{}
+	err := marshaler.MarshalLogArray(arr)
+	enc.AddReflected(key, arr.elems)
+	return err
+}
+
+// AddObject 实现zapcore.ObjectEncoder接口
+func (enc *kvEncoder) AddObject(key string, marshaler zapcore.ObjectMarshaler) error {
+	old := enc.prefix
+	enc.prefix = old + enc.escapeKey(key) + "."
+	err := marshaler.MarshalLogObject(enc)
+	enc.prefix = old
+	return err
+}
+
+// AddBinary 实现zapcore.ObjectEncoder接口
+func (enc *kvEncoder) AddBinary(key string, value []byte) {
+	enc.AddString(key, base64.StdEncoding.EncodeToString(value))
+}
+
+// AddByteString 实现zapcore.ObjectEncoder接口
+func (enc *kvEncoder) AddByteString(key string, value []byte) {
+	enc.AddString(key, string(value))
+}
+
+// AddBool 实现zapcore.ObjectEncoder接口
+func (enc *kvEncoder) AddBool(key string, value bool) {
+	enc.addRaw(key, strconv.FormatBool(value))
+}
+
+// AddComplex128 实现zapcore.ObjectEncoder接口
+func (enc *kvEncoder) AddComplex128(key string, value complex128) {
+	enc.addRaw(key, enc.escape(strconv.FormatComplex(value, 'f', -1, 128)))
+}
+
+// AddComplex64 实现zapcore.ObjectEncoder接口
+func (enc *kvEncoder) AddComplex64(key string, value complex64) {
+	enc.addRaw(key, enc.escape(strconv.FormatComplex(complex128(value), 'f', -1, 64)))
+}
+
+// AddDuration 实现zapcore.ObjectEncoder接口
+func (enc *kvEncoder) AddDuration(key string, value time.Duration) {
+	if enc.cfg.EncodeDuration == nil {
+		enc.AddString(key, value.String())
+		return
+	}
+	enc.addEncoded(key, func(pae zapcore.PrimitiveArrayEncoder) {
+		enc.cfg.EncodeDuration(value, pae)
+	})
+}
+
+// AddFloat64 实现zapcore.ObjectEncoder接口
+func (enc *kvEncoder) AddFloat64(key string, value float64) {
+	enc.addRaw(key, formatFloat(value, 64))
+}
+
+// AddFloat32 实现zapcore.ObjectEncoder接口
+func (enc *kvEncoder) AddFloat32(key string, value float32) {
+	enc.addRaw(key, formatFloat(float64(value), 32))
+}
+
+// AddInt 实现zapcore.ObjectEncoder接口
+func (enc *kvEncoder) AddInt(key string, value int) { enc.AddInt64(key, int64(value)) }
+
+// AddInt64 实现zapcore.ObjectEncoder接口
+func (enc *kvEncoder) AddInt64(key string, value int64) {
+	enc.addRaw(key, strconv.FormatInt(value, 10))
+}
+
+// AddInt32 实现zapcore.ObjectEncoder接口
+func (enc *kvEncoder) AddInt32(key string, value int32) { enc.AddInt64(key, int64(value)) }
+
+// AddInt16 实现zapcore.ObjectEncoder接口
+func (enc *kvEncoder) AddInt16(key string, value int16) { enc.AddInt64(key, int64(value)) }
+
+// AddInt8 实现zapcore.ObjectEncoder接口
+func (enc *kvEncoder) AddInt8(key string, value int8) { enc.AddInt64(key, int64(value)) }
+
+// AddString 实现zapcore.ObjectEncoder接口
+func (enc *kvEncoder) AddString(key, value string) {
+	enc.addRaw(key, enc.escape(value))
+}
+
+// AddTime 实现zapcore.ObjectEncoder接口
+func (enc *kvEncoder) AddTime(key string, value time.Time) {
+	if enc.cfg.EncodeTime == nil {
+		enc.AddString(key, value.Format(time.RFC3339Nano))
+		return
+	}
+	enc.addEncoded(key, func(pae zapcore.PrimitiveArrayEncoder) {
+		enc.cfg.EncodeTime(value, pae)
+	})
+}
+
+// AddUint 实现zapcore.ObjectEncoder接口
+func (enc *kvEncoder) AddUint(key string, value uint) { enc.AddUint64(key, uint64(value)) }
+
+// AddUint64 实现zapcore.ObjectEncoder接口
+func (enc *kvEncoder) AddUint64(key string, value uint64) {
+	enc.addRaw(key, strconv.FormatUint(value, 10))
+}
+
+// AddUint32 实现zapcore.ObjectEncoder接口
+func (enc *kvEncoder) AddUint32(key string, value uint32) { enc.AddUint64(key, uint64(value)) }
+
+// AddUint16 实现zapcore.ObjectEncoder接口
+func (enc *kvEncoder) AddUint16(key string, value uint16) { enc.AddUint64(key, uint64(value)) }
+
+// AddUint8 实现zapcore.ObjectEncoder接口
+func (enc *kvEncoder) AddUint8(key string, value uint8) { enc.AddUint64(key, uint64(value)) }
+
+// AddUintptr 实现zapcore.ObjectEncoder接口
+func (enc *kvEncoder) AddUintptr(key string, value uintptr) { enc.AddUint64(key, uint64(value)) }
+
+// AddReflected 实现zapcore.ObjectEncoder接口
+func (enc *kvEncoder) AddReflected(key string, value interface{}) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	enc.AddString(key, string(data))
+	return nil
+}
+
+// OpenNamespace 实现zapcore.ObjectEncoder接口，之后的字段都带上该命名空间前缀
+func (enc *kvEncoder) OpenNamespace(key string) {
+	enc.prefix = enc.prefix + enc.escapeKey(key) + "."
+}
+
+// formatFloat 格式化浮点数，NaN和Inf使用字符串表示
+func formatFloat(value float64, bitSize int) string {
+	switch {
+	case math.IsNaN(value):
+		return "NaN"
+	case math.IsInf(value, 1):
+		return "+Inf"
+	case math.IsInf(value, -1):
+		return "-Inf"
+	default:
+		return strconv.FormatFloat(value, 'f', -1, bitSize)
+	}
+}
+
+// logfmtEscape 按logfmt规则转义值，包含空白、等号、引号或控制字符时加引号
+func logfmtEscape(value string) string {
+	if value == "" {
+		return `""`
+	}
+	needsQuote := false
+	for _, r := range value {
+		if r <= ' ' || r == '=' || r == '"' || r == utf8.RuneError || r == 0x7f {
+			needsQuote = true
+			break
+		}
+	}
+	if !needsQuote {
+		return value
+	}
+	return strconv.Quote(value)
+}
+
+// logfmtKey 将key中的空白、等号、引号和控制字符替换为下划线
+func logfmtKey(key string) string {
+	return sanitizeKey(key, func(r rune) bool {
+		return r > ' ' && r != '=' && r != '"' && r != utf8.RuneError && r != 0x7f
+	})
+}
+
+// cefKey CEF扩展字段的key只能包含字母和数字，其他字符（除点号、下划线和连字符外）替换为下划线
+func cefKey(key string) string {
+	return sanitizeKey(key, func(r rune) bool {
+		return unicode.IsLetter(r) || unicode.IsDigit(r) || r == '.' || r == '_' || r == '-'
+	})
+}
+
+// sanitizeKey 将 valid 返回false的字符替换为下划线，没有需要替换的字符时返回原字符串
+func sanitizeKey(key string, valid func(rune) bool) string {
+	if strings.IndexFunc(key, func(r rune) bool { return !valid(r) }) < 0 {
+		return key
+	}
+	return strings.Map(func(r rune) rune {
+		if valid(r) {
+			return r
+		}
+		return '_'
+	}, key)
+}
+
+// cefEscape 按CEF扩展字段规则转义值
+func cefEscape(value string) string {
+	return cefExtensionEscaper.Replace(value)
+}
+
+var cefExtensionEscaper = strings.NewReplacer(`\`, `\\`, `=`, `\=`, "\n", `\n`, "\r", `\r`)
+
+// sliceArrayEncoder 将数组元素收集到切片中
+type sliceArrayEncoder struct {
+	elems []interface{}
+}
+
+func (s *sliceArrayEncoder) AppendArray(v zapcore.ArrayMarshaler) error {
+	enc := &sliceArrayEncoder{}
+	err := v.MarshalLogArray(enc)
+	s.elems = append(s.elems, enc.elems)
+	return err
+}
+
+func (s *sliceArrayEncoder) AppendObject(v zapcore.ObjectMarshaler) error {
+	m := zapcore.NewMapObjectEncoder()
+	err := v.MarshalLogObject(m)
+	s.elems = append(s.elems, m.Fields)
+	return err
+}
+
+func (s *sliceArrayEncoder) AppendReflected(v interface{}) error {
+	s.elems = append(s.elems, v)
+	return nil
+}
+
+func (s *sliceArrayEncoder) AppendBool(v bool)              { s.elems = append(s.elems, v) }
+func (s *sliceArrayEncoder) AppendByteString(v []byte)      { s.elems = append(s.elems, string(v)) }
+func (s *sliceArrayEncoder) AppendComplex128(v complex128)  { s.elems = append(s.elems, fmt.Sprint(v)) }
+func (s *sliceArrayEncoder) AppendComplex64(v complex64)    { s.elems = append(s.elems, fmt.Sprint(v)) }
+func (s *sliceArrayEncoder) AppendDuration(v time.Duration) { s.elems = append(s.elems, v.String()) }
+func (s *sliceArrayEncoder) AppendFloat64(v float64)        { s.elems = append(s.elems, v) }
+func (s *sliceArrayEncoder) AppendFloat32(v float32)        { s.elems = append(s.elems, v) }
+func (s *sliceArrayEncoder) AppendInt(v int)                { s.elems = append(s.elems, v) }
+func (s *sliceArrayEncoder) AppendInt64(v int64)            { s.elems = append(s.elems, v) }
+func (s *sliceArrayEncoder) AppendInt32(v int32)            { s.elems = append(s.elems, v) }
+func (s *sliceArrayEncoder) AppendInt16(v int16)            { s.elems = append(s.elems, v) }
+func (s *sliceArrayEncoder) AppendInt8(v int8)              { s.elems = append(s.elems, v) }
+func (s *sliceArrayEncoder) AppendString(v string)          { s.elems = append(s.elems, v) }
+func (s *sliceArrayEncoder) AppendTime(v time.Time) {
+	s.elems = append(s.elems, v.Format(time.RFC3339Nano))
+}
+func (s *sliceArrayEncoder) AppendUint(v uint)       { s.elems = append(s.elems, v) }
+func (s *sliceArrayEncoder) AppendUint64(v uint64)   { s.elems = append(s.elems, v) }
+func (s *sliceArrayEncoder) AppendUint32(v uint32)   { s.elems = append(s.elems, v) }
+func (s *sliceArrayEncoder) AppendUint16(v uint16)   { s.elems = append(s.elems, v) }
+func (s *sliceArrayEncoder) AppendUint8(v uint8)     { s.elems = append(s.elems, v) }
+func (s *sliceArrayEncoder) AppendUintptr(v uintptr) { s.elems = append(s.elems, v) }
@@ -0,0 +1,56 @@
+package logger
+
+import (
+	"go.uber.org/zap/buffer"
+	"go.uber.org/zap/zapcore"
+)
+
+// msgpackEncoder MessagePack编码器，每条日志编码为一个map，日志之间没有分隔符
+//
+// 时间字段使用Fluent的EventTime扩展类型（精确到纳秒），其他字段名与JSON格式相同
+type msgpackEncoder struct {
+	*mapEncoder
+	cfg *zapcore.EncoderConfig
+}
+
+// NewMsgpackEncoder 创建MessagePack格式的编码器，用 BinaryDecoder 或 virlog-decode 读取
+func NewMsgpackEncoder(cfg zapcore.EncoderConfig) zapcore.Encoder {
+	return &msgpackEncoder{mapEncoder: newMapEncoder(), cfg: &cfg}
+}
+
+// Clone 实现zapcore.Encoder接口
+func (enc *msgpackEncoder) Clone() zapcore.Encoder {
+	return &msgpackEncoder{mapEncoder: enc.clone(), cfg: enc.cfg}
+}
+
+// EncodeEntry 实现zapcore.Encoder接口
+func (enc *msgpackEncoder) EncodeEntry(ent zapcore.Entry, fields []zapcore.Field) (*buffer.Buffer, error) {
+	record := enc.entryFields(fields)
+	if enc.cfg.TimeKey != "" {
+		record[enc.cfg.TimeKey] = msgpackEventTime(ent.Time)
+	}
+	if enc.cfg.LevelKey != "" {
+		record[enc.cfg.LevelKey] = levelName(ent.Level)
+	}
+	if enc.cfg.NameKey != "" && ent.LoggerName != "" {
+		record[enc.cfg.NameKey] = ent.LoggerName
+	}
+	if enc.cfg.CallerKey != "" && ent.Caller.Defined {
+		record[enc.cfg.CallerKey] = ent.Caller.TrimmedPath()
+	}
+	if enc.cfg.FunctionKey != "" && ent.Caller.Defined && ent.Caller.Function != "" {
+		record[enc.cfg.FunctionKey] = ent.Caller.Function
+	}
+	if enc.cfg.MessageKey != "" {
+		record[enc.cfg.MessageKey] = ent.Message
+	}
+	if enc.cfg.StacktraceKey != "" && ent.Stack != "" {
+		record[enc.cfg.StacktraceKey] = ent.Stack
+	}
+
+	var w msgpackWriter
+	w.writeMap(record)
+	buf := binaryBufferPool.Get()
+	buf.Write(w.buf)
+	return buf, nil
+}
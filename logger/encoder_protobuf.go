@@ -0,0 +1,80 @@
+package logger
+
+import (
+	"fmt"
+
+	"go.uber.org/zap/buffer"
+	"go.uber.org/zap/zapcore"
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// LogEntry 消息的字段编号，见 logentry.proto
+const (
+	logEntryTime       protowire.Number = 1
+	logEntryLevel      protowire.Number = 2
+	logEntryLogger     protowire.Number = 3
+	logEntryMessage    protowire.Number = 4
+	logEntryCaller     protowire.Number = 5
+	logEntryStacktrace protowire.Number = 6
+	logEntryFields     protowire.Number = 7
+)
+
+// protobufEncoder protobuf编码器，每条日志编码为带长度前缀的 LogEntry 消息
+type protobufEncoder struct {
+	*mapEncoder
+}
+
+// NewProtobufEncoder 创建protobuf格式的编码器，消息结构见 logentry.proto，用 BinaryDecoder 或 virlog-decode 读取
+//
+// 字段名固定，不受 EncoderConfig 中字段名的影响
+func NewProtobufEncoder(zapcore.EncoderConfig) zapcore.Encoder {
+	return &protobufEncoder{mapEncoder: newMapEncoder()}
+}
+
+// Clone 实现zapcore.Encoder接口
+func (enc *protobufEncoder) Clone() zapcore.Encoder {
+	return &protobufEncoder{mapEncoder: enc.clone()}
+}
+
+// EncodeEntry 实现zapcore.Encoder接口
+func (enc *protobufEncoder) EncodeEntry(ent zapcore.Entry, fields []zapcore.Field) (*buffer.Buffer, error) {
+	var msg []byte
+	msg = protowire.AppendTag(msg, logEntryTime, protowire.VarintType)
+	msg = protowire.AppendVarint(msg, uint64(ent.Time.UnixNano()))
+	msg = appendProtoString(msg, logEntryLevel, levelName(ent.Level))
+	msg = appendProtoString(msg, logEntryLogger, ent.LoggerName)
+	msg = appendProtoString(msg, logEntryMessage, ent.Message)
+	if ent.Caller.Defined {
+		msg = appendProtoString(msg, logEntryCaller, ent.Caller.TrimmedPath())
+	}
+	msg = appendProtoString(msg, logEntryStacktrace, ent.Stack)
+
+	if record := enc.entryFields(fields); len(record) > 0 {
+		s, err := structpb.NewStruct(record)
+		if err != nil {
+			return nil, fmt.Errorf("编码日志字段失败: %w", err)
+		}
+		data, err := proto.MarshalOptions{Deterministic: true}.Marshal(s)
+		if err != nil {
+			return nil, fmt.Errorf("编码日志字段失败: %w", err)
+		}
+		msg = protowire.AppendTag(msg, logEntryFields, protowire.BytesType)
+		msg = protowire.AppendBytes(msg, data)
+	}
+
+	buf := binaryBufferPool.Get()
+	buf.Write(protowire.AppendVarint(nil, uint64(len(msg))))
+	buf.Write(msg)
+	return buf, nil
+}
+
+// appendProtoString 编码字符串字段，空字符串按proto3的规则省略
+func appendProtoString(b []byte, num protowire.Number, s string) []byte {
+	if s == "" {
+		return b
+	}
+	b = protowire.AppendTag(b, num, protowire.BytesType)
+	return protowire.AppendString(b, s)
+}
@@ -0,0 +1,199 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/constructorvirgil/virlog/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zapcore"
+)
+
+// 创建指定格式的测试Logger
+func newFormatLogger(t *testing.T, cfg *config.Config) (Logger, *bytes.Buffer) {
+	buf := &bytes.Buffer{}
+	cfg.EnableCaller = false
+	cfg.EnableStacktrace = false
+	log, err := NewLogger(cfg, WithSyncTarget(zapcore.AddSync(buf)))
+	require.NoError(t, err)
+	return log, buf
+}
+
+// 测试logfmt编码器
+func TestLogfmtEncoder(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.Format = "logfmt"
+	log, buf := newFormatLogger(t, cfg)
+
+	log.With(String("request_id", "req-1")).Info("hello world",
+		Int("status", 200),
+		String("path", "/api/users"),
+		String("quoted", `say "hi"`),
+		Bool("ok", true),
+		Duration("latency", 1500*time.Millisecond),
+		Err(errors.New("boom")),
+		Any("tags", []string{"a", "b"}),
+	)
+
+	line := strings.TrimSpace(buf.String())
+	assert.Contains(t, line, "level=info")
+	assert.Contains(t, line, `msg="hello world"`)
+	assert.Contains(t, line, "request_id=req-1 status=200 path=/api/users")
+	assert.Contains(t, line, `quoted="say \"hi\""`)
+	assert.Contains(t, line, "ok=true")
+	assert.Contains(t, line, "latency=1.5")
+	assert.Contains(t, line, "error=boom")
+	assert.Contains(t, line, `tags="[\"a\",\"b\"]"`)
+	assert.True(t, strings.HasPrefix(line, "time="))
+
+	// 命名空间展开为点号连接的key
+	buf.Reset()
+	log.Info("namespaced", Namespace("http"), Int("status", 404))
+	assert.Contains(t, buf.String(), "http.status=404")
+
+	// key中的空白、等号、引号和换行替换为下划线，不破坏行格式
+	buf.Reset()
+	log.Info("keys", String("a=b", "1"), String("a b", "2"), String("a\nb", "3"), String(`a"b`, "4"),
+		Namespace("x y"), Int("z", 5))
+	line = strings.TrimSpace(buf.String())
+	assert.NotContains(t, line, "\n")
+	assert.Contains(t, line, "a_b=1 a_b=2 a_b=3 a_b=4 x_y.z=5")
+}
+
+// 测试CEF编码器
+func TestCEFEncoder(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.Format = "cef"
+	cfg.CEF = &config.CEFConfig{DeviceVendor: "Acme", DeviceProduct: "auth|svc", DeviceVersion: "2.1"}
+	log, buf := newFormatLogger(t, cfg)
+
+	log.Warn("login failed", String("src", "10.0.0.1"), String("reason", "a=b"))
+
+	line := strings.TrimSpace(buf.String())
+	assert.True(t, strings.HasPrefix(line, `CEF:0|Acme|auth\|svc|2.1|warn|login failed|5|rt=`), line)
+	assert.Contains(t, line, "src=10.0.0.1")
+	assert.Contains(t, line, `reason=a\=b`)
+
+	// 扩展字段的key只保留字母、数字、点号、下划线和连字符
+	buf.Reset()
+	log.Info("keys", String("a=b", "1"), String("a b", "2"), String("a|b", "3"), String("a\nb", "4"), String(`a\b`, "5"))
+	line = strings.TrimSpace(buf.String())
+	assert.NotContains(t, line, "\n")
+	assert.Contains(t, line, "a_b=1 a_b=2 a_b=3 a_b=4 a_b=5")
+}
+
+// 测试通过配置调整时间、时长、级别格式和字段名
+func TestEncoderConfig(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.Encoder = &config.EncoderConfig{
+		TimeFormat:     "epoch_millis",
+		DurationFormat: "millis",
+		LevelFormat:    "uppercase",
+		MessageKey:     "message",
+		TimeKey:        "@timestamp",
+		NameKey:        "-",
+	}
+	log, buf := newFormatLogger(t, cfg)
+
+	before := time.Now().UnixMilli()
+	log.Info("hello", Duration("latency", 1500*time.Millisecond))
+
+	logData := make(map[string]interface{})
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &logData))
+	assert.Equal(t, "hello", logData["message"])
+	assert.Equal(t, "INFO", logData["level"])
+	assert.Equal(t, float64(1500), logData["latency"])
+	assert.GreaterOrEqual(t, logData["@timestamp"], float64(before))
+	assert.NotContains(t, logData, "msg")
+	assert.NotContains(t, logData, "time")
+
+	// 自定义时间布局和字符串时长
+	cfg = config.DefaultConfig()
+	cfg.Format = "logfmt"
+	cfg.Encoder = &config.EncoderConfig{TimeFormat: "2006-01-02", DurationFormat: "string"}
+	log, buf = newFormatLogger(t, cfg)
+	log.Info("hello", Duration("latency", 1500*time.Millisecond))
+	assert.True(t, strings.HasPrefix(buf.String(), "time="+time.Now().Format("2006-01-02")))
+	assert.Contains(t, buf.String(), "latency=1.5s")
+}
+
+// 创建控制台格式的测试Logger，时间字段固定省略
+func newConsoleLogger(t *testing.T, console *config.ConsoleConfig) (Logger, *bytes.Buffer) {
+	cfg := config.DefaultConfig()
+	cfg.Format = "console"
+	cfg.Encoder = &config.EncoderConfig{TimeKey: "-"}
+	cfg.Console = console
+	return newFormatLogger(t, cfg)
+}
+
+// 测试控制台编码器的字段格式和排序
+func TestConsoleEncoder(t *testing.T) {
+	log, buf := newConsoleLogger(t, &config.ConsoleConfig{Color: "never"})
+	log.Named("api").With(String("request_id", "req-1")).Info("hello world",
+		Int("status", 200),
+		String("quoted", `say "hi"`),
+		Duration("latency", 1500*time.Millisecond),
+		Any("tags", []string{"a", "b"}),
+		Namespace("db"),
+		Int("rows", 3))
+	assert.Equal(t, "info\tapi\thello world\trequest_id=req-1 status=200 quoted=\"say \\\"hi\\\"\" "+
+		"latency=1.5 tags=[\"a\",\"b\"] db.rows=3\n", buf.String())
+
+	log, buf = newConsoleLogger(t, &config.ConsoleConfig{
+		Color:          "never",
+		FieldOrder:     []string{"trace_id", "user"},
+		SortFields:     true,
+		MaxValueLength: 5,
+	})
+	log.Warn("sorted", String("zone", "cn-north"), String("user", "alice"), Int("attempt", 2), String("trace_id", "abc"))
+	assert.Equal(t, "warn\tsorted\ttrace_id=abc user=alice attempt=2 zone=cn-no…\n", buf.String())
+}
+
+// 测试多行错误和对齐列
+func TestConsoleEncoderLayout(t *testing.T) {
+	log, buf := newConsoleLogger(t, &config.ConsoleConfig{Color: "never", MultilineErrors: true, AlignColumns: true, MessageWidth: 10})
+	log.Error("失败", Err(errors.Join(errors.New("连接超时"), errors.New("重试次数耗尽"))), Int("attempt", 3))
+	log.Named("worker").Info("ok")
+
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	require.Len(t, lines, 5)
+	assert.Equal(t, "error "+strings.Repeat(" ", 16)+" 失败       attempt=3", lines[0], "中文按两列宽度对齐")
+	assert.Equal(t, "    error:", lines[1])
+	assert.Equal(t, "        连接超时", lines[2])
+	assert.Equal(t, "        重试次数耗尽", lines[3])
+	assert.Equal(t, "info  worker           ok", lines[4])
+
+	// 未开启多行错误时换行被转义
+	log, buf = newConsoleLogger(t, &config.ConsoleConfig{Color: "never"})
+	log.Error("失败", Err(errors.Join(errors.New("a"), errors.New("b"))))
+	assert.Equal(t, "error\t失败\terror=\"a\\nb\"\n", buf.String())
+}
+
+// 测试颜色主题和颜色开关
+func TestConsoleTheme(t *testing.T) {
+	log, buf := newConsoleLogger(t, &config.ConsoleConfig{Color: "always", Theme: "dark"})
+	log.Info("colored", Int("n", 1))
+	assert.Equal(t, "\x1b[92minfo\x1b[0m\t\x1b[1mcolored\x1b[0m\t\x1b[96mn\x1b[0m=1\n", buf.String())
+
+	require.NoError(t, RegisterConsoleTheme("test", ConsoleTheme{Warn: "\x1b[7m"}))
+	log, buf = newConsoleLogger(t, &config.ConsoleConfig{Color: "always", Theme: "test"})
+	log.Warn("custom")
+	assert.Equal(t, "\x1b[7mwarn\x1b[0m\tcustom\n", buf.String())
+	assert.Error(t, RegisterConsoleTheme("", ConsoleTheme{}))
+
+	// 未知主题使用默认主题
+	assert.Equal(t, consoleThemes["default"], lookupConsoleTheme("unknown"))
+
+	// 写入目标不是终端时 auto 不使用颜色，NO_COLOR 只影响 auto
+	assert.False(t, consoleColorEnabled(&config.ConsoleConfig{}, zapcore.AddSync(&bytes.Buffer{})))
+	t.Setenv("NO_COLOR", "1")
+	assert.False(t, consoleColorEnabled(&config.ConsoleConfig{}, os.Stdout))
+	assert.True(t, consoleColorEnabled(&config.ConsoleConfig{Color: "always"}, os.Stdout))
+	assert.False(t, consoleColorEnabled(&config.ConsoleConfig{Color: "never"}, os.Stdout))
+}
@@ -0,0 +1,86 @@
+package logger
+
+import (
+	"fmt"
+	"sync"
+)
+
+const (
+	// eventKey 事件名称的字段名
+	eventKey = "event"
+	// eventMissingFieldsKey 缺少的必需字段的字段名
+	eventMissingFieldsKey = "event_missing_fields"
+)
+
+var (
+	// 通过 RegisterEvent 注册的事件及其必需字段
+	eventSchemas   = map[string][]string{}
+	eventSchemasMu sync.RWMutex
+)
+
+// RegisterEvent 注册事件 name 的必需字段，之后通过 Event、LogEvent 输出该事件时检查这些字段
+//
+// 缺少必需字段的事件仍然输出，并带上 event_missing_fields 字段列出缺少的字段名，
+// 便于分析管道区分不完整的事件。重复注册同名事件会覆盖之前的注册
+func RegisterEvent(name string, required ...string) error {
+	if name == "" {
+		return fmt.Errorf("事件名称不能为空")
+	}
+
+	eventSchemasMu.Lock()
+	defer eventSchemasMu.Unlock()
+	eventSchemas[name] = append([]string(nil), required...)
+	return nil
+}
+
+// UnregisterEvent 注销事件的必需字段
+func UnregisterEvent(name string) {
+	eventSchemasMu.Lock()
+	defer eventSchemasMu.Unlock()
+	delete(eventSchemas, name)
+}
+
+// Event 使用默认Logger以Info级别输出结构化事件
+//
+// 日志的消息和 event 字段均为事件名称，分析管道可以按 event 字段消费日志，无需解析消息文本
+func Event(name string, fields ...Field) {
+	loadDefaults().caller.Info(name, eventFields(name, fields)...)
+}
+
+// LogEvent 使用 log 以Info级别输出结构化事件，见 Event
+func LogEvent(log Logger, name string, fields ...Field) {
+	log.WithOptions(WithCallerSkip(1)).Info(name, eventFields(name, fields)...)
+}
+
+// eventFields 返回事件的字段：event 字段在最前，缺少注册的必需字段时追加 event_missing_fields
+func eventFields(name string, fields []Field) []Field {
+	result := make([]Field, 0, len(fields)+2)
+	result = append(result, String(eventKey, name))
+	result = append(result, fields...)
+	if missing := missingEventFields(name, fields); len(missing) > 0 {
+		result = append(result, Strings(eventMissingFieldsKey, missing))
+	}
+	return result
+}
+
+// missingEventFields 返回事件缺少的必需字段，事件未注册时返回nil
+func missingEventFields(name string, fields []Field) []string {
+	eventSchemasMu.RLock()
+	required := eventSchemas[name]
+	eventSchemasMu.RUnlock()
+
+	var missing []string
+	for _, key := range required {
+		found := false
+		for _, f := range fields {
+			if f.Key == key {
+				found = true
+				break
+			}
+		}
+		if !found {
+			missing = append(missing, key)
+		}
+	}
+	return missing
+}
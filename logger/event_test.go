@@ -0,0 +1,58 @@
+package logger
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// 测试输出结构化事件并检查注册的必需字段
+func TestLogEvent(t *testing.T) {
+	require.NoError(t, RegisterEvent("order_created", "order_id", "amount"))
+	defer UnregisterEvent("order_created")
+	assert.Error(t, RegisterEvent(""))
+
+	log, buf := newCallerLogger(t)
+	LogEvent(log, "order_created", String("order_id", "A1"), Int("amount", 100))
+
+	entry := make(map[string]interface{})
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &entry))
+	assert.Equal(t, "order_created", entry["msg"])
+	assert.Equal(t, "order_created", entry["event"])
+	assert.Equal(t, "info", entry["level"])
+	assert.NotContains(t, entry, "event_missing_fields")
+	assert.Contains(t, entry["caller"], "logger/event_test.go:", "调用者为调用位置")
+
+	// 缺少必需字段时仍然输出，并列出缺少的字段
+	buf.Reset()
+	LogEvent(log, "order_created", String("order_id", "A2"))
+	entry = make(map[string]interface{})
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &entry))
+	assert.Equal(t, []interface{}{"amount"}, entry["event_missing_fields"])
+
+	// 未注册的事件不检查字段
+	buf.Reset()
+	LogEvent(log, "cache_miss")
+	entry = make(map[string]interface{})
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &entry))
+	assert.Equal(t, "cache_miss", entry["event"])
+	assert.NotContains(t, entry, "event_missing_fields")
+}
+
+// 测试全局函数使用默认Logger输出事件
+func TestEvent(t *testing.T) {
+	log, buf := newCallerLogger(t)
+	originalStd := DefaultLogger()
+	defer SetDefault(originalStd)
+	SetDefault(log)
+
+	Event("user_login", String("user_id", "u1"))
+
+	entry := make(map[string]interface{})
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &entry))
+	assert.Equal(t, "user_login", entry["event"])
+	assert.Equal(t, "u1", entry["user_id"])
+	assert.Contains(t, entry["caller"], "logger/event_test.go:", "调用者为调用位置")
+}
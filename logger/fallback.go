@@ -0,0 +1,191 @@
+package logger
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/constructorvirgil/virlog/config"
+	"go.uber.org/zap/zapcore"
+)
+
+const (
+	// defaultFallbackOutput 默认的备用输出
+	defaultFallbackOutput = "stderr"
+	// defaultFallbackThreshold 默认切换前允许的连续写入失败次数
+	defaultFallbackThreshold = 3
+	// defaultFallbackRetryInterval 默认尝试恢复原输出的间隔
+	defaultFallbackRetryInterval = 10 * time.Second
+
+	// fallbackFailoverMessage 切换到备用输出时写入备用输出的日志消息
+	fallbackFailoverMessage = "log_output_failover"
+	// fallbackRecoveredMessage 恢复原输出时写入原输出的日志消息
+	fallbackRecoveredMessage = "log_output_recovered"
+)
+
+// withFallback 配置了备用输出时，返回写入失败后切换到备用输出的core
+func withFallback(primary zapcore.Core, encoderConfig zapcore.EncoderConfig, cfg *config.Config, enab zapcore.LevelEnabler, outputs *outputStoppers) (zapcore.Core, error) {
+	fc := cfg.Fallback
+	if fc == nil {
+		return primary, nil
+	}
+	fallbackCfg := *cfg
+	fallbackCfg.Fallback = nil
+	fallbackCfg.Outputs = nil
+	fallbackCfg.OutputOptions = nil
+	fallbackCfg.Output = fc.Output
+	if fallbackCfg.Output == "" {
+		fallbackCfg.Output = defaultFallbackOutput
+	}
+	if fallbackCfg.Output == cfg.Output {
+		return primary, nil
+	}
+	fallback, err := newSingleOutputCore(nil, encoderConfig, &fallbackCfg, enab, outputs)
+	if err != nil {
+		return nil, err
+	}
+
+	state := &fallbackState{
+		output:        cfg.Output,
+		fallback:      fallbackCfg.Output,
+		threshold:     fc.ErrorThreshold,
+		retryInterval: fc.RetryInterval,
+	}
+	if state.threshold <= 0 {
+		state.threshold = defaultFallbackThreshold
+	}
+	if state.retryInterval <= 0 {
+		state.retryInterval = defaultFallbackRetryInterval
+	}
+	return &fallbackCore{primary: primary, fallback: fallback, state: state}, nil
+}
+
+// fallbackState 原输出的写入状态，由同一输出派生的core共享
+type fallbackState struct {
+	output        string
+	fallback      string
+	threshold     int
+	retryInterval time.Duration
+
+	mu       sync.Mutex
+	failures int
+	// failedOver 是否已切换到备用输出
+	failedOver bool
+	// lastAttempt 切换后最近一次尝试写入原输出的时间
+	lastAttempt time.Time
+}
+
+// tryPrimary 是否写入原输出，已切换到备用输出时每隔 retryInterval 尝试一次
+func (s *fallbackState) tryPrimary(now time.Time) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.failedOver {
+		return true
+	}
+	if now.Sub(s.lastAttempt) < s.retryInterval {
+		return false
+	}
+	s.lastAttempt = now
+	return true
+}
+
+// succeeded 记录原输出写入成功，返回是否从备用输出恢复
+func (s *fallbackState) succeeded() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	recovered := s.failedOver
+	s.failures = 0
+	s.failedOver = false
+	return recovered
+}
+
+// failed 记录原输出写入失败，返回是否使用备用输出，以及是否刚刚切换
+func (s *fallbackState) failed(now time.Time) (useFallback, switched bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.failedOver {
+		return true, false
+	}
+	s.failures++
+	if s.failures < s.threshold {
+		return false, false
+	}
+	s.failedOver = true
+	s.lastAttempt = now
+	return true, true
+}
+
+// fallbackCore 原输出连续写入失败时切换到备用输出，原输出恢复后切换回来
+//
+// 切换时向备用输出写入一条 log_output_failover 日志，恢复时向原输出写入一条 log_output_recovered 日志
+type fallbackCore struct {
+	primary  zapcore.Core
+	fallback zapcore.Core
+	state    *fallbackState
+}
+
+// Enabled 实现zapcore.Core接口
+func (c *fallbackCore) Enabled(level zapcore.Level) bool {
+	return c.primary.Enabled(level)
+}
+
+// With 实现zapcore.Core接口
+func (c *fallbackCore) With(fields []Field) zapcore.Core {
+	return &fallbackCore{primary: c.primary.With(fields), fallback: c.fallback.With(fields), state: c.state}
+}
+
+// Check 实现zapcore.Core接口
+func (c *fallbackCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(ent.Level) {
+		return ce.AddCore(ent, c)
+	}
+	return ce
+}
+
+// Write 实现zapcore.Core接口，未达到失败次数时返回原输出的错误
+func (c *fallbackCore) Write(ent zapcore.Entry, fields []Field) error {
+	now := time.Now()
+	if c.state.tryPrimary(now) {
+		err := c.primary.Write(ent, fields)
+		if err == nil {
+			if c.state.succeeded() {
+				_ = c.primary.Write(c.event(now, fallbackRecoveredMessage), []Field{
+					String("output", c.state.output),
+					String("fallback", c.state.fallback),
+				})
+			}
+			return nil
+		}
+		useFallback, switched := c.state.failed(now)
+		if !useFallback {
+			return err
+		}
+		if switched {
+			_ = c.fallback.Write(c.event(now, fallbackFailoverMessage), []Field{
+				String("output", c.state.output),
+				String("fallback", c.state.fallback),
+				Int("failures", c.state.threshold),
+				Err(err),
+			})
+		}
+	}
+	return c.fallback.Write(ent, fields)
+}
+
+// queueUsage 返回原输出的队列使用率
+func (c *fallbackCore) queueUsage() float64 {
+	if q, ok := c.primary.(queueReporter); ok {
+		return q.queueUsage()
+	}
+	return 0
+}
+
+// event 返回切换输出时写入的日志
+func (c *fallbackCore) event(now time.Time, message string) zapcore.Entry {
+	return zapcore.Entry{Level: WarnLevel, Time: now, Message: message}
+}
+
+// Sync 实现zapcore.Core接口
+func (c *fallbackCore) Sync() error {
+	return errors.Join(c.primary.Sync(), c.fallback.Sync())
+}
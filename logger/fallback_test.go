@@ -0,0 +1,89 @@
+package logger
+
+import (
+	"bytes"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/constructorvirgil/virlog/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zapcore"
+)
+
+// failingWriter 可以模拟写入失败的输出
+type failingWriter struct {
+	mu   sync.Mutex
+	buf  bytes.Buffer
+	fail bool
+}
+
+func (w *failingWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.fail {
+		return 0, errors.New("no space left on device")
+	}
+	return w.buf.Write(p)
+}
+
+func (w *failingWriter) setFail(fail bool) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.fail = fail
+}
+
+func (w *failingWriter) String() string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.buf.String()
+}
+
+// 测试原输出连续写入失败时切换到备用输出，恢复后切换回来
+func TestFallbackOutput(t *testing.T) {
+	primary := &failingWriter{}
+	backup := &bytes.Buffer{}
+	require.NoError(t, RegisterSink("fallback-primary", zapcore.AddSync(primary)))
+	defer UnregisterSink("fallback-primary")
+	require.NoError(t, RegisterSink("fallback-backup", zapcore.AddSync(backup)))
+	defer UnregisterSink("fallback-backup")
+
+	cfg := config.DefaultConfig()
+	cfg.Format = "json"
+	cfg.Output = "sink:fallback-primary"
+	cfg.Fallback = &config.FallbackConfig{
+		Output:         "sink:fallback-backup",
+		ErrorThreshold: 2,
+		RetryInterval:  20 * time.Millisecond,
+	}
+	log, err := NewLogger(cfg)
+	require.NoError(t, err)
+
+	log.Info("before failure")
+	assert.Contains(t, primary.String(), "before failure")
+
+	// 未达到失败次数时日志丢失，与没有备用输出时相同
+	primary.setFail(true)
+	log.Info("lost")
+	assert.Empty(t, backup.String())
+
+	log.With(String("request_id", "req-1")).Info("failed over")
+	log.Info("on fallback")
+	out := backup.String()
+	assert.Contains(t, out, `"msg":"log_output_failover"`)
+	assert.Contains(t, out, "no space left on device")
+	assert.Contains(t, out, `"msg":"failed over","request_id":"req-1"`)
+	assert.Contains(t, out, "on fallback")
+
+	// 恢复后在重试间隔到达时切换回原输出
+	primary.setFail(false)
+	log.Info("still on fallback")
+	assert.Contains(t, backup.String(), "still on fallback")
+	time.Sleep(30 * time.Millisecond)
+	log.Info("recovered")
+	assert.Contains(t, primary.String(), `"msg":"recovered"`)
+	assert.Contains(t, primary.String(), `"msg":"log_output_recovered"`)
+	assert.NotContains(t, backup.String(), `"msg":"recovered"`)
+}
@@ -0,0 +1,67 @@
+package logger
+
+import "sync"
+
+// defaultFieldCapacity 从池中取出的字段切片的初始容量
+const defaultFieldCapacity = 16
+
+// maxPooledFieldCapacity 超过该容量的切片不再放回池中，避免个别大请求长期占用内存
+const maxPooledFieldCapacity = 256
+
+var fieldBuilderPool = sync.Pool{
+	New: func() interface{} {
+		return &FieldBuilder{fields: make([]Field, 0, defaultFieldCapacity)}
+	},
+}
+
+// FieldBuilder 可复用的字段构建器，用于在热路径上减少 []Field 的分配
+//
+// 用法:
+//
+//	fb := logger.Acquire()
+//	defer fb.Release()
+//	log.Info("msg", fb.Add(logger.String("k", "v"), logger.Int("n", 1)).Fields()...)
+//
+// Release 之后不能再使用 Fields 返回的切片
+type FieldBuilder struct {
+	fields []Field
+}
+
+// Acquire 从池中获取一个空的字段构建器
+func Acquire() *FieldBuilder {
+	return fieldBuilderPool.Get().(*FieldBuilder)
+}
+
+// Add 追加字段
+func (b *FieldBuilder) Add(fields ...Field) *FieldBuilder {
+	b.fields = append(b.fields, fields...)
+	return b
+}
+
+// Fields 返回已追加的字段
+func (b *FieldBuilder) Fields() []Field {
+	return b.fields
+}
+
+// Len 返回已追加的字段数量
+func (b *FieldBuilder) Len() int {
+	return len(b.fields)
+}
+
+// Reset 清空已追加的字段
+func (b *FieldBuilder) Reset() {
+	// 清除引用，避免池中的切片持有字段中的对象
+	for i := range b.fields {
+		b.fields[i] = Field{}
+	}
+	b.fields = b.fields[:0]
+}
+
+// Release 清空构建器并放回池中
+func (b *FieldBuilder) Release() {
+	if cap(b.fields) > maxPooledFieldCapacity {
+		return
+	}
+	b.Reset()
+	fieldBuilderPool.Put(b)
+}
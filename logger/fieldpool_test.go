@@ -0,0 +1,43 @@
+package logger
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// 测试字段构建器
+func TestFieldBuilder(t *testing.T) {
+	logger, buf := newBufferLogger(InfoLevel)
+
+	fb := Acquire()
+	fb.Add(String("key", "value")).Add(Int("count", 3))
+	assert.Equal(t, 2, fb.Len())
+
+	logger.Info("pooled fields", fb.Fields()...)
+	fb.Release()
+
+	logData := make(map[string]interface{})
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &logData))
+	assert.Equal(t, "value", logData["key"])
+	assert.Equal(t, float64(3), logData["count"])
+
+	// 重新获取的构建器应为空
+	fb = Acquire()
+	defer fb.Release()
+	assert.Equal(t, 0, fb.Len())
+}
+
+// 测试With不与父Logger共享字段切片
+func TestWithDoesNotShareFields(t *testing.T) {
+	logger, _ := newBufferLogger(InfoLevel)
+
+	parent := logger.With(String("a", "1")).(*zapLogger)
+	child1 := parent.With(String("b", "2")).(*zapLogger)
+	child2 := parent.With(String("c", "3")).(*zapLogger)
+
+	assert.Equal(t, "b", child1.fields[1].Key)
+	assert.Equal(t, "c", child2.fields[1].Key)
+}
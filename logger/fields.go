@@ -0,0 +1,343 @@
+package logger
+
+import (
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// maxObjectDepth 展开嵌套结构体和map的最大深度，超过后按JSON序列化，避免循环引用导致无限递归
+const maxObjectDepth = 32
+
+var (
+	timeType          = reflect.TypeOf(time.Time{})
+	durationType      = reflect.TypeOf(time.Duration(0))
+	errorType         = reflect.TypeOf((*error)(nil)).Elem()
+	jsonMarshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+)
+
+// Fields 将map展开为字段，按键名排序以保证输出顺序稳定
+//
+// 基本类型转换为对应的类型化字段，结构体和map作为嵌套对象输出（规则同 Object）
+func Fields(m map[string]any) []Field {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	fields := make([]Field, 0, len(keys))
+	for _, k := range keys {
+		fields = append(fields, valueField(k, m[k]))
+	}
+	return fields
+}
+
+// Object 将结构体或map作为嵌套对象输出
+//
+// 结构体字段名遵循json tag：支持重命名、"-" 忽略和 omitempty，未导出字段被忽略，
+// 没有tag的匿名结构体字段会展开到外层。值为nil时输出null。
+// log tag 为 redact 的字段输出 ***，为 hash 的字段输出SHA-256摘要，见 Any
+func Object(key string, v any) Field {
+	if v == nil {
+		return zap.Reflect(key, nil)
+	}
+	if m, ok := v.(zapcore.ObjectMarshaler); ok {
+		return zap.Object(key, m)
+	}
+	return zap.Object(key, reflectObject{v: reflect.ValueOf(v)})
+}
+
+// valueField 根据值的类型返回类型化字段
+func valueField(key string, v any) Field {
+	if v == nil {
+		return zap.Reflect(key, nil)
+	}
+	rv := reflect.ValueOf(v)
+	if isObjectKind(rv) {
+		return Object(key, v)
+	}
+	return zap.Any(key, v)
+}
+
+// isObjectKind 判断值是否应展开为嵌套对象
+func isObjectKind(rv reflect.Value) bool {
+	t := rv.Type()
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t == timeType || hasCustomEncoding(t) {
+		return false
+	}
+	switch t.Kind() {
+	case reflect.Struct:
+		return true
+	case reflect.Map:
+		return t.Key().Kind() == reflect.String
+	}
+	return false
+}
+
+// hasCustomEncoding 判断类型是否自定义了JSON或文本编码，或实现了error接口，这些类型不展开
+func hasCustomEncoding(t reflect.Type) bool {
+	pt := reflect.PtrTo(t)
+	return t.Implements(jsonMarshalerType) || pt.Implements(jsonMarshalerType) ||
+		t.Implements(textMarshalerType) || pt.Implements(textMarshalerType) ||
+		t.Implements(errorType) || pt.Implements(errorType)
+}
+
+// reflectObject 通过反射编码结构体或map
+type reflectObject struct {
+	v     reflect.Value
+	depth int
+}
+
+// MarshalLogObject 实现zapcore.ObjectMarshaler接口
+func (o reflectObject) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	v := indirect(o.v)
+	if !v.IsValid() {
+		return nil
+	}
+	switch v.Kind() {
+	case reflect.Struct:
+		return encodeStruct(enc, v, o.depth)
+	case reflect.Map:
+		keys := v.MapKeys()
+		sort.Slice(keys, func(i, j int) bool { return keys[i].String() < keys[j].String() })
+		for _, k := range keys {
+			if err := encodeValue(enc, k.String(), v.MapIndex(k), o.depth+1); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return addReflected(enc, "value", v)
+}
+
+// encodeStruct 按json tag编码结构体的字段
+func encodeStruct(enc zapcore.ObjectEncoder, v reflect.Value, depth int) error {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		name, omitEmpty, skip := jsonFieldName(sf)
+		if skip {
+			continue
+		}
+		fv := v.Field(i)
+
+		// 没有tag的匿名结构体展开到外层，与encoding/json一致
+		if sf.Anonymous && name == "" {
+			inner := indirect(fv)
+			if inner.IsValid() && inner.Kind() == reflect.Struct {
+				if err := encodeStruct(enc, inner, depth); err != nil {
+					return err
+				}
+				continue
+			}
+			if !sf.IsExported() {
+				continue
+			}
+		}
+		if name == "" {
+			name = sf.Name
+		}
+		if omitEmpty && fv.IsZero() {
+			continue
+		}
+		if mode := sensitiveMode(sf); mode != "" {
+			if err := encodeSensitive(enc, name, mode, fv); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := encodeValue(enc, name, fv, depth+1); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// jsonFieldName 解析结构体字段的json tag，返回字段名、是否omitempty以及是否忽略该字段
+func jsonFieldName(sf reflect.StructField) (name string, omitEmpty, skip bool) {
+	if !sf.IsExported() && !sf.Anonymous {
+		return "", false, true
+	}
+	tag, ok := sf.Tag.Lookup("json")
+	if !ok {
+		return "", false, false
+	}
+	if tag == "-" {
+		return "", false, true
+	}
+	name, opts, _ := strings.Cut(tag, ",")
+	for opts != "" {
+		var opt string
+		opt, opts, _ = strings.Cut(opts, ",")
+		if opt == "omitempty" {
+			omitEmpty = true
+		}
+	}
+	return name, omitEmpty, false
+}
+
+// encodeValue 按值的类型调用编码器对应的方法
+func encodeValue(enc zapcore.ObjectEncoder, key string, v reflect.Value, depth int) error {
+	if v.Kind() == reflect.Interface || v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return enc.AddReflected(key, nil)
+		}
+	}
+	if v.CanInterface() {
+		if m, ok := v.Interface().(zapcore.ObjectMarshaler); ok {
+			return enc.AddObject(key, m)
+		}
+		if m, ok := v.Interface().(zapcore.ArrayMarshaler); ok {
+			return enc.AddArray(key, m)
+		}
+	}
+	v = indirect(v)
+	// 未导出的匿名结构体中的字段无法取得接口值，只按基本类型编码
+	if v.CanInterface() {
+		if depth > maxObjectDepth {
+			return enc.AddReflected(key, v.Interface())
+		}
+		switch {
+		case v.Type() == timeType:
+			enc.AddTime(key, v.Interface().(time.Time))
+			return nil
+		case v.Type() == durationType:
+			enc.AddDuration(key, time.Duration(v.Int()))
+			return nil
+		case v.Type().Implements(errorType):
+			enc.AddString(key, v.Interface().(error).Error())
+			return nil
+		case hasCustomEncoding(v.Type()):
+			return enc.AddReflected(key, v.Interface())
+		}
+	}
+
+	switch v.Kind() {
+	case reflect.Bool:
+		enc.AddBool(key, v.Bool())
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		enc.AddInt64(key, v.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		enc.AddUint64(key, v.Uint())
+	case reflect.Float32:
+		enc.AddFloat32(key, float32(v.Float()))
+	case reflect.Float64:
+		enc.AddFloat64(key, v.Float())
+	case reflect.String:
+		enc.AddString(key, v.String())
+	case reflect.Struct:
+		return enc.AddObject(key, reflectObject{v: v, depth: depth})
+	case reflect.Map:
+		if v.IsNil() {
+			return enc.AddReflected(key, nil)
+		}
+		if v.Type().Key().Kind() != reflect.String {
+			return addReflected(enc, key, v)
+		}
+		return enc.AddObject(key, reflectObject{v: v, depth: depth})
+	case reflect.Slice, reflect.Array:
+		if v.Kind() == reflect.Slice && v.IsNil() {
+			return enc.AddReflected(key, nil)
+		}
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			// 与encoding/json一致，[]byte按base64编码
+			return addReflected(enc, key, v)
+		}
+		return enc.AddArray(key, reflectArray{v: v, depth: depth})
+	default:
+		return addReflected(enc, key, v)
+	}
+	return nil
+}
+
+// addReflected 按JSON序列化值，无法取得接口值时输出其字符串形式
+func addReflected(enc zapcore.ObjectEncoder, key string, v reflect.Value) error {
+	if v.CanInterface() {
+		return enc.AddReflected(key, v.Interface())
+	}
+	enc.AddString(key, fmt.Sprint(v))
+	return nil
+}
+
+// reflectArray 通过反射编码切片或数组
+type reflectArray struct {
+	v     reflect.Value
+	depth int
+}
+
+// MarshalLogArray 实现zapcore.ArrayMarshaler接口
+func (a reflectArray) MarshalLogArray(enc zapcore.ArrayEncoder) error {
+	for i := 0; i < a.v.Len(); i++ {
+		elem := indirect(a.v.Index(i))
+		if !elem.IsValid() {
+			if err := enc.AppendReflected(nil); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := appendValue(enc, elem, a.depth+1); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// appendValue 将数组元素追加到编码器
+func appendValue(enc zapcore.ArrayEncoder, v reflect.Value, depth int) error {
+	if v.CanInterface() {
+		if m, ok := v.Interface().(zapcore.ObjectMarshaler); ok {
+			return enc.AppendObject(m)
+		}
+	}
+	if depth > maxObjectDepth || !isObjectKind(v) {
+		switch v.Kind() {
+		case reflect.Bool:
+			enc.AppendBool(v.Bool())
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			if v.Type() == durationType {
+				enc.AppendDuration(time.Duration(v.Int()))
+			} else {
+				enc.AppendInt64(v.Int())
+			}
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+			enc.AppendUint64(v.Uint())
+		case reflect.Float32, reflect.Float64:
+			enc.AppendFloat64(v.Float())
+		case reflect.String:
+			enc.AppendString(v.String())
+		default:
+			if !v.CanInterface() {
+				enc.AppendString(fmt.Sprint(v))
+			} else if v.Type() == timeType {
+				enc.AppendTime(v.Interface().(time.Time))
+			} else {
+				return enc.AppendReflected(v.Interface())
+			}
+		}
+		return nil
+	}
+	return enc.AppendObject(reflectObject{v: v, depth: depth})
+}
+
+// indirect 解引用指针和接口，nil时返回零值
+func indirect(v reflect.Value) reflect.Value {
+	for v.IsValid() && (v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface) {
+		if v.IsNil() {
+			return reflect.Value{}
+		}
+		v = v.Elem()
+	}
+	return v
+}
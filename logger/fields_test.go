@@ -0,0 +1,163 @@
+package logger
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zapcore"
+)
+
+type fieldsAddress struct {
+	City string `json:"city"`
+	Zip  string `json:"zip,omitempty"`
+}
+
+type fieldsAudit struct {
+	CreatedBy string `json:"created_by"`
+}
+
+type fieldsUser struct {
+	fieldsAudit
+	ID       int               `json:"id"`
+	Name     string            `json:"name"`
+	Password string            `json:"-"`
+	Email    string            `json:"email,omitempty"`
+	Address  *fieldsAddress    `json:"address"`
+	Tags     []string          `json:"tags"`
+	Labels   map[string]string `json:"labels"`
+	Timeout  time.Duration     `json:"timeout"`
+	Plain    bool
+	secret   string
+}
+
+// 测试Object按json tag展开结构体
+func TestObjectStruct(t *testing.T) {
+	log, buf := newBufferLogger(InfoLevel)
+	user := fieldsUser{
+		fieldsAudit: fieldsAudit{CreatedBy: "admin"},
+		ID:          7,
+		Name:        "alice",
+		Password:    "p@ss",
+		Address:     &fieldsAddress{City: "Shanghai"},
+		Tags:        []string{"a", "b"},
+		Labels:      map[string]string{"team": "core"},
+		Timeout:     time.Second,
+		Plain:       true,
+		secret:      "hidden",
+	}
+	log.Info("object", Object("user", user), Object("nil", nil))
+
+	entry := findLogEntry(t, buf.String(), "object")
+	require.NotNil(t, entry)
+	obj, ok := entry["user"].(map[string]interface{})
+	require.True(t, ok)
+	assert.Equal(t, float64(7), obj["id"])
+	assert.Equal(t, "alice", obj["name"])
+	assert.Equal(t, "admin", obj["created_by"], "匿名结构体展开到外层")
+	assert.Equal(t, true, obj["Plain"])
+	assert.Equal(t, map[string]interface{}{"city": "Shanghai"}, obj["address"])
+	assert.Equal(t, []interface{}{"a", "b"}, obj["tags"])
+	assert.Equal(t, map[string]interface{}{"team": "core"}, obj["labels"])
+	assert.Equal(t, float64(1), obj["timeout"])
+	assert.NotContains(t, obj, "Password")
+	assert.NotContains(t, obj, "email")
+	assert.NotContains(t, obj, "secret")
+	assert.Nil(t, entry["nil"])
+	assert.Contains(t, entry, "nil")
+}
+
+// 测试Fields将map展开为类型化字段
+func TestFieldsMap(t *testing.T) {
+	log, buf := newBufferLogger(InfoLevel)
+	fields := Fields(map[string]any{
+		"count":   3,
+		"name":    "job",
+		"ok":      true,
+		"err":     errors.New("boom"),
+		"address": fieldsAddress{City: "Beijing", Zip: "100000"},
+		"meta":    map[string]any{"retry": 2, "nested": map[string]any{"x": 1.5}},
+		"empty":   nil,
+	})
+	require.Len(t, fields, 7)
+	assert.Equal(t, "address", fields[0].Key, "按键名排序")
+	log.Info("fields", fields...)
+
+	entry := findLogEntry(t, buf.String(), "fields")
+	require.NotNil(t, entry)
+	assert.Equal(t, float64(3), entry["count"])
+	assert.Equal(t, "job", entry["name"])
+	assert.Equal(t, true, entry["ok"])
+	assert.Equal(t, "boom", entry["err"])
+	assert.Equal(t, map[string]interface{}{"city": "Beijing", "zip": "100000"}, entry["address"])
+	assert.Equal(t, map[string]interface{}{"retry": float64(2), "nested": map[string]interface{}{"x": 1.5}}, entry["meta"])
+	assert.Nil(t, entry["empty"])
+}
+
+type fieldsNode struct {
+	Name string      `json:"name"`
+	Next *fieldsNode `json:"next,omitempty"`
+}
+
+// 测试循环引用不会无限递归
+func TestObjectCycle(t *testing.T) {
+	log, buf := newBufferLogger(InfoLevel)
+	node := &fieldsNode{Name: "a"}
+	node.Next = node
+	log.Info("cycle", Object("node", node))
+	assert.Contains(t, buf.String(), "cycle")
+}
+
+// fieldsPoint 实现了 zapcore.ObjectMarshaler 的测试类型
+type fieldsPoint struct{ X, Y int }
+
+func (p fieldsPoint) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	enc.AddInt("x", p.X)
+	enc.AddInt("y", p.Y)
+	return nil
+}
+
+// 测试无需导入zap即可构造各类字段
+func TestFieldConstructors(t *testing.T) {
+	log, buf := newBufferLogger(InfoLevel)
+	var nilDuration *time.Duration
+	timeout := 2 * time.Second
+	log.Info("fields",
+		Uint("uint", 1),
+		Uint64("uint64", 2),
+		Float32("float32", 1.5),
+		Stringer("stringer", time.Minute),
+		Strings("strings", []string{"a", "b"}),
+		Ints("ints", []int{1, 2}),
+		Durationp("timeout", &timeout),
+		Durationp("nil_timeout", nilDuration),
+		ByteString("bytes", []byte("raw")),
+		Object("point", fieldsPoint{X: 1, Y: 2}),
+		Objects("points", []fieldsPoint{{X: 3, Y: 4}}),
+		Array("array", zapcore.ArrayMarshalerFunc(func(enc zapcore.ArrayEncoder) error {
+			enc.AppendString("x")
+			return nil
+		})),
+		Inline(fieldsPoint{X: 5, Y: 6}),
+		Dict("dict", String("k", "v")),
+	)
+
+	entry := findLogEntry(t, buf.String(), "fields")
+	require.NotNil(t, entry)
+	assert.Equal(t, float64(1), entry["uint"])
+	assert.Equal(t, float64(2), entry["uint64"])
+	assert.Equal(t, 1.5, entry["float32"])
+	assert.Equal(t, "1m0s", entry["stringer"])
+	assert.Equal(t, []interface{}{"a", "b"}, entry["strings"])
+	assert.Equal(t, []interface{}{float64(1), float64(2)}, entry["ints"])
+	assert.NotNil(t, entry["timeout"])
+	assert.Nil(t, entry["nil_timeout"])
+	assert.Equal(t, "raw", entry["bytes"])
+	assert.Equal(t, map[string]interface{}{"x": float64(1), "y": float64(2)}, entry["point"])
+	assert.Equal(t, []interface{}{map[string]interface{}{"x": float64(3), "y": float64(4)}}, entry["points"])
+	assert.Equal(t, []interface{}{"x"}, entry["array"])
+	assert.Equal(t, float64(5), entry["x"])
+	assert.Equal(t, map[string]interface{}{"k": "v"}, entry["dict"])
+}
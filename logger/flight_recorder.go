@@ -0,0 +1,145 @@
+package logger
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"sync"
+
+	"github.com/constructorvirgil/virlog/config"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+const (
+	// defaultFlightRecorderSize 未设置时内存中保留的日志条数
+	defaultFlightRecorderSize = 100
+	// triggerIDKey 关联触发日志和回放日志的字段名
+	triggerIDKey = "trigger_id"
+)
+
+// flightRecord 缓存的一条日志，core 为写入时带有上下文字段的core
+type flightRecord struct {
+	core   zapcore.Core
+	entry  zapcore.Entry
+	fields []Field
+}
+
+// flightRing 保存最近日志的环形缓冲区，由同一Logger派生的所有core共享
+type flightRing struct {
+	mu      sync.Mutex
+	records []flightRecord
+	next    int
+	full    bool
+}
+
+// add 添加一条日志，缓冲区满时覆盖最旧的日志
+func (r *flightRing) add(rec flightRecord) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.records[r.next] = rec
+	r.next++
+	if r.next == len(r.records) {
+		r.next = 0
+		r.full = true
+	}
+}
+
+// drain 按写入顺序取出并清空缓冲区中的日志
+func (r *flightRing) drain() []flightRecord {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var out []flightRecord
+	if r.full {
+		out = append(out, r.records[r.next:]...)
+	}
+	out = append(out, r.records[:r.next]...)
+	for i := range r.records {
+		r.records[i] = flightRecord{}
+	}
+	r.next = 0
+	r.full = false
+	return out
+}
+
+// flightRecorderCore 将低于日志级别的日志保留在内存中，遇到触发级别的日志时先输出这些日志
+//
+// 内部的core不做级别过滤，由 flightRecorderCore 按级别决定直接输出还是缓存
+type flightRecorderCore struct {
+	inner   zapcore.Core
+	gate    levelGate
+	trigger zapcore.Level
+	ring    *flightRing
+}
+
+// flightRecorderOption 根据配置返回启用上下文日志回放的选项，未配置时返回nil
+func flightRecorderOption(cfg *config.Config, gate levelGate) zap.Option {
+	if cfg.FlightRecorder == nil {
+		return nil
+	}
+	size := cfg.FlightRecorder.Size
+	if size <= 0 {
+		size = defaultFlightRecorderSize
+	}
+	trigger := ErrorLevel
+	if l, ok := parseLevel(cfg.FlightRecorder.TriggerLevel); ok {
+		trigger = l
+	}
+
+	ring := &flightRing{records: make([]flightRecord, size)}
+	return zap.WrapCore(func(core zapcore.Core) zapcore.Core {
+		return &flightRecorderCore{inner: core, gate: gate, trigger: trigger, ring: ring}
+	})
+}
+
+// Enabled 实现zapcore.Core接口，所有级别的日志都会被输出或缓存
+func (c *flightRecorderCore) Enabled(level zapcore.Level) bool {
+	return c.inner.Enabled(level)
+}
+
+// With 实现zapcore.Core接口
+func (c *flightRecorderCore) With(fields []Field) zapcore.Core {
+	return &flightRecorderCore{inner: c.inner.With(fields), gate: c.gate.with(fields), trigger: c.trigger, ring: c.ring}
+}
+
+// Check 实现zapcore.Core接口
+func (c *flightRecorderCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if !c.gate.enabled(ent.Level) {
+		// 在 Write 中缓存，此时日志已带有调用者和调用栈信息
+		return ce.AddCore(ent, c)
+	}
+	if ent.Level < c.trigger {
+		return c.inner.Check(ent, ce)
+	}
+
+	records := c.ring.drain()
+	if len(records) == 0 {
+		return c.inner.Check(ent, ce)
+	}
+	triggerID := String(triggerIDKey, newTriggerID())
+	for _, rec := range records {
+		if rce := rec.core.Check(rec.entry, nil); rce != nil {
+			rce.Write(append(rec.fields, triggerID)...)
+		}
+	}
+	return c.inner.With([]Field{triggerID}).Check(ent, ce)
+}
+
+// Write 实现zapcore.Core接口，只会收到低于日志级别的日志
+func (c *flightRecorderCore) Write(ent zapcore.Entry, fields []Field) error {
+	// 复制字段，调用方可能复用字段切片
+	c.ring.add(flightRecord{core: c.inner, entry: ent, fields: append([]Field(nil), fields...)})
+	return nil
+}
+
+// Sync 实现zapcore.Core接口
+func (c *flightRecorderCore) Sync() error {
+	return c.inner.Sync()
+}
+
+// newTriggerID 生成随机的触发ID
+func newTriggerID() string {
+	var b [8]byte
+	_, _ = rand.Read(b[:])
+	return hex.EncodeToString(b[:])
+}
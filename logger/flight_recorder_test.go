@@ -0,0 +1,71 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/constructorvirgil/virlog/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zapcore"
+)
+
+// 测试错误日志触发输出缓存的低级别日志
+func TestFlightRecorder(t *testing.T) {
+	buf := &bytes.Buffer{}
+	cfg := config.DefaultConfig()
+	cfg.Format = "json"
+	cfg.Level = "info"
+	cfg.EnableStacktrace = false
+	cfg.FlightRecorder = &config.FlightRecorderConfig{Size: 3}
+	log, err := NewLogger(cfg, WithSyncTarget(zapcore.AddSync(buf)))
+	require.NoError(t, err)
+
+	reqLog := log.With(String("request_id", "req-1"))
+	for _, msg := range []string{"debug 1", "debug 2", "debug 3", "debug 4"} {
+		reqLog.Debug(msg, String("step", msg))
+	}
+	log.Info("info")
+	assert.NotContains(t, buf.String(), "debug", "未触发时不输出缓存的日志")
+
+	reqLog.Error("failed")
+
+	var entries []map[string]interface{}
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		entry := make(map[string]interface{})
+		require.NoError(t, json.Unmarshal([]byte(line), &entry))
+		entries = append(entries, entry)
+	}
+	require.Len(t, entries, 5)
+	assert.Equal(t, "info", entries[0]["msg"])
+	assert.NotContains(t, entries[0], triggerIDKey)
+
+	// 只保留最近的3条，按写入顺序输出
+	triggerID := entries[4][triggerIDKey]
+	require.NotEmpty(t, triggerID)
+	for i, msg := range []string{"debug 2", "debug 3", "debug 4"} {
+		entry := entries[i+1]
+		assert.Equal(t, msg, entry["msg"])
+		assert.Equal(t, "debug", entry["level"])
+		assert.Equal(t, msg, entry["step"])
+		assert.Equal(t, "req-1", entry["request_id"])
+		assert.Contains(t, entry["caller"], "flight_recorder_test.go")
+		assert.Equal(t, triggerID, entry[triggerIDKey])
+	}
+	assert.Equal(t, "failed", entries[4]["msg"])
+	assert.Equal(t, "req-1", entries[4]["request_id"])
+
+	// 缓冲区已清空，再次出错时不重复输出
+	buf.Reset()
+	log.Error("failed again")
+	assert.Equal(t, 1, strings.Count(buf.String(), "\n"))
+	assert.NotContains(t, buf.String(), triggerIDKey)
+
+	// 调低级别后直接输出，不再缓存
+	log.SetLevel(DebugLevel)
+	buf.Reset()
+	log.Debug("direct")
+	assert.Contains(t, buf.String(), "direct")
+}
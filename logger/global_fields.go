@@ -0,0 +1,73 @@
+package logger
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+var (
+	// globalFields 默认Logger每条日志附加的字段，通过 SetGlobalFields 和 AddGlobalField 修改
+	globalFields atomic.Pointer[[]Field]
+	// globalFieldsMu 串行执行对 globalFields 的修改
+	globalFieldsMu sync.Mutex
+)
+
+// SetGlobalFields 替换默认Logger附加到之后每条日志的字段，不传参数时清空
+//
+// 适用于运行期间变化的信息（如选主后的角色、故障切换后的区域），无需重新创建Logger，
+// 也不会丢失通过 SetLevel 设置的级别。字段对全局日志函数、DefaultLogger 及其 With 创建的Logger生效
+func SetGlobalFields(fields ...Field) {
+	globalFieldsMu.Lock()
+	defer globalFieldsMu.Unlock()
+
+	copied := append([]Field(nil), fields...)
+	globalFields.Store(&copied)
+}
+
+// AddGlobalField 添加默认Logger附加到之后每条日志的字段，已存在同名字段时替换
+func AddGlobalField(field Field) {
+	globalFieldsMu.Lock()
+	defer globalFieldsMu.Unlock()
+
+	var current []Field
+	if p := globalFields.Load(); p != nil {
+		current = *p
+	}
+	updated := make([]Field, 0, len(current)+1)
+	for _, f := range current {
+		if f.Key != field.Key {
+			updated = append(updated, f)
+		}
+	}
+	updated = append(updated, field)
+	globalFields.Store(&updated)
+}
+
+// GlobalFields 返回当前的全局字段
+func GlobalFields() []Field {
+	if p := globalFields.Load(); p != nil {
+		return append([]Field(nil), *p...)
+	}
+	return nil
+}
+
+// withGlobalFields 使Logger在每条日志前附加全局字段，用于默认Logger
+func withGlobalFields() Option {
+	return func(l *zapLogger) {
+		l.globalFields = true
+	}
+}
+
+// appendGlobalFields 在日志字段前附加当前的全局字段
+func (l *zapLogger) appendGlobalFields(fields []Field) []Field {
+	if !l.globalFields {
+		return fields
+	}
+	p := globalFields.Load()
+	if p == nil || len(*p) == 0 {
+		return fields
+	}
+	merged := make([]Field, 0, len(*p)+len(fields))
+	merged = append(merged, *p...)
+	return append(merged, fields...)
+}
@@ -0,0 +1,60 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/constructorvirgil/virlog/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zapcore"
+)
+
+// 测试运行时修改默认Logger的全局字段
+func TestGlobalFields(t *testing.T) {
+	originalStd := DefaultLogger()
+	defer SetDefault(originalStd)
+	defer SetGlobalFields()
+
+	buf := &bytes.Buffer{}
+	log, err := NewLogger(config.DefaultConfig(), WithSyncTarget(zapcore.AddSync(buf)))
+	require.NoError(t, err)
+	SetDefault(log)
+
+	lastEntry := func() map[string]interface{} {
+		lines := bytes.Split(bytes.TrimSpace(buf.Bytes()), []byte("\n"))
+		entry := make(map[string]interface{})
+		require.NoError(t, json.Unmarshal(lines[len(lines)-1], &entry))
+		return entry
+	}
+
+	Info("before")
+	assert.NotContains(t, lastEntry(), "role")
+
+	// 通过With创建的Logger同样附加之后设置的全局字段
+	child := With(String("component", "scheduler"))
+	SetGlobalFields(String("role", "follower"), String("region", "us-east"))
+	SetLevel(DebugLevel)
+
+	Debug("global function")
+	entry := lastEntry()
+	assert.Equal(t, "follower", entry["role"])
+	assert.Equal(t, "us-east", entry["region"])
+
+	AddGlobalField(String("role", "leader"))
+	child.Info("child logger")
+	entry = lastEntry()
+	assert.Equal(t, "leader", entry["role"])
+	assert.Equal(t, "us-east", entry["region"])
+	assert.Equal(t, "scheduler", entry["component"])
+	assert.Len(t, GlobalFields(), 2)
+
+	// 非默认Logger不受影响
+	log.Info("not default")
+	assert.NotContains(t, lastEntry(), "role")
+
+	SetGlobalFields()
+	Info("cleared")
+	assert.NotContains(t, lastEntry(), "role")
+}
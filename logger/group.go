@@ -0,0 +1,94 @@
+package logger
+
+import (
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// Group 将字段嵌套在名为 name 的对象中输出，如 Group("db", Int("rows", 3)) 输出 {"db":{"rows":3}}
+//
+// 与 Namespace 不同，分组只包含传入的字段，不影响之后添加的字段，可以与其他字段一起传给 With 或日志方法。
+// 没有字段时不输出，name 为空时字段直接输出在外层；分组中同名的子分组合并为一个对象
+func Group(name string, fields ...Field) Field {
+	if len(fields) == 0 {
+		return zap.Skip()
+	}
+	if name == "" {
+		return zap.Inline(fieldGroup(fields))
+	}
+	return zap.Object(name, fieldGroup(fields))
+}
+
+// fieldGroup 分组中的字段
+type fieldGroup []Field
+
+// MarshalLogObject 实现zapcore.ObjectMarshaler接口
+func (g fieldGroup) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	// 按首次出现的位置合并同名子分组
+	var merged map[string]fieldGroup
+	for _, f := range g {
+		if sub, ok := subGroup(f); ok {
+			if merged == nil {
+				merged = make(map[string]fieldGroup)
+			}
+			merged[f.Key] = append(merged[f.Key], sub...)
+		}
+	}
+	for _, f := range g {
+		if _, ok := subGroup(f); !ok {
+			f.AddTo(enc)
+			continue
+		}
+		sub, first := merged[f.Key]
+		if !first {
+			continue
+		}
+		delete(merged, f.Key)
+		if err := enc.AddObject(f.Key, sub); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// subGroup 返回通过 Group 创建的具名分组的字段
+func subGroup(f Field) (fieldGroup, bool) {
+	if f.Type != zapcore.ObjectMarshalerType {
+		return nil, false
+	}
+	g, ok := f.Interface.(fieldGroup)
+	return g, ok
+}
+
+// FieldGroup 逐步构建分组字段，如访问日志、数据库统计等在不同位置收集的字段
+//
+// 用法:
+//
+//	db := logger.NewGroup("db").Add(logger.Int("rows", rows))
+//	db.Add(logger.Duration("elapsed", elapsed))
+//	log.With(db.Field()).Info("query")
+type FieldGroup struct {
+	name   string
+	fields []Field
+}
+
+// NewGroup 创建名为 name 的分组
+func NewGroup(name string) *FieldGroup {
+	return &FieldGroup{name: name}
+}
+
+// Add 追加字段，返回分组自身以便链式调用
+func (g *FieldGroup) Add(fields ...Field) *FieldGroup {
+	g.fields = append(g.fields, fields...)
+	return g
+}
+
+// Len 返回分组中的字段数
+func (g *FieldGroup) Len() int {
+	return len(g.fields)
+}
+
+// Field 返回包含当前所有字段的分组字段，之后追加的字段不影响已返回的字段
+func (g *FieldGroup) Field() Field {
+	return Group(g.name, append([]Field(nil), g.fields...)...)
+}
@@ -0,0 +1,52 @@
+package logger
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// 测试分组字段嵌套输出，且不影响之后添加的字段
+func TestGroup(t *testing.T) {
+	log, buf := newBufferLogger(InfoLevel)
+
+	log.With(Group("http", String("method", "GET"), Int("status", 200))).Info("request",
+		Group("db", Int("rows", 3), Group("pool", Int("idle", 1))),
+		String("user", "alice"),
+		Group("empty"),
+		Group("", String("inline", "yes")),
+	)
+
+	entry := findLogEntry(t, buf.String(), "request")
+	require.NotNil(t, entry)
+	assert.Equal(t, map[string]interface{}{"method": "GET", "status": float64(200)}, entry["http"])
+	assert.Equal(t, map[string]interface{}{"rows": float64(3), "pool": map[string]interface{}{"idle": float64(1)}}, entry["db"])
+	assert.Equal(t, "alice", entry["user"])
+	assert.Equal(t, "yes", entry["inline"])
+	assert.NotContains(t, entry, "empty")
+}
+
+// 测试同名子分组合并，以及构建器返回的字段不受之后追加的字段影响
+func TestFieldGroup(t *testing.T) {
+	log, buf := newBufferLogger(InfoLevel)
+
+	db := NewGroup("db").Add(Int("rows", 3))
+	first := db.Field()
+	db.Add(Group("stats", Int("hits", 1)), Group("stats", Int("misses", 2)))
+	assert.Equal(t, 3, db.Len())
+
+	log.Info("first", first)
+	log.With(db.Field()).Info("second")
+
+	entry := findLogEntry(t, buf.String(), "first")
+	require.NotNil(t, entry)
+	assert.Equal(t, map[string]interface{}{"rows": float64(3)}, entry["db"])
+
+	entry = findLogEntry(t, buf.String(), "second")
+	require.NotNil(t, entry)
+	assert.Equal(t, map[string]interface{}{
+		"rows":  float64(3),
+		"stats": map[string]interface{}{"hits": float64(1), "misses": float64(2)},
+	}, entry["db"])
+}
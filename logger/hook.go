@@ -0,0 +1,121 @@
+package logger
+
+import (
+	"fmt"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// Hook 每条日志写入时调用的钩子，fields 包含With添加的上下文字段和本条日志的字段
+//
+// 可用于统计指标、上报错误到外部系统等；钩子返回的错误和发生的panic都不会影响日志写入，
+// 只会输出到zap的ErrorOutput
+type Hook func(entry zapcore.Entry, fields []Field) error
+
+// WithHook 注册对所有已启用级别生效的钩子
+func WithHook(hook Hook) Option {
+	return WithLevelHook(zap.LevelEnablerFunc(func(Level) bool { return true }), hook)
+}
+
+// WithLevelHook 注册只对指定级别生效的钩子，如 WithLevelHook(ErrorLevel, hook) 只在Error及以上级别调用
+func WithLevelHook(enabler zapcore.LevelEnabler, hook Hook) Option {
+	return func(l *zapLogger) {
+		if hook == nil {
+			return
+		}
+		l.hooks = append(l.hooks, levelHook{hook: hook, enabler: enabler})
+	}
+}
+
+// levelHook 注册的钩子及其生效的级别
+type levelHook struct {
+	hook    Hook
+	enabler zapcore.LevelEnabler
+}
+
+// hookOptions 将注册的钩子转换为zap选项，钩子按注册顺序执行
+func (l *zapLogger) hookOptions() []zap.Option {
+	options := make([]zap.Option, 0, len(l.hooks))
+	for _, h := range l.hooks {
+		options = append(options, h.option(nil))
+	}
+	return options
+}
+
+// option 将钩子转换为zap选项，fields 为已通过With添加到core中的上下文字段
+func (h levelHook) option(fields []Field) zap.Option {
+	return zap.WrapCore(func(core zapcore.Core) zapcore.Core {
+		return &hookCore{Core: core, hook: h.hook, enabler: h.enabler, fields: fields}
+	})
+}
+
+// hookCore 在底层core接受日志后调用钩子的core
+type hookCore struct {
+	zapcore.Core
+	hook    Hook
+	enabler zapcore.LevelEnabler
+	// fields With添加的上下文字段
+	fields []Field
+}
+
+// With 实现zapcore.Core接口
+func (c *hookCore) With(fields []Field) zapcore.Core {
+	merged := make([]Field, 0, len(c.fields)+len(fields))
+	merged = append(merged, c.fields...)
+	merged = append(merged, fields...)
+	return &hookCore{
+		Core:    c.Core.With(fields),
+		hook:    c.hook,
+		enabler: c.enabler,
+		fields:  merged,
+	}
+}
+
+// Check 实现zapcore.Core接口，只有底层core接受的日志才会调用钩子
+func (c *hookCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	downstream := c.Core.Check(ent, ce)
+	if downstream == nil || !c.enabler.Enabled(ent.Level) {
+		return downstream
+	}
+	return downstream.AddCore(ent, &hookWriter{core: c})
+}
+
+// hookWriter 执行钩子的core，只由CheckedEntry调用其Write
+type hookWriter struct {
+	core *hookCore
+}
+
+// Enabled 实现zapcore.Core接口
+func (w *hookWriter) Enabled(Level) bool { return true }
+
+// With 实现zapcore.Core接口
+func (w *hookWriter) With([]Field) zapcore.Core { return w }
+
+// Check 实现zapcore.Core接口
+func (w *hookWriter) Check(_ zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	return ce
+}
+
+// Write 实现zapcore.Core接口，调用钩子并捕获其中的panic
+func (w *hookWriter) Write(ent zapcore.Entry, fields []Field) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("日志钩子panic: %v", r)
+		}
+	}()
+
+	all := fields
+	if len(w.core.fields) > 0 {
+		all = make([]Field, 0, len(w.core.fields)+len(fields))
+		all = append(all, w.core.fields...)
+		all = append(all, fields...)
+	}
+	if err := w.core.hook(ent, all); err != nil {
+		return fmt.Errorf("日志钩子执行失败: %w", err)
+	}
+	return nil
+}
+
+// Sync 实现zapcore.Core接口
+func (w *hookWriter) Sync() error { return nil }
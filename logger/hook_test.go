@@ -0,0 +1,82 @@
+package logger
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/constructorvirgil/virlog/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// 测试日志钩子
+func TestWithHook(t *testing.T) {
+	var (
+		all    []string
+		errs   []string
+		fields []Field
+	)
+
+	buf := &bytes.Buffer{}
+	cfg := config.DefaultConfig()
+	cfg.Level = "debug"
+	log, err := NewLogger(cfg,
+		WithSyncTarget(zapcore.AddSync(buf)),
+		WithHook(func(ent zapcore.Entry, fs []Field) error {
+			all = append(all, ent.Message)
+			fields = fs
+			return nil
+		}),
+		WithLevelHook(ErrorLevel, func(ent zapcore.Entry, fs []Field) error {
+			errs = append(errs, ent.Message)
+			return nil
+		}),
+	)
+	require.NoError(t, err)
+
+	reqLog := log.With(String("request_id", "req-1"))
+	reqLog.Debug("debug")
+	reqLog.Info("info", Int("status", 200))
+	reqLog.Error("error")
+
+	assert.Equal(t, []string{"debug", "info", "error"}, all)
+	assert.Equal(t, []string{"error"}, errs)
+	require.Len(t, fields, 1)
+	assert.Equal(t, "request_id", fields[0].Key)
+
+	// 未启用的级别不会调用钩子
+	log.SetLevel(WarnLevel)
+	log.Info("ignored")
+	assert.Len(t, all, 3)
+}
+
+// 测试钩子的panic和错误不影响日志写入
+func TestHookFailureDoesNotDropEntry(t *testing.T) {
+	buf := &bytes.Buffer{}
+	errOut := &bytes.Buffer{}
+	cfg := config.DefaultConfig()
+	log, err := NewLogger(cfg,
+		WithSyncTarget(zapcore.AddSync(buf)),
+		WithHook(func(zapcore.Entry, []Field) error {
+			panic("hook exploded")
+		}),
+		WithHook(func(zapcore.Entry, []Field) error {
+			return errors.New("hook failed")
+		}),
+	)
+	require.NoError(t, err)
+
+	// 将zap的内部错误输出重定向到缓冲区
+	raw := log.GetRawZapLogger().WithOptions(zap.ErrorOutput(zapcore.AddSync(errOut)))
+	assert.NotPanics(t, func() {
+		raw.Info("still written")
+	})
+
+	assert.Contains(t, buf.String(), "still written")
+	assert.True(t, strings.Contains(errOut.String(), "hook exploded"), errOut.String())
+	assert.True(t, strings.Contains(errOut.String(), "hook failed"), errOut.String())
+}
@@ -0,0 +1,193 @@
+package logger
+
+import (
+	"io"
+	"net/http"
+	"time"
+)
+
+const (
+	// defaultRequestIDHeader 默认传递请求ID的请求头
+	defaultRequestIDHeader = "X-Request-ID"
+	// defaultClientRetryBackoff 默认的重试间隔，第n次重试等待n倍间隔
+	defaultClientRetryBackoff = 100 * time.Millisecond
+)
+
+// clientConfig HTTP客户端日志的配置
+type clientConfig struct {
+	logger          Logger
+	requestIDHeader string
+	maxRetries      int
+	retryBackoff    time.Duration
+}
+
+// ClientOption 定义HTTP客户端日志选项的函数类型
+type ClientOption func(*clientConfig)
+
+// WithClientLogger 设置记录日志的Logger，默认使用请求上下文中的Logger
+func WithClientLogger(logger Logger) ClientOption {
+	return func(c *clientConfig) {
+		c.logger = logger
+	}
+}
+
+// WithRequestIDHeader 设置向下游传递请求ID的请求头，默认为 X-Request-ID，为空时不传递
+func WithRequestIDHeader(header string) ClientOption {
+	return func(c *clientConfig) {
+		c.requestIDHeader = header
+	}
+}
+
+// WithClientRetry 请求失败或响应状态码为429、5xx时最多重试 maxRetries 次，第n次重试前等待n倍的 backoff
+//
+// 只重试幂等方法（GET、HEAD、OPTIONS、TRACE、PUT、DELETE）或带有 Idempotency-Key 请求头的请求，
+// 请求体必须可以重新读取（http.NewRequest 对常见的请求体类型会设置 GetBody）
+func WithClientRetry(maxRetries int, backoff time.Duration) ClientOption {
+	return func(c *clientConfig) {
+		c.maxRetries = maxRetries
+		if backoff > 0 {
+			c.retryBackoff = backoff
+		}
+	}
+}
+
+// clientTransport 记录出站请求日志的http.RoundTripper
+type clientTransport struct {
+	base http.RoundTripper
+	cfg  *clientConfig
+}
+
+// HTTPClientTransport 返回记录出站HTTP请求日志的http.RoundTripper，是 HTTPMiddleware 的客户端对应
+//
+// 每个请求完成后以Info级别记录方法、URL（不含查询参数）、状态码、耗时和重试次数，请求失败时以Error级别记录。
+// 日志使用请求上下文中的Logger，上下文中有请求ID时（见 RequestIDFromContext）通过请求头传递给下游服务。
+// base 为nil时使用 http.DefaultTransport
+func HTTPClientTransport(base http.RoundTripper, opts ...ClientOption) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	cfg := &clientConfig{
+		requestIDHeader: defaultRequestIDHeader,
+		retryBackoff:    defaultClientRetryBackoff,
+	}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+	return &clientTransport{base: base, cfg: cfg}
+}
+
+// RoundTrip 实现http.RoundTripper接口
+func (t *clientTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	start := time.Now()
+	ctx := req.Context()
+	log := t.cfg.logger
+	if log == nil {
+		log = contextLogger(ctx)
+	}
+
+	// RoundTripper不能修改原请求，添加请求头时使用副本
+	out := req
+	if h := t.cfg.requestIDHeader; h != "" && req.Header.Get(h) == "" {
+		if id := RequestIDFromContext(ctx); id != "" {
+			out = req.Clone(ctx)
+			out.Header.Set(h, id)
+		}
+	}
+
+	var (
+		resp    *http.Response
+		err     error
+		retries int
+	)
+	for {
+		resp, err = t.base.RoundTrip(out)
+		if retries >= t.cfg.maxRetries || !retryable(out, resp, err) {
+			break
+		}
+		if resp != nil {
+			// 读完响应体以便复用连接
+			_, _ = io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+		}
+		retries++
+		log.Debug("HTTP client request retry",
+			String("http_method", req.Method),
+			String("url", redactedURL(req)),
+			Int("retry", retries),
+		)
+
+		if err = t.prepareRetry(req, &out, retries); err != nil {
+			break
+		}
+	}
+
+	fields := []Field{
+		String("http_method", req.Method),
+		String("url", redactedURL(req)),
+		Duration("latency", time.Since(start)),
+		Int("retries", retries),
+	}
+	if err != nil {
+		log.Error("HTTP client request failed", append(fields, Err(err))...)
+		return nil, err
+	}
+	log.Info("HTTP client request completed", append(fields, Int("status", resp.StatusCode))...)
+	return resp, nil
+}
+
+// prepareRetry 等待重试间隔并在需要时重新设置请求体，out 为原请求时替换为副本
+func (t *clientTransport) prepareRetry(req *http.Request, out **http.Request, retries int) error {
+	ctx := req.Context()
+	timer := time.NewTimer(time.Duration(retries) * t.cfg.retryBackoff)
+	select {
+	case <-ctx.Done():
+		timer.Stop()
+		return ctx.Err()
+	case <-timer.C:
+	}
+
+	r := *out
+	if r.GetBody == nil || r.Body == nil || r.Body == http.NoBody {
+		return nil
+	}
+	if r == req {
+		r = req.Clone(ctx)
+	}
+	body, err := r.GetBody()
+	if err != nil {
+		return err
+	}
+	r.Body = body
+	*out = r
+	return nil
+}
+
+// retryable 判断请求能否重试
+func retryable(req *http.Request, resp *http.Response, err error) bool {
+	if req.Context().Err() != nil {
+		return false
+	}
+	if req.Body != nil && req.Body != http.NoBody && req.GetBody == nil {
+		return false
+	}
+	switch req.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace, http.MethodPut, http.MethodDelete:
+	default:
+		if req.Header.Get("Idempotency-Key") == "" {
+			return false
+		}
+	}
+	if err != nil {
+		return true
+	}
+	return resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= http.StatusInternalServerError
+}
+
+// redactedURL 返回不含查询参数和密码的URL，避免在日志中泄露令牌等参数
+func redactedURL(req *http.Request) string {
+	u := *req.URL
+	u.RawQuery = ""
+	u.ForceQuery = false
+	u.Fragment = ""
+	return u.Redacted()
+}
@@ -0,0 +1,93 @@
+package logger
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// 测试客户端记录出站请求并传递请求ID
+func TestHTTPClientTransport(t *testing.T) {
+	var gotRequestID atomic.Value
+	downstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotRequestID.Store(r.Header.Get("X-Request-ID"))
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer downstream.Close()
+
+	log, buf := newBufferLogger(DebugLevel)
+	client := &http.Client{Transport: HTTPClientTransport(nil)}
+	handler := HTTPMiddleware(log)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		req, err := http.NewRequestWithContext(r.Context(), http.MethodGet, downstream.URL+"/users?token=secret", nil)
+		require.NoError(t, err)
+		resp, err := client.Do(req)
+		require.NoError(t, err)
+		resp.Body.Close()
+	}))
+
+	req := httptest.NewRequest(http.MethodPost, "/api", nil)
+	req.Header.Set("X-Request-ID", "req-42")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	assert.Equal(t, "req-42", gotRequestID.Load())
+	entry := findLogEntry(t, buf.String(), "HTTP client request completed")
+	require.NotNil(t, entry)
+	assert.Equal(t, "req-42", entry["request_id"])
+	assert.Equal(t, "GET", entry["http_method"])
+	assert.Equal(t, downstream.URL+"/users", entry["url"])
+	assert.Equal(t, float64(http.StatusAccepted), entry["status"])
+	assert.Equal(t, float64(0), entry["retries"])
+	assert.NotContains(t, buf.String(), "secret")
+}
+
+// 测试失败后重试并记录重试次数
+func TestHTTPClientTransportRetry(t *testing.T) {
+	var calls atomic.Int32
+	downstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer downstream.Close()
+
+	log, buf := newBufferLogger(InfoLevel)
+	client := &http.Client{Transport: HTTPClientTransport(nil,
+		WithClientLogger(log),
+		WithClientRetry(3, time.Millisecond))}
+
+	req, err := http.NewRequest(http.MethodPut, downstream.URL, strings.NewReader("body"))
+	require.NoError(t, err)
+	resp, err := client.Do(req)
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, int32(3), calls.Load())
+	entry := findLogEntry(t, buf.String(), "HTTP client request completed")
+	require.NotNil(t, entry)
+	assert.Equal(t, float64(2), entry["retries"])
+
+	// POST 不重试
+	calls.Store(0)
+	resp, err = client.Post(downstream.URL, "text/plain", strings.NewReader("body"))
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+	assert.Equal(t, int32(1), calls.Load())
+
+	// 请求失败时记录错误
+	buf.Reset()
+	_, err = client.Get("http://127.0.0.1:1")
+	require.Error(t, err)
+	entry = findLogEntry(t, buf.String(), "HTTP client request failed")
+	require.NotNil(t, entry)
+	assert.Equal(t, float64(3), entry["retries"])
+	assert.Contains(t, entry, "error")
+}
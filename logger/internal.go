@@ -0,0 +1,52 @@
+package logger
+
+import (
+	"fmt"
+
+	"go.uber.org/zap"
+)
+
+// InternalLogger 将库内部的诊断信息输出到全局Logger，
+// 实现了 config.InternalLogger 和 vconfig.InternalLogger 接口
+type InternalLogger struct {
+	// Component 产生日志的组件名称，输出为 component 字段
+	Component string
+}
+
+// Infow 以Info级别记录诊断信息，keysAndValues 为成对出现的键和值
+func (l InternalLogger) Infow(msg string, keysAndValues ...interface{}) {
+	l.target().Info(msg, l.fields(keysAndValues)...)
+}
+
+// Errorw 以Error级别记录诊断信息，keysAndValues 为成对出现的键和值
+func (l InternalLogger) Errorw(msg string, keysAndValues ...interface{}) {
+	l.target().Error(msg, l.fields(keysAndValues)...)
+}
+
+// target 返回输出诊断信息的Logger，默认Logger初始化期间（如读取全局配置时）输出到标准错误
+func (l InternalLogger) target() Logger {
+	if defaults.Load() == nil && initializing.Load() {
+		return stderrLogger()
+	}
+	return DefaultLogger()
+}
+
+// fields 将键值对转换为字段，缺少值的键以 !BADKEY 记录
+func (l InternalLogger) fields(keysAndValues []interface{}) []Field {
+	fields := make([]Field, 0, len(keysAndValues)/2+1)
+	if l.Component != "" {
+		fields = append(fields, String("component", l.Component))
+	}
+	for i := 0; i < len(keysAndValues); i += 2 {
+		if i+1 == len(keysAndValues) {
+			fields = append(fields, zap.Any("!BADKEY", keysAndValues[i]))
+			break
+		}
+		key, ok := keysAndValues[i].(string)
+		if !ok {
+			key = fmt.Sprint(keysAndValues[i])
+		}
+		fields = append(fields, zap.Any(key, keysAndValues[i+1]))
+	}
+	return fields
+}
@@ -0,0 +1,30 @@
+package logger
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// 测试内部诊断日志适配器
+func TestInternalLogger(t *testing.T) {
+	log, buf := newBufferLogger(DebugLevel)
+	old := DefaultLogger()
+	SetDefault(log)
+	defer SetDefault(old)
+
+	InternalLogger{Component: "vconfig"}.Errorw("读取配置文件失败",
+		"file", "app.yaml", "error", errors.New("permission denied"), "dangling")
+
+	entry := make(map[string]interface{})
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &entry))
+	assert.Equal(t, "error", entry["level"])
+	assert.Equal(t, "读取配置文件失败", entry["msg"])
+	assert.Equal(t, "vconfig", entry["component"])
+	assert.Equal(t, "app.yaml", entry["file"])
+	assert.Equal(t, "permission denied", entry["error"])
+	assert.Equal(t, "dangling", entry["!BADKEY"])
+}
@@ -0,0 +1,94 @@
+package logger
+
+import (
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// minLevelMarker 通过 WithMinLevel 添加到core的标记，字段类型为Skip，编码时被忽略
+type minLevelMarker struct {
+	level zapcore.Level
+}
+
+// WithMinLevel 使Logger及其派生的Logger输出不低于 level 的日志，即使全局级别更高
+//
+// 用于对单个请求临时开启Debug日志，如 log.WithOptions(WithMinLevel(DebugLevel))；
+// 只会放宽级别，不会过滤全局级别已允许的日志
+func WithMinLevel(level Level) Option {
+	return func(l *zapLogger) {
+		l.minLevel = &level
+	}
+}
+
+// levelGate 按Logger的级别、名称对应的级别和 WithMinLevel 设置的级别判断日志是否输出
+type levelGate struct {
+	level  zapcore.LevelEnabler
+	named  *namedLevels
+	name   string
+	min    zapcore.Level
+	hasMin bool
+}
+
+// enabled 判断日志级别是否输出，名称匹配配置中的 NamedLevels 时使用其级别代替Logger的级别
+func (g levelGate) enabled(level zapcore.Level) bool {
+	if g.hasMin && level >= g.min {
+		return true
+	}
+	if named, ok := g.named.lookup(g.name); ok {
+		return level >= named
+	}
+	return g.level.Enabled(level)
+}
+
+// with 返回应用了字段中 WithMinLevel 和 Named 标记的级别判断
+func (g levelGate) with(fields []Field) levelGate {
+	for _, f := range fields {
+		if f.Type != zapcore.SkipType {
+			continue
+		}
+		switch marker := f.Interface.(type) {
+		case minLevelMarker:
+			g.min, g.hasMin = marker.level, true
+		case nameMarker:
+			g.name = marker.name
+		}
+	}
+	return g
+}
+
+// levelCore 在最外层按级别过滤日志，内部的core不做级别过滤，
+// 因此通过 WithMinLevel 派生的Logger可以输出低于全局级别的日志
+type levelCore struct {
+	zapcore.Core
+	gate levelGate
+}
+
+// levelOption 返回按级别过滤日志的选项
+func levelOption(gate levelGate) zap.Option {
+	return zap.WrapCore(func(core zapcore.Core) zapcore.Core {
+		return &levelCore{Core: core, gate: gate}
+	})
+}
+
+// Enabled 实现zapcore.Core接口
+func (c *levelCore) Enabled(level zapcore.Level) bool {
+	return c.gate.enabled(level)
+}
+
+// With 实现zapcore.Core接口
+func (c *levelCore) With(fields []Field) zapcore.Core {
+	return &levelCore{Core: c.Core.With(fields), gate: c.gate.with(fields)}
+}
+
+// Check 实现zapcore.Core接口
+func (c *levelCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.gate.enabled(ent.Level) {
+		return c.Core.Check(ent, ce)
+	}
+	return ce
+}
+
+// minLevelField 返回 WithMinLevel 的标记字段
+func minLevelField(level zapcore.Level) Field {
+	return Field{Type: zapcore.SkipType, Interface: minLevelMarker{level: level}}
+}
@@ -0,0 +1,86 @@
+package logger
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/constructorvirgil/virlog/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zapcore"
+)
+
+// newLevelTestLogger 创建Info级别、写入缓冲区的Logger
+func newLevelTestLogger(t *testing.T, cfg *config.Config) (Logger, *bytes.Buffer) {
+	buf := &bytes.Buffer{}
+	if cfg == nil {
+		cfg = config.DefaultConfig()
+	}
+	cfg.Format = "json"
+	cfg.Level = "info"
+	log, err := NewLogger(cfg, WithSyncTarget(zapcore.AddSync(buf)))
+	require.NoError(t, err)
+	return log, buf
+}
+
+// 测试通过WithMinLevel对派生的Logger放宽级别
+func TestWithMinLevel(t *testing.T) {
+	log, buf := newLevelTestLogger(t, nil)
+
+	debugLog := log.With(String("request_id", "req-1")).WithOptions(WithMinLevel(DebugLevel))
+	debugLog.Debug("debug enabled")
+	debugLog.With(String("step", "db")).Debug("derived debug")
+	log.Debug("parent debug")
+
+	assert.NotNil(t, findLogEntry(t, buf.String(), "debug enabled"))
+	entry := findLogEntry(t, buf.String(), "derived debug")
+	require.NotNil(t, entry)
+	assert.Equal(t, "req-1", entry["request_id"])
+	assert.Equal(t, "db", entry["step"])
+	assert.NotContains(t, entry, "")
+	assert.NotContains(t, buf.String(), "parent debug", "父Logger不受影响")
+
+	// 只放宽级别，全局级别调低后其他Logger照常输出
+	log.SetLevel(DebugLevel)
+	log.Debug("global debug")
+	assert.Contains(t, buf.String(), "global debug")
+
+	created, createdBuf := newLevelTestLogger(t, nil)
+	created.Debug("hidden")
+	created.WithOptions(WithMinLevel(InfoLevel)).Debug("still hidden")
+	created.WithOptions(WithMinLevel(DebugLevel)).Debug("visible")
+	assert.NotContains(t, createdBuf.String(), "hidden")
+	assert.Contains(t, createdBuf.String(), "visible")
+}
+
+// 测试启用上下文日志回放时WithMinLevel的日志直接输出
+func TestWithMinLevelFlightRecorder(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.FlightRecorder = &config.FlightRecorderConfig{}
+	log, buf := newLevelTestLogger(t, cfg)
+
+	log.WithOptions(WithMinLevel(DebugLevel)).Debug("direct debug")
+	log.Debug("buffered debug")
+	assert.Contains(t, buf.String(), "direct debug")
+	assert.NotContains(t, buf.String(), "buffered debug")
+}
+
+// 测试中间件根据请求头对单个请求开启Debug日志
+func TestHTTPMiddlewareDebugHeader(t *testing.T) {
+	log, buf := newLevelTestLogger(t, nil)
+	handler := HTTPMiddleware(log, WithDebugHeader("X-Debug-Log"))(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		GetLoggerFromContext(r.Context()).Debug("handler debug", String("path", r.URL.Path))
+	}))
+
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/normal", nil))
+	assert.NotContains(t, buf.String(), "handler debug")
+
+	req := httptest.NewRequest(http.MethodGet, "/debug", nil)
+	req.Header.Set("X-Debug-Log", "1")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+	entry := findLogEntry(t, buf.String(), "handler debug")
+	require.NotNil(t, entry)
+	assert.Equal(t, "/debug", entry["path"])
+}
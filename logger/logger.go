@@ -1,15 +1,14 @@
 package logger
 
 import (
-	"os"
+	"fmt"
 	"sync"
-	"time"
+	"sync/atomic"
 
 	"github.com/constructorvirgil/virlog/config"
 
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
-	"gopkg.in/natefinch/lumberjack.v2"
 )
 
 // Field 是日志字段类型
@@ -18,28 +17,107 @@ type Field = zapcore.Field
 // 预定义的字段构造函数
 var (
 	// 基本类型
-	Binary  = zap.Binary
-	Bool    = zap.Bool
-	String  = zap.String
-	Int     = zap.Int
-	Int64   = zap.Int64
-	Float64 = zap.Float64
-	Err     = zap.Error
-	Any     = zap.Any
-
-	// 其他常用类型
+	Binary     = zap.Binary
+	ByteString = zap.ByteString
+	Bool       = zap.Bool
+	String     = zap.String
+	Int        = zap.Int
+	Int64      = zap.Int64
+	Int32      = zap.Int32
+	Int16      = zap.Int16
+	Int8       = zap.Int8
+	Uint       = zap.Uint
+	Uint64     = zap.Uint64
+	Uint32     = zap.Uint32
+	Uint16     = zap.Uint16
+	Uint8      = zap.Uint8
+	Uintptr    = zap.Uintptr
+	Float64    = zap.Float64
+	Float32    = zap.Float32
+	Complex128 = zap.Complex128
+	Complex64  = zap.Complex64
+	Err        = zap.Error
+	NamedError = zap.NamedError
+
+	// 指针类型，指针为nil时输出null
+	Boolp       = zap.Boolp
+	Stringp     = zap.Stringp
+	Intp        = zap.Intp
+	Int64p      = zap.Int64p
+	Int32p      = zap.Int32p
+	Int16p      = zap.Int16p
+	Int8p       = zap.Int8p
+	Uintp       = zap.Uintp
+	Uint64p     = zap.Uint64p
+	Uint32p     = zap.Uint32p
+	Uint16p     = zap.Uint16p
+	Uint8p      = zap.Uint8p
+	Uintptrp    = zap.Uintptrp
+	Float64p    = zap.Float64p
+	Float32p    = zap.Float32p
+	Complex128p = zap.Complex128p
+	Complex64p  = zap.Complex64p
+	Timep       = zap.Timep
+	Durationp   = zap.Durationp
+
+	// 切片类型
+	Bools       = zap.Bools
+	ByteStrings = zap.ByteStrings
+	Strings     = zap.Strings
+	Ints        = zap.Ints
+	Int64s      = zap.Int64s
+	Int32s      = zap.Int32s
+	Int16s      = zap.Int16s
+	Int8s       = zap.Int8s
+	Uints       = zap.Uints
+	Uint64s     = zap.Uint64s
+	Uint32s     = zap.Uint32s
+	Uint16s     = zap.Uint16s
+	Uint8s      = zap.Uint8s
+	Uintptrs    = zap.Uintptrs
+	Float64s    = zap.Float64s
+	Float32s    = zap.Float32s
+	Complex128s = zap.Complex128s
+	Complex64s  = zap.Complex64s
+	Times       = zap.Times
+	Durations   = zap.Durations
+	Errors      = zap.Errors
+
+	// 其他常用类型，Object 见 fields.go，Any 见 sensitive.go
 	Namespace = zap.Namespace
 	Reflect   = zap.Reflect
 	Skip      = zap.Skip
 	Time      = zap.Time
 	Duration  = zap.Duration
+	Stringer  = zap.Stringer
+	Array     = zap.Array
+	Inline    = zap.Inline
+	Dict      = zap.Dict
+	Stack     = zap.Stack
+	StackSkip = zap.StackSkip
 )
 
+// Objects 将实现了 zapcore.ObjectMarshaler 的元素切片作为对象数组输出
+func Objects[T zapcore.ObjectMarshaler](key string, values []T) Field {
+	return zap.Objects(key, values)
+}
+
+// ObjectValues 将元素切片作为对象数组输出，元素的指针实现了 zapcore.ObjectMarshaler
+func ObjectValues[T any, P zap.ObjectMarshalerPtr[T]](key string, values []T) Field {
+	return zap.ObjectValues[T, P](key, values)
+}
+
+// Stringers 将实现了 fmt.Stringer 的元素切片作为字符串数组输出
+func Stringers[T fmt.Stringer](key string, values []T) Field {
+	return zap.Stringers(key, values)
+}
+
 // 日志级别
 type Level = zapcore.Level
 
 const (
 	// 从低到高排序
+	TraceLevel  = zapcore.DebugLevel - 1 // 比Debug更详细的跟踪日志
 	DebugLevel  = zapcore.DebugLevel
 	InfoLevel   = zapcore.InfoLevel
 	WarnLevel   = zapcore.WarnLevel
@@ -51,6 +129,7 @@ const (
 
 // Logger 定义日志接口
 type Logger interface {
+	Trace(msg string, fields ...Field)
 	Debug(msg string, fields ...Field)
 	Info(msg string, fields ...Field)
 	Warn(msg string, fields ...Field)
@@ -62,6 +141,9 @@ type Logger interface {
 	// 支持层级日志记录
 	With(fields ...Field) Logger
 
+	// 返回名称为 当前名称.name 的子Logger，名称输出在 logger 字段中
+	Named(name string) Logger
+
 	// 支持动态修改日志级别
 	SetLevel(level Level)
 
@@ -70,40 +152,68 @@ type Logger interface {
 
 	// 获取原始zap logger
 	GetRawZapLogger() *zap.Logger
+
+	// 基于当前Logger应用选项，返回新的Logger
+	WithOptions(opts ...Option) Logger
 }
 
 // 确保 zapLogger 实现了 Logger 接口
 var _ Logger = (*zapLogger)(nil)
 
 // zapLogger 是对 zap.Logger 的封装
+//
+// zapLogger 创建后不再修改，With 会返回新的实例，因此日志方法无需加锁；
+// 级别通过 AtomicLevel 原子修改
 type zapLogger struct {
 	rawZapLogger *zap.Logger
 	atom         *zap.AtomicLevel
 	config       *config.Config
 	fields       []Field
-	mu           sync.RWMutex
-	syncTarget   zapcore.WriteSyncer // 自定义的同步输出目标
-}
+	syncTarget   zapcore.WriteSyncer    // 自定义的同步输出目标
+	hooks        []levelHook            // 通过WithHook注册的钩子
+	callerSkip   int                    // rawZapLogger 上的调用者跳过层数，包含zapLogger方法自身的一层
+	processors   []Processor            // 通过WithProcessors注册的处理器
+	rateLimits   []config.RateLimitRule // 通过RateLimit设置的限流规则
+	globalFields bool                   // 是否附加全局字段，仅默认Logger开启
+	minLevel     *Level                 // 通过WithMinLevel设置的最低输出级别
+	budget       *LogBudget             // 通过Budget设置的日志预算
+	adaptive     *AdaptiveSampler       // 通过AdaptiveSampling设置的自适应采样器
+	named        *namedLevels           // 按名称设置的级别，默认Logger在配置变更前后共享
+	outputs      *outputStoppers        // 创建的需要在Logger被替换后停止的输出
+}
+
+// wrapperCallerSkip zapLogger的日志方法包装zap.Logger带来的调用层数
+const wrapperCallerSkip = 1
 
 // getZapLevel 将配置中的日志级别字符串转换为zap日志级别
 func getZapLevel(levelStr string) zapcore.Level {
+	if level, ok := parseLevel(levelStr); ok {
+		return level
+	}
+	return InfoLevel
+}
+
+// parseLevel 解析日志级别字符串，无法识别时返回false
+func parseLevel(levelStr string) (zapcore.Level, bool) {
 	switch levelStr {
+	case "trace":
+		return TraceLevel, true
 	case "debug":
-		return DebugLevel
+		return DebugLevel, true
 	case "info":
-		return InfoLevel
+		return InfoLevel, true
 	case "warn":
-		return WarnLevel
+		return WarnLevel, true
 	case "error":
-		return ErrorLevel
+		return ErrorLevel, true
 	case "dpanic":
-		return DPanicLevel
+		return DPanicLevel, true
 	case "panic":
-		return PanicLevel
+		return PanicLevel, true
 	case "fatal":
-		return FatalLevel
+		return FatalLevel, true
 	default:
-		return InfoLevel
+		return InfoLevel, false
 	}
 }
 
@@ -117,52 +227,102 @@ func getEncoderConfig(cfg *config.Config) zapcore.EncoderConfig {
 		MessageKey:     "msg",
 		StacktraceKey:  "stacktrace",
 		LineEnding:     zapcore.DefaultLineEnding,
-		EncodeLevel:    zapcore.LowercaseLevelEncoder,
+		EncodeLevel:    lowercaseLevelEncoder,
 		EncodeTime:     zapcore.ISO8601TimeEncoder,
 		EncodeDuration: zapcore.SecondsDurationEncoder,
 		EncodeCaller:   zapcore.ShortCallerEncoder,
 	}
 
 	if cfg.Development {
-		encoderConfig.EncodeLevel = zapcore.CapitalColorLevelEncoder
+		encoderConfig.EncodeLevel = capitalColorLevelEncoder
 		encoderConfig.EncodeCaller = zapcore.FullCallerEncoder
 	}
+	applyEncoderConfig(&encoderConfig, cfg)
 
 	return encoderConfig
 }
 
-// getEncoder 获取日志编码器
-func getEncoder(encoderConfig zapcore.EncoderConfig, cfg *config.Config) zapcore.Encoder {
-	if cfg.Format == "console" {
+// getEncoder 获取日志编码器，w 为编码后日志的写入目标，用于判断控制台格式是否使用颜色，可以为nil
+func getEncoder(encoderConfig zapcore.EncoderConfig, cfg *config.Config, w zapcore.WriteSyncer) zapcore.Encoder {
+	switch cfg.Format {
+	case "console":
+		if cfg.Console != nil {
+			return newConsoleEncoder(encoderConfig, cfg, consoleColorEnabled(cfg.Console, w))
+		}
 		return zapcore.NewConsoleEncoder(encoderConfig)
+	case "logfmt":
+		return NewLogfmtEncoder(encoderConfig)
+	case "cef":
+		var header CEFHeader
+		if cfg.CEF != nil {
+			header = CEFHeader{
+				DeviceVendor:  cfg.CEF.DeviceVendor,
+				DeviceProduct: cfg.CEF.DeviceProduct,
+				DeviceVersion: cfg.CEF.DeviceVersion,
+			}
+		}
+		return NewCEFEncoder(encoderConfig, header)
+	case "msgpack":
+		return NewMsgpackEncoder(encoderConfig)
+	case "protobuf":
+		return NewProtobufEncoder(encoderConfig)
+	default:
+		return zapcore.NewJSONEncoder(encoderConfig)
 	}
-	return zapcore.NewJSONEncoder(encoderConfig)
 }
 
-// getOutputConfig 获取输出配置
-func getOutputConfig(cfg *config.Config) (zapcore.WriteSyncer, error) {
-	var writeSyncer zapcore.WriteSyncer
-	switch cfg.Output {
-	case "stdout":
-		writeSyncer = zapcore.AddSync(os.Stdout)
-	case "stderr":
-		writeSyncer = zapcore.AddSync(os.Stderr)
-	case "file":
-		if cfg.FileConfig == nil {
-			cfg.FileConfig = config.DefaultConfig().FileConfig
+// newOutputCore 根据输出配置创建核心
+//
+// journald 和 eventlog 直接写入系统日志设施，gelf、fluent 和 elasticsearch 发送结构化消息，其他输出通过WriteSyncer写入编码后的日志；
+// 配置了 Fallback 时每个输出写入失败后切换到备用输出
+func newOutputCore(syncTarget zapcore.WriteSyncer, encoderConfig zapcore.EncoderConfig, cfg *config.Config, enab zapcore.LevelEnabler, outputs *outputStoppers) (zapcore.Core, error) {
+	if syncTarget == nil && len(cfg.Outputs) > 0 {
+		return newTeeOutputCore(encoderConfig, cfg, enab, outputs)
+	}
+
+	core, err := newSingleOutputCore(syncTarget, encoderConfig, cfg, enab, outputs)
+	if err != nil || syncTarget != nil {
+		return core, err
+	}
+	return withFallback(core, encoderConfig, cfg, enab, outputs)
+}
+
+// newSingleOutputCore 创建 Output 指定的单个输出的核心，syncTarget 不为nil时写入 syncTarget，
+// 需要在Logger被替换后停止的输出记录到 outputs
+func newSingleOutputCore(syncTarget zapcore.WriteSyncer, encoderConfig zapcore.EncoderConfig, cfg *config.Config, enab zapcore.LevelEnabler, outputs *outputStoppers) (zapcore.Core, error) {
+	if syncTarget == nil {
+		var (
+			core zapcore.Core
+			err  error
+		)
+		switch cfg.Output {
+		case journaldOutput:
+			return newJournaldCore(enab)
+		case gelfOutput:
+			return newGELFCore(enab, cfg)
+		case fluentOutput:
+			core, err = newFluentCore(enab, cfg)
+		case elasticsearchOutput:
+			core, err = newElasticsearchCore(enab, cfg)
+		case eventLogOutput:
+			return newEventLogCore(getEncoder(encoderConfig, cfg, nil), enab, cfg)
 		}
-		lumberjackLogger := &lumberjack.Logger{
-			Filename:   cfg.FileConfig.Filename,
-			MaxSize:    cfg.FileConfig.MaxSize,
-			MaxBackups: cfg.FileConfig.MaxBackups,
-			MaxAge:     cfg.FileConfig.MaxAge,
-			Compress:   cfg.FileConfig.Compress,
+		if core != nil || err != nil {
+			outputs.add(core)
+			return core, err
 		}
-		writeSyncer = zapcore.AddSync(lumberjackLogger)
-	default:
-		writeSyncer = zapcore.AddSync(os.Stdout)
 	}
-	return writeSyncer, nil
+
+	writeSyncer := syncTarget
+	if writeSyncer == nil {
+		var err error
+		writeSyncer, err = getOutputConfig(cfg)
+		if err != nil {
+			return nil, err
+		}
+		outputs.add(writeSyncer)
+	}
+	return zapcore.NewCore(getEncoder(encoderConfig, cfg, writeSyncer), writeSyncer, enab), nil
 }
 
 // NewLogger 创建一个新的Logger实例
@@ -176,9 +336,10 @@ func NewLogger(cfg *config.Config, opts ...Option) (Logger, error) {
 
 	// 创建zapLogger实例
 	logger := &zapLogger{
-		atom:   &atom,
-		config: cfg,
-		fields: make([]Field, 0),
+		atom:       &atom,
+		config:     cfg,
+		fields:     make([]Field, 0),
+		callerSkip: wrapperCallerSkip,
 	}
 
 	// 应用所有选项
@@ -186,55 +347,75 @@ func NewLogger(cfg *config.Config, opts ...Option) (Logger, error) {
 		opt(logger)
 	}
 
+	// 配置了摘要密钥时设置 log:"hash" 字段使用的密钥
+	if cfg.HashKey != "" {
+		SetHashKey([]byte(cfg.HashKey))
+	}
+
+	// 追加配置中启用的错误上报
+	reporterHooks, err := errorReporterHooks(cfg)
+	if err != nil {
+		return nil, err
+	}
+	logger.hooks = append(logger.hooks, reporterHooks...)
+
 	// 获取encoder配置
 	encoderConfig := getEncoderConfig(cfg)
 
-	// 获取输出配置
-	var writeSyncer zapcore.WriteSyncer
-	var err error
-	if logger.syncTarget != nil {
-		// 如果设置了自定义同步目标，使用它
-		writeSyncer = logger.syncTarget
-	} else {
-		// 否则使用默认配置
-		writeSyncer, err = getOutputConfig(cfg)
-		if err != nil {
-			return nil, err
-		}
-	}
+	// 从配置中读取预设字段，进程信息字段在前
+	fields := append(runtimeFields(cfg.RuntimeFields), defaultFields(cfg.DefaultFields)...)
 
-	// 从配置中读取预设字段
-	var fields []Field
-	for k, v := range cfg.DefaultFields {
-		// 根据类型进行转换
-		switch val := v.(type) {
-		case string:
-			fields = append(fields, String(k, val))
-		case int:
-			fields = append(fields, Int(k, val))
-		case int64:
-			fields = append(fields, Int64(k, val))
-		case float64:
-			fields = append(fields, Float64(k, val))
-		case bool:
-			fields = append(fields, Bool(k, val))
-		default:
-			fields = append(fields, Any(k, val))
-		}
+	levels, err := parseNamedLevels(cfg.NamedLevels)
+	if err != nil {
+		return nil, err
+	}
+	if _, _, err := stacktraceLevel(cfg); err != nil {
+		return nil, err
+	}
+	if logger.named == nil {
+		logger.named = &namedLevels{}
 	}
 
-	// 创建核心
-	core := zapcore.NewCore(
-		getEncoder(encoderConfig, cfg),
-		writeSyncer,
-		atom,
-	)
+	// 创建核心，级别在最外层过滤，以便 WithMinLevel 放宽单个Logger的级别
+	logger.outputs = &outputStoppers{}
+	core, err := newOutputCore(logger.syncTarget, encoderConfig, cfg, TraceLevel, logger.outputs)
+	if err != nil {
+		return nil, err
+	}
+	if logger.adaptive != nil {
+		core = logger.adaptive.measure(core)
+	}
+	if len(logger.processors) > 0 {
+		core = newProcessorCore(core, logger.processors)
+	}
 
 	// 创建zap logger
-	rawZapLogger := zap.New(core, getZapOptions(cfg)...).With(fields...)
+	zapOptions := append(getZapOptions(cfg), zap.AddCallerSkip(logger.callerSkip))
+	if opt := rateLimitOption(cfg, logger.rateLimits); opt != nil {
+		zapOptions = append(zapOptions, opt)
+	}
+	zapOptions = append(zapOptions, logger.hookOptions()...)
+	zapOptions = append(zapOptions, zap.WithFatalHook(fatalHook{log: logger}))
+	gate := levelGate{level: atom, named: logger.named}
+	if opt := flightRecorderOption(cfg, gate); opt != nil {
+		zapOptions = append(zapOptions, opt)
+	} else {
+		zapOptions = append(zapOptions, levelOption(gate))
+	}
+	if logger.budget != nil {
+		zapOptions = append(zapOptions, budgetOption(logger.budget))
+	}
+	if logger.adaptive != nil {
+		zapOptions = append(zapOptions, adaptiveOption(logger.adaptive))
+	}
+	rawZapLogger := zap.New(core, zapOptions...).With(fields...)
+	if logger.minLevel != nil {
+		rawZapLogger = rawZapLogger.With(minLevelField(*logger.minLevel))
+	}
 
 	// 保存到zapLogger实例
 	logger.rawZapLogger = rawZapLogger
+	logger.named.store(levels)
 
 	return logger, nil
 }
@@ -247,8 +428,9 @@ func getZapOptions(cfg *config.Config) []zap.Option {
 		options = append(options, zap.AddCaller())
 	}
 
-	if cfg.EnableStacktrace {
-		options = append(options, zap.AddStacktrace(ErrorLevel))
+	// 级别已在 NewLogger 中校验
+	if level, ok, _ := stacktraceLevel(cfg); ok {
+		options = append(options, zap.AddStacktrace(level))
 	}
 
 	if cfg.Development {
@@ -257,81 +439,128 @@ func getZapOptions(cfg *config.Config) []zap.Option {
 
 	if cfg.EnableSampling {
 		options = append(options, zap.WrapCore(func(core zapcore.Core) zapcore.Core {
-			return zapcore.NewSamplerWithOptions(
-				core,
-				time.Second,
-				100,
-				100,
-			)
+			return newSamplingCore(core, cfg.Sampling)
 		}))
 	}
 
 	return options
 }
 
+// Trace 输出Trace级别日志
+func (l *zapLogger) Trace(msg string, fields ...Field) {
+	if !debugCompiled {
+		return
+	}
+	l.raw(fields).Log(TraceLevel, msg, l.appendGlobalFields(fields)...)
+}
+
 // Debug 输出Debug级别日志
 func (l *zapLogger) Debug(msg string, fields ...Field) {
-	l.mu.RLock()
-	defer l.mu.RUnlock()
-	l.rawZapLogger.Debug(msg, fields...)
+	if !debugCompiled {
+		return
+	}
+	l.raw(fields).Debug(msg, l.appendGlobalFields(fields)...)
 }
 
 // Info 输出Info级别日志
 func (l *zapLogger) Info(msg string, fields ...Field) {
-	l.mu.RLock()
-	defer l.mu.RUnlock()
-	l.rawZapLogger.Info(msg, fields...)
+	l.raw(fields).Info(msg, l.appendGlobalFields(fields)...)
 }
 
 // Warn 输出Warn级别日志
 func (l *zapLogger) Warn(msg string, fields ...Field) {
-	l.mu.RLock()
-	defer l.mu.RUnlock()
-	l.rawZapLogger.Warn(msg, fields...)
+	l.raw(fields).Warn(msg, l.appendGlobalFields(fields)...)
 }
 
 // Error 输出Error级别日志
 func (l *zapLogger) Error(msg string, fields ...Field) {
-	l.mu.RLock()
-	defer l.mu.RUnlock()
-	l.rawZapLogger.Error(msg, fields...)
+	l.raw(fields).Error(msg, l.appendGlobalFields(fields)...)
 }
 
 // DPanic 输出DPanic级别日志
 func (l *zapLogger) DPanic(msg string, fields ...Field) {
-	l.mu.RLock()
-	defer l.mu.RUnlock()
-	l.rawZapLogger.DPanic(msg, fields...)
+	l.raw(fields).DPanic(msg, l.appendGlobalFields(fields)...)
 }
 
 // Panic 输出Panic级别日志并触发panic
 func (l *zapLogger) Panic(msg string, fields ...Field) {
-	l.mu.RLock()
-	defer l.mu.RUnlock()
-	l.rawZapLogger.Panic(msg, fields...)
+	l.raw(fields).Panic(msg, l.appendGlobalFields(fields)...)
 }
 
-// Fatal 输出Fatal级别日志并调用os.Exit(1)
+// Fatal 输出Fatal级别日志，执行 Shutdown 后调用os.Exit(1)
 func (l *zapLogger) Fatal(msg string, fields ...Field) {
-	l.mu.RLock()
-	defer l.mu.RUnlock()
-	l.rawZapLogger.Fatal(msg, fields...)
+	l.raw(fields).Fatal(msg, l.appendGlobalFields(fields)...)
 }
 
 // With 返回带有指定字段的新Logger
 func (l *zapLogger) With(fields ...Field) Logger {
-	l.mu.Lock()
-	defer l.mu.Unlock()
-	allFields := append(l.fields, fields...)
+	// 复制字段，避免与父Logger共享底层数组
+	allFields := make([]Field, 0, len(l.fields)+len(fields))
+	allFields = append(allFields, l.fields...)
+	allFields = append(allFields, fields...)
+	rawZapLogger := l.rawZapLogger.With(fields...)
+	if hasNoStack(fields) {
+		rawZapLogger = rawZapLogger.WithOptions(noStackOption)
+	}
 	return &zapLogger{
-		rawZapLogger: l.rawZapLogger.With(fields...),
+		rawZapLogger: rawZapLogger,
 		atom:         l.atom,
 		config:       l.config,
 		fields:       allFields,
 		syncTarget:   l.syncTarget,
+		hooks:        l.hooks,
+		callerSkip:   l.callerSkip,
+		processors:   l.processors,
+		rateLimits:   l.rateLimits,
+		globalFields: l.globalFields,
+		minLevel:     l.minLevel,
+		budget:       l.budget,
+		adaptive:     l.adaptive,
+		named:        l.named,
+		outputs:      l.outputs,
 	}
 }
 
+// Named 返回名称为 当前名称.name 的子Logger，如 log.Named("http").Named("client") 的名称为 "http.client"
+//
+// 配置中的 NamedLevels 按名称前缀设置级别，配置变更后对已创建的子Logger同样生效
+func (l *zapLogger) Named(name string) Logger {
+	clone := *l
+	clone.rawZapLogger = l.rawZapLogger.Named(name)
+	clone.rawZapLogger = clone.rawZapLogger.With(nameField(clone.rawZapLogger.Name()))
+	return &clone
+}
+
+// WithOptions 基于当前Logger应用选项，返回新的Logger，当前Logger不受影响
+//
+// 只有 WithCallerSkip、WithHook、WithMinLevel 和 Budget 等作用于日志调用过程的选项生效，
+// WithSyncTarget、WithProcessors、RateLimit、AdaptiveSampling 等在创建时确定输出的选项会被忽略
+func (l *zapLogger) WithOptions(opts ...Option) Logger {
+	clone := *l
+	// 限制容量，避免追加钩子时修改当前Logger的底层数组
+	clone.hooks = l.hooks[:len(l.hooks):len(l.hooks)]
+	clone.processors = l.processors[:len(l.processors):len(l.processors)]
+	for _, opt := range opts {
+		opt(&clone)
+	}
+
+	var zapOptions []zap.Option
+	if delta := clone.callerSkip - l.callerSkip; delta != 0 {
+		zapOptions = append(zapOptions, zap.AddCallerSkip(delta))
+	}
+	for _, h := range clone.hooks[len(l.hooks):] {
+		zapOptions = append(zapOptions, h.option(l.fields))
+	}
+	if clone.budget != nil && clone.budget != l.budget {
+		zapOptions = append(zapOptions, budgetOption(clone.budget))
+	}
+	clone.rawZapLogger = l.rawZapLogger.WithOptions(zapOptions...)
+	if clone.minLevel != l.minLevel {
+		clone.rawZapLogger = clone.rawZapLogger.With(minLevelField(*clone.minLevel))
+	}
+	return &clone
+}
+
 // SetLevel 动态修改日志级别
 func (l *zapLogger) SetLevel(level Level) {
 	l.atom.SetLevel(level)
@@ -343,27 +572,99 @@ func (l *zapLogger) Sync() error {
 }
 
 // GetZapLogger 返回原始zap.Logger
+//
+// 返回的zap.Logger记录的调用者为直接调用它的位置
 func (l *zapLogger) GetRawZapLogger() *zap.Logger {
-	return l.rawZapLogger
+	if l.callerSkip == 0 {
+		return l.rawZapLogger
+	}
+	return l.rawZapLogger.WithOptions(zap.AddCallerSkip(-l.callerSkip))
 }
 
-var (
+// defaultLoggers 默认Logger及供全局日志函数使用的Logger，二者一起替换
+type defaultLoggers struct {
 	std Logger
-	mu  sync.RWMutex
-)
+	// caller 多跳过全局函数自身的一层调用
+	caller Logger
+}
+
+// defaults 当前的默认Logger，通过原子指针替换，全局日志函数无需加锁
+var defaults atomic.Pointer[defaultLoggers]
 
-// init 初始化全局Logger
+// init 设置config包的内部诊断日志使用全局Logger输出，默认Logger在首次使用时创建
 func init() {
-	var err error
-	std, err = NewLogger(config.GetConfig())
+	config.SetInternalLogger(InternalLogger{Component: "config"})
+}
+
+var (
+	// initDefaultOnce 保证默认Logger只初始化一次
+	initDefaultOnce sync.Once
+	// initErr 按全局配置创建默认Logger失败的原因，配置变更后成功替换默认Logger时清除
+	initErr atomic.Pointer[error]
+	// managedOutputs 由本包按全局配置创建的默认Logger的输出，配置变更替换默认Logger后停止
+	managedOutputs atomic.Pointer[outputStoppers]
+	// initializing 默认Logger正在初始化，此时内部诊断日志不能等待初始化完成
+	initializing atomic.Bool
+	// stderrLogger 初始化期间输出内部诊断日志的Logger
+	stderrLogger = sync.OnceValue(fallbackLogger)
+	// globalConfig 返回创建默认Logger使用的全局配置
+	globalConfig = config.GetConfig
+)
+
+// loadDefaults 返回当前的默认Logger，首次使用时按全局配置初始化
+func loadDefaults() *defaultLoggers {
+	if d := defaults.Load(); d != nil {
+		return d
+	}
+	initDefaultOnce.Do(initDefault)
+	return defaults.Load()
+}
+
+// initDefault 按全局配置创建默认Logger并开始监听配置变更
+//
+// 创建失败时（如环境变量中的配置无效）记录错误，使用输出到标准错误的控制台Logger，
+// 不会因导入本包而使程序panic；在此之前已通过 SetDefault 设置的Logger不会被替换
+func initDefault() {
+	initializing.Store(true)
+	defer initializing.Store(false)
+
+	std, err := NewLogger(globalConfig())
 	if err != nil {
-		panic("failed to initialize global logger: " + err.Error())
+		err = fmt.Errorf("初始化默认Logger失败: %w", err)
+		initErr.Store(&err)
+		std = stderrLogger()
+		std.Error("使用标准错误输出作为默认Logger", Err(err))
+	}
+	if defaults.CompareAndSwap(nil, newDefaultLoggers(std)) {
+		managedOutputs.Store(outputsOf(std))
 	}
 
 	// 启动配置监听
 	go watchConfig()
 }
 
+// fallbackLogger 返回输出到标准错误的控制台Logger
+func fallbackLogger() Logger {
+	cfg := config.DefaultConfig()
+	cfg.Format = "console"
+	cfg.Output = "stderr"
+	// 默认配置输出到标准错误，不会创建失败
+	log, _ := NewLogger(cfg)
+	return log
+}
+
+// InitError 返回按全局配置创建默认Logger失败的原因，成功时返回nil
+//
+// 失败时默认Logger为输出到标准错误的控制台Logger，配置修正后（如配置文件变更）会替换为按新配置创建的Logger，
+// 此后返回nil
+func InitError() error {
+	loadDefaults()
+	if err := initErr.Load(); err != nil {
+		return *err
+	}
+	return nil
+}
+
 // 监听配置变更
 func watchConfig() {
 	// 创建配置变更监听器
@@ -372,75 +673,110 @@ func watchConfig() {
 
 	// 监听配置变更
 	for cfg := range configChan {
-		// 创建新的logger
-		newLogger, err := NewLogger(cfg)
-		if err != nil {
-			// 配置变更失败，继续使用旧配置
-			continue
-		}
+		applyConfig(cfg)
+	}
+}
+
+// applyConfig 按变更后的配置创建Logger并替换默认Logger，创建失败时通过当前Logger记录错误并继续使用旧配置
+func applyConfig(cfg *config.Config) {
+	// 创建新的logger，与旧logger共享按名称设置的级别，使已创建的命名Logger使用新的级别
+	newLogger, err := NewLogger(cfg, shareNamedLevels(DefaultLogger()))
+	if err != nil {
+		InternalLogger{Component: "logger"}.Errorw("配置变更后创建Logger失败，继续使用当前配置", "error", err)
+		return
+	}
 
-		// 更新全局logger
-		SetDefault(newLogger)
+	// 更新全局logger，并刷新旧logger中缓冲的日志；旧logger由本包创建时，稍后停止其输出，
+	// 通过 SetDefault 设置的Logger的输出由调用方管理
+	old := DefaultLogger()
+	SetDefault(newLogger)
+	initErr.Store(nil)
+	_ = old.Sync()
+	if prev := managedOutputs.Swap(outputsOf(newLogger)); prev != nil && prev == outputsOf(old) {
+		stopReplacedOutputs(prev)
 	}
 }
 
 // 全局函数，使用默认Logger
 
+// Trace 使用默认Logger输出Trace级别日志
+func Trace(msg string, fields ...Field) {
+	if !debugCompiled {
+		return
+	}
+	loadDefaults().caller.Trace(msg, fields...)
+}
+
 // Debug 使用默认Logger输出Debug级别日志
 func Debug(msg string, fields ...Field) {
-	std.Debug(msg, fields...)
+	if !debugCompiled {
+		return
+	}
+	loadDefaults().caller.Debug(msg, fields...)
 }
 
 // Info 使用默认Logger输出Info级别日志
 func Info(msg string, fields ...Field) {
-	std.Info(msg, fields...)
+	loadDefaults().caller.Info(msg, fields...)
 }
 
 // Warn 使用默认Logger输出Warn级别日志
 func Warn(msg string, fields ...Field) {
-	std.Warn(msg, fields...)
+	loadDefaults().caller.Warn(msg, fields...)
 }
 
 // Error 使用默认Logger输出Error级别日志
 func Error(msg string, fields ...Field) {
-	std.Error(msg, fields...)
+	loadDefaults().caller.Error(msg, fields...)
 }
 
 // DPanic 使用默认Logger输出DPanic级别日志
 func DPanic(msg string, fields ...Field) {
-	std.DPanic(msg, fields...)
+	loadDefaults().caller.DPanic(msg, fields...)
 }
 
 // Panic 使用默认Logger输出Panic级别日志并触发panic
 func Panic(msg string, fields ...Field) {
-	std.Panic(msg, fields...)
+	loadDefaults().caller.Panic(msg, fields...)
 }
 
-// Fatal 使用默认Logger输出Fatal级别日志并调用os.Exit(1)
+// Fatal 使用默认Logger输出Fatal级别日志，执行 Shutdown 后调用os.Exit(1)
 func Fatal(msg string, fields ...Field) {
-	std.Fatal(msg, fields...)
+	loadDefaults().caller.Fatal(msg, fields...)
 }
 
 // With 使用默认Logger创建带有字段的新Logger
 func With(fields ...Field) Logger {
-	return std.With(fields...)
+	return loadDefaults().std.With(fields...)
+}
+
+// Named 返回默认Logger名称为 name 的子Logger
+func Named(name string) Logger {
+	return loadDefaults().std.Named(name)
 }
 
 // SetLevel 设置默认Logger的日志级别
 func SetLevel(level Level) {
-	std.SetLevel(level)
+	loadDefaults().std.SetLevel(level)
+}
+
+// Sync 刷新默认Logger缓冲的日志，启用了文件写缓冲时应在程序退出前调用
+func Sync() error {
+	return DefaultLogger().Sync()
 }
 
-// SetDefault 设置默认Logger
+// SetDefault 设置默认Logger，之后的日志会附加通过 SetGlobalFields 设置的全局字段
 func SetDefault(logger Logger) {
-	mu.Lock()
-	defer mu.Unlock()
-	std = logger
+	defaults.Store(newDefaultLoggers(logger))
+}
+
+// newDefaultLoggers 根据Logger创建默认Logger，附加全局字段
+func newDefaultLoggers(logger Logger) *defaultLoggers {
+	std := logger.WithOptions(withGlobalFields())
+	return &defaultLoggers{std: std, caller: std.WithOptions(WithCallerSkip(1))}
 }
 
 // DefaultLogger 返回默认Logger
 func DefaultLogger() Logger {
-	mu.RLock()
-	defer mu.RUnlock()
-	return std
+	return loadDefaults().std
 }
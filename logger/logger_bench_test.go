@@ -0,0 +1,190 @@
+package logger
+
+import (
+	"io"
+	"testing"
+	"time"
+
+	"github.com/constructorvirgil/virlog/config"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// 创建写入io.Discard的基准测试Logger
+func newBenchLogger(b *testing.B, level string) Logger {
+	cfg := config.DefaultConfig()
+	cfg.Level = level
+	cfg.EnableCaller = false
+	cfg.EnableStacktrace = false
+
+	log, err := NewLogger(cfg, WithSyncTarget(zapcore.AddSync(io.Discard)))
+	if err != nil {
+		b.Fatal(err)
+	}
+	return log
+}
+
+// 创建写入io.Discard的原始zap Logger，用于对比
+func newBenchZapLogger() *zap.Logger {
+	core := zapcore.NewCore(
+		zapcore.NewJSONEncoder(getEncoderConfig(config.DefaultConfig())),
+		zapcore.AddSync(io.Discard),
+		zap.NewAtomicLevelAt(InfoLevel),
+	)
+	return zap.New(core)
+}
+
+// 生成指定数量的字段
+func benchFields(n int) []Field {
+	fields := []Field{
+		String("request_id", "req-1234567890"),
+		Int("status", 200),
+		Duration("latency", 15*time.Millisecond),
+		Bool("cached", true),
+		Float64("ratio", 0.75),
+		String("method", "GET"),
+		String("path", "/api/v1/users"),
+		Int64("bytes", 1024),
+		String("user_agent", "virlog-bench"),
+		Int("attempt", 1),
+	}
+	return fields[:n]
+}
+
+func BenchmarkInfo(b *testing.B) {
+	for _, n := range []int{0, 5, 10} {
+		fields := benchFields(n)
+
+		b.Run(fieldsName(n)+"/virlog", func(b *testing.B) {
+			log := newBenchLogger(b, "info")
+			b.ReportAllocs()
+			b.ResetTimer()
+			b.RunParallel(func(pb *testing.PB) {
+				for pb.Next() {
+					log.Info("benchmark message", fields...)
+				}
+			})
+		})
+
+		b.Run(fieldsName(n)+"/zap", func(b *testing.B) {
+			log := newBenchZapLogger()
+			b.ReportAllocs()
+			b.ResetTimer()
+			b.RunParallel(func(pb *testing.PB) {
+				for pb.Next() {
+					log.Info("benchmark message", fields...)
+				}
+			})
+		})
+	}
+}
+
+func BenchmarkDebugDisabled(b *testing.B) {
+	for _, n := range []int{0, 5, 10} {
+		fields := benchFields(n)
+
+		b.Run(fieldsName(n), func(b *testing.B) {
+			log := newBenchLogger(b, "info")
+			b.ReportAllocs()
+			b.ResetTimer()
+			b.RunParallel(func(pb *testing.PB) {
+				for pb.Next() {
+					log.Debug("benchmark message", fields...)
+				}
+			})
+		})
+	}
+}
+
+// 全局日志函数，同时有goroutine替换默认Logger
+func BenchmarkGlobalInfo(b *testing.B) {
+	original := DefaultLogger()
+	defer SetDefault(original)
+	SetDefault(newBenchLogger(b, "info"))
+	fields := benchFields(5)
+
+	b.Run("steady", func(b *testing.B) {
+		b.ReportAllocs()
+		b.ResetTimer()
+		b.RunParallel(func(pb *testing.PB) {
+			for pb.Next() {
+				Info("benchmark message", fields...)
+			}
+		})
+	})
+
+	b.Run("swapping", func(b *testing.B) {
+		loggers := []Logger{newBenchLogger(b, "info"), newBenchLogger(b, "info")}
+		done := make(chan struct{})
+		defer close(done)
+		go func() {
+			for i := 0; ; i++ {
+				select {
+				case <-done:
+					return
+				default:
+					SetDefault(loggers[i%2])
+					SetLevel(InfoLevel)
+				}
+			}
+		}()
+		b.ReportAllocs()
+		b.ResetTimer()
+		b.RunParallel(func(pb *testing.PB) {
+			for pb.Next() {
+				Info("benchmark message", fields...)
+			}
+		})
+	})
+}
+
+func BenchmarkFieldBuilder(b *testing.B) {
+	log := newBenchLogger(b, "info")
+	b.ReportAllocs()
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			fb := Acquire()
+			fb.Add(
+				String("request_id", "req-1234567890"),
+				Int("status", 200),
+				Duration("latency", 15*time.Millisecond),
+				Bool("cached", true),
+				Float64("ratio", 0.75),
+			)
+			log.Info("benchmark message", fb.Fields()...)
+			fb.Release()
+		}
+	})
+}
+
+func fieldsName(n int) string {
+	switch n {
+	case 0:
+		return "0fields"
+	case 5:
+		return "5fields"
+	default:
+		return "10fields"
+	}
+}
+
+func BenchmarkNewLoggerDefaultFields(b *testing.B) {
+	cfg := config.DefaultConfig()
+	cfg.EnableCaller = false
+	cfg.DefaultFields = map[string]interface{}{
+		"service": "api",
+		"version": "1.2.3",
+		"replica": 3,
+		"canary":  false,
+		"deploy":  map[string]interface{}{"region": "cn-east", "zone": "a"},
+	}
+	target := WithSyncTarget(zapcore.AddSync(io.Discard))
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := NewLogger(cfg, target); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
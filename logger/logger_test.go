@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"os"
 	"runtime"
+	"sync"
 	"testing"
 
 	"os/exec"
@@ -220,11 +221,9 @@ func TestFileOutput(t *testing.T) {
 
 // 测试全局函数
 func TestGlobalFunctions(t *testing.T) {
-	// 保存原始的std logger
-	originalStd := std
-	defer func() {
-		std = originalStd
-	}()
+	// 保存原始的默认logger
+	originalStd := DefaultLogger()
+	defer SetDefault(originalStd)
 
 	// 创建测试logger
 	logger, buf := newBufferLogger(InfoLevel)
@@ -288,3 +287,89 @@ func cleanTempFile(t *testing.T, tempFile string) {
 		t.Logf("File locked, scheduled for deletion by separate process")
 	}
 }
+
+// 测试Trace级别
+func TestTraceLevel(t *testing.T) {
+	level, ok := parseLevel("trace")
+	require.True(t, ok)
+	assert.Equal(t, TraceLevel, level)
+
+	logger, buf := newBufferLogger(DebugLevel)
+	logger.Trace("trace message")
+	assert.Empty(t, buf.String())
+
+	logger.SetLevel(TraceLevel)
+	logger.Trace("trace message")
+	assert.Contains(t, buf.String(), `"msg":"trace message"`)
+
+	// 各级别格式都能输出Trace的名称
+	for format, want := range map[string]string{
+		"lowercase":       `"level":"trace"`,
+		"uppercase":       `"level":"TRACE"`,
+		"uppercase_color": `"level":"\u001b[34mTRACE\u001b[0m"`,
+	} {
+		enc := zapcore.NewJSONEncoder(zapcore.EncoderConfig{
+			LevelKey:    "level",
+			EncodeLevel: levelEncoder(format),
+		})
+		out, err := enc.EncodeEntry(zapcore.Entry{Level: TraceLevel}, nil)
+		require.NoError(t, err)
+		assert.Contains(t, out.String(), want, format)
+	}
+}
+
+// 测试全局配置无效时使用标准错误输出的默认Logger
+func TestInitErrorFallback(t *testing.T) {
+	original := defaults.Load()
+	originalConfig := globalConfig
+	originalOutputs := managedOutputs.Load()
+	defer func() {
+		defaults.Store(original)
+		globalConfig = originalConfig
+		managedOutputs.Store(originalOutputs)
+		initDefaultOnce = sync.Once{}
+		initDefaultOnce.Do(func() {})
+		initErr.Store(nil)
+	}()
+
+	globalConfig = func() *config.Config {
+		cfg := config.DefaultConfig()
+		cfg.NamedLevels = map[string]string{"db": "verbose"}
+		return cfg
+	}
+	defaults.Store(nil)
+	initDefaultOnce = sync.Once{}
+
+	err := InitError()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "初始化默认Logger失败")
+
+	log := DefaultLogger()
+	require.NotNil(t, log)
+	assert.Equal(t, "stderr", log.(*zapLogger).config.Output)
+	assert.NotPanics(t, func() { Info("fallback message") })
+
+	// 配置修正后替换默认Logger，不再返回错误
+	applyConfig(config.DefaultConfig())
+	assert.NoError(t, InitError())
+	assert.Equal(t, "stdout", DefaultLogger().(*zapLogger).config.Output)
+}
+
+// 测试首次使用前通过 SetDefault 设置的Logger不会被替换
+func TestInitKeepsSetDefault(t *testing.T) {
+	original := defaults.Load()
+	defer func() {
+		defaults.Store(original)
+		initDefaultOnce = sync.Once{}
+		initDefaultOnce.Do(func() {})
+	}()
+
+	logger, buf := newBufferLogger(InfoLevel)
+	defaults.Store(nil)
+	initDefaultOnce = sync.Once{}
+	SetDefault(logger)
+	initDefaultOnce.Do(initDefault)
+
+	Info("kept")
+	assert.Contains(t, buf.String(), "kept")
+}
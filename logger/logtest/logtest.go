@@ -0,0 +1,186 @@
+// Package logtest 提供用于测试日志行为的Logger
+//
+// logtest.New 返回的Logger会记录所有日志，测试中可以直接断言日志的级别、消息和字段，
+// 无需解析JSON输出；日志同时通过 t.Log 输出，便于测试失败时排查
+package logtest
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/constructorvirgil/virlog/config"
+	"github.com/constructorvirgil/virlog/logger"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// Entry 一条被记录的日志
+type Entry struct {
+	zapcore.Entry
+	// 日志字段，包含With添加的上下文字段
+	Fields []logger.Field
+}
+
+// ContextMap 将日志字段编码为map，便于断言字段值
+func (e Entry) ContextMap() map[string]interface{} {
+	enc := zapcore.NewMapObjectEncoder()
+	for _, f := range e.Fields {
+		f.AddTo(enc)
+	}
+	return enc.Fields
+}
+
+// Recorder 记录Logger输出的日志
+type Recorder struct {
+	t       testing.TB
+	mu      sync.RWMutex
+	entries []Entry
+}
+
+// New 创建记录所有级别日志的Logger，返回的Recorder用于读取和断言日志
+//
+// 注意 Fatal 仍然会调用 os.Exit，Panic 仍然会panic
+func New(t testing.TB) (logger.Logger, *Recorder) {
+	t.Helper()
+
+	rec := &Recorder{t: t}
+
+	cfg := config.DefaultConfig()
+	cfg.Level = "debug"
+	cfg.Format = "console"
+	cfg.EnableSampling = false
+	cfg.EnableCaller = false
+	cfg.EnableStacktrace = false
+	cfg.ErrorReporting = nil
+
+	log, err := logger.NewLogger(cfg,
+		logger.WithSyncTarget(zapcore.AddSync(testingWriter{t: t})),
+		logger.WithHook(rec.record))
+	if err != nil {
+		t.Fatalf("创建测试Logger失败: %v", err)
+	}
+	return log, rec
+}
+
+// record 记录一条日志，作为Logger的钩子调用
+func (r *Recorder) record(ent zapcore.Entry, fields []logger.Field) error {
+	copied := make([]logger.Field, len(fields))
+	copy(copied, fields)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.entries = append(r.entries, Entry{Entry: ent, Fields: copied})
+	return nil
+}
+
+// Entries 返回已记录的所有日志
+func (r *Recorder) Entries() []Entry {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	entries := make([]Entry, len(r.entries))
+	copy(entries, r.entries)
+	return entries
+}
+
+// Len 返回已记录的日志条数
+func (r *Recorder) Len() int {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return len(r.entries)
+}
+
+// Reset 清空已记录的日志
+func (r *Recorder) Reset() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.entries = nil
+}
+
+// Filter 返回指定级别、消息包含msgSubstr且带有所有指定字段的日志
+func (r *Recorder) Filter(level logger.Level, msgSubstr string, fields ...logger.Field) []Entry {
+	expected := zapcore.NewMapObjectEncoder()
+	for _, f := range fields {
+		f.AddTo(expected)
+	}
+
+	var matched []Entry
+	for _, e := range r.Entries() {
+		if e.Level != level || !strings.Contains(e.Message, msgSubstr) {
+			continue
+		}
+		if containsFields(e.ContextMap(), expected.Fields) {
+			matched = append(matched, e)
+		}
+	}
+	return matched
+}
+
+// AssertLogged 断言至少记录了一条匹配的日志，匹配规则同 Filter
+func (r *Recorder) AssertLogged(level logger.Level, msgSubstr string, fields ...logger.Field) bool {
+	r.t.Helper()
+	if len(r.Filter(level, msgSubstr, fields...)) > 0 {
+		return true
+	}
+	r.t.Errorf("未找到匹配的日志: level=%s msg=%q fields=%v\n已记录的日志:\n%s",
+		level, msgSubstr, fieldsString(fields), r.dump())
+	return false
+}
+
+// AssertNotLogged 断言没有记录匹配的日志，匹配规则同 Filter
+func (r *Recorder) AssertNotLogged(level logger.Level, msgSubstr string, fields ...logger.Field) bool {
+	r.t.Helper()
+	matched := r.Filter(level, msgSubstr, fields...)
+	if len(matched) == 0 {
+		return true
+	}
+	r.t.Errorf("存在不应记录的日志: level=%s msg=%q fields=%v，共 %d 条",
+		level, msgSubstr, fieldsString(fields), len(matched))
+	return false
+}
+
+// dump 将已记录的日志格式化为文本，用于断言失败时输出
+func (r *Recorder) dump() string {
+	var sb strings.Builder
+	for _, e := range r.Entries() {
+		fmt.Fprintf(&sb, "  [%s] %s %v\n", e.Level, e.Message, e.ContextMap())
+	}
+	if sb.Len() == 0 {
+		return "  (无)\n"
+	}
+	return sb.String()
+}
+
+// containsFields 判断actual是否包含expected中的所有字段且值相同
+func containsFields(actual, expected map[string]interface{}) bool {
+	for k, want := range expected {
+		got, ok := actual[k]
+		if !ok || !reflect.DeepEqual(got, want) {
+			return false
+		}
+	}
+	return true
+}
+
+// fieldsString 将字段格式化为文本
+func fieldsString(fields []logger.Field) string {
+	enc := zapcore.NewMapObjectEncoder()
+	for _, f := range fields {
+		f.AddTo(enc)
+	}
+	return fmt.Sprint(enc.Fields)
+}
+
+// testingWriter 将日志输出到 t.Log
+type testingWriter struct {
+	t testing.TB
+}
+
+// Write 实现io.Writer接口
+func (w testingWriter) Write(p []byte) (int, error) {
+	w.t.Helper()
+	w.t.Log(strings.TrimSuffix(string(p), "\n"))
+	return len(p), nil
+}
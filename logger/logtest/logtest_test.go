@@ -0,0 +1,50 @@
+package logtest
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/constructorvirgil/virlog/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// 测试记录日志并按级别、消息和字段断言
+func TestRecorder(t *testing.T) {
+	log, rec := New(t)
+
+	log.Debug("debug message")
+	log.With(logger.String("request_id", "req-1")).
+		Error("query failed", logger.Int("attempt", 3), logger.Err(errors.New("timeout")))
+
+	require.Equal(t, 2, rec.Len())
+	entries := rec.Entries()
+	assert.Equal(t, logger.DebugLevel, entries[0].Level)
+	assert.Equal(t, "req-1", entries[1].ContextMap()["request_id"])
+
+	assert.True(t, rec.AssertLogged(logger.ErrorLevel, "query", logger.String("request_id", "req-1"), logger.Int("attempt", 3)))
+	assert.True(t, rec.AssertNotLogged(logger.WarnLevel, "query"))
+	assert.Empty(t, rec.Filter(logger.ErrorLevel, "query", logger.Int("attempt", 4)))
+
+	rec.Reset()
+	assert.Equal(t, 0, rec.Len())
+}
+
+// 测试断言失败时报告错误
+func TestAssertLoggedFails(t *testing.T) {
+	ft := &fakeT{TB: t}
+	log, rec := New(ft)
+	log.Info("hello")
+
+	assert.False(t, rec.AssertLogged(logger.InfoLevel, "bye"))
+	assert.False(t, rec.AssertNotLogged(logger.InfoLevel, "hello"))
+	assert.Equal(t, 2, ft.errors)
+}
+
+// fakeT 记录Errorf调用次数，不让外层测试失败
+type fakeT struct {
+	testing.TB
+	errors int
+}
+
+func (f *fakeT) Errorf(string, ...interface{}) { f.errors++ }
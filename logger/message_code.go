@@ -0,0 +1,81 @@
+package logger
+
+import (
+	"sync"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// codeKey 消息代码的字段名
+const codeKey = "code"
+
+// Code 创建消息代码字段，如 Code("AUTH001")
+//
+// 消息代码是稳定的标识，仪表盘和告警按 code 字段统计，消息文本可以通过 MessageCatalog 按语言替换
+func Code(code string) Field {
+	return String(codeKey, code)
+}
+
+// MessageCatalog 按语言和消息代码保存日志消息文本，可以并发使用
+type MessageCatalog struct {
+	mu sync.RWMutex
+	// 语言 -> 消息代码 -> 消息文本
+	messages map[string]map[string]string
+}
+
+// NewMessageCatalog 创建空的消息目录
+func NewMessageCatalog() *MessageCatalog {
+	return &MessageCatalog{messages: make(map[string]map[string]string)}
+}
+
+// Register 注册语言 lang 下消息代码对应的消息文本，已注册的代码会被覆盖
+func (c *MessageCatalog) Register(lang string, messages map[string]string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	m := c.messages[lang]
+	if m == nil {
+		m = make(map[string]string, len(messages))
+		c.messages[lang] = m
+	}
+	for code, text := range messages {
+		m[code] = text
+	}
+}
+
+// Message 返回语言 lang 下消息代码对应的消息文本
+func (c *MessageCatalog) Message(lang, code string) (string, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	text, ok := c.messages[lang][code]
+	return text, ok
+}
+
+// CatalogProcessor 将带有 code 字段的日志消息替换为消息目录中的文本，langs 按顺序查找，
+// 如 CatalogProcessor(catalog, "zh-CN", "en") 优先使用中文，没有中文文本时使用英文
+//
+// 没有 code 字段或消息目录中没有对应文本时保留原消息，code 字段始终保留
+func CatalogProcessor(catalog *MessageCatalog, langs ...string) Processor {
+	return func(entry *zapcore.Entry, fields []Field) []Field {
+		code, ok := MessageCode(fields)
+		if !ok {
+			return fields
+		}
+		for _, lang := range langs {
+			if text, ok := catalog.Message(lang, code); ok {
+				entry.Message = text
+				break
+			}
+		}
+		return fields
+	}
+}
+
+// MessageCode 返回字段中最后一个 code 字段的值，没有 code 字段时返回false
+func MessageCode(fields []Field) (string, bool) {
+	for i := len(fields) - 1; i >= 0; i-- {
+		if fields[i].Key == codeKey && fields[i].Type == zapcore.StringType {
+			return fields[i].String, true
+		}
+	}
+	return "", false
+}
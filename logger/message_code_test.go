@@ -0,0 +1,64 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/constructorvirgil/virlog/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zapcore"
+)
+
+// 测试按消息代码和语言替换日志消息
+func TestCatalogProcessor(t *testing.T) {
+	catalog := NewMessageCatalog()
+	catalog.Register("zh-CN", map[string]string{"AUTH001": "登录失败"})
+	catalog.Register("en", map[string]string{"AUTH001": "login failed", "AUTH002": "token expired"})
+
+	buf := &bytes.Buffer{}
+	cfg := config.DefaultConfig()
+	cfg.Format = "json"
+	log, err := NewLogger(cfg,
+		WithSyncTarget(zapcore.AddSync(buf)),
+		WithProcessors(CatalogProcessor(catalog, "zh-CN", "en")))
+	require.NoError(t, err)
+
+	log.Warn("auth failed", Code("AUTH001"), String("user", "alice"))
+	log.With(Code("AUTH002")).Warn("token")
+	log.Warn("unknown code", Code("AUTH999"))
+	log.Info("no code")
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Len(t, lines, 4)
+	entries := make([]map[string]interface{}, len(lines))
+	for i, line := range lines {
+		require.NoError(t, json.Unmarshal([]byte(line), &entries[i]))
+	}
+
+	assert.Equal(t, "登录失败", entries[0]["msg"])
+	assert.Equal(t, "AUTH001", entries[0]["code"])
+	assert.Equal(t, "alice", entries[0]["user"])
+	assert.Equal(t, "token expired", entries[1]["msg"], "没有中文文本时使用英文")
+	assert.Equal(t, "AUTH002", entries[1]["code"])
+	assert.Equal(t, "unknown code", entries[2]["msg"])
+	assert.Equal(t, "no code", entries[3]["msg"])
+}
+
+// 测试重复注册覆盖已有的消息文本
+func TestMessageCatalogRegister(t *testing.T) {
+	catalog := NewMessageCatalog()
+	catalog.Register("en", map[string]string{"A": "first", "B": "b"})
+	catalog.Register("en", map[string]string{"A": "second"})
+
+	text, ok := catalog.Message("en", "A")
+	assert.True(t, ok)
+	assert.Equal(t, "second", text)
+	text, ok = catalog.Message("en", "B")
+	assert.True(t, ok)
+	assert.Equal(t, "b", text)
+	_, ok = catalog.Message("fr", "A")
+	assert.False(t, ok)
+}
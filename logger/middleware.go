@@ -4,13 +4,26 @@ import (
 	"context"
 	"net/http"
 	"time"
+
+	"go.uber.org/zap/zapcore"
 )
 
-// 定义上下文key类型，用于从上下文提取日志字段
-type loggerContextKey struct{}
+// requestIDContextKey 上下文中请求ID的key
+type requestIDContextKey struct{}
+
+// requestIDKey 请求ID的字段名
+const requestIDKey = "request_id"
 
 // HTTPMiddleware 返回一个用于HTTP服务的日志中间件
-func HTTPMiddleware(logger Logger) func(http.Handler) http.Handler {
+//
+// 通过 WithRequestBody、WithResponseBody 可以额外以Debug级别记录请求体和响应体，
+// 通过 WithDebugHeader 可以对单个请求开启Debug日志，
+// 通过 WithAccessLog 可以按Apache、W3C扩展日志或ECS JSON格式输出访问日志，
+// 通过 WithLogBudget 可以限制单个请求输出的日志条数，
+// 通过 WithSkipPaths、WithPathSampling 和 WithLevelByStatus 可以跳过、采样请求日志或按状态码设置级别
+func HTTPMiddleware(logger Logger, opts ...MiddlewareOption) func(http.Handler) http.Handler {
+	cfg := newMiddlewareConfig(opts)
+
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			start := time.Now()
@@ -33,44 +46,107 @@ func HTTPMiddleware(logger Logger) func(http.Handler) http.Handler {
 
 			// 创建请求上下文的logger
 			reqLogger := logger.With(
-				String("request_id", requestID),
+				String(requestIDKey, requestID),
 				String("method", r.Method),
 				String("path", r.URL.Path),
 				String("remote_addr", r.RemoteAddr),
 				String("user_agent", r.UserAgent()),
 			)
+			if cfg.debugRequested(r) {
+				reqLogger = reqLogger.WithOptions(WithMinLevel(DebugLevel))
+			}
+
+			// 处理请求时使用的logger，设置了日志预算时受预算限制
+			handlerLogger := reqLogger
+			var budget *LogBudget
+			if cfg.logBudget > 0 {
+				budget = NewLogBudget(cfg.logBudget)
+				handlerLogger = reqLogger.WithOptions(Budget(budget))
+			}
 
 			// 将logger添加到上下文
-			ctx := context.WithValue(r.Context(), loggerContextKey{}, reqLogger)
+			ctx := NewContext(r.Context(), handlerLogger)
+			ctx = context.WithValue(ctx, requestIDContextKey{}, requestID)
 
 			// 请求开始日志
-			reqLogger.Info("HTTP request started")
+			skip := cfg.skip(r)
+			sampled := cfg.sampled(r)
+			if !skip && sampled && cfg.accessLog == nil {
+				reqLogger.Info("HTTP request started")
+			}
+
+			// 记录请求体和响应体，仅在Debug级别启用时捕获
+			captureBody := cfg.captureEnabled(reqLogger)
+			if captureBody && cfg.requestBody {
+				cfg.logRequestBody(handlerLogger, r)
+			}
+			if captureBody && cfg.responseBody {
+				rw.capture = &bodyCapture{cfg: cfg}
+			}
 
 			// 处理请求
 			next.ServeHTTP(rw, r.WithContext(ctx))
 
+			if rw.capture != nil {
+				cfg.logResponseBody(handlerLogger, rw)
+			}
+			if budget != nil {
+				budget.Summarize(reqLogger)
+			}
+
 			// 计算请求处理时间
 			duration := time.Since(start)
 
-			// 请求结束日志
-			reqLogger.Info("HTTP request completed",
-				Int("status", rw.statusCode),
-				Int64("bytes", rw.responseSize),
-				Duration("latency", duration),
-			)
+			// 请求结束日志，未被采样的请求只记录Warn及以上级别
+			level := cfg.statusLevel(rw.statusCode)
+			switch {
+			case skip:
+			case !sampled && level < WarnLevel:
+			case cfg.accessLog != nil:
+				cfg.accessLog.log(logger, level, accessRecord{
+					r:         r,
+					requestID: requestID,
+					start:     start,
+					status:    rw.statusCode,
+					bytes:     rw.responseSize,
+					latency:   duration,
+				})
+			default:
+				logAtLevel(reqLogger, level, "HTTP request completed",
+					Int("status", rw.statusCode),
+					Int64("bytes", rw.responseSize),
+					Duration("latency", duration),
+				)
+			}
 		})
 	}
 }
 
-// GetLoggerFromContext 从HTTP请求上下文中获取Logger
+// GetLoggerFromContext 从HTTP请求上下文中获取Logger，没有时返回默认Logger
+//
+// Deprecated: 使用 context 包的 GetFromContext，它读取同一个Logger，并带上通过 RegisterExtractor 注册的提取函数提取的字段
 func GetLoggerFromContext(ctx context.Context) Logger {
+	return contextLogger(ctx)
+}
+
+// RequestIDFromContext 返回上下文中的请求ID
+//
+// 优先使用 HTTPMiddleware 保存的请求ID，否则查找上下文中Logger的 request_id 字段，都没有时返回空字符串
+func RequestIDFromContext(ctx context.Context) string {
 	if ctx == nil {
-		return DefaultLogger()
+		return ""
 	}
-	if ctxLogger, ok := ctx.Value(loggerContextKey{}).(Logger); ok {
-		return ctxLogger
+	if id, ok := ctx.Value(requestIDContextKey{}).(string); ok {
+		return id
 	}
-	return DefaultLogger()
+	if l, ok := ctx.Value(loggerContextKey{}).(*zapLogger); ok {
+		for i := len(l.fields) - 1; i >= 0; i-- {
+			if f := l.fields[i]; f.Key == requestIDKey && f.Type == zapcore.StringType {
+				return f.String
+			}
+		}
+	}
+	return ""
 }
 
 // responseWriter 是对http.ResponseWriter的封装，用于捕获状态码和响应大小
@@ -78,6 +154,8 @@ type responseWriter struct {
 	http.ResponseWriter
 	statusCode   int
 	responseSize int64
+	// capture 启用响应体记录时保存响应体
+	capture *bodyCapture
 }
 
 // WriteHeader 实现http.ResponseWriter接口
@@ -89,6 +167,9 @@ func (rw *responseWriter) WriteHeader(code int) {
 // Write 实现http.ResponseWriter接口
 func (rw *responseWriter) Write(b []byte) (int, error) {
 	size, err := rw.ResponseWriter.Write(b)
+	if rw.capture != nil {
+		rw.capture.write(rw.Header().Get("Content-Type"), b[:size])
+	}
 	rw.responseSize += int64(size)
 	return size, err
 }
@@ -0,0 +1,240 @@
+package logger
+
+import (
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// AccessLogFormat HTTP访问日志的格式
+type AccessLogFormat string
+
+const (
+	// AccessLogCommon Apache/NCSA通用日志格式（CLF）
+	AccessLogCommon AccessLogFormat = "common"
+	// AccessLogCombined Apache combined日志格式，在通用格式后追加Referer和User-Agent
+	AccessLogCombined AccessLogFormat = "combined"
+	// AccessLogELF W3C扩展日志格式（Extended Log Format），写入 Sink 时首行输出 #Fields 声明
+	AccessLogELF AccessLogFormat = "elf"
+	// AccessLogJSON 使用Elastic Common Schema（ECS）字段名的JSON日志
+	AccessLogJSON AccessLogFormat = "json"
+)
+
+// elfFields W3C扩展日志格式的字段声明
+const elfFields = "date time c-ip cs-username cs-method cs-uri-stem cs-uri-query sc-status sc-bytes time-taken cs(User-Agent) cs(Referer)"
+
+// MiddlewareConfig HTTP日志中间件的访问日志配置
+type MiddlewareConfig struct {
+	// AccessLogFormat 访问日志格式，设置后每个请求输出一条访问日志，代替 HTTP request started/completed 日志
+	AccessLogFormat AccessLogFormat
+	// SkipPaths 不记录日志的请求路径（完全匹配），如健康检查
+	SkipPaths []string
+	// Sink 访问日志的输出目标，为nil时通过中间件的Logger以Info级别输出
+	Sink zapcore.WriteSyncer
+}
+
+// WithAccessLog 设置访问日志格式、输出目标和不记录日志的路径
+func WithAccessLog(cfg MiddlewareConfig) MiddlewareOption {
+	return func(c *middlewareConfig) {
+		c.accessLog = newAccessLogger(cfg)
+		c.addSkipPaths(cfg.SkipPaths)
+	}
+}
+
+// accessLogger 按格式输出访问日志
+type accessLogger struct {
+	format AccessLogFormat
+	// sink 文本格式直接写入的输出目标
+	sink zapcore.WriteSyncer
+	// ecs 写入 Sink 的JSON格式使用的Logger
+	ecs *zap.Logger
+	// elfHeader 保证W3C扩展日志格式的字段声明只输出一次
+	elfHeader sync.Once
+}
+
+// accessRecord 一次请求的访问日志内容
+type accessRecord struct {
+	r         *http.Request
+	requestID string
+	start     time.Time
+	status    int
+	bytes     int64
+	latency   time.Duration
+}
+
+// newAccessLogger 创建访问日志输出，未设置格式时返回nil
+func newAccessLogger(cfg MiddlewareConfig) *accessLogger {
+	if cfg.AccessLogFormat == "" {
+		return nil
+	}
+	a := &accessLogger{format: cfg.AccessLogFormat}
+	if cfg.Sink == nil {
+		return a
+	}
+	a.sink = zapcore.Lock(cfg.Sink)
+	if a.format == AccessLogJSON {
+		a.ecs = zap.New(zapcore.NewCore(zapcore.NewJSONEncoder(ecsEncoderConfig()), a.sink, DebugLevel))
+	}
+	return a
+}
+
+// ecsEncoderConfig 返回使用ECS字段名的编码器配置
+func ecsEncoderConfig() zapcore.EncoderConfig {
+	return zapcore.EncoderConfig{
+		TimeKey:        "@timestamp",
+		LevelKey:       "log.level",
+		MessageKey:     "message",
+		LineEnding:     zapcore.DefaultLineEnding,
+		EncodeLevel:    zapcore.LowercaseLevelEncoder,
+		EncodeTime:     zapcore.RFC3339NanoTimeEncoder,
+		EncodeDuration: zapcore.NanosDurationEncoder,
+	}
+}
+
+// log 以 level 级别输出一条访问日志，logger 为未设置 Sink 时使用的Logger，文本格式写入 Sink 时不区分级别
+func (a *accessLogger) log(logger Logger, level Level, rec accessRecord) {
+	if a.format == AccessLogJSON {
+		fields := ecsFields(rec)
+		if a.ecs != nil {
+			if ce := a.ecs.Check(level, "HTTP access"); ce != nil {
+				ce.Write(fields...)
+			}
+		} else {
+			logAtLevel(logger, level, "HTTP access", fields...)
+		}
+		return
+	}
+
+	var line string
+	switch a.format {
+	case AccessLogCommon:
+		line = commonLogLine(rec)
+	case AccessLogELF:
+		line = elfLogLine(rec)
+	default:
+		// AccessLogCombined，未知格式也按combined输出
+		line = commonLogLine(rec) + " " + quoteLogValue(rec.r.Referer()) + " " + quoteLogValue(rec.r.UserAgent())
+	}
+	if a.sink == nil {
+		logAtLevel(logger, level, line)
+		return
+	}
+	if a.format == AccessLogELF {
+		a.elfHeader.Do(func() {
+			_, _ = a.sink.Write([]byte("#Version: 1.0\n#Fields: " + elfFields + "\n"))
+		})
+	}
+	_, _ = a.sink.Write([]byte(line + "\n"))
+}
+
+// ecsFields 返回使用ECS字段名的访问日志字段
+func ecsFields(rec accessRecord) []Field {
+	r := rec.r
+	fields := []Field{
+		String("http.request.id", rec.requestID),
+		String("http.request.method", r.Method),
+		String("http.version", strconv.Itoa(r.ProtoMajor)+"."+strconv.Itoa(r.ProtoMinor)),
+		String("url.original", r.RequestURI),
+		String("url.path", r.URL.Path),
+		Int("http.response.status_code", rec.status),
+		Int64("http.response.body.bytes", rec.bytes),
+		Int64("event.duration", rec.latency.Nanoseconds()),
+		String("client.ip", clientIP(r)),
+	}
+	if ua := r.UserAgent(); ua != "" {
+		fields = append(fields, String("user_agent.original", ua))
+	}
+	if ref := r.Referer(); ref != "" {
+		fields = append(fields, String("http.request.referrer", ref))
+	}
+	if user := requestUser(r); user != "" {
+		fields = append(fields, String("user.name", user))
+	}
+	return fields
+}
+
+// commonLogLine 返回通用日志格式的一行：host ident authuser [date] "request" status bytes
+func commonLogLine(rec accessRecord) string {
+	r := rec.r
+	var b strings.Builder
+	b.WriteString(clientIP(r))
+	b.WriteString(" - ")
+	b.WriteString(orDash(requestUser(r)))
+	b.WriteString(" [")
+	b.WriteString(rec.start.Format("02/Jan/2006:15:04:05 -0700"))
+	b.WriteString("] ")
+	b.WriteString(quoteLogValue(r.Method + " " + r.RequestURI + " " + r.Proto))
+	b.WriteString(" ")
+	b.WriteString(strconv.Itoa(rec.status))
+	b.WriteString(" ")
+	if rec.bytes > 0 {
+		b.WriteString(strconv.FormatInt(rec.bytes, 10))
+	} else {
+		b.WriteString("-")
+	}
+	return b.String()
+}
+
+// elfLogLine 返回W3C扩展日志格式的一行，字段见 elfFields，时间为UTC，耗时单位为秒
+func elfLogLine(rec accessRecord) string {
+	r := rec.r
+	start := rec.start.UTC()
+	return strings.Join([]string{
+		start.Format("2006-01-02"),
+		start.Format("15:04:05"),
+		clientIP(r),
+		elfValue(requestUser(r)),
+		r.Method,
+		elfValue(r.URL.EscapedPath()),
+		elfValue(r.URL.RawQuery),
+		strconv.Itoa(rec.status),
+		strconv.FormatInt(rec.bytes, 10),
+		strconv.FormatFloat(rec.latency.Seconds(), 'f', 3, 64),
+		elfValue(r.UserAgent()),
+		elfValue(r.Referer()),
+	}, " ")
+}
+
+// clientIP 返回客户端地址中的IP
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// requestUser 返回Basic认证的用户名
+func requestUser(r *http.Request) string {
+	if user, _, ok := r.BasicAuth(); ok {
+		return user
+	}
+	return ""
+}
+
+// quoteLogValue 返回用双引号包围的值，空值为 "-"，值中的双引号和反斜杠被转义
+func quoteLogValue(s string) string {
+	if s == "" {
+		return `"-"`
+	}
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+}
+
+// orDash 空值返回 "-"
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
+
+// elfValue 返回W3C扩展日志格式的字段值，空值为 "-"，空格替换为 +
+func elfValue(s string) string {
+	return strings.ReplaceAll(orDash(s), " ", "+")
+}
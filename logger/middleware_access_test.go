@@ -0,0 +1,88 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zapcore"
+)
+
+// serveAccessLog 使用指定的访问日志配置处理一个请求
+func serveAccessLog(log Logger, cfg MiddlewareConfig, target string) {
+	handler := HTTPMiddleware(log, WithAccessLog(cfg))(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write([]byte("hello"))
+	}))
+	req := httptest.NewRequest(http.MethodGet, target, nil)
+	req.RemoteAddr = "10.0.0.1:5000"
+	req.Header.Set("User-Agent", "curl/8.0 (test)")
+	req.Header.Set("Referer", "http://example.com/")
+	req.SetBasicAuth("frank", "secret")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+}
+
+// 测试Apache和W3C扩展日志格式写入独立的输出目标
+func TestAccessLogTextFormats(t *testing.T) {
+	log, logBuf := newBufferLogger(InfoLevel)
+
+	var sink bytes.Buffer
+	serveAccessLog(log, MiddlewareConfig{AccessLogFormat: AccessLogCombined, Sink: zapcore.AddSync(&sink)}, "/users?id=1")
+	assert.Regexp(t, regexp.MustCompile(`^10\.0\.0\.1 - frank \[\d{2}/\w{3}/\d{4}:\d{2}:\d{2}:\d{2} [+-]\d{4}\] "GET /users\?id=1 HTTP/1\.1" 201 5 "http://example\.com/" "curl/8\.0 \(test\)"\n$`), sink.String())
+	assert.Empty(t, logBuf.String(), "访问日志代替请求开始和结束日志")
+
+	sink.Reset()
+	serveAccessLog(log, MiddlewareConfig{AccessLogFormat: AccessLogCommon, Sink: zapcore.AddSync(&sink)}, "/")
+	assert.True(t, strings.HasSuffix(sink.String(), `"GET / HTTP/1.1" 201 5`+"\n"), sink.String())
+
+	sink.Reset()
+	elf := MiddlewareConfig{AccessLogFormat: AccessLogELF, Sink: zapcore.AddSync(&sink)}
+	handler := HTTPMiddleware(log, WithAccessLog(elf))(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	for i := 0; i < 2; i++ {
+		req := httptest.NewRequest(http.MethodGet, "/a%20b?x=1", nil)
+		req.Header.Set("User-Agent", "curl/8.0 (test)")
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+	}
+	lines := strings.Split(strings.TrimSpace(sink.String()), "\n")
+	require.Len(t, lines, 4, "字段声明只输出一次")
+	assert.Equal(t, "#Fields: "+elfFields, lines[1])
+	assert.Regexp(t, `^\d{4}-\d{2}-\d{2} \d{2}:\d{2}:\d{2} 192\.0\.2\.1 - GET /a%20b x=1 200 0 \d+\.\d{3} curl/8\.0\+\(test\) -$`, lines[2])
+}
+
+// 测试ECS字段名的JSON访问日志以及跳过的路径
+func TestAccessLogJSON(t *testing.T) {
+	var sink bytes.Buffer
+	log, logBuf := newBufferLogger(InfoLevel)
+	cfg := MiddlewareConfig{AccessLogFormat: AccessLogJSON, Sink: zapcore.AddSync(&sink), SkipPaths: []string{"/healthz"}}
+
+	serveAccessLog(log, cfg, "/orders?page=2")
+	entry := make(map[string]interface{})
+	require.NoError(t, json.Unmarshal(sink.Bytes(), &entry))
+	assert.Equal(t, "HTTP access", entry["message"])
+	assert.Contains(t, entry, "@timestamp")
+	assert.Equal(t, "GET", entry["http.request.method"])
+	assert.Equal(t, "/orders?page=2", entry["url.original"])
+	assert.Equal(t, "/orders", entry["url.path"])
+	assert.Equal(t, float64(201), entry["http.response.status_code"])
+	assert.Equal(t, float64(5), entry["http.response.body.bytes"])
+	assert.Equal(t, "10.0.0.1", entry["client.ip"])
+	assert.Equal(t, "frank", entry["user.name"])
+	assert.NotEmpty(t, entry["http.request.id"])
+
+	sink.Reset()
+	serveAccessLog(log, cfg, "/healthz")
+	assert.Empty(t, sink.String())
+	assert.Empty(t, logBuf.String())
+
+	// 未设置输出目标时通过中间件的Logger输出
+	serveAccessLog(log, MiddlewareConfig{AccessLogFormat: AccessLogJSON}, "/orders")
+	logEntry := findLogEntry(t, logBuf.String(), "HTTP access")
+	require.NotNil(t, logEntry)
+	assert.Equal(t, float64(201), logEntry["http.response.status_code"])
+}
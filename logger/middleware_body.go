@@ -0,0 +1,291 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+const (
+	// defaultMaxBodyBytes 默认记录的请求体和响应体最大字节数
+	defaultMaxBodyBytes = 4096
+	// redactedValue 脱敏字段替换后的值
+	redactedValue = "***"
+)
+
+// defaultBodyContentTypes 默认记录的内容类型，以 / 结尾的表示该大类下的所有类型
+var defaultBodyContentTypes = []string{
+	"application/json",
+	"application/x-www-form-urlencoded",
+	"application/xml",
+	"text/",
+}
+
+// middlewareConfig HTTP日志中间件的配置
+type middlewareConfig struct {
+	requestBody  bool
+	responseBody bool
+	maxBodyBytes int
+	contentTypes []string
+	redactFields map[string]struct{}
+	debugHeader  string
+	accessLog    *accessLogger
+	skipPaths    map[string]struct{}
+	pathSampling map[string]float64
+	statusLevels *statusLevels
+	logBudget    int
+}
+
+// MiddlewareOption 定义HTTP日志中间件选项的函数类型
+type MiddlewareOption func(*middlewareConfig)
+
+// WithRequestBody 以Debug级别记录请求体
+func WithRequestBody() MiddlewareOption {
+	return func(c *middlewareConfig) {
+		c.requestBody = true
+	}
+}
+
+// WithResponseBody 以Debug级别记录响应体
+func WithResponseBody() MiddlewareOption {
+	return func(c *middlewareConfig) {
+		c.responseBody = true
+	}
+}
+
+// WithBodyMaxBytes 设置记录的请求体和响应体最大字节数，超出部分截断，默认为4096
+func WithBodyMaxBytes(n int) MiddlewareOption {
+	return func(c *middlewareConfig) {
+		if n > 0 {
+			c.maxBodyBytes = n
+		}
+	}
+}
+
+// WithBodyContentTypes 设置允许记录的内容类型，如 "application/json"、"text/"（以 / 结尾匹配整个大类）
+//
+// 默认只记录JSON、表单、XML和文本，其他类型（如文件上传、图片）不记录
+func WithBodyContentTypes(contentTypes ...string) MiddlewareOption {
+	return func(c *middlewareConfig) {
+		c.contentTypes = contentTypes
+	}
+}
+
+// WithBodyRedaction 设置需要脱敏的字段名（不区分大小写），JSON和表单中的同名字段值会被替换为 ***
+func WithBodyRedaction(fields ...string) MiddlewareOption {
+	return func(c *middlewareConfig) {
+		for _, f := range fields {
+			c.redactFields[strings.ToLower(f)] = struct{}{}
+		}
+	}
+}
+
+// WithDebugHeader 请求头 header 的值为 "1" 或 "true" 时，该请求的Logger输出Debug及以上级别的日志
+//
+// 任何客户端都可以设置请求头，建议只在内部服务或经过网关过滤的入口使用
+func WithDebugHeader(header string) MiddlewareOption {
+	return func(c *middlewareConfig) {
+		c.debugHeader = header
+	}
+}
+
+// WithLogBudget 每个请求最多输出 max 条日志，超出的日志被丢弃，请求结束时输出一条 log_budget_exceeded 汇总日志
+//
+// 预算作用于上下文中的Logger，中间件自身的请求开始和结束日志不计入，Error及以上级别的日志不会被丢弃，见 LogBudget
+func WithLogBudget(max int) MiddlewareOption {
+	return func(c *middlewareConfig) {
+		c.logBudget = max
+	}
+}
+
+// newMiddlewareConfig 应用选项并返回中间件配置
+func newMiddlewareConfig(opts []MiddlewareOption) *middlewareConfig {
+	cfg := &middlewareConfig{
+		maxBodyBytes: defaultMaxBodyBytes,
+		contentTypes: defaultBodyContentTypes,
+		redactFields: make(map[string]struct{}),
+	}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+	return cfg
+}
+
+// debugRequested 判断请求是否要求输出Debug日志
+func (c *middlewareConfig) debugRequested(r *http.Request) bool {
+	if c.debugHeader == "" {
+		return false
+	}
+	switch strings.ToLower(r.Header.Get(c.debugHeader)) {
+	case "1", "true":
+		return true
+	}
+	return false
+}
+
+// skip 判断请求路径是否不记录日志
+func (c *middlewareConfig) skip(r *http.Request) bool {
+	_, ok := c.skipPaths[r.URL.Path]
+	return ok
+}
+
+// captureEnabled 判断是否需要捕获请求体或响应体
+func (c *middlewareConfig) captureEnabled(logger Logger) bool {
+	if !c.requestBody && !c.responseBody {
+		return false
+	}
+	raw := logger.GetRawZapLogger()
+	return raw != nil && raw.Core().Enabled(DebugLevel)
+}
+
+// allowContentType 判断内容类型是否允许记录
+func (c *middlewareConfig) allowContentType(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	for _, allowed := range c.contentTypes {
+		allowed = strings.ToLower(allowed)
+		if mediaType == allowed || (strings.HasSuffix(allowed, "/") && strings.HasPrefix(mediaType, allowed)) {
+			return true
+		}
+		// application/problem+json 等结构化后缀按JSON处理
+		if allowed == "application/json" && strings.HasSuffix(mediaType, "+json") {
+			return true
+		}
+	}
+	return false
+}
+
+// logRequestBody 读取并记录请求体，读取的内容会放回请求中，不影响后续处理
+func (c *middlewareConfig) logRequestBody(logger Logger, r *http.Request) {
+	contentType := r.Header.Get("Content-Type")
+	if r.Body == nil || r.Body == http.NoBody || !c.allowContentType(contentType) {
+		return
+	}
+
+	// 多读一个字节用于判断是否截断
+	captured, err := io.ReadAll(io.LimitReader(r.Body, int64(c.maxBodyBytes)+1))
+	r.Body = readCloser{Reader: io.MultiReader(bytes.NewReader(captured), r.Body), Closer: r.Body}
+	if err != nil {
+		return
+	}
+
+	truncated := len(captured) > c.maxBodyBytes
+	if truncated {
+		captured = captured[:c.maxBodyBytes]
+	}
+	logger.Debug("HTTP request body",
+		String("request_body", c.redact(contentType, captured, truncated)),
+		Bool("request_body_truncated", truncated),
+	)
+}
+
+// logResponseBody 记录捕获的响应体
+func (c *middlewareConfig) logResponseBody(logger Logger, rw *responseWriter) {
+	capture := rw.capture
+	if !capture.allowed || capture.buf.Len() == 0 {
+		return
+	}
+	logger.Debug("HTTP response body",
+		String("response_body", c.redact(capture.contentType, capture.buf.Bytes(), capture.truncated)),
+		Bool("response_body_truncated", capture.truncated),
+	)
+}
+
+// redact 对请求体或响应体中的敏感字段脱敏
+//
+// 无法解析的JSON（如被截断）无法可靠脱敏，配置了脱敏字段时不记录其内容
+func (c *middlewareConfig) redact(contentType string, body []byte, truncated bool) string {
+	if len(c.redactFields) == 0 {
+		return string(body)
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	switch {
+	case mediaType == "application/json" || strings.HasSuffix(mediaType, "+json"):
+		var v interface{}
+		if truncated || json.Unmarshal(body, &v) != nil {
+			return "[body omitted: cannot redact]"
+		}
+		redacted, err := json.Marshal(c.redactJSON(v))
+		if err != nil {
+			return "[body omitted: cannot redact]"
+		}
+		return string(redacted)
+	case mediaType == "application/x-www-form-urlencoded":
+		values, err := url.ParseQuery(string(body))
+		if err != nil {
+			return "[body omitted: cannot redact]"
+		}
+		for k := range values {
+			if _, ok := c.redactFields[strings.ToLower(k)]; ok {
+				values[k] = []string{redactedValue}
+			}
+		}
+		return values.Encode()
+	default:
+		return string(body)
+	}
+}
+
+// redactJSON 递归替换JSON中需要脱敏的字段
+func (c *middlewareConfig) redactJSON(v interface{}) interface{} {
+	switch val := v.(type) {
+	case map[string]interface{}:
+		for k, item := range val {
+			if _, ok := c.redactFields[strings.ToLower(k)]; ok {
+				val[k] = redactedValue
+				continue
+			}
+			val[k] = c.redactJSON(item)
+		}
+	case []interface{}:
+		for i, item := range val {
+			val[i] = c.redactJSON(item)
+		}
+	}
+	return v
+}
+
+// bodyCapture 捕获响应体的前 maxBodyBytes 字节
+type bodyCapture struct {
+	cfg         *middlewareConfig
+	buf         bytes.Buffer
+	checked     bool
+	allowed     bool
+	contentType string
+	truncated   bool
+}
+
+// write 记录写入的响应内容，第一次写入时根据内容类型决定是否记录
+func (b *bodyCapture) write(contentType string, p []byte) {
+	if !b.checked {
+		b.checked = true
+		if contentType == "" {
+			contentType = http.DetectContentType(p)
+		}
+		b.contentType = contentType
+		b.allowed = b.cfg.allowContentType(contentType)
+	}
+	if !b.allowed || b.truncated {
+		return
+	}
+
+	remaining := b.cfg.maxBodyBytes - b.buf.Len()
+	if len(p) > remaining {
+		p = p[:remaining]
+		b.truncated = true
+	}
+	b.buf.Write(p)
+}
+
+// readCloser 组合Reader和Closer，用于放回已读取的请求体
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
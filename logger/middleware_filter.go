@@ -0,0 +1,96 @@
+package logger
+
+import (
+	"math/rand/v2"
+	"net/http"
+)
+
+// WithSkipPaths 设置不记录请求日志和访问日志的请求路径（完全匹配），如健康检查和指标接口
+//
+// 请求仍然正常处理，上下文中的Logger不受影响
+func WithSkipPaths(paths ...string) MiddlewareOption {
+	return func(c *middlewareConfig) {
+		c.addSkipPaths(paths)
+	}
+}
+
+// WithPathSampling 对路径为 path（完全匹配）的请求按比例 rate 记录请求日志和访问日志，如 0.01 表示记录1%
+//
+// 用于高频的轮询接口。未被采样的请求在结束时如果按 WithLevelByStatus 应输出Warn及以上级别的日志，仍会输出结束日志
+func WithPathSampling(path string, rate float64) MiddlewareOption {
+	return func(c *middlewareConfig) {
+		if c.pathSampling == nil {
+			c.pathSampling = make(map[string]float64)
+		}
+		c.pathSampling[path] = rate
+	}
+}
+
+// WithLevelByStatus 按响应状态码设置请求结束日志和访问日志的级别：5xx 使用 serverError，4xx 使用 clientError，
+// 其他状态码使用Info级别
+//
+// 未设置时所有请求都使用Info级别，高于Error的级别按Error输出
+func WithLevelByStatus(serverError, clientError Level) MiddlewareOption {
+	return func(c *middlewareConfig) {
+		c.statusLevels = &statusLevels{serverError: serverError, clientError: clientError}
+	}
+}
+
+// statusLevels 按状态码设置的日志级别
+type statusLevels struct {
+	serverError Level
+	clientError Level
+}
+
+// addSkipPaths 添加不记录日志的请求路径
+func (c *middlewareConfig) addSkipPaths(paths []string) {
+	if len(paths) > 0 && c.skipPaths == nil {
+		c.skipPaths = make(map[string]struct{}, len(paths))
+	}
+	for _, p := range paths {
+		c.skipPaths[p] = struct{}{}
+	}
+}
+
+// sampled 判断请求是否被采样记录，未设置采样的路径总是记录
+func (c *middlewareConfig) sampled(r *http.Request) bool {
+	rate, ok := c.pathSampling[r.URL.Path]
+	if !ok || rate >= 1 {
+		return true
+	}
+	return rate > 0 && rand.Float64() < rate
+}
+
+// statusLevel 返回状态码对应的请求结束日志级别
+func (c *middlewareConfig) statusLevel(status int) Level {
+	if c.statusLevels == nil {
+		return InfoLevel
+	}
+	level := InfoLevel
+	switch {
+	case status >= 500:
+		level = c.statusLevels.serverError
+	case status >= 400:
+		level = c.statusLevels.clientError
+	}
+	if level > ErrorLevel {
+		level = ErrorLevel
+	}
+	return level
+}
+
+// logAtLevel 以指定级别输出日志，级别不高于Error
+func logAtLevel(logger Logger, level Level, msg string, fields ...Field) {
+	switch {
+	case level <= TraceLevel:
+		logger.Trace(msg, fields...)
+	case level == DebugLevel:
+		logger.Debug(msg, fields...)
+	case level == InfoLevel:
+		logger.Info(msg, fields...)
+	case level == WarnLevel:
+		logger.Warn(msg, fields...)
+	default:
+		logger.Error(msg, fields...)
+	}
+}
@@ -0,0 +1,96 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zapcore"
+)
+
+// statusHandler 返回请求参数 status 指定的状态码
+var statusHandler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	switch r.URL.Query().Get("status") {
+	case "404":
+		w.WriteHeader(http.StatusNotFound)
+	case "500":
+		w.WriteHeader(http.StatusInternalServerError)
+	}
+})
+
+// serveFiltered 使用中间件处理一个请求
+func serveFiltered(handler http.Handler, target string) {
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, target, nil))
+}
+
+// 测试跳过健康检查和指标路径
+func TestHTTPMiddlewareSkipPaths(t *testing.T) {
+	log, buf := newBufferLogger(InfoLevel)
+	var inHandler bool
+	handler := HTTPMiddleware(log, WithSkipPaths("/healthz", "/metrics"))(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		inHandler = GetLoggerFromContext(r.Context()) != DefaultLogger()
+	}))
+
+	serveFiltered(handler, "/healthz")
+	serveFiltered(handler, "/metrics")
+	assert.Empty(t, buf.String())
+	assert.True(t, inHandler, "上下文中的Logger不受影响")
+
+	serveFiltered(handler, "/metrics/extra")
+	assert.NotNil(t, findLogEntry(t, buf.String(), "HTTP request completed"))
+}
+
+// 测试按路径采样，未被采样的错误请求仍然记录
+func TestHTTPMiddlewarePathSampling(t *testing.T) {
+	log, buf := newBufferLogger(InfoLevel)
+	handler := HTTPMiddleware(log,
+		WithPathSampling("/poll", 0),
+		WithPathSampling("/always", 1),
+		WithLevelByStatus(ErrorLevel, WarnLevel),
+	)(statusHandler)
+
+	for i := 0; i < 10; i++ {
+		serveFiltered(handler, "/poll")
+	}
+	assert.Empty(t, buf.String())
+
+	serveFiltered(handler, "/poll?status=500")
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Len(t, lines, 1, "只输出结束日志")
+	entry := findLogEntry(t, buf.String(), "HTTP request completed")
+	require.NotNil(t, entry)
+	assert.Equal(t, "error", entry["level"])
+
+	buf.Reset()
+	serveFiltered(handler, "/always")
+	assert.NotNil(t, findLogEntry(t, buf.String(), "HTTP request started"))
+}
+
+// 测试按状态码设置请求结束日志和访问日志的级别
+func TestHTTPMiddlewareLevelByStatus(t *testing.T) {
+	log, buf := newBufferLogger(InfoLevel)
+	handler := HTTPMiddleware(log, WithLevelByStatus(ErrorLevel, WarnLevel))(statusHandler)
+
+	for status, level := range map[string]string{"200": "info", "404": "warn", "500": "error"} {
+		buf.Reset()
+		serveFiltered(handler, "/?status="+status)
+		entry := findLogEntry(t, buf.String(), "HTTP request completed")
+		require.NotNil(t, entry, status)
+		assert.Equal(t, level, entry["level"], status)
+	}
+
+	var sink bytes.Buffer
+	handler = HTTPMiddleware(log,
+		WithAccessLog(MiddlewareConfig{AccessLogFormat: AccessLogJSON, Sink: zapcore.AddSync(&sink)}),
+		WithLevelByStatus(FatalLevel, WarnLevel),
+	)(statusHandler)
+	serveFiltered(handler, "/?status=500")
+	entry := make(map[string]interface{})
+	require.NoError(t, json.Unmarshal(sink.Bytes(), &entry))
+	assert.Equal(t, "error", entry["log.level"], "高于Error的级别按Error输出")
+}
@@ -0,0 +1,88 @@
+package logger
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// findLogEntry 在JSON格式的日志输出中查找指定消息的日志
+func findLogEntry(t *testing.T, output, msg string) map[string]interface{} {
+	t.Helper()
+	for _, line := range strings.Split(strings.TrimSpace(output), "\n") {
+		entry := make(map[string]interface{})
+		require.NoError(t, json.Unmarshal([]byte(line), &entry))
+		if entry["msg"] == msg {
+			return entry
+		}
+	}
+	return nil
+}
+
+// 测试记录请求体和响应体并脱敏
+func TestHTTPMiddlewareBodyLogging(t *testing.T) {
+	log, buf := newBufferLogger(DebugLevel)
+
+	var handlerBody string
+	handler := HTTPMiddleware(log,
+		WithRequestBody(),
+		WithResponseBody(),
+		WithBodyRedaction("password", "token"),
+	)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		handlerBody = string(body)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"token":"abc","user":{"name":"alice"}}`))
+	}))
+
+	reqBody := `{"name":"alice","password":"secret"}`
+	req := httptest.NewRequest(http.MethodPost, "/login", strings.NewReader(reqBody))
+	req.Header.Set("Content-Type", "application/json; charset=utf-8")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	assert.Equal(t, reqBody, handlerBody, "处理器应读取到完整的请求体")
+
+	reqEntry := findLogEntry(t, buf.String(), "HTTP request body")
+	require.NotNil(t, reqEntry)
+	assert.Equal(t, "debug", reqEntry["level"])
+	assert.Contains(t, reqEntry["request_body"], `"password":"***"`)
+	assert.NotContains(t, reqEntry["request_body"], "secret")
+
+	respEntry := findLogEntry(t, buf.String(), "HTTP response body")
+	require.NotNil(t, respEntry)
+	assert.Contains(t, respEntry["response_body"], `"token":"***"`)
+	assert.Contains(t, respEntry["response_body"], "alice")
+}
+
+// 测试请求体截断和内容类型过滤
+func TestHTTPMiddlewareBodyLimits(t *testing.T) {
+	log, buf := newBufferLogger(DebugLevel)
+
+	handler := HTTPMiddleware(log, WithRequestBody(), WithResponseBody(), WithBodyMaxBytes(5))(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "image/png")
+			_, _ = w.Write([]byte("binary-data"))
+		}))
+
+	req := httptest.NewRequest(http.MethodPost, "/upload", strings.NewReader("hello world"))
+	req.Header.Set("Content-Type", "text/plain")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	reqEntry := findLogEntry(t, buf.String(), "HTTP request body")
+	require.NotNil(t, reqEntry)
+	assert.Equal(t, "hello", reqEntry["request_body"])
+	assert.Equal(t, true, reqEntry["request_body_truncated"])
+	assert.Nil(t, findLogEntry(t, buf.String(), "HTTP response body"), "不在允许列表中的内容类型不应记录")
+
+	// Debug级别未启用时不捕获
+	infoLog, infoBuf := newBufferLogger(InfoLevel)
+	HTTPMiddleware(infoLog, WithRequestBody())(http.NotFoundHandler()).
+		ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/", strings.NewReader("{}")))
+	assert.NotContains(t, infoBuf.String(), "HTTP request body")
+}
@@ -0,0 +1,367 @@
+package logger
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"time"
+)
+
+// msgpackEventTimeExt Fluent forward协议中EventTime的扩展类型
+const msgpackEventTimeExt = 0
+
+// msgpackEventTime 按Fluent EventTime扩展类型编码的时间
+type msgpackEventTime time.Time
+
+// msgpackWriter 按MessagePack格式编码值，只支持日志字段中出现的类型
+type msgpackWriter struct {
+	buf []byte
+}
+
+// writeValue 编码任意值，不支持的类型编码为其文本形式
+func (w *msgpackWriter) writeValue(v interface{}) {
+	switch val := v.(type) {
+	case nil:
+		w.buf = append(w.buf, 0xc0)
+	case bool:
+		if val {
+			w.buf = append(w.buf, 0xc3)
+		} else {
+			w.buf = append(w.buf, 0xc2)
+		}
+	case string:
+		w.writeString(val)
+	case []byte:
+		w.writeBinary(val)
+	case int:
+		w.writeInt(int64(val))
+	case int8:
+		w.writeInt(int64(val))
+	case int16:
+		w.writeInt(int64(val))
+	case int32:
+		w.writeInt(int64(val))
+	case int64:
+		w.writeInt(val)
+	case uint:
+		w.writeUint(uint64(val))
+	case uint8:
+		w.writeUint(uint64(val))
+	case uint16:
+		w.writeUint(uint64(val))
+	case uint32:
+		w.writeUint(uint64(val))
+	case uint64:
+		w.writeUint(val)
+	case uintptr:
+		w.writeUint(uint64(val))
+	case float32:
+		w.writeFloat(float64(val))
+	case float64:
+		w.writeFloat(val)
+	case time.Duration:
+		w.writeInt(int64(val))
+	case time.Time:
+		w.writeString(val.Format(time.RFC3339Nano))
+	case msgpackEventTime:
+		w.writeEventTime(time.Time(val))
+	case []interface{}:
+		w.writeArrayHeader(len(val))
+		for _, item := range val {
+			w.writeValue(item)
+		}
+	case map[string]interface{}:
+		w.writeMap(val)
+	case error:
+		w.writeString(val.Error())
+	default:
+		w.writeString(fmt.Sprint(val))
+	}
+}
+
+// writeMap 编码map，key按字典序排列保证输出稳定
+func (w *msgpackWriter) writeMap(m map[string]interface{}) {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	w.writeMapHeader(len(keys))
+	for _, k := range keys {
+		w.writeString(k)
+		w.writeValue(m[k])
+	}
+}
+
+// writeMapHeader 编码map头
+func (w *msgpackWriter) writeMapHeader(n int) {
+	switch {
+	case n < 16:
+		w.buf = append(w.buf, 0x80|byte(n))
+	case n <= math.MaxUint16:
+		w.buf = append(w.buf, 0xde)
+		w.buf = binary.BigEndian.AppendUint16(w.buf, uint16(n))
+	default:
+		w.buf = append(w.buf, 0xdf)
+		w.buf = binary.BigEndian.AppendUint32(w.buf, uint32(n))
+	}
+}
+
+// writeArrayHeader 编码数组头
+func (w *msgpackWriter) writeArrayHeader(n int) {
+	switch {
+	case n < 16:
+		w.buf = append(w.buf, 0x90|byte(n))
+	case n <= math.MaxUint16:
+		w.buf = append(w.buf, 0xdc)
+		w.buf = binary.BigEndian.AppendUint16(w.buf, uint16(n))
+	default:
+		w.buf = append(w.buf, 0xdd)
+		w.buf = binary.BigEndian.AppendUint32(w.buf, uint32(n))
+	}
+}
+
+// writeString 编码字符串
+func (w *msgpackWriter) writeString(s string) {
+	n := len(s)
+	switch {
+	case n < 32:
+		w.buf = append(w.buf, 0xa0|byte(n))
+	case n <= math.MaxUint8:
+		w.buf = append(w.buf, 0xd9, byte(n))
+	case n <= math.MaxUint16:
+		w.buf = append(w.buf, 0xda)
+		w.buf = binary.BigEndian.AppendUint16(w.buf, uint16(n))
+	default:
+		w.buf = append(w.buf, 0xdb)
+		w.buf = binary.BigEndian.AppendUint32(w.buf, uint32(n))
+	}
+	w.buf = append(w.buf, s...)
+}
+
+// writeBinary 编码二进制数据
+func (w *msgpackWriter) writeBinary(b []byte) {
+	n := len(b)
+	switch {
+	case n <= math.MaxUint8:
+		w.buf = append(w.buf, 0xc4, byte(n))
+	case n <= math.MaxUint16:
+		w.buf = append(w.buf, 0xc5)
+		w.buf = binary.BigEndian.AppendUint16(w.buf, uint16(n))
+	default:
+		w.buf = append(w.buf, 0xc6)
+		w.buf = binary.BigEndian.AppendUint32(w.buf, uint32(n))
+	}
+	w.buf = append(w.buf, b...)
+}
+
+// writeInt 编码有符号整数
+func (w *msgpackWriter) writeInt(n int64) {
+	switch {
+	case n >= 0:
+		w.writeUint(uint64(n))
+	case n >= -32:
+		w.buf = append(w.buf, byte(n))
+	case n >= math.MinInt8:
+		w.buf = append(w.buf, 0xd0, byte(n))
+	case n >= math.MinInt16:
+		w.buf = append(w.buf, 0xd1)
+		w.buf = binary.BigEndian.AppendUint16(w.buf, uint16(n))
+	case n >= math.MinInt32:
+		w.buf = append(w.buf, 0xd2)
+		w.buf = binary.BigEndian.AppendUint32(w.buf, uint32(n))
+	default:
+		w.buf = append(w.buf, 0xd3)
+		w.buf = binary.BigEndian.AppendUint64(w.buf, uint64(n))
+	}
+}
+
+// writeUint 编码无符号整数
+func (w *msgpackWriter) writeUint(n uint64) {
+	switch {
+	case n < 128:
+		w.buf = append(w.buf, byte(n))
+	case n <= math.MaxUint8:
+		w.buf = append(w.buf, 0xcc, byte(n))
+	case n <= math.MaxUint16:
+		w.buf = append(w.buf, 0xcd)
+		w.buf = binary.BigEndian.AppendUint16(w.buf, uint16(n))
+	case n <= math.MaxUint32:
+		w.buf = append(w.buf, 0xce)
+		w.buf = binary.BigEndian.AppendUint32(w.buf, uint32(n))
+	default:
+		w.buf = append(w.buf, 0xcf)
+		w.buf = binary.BigEndian.AppendUint64(w.buf, n)
+	}
+}
+
+// writeFloat 编码浮点数
+func (w *msgpackWriter) writeFloat(f float64) {
+	w.buf = append(w.buf, 0xcb)
+	w.buf = binary.BigEndian.AppendUint64(w.buf, math.Float64bits(f))
+}
+
+// writeEventTime 编码Fluent EventTime扩展类型，精确到纳秒
+func (w *msgpackWriter) writeEventTime(t time.Time) {
+	w.buf = append(w.buf, 0xd7, msgpackEventTimeExt)
+	w.buf = binary.BigEndian.AppendUint32(w.buf, uint32(t.Unix()))
+	w.buf = binary.BigEndian.AppendUint32(w.buf, uint32(t.Nanosecond()))
+}
+
+// readMsgpack 从r中解码一个值，EventTime解码为time.Time，map解码为map[string]interface{}
+func readMsgpack(r *bufio.Reader) (interface{}, error) {
+	c, err := r.ReadByte()
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case c <= 0x7f:
+		return int64(c), nil
+	case c >= 0xe0:
+		return int64(int8(c)), nil
+	case c&0xf0 == 0x80:
+		return readMsgpackMap(r, int(c&0x0f))
+	case c&0xf0 == 0x90:
+		return readMsgpackArray(r, int(c&0x0f))
+	case c&0xe0 == 0xa0:
+		return readMsgpackString(r, int(c&0x1f))
+	}
+
+	switch c {
+	case 0xc0:
+		return nil, nil
+	case 0xc2:
+		return false, nil
+	case 0xc3:
+		return true, nil
+	case 0xc4, 0xc5, 0xc6:
+		n, err := readMsgpackLength(r, c-0xc4)
+		if err != nil {
+			return nil, err
+		}
+		b := make([]byte, n)
+		_, err = io.ReadFull(r, b)
+		return b, err
+	case 0xca:
+		b, err := readMsgpackBytes(r, 4)
+		if err != nil {
+			return nil, err
+		}
+		return float64(math.Float32frombits(binary.BigEndian.Uint32(b))), nil
+	case 0xcb:
+		b, err := readMsgpackBytes(r, 8)
+		if err != nil {
+			return nil, err
+		}
+		return math.Float64frombits(binary.BigEndian.Uint64(b)), nil
+	case 0xcc, 0xcd, 0xce, 0xcf:
+		b, err := readMsgpackBytes(r, 1<<(c-0xcc))
+		if err != nil {
+			return nil, err
+		}
+		return int64(readBigEndian(b)), nil
+	case 0xd0, 0xd1, 0xd2, 0xd3:
+		size := 1 << (c - 0xd0)
+		b, err := readMsgpackBytes(r, size)
+		if err != nil {
+			return nil, err
+		}
+		// 按位宽进行符号扩展
+		shift := 64 - 8*size
+		return int64(readBigEndian(b)<<shift) >> shift, nil
+	case 0xd7:
+		b, err := readMsgpackBytes(r, 9)
+		if err != nil {
+			return nil, err
+		}
+		if b[0] != msgpackEventTimeExt {
+			return nil, fmt.Errorf("不支持的msgpack扩展类型: %d", b[0])
+		}
+		return time.Unix(int64(binary.BigEndian.Uint32(b[1:5])), int64(binary.BigEndian.Uint32(b[5:9]))), nil
+	case 0xd9, 0xda, 0xdb:
+		n, err := readMsgpackLength(r, c-0xd9)
+		if err != nil {
+			return nil, err
+		}
+		return readMsgpackString(r, n)
+	case 0xdc, 0xdd:
+		n, err := readMsgpackLength(r, c-0xdc+1)
+		if err != nil {
+			return nil, err
+		}
+		return readMsgpackArray(r, n)
+	case 0xde, 0xdf:
+		n, err := readMsgpackLength(r, c-0xde+1)
+		if err != nil {
+			return nil, err
+		}
+		return readMsgpackMap(r, n)
+	}
+	return nil, fmt.Errorf("不支持的msgpack类型: 0x%x", c)
+}
+
+// readMsgpackLength 读取长度，sizeIndex 为0、1、2分别表示1、2、4字节
+func readMsgpackLength(r *bufio.Reader, sizeIndex byte) (int, error) {
+	b, err := readMsgpackBytes(r, 1<<sizeIndex)
+	if err != nil {
+		return 0, err
+	}
+	return int(readBigEndian(b)), nil
+}
+
+// readMsgpackBytes 读取n个字节
+func readMsgpackBytes(r *bufio.Reader, n int) ([]byte, error) {
+	b := make([]byte, n)
+	_, err := io.ReadFull(r, b)
+	return b, err
+}
+
+// readBigEndian 将大端字节解析为整数
+func readBigEndian(b []byte) uint64 {
+	var n uint64
+	for _, c := range b {
+		n = n<<8 | uint64(c)
+	}
+	return n
+}
+
+// readMsgpackString 读取长度为n的字符串
+func readMsgpackString(r *bufio.Reader, n int) (string, error) {
+	b, err := readMsgpackBytes(r, n)
+	return string(b), err
+}
+
+// readMsgpackArray 读取n个元素的数组
+func readMsgpackArray(r *bufio.Reader, n int) ([]interface{}, error) {
+	arr := make([]interface{}, n)
+	for i := range arr {
+		v, err := readMsgpack(r)
+		if err != nil {
+			return nil, err
+		}
+		arr[i] = v
+	}
+	return arr, nil
+}
+
+// readMsgpackMap 读取n个键值对的map，key转换为字符串
+func readMsgpackMap(r *bufio.Reader, n int) (map[string]interface{}, error) {
+	m := make(map[string]interface{}, n)
+	for i := 0; i < n; i++ {
+		k, err := readMsgpack(r)
+		if err != nil {
+			return nil, err
+		}
+		v, err := readMsgpack(r)
+		if err != nil {
+			return nil, err
+		}
+		m[fmt.Sprint(k)] = v
+	}
+	return m, nil
+}
@@ -0,0 +1,74 @@
+package logger
+
+import (
+	"fmt"
+	"strings"
+	"sync/atomic"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// nameMarker 通过 Named 添加到core的标记，字段类型为Skip，编码时被忽略
+type nameMarker struct {
+	name string
+}
+
+// nameField 返回 Named 的标记字段
+func nameField(name string) Field {
+	return Field{Type: zapcore.SkipType, Interface: nameMarker{name: name}}
+}
+
+// namedLevels 按Logger名称前缀设置的级别，配置变更时整体替换，
+// 默认Logger在配置变更前后共享同一个实例，已创建的命名Logger也会使用新的级别
+type namedLevels struct {
+	levels atomic.Pointer[map[string]zapcore.Level]
+}
+
+// parseNamedLevels 解析配置中按名称设置的级别
+func parseNamedLevels(cfg map[string]string) (map[string]zapcore.Level, error) {
+	levels := make(map[string]zapcore.Level, len(cfg))
+	for name, levelStr := range cfg {
+		level, ok := parseLevel(levelStr)
+		if !ok {
+			return nil, fmt.Errorf("Logger %s 的日志级别无效: %s", name, levelStr)
+		}
+		levels[name] = level
+	}
+	return levels, nil
+}
+
+// store 替换按名称设置的级别
+func (n *namedLevels) store(levels map[string]zapcore.Level) {
+	n.levels.Store(&levels)
+}
+
+// lookup 返回名称匹配的最长前缀设置的级别，前缀按 "." 分隔的段匹配，
+// 如 "http" 匹配 "http" 和 "http.client"，不匹配 "https"
+func (n *namedLevels) lookup(name string) (zapcore.Level, bool) {
+	if n == nil || name == "" {
+		return 0, false
+	}
+	levels := n.levels.Load()
+	if levels == nil || len(*levels) == 0 {
+		return 0, false
+	}
+	for {
+		if level, ok := (*levels)[name]; ok {
+			return level, true
+		}
+		i := strings.LastIndexByte(name, '.')
+		if i < 0 {
+			return 0, false
+		}
+		name = name[:i]
+	}
+}
+
+// shareNamedLevels 使新Logger与 log 共享按名称设置的级别
+func shareNamedLevels(log Logger) Option {
+	return func(l *zapLogger) {
+		if z, ok := log.(*zapLogger); ok {
+			l.named = z.named
+		}
+	}
+}
@@ -0,0 +1,62 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/constructorvirgil/virlog/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zapcore"
+)
+
+// 测试子Logger的名称层级和按名称前缀设置的级别
+func TestNamedLogger(t *testing.T) {
+	buf := &bytes.Buffer{}
+	cfg := config.DefaultConfig()
+	cfg.Format = "json"
+	cfg.Level = "info"
+	cfg.NamedLevels = map[string]string{
+		"http":        "warn",
+		"http.client": "debug",
+	}
+	log, err := NewLogger(cfg, WithSyncTarget(zapcore.AddSync(buf)))
+	require.NoError(t, err)
+
+	client := log.Named("http").Named("client")
+	client.Debug("client debug")
+	log.Named("http").Info("http info")
+	log.Named("https").Info("https info")
+	log.Named("http").Named("server").Warn("server warn")
+	log.Debug("root debug")
+
+	var names []string
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		entry := make(map[string]interface{})
+		require.NoError(t, json.Unmarshal([]byte(line), &entry))
+		names = append(names, entry["logger"].(string)+":"+entry["msg"].(string))
+	}
+	assert.Equal(t, []string{
+		"http.client:client debug",
+		"https:https info",
+		"http.server:server warn",
+	}, names)
+
+	// 按名称设置的级别变更后，已创建的子Logger使用新的级别
+	cfg.NamedLevels = map[string]string{"http": "error"}
+	_, err = NewLogger(cfg, WithSyncTarget(zapcore.AddSync(buf)), shareNamedLevels(log))
+	require.NoError(t, err)
+	buf.Reset()
+	client.Debug("client debug")
+	client.Warn("client warn")
+	client.Error("client error")
+	assert.NotContains(t, buf.String(), "client debug")
+	assert.NotContains(t, buf.String(), "client warn")
+	assert.Contains(t, buf.String(), "client error")
+
+	cfg.NamedLevels = map[string]string{"http": "verbose"}
+	_, err = NewLogger(cfg)
+	assert.Error(t, err)
+}
@@ -12,3 +12,12 @@ func WithSyncTarget(syncTarget zapcore.WriteSyncer) Option {
 		l.syncTarget = syncTarget
 	}
 }
+
+// WithCallerSkip 增加记录调用者时跳过的调用层数，语义与zap.AddCallerSkip相同
+//
+// 封装了Logger的辅助函数可以用它让调用者指向辅助函数的调用方，而不是辅助函数本身
+func WithCallerSkip(skip int) Option {
+	return func(l *zapLogger) {
+		l.callerSkip += skip
+	}
+}
@@ -0,0 +1,108 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"runtime"
+	"testing"
+
+	"github.com/constructorvirgil/virlog/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zapcore"
+)
+
+// newCallerLogger 创建记录调用者信息并输出到buffer的Logger
+func newCallerLogger(t *testing.T, opts ...Option) (Logger, *bytes.Buffer) {
+	buf := &bytes.Buffer{}
+	cfg := config.DefaultConfig()
+	cfg.Format = "json"
+	cfg.EnableCaller = true
+	log, err := NewLogger(cfg, append([]Option{WithSyncTarget(zapcore.AddSync(buf))}, opts...)...)
+	require.NoError(t, err)
+	return log, buf
+}
+
+// lastCaller 返回最后一条日志的调用者
+func lastCaller(t *testing.T, buf *bytes.Buffer) string {
+	lines := bytes.Split(bytes.TrimSpace(buf.Bytes()), []byte("\n"))
+	entry := make(map[string]interface{})
+	require.NoError(t, json.Unmarshal(lines[len(lines)-1], &entry))
+	caller, _ := entry["caller"].(string)
+	return caller
+}
+
+// helperLine logHelper 中日志调用的位置
+var helperLine string
+
+// logHelper 模拟业务项目中封装Logger的辅助函数
+func logHelper(log Logger, msg string) {
+	helperLine = callerLine()
+	log.Info(msg)
+}
+
+// callerLine 返回调用位置的下一行，用于断言下一行日志调用的调用者
+func callerLine() string {
+	_, _, line, _ := runtime.Caller(1)
+	return fmt.Sprintf("logger/option_test.go:%d", line+1)
+}
+
+// 测试调用者信息指向实际调用位置，以及WithCallerSkip跳过封装函数
+func TestWithCallerSkip(t *testing.T) {
+	log, buf := newCallerLogger(t)
+	expected := callerLine()
+	log.Info("direct")
+	assert.Equal(t, expected, lastCaller(t, buf))
+
+	logHelper(log, "wrapped")
+	assert.Equal(t, helperLine, lastCaller(t, buf), "未跳过时调用者为封装函数")
+
+	skipped, buf := newCallerLogger(t, WithCallerSkip(1))
+	expected = callerLine()
+	logHelper(skipped, "skipped")
+	assert.Equal(t, expected, lastCaller(t, buf), "跳过一层后调用者为封装函数的调用方")
+
+	// WithOptions 基于已有Logger调整，不影响原Logger
+	log, buf = newCallerLogger(t)
+	child := log.With(String("component", "db")).WithOptions(WithCallerSkip(1))
+	expected = callerLine()
+	logHelper(child, "child")
+	assert.Equal(t, expected, lastCaller(t, buf))
+	assert.Contains(t, buf.String(), `"component":"db"`)
+
+	expected = callerLine()
+	log.Info("parent")
+	assert.Equal(t, expected, lastCaller(t, buf))
+
+	// 原始zap.Logger的调用者为直接调用位置
+	expected = callerLine()
+	log.GetRawZapLogger().Info("raw")
+	assert.Equal(t, expected, lastCaller(t, buf))
+
+	// 全局函数的调用者为调用全局函数的位置
+	originalStd := DefaultLogger()
+	defer SetDefault(originalStd)
+	SetDefault(log)
+	expected = callerLine()
+	Info("global")
+	assert.Equal(t, expected, lastCaller(t, buf))
+}
+
+// 测试WithOptions追加钩子
+func TestWithOptionsHook(t *testing.T) {
+	log, _ := newCallerLogger(t)
+
+	var fields []Field
+	hooked := log.With(String("request_id", "req-1")).WithOptions(WithHook(func(ent zapcore.Entry, f []Field) error {
+		fields = f
+		return nil
+	}))
+	hooked.Info("hooked", Int("n", 1))
+	require.Len(t, fields, 2)
+	assert.Equal(t, "request_id", fields[0].Key)
+
+	fields = nil
+	log.Info("not hooked")
+	assert.Nil(t, fields)
+}
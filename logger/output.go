@@ -0,0 +1,423 @@
+package logger
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"github.com/constructorvirgil/virlog/config"
+	"github.com/constructorvirgil/virlog/internal/compress"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"gopkg.in/natefinch/lumberjack.v2"
+)
+
+// OutputFactory 根据输出参数创建输出目标，参数来自配置中的 OutputOptions
+type OutputFactory func(cfg map[string]interface{}) (zapcore.WriteSyncer, error)
+
+var (
+	// 已注册的输出工厂，内置输出在包变量初始化时注册，保证全局Logger初始化时可用
+	outputFactories = map[string]OutputFactory{
+		"stdout":       newStdoutOutput,
+		"stderr":       newStderrOutput,
+		"file":         newFileOutput,
+		sinkOutputName: newSinkOutput,
+	}
+	outputFactoriesMu sync.RWMutex
+)
+
+// RegisterOutputFactory 注册输出工厂，之后可以在配置中通过 Output: "<name>" 使用，
+// OutputOptions 中的参数会传给工厂
+//
+// 内置的 stdout、stderr、file 和 sink 输出也通过该注册表创建，重复注册同名工厂会覆盖之前的注册
+func RegisterOutputFactory(name string, factory OutputFactory) error {
+	if name == "" {
+		return fmt.Errorf("输出名称不能为空")
+	}
+	if factory == nil {
+		return fmt.Errorf("输出工厂不能为nil: %s", name)
+	}
+
+	outputFactoriesMu.Lock()
+	defer outputFactoriesMu.Unlock()
+	outputFactories[name] = factory
+	return nil
+}
+
+// UnregisterOutputFactory 注销输出工厂
+func UnregisterOutputFactory(name string) {
+	outputFactoriesMu.Lock()
+	defer outputFactoriesMu.Unlock()
+	delete(outputFactories, name)
+}
+
+// lookupOutputFactory 查找已注册的输出工厂
+func lookupOutputFactory(name string) (OutputFactory, bool) {
+	outputFactoriesMu.RLock()
+	defer outputFactoriesMu.RUnlock()
+	factory, ok := outputFactories[name]
+	return factory, ok
+}
+
+// getOutputConfig 根据配置创建输出目标，未注册的输出名称使用stdout
+func getOutputConfig(cfg *config.Config) (zapcore.WriteSyncer, error) {
+	name := cfg.Output
+	options := make(map[string]interface{}, len(cfg.OutputOptions)+5)
+	for k, v := range cfg.OutputOptions {
+		options[k] = v
+	}
+
+	switch {
+	case strings.HasPrefix(name, sinkOutputPrefix):
+		// sink:<name> 等价于 Output: "sink" 且 OutputOptions.name 为 <name>
+		options["name"] = strings.TrimPrefix(name, sinkOutputPrefix)
+		name = sinkOutputName
+	case name == "file":
+		// 兼容 FileConfig，OutputOptions 中的同名参数优先
+		if cfg.FileConfig == nil {
+			cfg.FileConfig = config.DefaultConfig().FileConfig
+		}
+		for k, v := range fileConfigOptions(cfg.FileConfig) {
+			if _, ok := options[k]; !ok {
+				options[k] = v
+			}
+		}
+	}
+
+	factory, ok := lookupOutputFactory(name)
+	if !ok {
+		factory = newStdoutOutput
+	}
+	ws, err := factory(options)
+	if err != nil {
+		return nil, fmt.Errorf("创建输出 %s 失败: %w", name, err)
+	}
+	return ws, nil
+}
+
+// newTeeOutputCore 为 Outputs 中的每个输出创建独立编码器的核心，并合并为一个核心
+func newTeeOutputCore(encoderConfig zapcore.EncoderConfig, cfg *config.Config, enab zapcore.LevelEnabler, outputs *outputStoppers) (zapcore.Core, error) {
+	cores := make([]zapcore.Core, 0, len(cfg.Outputs))
+	for i, output := range cfg.Outputs {
+		outputCfg := *cfg
+		outputCfg.Outputs = nil
+		outputCfg.Output = output.Type
+		if outputCfg.Output == "" {
+			outputCfg.Output = "stdout"
+		}
+		if output.Format != "" {
+			outputCfg.Format = output.Format
+		}
+		outputCfg.OutputOptions = output.Options
+		if output.FileConfig != nil {
+			outputCfg.FileConfig = output.FileConfig
+		}
+		if output.CEF != nil {
+			outputCfg.CEF = output.CEF
+		}
+
+		outputEncoderConfig := encoderConfig
+		if output.Encoder != nil {
+			outputCfg.Encoder = mergeEncoderConfig(cfg.Encoder, output.Encoder)
+			outputEncoderConfig = getEncoderConfig(&outputCfg)
+		}
+		if outputCfg.Format != "console" && (output.Encoder == nil || output.Encoder.LevelFormat == "") {
+			// 彩色级别只适合控制台格式，避免颜色控制字符写入JSON等结构化日志，该输出明确指定级别格式时除外
+			outputEncoderConfig.EncodeLevel = plainLevelEncoder(&outputCfg)
+		}
+
+		outputEnab := enab
+		if output.Level != "" {
+			level, ok := parseLevel(output.Level)
+			if !ok {
+				return nil, fmt.Errorf("输出 %d 的日志级别无效: %s", i, output.Level)
+			}
+			outputEnab = zap.LevelEnablerFunc(func(l zapcore.Level) bool {
+				return l >= level && enab.Enabled(l)
+			})
+		}
+
+		core, err := newOutputCore(nil, outputEncoderConfig, &outputCfg, outputEnab, outputs)
+		if err != nil {
+			return nil, err
+		}
+		cores = append(cores, core)
+	}
+	return &teeOutputCore{Core: zapcore.NewTee(cores...), outputs: cores}, nil
+}
+
+// teeOutputCore 写入多个输出的核心，保留各输出的核心以便统计队列使用率
+type teeOutputCore struct {
+	zapcore.Core
+	outputs []zapcore.Core
+}
+
+// queueUsage 返回各输出中最大的队列使用率
+func (c *teeOutputCore) queueUsage() float64 {
+	var usage float64
+	for _, core := range c.outputs {
+		if q, ok := core.(queueReporter); ok {
+			usage = max(usage, q.queueUsage())
+		}
+	}
+	return usage
+}
+
+// newStdoutOutput 创建标准输出
+func newStdoutOutput(map[string]interface{}) (zapcore.WriteSyncer, error) {
+	return consoleOutput{os.Stdout}, nil
+}
+
+// newStderrOutput 创建标准错误输出
+func newStderrOutput(map[string]interface{}) (zapcore.WriteSyncer, error) {
+	return consoleOutput{os.Stderr}, nil
+}
+
+// consoleOutput 标准输出和标准错误输出
+type consoleOutput struct {
+	*os.File
+}
+
+// Sync 刷新输出，标准输出是管道或终端时不支持fsync，忽略返回的 EINVAL 和 ENOTTY
+func (o consoleOutput) Sync() error {
+	err := o.File.Sync()
+	if errors.Is(err, syscall.EINVAL) || errors.Is(err, syscall.ENOTTY) {
+		return nil
+	}
+	return err
+}
+
+// newSinkOutput 使用通过 RegisterSink 注册的输出目标，参数 name 为目标名称
+func newSinkOutput(cfg map[string]interface{}) (zapcore.WriteSyncer, error) {
+	return lookupSink(optionString(cfg, "name", ""))
+}
+
+// fileConfigOptions 将文件输出配置转换为输出参数
+func fileConfigOptions(fc *config.FileConfig) map[string]interface{} {
+	return map[string]interface{}{
+		"filename":       fc.Filename,
+		"max_size":       fc.MaxSize,
+		"max_backups":    fc.MaxBackups,
+		"max_age":        fc.MaxAge,
+		"compress":       fc.Compress,
+		"buffer_size":    fc.BufferSize,
+		"flush_interval": fc.FlushInterval,
+		"archive":        fc.Archive,
+	}
+}
+
+// defaultFlushInterval 文件输出启用缓冲时默认的刷新间隔
+const defaultFlushInterval = time.Second
+
+// newFileOutput 创建按大小切割的文件输出
+//
+// buffer_size 大于0时日志先写入内存缓冲区，缓冲区写满或每隔 flush_interval 写入文件，
+// Logger.Sync 以及 Panic、Fatal 级别的日志会立即刷新缓冲区
+func newFileOutput(cfg map[string]interface{}) (zapcore.WriteSyncer, error) {
+	filename := optionString(cfg, "filename", "")
+	if filename == "" {
+		return nil, fmt.Errorf("未指定日志文件路径")
+	}
+	compress := optionBool(cfg, "compress", false)
+	var arch *archiver
+	if ac, _ := cfg["archive"].(*config.ArchiveConfig); ac != nil {
+		var err error
+		if arch, err = newArchiver(filename, compress, ac); err != nil {
+			return nil, fmt.Errorf("启动日志归档失败: %w", err)
+		}
+	}
+	file := &lumberjack.Logger{
+		Filename:   filename,
+		MaxSize:    optionInt(cfg, "max_size", 0),
+		MaxBackups: optionInt(cfg, "max_backups", 0),
+		MaxAge:     optionInt(cfg, "max_age", 0),
+		Compress:   compress,
+	}
+	out := &fileOutput{WriteSyncer: zapcore.AddSync(file), file: file, archiver: arch}
+	if arch != nil {
+		go arch.run()
+	}
+
+	bufferSize := optionInt(cfg, "buffer_size", 0)
+	if bufferSize <= 0 {
+		return out, nil
+	}
+	out.buffered = &zapcore.BufferedWriteSyncer{
+		WS:            out.WriteSyncer,
+		Size:          bufferSize,
+		FlushInterval: optionDuration(cfg, "flush_interval", defaultFlushInterval),
+	}
+	out.WriteSyncer = out.buffered
+	return out, nil
+}
+
+// fileOutput 文件输出，Logger被替换后停止缓冲区的定时刷新和归档任务并关闭文件
+type fileOutput struct {
+	zapcore.WriteSyncer
+	buffered *zapcore.BufferedWriteSyncer
+	file     *lumberjack.Logger
+	// archiver 该输出的归档任务，未开启归档时为nil
+	archiver *archiver
+}
+
+// stopOutput 实现outputStopper接口，停止归档任务，刷新缓冲区后关闭文件
+func (o *fileOutput) stopOutput() error {
+	if o.archiver != nil {
+		o.archiver.close()
+	}
+	var err error
+	if o.buffered != nil {
+		err = o.buffered.Stop()
+	}
+	return errors.Join(err, o.file.Close())
+}
+
+// errOutputStopped 输出已随被替换的Logger停止，日志被丢弃
+var errOutputStopped = errors.New("输出已停止，日志被丢弃")
+
+// replacedOutputGrace Logger被替换后，等待仍在使用旧Logger的写入完成再停止其输出的时间（纳秒），
+// 配置变更时在后台读取，测试时修改
+var replacedOutputGrace atomic.Int64
+
+func init() {
+	replacedOutputGrace.Store(int64(5 * time.Second))
+}
+
+// outputStopper 带有后台goroutine、网络连接或打开的文件的输出
+type outputStopper interface {
+	// stopOutput 发送或写入缓冲的日志，停止后台goroutine并释放资源
+	stopOutput() error
+}
+
+// outputStoppers 一个Logger创建的需要停止的输出，由其派生的Logger共享
+type outputStoppers struct {
+	mu       sync.Mutex
+	stoppers []outputStopper
+	stopped  bool
+}
+
+// add 记录需要停止的输出，v 未实现outputStopper时忽略
+func (s *outputStoppers) add(v interface{}) {
+	o, ok := v.(outputStopper)
+	if !ok || s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.stoppers = append(s.stoppers, o)
+}
+
+// stop 停止所有输出，重复调用时不做任何操作
+func (s *outputStoppers) stop() error {
+	s.mu.Lock()
+	if s.stopped {
+		s.mu.Unlock()
+		return nil
+	}
+	s.stopped = true
+	stoppers := s.stoppers
+	s.mu.Unlock()
+
+	var errs []error
+	for _, o := range stoppers {
+		if err := o.stopOutput(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// outputsOf 返回由 NewLogger 创建的Logger的输出，其他Logger返回nil
+func outputsOf(log Logger) *outputStoppers {
+	if l, ok := log.(*zapLogger); ok {
+		return l.outputs
+	}
+	return nil
+}
+
+// stopReplacedOutputs 在 replacedOutputGrace 之后停止被替换的Logger的输出，
+// 此后仍使用该Logger写入的日志可能被丢弃
+func stopReplacedOutputs(outputs *outputStoppers) {
+	if outputs == nil {
+		return
+	}
+	time.AfterFunc(time.Duration(replacedOutputGrace.Load()), func() {
+		_ = outputs.stop()
+	})
+}
+
+// optionString 读取字符串参数
+func optionString(cfg map[string]interface{}, key, def string) string {
+	v, ok := cfg[key]
+	if !ok || v == nil {
+		return def
+	}
+	if s, ok := v.(string); ok {
+		return s
+	}
+	return fmt.Sprint(v)
+}
+
+// optionInt 读取整数参数，兼容JSON解码得到的float64和字符串
+func optionInt(cfg map[string]interface{}, key string, def int) int {
+	switch v := cfg[key].(type) {
+	case int:
+		return v
+	case int64:
+		return int(v)
+	case float64:
+		return int(v)
+	case string:
+		if n, err := strconv.Atoi(v); err == nil {
+			return n
+		}
+	}
+	return def
+}
+
+// optionBool 读取布尔参数，兼容字符串
+func optionBool(cfg map[string]interface{}, key string, def bool) bool {
+	switch v := cfg[key].(type) {
+	case bool:
+		return v
+	case string:
+		if b, err := strconv.ParseBool(v); err == nil {
+			return b
+		}
+	}
+	return def
+}
+
+// optionDuration 读取时间间隔参数，兼容字符串（如 "500ms"）和以纳秒为单位的数字
+func optionDuration(cfg map[string]interface{}, key string, def time.Duration) time.Duration {
+	var d time.Duration
+	switch v := cfg[key].(type) {
+	case time.Duration:
+		d = v
+	case int:
+		d = time.Duration(v)
+	case int64:
+		d = time.Duration(v)
+	case float64:
+		d = time.Duration(v)
+	case string:
+		if parsed, err := time.ParseDuration(v); err == nil {
+			d = parsed
+		}
+	}
+	if d <= 0 {
+		return def
+	}
+	return d
+}
+
+// newCompressor 根据配置创建网络输出的压缩器，supported 为输出支持的算法，未配置算法时返回nil
+func newCompressor(cfg config.CompressionConfig, supported ...string) (*compress.Compressor, error) {
+	return compress.New(cfg.Algorithm, cfg.Level, cfg.MinBytes, supported...)
+}
@@ -0,0 +1,489 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/constructorvirgil/virlog/config"
+	"github.com/constructorvirgil/virlog/internal/compress"
+	"go.uber.org/zap/zapcore"
+)
+
+const (
+	// elasticsearchOutput 输出到Elasticsearch的Output配置值
+	elasticsearchOutput = "elasticsearch"
+	// esDefaultURL 默认的Elasticsearch地址
+	esDefaultURL = "http://localhost:9200"
+	// esDefaultIndex 默认的索引名前缀
+	esDefaultIndex = "logs-virlog"
+	// esDefaultBatchSize 默认每个_bulk请求包含的日志条数
+	esDefaultBatchSize = 500
+	// esDefaultFlushInterval 默认的定时发送间隔
+	esDefaultFlushInterval = time.Second
+	// esDefaultBufferSize 默认缓冲的日志条数
+	esDefaultBufferSize = 8192
+	// esDefaultMaxRetries 默认的429重试次数
+	esDefaultMaxRetries = 3
+	// esDefaultTimeout 默认的请求超时时间
+	esDefaultTimeout = 10 * time.Second
+	// esIndexDateLayout 按天索引的日期格式
+	esIndexDateLayout = "2006.01.02"
+	// esTemplatePriority 索引模板的优先级，高于Elasticsearch内置的 logs-*-* 模板
+	esTemplatePriority = 200
+)
+
+// errElasticsearchBufferFull Elasticsearch发送缓冲区已满，日志被丢弃
+var errElasticsearchBufferFull = errors.New("elasticsearch缓冲区已满，日志被丢弃")
+
+// esRetryInterval 收到429后第一次重试的等待时间，之后每次翻倍
+var esRetryInterval = 500 * time.Millisecond
+
+// esDocument 待发送的一条日志
+type esDocument struct {
+	index string
+	body  []byte
+}
+
+// elasticsearchCore 通过_bulk接口批量写入Elasticsearch的core
+//
+// 日志先写入内存缓冲区，由后台goroutine按批或定时发送，返回429时按退避间隔重试
+type elasticsearchCore struct {
+	zapcore.LevelEnabler
+	fields []Field
+	client *esClient
+}
+
+// newElasticsearchCore 根据配置创建写入Elasticsearch的core
+func newElasticsearchCore(enab zapcore.LevelEnabler, cfg *config.Config) (zapcore.Core, error) {
+	ec := config.ElasticsearchConfig{}
+	if cfg.Elasticsearch != nil {
+		ec = *cfg.Elasticsearch
+	}
+	if ec.URL == "" {
+		ec.URL = esDefaultURL
+	}
+	if ec.Index == "" {
+		ec.Index = esDefaultIndex
+	}
+	if ec.BatchSize <= 0 {
+		ec.BatchSize = esDefaultBatchSize
+	}
+	if ec.FlushInterval <= 0 {
+		ec.FlushInterval = esDefaultFlushInterval
+	}
+	if ec.BufferSize <= 0 {
+		ec.BufferSize = esDefaultBufferSize
+	}
+	if ec.MaxRetries <= 0 {
+		ec.MaxRetries = esDefaultMaxRetries
+	}
+	if ec.Timeout <= 0 {
+		ec.Timeout = esDefaultTimeout
+	}
+	ec.URL = strings.TrimRight(ec.URL, "/")
+	compressor, err := newCompressor(ec.Compression, compress.Gzip)
+	if err != nil {
+		return nil, fmt.Errorf("elasticsearch输出: %w", err)
+	}
+
+	client := &esClient{
+		cfg:        ec,
+		compressor: compressor,
+		http:       &http.Client{Timeout: ec.Timeout},
+		queue:      make(chan esDocument, ec.BufferSize),
+		flush:      make(chan chan error),
+		stop:       make(chan struct{}),
+		done:       make(chan struct{}),
+	}
+	go client.run()
+
+	return &elasticsearchCore{LevelEnabler: enab, client: client}, nil
+}
+
+// With 实现zapcore.Core接口
+func (c *elasticsearchCore) With(fields []Field) zapcore.Core {
+	merged := make([]Field, 0, len(c.fields)+len(fields))
+	merged = append(merged, c.fields...)
+	merged = append(merged, fields...)
+	return &elasticsearchCore{LevelEnabler: c.LevelEnabler, fields: merged, client: c.client}
+}
+
+// Check 实现zapcore.Core接口
+func (c *elasticsearchCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(ent.Level) {
+		return ce.AddCore(ent, c)
+	}
+	return ce
+}
+
+// Write 实现zapcore.Core接口，缓冲区已满时丢弃日志并返回错误
+func (c *elasticsearchCore) Write(ent zapcore.Entry, fields []Field) error {
+	enc := zapcore.NewMapObjectEncoder()
+	for _, f := range c.fields {
+		f.AddTo(enc)
+	}
+	for _, f := range fields {
+		f.AddTo(enc)
+	}
+
+	doc := enc.Fields
+	doc["@timestamp"] = ent.Time.UTC().Format(time.RFC3339Nano)
+	doc["level"] = levelName(ent.Level)
+	doc["message"] = ent.Message
+	if ent.LoggerName != "" {
+		doc["logger"] = ent.LoggerName
+	}
+	if ent.Caller.Defined {
+		doc["caller"] = ent.Caller.TrimmedPath()
+	}
+	if ent.Stack != "" {
+		doc["stacktrace"] = ent.Stack
+	}
+	body, err := json.Marshal(doc)
+	if err != nil {
+		return fmt.Errorf("编码Elasticsearch文档失败: %w", err)
+	}
+
+	select {
+	case <-c.client.done:
+		return errOutputStopped
+	default:
+	}
+	select {
+	case c.client.queue <- esDocument{index: c.client.indexName(ent.Time), body: body}:
+		return nil
+	default:
+		return errElasticsearchBufferFull
+	}
+}
+
+// Sync 实现zapcore.Core接口，等待缓冲区中的日志发送完成，返回上次Sync以来发送失败的错误
+func (c *elasticsearchCore) Sync() error {
+	return c.client.sync()
+}
+
+// stopOutput 实现outputStopper接口，发送缓冲区中的日志后停止后台goroutine
+func (c *elasticsearchCore) stopOutput() error {
+	return c.client.close()
+}
+
+// queueUsage 返回缓冲区使用率
+func (c *elasticsearchCore) queueUsage() float64 {
+	return float64(len(c.client.queue)) / float64(cap(c.client.queue))
+}
+
+// esClient 批量发送日志到Elasticsearch，请求只在后台goroutine中发送
+type esClient struct {
+	cfg        config.ElasticsearchConfig
+	compressor *compress.Compressor
+	http       *http.Client
+	queue      chan esDocument
+	flush      chan chan error
+	// stop 关闭后后台goroutine发送缓冲区中的日志并退出，退出后关闭 done
+	stop     chan struct{}
+	done     chan struct{}
+	stopOnce sync.Once
+
+	// templateReady 索引模板已创建
+	templateReady bool
+
+	mu      sync.Mutex
+	lastErr error
+}
+
+// indexName 返回日志写入的索引，数据流使用固定名称，否则按UTC日期每天一个索引
+func (c *esClient) indexName(t time.Time) string {
+	if c.cfg.DataStream {
+		return c.cfg.Index
+	}
+	return c.cfg.Index + "-" + t.UTC().Format(esIndexDateLayout)
+}
+
+// run 从缓冲区取出日志，满一批或到达定时间隔时发送
+func (c *esClient) run() {
+	defer close(c.done)
+	ticker := time.NewTicker(c.cfg.FlushInterval)
+	defer ticker.Stop()
+
+	batch := make([]esDocument, 0, c.cfg.BatchSize)
+	for {
+		select {
+		case doc := <-c.queue:
+			batch = append(batch, doc)
+			if len(batch) >= c.cfg.BatchSize {
+				batch = c.send(batch)
+			}
+		case <-ticker.C:
+			batch = c.send(batch)
+		case done := <-c.flush:
+			batch = c.drain(batch)
+			done <- c.takeError()
+		case <-c.stop:
+			c.drain(batch)
+			return
+		}
+	}
+}
+
+// drain 发送当前批次和缓冲区中的所有日志
+func (c *esClient) drain(batch []esDocument) []esDocument {
+	for {
+		batch = c.send(batch)
+		if len(c.queue) == 0 {
+			return batch
+		}
+		batch = c.collect(batch)
+	}
+}
+
+// collect 取出缓冲区中已有的日志，最多凑满一批
+func (c *esClient) collect(batch []esDocument) []esDocument {
+	for len(batch) < c.cfg.BatchSize {
+		select {
+		case doc := <-c.queue:
+			batch = append(batch, doc)
+		default:
+			return batch
+		}
+	}
+	return batch
+}
+
+// sync 等待缓冲区中的日志发送完成
+func (c *esClient) sync() error {
+	done := make(chan error, 1)
+	select {
+	case c.flush <- done:
+	case <-c.done:
+		return nil
+	case <-time.After(c.cfg.Timeout):
+		return fmt.Errorf("等待Elasticsearch发送超时")
+	}
+	select {
+	case err := <-done:
+		return err
+	case <-time.After(c.cfg.Timeout * time.Duration(c.cfg.MaxRetries+1)):
+		return fmt.Errorf("等待Elasticsearch发送超时")
+	}
+}
+
+// close 停止后台goroutine，等待缓冲区中的日志发送完成
+func (c *esClient) close() error {
+	c.stopOnce.Do(func() { close(c.stop) })
+	select {
+	case <-c.done:
+		return c.takeError()
+	case <-time.After(c.cfg.Timeout * time.Duration(c.cfg.MaxRetries+1)):
+		return fmt.Errorf("等待Elasticsearch发送超时")
+	}
+}
+
+// setError 记录发送失败的错误，Sync时返回
+func (c *esClient) setError(err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.lastErr = err
+}
+
+// takeError 返回并清除记录的错误
+func (c *esClient) takeError() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	err := c.lastErr
+	c.lastErr = nil
+	return err
+}
+
+// send 发送一批日志，返回清空后的batch以便复用
+//
+// 整个请求或其中部分文档返回429时，按退避间隔只重试这些文档，最多重试 MaxRetries 次
+func (c *esClient) send(batch []esDocument) []esDocument {
+	if len(batch) == 0 {
+		return batch
+	}
+	if c.cfg.Template && !c.templateReady {
+		if err := c.putTemplate(); err != nil {
+			c.setError(err)
+		} else {
+			c.templateReady = true
+		}
+	}
+
+	pending := batch
+	interval := esRetryInterval
+	for attempt := 0; ; attempt++ {
+		retry, err := c.bulk(pending)
+		if err != nil {
+			c.setError(err)
+		}
+		if len(retry) == 0 {
+			break
+		}
+		if attempt >= c.cfg.MaxRetries {
+			c.setError(fmt.Errorf("elasticsearch持续返回429，%d条日志被丢弃", len(retry)))
+			break
+		}
+		time.Sleep(interval)
+		interval *= 2
+		pending = retry
+	}
+
+	for i := range batch {
+		batch[i] = esDocument{}
+	}
+	return batch[:0]
+}
+
+// esBulkResponse _bulk接口的响应
+type esBulkResponse struct {
+	Errors bool `json:"errors"`
+	Items  []map[string]struct {
+		Status int `json:"status"`
+		Error  struct {
+			Type   string `json:"type"`
+			Reason string `json:"reason"`
+		} `json:"error"`
+	} `json:"items"`
+}
+
+// bulk 发送一次_bulk请求，返回需要因429重试的文档
+func (c *esClient) bulk(docs []esDocument) ([]esDocument, error) {
+	op := "index"
+	if c.cfg.DataStream {
+		// 数据流只支持create操作
+		op = "create"
+	}
+	var body bytes.Buffer
+	for _, doc := range docs {
+		action, _ := json.Marshal(map[string]interface{}{op: map[string]string{"_index": doc.index}})
+		body.Write(action)
+		body.WriteByte('\n')
+		body.Write(doc.body)
+		body.WriteByte('\n')
+	}
+
+	payload, encoding, err := c.compressor.Compress(body.Bytes())
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.doEncoded(http.MethodPost, "/_bulk", "application/x-ndjson", encoding, bytes.NewReader(payload))
+	if err != nil {
+		return nil, fmt.Errorf("发送日志到Elasticsearch失败: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusTooManyRequests {
+		io.Copy(io.Discard, resp.Body)
+		return docs, nil
+	}
+	if resp.StatusCode/100 != 2 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return nil, fmt.Errorf("elasticsearch返回%d: %s", resp.StatusCode, bytes.TrimSpace(msg))
+	}
+
+	var result esBulkResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("解析Elasticsearch响应失败: %w", err)
+	}
+	if !result.Errors {
+		return nil, nil
+	}
+
+	var (
+		retry    []esDocument
+		failed   int
+		firstErr string
+	)
+	for i, item := range result.Items {
+		if i >= len(docs) {
+			break
+		}
+		for _, r := range item {
+			switch {
+			case r.Status == http.StatusTooManyRequests:
+				retry = append(retry, docs[i])
+			case r.Status/100 != 2:
+				failed++
+				if firstErr == "" {
+					firstErr = r.Error.Type + ": " + r.Error.Reason
+				}
+			}
+		}
+	}
+	if failed > 0 {
+		return retry, fmt.Errorf("elasticsearch拒绝了%d条日志: %s", failed, firstErr)
+	}
+	return retry, nil
+}
+
+// putTemplate 创建或更新索引模板，映射日志的固定字段
+func (c *esClient) putTemplate() error {
+	template := map[string]interface{}{
+		"priority": esTemplatePriority,
+		"template": map[string]interface{}{
+			"mappings": map[string]interface{}{
+				"properties": map[string]interface{}{
+					"@timestamp": map[string]string{"type": "date"},
+					"level":      map[string]string{"type": "keyword"},
+					"logger":     map[string]string{"type": "keyword"},
+					"caller":     map[string]string{"type": "keyword"},
+					"message":    map[string]string{"type": "text"},
+					"stacktrace": map[string]interface{}{"type": "text", "index": false},
+				},
+			},
+		},
+	}
+	if c.cfg.DataStream {
+		template["index_patterns"] = []string{c.cfg.Index}
+		template["data_stream"] = map[string]interface{}{}
+	} else {
+		template["index_patterns"] = []string{c.cfg.Index + "-*"}
+	}
+	body, err := json.Marshal(template)
+	if err != nil {
+		return err
+	}
+
+	resp, err := c.do(http.MethodPut, "/_index_template/"+c.cfg.Index, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("创建Elasticsearch索引模板失败: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("创建Elasticsearch索引模板失败，返回%d: %s", resp.StatusCode, bytes.TrimSpace(msg))
+	}
+	io.Copy(io.Discard, resp.Body)
+	return nil
+}
+
+// do 发送带认证信息的请求
+func (c *esClient) do(method, path, contentType string, body io.Reader) (*http.Response, error) {
+	return c.doEncoded(method, path, contentType, "", body)
+}
+
+// doEncoded 发送带认证信息的请求，encoding 不为空时设置为请求体的 Content-Encoding
+func (c *esClient) doEncoded(method, path, contentType, encoding string, body io.Reader) (*http.Response, error) {
+	req, err := http.NewRequest(method, c.cfg.URL+path, body)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", contentType)
+	if encoding != "" {
+		req.Header.Set("Content-Encoding", encoding)
+	}
+	switch {
+	case c.cfg.APIKey != "":
+		req.Header.Set("Authorization", "ApiKey "+c.cfg.APIKey)
+	case c.cfg.Username != "":
+		req.SetBasicAuth(c.cfg.Username, c.cfg.Password)
+	}
+	return c.http.Do(req)
+}
@@ -0,0 +1,278 @@
+package logger
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/constructorvirgil/virlog/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeElasticsearch 模拟Elasticsearch的_bulk和_index_template接口
+type fakeElasticsearch struct {
+	server *httptest.Server
+
+	mu        sync.Mutex
+	actions   []map[string]map[string]string
+	docs      []map[string]interface{}
+	templates map[string]map[string]interface{}
+	auth      []string
+	encodings []string
+	// reject429 前几次_bulk请求整体返回429
+	reject429 int
+	// rejectItem 第一次_bulk请求中该位置的文档返回429
+	rejectItem int
+}
+
+// newFakeElasticsearch 创建模拟服务
+func newFakeElasticsearch(t *testing.T) *fakeElasticsearch {
+	f := &fakeElasticsearch{templates: make(map[string]map[string]interface{}), rejectItem: -1}
+	f.server = httptest.NewServer(http.HandlerFunc(f.handle))
+	t.Cleanup(f.server.Close)
+	return f
+}
+
+// handle 处理请求，_bulk请求的每个文档都写入成功，除非设置了拒绝
+func (f *fakeElasticsearch) handle(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.auth = append(f.auth, r.Header.Get("Authorization"))
+	f.encodings = append(f.encodings, r.Header.Get("Content-Encoding"))
+	if r.Header.Get("Content-Encoding") == "gzip" {
+		gr, err := gzip.NewReader(r.Body)
+		if err != nil {
+			http.Error(w, "invalid gzip body", http.StatusBadRequest)
+			return
+		}
+		r.Body = gr
+	}
+
+	if strings.HasPrefix(r.URL.Path, "/_index_template/") {
+		var template map[string]interface{}
+		_ = json.NewDecoder(r.Body).Decode(&template)
+		f.templates[strings.TrimPrefix(r.URL.Path, "/_index_template/")] = template
+		fmt.Fprint(w, `{"acknowledged":true}`)
+		return
+	}
+
+	if f.reject429 > 0 {
+		f.reject429--
+		w.WriteHeader(http.StatusTooManyRequests)
+		return
+	}
+
+	var (
+		items  []string
+		errors bool
+	)
+	scanner := bufio.NewScanner(r.Body)
+	for i := 0; scanner.Scan(); i++ {
+		var action map[string]map[string]string
+		if err := json.Unmarshal(scanner.Bytes(), &action); err != nil || len(action) != 1 || !scanner.Scan() {
+			http.Error(w, "invalid bulk body", http.StatusBadRequest)
+			return
+		}
+		op := "index"
+		if _, ok := action["create"]; ok {
+			op = "create"
+		}
+		if i == f.rejectItem {
+			f.rejectItem = -1
+			errors = true
+			items = append(items, fmt.Sprintf(`{%q:{"status":429,"error":{"type":"es_rejected_execution_exception","reason":"busy"}}}`, op))
+			continue
+		}
+		var doc map[string]interface{}
+		_ = json.Unmarshal(scanner.Bytes(), &doc)
+		f.actions = append(f.actions, action)
+		f.docs = append(f.docs, doc)
+		items = append(items, fmt.Sprintf(`{%q:{"status":201}}`, op))
+	}
+	fmt.Fprintf(w, `{"errors":%t,"items":[%s]}`, errors, strings.Join(items, ","))
+}
+
+// received 返回已写入的文档
+func (f *fakeElasticsearch) received() ([]map[string]map[string]string, []map[string]interface{}) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append(f.actions[:0:0], f.actions...), append(f.docs[:0:0], f.docs...)
+}
+
+// newElasticsearchLogger 创建写入模拟服务的Logger
+func newElasticsearchLogger(t *testing.T, f *fakeElasticsearch, ec config.ElasticsearchConfig) Logger {
+	cfg := config.DefaultConfig()
+	cfg.Output = "elasticsearch"
+	cfg.EnableStacktrace = false
+	ec.URL = f.server.URL
+	cfg.Elasticsearch = &ec
+	log, err := NewLogger(cfg)
+	require.NoError(t, err)
+	return log
+}
+
+// 测试按天写入索引
+func TestElasticsearchOutput(t *testing.T) {
+	f := newFakeElasticsearch(t)
+	log := newElasticsearchLogger(t, f, config.ElasticsearchConfig{
+		Index:    "logs-app",
+		Username: "elastic",
+		Password: "secret",
+	})
+
+	log.With(String("request_id", "req-1")).Info("first", Int("status", 200))
+	log.Debug("filtered")
+	log.Warn("second")
+	require.NoError(t, log.Sync())
+
+	actions, docs := f.received()
+	require.Len(t, docs, 2)
+	index := "logs-app-" + time.Now().UTC().Format("2006.01.02")
+	assert.Equal(t, index, actions[0]["index"]["_index"])
+	assert.Equal(t, "first", docs[0]["message"])
+	assert.Equal(t, "info", docs[0]["level"])
+	assert.Equal(t, "req-1", docs[0]["request_id"])
+	assert.Equal(t, float64(200), docs[0]["status"])
+	assert.Contains(t, docs[0]["caller"], "output_elasticsearch_test.go")
+	_, err := time.Parse(time.RFC3339Nano, docs[0]["@timestamp"].(string))
+	assert.NoError(t, err)
+	assert.Equal(t, "second", docs[1]["message"])
+	assert.True(t, strings.HasPrefix(f.auth[0], "Basic "))
+
+	client := &esClient{cfg: config.ElasticsearchConfig{Index: "logs-app"}}
+	assert.Equal(t, "logs-app-2024.06.01", client.indexName(time.Date(2024, 6, 1, 23, 0, 0, 0, time.UTC)))
+}
+
+// 测试写入数据流并创建索引模板
+func TestElasticsearchOutputDataStream(t *testing.T) {
+	f := newFakeElasticsearch(t)
+	log := newElasticsearchLogger(t, f, config.ElasticsearchConfig{
+		Index:      "logs-app-default",
+		DataStream: true,
+		Template:   true,
+		APIKey:     "key",
+	})
+
+	log.Info("hello")
+	require.NoError(t, log.Sync())
+
+	actions, docs := f.received()
+	require.Len(t, docs, 1)
+	assert.Equal(t, "logs-app-default", actions[0]["create"]["_index"])
+	assert.Equal(t, "ApiKey key", f.auth[0])
+
+	template := f.templates["logs-app-default"]
+	require.NotNil(t, template)
+	assert.Equal(t, []interface{}{"logs-app-default"}, template["index_patterns"])
+	assert.Contains(t, template, "data_stream")
+}
+
+// 测试返回429时重试
+func TestElasticsearchOutputRetry(t *testing.T) {
+	original := esRetryInterval
+	esRetryInterval = time.Millisecond
+	defer func() { esRetryInterval = original }()
+
+	f := newFakeElasticsearch(t)
+	f.reject429 = 2
+	f.rejectItem = 1
+	log := newElasticsearchLogger(t, f, config.ElasticsearchConfig{BatchSize: 10})
+
+	log.Info("a")
+	log.Info("b")
+	log.Info("c")
+	require.NoError(t, log.Sync())
+
+	_, docs := f.received()
+	messages := make([]string, 0, len(docs))
+	for _, doc := range docs {
+		messages = append(messages, doc["message"].(string))
+	}
+	assert.ElementsMatch(t, []string{"a", "b", "c"}, messages)
+
+	// 超过重试次数后丢弃日志，Sync返回错误
+	f.mu.Lock()
+	f.reject429 = 10
+	f.mu.Unlock()
+	log.Info("dropped")
+	err := log.Sync()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "429")
+}
+
+// 测试其他错误在Sync时返回
+func TestElasticsearchOutputError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.Copy(io.Discard, r.Body)
+		http.Error(w, `{"error":"unauthorized"}`, http.StatusUnauthorized)
+	}))
+	defer server.Close()
+
+	cfg := config.DefaultConfig()
+	cfg.Output = "elasticsearch"
+	cfg.Elasticsearch = &config.ElasticsearchConfig{URL: server.URL}
+	log, err := NewLogger(cfg)
+	require.NoError(t, err)
+
+	log.Info("hello")
+	err = log.Sync()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "401")
+	assert.NoError(t, log.Sync())
+}
+
+// 测试压缩_bulk请求体，小于最小压缩字节数的批次不压缩
+func TestElasticsearchOutputCompression(t *testing.T) {
+	f := newFakeElasticsearch(t)
+	log := newElasticsearchLogger(t, f, config.ElasticsearchConfig{
+		Index:       "logs-app",
+		Compression: config.CompressionConfig{Algorithm: "gzip", MinBytes: 200},
+	})
+
+	log.Info(strings.Repeat("x", 300))
+	require.NoError(t, log.Sync())
+	log.Info("small")
+	require.NoError(t, log.Sync())
+
+	_, docs := f.received()
+	require.Len(t, docs, 2)
+	assert.Equal(t, strings.Repeat("x", 300), docs[0]["message"])
+	assert.Equal(t, "small", docs[1]["message"])
+	f.mu.Lock()
+	assert.Equal(t, []string{"gzip", ""}, f.encodings)
+	f.mu.Unlock()
+
+	// Elasticsearch只支持gzip
+	_, err := NewLogger(&config.Config{
+		Output:        "elasticsearch",
+		Elasticsearch: &config.ElasticsearchConfig{Compression: config.CompressionConfig{Algorithm: "zstd"}},
+	})
+	assert.ErrorContains(t, err, "不支持的压缩算法")
+}
+
+// 测试停止输出时发送缓冲区中的日志并结束后台goroutine
+func TestElasticsearchOutputStop(t *testing.T) {
+	f := newFakeElasticsearch(t)
+	log := newElasticsearchLogger(t, f, config.ElasticsearchConfig{Index: "logs-app", FlushInterval: time.Hour})
+	log.Info("before stop")
+
+	require.NoError(t, outputsOf(log).stop())
+	_, docs := f.received()
+	require.Len(t, docs, 1)
+	assert.Equal(t, "before stop", docs[0]["message"])
+
+	// 停止后的日志被丢弃，Sync不会阻塞
+	log.Info("after stop")
+	assert.NoError(t, log.Sync())
+	_, docs = f.received()
+	assert.Len(t, docs, 1)
+}
@@ -0,0 +1,18 @@
+//go:build !windows
+
+package logger
+
+import (
+	"fmt"
+
+	"github.com/constructorvirgil/virlog/config"
+	"go.uber.org/zap/zapcore"
+)
+
+// eventLogOutput 输出到Windows事件日志的Output配置值
+const eventLogOutput = "eventlog"
+
+// newEventLogCore Windows事件日志仅支持Windows
+func newEventLogCore(zapcore.Encoder, zapcore.LevelEnabler, *config.Config) (zapcore.Core, error) {
+	return nil, fmt.Errorf("eventlog输出仅支持Windows")
+}
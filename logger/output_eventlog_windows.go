@@ -0,0 +1,88 @@
+//go:build windows
+
+package logger
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/constructorvirgil/virlog/config"
+	"go.uber.org/zap/zapcore"
+	"golang.org/x/sys/windows/svc/eventlog"
+)
+
+// eventLogOutput 输出到Windows事件日志的Output配置值
+const eventLogOutput = "eventlog"
+
+// eventLogID 写入事件日志时使用的事件ID
+const eventLogID = 1
+
+// eventLogCore 写入Windows事件日志的core，事件内容为编码后的日志
+type eventLogCore struct {
+	zapcore.LevelEnabler
+	enc zapcore.Encoder
+	log *eventlog.Log
+}
+
+// newEventLogCore 创建写入Windows事件日志的core
+func newEventLogCore(enc zapcore.Encoder, enab zapcore.LevelEnabler, cfg *config.Config) (zapcore.Core, error) {
+	source := defaultEventLogSource()
+	if cfg.EventLog != nil && cfg.EventLog.Source != "" {
+		source = cfg.EventLog.Source
+	}
+	log, err := eventlog.Open(source)
+	if err != nil {
+		return nil, fmt.Errorf("打开Windows事件日志失败: %w", err)
+	}
+	return &eventLogCore{LevelEnabler: enab, enc: enc, log: log}, nil
+}
+
+// With 实现zapcore.Core接口
+func (c *eventLogCore) With(fields []Field) zapcore.Core {
+	enc := c.enc.Clone()
+	for _, f := range fields {
+		f.AddTo(enc)
+	}
+	return &eventLogCore{LevelEnabler: c.LevelEnabler, enc: enc, log: c.log}
+}
+
+// Check 实现zapcore.Core接口
+func (c *eventLogCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(ent.Level) {
+		return ce.AddCore(ent, c)
+	}
+	return ce
+}
+
+// Write 实现zapcore.Core接口，按级别写入信息、警告或错误事件
+func (c *eventLogCore) Write(ent zapcore.Entry, fields []Field) error {
+	buf, err := c.enc.EncodeEntry(ent, fields)
+	if err != nil {
+		return err
+	}
+	msg := strings.TrimRight(buf.String(), "\r\n")
+	buf.Free()
+
+	switch {
+	case ent.Level >= zapcore.ErrorLevel:
+		return c.log.Error(eventLogID, msg)
+	case ent.Level == zapcore.WarnLevel:
+		return c.log.Warning(eventLogID, msg)
+	default:
+		return c.log.Info(eventLogID, msg)
+	}
+}
+
+// Sync 实现zapcore.Core接口，事件日志写入是同步的
+func (c *eventLogCore) Sync() error { return nil }
+
+// defaultEventLogSource 默认的事件来源为可执行文件名
+func defaultEventLogSource() string {
+	exe, err := os.Executable()
+	if err != nil {
+		return "virlog"
+	}
+	return strings.TrimSuffix(filepath.Base(exe), filepath.Ext(exe))
+}
@@ -0,0 +1,360 @@
+package logger
+
+import (
+	"bufio"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/constructorvirgil/virlog/config"
+	"github.com/constructorvirgil/virlog/internal/compress"
+	"go.uber.org/zap/zapcore"
+)
+
+const (
+	// fluentOutput 输出到Fluentd/Fluent Bit的Output配置值
+	fluentOutput = "fluent"
+	// fluentDefaultPort Fluent forward输入的默认端口
+	fluentDefaultPort = 24224
+	// fluentDefaultTag 默认的tag
+	fluentDefaultTag = "virlog"
+	// fluentDefaultBufferSize 默认缓冲的日志条数
+	fluentDefaultBufferSize = 8192
+	// fluentDefaultTimeout 默认的网络超时时间
+	fluentDefaultTimeout = 5 * time.Second
+	// fluentMaxBatch 单条forward消息包含的最大日志条数
+	fluentMaxBatch = 1000
+	// fluentMaxRetryInterval 重连的最大间隔
+	fluentMaxRetryInterval = 5 * time.Second
+)
+
+// errFluentBufferFull Fluent发送缓冲区已满，日志被丢弃
+var errFluentBufferFull = errors.New("fluent缓冲区已满，日志被丢弃")
+
+// fluentRecord 待发送的一条日志
+type fluentRecord struct {
+	time   time.Time
+	record map[string]interface{}
+}
+
+// fluentCore 通过Fluent forward协议发送日志的core
+//
+// 日志先写入内存缓冲区，由后台goroutine批量发送，连接断开后自动重连并重新发送未确认的批次
+type fluentCore struct {
+	zapcore.LevelEnabler
+	fields []Field
+	client *fluentClient
+}
+
+// newFluentCore 根据配置创建发送到Fluentd/Fluent Bit的core
+func newFluentCore(enab zapcore.LevelEnabler, cfg *config.Config) (zapcore.Core, error) {
+	fc := config.FluentConfig{}
+	if cfg.Fluent != nil {
+		fc = *cfg.Fluent
+	}
+	if fc.Host == "" {
+		fc.Host = "localhost"
+	}
+	if fc.Port == 0 {
+		fc.Port = fluentDefaultPort
+	}
+	if fc.Tag == "" {
+		fc.Tag = fluentDefaultTag
+	}
+	if fc.BufferSize <= 0 {
+		fc.BufferSize = fluentDefaultBufferSize
+	}
+	if fc.Timeout <= 0 {
+		fc.Timeout = fluentDefaultTimeout
+	}
+
+	compressor, err := newCompressor(fc.Compression, compress.Gzip)
+	if err != nil {
+		return nil, fmt.Errorf("fluent输出: %w", err)
+	}
+
+	client := &fluentClient{
+		addr:       net.JoinHostPort(fc.Host, strconv.Itoa(fc.Port)),
+		tag:        fc.Tag,
+		compressor: compressor,
+		requireAck: fc.RequireAck,
+		timeout:    fc.Timeout,
+		queue:      make(chan fluentRecord, fc.BufferSize),
+		flush:      make(chan chan struct{}),
+		stop:       make(chan struct{}),
+		done:       make(chan struct{}),
+	}
+	go client.run()
+
+	return &fluentCore{LevelEnabler: enab, client: client}, nil
+}
+
+// With 实现zapcore.Core接口
+func (c *fluentCore) With(fields []Field) zapcore.Core {
+	merged := make([]Field, 0, len(c.fields)+len(fields))
+	merged = append(merged, c.fields...)
+	merged = append(merged, fields...)
+	return &fluentCore{LevelEnabler: c.LevelEnabler, fields: merged, client: c.client}
+}
+
+// Check 实现zapcore.Core接口
+func (c *fluentCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(ent.Level) {
+		return ce.AddCore(ent, c)
+	}
+	return ce
+}
+
+// Write 实现zapcore.Core接口，缓冲区已满时丢弃日志并返回错误
+func (c *fluentCore) Write(ent zapcore.Entry, fields []Field) error {
+	enc := zapcore.NewMapObjectEncoder()
+	for _, f := range c.fields {
+		f.AddTo(enc)
+	}
+	for _, f := range fields {
+		f.AddTo(enc)
+	}
+
+	record := enc.Fields
+	record["level"] = levelName(ent.Level)
+	record["msg"] = ent.Message
+	if ent.LoggerName != "" {
+		record["logger"] = ent.LoggerName
+	}
+	if ent.Caller.Defined {
+		record["caller"] = ent.Caller.TrimmedPath()
+	}
+	if ent.Stack != "" {
+		record["stacktrace"] = ent.Stack
+	}
+
+	select {
+	case <-c.client.done:
+		return errOutputStopped
+	default:
+	}
+	select {
+	case c.client.queue <- fluentRecord{time: ent.Time, record: record}:
+		return nil
+	default:
+		return errFluentBufferFull
+	}
+}
+
+// Sync 实现zapcore.Core接口，等待缓冲区中的日志发送完成
+func (c *fluentCore) Sync() error {
+	return c.client.sync()
+}
+
+// stopOutput 实现outputStopper接口，发送缓冲区中的日志后停止后台goroutine并关闭连接
+func (c *fluentCore) stopOutput() error {
+	return c.client.close()
+}
+
+// queueUsage 返回缓冲区使用率
+func (c *fluentCore) queueUsage() float64 {
+	return float64(len(c.client.queue)) / float64(cap(c.client.queue))
+}
+
+// fluentClient 批量发送日志到Fluentd，连接和发送只在后台goroutine中进行
+type fluentClient struct {
+	addr       string
+	tag        string
+	compressor *compress.Compressor
+	requireAck bool
+	timeout    time.Duration
+	queue      chan fluentRecord
+	flush      chan chan struct{}
+	// stop 关闭后后台goroutine发送缓冲区中的日志、关闭连接并退出，退出后关闭 done
+	stop     chan struct{}
+	done     chan struct{}
+	stopOnce sync.Once
+
+	conn   net.Conn
+	reader *bufio.Reader
+}
+
+// run 从缓冲区取出日志批量发送，收到刷新请求时发送缓冲区中的所有日志
+func (c *fluentClient) run() {
+	defer close(c.done)
+	batch := make([]fluentRecord, 0, fluentMaxBatch)
+	for {
+		select {
+		case rec := <-c.queue:
+			batch = c.collect(append(batch[:0], rec))
+			c.sendWithRetry(batch)
+		case done := <-c.flush:
+			for len(c.queue) > 0 {
+				batch = c.collect(batch[:0])
+				c.sendWithRetry(batch)
+			}
+			close(done)
+		case <-c.stop:
+			for len(c.queue) > 0 {
+				batch = c.collect(batch[:0])
+				c.sendWithRetry(batch)
+			}
+			if c.conn != nil {
+				c.conn.Close()
+				c.conn = nil
+			}
+			return
+		}
+	}
+}
+
+// collect 取出缓冲区中已有的日志，最多 fluentMaxBatch 条
+func (c *fluentClient) collect(batch []fluentRecord) []fluentRecord {
+	for len(batch) < fluentMaxBatch {
+		select {
+		case rec := <-c.queue:
+			batch = append(batch, rec)
+		default:
+			return batch
+		}
+	}
+	return batch
+}
+
+// sync 等待缓冲区中的日志发送完成
+func (c *fluentClient) sync() error {
+	done := make(chan struct{})
+	select {
+	case c.flush <- done:
+	case <-c.done:
+		return nil
+	case <-time.After(c.timeout):
+		return fmt.Errorf("等待Fluent发送超时")
+	}
+	select {
+	case <-done:
+		return nil
+	case <-time.After(c.timeout):
+		return fmt.Errorf("等待Fluent发送超时")
+	}
+}
+
+// close 停止后台goroutine，等待缓冲区中的日志发送完成
+func (c *fluentClient) close() error {
+	c.stopOnce.Do(func() { close(c.stop) })
+	select {
+	case <-c.done:
+		return nil
+	case <-time.After(c.timeout):
+		return fmt.Errorf("等待Fluent发送超时")
+	}
+}
+
+// sendWithRetry 发送一个批次，失败时重连并重试，直到发送成功或客户端被停止
+func (c *fluentClient) sendWithRetry(batch []fluentRecord) {
+	if len(batch) == 0 {
+		return
+	}
+	message, chunk := c.encode(batch)
+
+	interval := 100 * time.Millisecond
+	for {
+		err := c.send(message, chunk)
+		if err == nil {
+			return
+		}
+		if c.conn != nil {
+			c.conn.Close()
+			c.conn = nil
+		}
+		select {
+		case <-c.stop:
+			// 停止后不再重试，丢弃该批次
+			return
+		case <-time.After(interval):
+		}
+		if interval *= 2; interval > fluentMaxRetryInterval {
+			interval = fluentMaxRetryInterval
+		}
+	}
+}
+
+// encode 按Forward模式编码批次：[tag, [[time, record], ...], {chunk, size}]；
+// 配置压缩时使用CompressedPackedForward模式：[tag, gzip(entries), {chunk, size, compressed}]
+func (c *fluentClient) encode(batch []fluentRecord) ([]byte, string) {
+	entries := &msgpackWriter{}
+	for _, rec := range batch {
+		entries.writeArrayHeader(2)
+		entries.writeEventTime(rec.time)
+		entries.writeMap(rec.record)
+	}
+	payload, encoding, err := c.compressor.Compress(entries.buf)
+	if err != nil {
+		// 压缩失败时不压缩发送
+		encoding = ""
+	}
+
+	w := &msgpackWriter{}
+	w.writeArrayHeader(3)
+	w.writeString(c.tag)
+	options := 1
+	if encoding != "" {
+		w.writeBinary(payload)
+		options++
+	} else {
+		w.writeArrayHeader(len(batch))
+		w.buf = append(w.buf, entries.buf...)
+	}
+
+	var chunk string
+	if c.requireAck {
+		options++
+	}
+	w.writeMapHeader(options)
+	if c.requireAck {
+		id := make([]byte, 16)
+		_, _ = rand.Read(id)
+		chunk = base64.StdEncoding.EncodeToString(id)
+		w.writeString("chunk")
+		w.writeString(chunk)
+	}
+	if encoding != "" {
+		w.writeString("compressed")
+		w.writeString(encoding)
+	}
+	w.writeString("size")
+	w.writeInt(int64(len(batch)))
+	return w.buf, chunk
+}
+
+// send 发送编码后的消息，要求确认时等待服务端返回相同的chunk
+func (c *fluentClient) send(message []byte, chunk string) error {
+	if c.conn == nil {
+		conn, err := net.DialTimeout("tcp", c.addr, c.timeout)
+		if err != nil {
+			return fmt.Errorf("连接Fluent服务失败: %w", err)
+		}
+		c.conn = conn
+		c.reader = bufio.NewReader(conn)
+	}
+
+	if err := c.conn.SetDeadline(time.Now().Add(c.timeout)); err != nil {
+		return err
+	}
+	if _, err := c.conn.Write(message); err != nil {
+		return err
+	}
+	if chunk == "" {
+		return nil
+	}
+
+	resp, err := readMsgpack(c.reader)
+	if err != nil {
+		return err
+	}
+	ack, _ := resp.(map[string]interface{})
+	if ack == nil || ack["ack"] != chunk {
+		return fmt.Errorf("fluent确认不匹配: %v", resp)
+	}
+	return nil
+}
@@ -0,0 +1,218 @@
+package logger
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"io"
+	"net"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/constructorvirgil/virlog/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeFluent 模拟Fluentd的forward输入，dropFirst 为true时第一条消息不确认并断开连接
+type fakeFluent struct {
+	ln        net.Listener
+	messages  chan []interface{}
+	dropFirst atomic.Bool
+}
+
+// newFakeFluent 创建监听本地端口的模拟服务
+func newFakeFluent(t *testing.T, dropFirst bool) *fakeFluent {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { ln.Close() })
+
+	f := &fakeFluent{ln: ln, messages: make(chan []interface{}, 100)}
+	f.dropFirst.Store(dropFirst)
+	go f.serve()
+	return f
+}
+
+// serve 接受连接并解码forward消息，消息带chunk时返回确认
+func (f *fakeFluent) serve() {
+	for {
+		conn, err := f.ln.Accept()
+		if err != nil {
+			return
+		}
+		go func(conn net.Conn) {
+			defer conn.Close()
+			r := bufio.NewReader(conn)
+			for {
+				v, err := readMsgpack(r)
+				if err != nil {
+					return
+				}
+				msg, _ := v.([]interface{})
+				if f.dropFirst.CompareAndSwap(true, false) {
+					return
+				}
+				f.messages <- msg
+				if len(msg) == 3 {
+					option, _ := msg[2].(map[string]interface{})
+					if chunk, ok := option["chunk"]; ok {
+						w := &msgpackWriter{}
+						w.writeMap(map[string]interface{}{"ack": chunk})
+						if _, err := conn.Write(w.buf); err != nil {
+							return
+						}
+					}
+				}
+			}
+		}(conn)
+	}
+}
+
+// newFluentLogger 创建发送到模拟服务的Logger
+func newFluentLogger(t *testing.T, f *fakeFluent, requireAck bool) Logger {
+	return newFluentLoggerWithConfig(t, f, config.FluentConfig{RequireAck: requireAck})
+}
+
+// newFluentLoggerWithConfig 使用指定配置创建发送到模拟服务的Logger
+func newFluentLoggerWithConfig(t *testing.T, f *fakeFluent, fc config.FluentConfig) Logger {
+	cfg := config.DefaultConfig()
+	cfg.Output = "fluent"
+	cfg.EnableStacktrace = false
+	fc.Host = "127.0.0.1"
+	fc.Port = f.ln.Addr().(*net.TCPAddr).Port
+	fc.Tag = "app.web"
+	cfg.Fluent = &fc
+	log, err := NewLogger(cfg)
+	require.NoError(t, err)
+	return log
+}
+
+// receiveFluentRecords 读取消息中的所有记录
+func receiveFluentRecords(t *testing.T, f *fakeFluent, count int) []map[string]interface{} {
+	t.Helper()
+	var records []map[string]interface{}
+	for len(records) < count {
+		select {
+		case msg := <-f.messages:
+			require.Len(t, msg, 3)
+			assert.Equal(t, "app.web", msg[0])
+			for _, e := range fluentEntries(t, msg) {
+				entry := e.([]interface{})
+				require.Len(t, entry, 2)
+				assert.IsType(t, time.Time{}, entry[0])
+				records = append(records, entry[1].(map[string]interface{}))
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("未收到Fluent消息，已收到 %d 条", len(records))
+		}
+	}
+	return records
+}
+
+// fluentEntries 返回消息中的日志条目，CompressedPackedForward模式的消息先解压
+func fluentEntries(t *testing.T, msg []interface{}) []interface{} {
+	t.Helper()
+	if entries, ok := msg[1].([]interface{}); ok {
+		return entries
+	}
+	packed, ok := msg[1].([]byte)
+	require.True(t, ok, "未知的消息格式: %T", msg[1])
+	option, _ := msg[2].(map[string]interface{})
+	require.Equal(t, "gzip", option["compressed"])
+
+	gr, err := gzip.NewReader(bytes.NewReader(packed))
+	require.NoError(t, err)
+	data, err := io.ReadAll(gr)
+	require.NoError(t, err)
+	r := bufio.NewReader(bytes.NewReader(data))
+	var entries []interface{}
+	for {
+		entry, err := readMsgpack(r)
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+		entries = append(entries, entry)
+	}
+	assert.Equal(t, int64(len(entries)), option["size"])
+	return entries
+}
+
+// 测试通过forward协议发送日志
+func TestFluentOutput(t *testing.T) {
+	f := newFakeFluent(t, false)
+	log := newFluentLogger(t, f, false)
+
+	log.With(String("request_id", "req-1")).Info("first", Int("status", 200))
+	log.Debug("filtered")
+	log.Warn("second", Duration("latency", time.Second))
+	require.NoError(t, log.Sync())
+
+	records := receiveFluentRecords(t, f, 2)
+	assert.Equal(t, "first", records[0]["msg"])
+	assert.Equal(t, "info", records[0]["level"])
+	assert.Equal(t, "req-1", records[0]["request_id"])
+	assert.Equal(t, int64(200), records[0]["status"])
+	assert.Contains(t, records[0]["caller"], "output_fluent_test.go")
+	assert.Equal(t, "second", records[1]["msg"])
+	assert.Equal(t, int64(time.Second), records[1]["latency"])
+}
+
+// 测试开启确认后连接断开时重连并重新发送
+func TestFluentOutputAckReconnect(t *testing.T) {
+	f := newFakeFluent(t, true)
+	log := newFluentLogger(t, f, true)
+
+	log.Error("must arrive")
+
+	records := receiveFluentRecords(t, f, 1)
+	assert.Equal(t, "must arrive", records[0]["msg"])
+	assert.Equal(t, "error", records[0]["level"])
+}
+
+// 测试以CompressedPackedForward模式发送压缩的批次
+func TestFluentOutputCompression(t *testing.T) {
+	f := newFakeFluent(t, false)
+	log := newFluentLoggerWithConfig(t, f, config.FluentConfig{
+		RequireAck:  true,
+		Compression: config.CompressionConfig{Algorithm: "gzip", MinBytes: -1},
+	})
+
+	log.Info("first")
+	log.Warn("second")
+	require.NoError(t, log.Sync())
+
+	records := receiveFluentRecords(t, f, 2)
+	assert.Equal(t, "first", records[0]["msg"])
+	assert.Equal(t, "second", records[1]["msg"])
+
+	_, err := NewLogger(&config.Config{
+		Output: "fluent",
+		Fluent: &config.FluentConfig{Compression: config.CompressionConfig{Algorithm: "zstd"}},
+	})
+	assert.ErrorContains(t, err, "不支持的压缩算法")
+}
+
+// 测试停止输出时发送缓冲区中的日志，服务不可用时不会一直重试
+func TestFluentOutputStop(t *testing.T) {
+	f := newFakeFluent(t, false)
+	log := newFluentLogger(t, f, false)
+	log.Info("before stop")
+	require.NoError(t, log.Sync())
+	require.NoError(t, outputsOf(log).stop())
+	records := receiveFluentRecords(t, f, 1)
+	assert.Equal(t, "before stop", records[0]["msg"])
+
+	log.Info("after stop")
+	assert.NoError(t, log.Sync())
+
+	// 服务已关闭时停止不会阻塞在重试中
+	down := newFakeFluent(t, false)
+	downLog := newFluentLoggerWithConfig(t, down, config.FluentConfig{Timeout: 200 * time.Millisecond})
+	down.ln.Close()
+	downLog.Info("lost")
+	start := time.Now()
+	_ = outputsOf(downLog).stop()
+	assert.Less(t, time.Since(start), 2*time.Second)
+}
@@ -0,0 +1,331 @@
+package logger
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"crypto/rand"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net"
+	"os"
+	"regexp"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/constructorvirgil/virlog/config"
+	"go.uber.org/zap/zapcore"
+)
+
+const (
+	// gelfOutput 输出到Graylog的Output配置值
+	gelfOutput = "gelf"
+	// gelfDefaultPort Graylog GELF输入的默认端口
+	gelfDefaultPort = 12201
+	// gelfDefaultChunkSize UDP分块的默认大小，适合以太网MTU
+	gelfDefaultChunkSize = 1420
+	// gelfMaxChunks GELF协议允许的最大分块数
+	gelfMaxChunks = 128
+	// gelfChunkHeaderSize 分块头大小：2字节魔数、8字节消息ID、1字节序号、1字节总数
+	gelfChunkHeaderSize = 12
+	// gelfDialTimeout 建立连接的超时时间
+	gelfDialTimeout = 5 * time.Second
+)
+
+// gelfFieldNamePattern GELF附加字段名允许的字符
+var gelfFieldNamePattern = regexp.MustCompile(`[^\w.\-]`)
+
+// gelfTransport 发送编码后的GELF消息
+type gelfTransport interface {
+	send(payload []byte) error
+}
+
+// gelfCore 将日志编码为GELF消息发送到Graylog的core
+type gelfCore struct {
+	zapcore.LevelEnabler
+	fields    []Field
+	source    string
+	transport gelfTransport
+}
+
+// newGELFCore 根据配置创建写入Graylog的core
+func newGELFCore(enab zapcore.LevelEnabler, cfg *config.Config) (zapcore.Core, error) {
+	gc := config.GELFConfig{}
+	if cfg.GELF != nil {
+		gc = *cfg.GELF
+	}
+	if gc.Host == "" {
+		gc.Host = "localhost"
+	}
+	if gc.Port == 0 {
+		gc.Port = gelfDefaultPort
+	}
+	source := gc.Source
+	if source == "" {
+		source, _ = os.Hostname()
+	}
+	addr := net.JoinHostPort(gc.Host, strconv.Itoa(gc.Port))
+
+	var transport gelfTransport
+	switch gc.Protocol {
+	case "", "udp":
+		t, err := newGELFUDPTransport(addr, gc.Compression, gc.ChunkSize)
+		if err != nil {
+			return nil, err
+		}
+		transport = t
+	case "tcp":
+		t := &gelfTCPTransport{addr: addr}
+		if gc.TLS {
+			t.tlsConfig = &tls.Config{ServerName: gc.Host, InsecureSkipVerify: gc.TLSInsecureSkipVerify}
+		}
+		transport = t
+	default:
+		return nil, fmt.Errorf("不支持的GELF传输协议: %s", gc.Protocol)
+	}
+
+	return &gelfCore{LevelEnabler: enab, source: source, transport: transport}, nil
+}
+
+// With 实现zapcore.Core接口
+func (c *gelfCore) With(fields []Field) zapcore.Core {
+	merged := make([]Field, 0, len(c.fields)+len(fields))
+	merged = append(merged, c.fields...)
+	merged = append(merged, fields...)
+	return &gelfCore{LevelEnabler: c.LevelEnabler, fields: merged, source: c.source, transport: c.transport}
+}
+
+// Check 实现zapcore.Core接口
+func (c *gelfCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(ent.Level) {
+		return ce.AddCore(ent, c)
+	}
+	return ce
+}
+
+// Write 实现zapcore.Core接口
+func (c *gelfCore) Write(ent zapcore.Entry, fields []Field) error {
+	payload, err := json.Marshal(c.message(ent, fields))
+	if err != nil {
+		return fmt.Errorf("编码GELF消息失败: %w", err)
+	}
+	return c.transport.send(payload)
+}
+
+// Sync 实现zapcore.Core接口，GELF消息在写入时已发送
+func (c *gelfCore) Sync() error { return nil }
+
+// message 构造GELF 1.1消息，日志字段作为以下划线开头的附加字段
+func (c *gelfCore) message(ent zapcore.Entry, fields []Field) map[string]interface{} {
+	enc := zapcore.NewMapObjectEncoder()
+	for _, f := range c.fields {
+		f.AddTo(enc)
+	}
+	for _, f := range fields {
+		f.AddTo(enc)
+	}
+
+	msg := make(map[string]interface{}, len(enc.Fields)+8)
+	for k, v := range enc.Fields {
+		msg[gelfFieldName(k)] = gelfFieldValue(v)
+	}
+	msg["version"] = "1.1"
+	msg["host"] = c.source
+	msg["short_message"] = ent.Message
+	msg["timestamp"] = math.Round(float64(ent.Time.UnixNano())/1e6) / 1e3
+	msg["level"] = gelfLevel(ent.Level)
+	if ent.Stack != "" {
+		msg["full_message"] = ent.Message + "\n" + ent.Stack
+	}
+	if ent.LoggerName != "" {
+		msg["_logger"] = ent.LoggerName
+	}
+	if ent.Caller.Defined {
+		msg["_file"] = ent.Caller.File
+		msg["_line"] = ent.Caller.Line
+	}
+	return msg
+}
+
+// gelfLevel 将日志级别映射为syslog级别
+func gelfLevel(level zapcore.Level) int {
+	switch {
+	case level >= zapcore.PanicLevel:
+		return 2 // critical
+	case level >= zapcore.ErrorLevel:
+		return 3 // error
+	case level == zapcore.WarnLevel:
+		return 4 // warning
+	case level == zapcore.InfoLevel:
+		return 6 // informational
+	default:
+		return 7 // debug
+	}
+}
+
+// gelfFieldName 将字段名转换为GELF附加字段名，"id" 为保留字段，改为 "_id_"
+func gelfFieldName(key string) string {
+	name := gelfFieldNamePattern.ReplaceAllString(key, "_")
+	if name == "id" {
+		return "_id_"
+	}
+	return "_" + name
+}
+
+// gelfFieldValue GELF附加字段只支持字符串和数字，其他类型转换为文本
+func gelfFieldValue(v interface{}) interface{} {
+	switch val := v.(type) {
+	case string, int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, float32, float64:
+		return val
+	case time.Duration:
+		return val.Seconds()
+	case time.Time:
+		return val.Format(time.RFC3339Nano)
+	case map[string]interface{}, []interface{}:
+		if b, err := json.Marshal(val); err == nil {
+			return string(b)
+		}
+	}
+	return fmt.Sprint(v)
+}
+
+// gelfUDPTransport 通过UDP发送GELF消息，超过分块大小的消息按GELF分块协议拆分
+type gelfUDPTransport struct {
+	conn        net.Conn
+	compression string
+	chunkSize   int
+}
+
+// newGELFUDPTransport 创建UDP传输
+func newGELFUDPTransport(addr, compression string, chunkSize int) (*gelfUDPTransport, error) {
+	switch compression {
+	case "":
+		compression = "gzip"
+	case "gzip", "zlib", "none":
+	default:
+		return nil, fmt.Errorf("不支持的GELF压缩方式: %s", compression)
+	}
+	if chunkSize <= gelfChunkHeaderSize {
+		chunkSize = gelfDefaultChunkSize
+	}
+	conn, err := net.Dial("udp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("连接GELF服务失败: %w", err)
+	}
+	return &gelfUDPTransport{conn: conn, compression: compression, chunkSize: chunkSize}, nil
+}
+
+// send 实现gelfTransport接口
+func (t *gelfUDPTransport) send(payload []byte) error {
+	payload, err := t.compress(payload)
+	if err != nil {
+		return err
+	}
+	if len(payload) <= t.chunkSize {
+		_, err := t.conn.Write(payload)
+		return err
+	}
+
+	dataSize := t.chunkSize - gelfChunkHeaderSize
+	count := (len(payload) + dataSize - 1) / dataSize
+	if count > gelfMaxChunks {
+		return fmt.Errorf("GELF消息过大: %d 字节", len(payload))
+	}
+
+	var id [8]byte
+	if _, err := rand.Read(id[:]); err != nil {
+		return err
+	}
+	chunk := make([]byte, 0, t.chunkSize)
+	for i := 0; i < count; i++ {
+		end := (i + 1) * dataSize
+		if end > len(payload) {
+			end = len(payload)
+		}
+		chunk = append(chunk[:0], 0x1e, 0x0f)
+		chunk = append(chunk, id[:]...)
+		chunk = append(chunk, byte(i), byte(count))
+		chunk = append(chunk, payload[i*dataSize:end]...)
+		if _, err := t.conn.Write(chunk); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// compress 按配置压缩消息
+func (t *gelfUDPTransport) compress(payload []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	switch t.compression {
+	case "gzip":
+		w := gzip.NewWriter(&buf)
+		if _, err := w.Write(payload); err != nil {
+			return nil, err
+		}
+		if err := w.Close(); err != nil {
+			return nil, err
+		}
+	case "zlib":
+		w := zlib.NewWriter(&buf)
+		if _, err := w.Write(payload); err != nil {
+			return nil, err
+		}
+		if err := w.Close(); err != nil {
+			return nil, err
+		}
+	default:
+		return payload, nil
+	}
+	return buf.Bytes(), nil
+}
+
+// gelfTCPTransport 通过TCP发送GELF消息，每条消息以空字节结尾，连接断开后自动重连
+type gelfTCPTransport struct {
+	addr      string
+	tlsConfig *tls.Config
+
+	mu   sync.Mutex
+	conn net.Conn
+}
+
+// send 实现gelfTransport接口
+func (t *gelfTCPTransport) send(payload []byte) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	frame := make([]byte, 0, len(payload)+1)
+	frame = append(frame, payload...)
+	frame = append(frame, 0)
+
+	// 连接可能已被服务端关闭，写入失败时重连一次
+	for attempt := 0; ; attempt++ {
+		if t.conn == nil {
+			conn, err := t.dial()
+			if err != nil {
+				return fmt.Errorf("连接GELF服务失败: %w", err)
+			}
+			t.conn = conn
+		}
+		_, err := t.conn.Write(frame)
+		if err == nil {
+			return nil
+		}
+		t.conn.Close()
+		t.conn = nil
+		if attempt > 0 {
+			return err
+		}
+	}
+}
+
+// dial 建立TCP连接，配置了TLS时使用TLS
+func (t *gelfTCPTransport) dial() (net.Conn, error) {
+	dialer := &net.Dialer{Timeout: gelfDialTimeout}
+	if t.tlsConfig != nil {
+		return tls.DialWithDialer(dialer, "tcp", t.addr, t.tlsConfig)
+	}
+	return dialer.Dial("tcp", t.addr)
+}
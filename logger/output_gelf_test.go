@@ -0,0 +1,173 @@
+package logger
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"encoding/json"
+	"io"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/constructorvirgil/virlog/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// readGELFUDP 从UDP连接读取一条GELF消息，合并分块并解压
+func readGELFUDP(t *testing.T, conn net.PacketConn) map[string]interface{} {
+	t.Helper()
+	require.NoError(t, conn.SetReadDeadline(time.Now().Add(3*time.Second)))
+
+	var payload []byte
+	chunks := map[byte][]byte{}
+	buf := make([]byte, 65535)
+	for {
+		n, _, err := conn.ReadFrom(buf)
+		require.NoError(t, err)
+		packet := append([]byte(nil), buf[:n]...)
+		if len(packet) < 2 || packet[0] != 0x1e || packet[1] != 0x0f {
+			payload = packet
+			break
+		}
+		chunks[packet[10]] = packet[12:]
+		if len(chunks) == int(packet[11]) {
+			for i := 0; i < len(chunks); i++ {
+				payload = append(payload, chunks[byte(i)]...)
+			}
+			break
+		}
+	}
+
+	var r io.Reader = bytes.NewReader(payload)
+	switch {
+	case bytes.HasPrefix(payload, []byte{0x1f, 0x8b}):
+		gz, err := gzip.NewReader(r)
+		require.NoError(t, err)
+		r = gz
+	case payload[0] == 0x78:
+		zr, err := zlib.NewReader(r)
+		require.NoError(t, err)
+		r = zr
+	}
+	var msg map[string]interface{}
+	require.NoError(t, json.NewDecoder(r).Decode(&msg))
+	return msg
+}
+
+// newGELFUDPLogger 创建发送到本地UDP端口的Logger
+func newGELFUDPLogger(t *testing.T, gc *config.GELFConfig) (Logger, net.PacketConn) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+
+	addr := conn.LocalAddr().(*net.UDPAddr)
+	gc.Host = "127.0.0.1"
+	gc.Port = addr.Port
+
+	cfg := config.DefaultConfig()
+	cfg.Output = "gelf"
+	cfg.GELF = gc
+	cfg.EnableStacktrace = false
+	log, err := NewLogger(cfg)
+	require.NoError(t, err)
+	return log, conn
+}
+
+// 测试通过UDP发送GELF消息
+func TestGELFOutputUDP(t *testing.T) {
+	log, conn := newGELFUDPLogger(t, &config.GELFConfig{Source: "web-1"})
+
+	log.With(String("request_id", "req-1")).Warn("slow request",
+		Int("status", 200), Bool("cached", true), String("id", "x"))
+
+	msg := readGELFUDP(t, conn)
+	assert.Equal(t, "1.1", msg["version"])
+	assert.Equal(t, "web-1", msg["host"])
+	assert.Equal(t, "slow request", msg["short_message"])
+	assert.Equal(t, float64(4), msg["level"])
+	assert.Equal(t, "req-1", msg["_request_id"])
+	assert.Equal(t, float64(200), msg["_status"])
+	assert.Equal(t, "true", msg["_cached"])
+	assert.Equal(t, "x", msg["_id_"])
+	assert.NotContains(t, msg, "_id")
+	assert.InDelta(t, float64(time.Now().Unix()), msg["timestamp"], 5)
+	assert.Contains(t, msg["_file"], "output_gelf_test.go")
+}
+
+// 测试超过分块大小的消息按GELF分块协议发送
+func TestGELFOutputUDPChunked(t *testing.T) {
+	for _, compression := range []string{"none", "zlib"} {
+		t.Run(compression, func(t *testing.T) {
+			log, conn := newGELFUDPLogger(t, &config.GELFConfig{Compression: compression, ChunkSize: 64})
+
+			long := strings.Repeat("abcdefghij", 50)
+			log.Error("large message", String("payload", long))
+
+			msg := readGELFUDP(t, conn)
+			assert.Equal(t, "large message", msg["short_message"])
+			assert.Equal(t, float64(3), msg["level"])
+			assert.Equal(t, long, msg["_payload"])
+		})
+	}
+
+	_, err := NewLogger(&config.Config{Output: "gelf", GELF: &config.GELFConfig{Compression: "lz4"}})
+	assert.Error(t, err)
+}
+
+// 测试通过TCP发送GELF消息
+func TestGELFOutputTCP(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer ln.Close()
+
+	received := make(chan map[string]interface{}, 2)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		r := bufio.NewReader(conn)
+		for {
+			frame, err := r.ReadBytes(0)
+			if err != nil {
+				return
+			}
+			var msg map[string]interface{}
+			if json.Unmarshal(frame[:len(frame)-1], &msg) == nil {
+				received <- msg
+			}
+		}
+	}()
+
+	cfg := config.DefaultConfig()
+	cfg.Output = "gelf"
+	cfg.GELF = &config.GELFConfig{
+		Host:     "127.0.0.1",
+		Port:     ln.Addr().(*net.TCPAddr).Port,
+		Protocol: "tcp",
+	}
+	log, err := NewLogger(cfg)
+	require.NoError(t, err)
+
+	log.Info("first")
+	log.Debug("filtered")
+	log.Info("second", Duration("latency", 1500*time.Millisecond))
+
+	for _, want := range []string{"first", "second"} {
+		select {
+		case msg := <-received:
+			assert.Equal(t, want, msg["short_message"])
+			assert.Equal(t, float64(6), msg["level"])
+			if want == "second" {
+				assert.Equal(t, 1.5, msg["_latency"])
+			}
+		case <-time.After(3 * time.Second):
+			t.Fatalf("未收到GELF消息 %s", want)
+		}
+	}
+}
@@ -0,0 +1,127 @@
+//go:build linux
+
+package logger
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/coreos/go-systemd/v22/journal"
+	"go.uber.org/zap/zapcore"
+)
+
+// journaldOutput 输出到systemd-journald的Output配置值
+const journaldOutput = "journald"
+
+// journaldCore 通过journald原生协议写入日志的core，日志字段作为journald字段透传
+type journaldCore struct {
+	zapcore.LevelEnabler
+	fields []Field
+	send   func(message string, priority journal.Priority, vars map[string]string) error
+}
+
+// newJournaldCore 创建写入journald的core，journald不可用时返回错误
+func newJournaldCore(enab zapcore.LevelEnabler) (zapcore.Core, error) {
+	if !journal.Enabled() {
+		return nil, fmt.Errorf("journald不可用")
+	}
+	return &journaldCore{LevelEnabler: enab, send: journal.Send}, nil
+}
+
+// With 实现zapcore.Core接口
+func (c *journaldCore) With(fields []Field) zapcore.Core {
+	merged := make([]Field, 0, len(c.fields)+len(fields))
+	merged = append(merged, c.fields...)
+	merged = append(merged, fields...)
+	return &journaldCore{LevelEnabler: c.LevelEnabler, fields: merged, send: c.send}
+}
+
+// Check 实现zapcore.Core接口
+func (c *journaldCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(ent.Level) {
+		return ce.AddCore(ent, c)
+	}
+	return ce
+}
+
+// Write 实现zapcore.Core接口
+func (c *journaldCore) Write(ent zapcore.Entry, fields []Field) error {
+	enc := zapcore.NewMapObjectEncoder()
+	for _, f := range c.fields {
+		f.AddTo(enc)
+	}
+	for _, f := range fields {
+		f.AddTo(enc)
+	}
+
+	vars := make(map[string]string, len(enc.Fields)+6)
+	for k, v := range enc.Fields {
+		name := journaldFieldName(k)
+		if name == "" || name == "MESSAGE" || name == "PRIORITY" {
+			continue
+		}
+		vars[name] = journaldFieldValue(v)
+	}
+	if ent.LoggerName != "" {
+		vars["LOGGER"] = ent.LoggerName
+	}
+	if ent.Caller.Defined {
+		vars["CODE_FILE"] = ent.Caller.File
+		vars["CODE_LINE"] = strconv.Itoa(ent.Caller.Line)
+		if ent.Caller.Function != "" {
+			vars["CODE_FUNC"] = ent.Caller.Function
+		}
+	}
+	if ent.Stack != "" {
+		vars["STACKTRACE"] = ent.Stack
+	}
+
+	return c.send(ent.Message, journaldPriority(ent.Level), vars)
+}
+
+// Sync 实现zapcore.Core接口，journald写入是同步的
+func (c *journaldCore) Sync() error { return nil }
+
+// journaldPriority 将日志级别映射为syslog优先级
+func journaldPriority(level zapcore.Level) journal.Priority {
+	switch {
+	case level >= zapcore.DPanicLevel:
+		return journal.PriCrit
+	case level == zapcore.ErrorLevel:
+		return journal.PriErr
+	case level == zapcore.WarnLevel:
+		return journal.PriWarning
+	case level == zapcore.InfoLevel:
+		return journal.PriInfo
+	default:
+		return journal.PriDebug
+	}
+}
+
+// journaldFieldName 将字段名转换为journald字段名：大写字母、数字和下划线，且不能以下划线开头
+func journaldFieldName(key string) string {
+	var b strings.Builder
+	for _, r := range strings.ToUpper(key) {
+		if (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') || r == '_' {
+			b.WriteRune(r)
+		} else {
+			b.WriteByte('_')
+		}
+	}
+	return strings.TrimLeft(b.String(), "_")
+}
+
+// journaldFieldValue 将字段值转换为文本，复合类型编码为JSON
+func journaldFieldValue(v interface{}) string {
+	switch val := v.(type) {
+	case string:
+		return val
+	case map[string]interface{}, []interface{}:
+		if b, err := json.Marshal(val); err == nil {
+			return string(b)
+		}
+	}
+	return fmt.Sprint(v)
+}
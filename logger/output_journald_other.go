@@ -0,0 +1,17 @@
+//go:build !linux
+
+package logger
+
+import (
+	"fmt"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// journaldOutput 输出到systemd-journald的Output配置值
+const journaldOutput = "journald"
+
+// newJournaldCore journald仅支持Linux
+func newJournaldCore(zapcore.LevelEnabler) (zapcore.Core, error) {
+	return nil, fmt.Errorf("journald输出仅支持Linux")
+}
@@ -0,0 +1,53 @@
+//go:build linux
+
+package logger
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/coreos/go-systemd/v22/journal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// 测试journald输出的字段透传和优先级映射
+func TestJournaldCore(t *testing.T) {
+	type sent struct {
+		message  string
+		priority journal.Priority
+		vars     map[string]string
+	}
+	var entries []sent
+
+	core := &journaldCore{
+		LevelEnabler: zapcore.InfoLevel,
+		send: func(message string, priority journal.Priority, vars map[string]string) error {
+			entries = append(entries, sent{message, priority, vars})
+			return nil
+		},
+	}
+	log := zap.New(core, zap.AddCaller()).Named("api").With(zap.String("request_id", "req-1"))
+
+	log.Debug("ignored")
+	log.Info("request done", zap.Int("http.status", 200), zap.String("_private", "x"), zap.String("message", "dup"))
+	log.Error("request failed", zap.Error(errors.New("boom")), zap.Strings("tags", []string{"a", "b"}))
+
+	require.Len(t, entries, 2)
+
+	assert.Equal(t, "request done", entries[0].message)
+	assert.Equal(t, journal.PriInfo, entries[0].priority)
+	assert.Equal(t, "req-1", entries[0].vars["REQUEST_ID"])
+	assert.Equal(t, "200", entries[0].vars["HTTP_STATUS"])
+	assert.Equal(t, "x", entries[0].vars["PRIVATE"])
+	assert.NotContains(t, entries[0].vars, "MESSAGE")
+	assert.Equal(t, "api", entries[0].vars["LOGGER"])
+	assert.NotEmpty(t, entries[0].vars["CODE_FILE"])
+	assert.NotEmpty(t, entries[0].vars["CODE_LINE"])
+
+	assert.Equal(t, journal.PriErr, entries[1].priority)
+	assert.Equal(t, "boom", entries[1].vars["ERROR"])
+	assert.Equal(t, `["a","b"]`, entries[1].vars["TAGS"])
+}
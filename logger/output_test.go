@@ -0,0 +1,270 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/constructorvirgil/virlog/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zapcore"
+)
+
+// 测试注册自定义输出工厂
+func TestRegisterOutputFactory(t *testing.T) {
+	buf := &bytes.Buffer{}
+	var received map[string]interface{}
+	require.NoError(t, RegisterOutputFactory("memory-test", func(cfg map[string]interface{}) (zapcore.WriteSyncer, error) {
+		received = cfg
+		return zapcore.AddSync(buf), nil
+	}))
+	defer UnregisterOutputFactory("memory-test")
+
+	cfg := config.DefaultConfig()
+	cfg.Output = "memory-test"
+	cfg.OutputOptions = map[string]interface{}{"topic": "logs"}
+
+	log, err := NewLogger(cfg)
+	require.NoError(t, err)
+	log.Info("factory message")
+
+	assert.Equal(t, "logs", received["topic"])
+	logData := make(map[string]interface{})
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &logData))
+	assert.Equal(t, "factory message", logData["msg"])
+
+	assert.Error(t, RegisterOutputFactory("", nil))
+	assert.Error(t, RegisterOutputFactory("nil-factory", nil))
+}
+
+// 测试内置的文件输出通过输出参数配置
+func TestFileOutputOptions(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "options.log")
+
+	cfg := config.DefaultConfig()
+	cfg.Output = "file"
+	cfg.OutputOptions = map[string]interface{}{"filename": filename, "max_size": float64(1)}
+
+	log, err := NewLogger(cfg)
+	require.NoError(t, err)
+	log.Info("file message")
+	require.NoError(t, log.Sync())
+
+	content, err := os.ReadFile(filename)
+	require.NoError(t, err)
+	assert.Contains(t, string(content), "file message")
+
+	assert.Equal(t, 1, optionInt(cfg.OutputOptions, "max_size", 0))
+	assert.Equal(t, 7, optionInt(map[string]interface{}{"n": "7"}, "n", 0))
+	assert.True(t, optionBool(map[string]interface{}{"b": "true"}, "b", false))
+}
+
+// 测试文件输出的写缓冲在Sync和定时刷新时写入文件
+func TestBufferedFileOutput(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "buffered.log")
+
+	cfg := config.DefaultConfig()
+	cfg.Output = "file"
+	cfg.FileConfig.Filename = filename
+	cfg.FileConfig.BufferSize = 64 * 1024
+	cfg.FileConfig.FlushInterval = time.Hour
+
+	log, err := NewLogger(cfg)
+	require.NoError(t, err)
+	log.Info("buffered message")
+
+	content, _ := os.ReadFile(filename)
+	assert.NotContains(t, string(content), "buffered message", "Sync之前日志应停留在缓冲区")
+
+	require.NoError(t, log.Sync())
+	content, err = os.ReadFile(filename)
+	require.NoError(t, err)
+	assert.Contains(t, string(content), "buffered message")
+
+	// 定时刷新
+	cfg.OutputOptions = map[string]interface{}{"flush_interval": "50ms"}
+	log, err = NewLogger(cfg)
+	require.NoError(t, err)
+	log.Info("interval message")
+	assert.Eventually(t, func() bool {
+		content, _ := os.ReadFile(filename)
+		return bytes.Contains(content, []byte("interval message"))
+	}, 2*time.Second, 20*time.Millisecond)
+
+	assert.Equal(t, 50*time.Millisecond, optionDuration(cfg.OutputOptions, "flush_interval", time.Second))
+	assert.Equal(t, time.Second, optionDuration(map[string]interface{}{}, "flush_interval", time.Second))
+}
+
+// 测试替换默认Logger后停止旧Logger的文件输出，缓冲的日志写入文件
+func TestReplacedOutputStopped(t *testing.T) {
+	original := defaults.Load()
+	originalGrace := replacedOutputGrace.Load()
+	defer func() {
+		defaults.Store(original)
+		replacedOutputGrace.Store(originalGrace)
+		managedOutputs.Store(nil)
+	}()
+	replacedOutputGrace.Store(int64(10 * time.Millisecond))
+
+	filename := filepath.Join(t.TempDir(), "replaced.log")
+	cfg := config.DefaultConfig()
+	cfg.Output = "file"
+	cfg.FileConfig.Filename = filename
+	cfg.FileConfig.BufferSize = 64 * 1024
+	cfg.FileConfig.FlushInterval = time.Hour
+
+	old, err := NewLogger(cfg)
+	require.NoError(t, err)
+	SetDefault(old)
+	managedOutputs.Store(outputsOf(old))
+	oldOutputs := outputsOf(old)
+	require.Len(t, oldOutputs.stoppers, 1)
+
+	applyConfig(cfg)
+	assert.NotSame(t, oldOutputs, outputsOf(DefaultLogger()))
+	assert.Eventually(t, func() bool {
+		oldOutputs.mu.Lock()
+		defer oldOutputs.mu.Unlock()
+		return oldOutputs.stopped
+	}, 2*time.Second, 10*time.Millisecond)
+
+	// 通过 SetDefault 设置的Logger的输出不会被停止
+	custom, err := NewLogger(cfg)
+	require.NoError(t, err)
+	SetDefault(custom)
+	applyConfig(cfg)
+	time.Sleep(50 * time.Millisecond)
+	assert.False(t, outputsOf(custom).stopped)
+	require.NoError(t, outputsOf(custom).stop())
+	assert.NoError(t, outputsOf(custom).stop(), "重复停止不做任何操作")
+}
+
+// 测试配置变更后创建Logger失败时记录错误并继续使用当前Logger
+func TestApplyConfigError(t *testing.T) {
+	original := DefaultLogger()
+	defer SetDefault(original)
+	current, buf := newBufferLogger(InfoLevel)
+	SetDefault(current)
+	before := DefaultLogger()
+
+	cfg := config.DefaultConfig()
+	cfg.NamedLevels = map[string]string{"db": "verbose"}
+	applyConfig(cfg)
+
+	assert.Same(t, before, DefaultLogger())
+	entry := findLogEntry(t, buf.String(), "配置变更后创建Logger失败，继续使用当前配置")
+	require.NotNil(t, entry)
+	assert.Equal(t, "logger", entry["component"])
+	assert.Contains(t, entry["error"], "verbose")
+}
+
+// 测试停止文件输出时刷新缓冲区
+func TestFileOutputStop(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "stop.log")
+	ws, err := newFileOutput(map[string]interface{}{"filename": filename, "buffer_size": 1024, "flush_interval": "1h"})
+	require.NoError(t, err)
+	_, err = ws.Write([]byte("buffered\n"))
+	require.NoError(t, err)
+
+	require.NoError(t, ws.(outputStopper).stopOutput())
+	content, err := os.ReadFile(filename)
+	require.NoError(t, err)
+	assert.Equal(t, "buffered\n", string(content))
+}
+
+// 测试未注册的输出名称使用stdout
+func TestUnknownOutputFallsBackToStdout(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.Output = "no-such-output"
+
+	ws, err := getOutputConfig(cfg)
+	require.NoError(t, err)
+	assert.NotNil(t, ws)
+}
+
+// 测试同时输出控制台格式和JSON格式
+func TestMultipleOutputs(t *testing.T) {
+	consoleBuf := &bytes.Buffer{}
+	jsonBuf := &bytes.Buffer{}
+	require.NoError(t, RegisterSink("multi-console", zapcore.AddSync(consoleBuf)))
+	require.NoError(t, RegisterSink("multi-json", zapcore.AddSync(jsonBuf)))
+	defer UnregisterSink("multi-console")
+	defer UnregisterSink("multi-json")
+
+	cfg := config.DefaultConfig()
+	cfg.Level = "debug"
+	cfg.Development = true
+	cfg.Outputs = []config.OutputConfig{
+		{Type: "sink:multi-console", Format: "console"},
+		{Type: "sink:multi-json", Format: "json", Level: "info"},
+	}
+
+	log, err := NewLogger(cfg)
+	require.NoError(t, err)
+	log.Debug("debug message")
+	log.Info("info message", String("user", "alice"))
+
+	// 控制台输出为文本格式，包含所有级别
+	assert.Contains(t, consoleBuf.String(), "debug message")
+	assert.Contains(t, consoleBuf.String(), "info message")
+	assert.Contains(t, consoleBuf.String(), `{"user": "alice"}`)
+
+	// JSON输出只包含info及以上级别，且级别不带颜色控制字符
+	lines := bytes.Split(bytes.TrimSpace(jsonBuf.Bytes()), []byte("\n"))
+	require.Len(t, lines, 1)
+	logData := make(map[string]interface{})
+	require.NoError(t, json.Unmarshal(lines[0], &logData))
+	assert.Equal(t, "info message", logData["msg"])
+	assert.Equal(t, "INFO", logData["level"])
+	assert.Equal(t, "alice", logData["user"])
+
+	cfg.Outputs = []config.OutputConfig{{Type: "stdout", Level: "verbose"}}
+	_, err = NewLogger(cfg)
+	assert.Error(t, err)
+}
+
+// 测试每个输出使用独立的编码器配置
+func TestOutputEncoderOverride(t *testing.T) {
+	consoleBuf := &bytes.Buffer{}
+	jsonBuf := &bytes.Buffer{}
+	logfmtBuf := &bytes.Buffer{}
+	require.NoError(t, RegisterSink("encoder-console", zapcore.AddSync(consoleBuf)))
+	require.NoError(t, RegisterSink("encoder-json", zapcore.AddSync(jsonBuf)))
+	require.NoError(t, RegisterSink("encoder-logfmt", zapcore.AddSync(logfmtBuf)))
+	defer UnregisterSink("encoder-console")
+	defer UnregisterSink("encoder-json")
+	defer UnregisterSink("encoder-logfmt")
+
+	cfg := config.DefaultConfig()
+	cfg.Encoder = &config.EncoderConfig{MessageKey: "message"}
+	cfg.Outputs = []config.OutputConfig{
+		{Type: "sink:encoder-console", Format: "console", Encoder: &config.EncoderConfig{LevelFormat: "uppercase_color", TimeKey: "-"}},
+		{Type: "sink:encoder-json", Format: "json", Encoder: &config.EncoderConfig{TimeFormat: "epoch", CallerKey: "-"}},
+		{Type: "sink:encoder-logfmt", Format: "logfmt"},
+	}
+
+	log, err := NewLogger(cfg)
+	require.NoError(t, err)
+	log.Info("hello")
+
+	// 控制台输出使用彩色级别且不输出时间
+	assert.Contains(t, consoleBuf.String(), "\x1b[34mINFO\x1b[0m")
+	assert.NotContains(t, consoleBuf.String(), time.Now().Format("2006-01-02"))
+
+	// JSON输出合并全局配置：消息字段名来自全局配置，时间和调用者字段来自该输出的配置
+	logData := make(map[string]interface{})
+	require.NoError(t, json.Unmarshal(jsonBuf.Bytes(), &logData))
+	assert.Equal(t, "hello", logData["message"])
+	assert.IsType(t, float64(0), logData["time"])
+	assert.NotContains(t, logData, "caller")
+	assert.Equal(t, "info", logData["level"])
+
+	// 未指定编码器配置的输出使用全局配置
+	assert.Contains(t, logfmtBuf.String(), "message=hello")
+	assert.Contains(t, logfmtBuf.String(), "caller=")
+	assert.Contains(t, logfmtBuf.String(), "level=info")
+}
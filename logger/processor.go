@@ -0,0 +1,146 @@
+package logger
+
+import (
+	"os"
+	"runtime/debug"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// Processor 在日志编码前处理每条日志的字段，fields 包含With添加的上下文字段和本条日志的字段，
+// 返回值为实际编码的字段，可以在其中修改、添加或删除字段；entry 可以直接修改（如改写消息）
+//
+// Processor 在采样之后、编码之前执行，钩子看到的仍是处理前的字段
+type Processor func(entry *zapcore.Entry, fields []Field) []Field
+
+// WithProcessors 注册日志处理器，多个处理器按注册顺序依次执行
+//
+// 处理器只能在创建Logger时注册，通过 Logger.WithOptions 追加的处理器不会生效
+func WithProcessors(processors ...Processor) Option {
+	return func(l *zapLogger) {
+		for _, p := range processors {
+			if p != nil {
+				l.processors = append(l.processors, p)
+			}
+		}
+	}
+}
+
+// processorCore 在写入底层core前依次执行处理器的core
+//
+// With添加的字段保存在processorCore中而不是传给底层core，以便处理器能处理上下文字段
+type processorCore struct {
+	zapcore.Core
+	processors []Processor
+	fields     []Field
+}
+
+// newProcessorCore 创建执行处理器的core
+func newProcessorCore(core zapcore.Core, processors []Processor) zapcore.Core {
+	return &processorCore{Core: core, processors: processors}
+}
+
+// With 实现zapcore.Core接口
+func (c *processorCore) With(fields []Field) zapcore.Core {
+	merged := make([]Field, 0, len(c.fields)+len(fields))
+	merged = append(merged, c.fields...)
+	merged = append(merged, fields...)
+	return &processorCore{Core: c.Core, processors: c.processors, fields: merged}
+}
+
+// Check 实现zapcore.Core接口
+func (c *processorCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(ent.Level) {
+		return ce.AddCore(ent, c)
+	}
+	return ce
+}
+
+// Write 实现zapcore.Core接口
+func (c *processorCore) Write(ent zapcore.Entry, fields []Field) error {
+	all := make([]Field, 0, len(c.fields)+len(fields))
+	all = append(all, c.fields...)
+	all = append(all, fields...)
+	for _, p := range c.processors {
+		all = p(&ent, all)
+	}
+	return c.Core.Write(ent, all)
+}
+
+// HostnameProcessor 添加 hostname 字段
+func HostnameProcessor() Processor {
+	hostname, err := os.Hostname()
+	if err != nil {
+		hostname = "unknown"
+	}
+	return StaticFieldsProcessor(String("hostname", hostname))
+}
+
+// StaticFieldsProcessor 为每条日志添加固定的字段，如版本号、部署区域
+func StaticFieldsProcessor(extra ...Field) Processor {
+	return func(_ *zapcore.Entry, fields []Field) []Field {
+		return append(fields, extra...)
+	}
+}
+
+// K8sProcessor 添加Kubernetes Pod元数据字段
+//
+// 元数据从通过 Downward API 注入的环境变量 POD_NAME、POD_NAMESPACE、POD_IP、NODE_NAME 读取，
+// 未设置的环境变量对应的字段不添加
+func K8sProcessor() Processor {
+	envFields := []struct{ env, key string }{
+		{"POD_NAME", "k8s.pod"},
+		{"POD_NAMESPACE", "k8s.namespace"},
+		{"POD_IP", "k8s.pod_ip"},
+		{"NODE_NAME", "k8s.node"},
+	}
+	var extra []Field
+	for _, f := range envFields {
+		if v := os.Getenv(f.env); v != "" {
+			extra = append(extra, String(f.key, v))
+		}
+	}
+	return StaticFieldsProcessor(extra...)
+}
+
+// GitCommitProcessor 添加 git_commit 字段，值为构建时记录的VCS修订号，无法获取时不添加
+func GitCommitProcessor() Processor {
+	var extra []Field
+	if info, ok := debug.ReadBuildInfo(); ok {
+		for _, setting := range info.Settings {
+			if setting.Key == "vcs.revision" && setting.Value != "" {
+				extra = append(extra, String("git_commit", setting.Value))
+			}
+		}
+	}
+	return StaticFieldsProcessor(extra...)
+}
+
+// RenameFieldsProcessor 按映射重命名字段，如 {"error": "err.message"}
+func RenameFieldsProcessor(mapping map[string]string) Processor {
+	return func(_ *zapcore.Entry, fields []Field) []Field {
+		for i := range fields {
+			if newKey, ok := mapping[fields[i].Key]; ok {
+				fields[i].Key = newKey
+			}
+		}
+		return fields
+	}
+}
+
+// DropFieldsProcessor 删除指定名称的字段
+func DropFieldsProcessor(keys ...string) Processor {
+	drop := make(map[string]struct{}, len(keys))
+	for _, k := range keys {
+		drop[k] = struct{}{}
+	}
+	return func(_ *zapcore.Entry, fields []Field) []Field {
+		kept := fields[:0]
+		for _, f := range fields {
+			if _, ok := drop[f.Key]; !ok {
+				kept = append(kept, f)
+			}
+		}
+		return kept
+	}
+}
@@ -0,0 +1,64 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/constructorvirgil/virlog/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zapcore"
+)
+
+// 测试处理器修改、添加和删除字段
+func TestWithProcessors(t *testing.T) {
+	buf := &bytes.Buffer{}
+	cfg := config.DefaultConfig()
+	cfg.Format = "json"
+	cfg.DefaultFields = map[string]interface{}{"app": "demo"}
+
+	t.Setenv("POD_NAME", "api-7d9f")
+	log, err := NewLogger(cfg,
+		WithSyncTarget(zapcore.AddSync(buf)),
+		WithProcessors(
+			StaticFieldsProcessor(String("region", "cn-east")),
+			K8sProcessor(),
+			RenameFieldsProcessor(map[string]string{"app": "service"}),
+			DropFieldsProcessor("password"),
+			func(ent *zapcore.Entry, fields []Field) []Field {
+				ent.Message = "[processed] " + ent.Message
+				return fields
+			},
+		))
+	require.NoError(t, err)
+
+	log.With(String("request_id", "req-1")).Info("login", String("password", "secret"))
+
+	entry := make(map[string]interface{})
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &entry))
+	assert.Equal(t, "[processed] login", entry["msg"])
+	assert.Equal(t, "cn-east", entry["region"])
+	assert.Equal(t, "api-7d9f", entry["k8s.pod"])
+	assert.Equal(t, "demo", entry["service"], "默认字段也应经过处理器")
+	assert.NotContains(t, entry, "app")
+	assert.Equal(t, "req-1", entry["request_id"])
+	assert.NotContains(t, entry, "password")
+}
+
+// 测试处理器不影响钩子看到的字段
+func TestProcessorsRunBeforeEncoding(t *testing.T) {
+	var hookFields []Field
+	log, err := NewLogger(config.DefaultConfig(),
+		WithSyncTarget(zapcore.AddSync(&bytes.Buffer{})),
+		WithProcessors(HostnameProcessor(), GitCommitProcessor()),
+		WithHook(func(_ zapcore.Entry, fields []Field) error {
+			hookFields = fields
+			return nil
+		}))
+	require.NoError(t, err)
+
+	log.Info("message", Int("n", 1))
+	require.Len(t, hookFields, 1)
+	assert.Equal(t, "n", hookFields[0].Key)
+}
@@ -0,0 +1,192 @@
+package logger
+
+import (
+	"sync"
+	"time"
+
+	"github.com/constructorvirgil/virlog/config"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+const (
+	// defaultRateLimitInterval 未设置限流周期时的默认值
+	defaultRateLimitInterval = time.Second
+	// maxRateLimitKeys 限流时最多跟踪的消息数，超出后新的消息不再限流，避免动态消息导致内存无限增长
+	maxRateLimitKeys = 4096
+	// suppressedCountKey 记录被丢弃日志条数的字段名
+	suppressedCountKey = "suppressed_count"
+)
+
+// RateLimit 对指定消息的日志限流，每个周期 per 内最多输出 n 条，
+// 超出的日志被丢弃，之后输出的第一条该消息的日志带有 suppressed_count 字段
+//
+// 与配置中的 RateLimit.Rules 合并生效，同一消息以通过选项设置的规则为准
+func RateLimit(message string, n int, per time.Duration) Option {
+	return func(l *zapLogger) {
+		l.rateLimits = append(l.rateLimits, config.RateLimitRule{Message: message, Limit: n, Interval: per})
+	}
+}
+
+// rateLimitRule 生效的限流规则
+type rateLimitRule struct {
+	limit    int
+	interval time.Duration
+}
+
+// rateLimitKey 限流计数的key，相同级别、相同消息的日志共享计数
+type rateLimitKey struct {
+	level   zapcore.Level
+	message string
+}
+
+// rateLimitWindow 单个消息在当前周期内的计数
+type rateLimitWindow struct {
+	start time.Time
+	count int
+	// suppressed 当前周期内被丢弃的条数
+	suppressed int
+	// pending 尚未报告的被丢弃条数，在下一条输出的日志中报告
+	pending int
+}
+
+// rateLimiter 按消息计数的限流器，由同一Logger派生的所有core共享
+type rateLimiter struct {
+	rules    map[string]rateLimitRule
+	fallback *rateLimitRule
+	now      func() time.Time
+
+	mu      sync.Mutex
+	windows map[rateLimitKey]*rateLimitWindow
+}
+
+// rateLimitOption 根据配置和选项创建限流的zap选项，未启用限流时返回nil
+func rateLimitOption(cfg *config.Config, rules []config.RateLimitRule) zap.Option {
+	limiter := newRateLimiter(cfg.RateLimit, rules)
+	if limiter == nil {
+		return nil
+	}
+	return zap.WrapCore(func(core zapcore.Core) zapcore.Core {
+		return &rateLimitCore{Core: core, limiter: limiter}
+	})
+}
+
+// newRateLimiter 合并配置和选项中的限流规则，没有任何规则时返回nil
+func newRateLimiter(cfg *config.RateLimitConfig, extra []config.RateLimitRule) *rateLimiter {
+	l := &rateLimiter{
+		rules:   make(map[string]rateLimitRule),
+		now:     time.Now,
+		windows: make(map[rateLimitKey]*rateLimitWindow),
+	}
+
+	var rules []config.RateLimitRule
+	if cfg != nil {
+		if cfg.Limit > 0 {
+			l.fallback = &rateLimitRule{limit: cfg.Limit, interval: intervalOrDefault(cfg.Interval)}
+		}
+		rules = append(rules, cfg.Rules...)
+	}
+	rules = append(rules, extra...)
+
+	for _, r := range rules {
+		if r.Limit <= 0 {
+			continue
+		}
+		l.rules[r.Message] = rateLimitRule{limit: r.Limit, interval: intervalOrDefault(r.Interval)}
+	}
+
+	if l.fallback == nil && len(l.rules) == 0 {
+		return nil
+	}
+	return l
+}
+
+// intervalOrDefault 返回限流周期，未设置时使用默认值
+func intervalOrDefault(d time.Duration) time.Duration {
+	if d <= 0 {
+		return defaultRateLimitInterval
+	}
+	return d
+}
+
+// allow 判断日志是否允许输出，允许时返回需要报告的被丢弃条数
+func (l *rateLimiter) allow(ent zapcore.Entry) (bool, int) {
+	rule, ok := l.rules[ent.Message]
+	if !ok {
+		if l.fallback == nil {
+			return true, 0
+		}
+		rule = *l.fallback
+	}
+
+	key := rateLimitKey{level: ent.Level, message: ent.Message}
+	now := l.now()
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	w, ok := l.windows[key]
+	if !ok {
+		if len(l.windows) >= maxRateLimitKeys {
+			l.evictExpired(now, rule.interval)
+			if len(l.windows) >= maxRateLimitKeys {
+				return true, 0
+			}
+		}
+		w = &rateLimitWindow{start: now}
+		l.windows[key] = w
+	}
+
+	if now.Sub(w.start) >= rule.interval {
+		w.pending += w.suppressed
+		w.start = now
+		w.count = 0
+		w.suppressed = 0
+	}
+
+	w.count++
+	if w.count > rule.limit {
+		w.suppressed++
+		return false, 0
+	}
+
+	pending := w.pending
+	w.pending = 0
+	return true, pending
+}
+
+// evictExpired 删除已过期且没有待报告条数的计数
+func (l *rateLimiter) evictExpired(now time.Time, interval time.Duration) {
+	for key, w := range l.windows {
+		if now.Sub(w.start) >= interval && w.suppressed == 0 && w.pending == 0 {
+			delete(l.windows, key)
+		}
+	}
+}
+
+// rateLimitCore 按消息限流的core
+type rateLimitCore struct {
+	zapcore.Core
+	limiter *rateLimiter
+}
+
+// With 实现zapcore.Core接口，派生的core共享限流计数
+func (c *rateLimitCore) With(fields []Field) zapcore.Core {
+	return &rateLimitCore{Core: c.Core.With(fields), limiter: c.limiter}
+}
+
+// Check 实现zapcore.Core接口
+func (c *rateLimitCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if !c.Enabled(ent.Level) {
+		return ce
+	}
+
+	allowed, suppressed := c.limiter.allow(ent)
+	if !allowed {
+		return ce
+	}
+	if suppressed > 0 {
+		return c.Core.With([]Field{Int(suppressedCountKey, suppressed)}).Check(ent, ce)
+	}
+	return c.Core.Check(ent, ce)
+}
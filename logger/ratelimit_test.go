@@ -0,0 +1,85 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/constructorvirgil/virlog/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zapcore"
+)
+
+// 测试限流器按周期计数并报告被丢弃的条数
+func TestRateLimiter(t *testing.T) {
+	limiter := newRateLimiter(&config.RateLimitConfig{Limit: 2, Interval: time.Second}, nil)
+	require.NotNil(t, limiter)
+
+	now := time.Unix(0, 0)
+	limiter.now = func() time.Time { return now }
+	ent := zapcore.Entry{Level: ErrorLevel, Message: "db connection refused"}
+
+	for i := 0; i < 2; i++ {
+		allowed, suppressed := limiter.allow(ent)
+		assert.True(t, allowed)
+		assert.Zero(t, suppressed)
+	}
+	for i := 0; i < 3; i++ {
+		allowed, _ := limiter.allow(ent)
+		assert.False(t, allowed)
+	}
+
+	// 其他消息不受影响
+	allowed, _ := limiter.allow(zapcore.Entry{Level: ErrorLevel, Message: "other"})
+	assert.True(t, allowed)
+
+	// 下一个周期输出的第一条日志报告被丢弃的条数
+	now = now.Add(time.Second)
+	allowed, suppressed := limiter.allow(ent)
+	assert.True(t, allowed)
+	assert.Equal(t, 3, suppressed)
+	_, suppressed = limiter.allow(ent)
+	assert.Zero(t, suppressed)
+
+	assert.Nil(t, newRateLimiter(nil, nil), "没有规则时不启用限流")
+}
+
+// 测试通过选项设置的单条消息限流
+func TestRateLimitOption(t *testing.T) {
+	buf := &bytes.Buffer{}
+	cfg := config.DefaultConfig()
+	cfg.Format = "json"
+	log, err := NewLogger(cfg,
+		WithSyncTarget(zapcore.AddSync(buf)),
+		RateLimit("db connection refused", 1, 50*time.Millisecond))
+	require.NoError(t, err)
+
+	for i := 0; i < 5; i++ {
+		log.Error("db connection refused")
+		log.Info("unlimited")
+	}
+	time.Sleep(60 * time.Millisecond)
+	log.With(String("db", "orders")).Error("db connection refused")
+
+	var limited []map[string]interface{}
+	unlimited := 0
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		entry := make(map[string]interface{})
+		require.NoError(t, json.Unmarshal([]byte(line), &entry))
+		switch entry["msg"] {
+		case "db connection refused":
+			limited = append(limited, entry)
+		case "unlimited":
+			unlimited++
+		}
+	}
+
+	assert.Equal(t, 5, unlimited)
+	require.Len(t, limited, 2)
+	assert.NotContains(t, limited[0], suppressedCountKey)
+	assert.Equal(t, float64(4), limited[1][suppressedCountKey])
+	assert.Equal(t, "orders", limited[1]["db"])
+}
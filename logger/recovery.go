@@ -0,0 +1,109 @@
+package logger
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"go.uber.org/zap"
+)
+
+// recoveryConfig 恢复中间件的配置
+type recoveryConfig struct {
+	rePanic bool
+}
+
+// RecoveryOption 定义恢复中间件选项的函数类型
+type RecoveryOption func(*recoveryConfig)
+
+// WithRePanic 记录日志后重新抛出panic，交由上层（如http.Server）处理
+func WithRePanic() RecoveryOption {
+	return func(c *recoveryConfig) {
+		c.rePanic = true
+	}
+}
+
+// RecoveryMiddleware 返回一个捕获panic的HTTP中间件
+//
+// panic会以Error级别记录，包含调用栈以及请求上下文Logger中的字段（如request_id），
+// 默认向客户端返回500，使用 WithRePanic 时记录后重新抛出。
+// 与 HTTPMiddleware 一起使用时应放在其内层，以便使用请求上下文中的Logger
+func RecoveryMiddleware(logger Logger, opts ...RecoveryOption) func(http.Handler) http.Handler {
+	cfg := &recoveryConfig{}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			rw := &recoveryResponseWriter{ResponseWriter: w}
+			defer func() {
+				rec := recover()
+				if rec == nil {
+					return
+				}
+				// http.ErrAbortHandler 用于主动中断响应，按net/http约定直接抛出
+				if rec == http.ErrAbortHandler {
+					panic(rec)
+				}
+
+				log := logger
+				if ctxLogger, ok := FromContext(r.Context()); ok {
+					log = ctxLogger
+				}
+				logPanic(log, rec, "HTTP handler panic",
+					String("method", r.Method),
+					String("path", r.URL.Path),
+				)
+
+				if cfg.rePanic {
+					panic(rec)
+				}
+				if !rw.wroteHeader {
+					http.Error(rw, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+				}
+			}()
+
+			next.ServeHTTP(rw, r)
+		})
+	}
+}
+
+// RecoverAndLog 捕获当前goroutine中的panic并使用上下文中的Logger记录，必须直接通过defer调用：
+//
+//	defer logger.RecoverAndLog(ctx)
+func RecoverAndLog(ctx context.Context) {
+	if rec := recover(); rec != nil {
+		logPanic(contextLogger(ctx), rec, "panic recovered")
+	}
+}
+
+// logPanic 以Error级别记录panic及调用栈
+func logPanic(log Logger, rec interface{}, msg string, fields ...Field) {
+	fields = append(fields,
+		String("panic", fmt.Sprint(rec)),
+		zap.StackSkip("stack", 2),
+	)
+	if err, ok := rec.(error); ok {
+		fields = append(fields, Err(err))
+	}
+	log.Error(msg, fields...)
+}
+
+// recoveryResponseWriter 记录响应头是否已写出
+type recoveryResponseWriter struct {
+	http.ResponseWriter
+	wroteHeader bool
+}
+
+// WriteHeader 实现http.ResponseWriter接口
+func (rw *recoveryResponseWriter) WriteHeader(code int) {
+	rw.wroteHeader = true
+	rw.ResponseWriter.WriteHeader(code)
+}
+
+// Write 实现http.ResponseWriter接口
+func (rw *recoveryResponseWriter) Write(b []byte) (int, error) {
+	rw.wroteHeader = true
+	return rw.ResponseWriter.Write(b)
+}
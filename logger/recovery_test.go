@@ -0,0 +1,74 @@
+package logger
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// 测试HTTP恢复中间件
+func TestRecoveryMiddleware(t *testing.T) {
+	log, buf := newBufferLogger(InfoLevel)
+
+	handler := HTTPMiddleware(log)(RecoveryMiddleware(log)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic("handler exploded")
+	})))
+
+	req := httptest.NewRequest(http.MethodGet, "/panic", nil)
+	req.Header.Set("X-Request-ID", "req-panic")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusInternalServerError, rec.Code)
+
+	var panicEntry map[string]interface{}
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		entry := make(map[string]interface{})
+		require.NoError(t, json.Unmarshal([]byte(line), &entry))
+		if entry["msg"] == "HTTP handler panic" {
+			panicEntry = entry
+		}
+	}
+	require.NotNil(t, panicEntry, "应记录panic日志")
+	assert.Equal(t, "error", panicEntry["level"])
+	assert.Equal(t, "handler exploded", panicEntry["panic"])
+	assert.Equal(t, "req-panic", panicEntry["request_id"])
+	assert.NotEmpty(t, panicEntry["stack"])
+}
+
+// 测试重新抛出panic
+func TestRecoveryMiddlewareRePanic(t *testing.T) {
+	log, buf := newBufferLogger(InfoLevel)
+
+	handler := RecoveryMiddleware(log, WithRePanic())(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic("again")
+	}))
+
+	assert.PanicsWithValue(t, "again", func() {
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	})
+	assert.Contains(t, buf.String(), "HTTP handler panic")
+}
+
+// 测试RecoverAndLog
+func TestRecoverAndLog(t *testing.T) {
+	log, buf := newBufferLogger(InfoLevel)
+	ctx := context.WithValue(context.Background(), loggerContextKey{}, log.With(String("job", "sync")))
+
+	assert.NotPanics(t, func() {
+		defer RecoverAndLog(ctx)
+		panic("job failed")
+	})
+
+	entry := make(map[string]interface{})
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &entry))
+	assert.Equal(t, "panic recovered", entry["msg"])
+	assert.Equal(t, "job failed", entry["panic"])
+	assert.Equal(t, "sync", entry["job"])
+}
@@ -0,0 +1,258 @@
+package remote
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/constructorvirgil/virlog/logger"
+	"go.uber.org/zap/zapcore"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+const (
+	// defaultBatchSize 默认每个流发送的最大日志数
+	defaultBatchSize = 500
+	// defaultFlushInterval 默认的定时发送间隔
+	defaultFlushInterval = time.Second
+	// defaultQueueSize 默认的待发送队列长度
+	defaultQueueSize = 10000
+	// defaultReconnectInterval 默认的发送失败后重试间隔
+	defaultReconnectInterval = time.Second
+	// defaultTimeout 默认的单批发送超时
+	defaultTimeout = 10 * time.Second
+)
+
+// Options 转发日志的配置
+type Options struct {
+	// 每个流发送的最大日志数，默认500
+	BatchSize int
+	// 定时发送的间隔，不足一批的日志最多等待该时长后发送，默认1秒
+	FlushInterval time.Duration
+	// 待发送队列长度，队列满时丢弃新日志，默认10000
+	QueueSize int
+	// 发送失败后重试的间隔，默认1秒
+	ReconnectInterval time.Duration
+	// 单批发送的超时时间，默认10秒
+	Timeout time.Duration
+	// 日志来源，collector将其写入 source 字段，默认为主机名
+	Source string
+	// 额外的拨号选项，未指定传输凭证时使用不加密的连接
+	DialOptions []grpc.DialOption
+	// 发送失败时调用，默认输出到标准错误
+	OnError func(error)
+}
+
+// Client 将日志转发到collector的客户端
+//
+// 每批日志通过一个流发送，collector写入后确认；发送失败时整批重试直到成功，
+// collector重启期间的日志不会丢失，但中断前已写入的部分可能重复
+type Client struct {
+	conn *grpc.ClientConn
+	opts Options
+
+	queue chan *structpb.Struct
+	flush chan chan struct{}
+
+	closeOnce sync.Once
+	done      chan struct{}
+	stopped   chan struct{}
+}
+
+// Dial 创建连接到 target 的客户端，返回的 Client 需要调用 Close 发送剩余日志
+//
+// 连接在首次发送时建立，collector不可用时日志在队列中等待重试
+func Dial(target string, opts Options) (*Client, error) {
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = defaultBatchSize
+	}
+	if opts.FlushInterval <= 0 {
+		opts.FlushInterval = defaultFlushInterval
+	}
+	if opts.QueueSize <= 0 {
+		opts.QueueSize = defaultQueueSize
+	}
+	if opts.ReconnectInterval <= 0 {
+		opts.ReconnectInterval = defaultReconnectInterval
+	}
+	if opts.Timeout <= 0 {
+		opts.Timeout = defaultTimeout
+	}
+	if opts.Source == "" {
+		opts.Source, _ = os.Hostname()
+	}
+	if opts.OnError == nil {
+		opts.OnError = func(err error) {
+			fmt.Fprintf(os.Stderr, "remote: %v\n", err)
+		}
+	}
+
+	dialOptions := append([]grpc.DialOption{grpc.WithTransportCredentials(insecure.NewCredentials())}, opts.DialOptions...)
+	conn, err := grpc.Dial(target, dialOptions...)
+	if err != nil {
+		return nil, fmt.Errorf("连接collector失败: %w", err)
+	}
+
+	c := &Client{
+		conn:    conn,
+		opts:    opts,
+		queue:   make(chan *structpb.Struct, opts.QueueSize),
+		flush:   make(chan chan struct{}),
+		done:    make(chan struct{}),
+		stopped: make(chan struct{}),
+	}
+	go c.run()
+	return c, nil
+}
+
+// Hook 返回转发日志的钩子，配合 logger.WithHook 或 logger.WithLevelHook 使用
+func (c *Client) Hook() logger.Hook {
+	return func(ent zapcore.Entry, fields []logger.Field) error {
+		select {
+		case <-c.done:
+			return nil
+		default:
+		}
+		select {
+		case c.queue <- encodeEntry(ent, fields):
+		default:
+			// 队列已满，丢弃日志，避免阻塞日志写入
+		}
+		return nil
+	}
+}
+
+// Flush 立即发送队列中的日志，超时返回false
+func (c *Client) Flush(timeout time.Duration) bool {
+	finished := make(chan struct{})
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case c.flush <- finished:
+	case <-c.stopped:
+		return true
+	case <-timer.C:
+		return false
+	}
+	select {
+	case <-finished:
+		return true
+	case <-timer.C:
+		return false
+	}
+}
+
+// Close 发送队列中剩余的日志并关闭连接，collector不可用时只尝试一次
+func (c *Client) Close() error {
+	c.closeOnce.Do(func() {
+		close(c.done)
+	})
+	<-c.stopped
+	return c.conn.Close()
+}
+
+// run 后台按批发送队列中的日志
+func (c *Client) run() {
+	defer close(c.stopped)
+	ticker := time.NewTicker(c.opts.FlushInterval)
+	defer ticker.Stop()
+
+	batch := make([]*structpb.Struct, 0, c.opts.BatchSize)
+	for {
+		select {
+		case entry := <-c.queue:
+			batch = append(batch, entry)
+			if len(batch) >= c.opts.BatchSize {
+				batch = c.send(batch)
+			}
+		case <-ticker.C:
+			batch = c.send(batch)
+		case finished := <-c.flush:
+			batch = c.send(c.drain(batch))
+			close(finished)
+		case <-c.done:
+			c.send(c.drain(batch))
+			return
+		}
+	}
+}
+
+// drain 取出队列中已有的日志，按批发送超出的部分
+func (c *Client) drain(batch []*structpb.Struct) []*structpb.Struct {
+	for {
+		select {
+		case entry := <-c.queue:
+			batch = append(batch, entry)
+			if len(batch) >= c.opts.BatchSize {
+				batch = c.send(batch)
+			}
+		default:
+			return batch
+		}
+	}
+}
+
+// send 发送一批日志，失败时按间隔重试直到成功，关闭后失败则丢弃，返回清空后的batch以便复用
+func (c *Client) send(batch []*structpb.Struct) []*structpb.Struct {
+	if len(batch) == 0 {
+		return batch
+	}
+	for {
+		err := c.forward(batch)
+		if err == nil {
+			break
+		}
+		select {
+		case <-c.done:
+			c.opts.OnError(fmt.Errorf("丢弃%d条日志: %w", len(batch), err))
+			return c.reset(batch)
+		default:
+		}
+		c.opts.OnError(fmt.Errorf("发送%d条日志失败: %w", len(batch), err))
+
+		// 等待重试，期间关闭时立即进行最后一次尝试
+		timer := time.NewTimer(c.opts.ReconnectInterval)
+		select {
+		case <-c.done:
+			timer.Stop()
+		case <-timer.C:
+		}
+	}
+	return c.reset(batch)
+}
+
+// reset 清空batch以便复用
+func (c *Client) reset(batch []*structpb.Struct) []*structpb.Struct {
+	for i := range batch {
+		batch[i] = nil
+	}
+	return batch[:0]
+}
+
+// forward 通过一个流发送一批日志，collector写入全部日志后返回nil
+func (c *Client) forward(batch []*structpb.Struct) error {
+	ctx, cancel := context.WithTimeout(context.Background(), c.opts.Timeout)
+	defer cancel()
+	ctx = metadata.AppendToOutgoingContext(ctx, sourceMetadataKey, c.opts.Source)
+
+	stream, err := c.conn.NewStream(ctx, &forwardStreamDesc, forwardMethod)
+	if err != nil {
+		return err
+	}
+	for _, entry := range batch {
+		if err := stream.SendMsg(entry); err != nil {
+			// 流已结束，实际的错误由RecvMsg返回
+			break
+		}
+	}
+	if err := stream.CloseSend(); err != nil {
+		return err
+	}
+	return stream.RecvMsg(&emptypb.Empty{})
+}
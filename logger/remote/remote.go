@@ -0,0 +1,128 @@
+// Package remote 通过gRPC流将日志转发到集中的 virlog collector，由 collector 写入自身的输出
+//
+// 各进程使用 Client 转发日志：
+//
+//	client, err := remote.Dial("collector:7070", remote.Options{})
+//	defer client.Close()
+//	log, err := logger.NewLogger(cfg, logger.WithHook(client.Hook()))
+//
+// collector 使用 Server 接收日志，按自身的配置（级别、格式、输出）写入：
+//
+//	server := grpc.NewServer()
+//	remote.NewServer(collectorLogger).Register(server)
+//	server.Serve(lis)
+//
+// 协议定义见 remote.proto，cmd/virlog-collector 是可以直接部署的 collector
+package remote
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/constructorvirgil/virlog/logger"
+	"go.uber.org/zap/zapcore"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+const (
+	// forwardMethod 转发日志的gRPC方法
+	forwardMethod = "/virlog.remote.v1.Collector/Forward"
+	// sourceMetadataKey 标识日志来源的metadata
+	sourceMetadataKey = "virlog-source"
+	// sourceField collector写入日志来源的字段
+	sourceField = "source"
+)
+
+// 日志中的字段名，与 remote.proto 一致
+const (
+	keyTime       = "time"
+	keyLevel      = "level"
+	keyLogger     = "logger"
+	keyMessage    = "message"
+	keyCallerFile = "caller_file"
+	keyCallerLine = "caller_line"
+	keyStack      = "stack"
+	keyFields     = "fields"
+)
+
+// forwardStreamDesc Forward方法的流描述
+var forwardStreamDesc = grpc.StreamDesc{
+	StreamName:    "Forward",
+	ClientStreams: true,
+}
+
+// encodeEntry 将日志编码为协议中的Struct
+func encodeEntry(ent zapcore.Entry, fields []logger.Field) *structpb.Struct {
+	enc := zapcore.NewMapObjectEncoder()
+	for _, f := range fields {
+		f.AddTo(enc)
+	}
+	values := make(map[string]*structpb.Value, len(enc.Fields))
+	for k, v := range enc.Fields {
+		values[k] = toValue(v)
+	}
+
+	level := ent.Level.String()
+	if ent.Level == logger.TraceLevel {
+		level = "trace"
+	}
+	s := &structpb.Struct{Fields: map[string]*structpb.Value{
+		keyTime:    structpb.NewStringValue(ent.Time.Format(time.RFC3339Nano)),
+		keyLevel:   structpb.NewStringValue(level),
+		keyLogger:  structpb.NewStringValue(ent.LoggerName),
+		keyMessage: structpb.NewStringValue(ent.Message),
+		keyFields:  structpb.NewStructValue(&structpb.Struct{Fields: values}),
+	}}
+	if ent.Caller.Defined {
+		s.Fields[keyCallerFile] = structpb.NewStringValue(ent.Caller.File)
+		s.Fields[keyCallerLine] = structpb.NewNumberValue(float64(ent.Caller.Line))
+	}
+	if ent.Stack != "" {
+		s.Fields[keyStack] = structpb.NewStringValue(ent.Stack)
+	}
+	return s
+}
+
+// toValue 将字段值转换为Struct中的值，时间、对象等先按JSON序列化，无法序列化时使用字符串
+func toValue(v interface{}) *structpb.Value {
+	if value, err := structpb.NewValue(v); err == nil {
+		return value
+	}
+	data, err := json.Marshal(v)
+	if err == nil {
+		var decoded interface{}
+		if err := json.Unmarshal(data, &decoded); err == nil {
+			if value, err := structpb.NewValue(decoded); err == nil {
+				return value
+			}
+		}
+	}
+	return structpb.NewStringValue(fmt.Sprint(v))
+}
+
+// decodeEntry 将协议中的Struct解码为日志，字段按名称排序，嵌套对象输出为子对象
+func decodeEntry(s *structpb.Struct) (zapcore.Entry, []logger.Field, error) {
+	get := func(key string) *structpb.Value { return s.GetFields()[key] }
+
+	var ent zapcore.Entry
+	t, err := time.Parse(time.RFC3339Nano, get(keyTime).GetStringValue())
+	if err != nil {
+		return ent, nil, fmt.Errorf("无效的日志时间: %w", err)
+	}
+	ent.Time = t
+	if level := get(keyLevel).GetStringValue(); level == "trace" {
+		ent.Level = logger.TraceLevel
+	} else if ent.Level, err = zapcore.ParseLevel(level); err != nil {
+		return ent, nil, fmt.Errorf("无效的日志级别: %w", err)
+	}
+	ent.LoggerName = get(keyLogger).GetStringValue()
+	ent.Message = get(keyMessage).GetStringValue()
+	if file := get(keyCallerFile).GetStringValue(); file != "" {
+		ent.Caller = zapcore.EntryCaller{Defined: true, File: file, Line: int(get(keyCallerLine).GetNumberValue())}
+	}
+	ent.Stack = get(keyStack).GetStringValue()
+
+	return ent, logger.Fields(get(keyFields).GetStructValue().AsMap()), nil
+}
@@ -0,0 +1,166 @@
+package remote
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"net"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/constructorvirgil/virlog/config"
+	"github.com/constructorvirgil/virlog/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zapcore"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/backoff"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// syncBuffer 并发安全的输出
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) Sync() error { return nil }
+
+// entries 返回已写入的日志
+func (b *syncBuffer) entries(t *testing.T) []map[string]interface{} {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	var entries []map[string]interface{}
+	scanner := bufio.NewScanner(bytes.NewReader(b.buf.Bytes()))
+	for scanner.Scan() {
+		entry := make(map[string]interface{})
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &entry))
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+// collectorFixture 使用内存连接的collector，可以重启以模拟collector不可用
+type collectorFixture struct {
+	out      *syncBuffer
+	log      logger.Logger
+	listener atomic.Pointer[bufconn.Listener]
+	server   *grpc.Server
+}
+
+func newCollector(t *testing.T, level string) *collectorFixture {
+	cfg := config.DefaultConfig()
+	cfg.Format = "json"
+	cfg.Level = level
+	cfg.EnableStacktrace = false
+	out := &syncBuffer{}
+	log, err := logger.NewLogger(cfg, logger.WithSyncTarget(out))
+	require.NoError(t, err)
+
+	f := &collectorFixture{out: out, log: log}
+	f.start()
+	t.Cleanup(func() { f.server.Stop() })
+	return f
+}
+
+// start 启动新的collector
+func (f *collectorFixture) start() {
+	lis := bufconn.Listen(1 << 20)
+	f.listener.Store(lis)
+	f.server = grpc.NewServer()
+	NewServer(f.log).Register(f.server)
+	go f.server.Serve(lis)
+}
+
+// dial 创建连接到collector的客户端
+func (f *collectorFixture) dial(t *testing.T, opts Options) *Client {
+	opts.DialOptions = append(opts.DialOptions, grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+		return f.listener.Load().DialContext(ctx)
+	}))
+	client, err := Dial("passthrough:///collector", opts)
+	require.NoError(t, err)
+	return client
+}
+
+// 测试转发日志并由collector按自身配置写入
+func TestForward(t *testing.T) {
+	collector := newCollector(t, "info")
+	client := collector.dial(t, Options{Source: "api-1"})
+
+	cfg := config.DefaultConfig()
+	cfg.Level = "trace"
+	cfg.EnableStacktrace = false
+	log, err := logger.NewLogger(cfg, logger.WithSyncTarget(zapcore.AddSync(&bytes.Buffer{})), logger.WithHook(client.Hook()))
+	require.NoError(t, err)
+
+	log.Named("orders").Info("created",
+		logger.Int("status", 200),
+		logger.Duration("latency", 15*time.Millisecond),
+		logger.Object("user", map[string]interface{}{"id": "u-1"}))
+	log.Debug("filtered by collector")
+	log.Warn("slow")
+	require.NoError(t, client.Close())
+
+	entries := collector.out.entries(t)
+	require.Len(t, entries, 2)
+	assert.Equal(t, "created", entries[0]["msg"])
+	assert.Equal(t, "info", entries[0]["level"])
+	assert.Equal(t, "orders", entries[0]["logger"])
+	assert.Equal(t, float64(200), entries[0]["status"])
+	assert.Equal(t, float64(15*time.Millisecond), entries[0]["latency"])
+	assert.Equal(t, map[string]interface{}{"id": "u-1"}, entries[0]["user"])
+	assert.Equal(t, "api-1", entries[0]["source"])
+	assert.Contains(t, entries[0]["caller"], "remote_test.go")
+	assert.Equal(t, "slow", entries[1]["msg"])
+	assert.Equal(t, "warn", entries[1]["level"])
+}
+
+// 测试collector重启后重新连接并发送期间的日志
+func TestForwardReconnect(t *testing.T) {
+	collector := newCollector(t, "info")
+	var (
+		mu     sync.Mutex
+		errors []error
+	)
+	client := collector.dial(t, Options{
+		ReconnectInterval: 10 * time.Millisecond,
+		DialOptions: []grpc.DialOption{grpc.WithConnectParams(grpc.ConnectParams{
+			Backoff:           backoff.Config{BaseDelay: 10 * time.Millisecond, MaxDelay: 10 * time.Millisecond},
+			MinConnectTimeout: time.Second,
+		})},
+		OnError: func(err error) {
+			mu.Lock()
+			errors = append(errors, err)
+			mu.Unlock()
+		},
+	})
+	hook := client.Hook()
+
+	require.NoError(t, hook(zapcore.Entry{Level: logger.InfoLevel, Time: time.Now(), Message: "before"}, nil))
+	require.True(t, client.Flush(time.Second))
+	assert.Eventually(t, func() bool { return len(collector.out.entries(t)) == 1 }, time.Second, 10*time.Millisecond)
+
+	// collector不可用时日志保留在客户端，恢复后发送
+	collector.server.Stop()
+	require.NoError(t, hook(zapcore.Entry{Level: logger.InfoLevel, Time: time.Now(), Message: "during"}, nil))
+	assert.False(t, client.Flush(100*time.Millisecond))
+	collector.start()
+	require.True(t, client.Flush(time.Second))
+	require.NoError(t, client.Close())
+
+	entries := collector.out.entries(t)
+	require.Len(t, entries, 2)
+	assert.Equal(t, "during", entries[1]["msg"])
+	mu.Lock()
+	assert.NotEmpty(t, errors)
+	mu.Unlock()
+}
@@ -0,0 +1,80 @@
+package remote
+
+import (
+	"errors"
+	"io"
+
+	"github.com/constructorvirgil/virlog/logger"
+	"go.uber.org/zap/zapcore"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// collector Server 实现的服务接口
+type collector interface {
+	forward(stream grpc.ServerStream) error
+}
+
+// collectorServiceDesc Collector服务的描述
+var collectorServiceDesc = grpc.ServiceDesc{
+	ServiceName: "virlog.remote.v1.Collector",
+	HandlerType: (*collector)(nil),
+	Streams: []grpc.StreamDesc{{
+		StreamName:    forwardStreamDesc.StreamName,
+		ClientStreams: true,
+		Handler: func(srv interface{}, stream grpc.ServerStream) error {
+			return srv.(collector).forward(stream)
+		},
+	}},
+	Metadata: "remote.proto",
+}
+
+// Server 接收转发的日志并写入 collector 自身的Logger
+type Server struct {
+	core zapcore.Core
+}
+
+// NewServer 创建写入 log 的collector服务
+//
+// 日志保留客户端的时间、级别、名称和调用位置，由 log 的级别、钩子和输出处理；
+// 客户端标识了来源时附加 source 字段
+func NewServer(log logger.Logger) *Server {
+	return &Server{core: log.GetRawZapLogger().Core()}
+}
+
+// Register 将服务注册到gRPC服务器
+func (s *Server) Register(server *grpc.Server) {
+	server.RegisterService(&collectorServiceDesc, s)
+}
+
+// forward 处理Forward流，客户端关闭发送后同步输出并返回
+func (s *Server) forward(stream grpc.ServerStream) error {
+	var source []logger.Field
+	if md, ok := metadata.FromIncomingContext(stream.Context()); ok {
+		if values := md.Get(sourceMetadataKey); len(values) > 0 && values[0] != "" {
+			source = []logger.Field{logger.String(sourceField, values[0])}
+		}
+	}
+
+	for {
+		msg := &structpb.Struct{}
+		if err := stream.RecvMsg(msg); err != nil {
+			if errors.Is(err, io.EOF) {
+				_ = s.core.Sync()
+				return stream.SendMsg(&emptypb.Empty{})
+			}
+			return err
+		}
+		ent, fields, err := decodeEntry(msg)
+		if err != nil {
+			return status.Error(codes.InvalidArgument, err.Error())
+		}
+		if ce := s.core.Check(ent, nil); ce != nil {
+			ce.Write(append(fields, source...)...)
+		}
+	}
+}
@@ -0,0 +1,75 @@
+package logger
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/constructorvirgil/virlog/config"
+	"go.uber.org/zap/zapcore"
+)
+
+// ErrorReporterFactory 根据日志配置创建错误上报钩子，未启用上报时返回nil钩子
+//
+// 返回的 LevelEnabler 决定钩子生效的级别
+type ErrorReporterFactory func(cfg *config.Config) (Hook, zapcore.LevelEnabler, error)
+
+var (
+	// 已注册的错误上报工厂
+	errorReporters   = make(map[string]ErrorReporterFactory)
+	errorReportersMu sync.RWMutex
+)
+
+// RegisterErrorReporter 注册错误上报工厂，NewLogger 创建Logger时会调用所有已注册的工厂，
+// 通常由上报实现包（如 logger/sinks/sentry）在init中注册
+func RegisterErrorReporter(name string, factory ErrorReporterFactory) error {
+	if name == "" {
+		return fmt.Errorf("错误上报名称不能为空")
+	}
+	if factory == nil {
+		return fmt.Errorf("错误上报工厂不能为nil: %s", name)
+	}
+
+	errorReportersMu.Lock()
+	defer errorReportersMu.Unlock()
+	errorReporters[name] = factory
+	return nil
+}
+
+// UnregisterErrorReporter 注销错误上报工厂
+func UnregisterErrorReporter(name string) {
+	errorReportersMu.Lock()
+	defer errorReportersMu.Unlock()
+	delete(errorReporters, name)
+}
+
+// errorReporterHooks 按名称顺序调用已注册的错误上报工厂，返回启用的钩子
+func errorReporterHooks(cfg *config.Config) ([]levelHook, error) {
+	errorReportersMu.RLock()
+	names := make([]string, 0, len(errorReporters))
+	for name := range errorReporters {
+		names = append(names, name)
+	}
+	factories := make([]ErrorReporterFactory, 0, len(names))
+	sort.Strings(names)
+	for _, name := range names {
+		factories = append(factories, errorReporters[name])
+	}
+	errorReportersMu.RUnlock()
+
+	var hooks []levelHook
+	for i, factory := range factories {
+		hook, enabler, err := factory(cfg)
+		if err != nil {
+			return nil, fmt.Errorf("创建错误上报 %s 失败: %w", names[i], err)
+		}
+		if hook == nil {
+			continue
+		}
+		if enabler == nil {
+			enabler = ErrorLevel
+		}
+		hooks = append(hooks, levelHook{hook: hook, enabler: enabler})
+	}
+	return hooks, nil
+}
@@ -0,0 +1,17 @@
+package logger
+
+import "context"
+
+// RunWith 执行 fn，传给 fn 的上下文中保存了 log，fn 的调用链中通过 Ctx 获取的Logger为 log
+//
+// 用于迁移没有传递Logger的旧代码：调用链只需传递 context，将全局日志函数 logger.Info(...)
+// 改为 logger.Ctx(ctx).Info(...)。上下文可以传给 fn 中启动的goroutine；嵌套调用时使用最内层的 log。
+// log 与默认Logger一样附加 SetGlobalFields 设置的全局字段
+func RunWith(ctx context.Context, log Logger, fn func(ctx context.Context)) {
+	fn(NewContext(ctx, log.WithOptions(withGlobalFields())))
+}
+
+// Ctx 返回上下文中保存的Logger，没有时返回默认Logger
+func Ctx(ctx context.Context) Logger {
+	return contextLogger(ctx)
+}
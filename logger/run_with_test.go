@@ -0,0 +1,69 @@
+package logger
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// 测试 RunWith 的调用链中通过 Ctx 获取指定的Logger，全局日志函数不受影响
+func TestRunWith(t *testing.T) {
+	originalStd := DefaultLogger()
+	defer SetDefault(originalStd)
+
+	defaultLog, defaultBuf := newBufferLogger(InfoLevel)
+	SetDefault(defaultLog)
+	pinned, pinnedBuf := newBufferLogger(DebugLevel)
+	inner, innerBuf := newBufferLogger(InfoLevel)
+
+	RunWith(context.Background(), pinned.With(String("job", "legacy")), func(ctx context.Context) {
+		Ctx(ctx).Debug("pinned debug")
+		Ctx(ctx).With(String("k", "v")).Info("pinned with")
+		Info("global")
+
+		RunWith(ctx, inner, func(ctx context.Context) {
+			Ctx(ctx).Info("inner")
+		})
+		Ctx(ctx).Info("restored")
+
+		// 上下文传给其他goroutine后仍使用指定的Logger
+		var wg sync.WaitGroup
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			Ctx(ctx).Info("other goroutine")
+		}()
+		wg.Wait()
+	})
+	Ctx(context.Background()).Info("after")
+
+	entry := findLogEntry(t, pinnedBuf.String(), "pinned debug")
+	require.NotNil(t, entry)
+	assert.Equal(t, "legacy", entry["job"])
+	assert.NotNil(t, findLogEntry(t, pinnedBuf.String(), "pinned with"))
+	assert.NotNil(t, findLogEntry(t, pinnedBuf.String(), "restored"))
+	assert.NotNil(t, findLogEntry(t, pinnedBuf.String(), "other goroutine"))
+	assert.NotNil(t, findLogEntry(t, innerBuf.String(), "inner"))
+	assert.NotContains(t, pinnedBuf.String(), "inner")
+
+	assert.NotNil(t, findLogEntry(t, defaultBuf.String(), "global"))
+	assert.NotNil(t, findLogEntry(t, defaultBuf.String(), "after"))
+	assert.NotContains(t, defaultBuf.String(), "pinned")
+}
+
+// 测试 RunWith 指定的Logger附加全局字段
+func TestRunWithGlobalFields(t *testing.T) {
+	SetGlobalFields(String("region", "cn"))
+	defer SetGlobalFields()
+
+	pinned, buf := newBufferLogger(InfoLevel)
+	RunWith(context.Background(), pinned, func(ctx context.Context) {
+		Ctx(ctx).Info("with global")
+	})
+	entry := findLogEntry(t, buf.String(), "with global")
+	require.NotNil(t, entry)
+	assert.Equal(t, "cn", entry["region"])
+}
@@ -0,0 +1,103 @@
+package logger
+
+import (
+	"bufio"
+	"io"
+	"os"
+	"regexp"
+	"runtime"
+	"runtime/debug"
+	"sync"
+
+	"github.com/constructorvirgil/virlog/config"
+)
+
+var (
+	// cgroupContainerID cgroup路径中的容器ID，如Docker的 /docker/<id>、containerd的 cri-containerd-<id>.scope
+	cgroupContainerID = regexp.MustCompile(`([0-9a-f]{64})`)
+	// mountinfoContainerID cgroup v2下从容器运行时挂载的 /containers/<id>/hostname 等文件中识别容器ID，
+	// 不匹配其他64位十六进制串，避免误识别overlay文件系统的镜像层ID
+	mountinfoContainerID = regexp.MustCompile(`/containers/([0-9a-f]{64})/`)
+)
+
+// processInfo 进程信息，进程运行期间不变，首次使用时读取
+var processInfo = sync.OnceValue(func() processInfoValues {
+	info := processInfoValues{
+		pid:       os.Getpid(),
+		goVersion: runtime.Version(),
+	}
+	info.hostname, _ = os.Hostname()
+	if build, ok := debug.ReadBuildInfo(); ok {
+		if build.Main.Version != "" && build.Main.Version != "(devel)" {
+			info.buildVersion = build.Main.Version
+		}
+		for _, setting := range build.Settings {
+			if setting.Key == "vcs.revision" {
+				info.vcsRevision = setting.Value
+			}
+		}
+	}
+	if f, err := os.Open("/proc/self/cgroup"); err == nil {
+		info.containerID = findContainerID(f, cgroupContainerID)
+		f.Close()
+	}
+	if info.containerID == "" {
+		if f, err := os.Open("/proc/self/mountinfo"); err == nil {
+			info.containerID = findContainerID(f, mountinfoContainerID)
+			f.Close()
+		}
+	}
+	return info
+})
+
+// processInfoValues 附加到日志的进程信息
+type processInfoValues struct {
+	hostname     string
+	pid          int
+	goVersion    string
+	buildVersion string
+	vcsRevision  string
+	containerID  string
+}
+
+// runtimeFields 返回配置中开启的进程信息字段，每次返回新的切片
+func runtimeFields(cfg *config.RuntimeFieldsConfig) []Field {
+	if cfg == nil {
+		return nil
+	}
+	info := processInfo()
+	var fields []Field
+	if cfg.Hostname && info.hostname != "" {
+		fields = append(fields, String("hostname", info.hostname))
+	}
+	if cfg.PID {
+		fields = append(fields, Int("pid", info.pid))
+	}
+	if cfg.GoVersion {
+		fields = append(fields, String("go_version", info.goVersion))
+	}
+	if cfg.Build {
+		if info.buildVersion != "" {
+			fields = append(fields, String("build_version", info.buildVersion))
+		}
+		if info.vcsRevision != "" {
+			fields = append(fields, String("vcs_revision", info.vcsRevision))
+		}
+	}
+	if cfg.ContainerID && info.containerID != "" {
+		fields = append(fields, String("container_id", info.containerID))
+	}
+	return fields
+}
+
+// findContainerID 返回第一行匹配 pattern 的内容中的容器ID
+func findContainerID(r io.Reader, pattern *regexp.Regexp) string {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 4096), 1024*1024)
+	for scanner.Scan() {
+		if m := pattern.FindStringSubmatch(scanner.Text()); m != nil {
+			return m[1]
+		}
+	}
+	return ""
+}
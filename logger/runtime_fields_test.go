@@ -0,0 +1,59 @@
+package logger
+
+import (
+	"bytes"
+	"os"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/constructorvirgil/virlog/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zapcore"
+)
+
+// 测试按配置附加进程信息字段
+func TestRuntimeFields(t *testing.T) {
+	buf := &bytes.Buffer{}
+	cfg := config.DefaultConfig()
+	cfg.Format = "json"
+	cfg.RuntimeFields = &config.RuntimeFieldsConfig{Hostname: true, PID: true, GoVersion: true}
+	log, err := NewLogger(cfg, WithSyncTarget(zapcore.AddSync(buf)))
+	require.NoError(t, err)
+
+	log.Info("with runtime fields")
+	entry := findLogEntry(t, buf.String(), "with runtime fields")
+	require.NotNil(t, entry)
+	hostname, _ := os.Hostname()
+	assert.Equal(t, hostname, entry["hostname"])
+	assert.Equal(t, float64(os.Getpid()), entry["pid"])
+	assert.Equal(t, runtime.Version(), entry["go_version"])
+	assert.NotContains(t, entry, "container_id")
+
+	// 未开启时不附加
+	buf.Reset()
+	cfg.RuntimeFields = nil
+	log, err = NewLogger(cfg, WithSyncTarget(zapcore.AddSync(buf)))
+	require.NoError(t, err)
+	log.Info("without runtime fields")
+	entry = findLogEntry(t, buf.String(), "without runtime fields")
+	require.NotNil(t, entry)
+	assert.NotContains(t, entry, "pid")
+}
+
+// 测试从cgroup和mountinfo中识别容器ID
+func TestFindContainerID(t *testing.T) {
+	id := strings.Repeat("0123456789abcdef", 4)
+	layer := strings.Repeat("fedcba9876543210", 4)
+
+	cgroup := "12:memory:/docker/" + id + "\n11:cpu:/docker/" + id + "\n"
+	assert.Equal(t, id, findContainerID(strings.NewReader(cgroup), cgroupContainerID))
+	cgroup = "0::/system.slice/cri-containerd-" + id + ".scope\n"
+	assert.Equal(t, id, findContainerID(strings.NewReader(cgroup), cgroupContainerID))
+	assert.Empty(t, findContainerID(strings.NewReader("0::/\n"), cgroupContainerID))
+
+	mountinfo := "1 0 0:1 / / rw - overlay overlay rw,upperdir=/var/lib/docker/overlay2/" + layer + "/diff\n" +
+		"2 1 8:1 /var/lib/docker/containers/" + id + "/hostname /etc/hostname rw - ext4 /dev/sda1 rw\n"
+	assert.Equal(t, id, findContainerID(strings.NewReader(mountinfo), mountinfoContainerID))
+}
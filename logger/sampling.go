@@ -0,0 +1,79 @@
+package logger
+
+import (
+	"time"
+
+	"github.com/constructorvirgil/virlog/config"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// newSamplingCore 根据采样配置包装core
+//
+// 未覆盖的级别共用一个采样器，在LevelOverrides中出现的级别使用各自独立的采样器，
+// Disabled的级别直接写入底层core，不会被丢弃
+func newSamplingCore(core zapcore.Core, cfg *config.SamplingConfig) zapcore.Core {
+	if cfg == nil {
+		cfg = config.DefaultSamplingConfig()
+	}
+
+	tick := cfg.Tick
+	if tick <= 0 {
+		tick = time.Second
+	}
+
+	if len(cfg.LevelOverrides) == 0 {
+		return zapcore.NewSamplerWithOptions(core, tick, cfg.Initial, cfg.Thereafter)
+	}
+
+	sc := &levelSamplingCore{
+		Core:     core,
+		fallback: zapcore.NewSamplerWithOptions(core, tick, cfg.Initial, cfg.Thereafter),
+		levels:   make(map[zapcore.Level]zapcore.Core, len(cfg.LevelOverrides)),
+	}
+	for name, override := range cfg.LevelOverrides {
+		level, ok := parseLevel(name)
+		if !ok {
+			continue
+		}
+		if override.Disabled {
+			sc.levels[level] = core
+			continue
+		}
+		sc.levels[level] = zapcore.NewSamplerWithOptions(core, tick, override.Initial, override.Thereafter)
+	}
+	return sc
+}
+
+// levelSamplingCore 按日志级别分派到不同采样器的core
+type levelSamplingCore struct {
+	zapcore.Core
+	fallback zapcore.Core
+	levels   map[zapcore.Level]zapcore.Core
+}
+
+// coreFor 返回处理指定级别的core
+func (c *levelSamplingCore) coreFor(level zapcore.Level) zapcore.Core {
+	if core, ok := c.levels[level]; ok {
+		return core
+	}
+	return c.fallback
+}
+
+// With 实现zapcore.Core接口
+func (c *levelSamplingCore) With(fields []zapcore.Field) zapcore.Core {
+	levels := make(map[zapcore.Level]zapcore.Core, len(c.levels))
+	for level, core := range c.levels {
+		levels[level] = core.With(fields)
+	}
+	return &levelSamplingCore{
+		Core:     c.Core.With(fields),
+		fallback: c.fallback.With(fields),
+		levels:   levels,
+	}
+}
+
+// Check 实现zapcore.Core接口
+func (c *levelSamplingCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	return c.coreFor(ent.Level).Check(ent, ce)
+}
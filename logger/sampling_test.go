@@ -0,0 +1,63 @@
+package logger
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/constructorvirgil/virlog/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zapcore"
+)
+
+// 测试按级别覆盖采样参数
+func TestSamplingLevelOverrides(t *testing.T) {
+	buf := &bytes.Buffer{}
+
+	cfg := config.DefaultConfig()
+	cfg.Level = "debug"
+	cfg.EnableSampling = true
+	cfg.Sampling = &config.SamplingConfig{
+		Initial:    5,
+		Thereafter: 0,
+		Tick:       time.Minute,
+		LevelOverrides: map[string]config.LevelSamplingConfig{
+			"debug": {Initial: 1, Thereafter: 0},
+			"error": {Disabled: true},
+		},
+	}
+
+	log, err := NewLogger(cfg, WithSyncTarget(zapcore.AddSync(buf)))
+	require.NoError(t, err)
+
+	for i := 0; i < 10; i++ {
+		log.Debug("debug message")
+		log.Info("info message")
+		log.Error("error message")
+	}
+
+	output := buf.String()
+	assert.Equal(t, 1, strings.Count(output, "debug message"), "debug应按覆盖配置采样")
+	assert.Equal(t, 5, strings.Count(output, "info message"), "info应使用默认采样配置")
+	assert.Equal(t, 10, strings.Count(output, "error message"), "error不应被丢弃")
+}
+
+// 测试未启用采样时不丢弃日志
+func TestSamplingDisabled(t *testing.T) {
+	buf := &bytes.Buffer{}
+
+	cfg := config.DefaultConfig()
+	cfg.EnableSampling = false
+	cfg.Sampling = &config.SamplingConfig{Initial: 1, Thereafter: 0, Tick: time.Minute}
+
+	log, err := NewLogger(cfg, WithSyncTarget(zapcore.AddSync(buf)))
+	require.NoError(t, err)
+
+	for i := 0; i < 10; i++ {
+		log.Info("info message")
+	}
+
+	assert.Equal(t, 10, strings.Count(buf.String(), "info message"))
+}
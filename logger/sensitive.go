@@ -0,0 +1,165 @@
+package logger
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"reflect"
+	"sync"
+	"sync/atomic"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+const (
+	// sensitiveTag 标记敏感结构体字段的tag名称
+	sensitiveTag = "log"
+	// tagRedact 字段值替换为 ***
+	tagRedact = "redact"
+	// tagHash 字段值替换为HMAC-SHA256的前16位十六进制
+	tagHash = "hash"
+	// hashPrefix 摘要值的前缀
+	hashPrefix = "hmac:"
+	// hashBytes 输出的摘要字节数，截断为8字节（16位十六进制），足以关联日志中的同一个值
+	hashBytes = 8
+)
+
+var (
+	// sensitiveTypes 缓存类型中是否包含敏感字段，值为bool
+	sensitiveTypes sync.Map
+	// hashKey 计算 log:"hash" 字段摘要的HMAC密钥
+	hashKey atomic.Pointer[[]byte]
+)
+
+func init() {
+	key := make([]byte, 32)
+	_, _ = rand.Read(key)
+	hashKey.Store(&key)
+}
+
+// SetHashKey 设置 log:"hash" 字段计算HMAC-SHA256使用的密钥，key 为空时不修改，也可以通过配置 HashKey 设置
+//
+// 未设置时使用进程启动时生成的随机密钥，同一进程内相同的值摘要相同，不同进程之间不同；
+// 需要跨进程或跨重启关联同一个值时，各进程设置相同的密钥。密钥泄露后可以通过穷举还原手机号等取值范围有限的值
+func SetHashKey(key []byte) {
+	if len(key) == 0 {
+		return
+	}
+	key = append([]byte(nil), key...)
+	hashKey.Store(&key)
+}
+
+// Any 根据值的类型选择字段类型，同 zap.Any
+//
+// 值（或其嵌套的结构体、切片和map）包含 log tag 标记的敏感字段时，按 Object 的规则展开并脱敏：
+//
+//	type User struct {
+//		Name     string `json:"name"`
+//		Password string `json:"password" log:"redact"` // 输出 ***
+//		Phone    string `json:"phone" log:"hash"`      // 输出 hmac:<前16位十六进制>
+//	}
+func Any(key string, v any) Field {
+	if v == nil {
+		return zap.Any(key, v)
+	}
+	if _, ok := v.(zapcore.ObjectMarshaler); ok {
+		return zap.Any(key, v)
+	}
+	rv := reflect.ValueOf(v)
+	if !hasSensitiveFields(rv.Type()) {
+		return zap.Any(key, v)
+	}
+	inner := indirect(rv)
+	if !inner.IsValid() {
+		return zap.Any(key, v)
+	}
+	switch inner.Kind() {
+	case reflect.Struct, reflect.Map:
+		return Object(key, v)
+	case reflect.Slice, reflect.Array:
+		return zap.Array(key, reflectArray{v: inner})
+	}
+	return zap.Any(key, v)
+}
+
+// sensitiveMode 返回结构体字段 log tag 标记的脱敏方式，未标记时返回空字符串
+func sensitiveMode(sf reflect.StructField) string {
+	switch tag := sf.Tag.Get(sensitiveTag); tag {
+	case tagRedact, tagHash:
+		return tag
+	}
+	return ""
+}
+
+// hasSensitiveFields 判断类型中是否包含敏感字段，自定义了编码的类型不通过反射展开，不检查其字段
+func hasSensitiveFields(t reflect.Type) bool {
+	if cached, ok := sensitiveTypes.Load(t); ok {
+		return cached.(bool)
+	}
+	result := containsSensitive(t, map[reflect.Type]bool{})
+	sensitiveTypes.Store(t, result)
+	return result
+}
+
+// containsSensitive 递归检查类型，visited 避免循环引用的类型无限递归
+func containsSensitive(t reflect.Type, visited map[reflect.Type]bool) bool {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if visited[t] || t == timeType || hasCustomEncoding(t) {
+		return false
+	}
+	visited[t] = true
+
+	switch t.Kind() {
+	case reflect.Struct:
+		for i := 0; i < t.NumField(); i++ {
+			sf := t.Field(i)
+			if _, _, skip := jsonFieldName(sf); skip {
+				continue
+			}
+			if sensitiveMode(sf) != "" || containsSensitive(sf.Type, visited) {
+				return true
+			}
+		}
+	case reflect.Slice, reflect.Array, reflect.Map:
+		return containsSensitive(t.Elem(), visited)
+	}
+	return false
+}
+
+// encodeSensitive 按脱敏方式编码字段值，nil指针和接口输出null
+func encodeSensitive(enc zapcore.ObjectEncoder, key, mode string, v reflect.Value) error {
+	v = indirect(v)
+	if !v.IsValid() {
+		return enc.AddReflected(key, nil)
+	}
+	if mode == tagHash {
+		enc.AddString(key, hashValue(v))
+		return nil
+	}
+	enc.AddString(key, redactedValue)
+	return nil
+}
+
+// hashValue 返回值以 hashKey 为密钥的HMAC-SHA256，截断为 hashBytes 字节，
+// 字符串和字节切片按原始内容计算，其他类型按其字符串形式计算
+func hashValue(v reflect.Value) string {
+	var data []byte
+	switch {
+	case v.Kind() == reflect.String:
+		data = []byte(v.String())
+	case v.Kind() == reflect.Slice && v.Type().Elem().Kind() == reflect.Uint8:
+		data = v.Bytes()
+	case v.CanInterface():
+		data = []byte(fmt.Sprint(v.Interface()))
+	default:
+		data = []byte(fmt.Sprint(v))
+	}
+	mac := hmac.New(sha256.New, *hashKey.Load())
+	mac.Write(data)
+	return hashPrefix + hex.EncodeToString(mac.Sum(nil)[:hashBytes])
+}
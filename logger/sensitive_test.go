@@ -0,0 +1,129 @@
+package logger
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/constructorvirgil/virlog/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type sensitiveCard struct {
+	Number string `json:"number" log:"redact"`
+	Bank   string `json:"bank"`
+}
+
+type sensitiveUser struct {
+	Name     string          `json:"name"`
+	Password string          `json:"password" log:"redact"`
+	Phone    string          `json:"phone" log:"hash"`
+	Token    *string         `json:"token" log:"redact"`
+	Email    string          `json:"email,omitempty" log:"hash"`
+	Cards    []sensitiveCard `json:"cards"`
+	Note     string          `json:"note" log:"unknown"`
+}
+
+type plainUser struct {
+	Name string `json:"name"`
+}
+
+// 测试Object按log tag脱敏
+func TestObjectSensitiveTags(t *testing.T) {
+	setTestHashKey(t, []byte("test-key"))
+	log, buf := newBufferLogger(InfoLevel)
+	user := sensitiveUser{
+		Name:     "alice",
+		Password: "p@ss",
+		Phone:    "13800000000",
+		Cards:    []sensitiveCard{{Number: "6222000011112222", Bank: "ICBC"}},
+		Note:     "vip",
+	}
+	log.Info("object", Object("user", user))
+
+	entry := findLogEntry(t, buf.String(), "object")
+	require.NotNil(t, entry)
+	obj := entry["user"].(map[string]interface{})
+	assert.Equal(t, "alice", obj["name"])
+	assert.Equal(t, redactedValue, obj["password"])
+	assert.Equal(t, hashPrefix+"7bdaca6d2b065054", obj["phone"])
+	assert.Nil(t, obj["token"], "nil指针输出null")
+	assert.NotContains(t, obj, "email", "omitempty优先")
+	assert.Equal(t, "vip", obj["note"], "未知的tag值不脱敏")
+	card := obj["cards"].([]interface{})[0].(map[string]interface{})
+	assert.Equal(t, redactedValue, card["number"])
+	assert.Equal(t, "ICBC", card["bank"])
+	assert.NotContains(t, buf.String(), "p@ss")
+	assert.NotContains(t, buf.String(), "13800000000")
+	assert.NotContains(t, buf.String(), "6222000011112222")
+}
+
+// 测试Any对包含敏感字段的值脱敏，其他值保持zap.Any的行为
+func TestAnySensitiveTags(t *testing.T) {
+	log, buf := newBufferLogger(InfoLevel)
+	token := "secret-token"
+	log.Info("any",
+		Any("user", &sensitiveUser{Name: "bob", Token: &token}),
+		Any("users", []sensitiveUser{{Name: "carol", Password: "123"}}),
+		Any("byName", map[string]sensitiveCard{"main": {Number: "1234", Bank: "BOC"}}),
+		Any("plain", plainUser{Name: "dave"}),
+		Any("nilUser", (*sensitiveUser)(nil)),
+		Any("count", 3))
+
+	output := buf.String()
+	assert.NotContains(t, output, "secret-token")
+	assert.NotContains(t, output, `"123"`)
+	assert.NotContains(t, output, "1234")
+
+	entry := findLogEntry(t, output, "any")
+	require.NotNil(t, entry)
+	assert.Equal(t, redactedValue, entry["user"].(map[string]interface{})["token"])
+	users := entry["users"].([]interface{})
+	assert.Equal(t, "carol", users[0].(map[string]interface{})["name"])
+	assert.Equal(t, redactedValue, entry["byName"].(map[string]interface{})["main"].(map[string]interface{})["number"])
+	assert.Equal(t, map[string]interface{}{"name": "dave"}, entry["plain"])
+	assert.Nil(t, entry["nilUser"])
+	assert.Equal(t, float64(3), entry["count"])
+}
+
+// 测试敏感字段检查的缓存和循环引用
+func TestHasSensitiveFields(t *testing.T) {
+	type node struct {
+		Next   *node  `json:"next"`
+		Secret string `json:"secret" log:"hash"`
+	}
+	type ignored struct {
+		Secret string `json:"-" log:"redact"`
+	}
+	assert.True(t, hasSensitiveFields(reflect.TypeOf(node{})))
+	assert.True(t, hasSensitiveFields(reflect.TypeOf([]*node{})))
+	assert.False(t, hasSensitiveFields(reflect.TypeOf(plainUser{})))
+	assert.False(t, hasSensitiveFields(reflect.TypeOf(ignored{})))
+	assert.True(t, strings.HasPrefix(hashValue(reflect.ValueOf(42)), hashPrefix))
+}
+
+// setTestHashKey 设置摘要密钥，测试结束后恢复
+func setTestHashKey(t *testing.T, key []byte) {
+	original := hashKey.Load()
+	t.Cleanup(func() { hashKey.Store(original) })
+	SetHashKey(key)
+}
+
+// 测试摘要取决于密钥，可以通过配置设置密钥
+func TestHashKey(t *testing.T) {
+	setTestHashKey(t, []byte("key-a"))
+	phone := reflect.ValueOf("13800000000")
+	first := hashValue(phone)
+	assert.Len(t, first, len(hashPrefix)+2*hashBytes)
+	assert.Equal(t, first, hashValue(phone), "相同密钥的摘要相同")
+
+	SetHashKey(nil)
+	assert.Equal(t, first, hashValue(phone), "空密钥不修改")
+
+	cfg := config.DefaultConfig()
+	cfg.HashKey = "key-b"
+	_, err := NewLogger(cfg)
+	require.NoError(t, err)
+	assert.NotEqual(t, first, hashValue(phone), "不同密钥的摘要不同")
+}
@@ -0,0 +1,124 @@
+package logger
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// fatalShutdownTimeout Fatal 退出前等待 Shutdown 完成的最长时间
+const fatalShutdownTimeout = 5 * time.Second
+
+// exit 结束进程，测试中替换
+var exit = os.Exit
+
+// ShutdownHook 程序退出前执行的关闭钩子，应在 ctx 结束前返回
+type ShutdownHook func(ctx context.Context) error
+
+var (
+	// shutdownHooks 通过 RegisterShutdownHook 注册的关闭钩子
+	shutdownHooks []namedShutdownHook
+	// shutdownMu 保护 shutdownHooks 和 shutdownRun
+	shutdownMu sync.Mutex
+	// shutdownRun 正在进行或已完成的关闭，保证只执行一次
+	shutdownRun *shutdownOnce
+)
+
+// namedShutdownHook 关闭钩子及其名称
+type namedShutdownHook struct {
+	name string
+	hook ShutdownHook
+}
+
+// shutdownOnce 一次关闭的执行结果
+type shutdownOnce struct {
+	done chan struct{}
+	err  error
+}
+
+// RegisterShutdownHook 注册 Shutdown 时执行的关闭钩子，后注册的先执行
+//
+// 适用于关闭批量写入的输出（如 columnar.Sink、remote.Client）或自行创建的Logger：
+//
+//	logger.RegisterShutdownHook("remote", func(ctx context.Context) error { return client.Close() })
+func RegisterShutdownHook(name string, hook ShutdownHook) {
+	if hook == nil {
+		return
+	}
+	shutdownMu.Lock()
+	defer shutdownMu.Unlock()
+	shutdownHooks = append(shutdownHooks, namedShutdownHook{name: name, hook: hook})
+}
+
+// Shutdown 在程序退出前调用，依次执行关闭钩子并刷新默认Logger的缓冲区和网络输出，ctx 结束时返回 ctx.Err()
+//
+// 可以多次调用，只有第一次执行，之后的调用等待其完成并返回相同的结果。Fatal 在退出前自动调用
+func Shutdown(ctx context.Context) error {
+	shutdownMu.Lock()
+	run := shutdownRun
+	if run == nil {
+		run = &shutdownOnce{done: make(chan struct{})}
+		shutdownRun = run
+		hooks := append([]namedShutdownHook(nil), shutdownHooks...)
+		go func() {
+			run.err = runShutdown(ctx, hooks)
+			close(run.done)
+		}()
+	}
+	shutdownMu.Unlock()
+
+	select {
+	case <-run.done:
+		return run.err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// runShutdown 按注册的相反顺序执行关闭钩子，钩子中输出的日志随后一并刷新
+func runShutdown(ctx context.Context, hooks []namedShutdownHook) error {
+	var errs []error
+	for i := len(hooks) - 1; i >= 0; i-- {
+		if err := hooks[i].hook(ctx); err != nil {
+			errs = append(errs, fmt.Errorf("关闭钩子 %s 失败: %w", hooks[i].name, err))
+		}
+	}
+	if err := syncContext(ctx, DefaultLogger().Sync); err != nil {
+		errs = append(errs, fmt.Errorf("刷新日志失败: %w", err))
+	}
+	return errors.Join(errs...)
+}
+
+// syncContext 执行 sync，ctx 先结束时返回 ctx.Err()
+func syncContext(ctx context.Context, sync func() error) error {
+	result := make(chan error, 1)
+	go func() {
+		result <- sync()
+	}()
+	select {
+	case err := <-result:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// fatalHook Fatal 级别的日志写入后刷新输出、执行 Shutdown，再结束进程
+type fatalHook struct {
+	log *zapLogger
+}
+
+// OnWrite 实现zapcore.CheckWriteHook接口
+func (h fatalHook) OnWrite(*zapcore.CheckedEntry, []Field) {
+	ctx, cancel := context.WithTimeout(context.Background(), fatalShutdownTimeout)
+	// 输出Fatal日志的Logger可能不是默认Logger，先刷新它自己的网络输出
+	_ = syncContext(ctx, h.log.rawZapLogger.Sync)
+	_ = Shutdown(ctx)
+	cancel()
+	exit(1)
+}
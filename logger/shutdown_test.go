@@ -0,0 +1,117 @@
+package logger
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/constructorvirgil/virlog/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zapcore"
+)
+
+// resetShutdown 清空关闭钩子和关闭状态
+func resetShutdown(t *testing.T) {
+	reset := func() {
+		shutdownMu.Lock()
+		shutdownHooks = nil
+		shutdownRun = nil
+		shutdownMu.Unlock()
+	}
+	reset()
+	t.Cleanup(reset)
+}
+
+// 测试关闭钩子按注册的相反顺序只执行一次
+func TestShutdown(t *testing.T) {
+	resetShutdown(t)
+
+	var order []string
+	RegisterShutdownHook("first", func(ctx context.Context) error {
+		order = append(order, "first")
+		return nil
+	})
+	RegisterShutdownHook("second", func(ctx context.Context) error {
+		order = append(order, "second")
+		return errors.New("boom")
+	})
+
+	err := Shutdown(context.Background())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "second")
+	assert.Equal(t, []string{"second", "first"}, order)
+
+	// 再次调用不重复执行，返回相同的结果
+	assert.Equal(t, err, Shutdown(context.Background()))
+	assert.Equal(t, []string{"second", "first"}, order)
+}
+
+// 测试标准输出是管道时刷新日志不返回错误
+func TestShutdownStdoutPipe(t *testing.T) {
+	resetShutdown(t)
+
+	r, w, err := os.Pipe()
+	require.NoError(t, err)
+	defer r.Close()
+	defer w.Close()
+	require.Error(t, w.Sync(), "管道不支持fsync")
+
+	stdout := os.Stdout
+	os.Stdout = w
+	defer func() { os.Stdout = stdout }()
+
+	cfg := config.DefaultConfig()
+	cfg.Output = "stdout"
+	log, err := NewLogger(cfg)
+	require.NoError(t, err)
+	old := DefaultLogger()
+	SetDefault(log)
+	defer SetDefault(old)
+
+	assert.NoError(t, Shutdown(context.Background()))
+}
+
+// 测试钩子超过截止时间时返回
+func TestShutdownDeadline(t *testing.T) {
+	resetShutdown(t)
+
+	release := make(chan struct{})
+	defer close(release)
+	RegisterShutdownHook("slow", func(ctx context.Context) error {
+		<-release
+		return nil
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, Shutdown(ctx), context.DeadlineExceeded)
+}
+
+// 测试Fatal在退出前执行Shutdown
+func TestFatalRunsShutdown(t *testing.T) {
+	resetShutdown(t)
+	code := -1
+	exit = func(c int) { code = c }
+	defer func() { exit = os.Exit }()
+
+	var hooked bool
+	RegisterShutdownHook("flush", func(ctx context.Context) error {
+		hooked = true
+		return nil
+	})
+
+	buf := &bytes.Buffer{}
+	cfg := config.DefaultConfig()
+	cfg.Format = "json"
+	log, err := NewLogger(cfg, WithSyncTarget(zapcore.AddSync(buf)))
+	require.NoError(t, err)
+
+	log.Fatal("fatal error")
+	assert.Equal(t, 1, code)
+	assert.True(t, hooked)
+	assert.Contains(t, buf.String(), "fatal error")
+}
@@ -0,0 +1,56 @@
+package logger
+
+import (
+	"fmt"
+	"sync"
+
+	"go.uber.org/zap/zapcore"
+)
+
+const (
+	// sinkOutputName 引用已注册输出目标的输出名称
+	sinkOutputName = "sink"
+	// sinkOutputPrefix 配置中引用已注册输出目标的前缀，如 Output: "sink:mybuf"
+	sinkOutputPrefix = sinkOutputName + ":"
+)
+
+var (
+	// 已注册的输出目标
+	sinks   = make(map[string]zapcore.WriteSyncer)
+	sinksMu sync.RWMutex
+)
+
+// RegisterSink 注册一个命名的输出目标，之后可以在配置中通过 Output: "sink:<name>" 使用
+//
+// 重复注册同名目标会覆盖之前的注册
+func RegisterSink(name string, ws zapcore.WriteSyncer) error {
+	if name == "" {
+		return fmt.Errorf("输出目标名称不能为空")
+	}
+	if ws == nil {
+		return fmt.Errorf("输出目标不能为nil: %s", name)
+	}
+
+	sinksMu.Lock()
+	defer sinksMu.Unlock()
+	sinks[name] = ws
+	return nil
+}
+
+// UnregisterSink 注销命名的输出目标
+func UnregisterSink(name string) {
+	sinksMu.Lock()
+	defer sinksMu.Unlock()
+	delete(sinks, name)
+}
+
+// lookupSink 查找已注册的输出目标
+func lookupSink(name string) (zapcore.WriteSyncer, error) {
+	sinksMu.RLock()
+	defer sinksMu.RUnlock()
+	ws, ok := sinks[name]
+	if !ok {
+		return nil, fmt.Errorf("未注册的输出目标: %s", name)
+	}
+	return ws, nil
+}
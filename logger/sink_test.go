@@ -0,0 +1,44 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/constructorvirgil/virlog/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zapcore"
+)
+
+// 测试通过配置使用已注册的输出目标
+func TestRegisterSink(t *testing.T) {
+	buf := &bytes.Buffer{}
+	require.NoError(t, RegisterSink("test-buf", zapcore.AddSync(buf)))
+	defer UnregisterSink("test-buf")
+
+	cfg := config.DefaultConfig()
+	cfg.Output = "sink:test-buf"
+
+	log, err := NewLogger(cfg)
+	require.NoError(t, err)
+
+	log.Info("sink message", String("key", "value"))
+
+	logData := make(map[string]interface{})
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &logData))
+	assert.Equal(t, "sink message", logData["msg"])
+	assert.Equal(t, "value", logData["key"])
+}
+
+// 测试未注册的输出目标
+func TestUnregisteredSink(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.Output = "sink:missing"
+
+	_, err := NewLogger(cfg)
+	assert.Error(t, err)
+
+	assert.Error(t, RegisterSink("", zapcore.AddSync(&bytes.Buffer{})))
+	assert.Error(t, RegisterSink("nil", nil))
+}
@@ -0,0 +1,235 @@
+package columnar
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// defaultBigQueryEndpoint BigQuery REST API的默认地址
+const defaultBigQueryEndpoint = "https://bigquery.googleapis.com"
+
+// BigQueryOptions BigQuery的连接配置，通过流式插入（tabledata.insertAll）写入
+type BigQueryOptions struct {
+	// 项目ID
+	Project string
+	// 数据集
+	Dataset string
+	// 表名
+	Table string
+	// 返回OAuth2访问令牌，每次请求前调用，可使用 golang.org/x/oauth2/google 的TokenSource实现
+	TokenSource func(ctx context.Context) (string, error)
+	// 是否自动建表和添加列，表按 timestamp 按天分区
+	CreateTable bool
+	// REST API地址，默认为 https://bigquery.googleapis.com
+	Endpoint string
+	// 发送请求使用的HTTP客户端，默认超时30秒
+	HTTPClient *http.Client
+}
+
+// bigQuery 通过REST API写入BigQuery
+type bigQuery struct {
+	opts   BigQueryOptions
+	client *http.Client
+}
+
+// BigQuery 创建写入BigQuery的 Inserter
+//
+// BigQuery没有无符号整数类型，TypeUint 的列使用 NUMERIC 类型
+func BigQuery(opts BigQueryOptions) (Inserter, error) {
+	if opts.Project == "" || opts.Dataset == "" || opts.Table == "" {
+		return nil, fmt.Errorf("BigQuery的项目、数据集和表名不能为空")
+	}
+	if opts.TokenSource == nil {
+		return nil, fmt.Errorf("BigQuery的TokenSource不能为nil")
+	}
+	if opts.Endpoint == "" {
+		opts.Endpoint = defaultBigQueryEndpoint
+	}
+	client := opts.HTTPClient
+	if client == nil {
+		client = &http.Client{Timeout: defaultTimeout}
+	}
+	return &bigQuery{opts: opts, client: client}, nil
+}
+
+// bigQueryField 表结构中的字段
+type bigQueryField struct {
+	Name string `json:"name"`
+	Type string `json:"type"`
+	Mode string `json:"mode,omitempty"`
+}
+
+// bigQueryTable 表资源中用到的部分
+type bigQueryTable struct {
+	TableReference map[string]string `json:"tableReference,omitempty"`
+	Schema         struct {
+		Fields []bigQueryField `json:"fields"`
+	} `json:"schema"`
+	TimePartitioning map[string]string `json:"timePartitioning,omitempty"`
+}
+
+// EnsureColumns 实现 Inserter 接口，表不存在时建表，否则为表补充缺少的列，未开启 CreateTable 时不修改表结构
+func (b *bigQuery) EnsureColumns(ctx context.Context, columns []Column) error {
+	if !b.opts.CreateTable {
+		return nil
+	}
+
+	var table bigQueryTable
+	status, err := b.do(ctx, http.MethodGet, b.tableURL(), nil, &table)
+	if status == http.StatusNotFound {
+		table = bigQueryTable{
+			TableReference: map[string]string{
+				"projectId": b.opts.Project,
+				"datasetId": b.opts.Dataset,
+				"tableId":   b.opts.Table,
+			},
+			TimePartitioning: map[string]string{"type": "DAY", "field": ColumnTimestamp},
+		}
+		for _, col := range columns {
+			table.Schema.Fields = append(table.Schema.Fields, bigQueryColumn(col))
+		}
+		_, err = b.do(ctx, http.MethodPost, b.datasetURL()+"/tables", table, nil)
+		return err
+	}
+	if err != nil {
+		return err
+	}
+
+	existing := make(map[string]struct{}, len(table.Schema.Fields))
+	for _, f := range table.Schema.Fields {
+		existing[f.Name] = struct{}{}
+	}
+	fields := table.Schema.Fields
+	for _, col := range columns {
+		if _, ok := existing[col.Name]; !ok {
+			field := bigQueryColumn(col)
+			// 只能为已有的表添加可以为NULL的列
+			field.Mode = "NULLABLE"
+			fields = append(fields, field)
+		}
+	}
+	if len(fields) == len(table.Schema.Fields) {
+		return nil
+	}
+	var patch bigQueryTable
+	patch.Schema.Fields = fields
+	_, err = b.do(ctx, http.MethodPatch, b.tableURL(), patch, nil)
+	return err
+}
+
+// Insert 实现 Inserter 接口
+func (b *bigQuery) Insert(ctx context.Context, rows []Row) error {
+	type insertRow struct {
+		JSON Row `json:"json"`
+	}
+	req := struct {
+		Rows []insertRow `json:"rows"`
+		// 表中缺少的列（如未开启 CreateTable 时的新字段）直接忽略
+		IgnoreUnknownValues bool `json:"ignoreUnknownValues"`
+	}{IgnoreUnknownValues: true}
+	for _, row := range rows {
+		req.Rows = append(req.Rows, insertRow{JSON: row})
+	}
+
+	var resp struct {
+		InsertErrors []struct {
+			Index  int `json:"index"`
+			Errors []struct {
+				Reason  string `json:"reason"`
+				Message string `json:"message"`
+			} `json:"errors"`
+		} `json:"insertErrors"`
+	}
+	if _, err := b.do(ctx, http.MethodPost, b.tableURL()+"/insertAll", req, &resp); err != nil {
+		return err
+	}
+	if len(resp.InsertErrors) > 0 {
+		first := resp.InsertErrors[0]
+		msg := "unknown error"
+		if len(first.Errors) > 0 {
+			msg = first.Errors[0].Reason + ": " + first.Errors[0].Message
+		}
+		return fmt.Errorf("BigQuery拒绝了%d行，第%d行: %s", len(resp.InsertErrors), first.Index, msg)
+	}
+	return nil
+}
+
+// datasetURL 返回数据集的资源地址
+func (b *bigQuery) datasetURL() string {
+	return strings.TrimSuffix(b.opts.Endpoint, "/") + "/bigquery/v2/projects/" + url.PathEscape(b.opts.Project) +
+		"/datasets/" + url.PathEscape(b.opts.Dataset)
+}
+
+// tableURL 返回表的资源地址
+func (b *bigQuery) tableURL() string {
+	return b.datasetURL() + "/tables/" + url.PathEscape(b.opts.Table)
+}
+
+// do 发送请求，in 不为nil时以JSON作为请求体，out 不为nil时解析响应；返回响应状态码
+func (b *bigQuery) do(ctx context.Context, method, u string, in, out interface{}) (int, error) {
+	var body io.Reader
+	if in != nil {
+		data, err := json.Marshal(in)
+		if err != nil {
+			return 0, fmt.Errorf("序列化BigQuery请求失败: %w", err)
+		}
+		body = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, u, body)
+	if err != nil {
+		return 0, fmt.Errorf("创建BigQuery请求失败: %w", err)
+	}
+	token, err := b.opts.TokenSource(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("获取BigQuery访问令牌失败: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := b.client.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("请求BigQuery失败: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		return resp.StatusCode, fmt.Errorf("BigQuery返回错误: %s: %s", resp.Status, strings.TrimSpace(string(msg)))
+	}
+	if out != nil {
+		if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+			return resp.StatusCode, fmt.Errorf("解析BigQuery响应失败: %w", err)
+		}
+	}
+	return resp.StatusCode, nil
+}
+
+// bigQueryColumn 返回列对应的BigQuery字段，除 timestamp 外的列可以为NULL
+func bigQueryColumn(col Column) bigQueryField {
+	field := bigQueryField{Name: col.Name, Mode: "NULLABLE"}
+	switch col.Type {
+	case TypeInt:
+		field.Type = "INT64"
+	case TypeUint:
+		field.Type = "NUMERIC"
+	case TypeFloat:
+		field.Type = "FLOAT64"
+	case TypeBool:
+		field.Type = "BOOL"
+	case TypeTime:
+		field.Type = "TIMESTAMP"
+	default:
+		field.Type = "STRING"
+	}
+	if col.Name == ColumnTimestamp {
+		field.Mode = "REQUIRED"
+	}
+	return field
+}
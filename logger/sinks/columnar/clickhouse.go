@@ -0,0 +1,187 @@
+package columnar
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/constructorvirgil/virlog/config"
+	"github.com/constructorvirgil/virlog/internal/compress"
+)
+
+// ClickHouseOptions ClickHouse的连接配置，通过HTTP接口写入
+type ClickHouseOptions struct {
+	// HTTP接口地址，如 http://localhost:8123
+	URL string
+	// 数据库名称，默认为 default
+	Database string
+	// 表名
+	Table string
+	// 用户名和密码
+	User     string
+	Password string
+	// 是否自动建表和添加列，表使用MergeTree引擎并按 timestamp 排序
+	CreateTable bool
+	// 发送请求使用的HTTP客户端，默认超时30秒
+	HTTPClient *http.Client
+	// INSERT 请求体的压缩配置，支持 gzip 和 zstd
+	Compression config.CompressionConfig
+}
+
+// clickHouse 通过HTTP接口写入ClickHouse
+type clickHouse struct {
+	opts       ClickHouseOptions
+	table      string
+	client     *http.Client
+	compressor *compress.Compressor
+}
+
+// ClickHouse 创建写入ClickHouse的 Inserter，行以 JSONEachRow 格式写入
+func ClickHouse(opts ClickHouseOptions) (Inserter, error) {
+	if opts.URL == "" {
+		return nil, fmt.Errorf("ClickHouse地址不能为空")
+	}
+	if _, err := url.Parse(opts.URL); err != nil {
+		return nil, fmt.Errorf("无效的ClickHouse地址: %w", err)
+	}
+	if opts.Table == "" {
+		return nil, fmt.Errorf("ClickHouse表名不能为空")
+	}
+	if opts.Database == "" {
+		opts.Database = "default"
+	}
+	client := opts.HTTPClient
+	if client == nil {
+		client = &http.Client{Timeout: defaultTimeout}
+	}
+	cc := opts.Compression
+	compressor, err := compress.New(cc.Algorithm, cc.Level, cc.MinBytes, compress.Gzip, compress.Zstd)
+	if err != nil {
+		return nil, fmt.Errorf("ClickHouse压缩配置: %w", err)
+	}
+	return &clickHouse{
+		opts:       opts,
+		table:      quoteClickHouse(opts.Database) + "." + quoteClickHouse(opts.Table),
+		client:     client,
+		compressor: compressor,
+	}, nil
+}
+
+// EnsureColumns 实现 Inserter 接口，未开启 CreateTable 时不修改表结构
+func (c *clickHouse) EnsureColumns(ctx context.Context, columns []Column) error {
+	if !c.opts.CreateTable {
+		return nil
+	}
+
+	defs := make([]string, 0, len(columns))
+	for _, col := range columns {
+		defs = append(defs, quoteClickHouse(col.Name)+" "+clickHouseType(col))
+	}
+	create := fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s (%s) ENGINE = MergeTree ORDER BY %s",
+		c.table, strings.Join(defs, ", "), quoteClickHouse(ColumnTimestamp))
+	if err := c.exec(ctx, create, nil); err != nil {
+		return err
+	}
+
+	// 表已存在时补充缺少的列
+	alters := make([]string, 0, len(columns))
+	for _, def := range defs {
+		alters = append(alters, "ADD COLUMN IF NOT EXISTS "+def)
+	}
+	return c.exec(ctx, fmt.Sprintf("ALTER TABLE %s %s", c.table, strings.Join(alters, ", ")), nil)
+}
+
+// Insert 实现 Inserter 接口
+func (c *clickHouse) Insert(ctx context.Context, rows []Row) error {
+	var body bytes.Buffer
+	enc := json.NewEncoder(&body)
+	for _, row := range rows {
+		if err := enc.Encode(row); err != nil {
+			return fmt.Errorf("序列化日志失败: %w", err)
+		}
+	}
+	payload, encoding, err := c.compressor.Compress(body.Bytes())
+	if err != nil {
+		return err
+	}
+	return c.execEncoded(ctx, fmt.Sprintf("INSERT INTO %s FORMAT JSONEachRow", c.table), encoding, bytes.NewReader(payload))
+}
+
+// exec 执行一条语句，body 不为nil时作为 INSERT 的数据
+func (c *clickHouse) exec(ctx context.Context, query string, body io.Reader) error {
+	return c.execEncoded(ctx, query, "", body)
+}
+
+// execEncoded 执行一条语句，encoding 不为空时设置为 INSERT 数据的 Content-Encoding
+func (c *clickHouse) execEncoded(ctx context.Context, query, encoding string, body io.Reader) error {
+	params := url.Values{}
+	params.Set("database", c.opts.Database)
+	// 时间以RFC3339格式写入
+	params.Set("date_time_input_format", "best_effort")
+	// 表中缺少的列（如未开启 CreateTable 时的新字段）直接忽略
+	params.Set("input_format_skip_unknown_fields", "1")
+
+	var reqBody io.Reader = strings.NewReader(query)
+	if body != nil {
+		params.Set("query", query)
+		reqBody = body
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, strings.TrimSuffix(c.opts.URL, "/")+"/?"+params.Encode(), reqBody)
+	if err != nil {
+		return fmt.Errorf("创建ClickHouse请求失败: %w", err)
+	}
+	if encoding != "" {
+		req.Header.Set("Content-Encoding", encoding)
+	}
+	if c.opts.User != "" {
+		req.Header.Set("X-ClickHouse-User", c.opts.User)
+		req.Header.Set("X-ClickHouse-Key", c.opts.Password)
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("请求ClickHouse失败: %w", err)
+	}
+	defer resp.Body.Close()
+	msg, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("ClickHouse返回错误: %s: %s", resp.Status, strings.TrimSpace(string(msg)))
+	}
+	return nil
+}
+
+// clickHouseType 返回列对应的ClickHouse类型，除 timestamp 外的列可以为NULL
+func clickHouseType(col Column) string {
+	var t string
+	switch col.Type {
+	case TypeInt:
+		t = "Int64"
+	case TypeUint:
+		t = "UInt64"
+	case TypeFloat:
+		t = "Float64"
+	case TypeBool:
+		t = "Bool"
+	case TypeTime:
+		t = "DateTime64(9, 'UTC')"
+	default:
+		t = "String"
+	}
+	switch col.Name {
+	case ColumnTimestamp:
+		return t
+	case ColumnLevel:
+		return "LowCardinality(String)"
+	}
+	return "Nullable(" + t + ")"
+}
+
+// quoteClickHouse 用反引号引用标识符
+func quoteClickHouse(name string) string {
+	return "`" + strings.NewReplacer(`\`, `\\`, "`", "\\`").Replace(name) + "`"
+}
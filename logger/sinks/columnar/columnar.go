@@ -0,0 +1,489 @@
+// Package columnar 将结构化日志按批写入以数据库作为日志存储的列式数据库（ClickHouse、BigQuery）
+//
+// 每条日志为一行，固定包含 timestamp、level、logger、message、caller、stacktrace 列，
+// 其余字段各自成为一列，列类型由字段首次出现时的值推导，出现新字段时自动添加列：
+//
+//	inserter, err := columnar.ClickHouse(columnar.ClickHouseOptions{
+//		URL:         "http://localhost:8123",
+//		Table:       "logs",
+//		CreateTable: true,
+//	})
+//	sink := columnar.New(inserter, columnar.Options{})
+//	defer sink.Close()
+//	log, err := logger.NewLogger(cfg, logger.WithLevelHook(logger.InfoLevel, sink.Hook()))
+package columnar
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/constructorvirgil/virlog/logger"
+	"go.uber.org/zap/zapcore"
+)
+
+const (
+	// defaultBatchSize 默认每批写入的最大行数
+	defaultBatchSize = 1000
+	// defaultFlushInterval 默认的定时写入间隔
+	defaultFlushInterval = 5 * time.Second
+	// defaultQueueSize 默认的待写入队列长度
+	defaultQueueSize = 10000
+	// defaultTimeout 默认的单次写入超时
+	defaultTimeout = 30 * time.Second
+)
+
+// ColumnType 列的类型
+type ColumnType int
+
+const (
+	// TypeString 字符串，对象、数组等复杂字段以JSON字符串写入
+	TypeString ColumnType = iota
+	// TypeInt 有符号整数，time.Duration 以纳秒写入
+	TypeInt
+	// TypeUint 无符号整数
+	TypeUint
+	// TypeFloat 浮点数
+	TypeFloat
+	// TypeBool 布尔值
+	TypeBool
+	// TypeTime 时间
+	TypeTime
+)
+
+// String 返回类型名称
+func (t ColumnType) String() string {
+	switch t {
+	case TypeInt:
+		return "int"
+	case TypeUint:
+		return "uint"
+	case TypeFloat:
+		return "float"
+	case TypeBool:
+		return "bool"
+	case TypeTime:
+		return "time"
+	default:
+		return "string"
+	}
+}
+
+// Column 表中的一列
+type Column struct {
+	Name string
+	Type ColumnType
+}
+
+// Row 一条日志对应的行，键为列名，值的类型与列类型一致，类型不一致的值为nil
+type Row map[string]interface{}
+
+// 固定列
+const (
+	ColumnTimestamp  = "timestamp"
+	ColumnLevel      = "level"
+	ColumnLogger     = "logger"
+	ColumnMessage    = "message"
+	ColumnCaller     = "caller"
+	ColumnStacktrace = "stacktrace"
+)
+
+// fixedColumns 每张表都包含的列
+var fixedColumns = []Column{
+	{Name: ColumnTimestamp, Type: TypeTime},
+	{Name: ColumnLevel, Type: TypeString},
+	{Name: ColumnLogger, Type: TypeString},
+	{Name: ColumnMessage, Type: TypeString},
+	{Name: ColumnCaller, Type: TypeString},
+	{Name: ColumnStacktrace, Type: TypeString},
+}
+
+// Inserter 写入数据库的接口
+type Inserter interface {
+	// EnsureColumns 确保表中包含给定的列，首次写入前和出现新字段时调用，columns 为当前全部的列
+	EnsureColumns(ctx context.Context, columns []Column) error
+	// Insert 写入一批行
+	Insert(ctx context.Context, rows []Row) error
+}
+
+// Options 批量写入的配置
+type Options struct {
+	// 每批写入的最大行数，默认1000
+	BatchSize int
+	// 定时写入的间隔，不足一批的日志最多等待该时长后写入，默认5秒
+	FlushInterval time.Duration
+	// 待写入队列长度，队列满时丢弃新日志，默认10000
+	QueueSize int
+	// 单次建表或写入的超时时间，默认30秒
+	Timeout time.Duration
+	// 写入失败时调用，默认输出到标准错误
+	OnError func(error)
+}
+
+// Sink 按批写入日志的输出目标
+type Sink struct {
+	inserter Inserter
+	opts     Options
+
+	queue chan Row
+	flush chan chan struct{}
+
+	mu      sync.Mutex
+	columns map[string]ColumnType
+	// dirty 存在尚未成功同步到表结构的列
+	dirty bool
+
+	closeOnce sync.Once
+	done      chan struct{}
+	stopped   chan struct{}
+}
+
+// New 创建批量写入的输出目标，返回的 Sink 需要调用 Close 写入剩余日志
+func New(inserter Inserter, opts Options) *Sink {
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = defaultBatchSize
+	}
+	if opts.FlushInterval <= 0 {
+		opts.FlushInterval = defaultFlushInterval
+	}
+	if opts.QueueSize <= 0 {
+		opts.QueueSize = defaultQueueSize
+	}
+	if opts.Timeout <= 0 {
+		opts.Timeout = defaultTimeout
+	}
+	if opts.OnError == nil {
+		opts.OnError = func(err error) {
+			fmt.Fprintf(os.Stderr, "columnar: %v\n", err)
+		}
+	}
+
+	s := &Sink{
+		inserter: inserter,
+		opts:     opts,
+		queue:    make(chan Row, opts.QueueSize),
+		flush:    make(chan chan struct{}),
+		columns:  make(map[string]ColumnType, len(fixedColumns)),
+		dirty:    true,
+		done:     make(chan struct{}),
+		stopped:  make(chan struct{}),
+	}
+	for _, c := range fixedColumns {
+		s.columns[c.Name] = c.Type
+	}
+	go s.run()
+	return s
+}
+
+// Hook 返回写入日志的钩子，配合 logger.WithHook 或 logger.WithLevelHook 使用
+func (s *Sink) Hook() logger.Hook {
+	return func(ent zapcore.Entry, fields []logger.Field) error {
+		select {
+		case <-s.done:
+			return nil
+		default:
+		}
+		select {
+		case s.queue <- newRow(ent, fields):
+		default:
+			// 队列已满，丢弃日志，避免阻塞日志写入
+		}
+		return nil
+	}
+}
+
+// Columns 返回当前推导出的全部列，固定列在前，其余按名称排序
+func (s *Sink) Columns() []Column {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.columnsLocked()
+}
+
+// columnsLocked 返回当前全部的列，调用方需持有锁
+func (s *Sink) columnsLocked() []Column {
+	columns := append([]Column(nil), fixedColumns...)
+	names := make([]string, 0, len(s.columns))
+	for name := range s.columns {
+		if !isFixedColumn(name) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		columns = append(columns, Column{Name: name, Type: s.columns[name]})
+	}
+	return columns
+}
+
+// Flush 立即写入队列中的日志，超时返回false
+func (s *Sink) Flush(timeout time.Duration) bool {
+	finished := make(chan struct{})
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case s.flush <- finished:
+	case <-s.stopped:
+		return true
+	case <-timer.C:
+		return false
+	}
+	select {
+	case <-finished:
+		return true
+	case <-timer.C:
+		return false
+	}
+}
+
+// Close 写入队列中剩余的日志并停止后台写入
+func (s *Sink) Close() {
+	s.closeOnce.Do(func() {
+		close(s.done)
+	})
+	<-s.stopped
+}
+
+// run 后台按批写入队列中的日志
+func (s *Sink) run() {
+	defer close(s.stopped)
+	ticker := time.NewTicker(s.opts.FlushInterval)
+	defer ticker.Stop()
+
+	batch := make([]Row, 0, s.opts.BatchSize)
+	for {
+		select {
+		case row := <-s.queue:
+			batch = append(batch, row)
+			if len(batch) >= s.opts.BatchSize {
+				batch = s.write(batch)
+			}
+		case <-ticker.C:
+			batch = s.write(batch)
+		case finished := <-s.flush:
+			batch = s.write(s.drain(batch))
+			close(finished)
+		case <-s.done:
+			s.write(s.drain(batch))
+			return
+		}
+	}
+}
+
+// drain 取出队列中已有的日志，按批写入超出的部分
+func (s *Sink) drain(batch []Row) []Row {
+	for {
+		select {
+		case row := <-s.queue:
+			batch = append(batch, row)
+			if len(batch) >= s.opts.BatchSize {
+				batch = s.write(batch)
+			}
+		default:
+			return batch
+		}
+	}
+}
+
+// write 同步表结构后写入一批日志，返回清空后的batch以便复用
+func (s *Sink) write(batch []Row) []Row {
+	if len(batch) == 0 {
+		return batch
+	}
+	s.mu.Lock()
+	for _, row := range batch {
+		s.conform(row)
+	}
+	var columns []Column
+	if s.dirty {
+		columns = s.columnsLocked()
+	}
+	s.mu.Unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), s.opts.Timeout)
+	defer cancel()
+	if columns != nil {
+		if err := s.inserter.EnsureColumns(ctx, columns); err != nil {
+			s.opts.OnError(fmt.Errorf("同步表结构失败: %w", err))
+		} else {
+			s.mu.Lock()
+			// 同步期间没有出现新列时才清除标记
+			s.dirty = len(s.columns) != len(columns)
+			s.mu.Unlock()
+		}
+	}
+	if err := s.inserter.Insert(ctx, batch); err != nil {
+		s.opts.OnError(fmt.Errorf("写入%d条日志失败: %w", len(batch), err))
+	}
+
+	for i := range batch {
+		batch[i] = nil
+	}
+	return batch[:0]
+}
+
+// conform 为新字段推导列类型，将与列类型不一致的值替换为nil，调用方需持有锁
+func (s *Sink) conform(row Row) {
+	for name, v := range row {
+		t := columnType(v)
+		current, ok := s.columns[name]
+		if !ok {
+			s.columns[name] = t
+			s.dirty = true
+			continue
+		}
+		if current == t {
+			continue
+		}
+		switch {
+		case current == TypeString:
+			row[name] = stringValue(v)
+		case current == TypeFloat && (t == TypeInt || t == TypeUint):
+			row[name] = toFloat(v)
+		default:
+			row[name] = nil
+		}
+	}
+}
+
+// newRow 将日志转换为行
+func newRow(ent zapcore.Entry, fields []logger.Field) Row {
+	enc := zapcore.NewMapObjectEncoder()
+	for _, f := range fields {
+		f.AddTo(enc)
+	}
+
+	row := make(Row, len(enc.Fields)+len(fixedColumns))
+	for k, v := range enc.Fields {
+		name := columnName(k)
+		if isFixedColumn(name) {
+			name = "field_" + name
+		}
+		row[name] = normalize(v)
+	}
+	row[ColumnTimestamp] = ent.Time.UTC()
+	row[ColumnLevel] = ent.Level.String()
+	if ent.Level == logger.TraceLevel {
+		row[ColumnLevel] = "trace"
+	}
+	row[ColumnLogger] = ent.LoggerName
+	row[ColumnMessage] = ent.Message
+	if ent.Caller.Defined {
+		row[ColumnCaller] = ent.Caller.TrimmedPath()
+	}
+	if ent.Stack != "" {
+		row[ColumnStacktrace] = ent.Stack
+	}
+	return row
+}
+
+// invalidColumnChars 列名中不允许的字符
+var invalidColumnChars = regexp.MustCompile(`[^A-Za-z0-9_]`)
+
+// columnName 将字段名转换为列名，如 http.status 转换为 http_status
+func columnName(key string) string {
+	name := invalidColumnChars.ReplaceAllString(key, "_")
+	if name == "" || (name[0] >= '0' && name[0] <= '9') {
+		name = "_" + name
+	}
+	return name
+}
+
+// isFixedColumn 判断是否为固定列
+func isFixedColumn(name string) bool {
+	for _, c := range fixedColumns {
+		if c.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// normalize 将字段值转换为列值：整数统一为int64或uint64，浮点数为float64，
+// 时间为UTC时间，时长为纳秒，复杂类型为JSON字符串
+func normalize(v interface{}) interface{} {
+	switch x := v.(type) {
+	case nil, string, bool, int64, uint64, float64:
+		return x
+	case int:
+		return int64(x)
+	case int32:
+		return int64(x)
+	case int16:
+		return int64(x)
+	case int8:
+		return int64(x)
+	case uint:
+		return uint64(x)
+	case uint32:
+		return uint64(x)
+	case uint16:
+		return uint64(x)
+	case uint8:
+		return uint64(x)
+	case uintptr:
+		return uint64(x)
+	case float32:
+		return float64(x)
+	case time.Duration:
+		return int64(x)
+	case time.Time:
+		return x.UTC()
+	case []byte:
+		return string(x)
+	default:
+		return stringValue(x)
+	}
+}
+
+// columnType 返回列值对应的列类型
+func columnType(v interface{}) ColumnType {
+	switch v.(type) {
+	case int64:
+		return TypeInt
+	case uint64:
+		return TypeUint
+	case float64:
+		return TypeFloat
+	case bool:
+		return TypeBool
+	case time.Time:
+		return TypeTime
+	default:
+		return TypeString
+	}
+}
+
+// stringValue 将值转换为字符串，复杂类型使用JSON
+func stringValue(v interface{}) interface{} {
+	switch x := v.(type) {
+	case nil:
+		return nil
+	case string:
+		return x
+	case time.Time:
+		return x.Format(time.RFC3339Nano)
+	case fmt.Stringer:
+		return x.String()
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(b)
+}
+
+// toFloat 将整数转换为浮点数
+func toFloat(v interface{}) float64 {
+	switch x := v.(type) {
+	case int64:
+		return float64(x)
+	case uint64:
+		return float64(x)
+	}
+	return 0
+}
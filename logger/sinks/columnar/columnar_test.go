@@ -0,0 +1,374 @@
+package columnar
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/constructorvirgil/virlog/config"
+	"github.com/constructorvirgil/virlog/logger"
+	"github.com/klauspost/compress/zstd"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zapcore"
+)
+
+// fakeInserter 记录写入的行和表结构
+type fakeInserter struct {
+	mu      sync.Mutex
+	rows    []Row
+	batches int
+	columns [][]Column
+	failCol bool
+}
+
+func (f *fakeInserter) EnsureColumns(ctx context.Context, columns []Column) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.failCol {
+		return errors.New("ddl failed")
+	}
+	f.columns = append(f.columns, columns)
+	return nil
+}
+
+func (f *fakeInserter) Insert(ctx context.Context, rows []Row) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.rows = append(f.rows, rows...)
+	f.batches++
+	return nil
+}
+
+func (f *fakeInserter) snapshot() ([]Row, int, [][]Column) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]Row(nil), f.rows...), f.batches, append([][]Column(nil), f.columns...)
+}
+
+// newTestLogger 创建将日志写入sink的Logger
+func newTestLogger(t *testing.T, sink *Sink) logger.Logger {
+	log, err := logger.NewLogger(config.DefaultConfig(),
+		logger.WithSyncTarget(zapcore.AddSync(io.Discard)),
+		logger.WithHook(sink.Hook()))
+	require.NoError(t, err)
+	return log
+}
+
+// 测试按字段推导列类型并按批写入
+func TestSinkSchema(t *testing.T) {
+	ins := &fakeInserter{}
+	sink := New(ins, Options{BatchSize: 2, FlushInterval: time.Hour})
+	log := newTestLogger(t, sink)
+
+	log.With(logger.String("service", "api")).Info("request",
+		logger.Int("status", 200),
+		logger.Uint64("bytes", 512),
+		logger.Float64("ratio", 0.5),
+		logger.Bool("cached", true),
+		logger.Duration("latency", time.Millisecond),
+		logger.Any("tags", []string{"a", "b"}),
+		logger.String("http.method", "GET"),
+		logger.String("message", "shadowed"),
+	)
+	// 批次满后写入
+	require.Eventually(t, func() bool {
+		log.Info("second", logger.String("status", "bad"), logger.Int("ratio", 2))
+		_, batches, _ := ins.snapshot()
+		return batches > 0
+	}, 3*time.Second, 10*time.Millisecond)
+
+	rows, _, columns := ins.snapshot()
+	require.Len(t, columns, 1)
+	types := make(map[string]ColumnType)
+	for _, c := range columns[0] {
+		types[c.Name] = c.Type
+	}
+	assert.Equal(t, ColumnTimestamp, columns[0][0].Name)
+	assert.Equal(t, TypeTime, types[ColumnTimestamp])
+	assert.Equal(t, TypeString, types["service"])
+	assert.Equal(t, TypeInt, types["status"])
+	assert.Equal(t, TypeUint, types["bytes"])
+	assert.Equal(t, TypeFloat, types["ratio"])
+	assert.Equal(t, TypeBool, types["cached"])
+	assert.Equal(t, TypeInt, types["latency"])
+	assert.Equal(t, TypeString, types["tags"])
+	assert.Equal(t, TypeString, types["http_method"])
+	assert.Equal(t, TypeString, types["field_message"])
+
+	first := rows[0]
+	assert.Equal(t, "request", first[ColumnMessage])
+	assert.Equal(t, "info", first[ColumnLevel])
+	assert.Equal(t, int64(200), first["status"])
+	assert.Equal(t, int64(time.Millisecond), first["latency"])
+	assert.Equal(t, `["a","b"]`, first["tags"])
+	assert.Equal(t, "shadowed", first["field_message"])
+	assert.IsType(t, time.Time{}, first[ColumnTimestamp])
+
+	// 类型与列不一致时整数转换为浮点数，其他值写入NULL
+	second := rows[1]
+	assert.Nil(t, second["status"])
+	assert.Equal(t, float64(2), second["ratio"])
+
+	sink.Close()
+}
+
+// 测试定时写入、Flush以及出现新字段时同步表结构
+func TestSinkFlush(t *testing.T) {
+	ins := &fakeInserter{}
+	sink := New(ins, Options{FlushInterval: 20 * time.Millisecond})
+	log := newTestLogger(t, sink)
+
+	log.Info("first")
+	require.Eventually(t, func() bool {
+		rows, _, _ := ins.snapshot()
+		return len(rows) == 1
+	}, 3*time.Second, 10*time.Millisecond)
+
+	log.Info("second", logger.String("user", "alice"))
+	require.True(t, sink.Flush(time.Second))
+	rows, _, columns := ins.snapshot()
+	require.Len(t, rows, 2)
+	require.Len(t, columns, 2)
+	assert.Equal(t, Column{Name: "user", Type: TypeString}, columns[1][len(columns[1])-1])
+
+	// 没有新字段时不再同步表结构
+	log.Info("third", logger.String("user", "bob"))
+	require.True(t, sink.Flush(time.Second))
+	_, _, columns = ins.snapshot()
+	assert.Len(t, columns, 2)
+
+	// 关闭时写入剩余日志，之后的日志被丢弃
+	sink.Close()
+	log.Info("after close")
+	rows, _, _ = ins.snapshot()
+	assert.Len(t, rows, 3)
+}
+
+// 测试同步表结构失败时报告错误并在下一批重试
+func TestSinkEnsureColumnsError(t *testing.T) {
+	ins := &fakeInserter{failCol: true}
+	var mu sync.Mutex
+	var errs []error
+	sink := New(ins, Options{FlushInterval: time.Hour, OnError: func(err error) {
+		mu.Lock()
+		errs = append(errs, err)
+		mu.Unlock()
+	}})
+	defer sink.Close()
+	log := newTestLogger(t, sink)
+
+	log.Info("first")
+	require.True(t, sink.Flush(time.Second))
+	mu.Lock()
+	require.Len(t, errs, 1)
+	assert.Contains(t, errs[0].Error(), "ddl failed")
+	mu.Unlock()
+
+	ins.mu.Lock()
+	ins.failCol = false
+	ins.mu.Unlock()
+	log.Info("second")
+	require.True(t, sink.Flush(time.Second))
+	rows, _, columns := ins.snapshot()
+	assert.Len(t, rows, 2)
+	assert.Len(t, columns, 1)
+}
+
+// 测试写入ClickHouse的建表和插入语句
+func TestClickHouse(t *testing.T) {
+	var mu sync.Mutex
+	var queries []string
+	var inserted []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		defer mu.Unlock()
+		assert.Equal(t, "default", r.Header.Get("X-ClickHouse-User"))
+		assert.Equal(t, "logs_db", r.URL.Query().Get("database"))
+		if q := r.URL.Query().Get("query"); q != "" {
+			queries = append(queries, q)
+			inserted = append(inserted, strings.Split(strings.TrimSpace(string(body)), "\n")...)
+			return
+		}
+		queries = append(queries, string(body))
+	}))
+	defer server.Close()
+
+	ins, err := ClickHouse(ClickHouseOptions{
+		URL:         server.URL,
+		Database:    "logs_db",
+		Table:       "app_logs",
+		User:        "default",
+		CreateTable: true,
+	})
+	require.NoError(t, err)
+	sink := New(ins, Options{FlushInterval: time.Hour})
+	log := newTestLogger(t, sink)
+	log.Warn("slow query", logger.Int("rows", 3))
+	sink.Close()
+
+	mu.Lock()
+	defer mu.Unlock()
+	require.Len(t, queries, 3)
+	assert.Contains(t, queries[0], "CREATE TABLE IF NOT EXISTS `logs_db`.`app_logs`")
+	assert.Contains(t, queries[0], "`timestamp` DateTime64(9, 'UTC')")
+	assert.Contains(t, queries[0], "`rows` Nullable(Int64)")
+	assert.Contains(t, queries[0], "ORDER BY `timestamp`")
+	assert.Contains(t, queries[1], "ALTER TABLE `logs_db`.`app_logs` ADD COLUMN IF NOT EXISTS `timestamp`")
+	assert.Equal(t, "INSERT INTO `logs_db`.`app_logs` FORMAT JSONEachRow", queries[2])
+
+	require.Len(t, inserted, 1)
+	var row map[string]interface{}
+	require.NoError(t, json.Unmarshal([]byte(inserted[0]), &row))
+	assert.Equal(t, "slow query", row["message"])
+	assert.Equal(t, "warn", row["level"])
+	assert.Equal(t, float64(3), row["rows"])
+
+	_, err = ClickHouse(ClickHouseOptions{URL: server.URL})
+	assert.Error(t, err)
+}
+
+// 测试压缩写入ClickHouse的数据
+func TestClickHouseCompression(t *testing.T) {
+	var mu sync.Mutex
+	var encoding string
+	var inserted []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		defer mu.Unlock()
+		encoding = r.Header.Get("Content-Encoding")
+		inserted = body
+	}))
+	defer server.Close()
+
+	ins, err := ClickHouse(ClickHouseOptions{
+		URL:         server.URL,
+		Table:       "app_logs",
+		Compression: config.CompressionConfig{Algorithm: "zstd", MinBytes: -1},
+	})
+	require.NoError(t, err)
+	sink := New(ins, Options{FlushInterval: time.Hour})
+	log := newTestLogger(t, sink)
+	log.Info("compressed")
+	sink.Close()
+
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Equal(t, "zstd", encoding)
+	dec, err := zstd.NewReader(nil)
+	require.NoError(t, err)
+	defer dec.Close()
+	plain, err := dec.DecodeAll(inserted, nil)
+	require.NoError(t, err)
+	var row map[string]interface{}
+	require.NoError(t, json.Unmarshal(plain, &row))
+	assert.Equal(t, "compressed", row["message"])
+
+	_, err = ClickHouse(ClickHouseOptions{
+		URL:         server.URL,
+		Table:       "app_logs",
+		Compression: config.CompressionConfig{Algorithm: "lz4"},
+	})
+	assert.ErrorContains(t, err, "不支持的压缩算法")
+}
+
+// 测试写入BigQuery时建表、补充列和流式插入
+func TestBigQuery(t *testing.T) {
+	var mu sync.Mutex
+	var requests []string
+	var created, patched bigQueryTable
+	exists := false
+	var insertBody bytes.Buffer
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		assert.Equal(t, "Bearer token", r.Header.Get("Authorization"))
+		requests = append(requests, r.Method+" "+r.URL.Path)
+		const table = "/bigquery/v2/projects/p/datasets/d/tables/logs"
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == table:
+			if !exists {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			json.NewEncoder(w).Encode(created)
+		case r.Method == http.MethodPost && r.URL.Path == "/bigquery/v2/projects/p/datasets/d/tables":
+			json.NewDecoder(r.Body).Decode(&created)
+			exists = true
+			w.Write([]byte("{}"))
+		case r.Method == http.MethodPatch && r.URL.Path == table:
+			json.NewDecoder(r.Body).Decode(&patched)
+			w.Write([]byte("{}"))
+		case r.Method == http.MethodPost && r.URL.Path == table+"/insertAll":
+			io.Copy(&insertBody, r.Body)
+			w.Write([]byte(`{"kind":"bigquery#tableDataInsertAllResponse"}`))
+		default:
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	}))
+	defer server.Close()
+
+	ins, err := BigQuery(BigQueryOptions{
+		Project:     "p",
+		Dataset:     "d",
+		Table:       "logs",
+		TokenSource: func(ctx context.Context) (string, error) { return "token", nil },
+		CreateTable: true,
+		Endpoint:    server.URL,
+	})
+	require.NoError(t, err)
+	sink := New(ins, Options{FlushInterval: time.Hour})
+	defer sink.Close()
+	log := newTestLogger(t, sink)
+
+	log.Info("first", logger.Bool("ok", true))
+	require.True(t, sink.Flush(time.Second))
+	log.Info("second", logger.Float64("score", 1.5))
+	require.True(t, sink.Flush(time.Second))
+
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Equal(t, []string{
+		"GET /bigquery/v2/projects/p/datasets/d/tables/logs",
+		"POST /bigquery/v2/projects/p/datasets/d/tables",
+		"POST /bigquery/v2/projects/p/datasets/d/tables/logs/insertAll",
+		"GET /bigquery/v2/projects/p/datasets/d/tables/logs",
+		"PATCH /bigquery/v2/projects/p/datasets/d/tables/logs",
+		"POST /bigquery/v2/projects/p/datasets/d/tables/logs/insertAll",
+	}, requests)
+	assert.Equal(t, "logs", created.TableReference["tableId"])
+	assert.Equal(t, bigQueryField{Name: "timestamp", Type: "TIMESTAMP", Mode: "REQUIRED"}, created.Schema.Fields[0])
+	assert.Contains(t, created.Schema.Fields, bigQueryField{Name: "ok", Type: "BOOL", Mode: "NULLABLE"})
+	assert.Contains(t, patched.Schema.Fields, bigQueryField{Name: "score", Type: "FLOAT64", Mode: "NULLABLE"})
+	assert.Contains(t, insertBody.String(), `"message":"second"`)
+}
+
+// 测试BigQuery拒绝部分行时返回错误
+func TestBigQueryInsertErrors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"insertErrors":[{"index":0,"errors":[{"reason":"invalid","message":"no such field"}]}]}`))
+	}))
+	defer server.Close()
+
+	ins, err := BigQuery(BigQueryOptions{
+		Project:     "p",
+		Dataset:     "d",
+		Table:       "logs",
+		TokenSource: func(ctx context.Context) (string, error) { return "token", nil },
+		Endpoint:    server.URL,
+	})
+	require.NoError(t, err)
+	err = ins.Insert(context.Background(), []Row{{ColumnMessage: "x"}})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "no such field")
+}
@@ -0,0 +1,124 @@
+package otlp
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/otlp/otlplog/otlploggrpc"
+	"go.opentelemetry.io/otel/exporters/otlp/otlplog/otlploghttp"
+	sdklog "go.opentelemetry.io/otel/sdk/log"
+	"go.opentelemetry.io/otel/sdk/resource"
+)
+
+const (
+	// ProtocolGRPC 通过gRPC导出，Collector默认端口4317
+	ProtocolGRPC = "grpc"
+	// ProtocolHTTP 通过HTTP（protobuf）导出，Collector默认端口4318
+	ProtocolHTTP = "http"
+)
+
+// ExporterOptions 导出到OTel Collector的配置
+type ExporterOptions struct {
+	// 导出协议，ProtocolGRPC（默认）或 ProtocolHTTP
+	Protocol string
+	// Collector地址，如 "otel-collector:4317"，为空时使用 OTEL_EXPORTER_OTLP_ENDPOINT 环境变量或SDK默认地址
+	Endpoint string
+	// 是否使用明文连接，默认使用TLS
+	Insecure bool
+	// 附加的请求头，如认证信息
+	Headers map[string]string
+	// 单次导出的超时时间，默认10秒
+	Timeout time.Duration
+	// 资源属性 service.name
+	ServiceName string
+	// 其他资源属性，如 deployment.environment
+	ResourceAttributes map[string]string
+	// 批量导出的间隔，默认1秒
+	ExportInterval time.Duration
+}
+
+// NewLoggerProvider 创建批量导出到OTel Collector的LoggerProvider，退出前需要调用其 Shutdown 导出剩余的日志
+func NewLoggerProvider(ctx context.Context, opts ExporterOptions) (*sdklog.LoggerProvider, error) {
+	exporter, err := newExporter(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := newResource(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	var batchOpts []sdklog.BatchProcessorOption
+	if opts.ExportInterval > 0 {
+		batchOpts = append(batchOpts, sdklog.WithExportInterval(opts.ExportInterval))
+	}
+	return sdklog.NewLoggerProvider(
+		sdklog.WithResource(res),
+		sdklog.WithProcessor(sdklog.NewBatchProcessor(exporter, batchOpts...)),
+	), nil
+}
+
+// newExporter 按协议创建OTLP导出器
+func newExporter(ctx context.Context, opts ExporterOptions) (sdklog.Exporter, error) {
+	switch opts.Protocol {
+	case "", ProtocolGRPC:
+		var grpcOpts []otlploggrpc.Option
+		if opts.Endpoint != "" {
+			grpcOpts = append(grpcOpts, otlploggrpc.WithEndpoint(opts.Endpoint))
+		}
+		if opts.Insecure {
+			grpcOpts = append(grpcOpts, otlploggrpc.WithInsecure())
+		}
+		if len(opts.Headers) > 0 {
+			grpcOpts = append(grpcOpts, otlploggrpc.WithHeaders(opts.Headers))
+		}
+		if opts.Timeout > 0 {
+			grpcOpts = append(grpcOpts, otlploggrpc.WithTimeout(opts.Timeout))
+		}
+		exporter, err := otlploggrpc.New(ctx, grpcOpts...)
+		if err != nil {
+			return nil, fmt.Errorf("创建OTLP gRPC导出器失败: %w", err)
+		}
+		return exporter, nil
+	case ProtocolHTTP:
+		var httpOpts []otlploghttp.Option
+		if opts.Endpoint != "" {
+			httpOpts = append(httpOpts, otlploghttp.WithEndpoint(opts.Endpoint))
+		}
+		if opts.Insecure {
+			httpOpts = append(httpOpts, otlploghttp.WithInsecure())
+		}
+		if len(opts.Headers) > 0 {
+			httpOpts = append(httpOpts, otlploghttp.WithHeaders(opts.Headers))
+		}
+		if opts.Timeout > 0 {
+			httpOpts = append(httpOpts, otlploghttp.WithTimeout(opts.Timeout))
+		}
+		exporter, err := otlploghttp.New(ctx, httpOpts...)
+		if err != nil {
+			return nil, fmt.Errorf("创建OTLP HTTP导出器失败: %w", err)
+		}
+		return exporter, nil
+	default:
+		return nil, fmt.Errorf("不支持的OTLP协议: %s", opts.Protocol)
+	}
+}
+
+// newResource 在SDK默认资源（包括 OTEL_RESOURCE_ATTRIBUTES 环境变量）的基础上添加配置的资源属性
+func newResource(opts ExporterOptions) (*resource.Resource, error) {
+	var attrs []attribute.KeyValue
+	if opts.ServiceName != "" {
+		attrs = append(attrs, attribute.String("service.name", opts.ServiceName))
+	}
+	for k, v := range opts.ResourceAttributes {
+		attrs = append(attrs, attribute.String(k, v))
+	}
+	res, err := resource.Merge(resource.Default(), resource.NewSchemaless(attrs...))
+	if err != nil {
+		return nil, fmt.Errorf("创建OTLP资源失败: %w", err)
+	}
+	return res, nil
+}
@@ -0,0 +1,346 @@
+// Package otlp 将日志转换为OpenTelemetry的LogRecord，通过OpenTelemetry SDK导出到OTel Collector
+//
+// 使用 NewLoggerProvider 创建以gRPC或HTTP协议导出的 LoggerProvider，再通过 WithProvider 接入日志：
+//
+//	provider, err := otlp.NewLoggerProvider(ctx, otlp.ExporterOptions{
+//		Endpoint:    "otel-collector:4317",
+//		Insecure:    true,
+//		ServiceName: "order-service",
+//	})
+//	defer provider.Shutdown(context.Background())
+//	log, err := logger.NewLogger(cfg, otlp.WithProvider(provider, logger.InfoLevel))
+//
+// 日志级别转换为LogRecord的severity，消息为body，字段转换为attributes（对象和数组保持嵌套结构），
+// 通过 TraceContext 添加的追踪上下文或十六进制的 trace_id、span_id 字段设置LogRecord的trace_id和span_id
+package otlp
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/constructorvirgil/virlog/logger"
+	otellog "go.opentelemetry.io/otel/log"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+const (
+	// ScopeName 默认的instrumentation scope名称
+	ScopeName = "github.com/constructorvirgil/virlog"
+
+	// 日志中的追踪上下文字段名，值为十六进制字符串
+	FieldTraceID = "trace_id"
+	FieldSpanID  = "span_id"
+)
+
+// 固定的attribute名称，遵循OpenTelemetry语义约定
+const (
+	attrLogger     = "logger"
+	attrFilepath   = "code.filepath"
+	attrLineno     = "code.lineno"
+	attrFunction   = "code.function"
+	attrStacktrace = "exception.stacktrace"
+)
+
+// spanContextMarker 通过 TraceContext 添加的追踪上下文，字段类型为Skip，其他输出编码时忽略
+type spanContextMarker struct {
+	sc trace.SpanContext
+}
+
+// TraceContext 返回携带 ctx 中span的追踪上下文的字段，ctx 中没有有效的span时返回被忽略的空字段：
+//
+//	log.Info("订单已创建", otlp.TraceContext(ctx), logger.String("order_id", id))
+func TraceContext(ctx context.Context) logger.Field {
+	sc := trace.SpanContextFromContext(ctx)
+	if !sc.IsValid() {
+		return logger.Skip()
+	}
+	return logger.Field{Type: zapcore.SkipType, Interface: spanContextMarker{sc: sc}}
+}
+
+// Option Core的配置选项
+type Option func(*Core)
+
+// WithLevel 设置导出的最低级别，默认导出所有级别
+func WithLevel(enabler zapcore.LevelEnabler) Option {
+	return func(c *Core) {
+		c.enabler = enabler
+	}
+}
+
+// WithScopeName 设置instrumentation scope名称，默认为 ScopeName
+func WithScopeName(name string) Option {
+	return func(c *Core) {
+		c.scope = name
+	}
+}
+
+// Core 将日志转换为LogRecord并通过OpenTelemetry的Logger导出的zapcore.Core
+type Core struct {
+	logger  otellog.Logger
+	enabler zapcore.LevelEnabler
+	scope   string
+	// fields With添加的上下文字段
+	fields []zapcore.Field
+}
+
+// NewCore 创建通过 provider 导出日志的Core，可以与其他Core组合使用，也可以通过 Hook 接入virlog
+func NewCore(provider otellog.LoggerProvider, opts ...Option) *Core {
+	c := &Core{
+		enabler: zap.LevelEnablerFunc(func(zapcore.Level) bool { return true }),
+		scope:   ScopeName,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	c.logger = provider.Logger(c.scope)
+	return c
+}
+
+// WithProvider 将不低于 level 的日志通过 provider 导出
+func WithProvider(provider otellog.LoggerProvider, level zapcore.LevelEnabler, opts ...Option) logger.Option {
+	opts = append([]Option{WithLevel(level)}, opts...)
+	return logger.WithLevelHook(level, NewCore(provider, opts...).Hook())
+}
+
+// Hook 返回导出日志的钩子，配合 logger.WithLevelHook 使用，钩子收到的字段已包含With添加的字段
+func (c *Core) Hook() logger.Hook {
+	return func(ent zapcore.Entry, fields []logger.Field) error {
+		c.emit(ent, fields)
+		return nil
+	}
+}
+
+// Enabled 实现zapcore.Core接口
+func (c *Core) Enabled(level zapcore.Level) bool {
+	return c.enabler.Enabled(level)
+}
+
+// With 实现zapcore.Core接口
+func (c *Core) With(fields []zapcore.Field) zapcore.Core {
+	clone := *c
+	clone.fields = make([]zapcore.Field, 0, len(c.fields)+len(fields))
+	clone.fields = append(clone.fields, c.fields...)
+	clone.fields = append(clone.fields, fields...)
+	return &clone
+}
+
+// Check 实现zapcore.Core接口
+func (c *Core) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(ent.Level) {
+		return ce.AddCore(ent, c)
+	}
+	return ce
+}
+
+// Write 实现zapcore.Core接口
+func (c *Core) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	if len(c.fields) == 0 {
+		c.emit(ent, fields)
+		return nil
+	}
+	all := make([]zapcore.Field, 0, len(c.fields)+len(fields))
+	all = append(all, c.fields...)
+	all = append(all, fields...)
+	c.emit(ent, all)
+	return nil
+}
+
+// Sync 实现zapcore.Core接口，LogRecord由SDK的处理器批量导出，刷新需要调用 LoggerProvider 的 ForceFlush
+func (c *Core) Sync() error {
+	return nil
+}
+
+// emit 转换并导出一条日志
+func (c *Core) emit(ent zapcore.Entry, fields []zapcore.Field) {
+	var record otellog.Record
+	record.SetTimestamp(ent.Time)
+	record.SetObservedTimestamp(time.Now())
+	record.SetSeverity(severity(ent.Level))
+	record.SetSeverityText(levelText(ent.Level))
+	record.SetBody(otellog.StringValue(ent.Message))
+
+	if ent.LoggerName != "" {
+		record.AddAttributes(otellog.String(attrLogger, ent.LoggerName))
+	}
+	if ent.Caller.Defined {
+		record.AddAttributes(
+			otellog.String(attrFilepath, ent.Caller.File),
+			otellog.Int(attrLineno, ent.Caller.Line))
+		if ent.Caller.Function != "" {
+			record.AddAttributes(otellog.String(attrFunction, ent.Caller.Function))
+		}
+	}
+	if ent.Stack != "" {
+		record.AddAttributes(otellog.String(attrStacktrace, ent.Stack))
+	}
+
+	sc, attrs := convertFields(fields)
+	record.AddAttributes(attrs...)
+
+	ctx := context.Background()
+	if sc.IsValid() {
+		ctx = trace.ContextWithSpanContext(ctx, sc)
+	}
+	c.logger.Emit(ctx, record)
+}
+
+// convertFields 将字段转换为attributes，并提取其中的追踪上下文
+//
+// 十六进制的 trace_id、span_id 字段只用于设置追踪上下文，不作为attribute
+func convertFields(fields []zapcore.Field) (trace.SpanContext, []otellog.KeyValue) {
+	var sc trace.SpanContext
+	enc := zapcore.NewMapObjectEncoder()
+	for _, f := range fields {
+		if f.Type == zapcore.SkipType {
+			if m, ok := f.Interface.(spanContextMarker); ok {
+				sc = m.sc
+			}
+			continue
+		}
+		f.AddTo(enc)
+	}
+
+	if !sc.IsValid() {
+		traceID, _ := enc.Fields[FieldTraceID].(string)
+		spanID, _ := enc.Fields[FieldSpanID].(string)
+		if parsed, ok := parseSpanContext(traceID, spanID); ok {
+			sc = parsed
+			delete(enc.Fields, FieldTraceID)
+			delete(enc.Fields, FieldSpanID)
+		}
+	}
+	return sc, keyValues(enc.Fields)
+}
+
+// parseSpanContext 解析十六进制的trace id和span id，span id 可以为空
+func parseSpanContext(traceID, spanID string) (trace.SpanContext, bool) {
+	tid, err := trace.TraceIDFromHex(traceID)
+	if err != nil {
+		return trace.SpanContext{}, false
+	}
+	cfg := trace.SpanContextConfig{TraceID: tid, TraceFlags: trace.FlagsSampled}
+	if spanID != "" {
+		sid, err := trace.SpanIDFromHex(spanID)
+		if err != nil {
+			return trace.SpanContext{}, false
+		}
+		cfg.SpanID = sid
+	}
+	return trace.NewSpanContext(cfg), true
+}
+
+// keyValues 将编码后的字段转换为按名称排序的attributes
+func keyValues(m map[string]interface{}) []otellog.KeyValue {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	kvs := make([]otellog.KeyValue, 0, len(keys))
+	for _, k := range keys {
+		kvs = append(kvs, otellog.KeyValue{Key: k, Value: value(m[k])})
+	}
+	return kvs
+}
+
+// value 将 zapcore.MapObjectEncoder 编码后的值转换为attribute的值
+func value(v interface{}) otellog.Value {
+	switch val := v.(type) {
+	case nil:
+		return otellog.Value{}
+	case string:
+		return otellog.StringValue(val)
+	case bool:
+		return otellog.BoolValue(val)
+	case int:
+		return otellog.Int64Value(int64(val))
+	case int8:
+		return otellog.Int64Value(int64(val))
+	case int16:
+		return otellog.Int64Value(int64(val))
+	case int32:
+		return otellog.Int64Value(int64(val))
+	case int64:
+		return otellog.Int64Value(val)
+	case uint:
+		return uintValue(uint64(val))
+	case uint8:
+		return otellog.Int64Value(int64(val))
+	case uint16:
+		return otellog.Int64Value(int64(val))
+	case uint32:
+		return otellog.Int64Value(int64(val))
+	case uint64:
+		return uintValue(val)
+	case uintptr:
+		return uintValue(uint64(val))
+	case float32:
+		return otellog.Float64Value(float64(val))
+	case float64:
+		return otellog.Float64Value(val)
+	case complex64, complex128:
+		return otellog.StringValue(fmt.Sprint(val))
+	case []byte:
+		return otellog.BytesValue(val)
+	case time.Time:
+		return otellog.StringValue(val.Format(time.RFC3339Nano))
+	case time.Duration:
+		return otellog.StringValue(val.String())
+	case map[string]interface{}:
+		return otellog.MapValue(keyValues(val)...)
+	case []interface{}:
+		values := make([]otellog.Value, len(val))
+		for i, item := range val {
+			values[i] = value(item)
+		}
+		return otellog.SliceValue(values...)
+	case fmt.Stringer:
+		return otellog.StringValue(val.String())
+	default:
+		return otellog.StringValue(fmt.Sprintf("%+v", val))
+	}
+}
+
+// uintValue 超出int64范围的无符号整数转换为十进制字符串
+func uintValue(v uint64) otellog.Value {
+	if v > math.MaxInt64 {
+		return otellog.StringValue(fmt.Sprint(v))
+	}
+	return otellog.Int64Value(int64(v))
+}
+
+// severity 将日志级别转换为OpenTelemetry的severity
+func severity(level zapcore.Level) otellog.Severity {
+	switch {
+	case level <= logger.TraceLevel:
+		return otellog.SeverityTrace1
+	case level == zapcore.DebugLevel:
+		return otellog.SeverityDebug1
+	case level == zapcore.InfoLevel:
+		return otellog.SeverityInfo1
+	case level == zapcore.WarnLevel:
+		return otellog.SeverityWarn1
+	case level == zapcore.ErrorLevel:
+		return otellog.SeverityError1
+	case level == zapcore.DPanicLevel:
+		return otellog.SeverityFatal1
+	case level == zapcore.PanicLevel:
+		return otellog.SeverityFatal2
+	default:
+		return otellog.SeverityFatal4
+	}
+}
+
+// levelText 返回日志级别名称，作为severity_text
+func levelText(level zapcore.Level) string {
+	if level == logger.TraceLevel {
+		return "TRACE"
+	}
+	return level.CapitalString()
+}
@@ -0,0 +1,183 @@
+package otlp
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/constructorvirgil/virlog/config"
+	"github.com/constructorvirgil/virlog/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	otellog "go.opentelemetry.io/otel/log"
+	sdklog "go.opentelemetry.io/otel/sdk/log"
+	"go.opentelemetry.io/otel/trace"
+	collogspb "go.opentelemetry.io/proto/otlp/collector/logs/v1"
+	"go.uber.org/zap/zapcore"
+	"google.golang.org/protobuf/proto"
+)
+
+// memoryExporter 保存导出的LogRecord
+type memoryExporter struct {
+	mu      sync.Mutex
+	records []sdklog.Record
+}
+
+func (e *memoryExporter) Export(_ context.Context, records []sdklog.Record) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	for _, r := range records {
+		e.records = append(e.records, r.Clone())
+	}
+	return nil
+}
+
+func (e *memoryExporter) Shutdown(context.Context) error   { return nil }
+func (e *memoryExporter) ForceFlush(context.Context) error { return nil }
+
+// attributes 返回LogRecord的attributes
+func attributes(r sdklog.Record) map[string]otellog.Value {
+	attrs := make(map[string]otellog.Value)
+	r.WalkAttributes(func(kv otellog.KeyValue) bool {
+		attrs[kv.Key] = kv.Value
+		return true
+	})
+	return attrs
+}
+
+// newTestLogger 创建导出到内存的Logger
+func newTestLogger(t *testing.T, level zapcore.LevelEnabler) (logger.Logger, *memoryExporter) {
+	exporter := &memoryExporter{}
+	provider := sdklog.NewLoggerProvider(sdklog.WithProcessor(sdklog.NewSimpleProcessor(exporter)))
+	cfg := config.DefaultConfig()
+	cfg.EnableStacktrace = false
+	log, err := logger.NewLogger(cfg, logger.WithSyncTarget(zapcore.AddSync(io.Discard)),
+		WithProvider(provider, level))
+	require.NoError(t, err)
+	return log, exporter
+}
+
+// 测试日志转换为LogRecord
+func TestCore(t *testing.T) {
+	log, exporter := newTestLogger(t, logger.InfoLevel)
+
+	log.Debug("debug message")
+	log.Named("orders").With(logger.String("service", "order")).Warn("库存不足",
+		logger.Int("count", 3),
+		logger.Any("item", map[string]interface{}{"sku": "A1", "tags": []string{"x", "y"}}),
+		logger.Duration("elapsed", 1500*time.Millisecond))
+
+	require.Len(t, exporter.records, 1)
+	r := exporter.records[0]
+	assert.Equal(t, "库存不足", r.Body().AsString())
+	assert.Equal(t, otellog.SeverityWarn1, r.Severity())
+	assert.Equal(t, "WARN", r.SeverityText())
+	assert.Equal(t, ScopeName, r.InstrumentationScope().Name)
+
+	attrs := attributes(r)
+	assert.Equal(t, "orders", attrs["logger"].AsString())
+	assert.Equal(t, "order", attrs["service"].AsString())
+	assert.Equal(t, int64(3), attrs["count"].AsInt64())
+	assert.Equal(t, "1.5s", attrs["elapsed"].AsString())
+	assert.Contains(t, attrs["code.filepath"].AsString(), "otlp_test.go")
+	item := attrs["item"]
+	require.Equal(t, otellog.KindMap, item.Kind())
+	assert.Len(t, item.AsMap(), 2)
+	assert.False(t, r.TraceID().IsValid())
+}
+
+// 测试追踪上下文
+func TestTraceContext(t *testing.T) {
+	log, exporter := newTestLogger(t, logger.InfoLevel)
+
+	traceID, _ := trace.TraceIDFromHex("4bf92f3577b34da6a3ce929d0e0e4736")
+	spanID, _ := trace.SpanIDFromHex("00f067aa0ba902b7")
+	ctx := trace.ContextWithSpanContext(context.Background(), trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    traceID,
+		SpanID:     spanID,
+		TraceFlags: trace.FlagsSampled,
+	}))
+
+	log.Info("from span", TraceContext(ctx))
+	log.Info("from fields", logger.String("trace_id", traceID.String()), logger.String("span_id", spanID.String()))
+	log.Info("no span", TraceContext(context.Background()), logger.String("trace_id", "not-hex"))
+
+	require.Len(t, exporter.records, 3)
+	for _, r := range exporter.records[:2] {
+		assert.Equal(t, traceID, r.TraceID(), r.Body().AsString())
+		assert.Equal(t, spanID, r.SpanID(), r.Body().AsString())
+		assert.NotContains(t, attributes(r), "trace_id")
+	}
+	r := exporter.records[2]
+	assert.False(t, r.TraceID().IsValid())
+	assert.Equal(t, "not-hex", attributes(r)["trace_id"].AsString())
+}
+
+// 测试级别转换
+func TestSeverity(t *testing.T) {
+	assert.Equal(t, otellog.SeverityTrace1, severity(logger.TraceLevel))
+	assert.Equal(t, otellog.SeverityDebug1, severity(logger.DebugLevel))
+	assert.Equal(t, otellog.SeverityError1, severity(logger.ErrorLevel))
+	assert.Equal(t, otellog.SeverityFatal4, severity(logger.FatalLevel))
+	assert.Equal(t, "TRACE", levelText(logger.TraceLevel))
+}
+
+// 测试通过HTTP协议导出到Collector
+func TestNewLoggerProviderHTTP(t *testing.T) {
+	requests := make(chan *collogspb.ExportLogsServiceRequest, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v1/logs", r.URL.Path)
+		assert.Equal(t, "secret", r.Header.Get("X-Token"))
+		body, _ := io.ReadAll(r.Body)
+		req := &collogspb.ExportLogsServiceRequest{}
+		assert.NoError(t, proto.Unmarshal(body, req))
+		requests <- req
+		w.Header().Set("Content-Type", "application/x-protobuf")
+		resp, _ := proto.Marshal(&collogspb.ExportLogsServiceResponse{})
+		w.Write(resp)
+	}))
+	defer server.Close()
+
+	provider, err := NewLoggerProvider(context.Background(), ExporterOptions{
+		Protocol:    ProtocolHTTP,
+		Endpoint:    strings.TrimPrefix(server.URL, "http://"),
+		Insecure:    true,
+		Headers:     map[string]string{"X-Token": "secret"},
+		ServiceName: "order-service",
+	})
+	require.NoError(t, err)
+
+	cfg := config.DefaultConfig()
+	log, err := logger.NewLogger(cfg, logger.WithSyncTarget(zapcore.AddSync(&bytes.Buffer{})),
+		WithProvider(provider, logger.InfoLevel))
+	require.NoError(t, err)
+	log.Error("支付失败", logger.String("order_id", "42"))
+	require.NoError(t, provider.Shutdown(context.Background()))
+
+	select {
+	case req := <-requests:
+		require.Len(t, req.ResourceLogs, 1)
+		var service string
+		for _, kv := range req.ResourceLogs[0].Resource.Attributes {
+			if kv.Key == "service.name" {
+				service = kv.Value.GetStringValue()
+			}
+		}
+		assert.Equal(t, "order-service", service)
+		records := req.ResourceLogs[0].ScopeLogs[0].LogRecords
+		require.Len(t, records, 1)
+		assert.Equal(t, "支付失败", records[0].Body.GetStringValue())
+		assert.Equal(t, "ERROR", records[0].SeverityText)
+	case <-time.After(5 * time.Second):
+		t.Fatal("未收到导出请求")
+	}
+
+	_, err = NewLoggerProvider(context.Background(), ExporterOptions{Protocol: "udp"})
+	assert.Error(t, err)
+}
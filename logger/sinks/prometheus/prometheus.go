@@ -0,0 +1,46 @@
+// Package prometheus 按日志统计Prometheus指标，不需要额外的日志收集链路即可按错误率告警
+//
+// 创建计数器并注册到Prometheus后，通过 WithErrorCounter 在写入Error及以上级别的日志时自动计数：
+//
+//	counter := prometheus.NewErrorCounter(prom.CounterOpts{Name: "app_log_errors_total"})
+//	prom.MustRegister(counter)
+//	log, _ := logger.NewLogger(cfg, prometheus.WithErrorCounter(counter))
+package prometheus
+
+import (
+	"github.com/constructorvirgil/virlog/logger"
+	prom "github.com/prometheus/client_golang/prometheus"
+	"go.uber.org/zap/zapcore"
+)
+
+const (
+	// LabelLogger 日志记录器名称的标签名，未命名的日志记录器为空字符串
+	LabelLogger = "logger"
+	// LabelCode 消息代码的标签名，没有 code 字段的日志为空字符串
+	LabelCode = "code"
+)
+
+// NewErrorCounter 创建带 logger 和 code 标签的计数器，需要由调用方注册到Prometheus
+func NewErrorCounter(opts prom.CounterOpts) *prom.CounterVec {
+	return prom.NewCounterVec(opts, []string{LabelLogger, LabelCode})
+}
+
+// Hook 返回为每条日志增加计数的钩子，配合 logger.WithLevelHook 使用
+//
+// counter 必须只包含 logger 和 code 两个标签，可以使用 NewErrorCounter 创建
+func Hook(counter *prom.CounterVec) logger.Hook {
+	return func(ent zapcore.Entry, fields []logger.Field) error {
+		code, _ := logger.MessageCode(fields)
+		c, err := counter.GetMetricWith(prom.Labels{LabelLogger: ent.LoggerName, LabelCode: code})
+		if err != nil {
+			return err
+		}
+		c.Inc()
+		return nil
+	}
+}
+
+// WithErrorCounter 在写入Error及以上级别的日志时增加计数
+func WithErrorCounter(counter *prom.CounterVec) logger.Option {
+	return logger.WithLevelHook(logger.ErrorLevel, Hook(counter))
+}
@@ -0,0 +1,42 @@
+package prometheus
+
+import (
+	"io"
+	"testing"
+
+	"github.com/constructorvirgil/virlog/config"
+	"github.com/constructorvirgil/virlog/logger"
+	prom "github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zapcore"
+)
+
+// 测试按日志记录器名称和消息代码统计Error及以上级别的日志
+func TestErrorCounter(t *testing.T) {
+	counter := NewErrorCounter(prom.CounterOpts{Name: "test_log_errors_total"})
+	log, err := logger.NewLogger(config.DefaultConfig(),
+		logger.WithSyncTarget(zapcore.AddSync(io.Discard)),
+		WithErrorCounter(counter))
+	require.NoError(t, err)
+
+	log.Info("ignored", logger.Code("DB001"))
+	log.Warn("ignored", logger.Code("DB001"))
+	log.Error("query failed", logger.Code("DB001"))
+	log.Error("query failed", logger.Code("DB001"))
+	log.Named("auth").With(logger.Code("AUTH001")).Error("login failed")
+	log.Error("no code")
+
+	assert.Equal(t, 2.0, testutil.ToFloat64(counter.WithLabelValues("", "DB001")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(counter.WithLabelValues("auth", "AUTH001")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(counter.WithLabelValues("", "")))
+	assert.Equal(t, 3, testutil.CollectAndCount(counter))
+}
+
+// 测试计数器标签不匹配时返回错误而不是panic
+func TestHookLabelMismatch(t *testing.T) {
+	counter := prom.NewCounterVec(prom.CounterOpts{Name: "test_log_errors_total"}, []string{"level"})
+	err := Hook(counter)(zapcore.Entry{Level: zapcore.ErrorLevel}, nil)
+	assert.Error(t, err)
+}
@@ -0,0 +1,47 @@
+package sentry
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// dsn 解析后的Sentry DSN
+type dsn struct {
+	raw       string
+	publicKey string
+	// envelopeURL 上报事件的地址，如 https://o0.ingest.sentry.io/api/42/envelope/
+	envelopeURL string
+}
+
+// parseDSN 解析形如 https://<public_key>@<host>[/<path>]/<project_id> 的DSN
+func parseDSN(raw string) (*dsn, error) {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return nil, fmt.Errorf("解析Sentry DSN失败: %w", err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, fmt.Errorf("不支持的Sentry DSN协议: %s", u.Scheme)
+	}
+	if u.User == nil || u.User.Username() == "" {
+		return nil, fmt.Errorf("Sentry DSN缺少public key")
+	}
+
+	path := strings.TrimSuffix(u.Path, "/")
+	idx := strings.LastIndex(path, "/")
+	projectID := path[idx+1:]
+	if projectID == "" {
+		return nil, fmt.Errorf("Sentry DSN缺少project id")
+	}
+
+	return &dsn{
+		raw:         raw,
+		publicKey:   u.User.Username(),
+		envelopeURL: fmt.Sprintf("%s://%s%s/api/%s/envelope/", u.Scheme, u.Host, path[:idx], projectID),
+	}, nil
+}
+
+// authHeader 返回 X-Sentry-Auth 请求头的值
+func (d *dsn) authHeader() string {
+	return fmt.Sprintf("Sentry sentry_version=7, sentry_client=%s, sentry_key=%s", clientName, d.publicKey)
+}
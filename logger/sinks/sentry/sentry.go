@@ -0,0 +1,428 @@
+// Package sentry 将Error及以上级别的日志上报到Sentry
+//
+// 导入该包后，在日志配置中设置 ErrorReporting.Sentry.DSN（或环境变量 VIRLOG_SENTRY_DSN）即可启用：
+//
+//	import _ "github.com/constructorvirgil/virlog/logger/sinks/sentry"
+//
+// 也可以通过 New 创建 Client，再使用 logger.WithLevelHook 手动注册
+package sentry
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/constructorvirgil/virlog/config"
+	"github.com/constructorvirgil/virlog/logger"
+	"go.uber.org/zap/zapcore"
+)
+
+// clientName 上报时使用的客户端标识
+const clientName = "virlog.sentry/1.0"
+
+const (
+	// defaultQueueSize 默认的待发送事件队列长度
+	defaultQueueSize = 100
+	// defaultRetryAfter 服务端限流但未指定时长时的默认等待时间
+	defaultRetryAfter = time.Minute
+)
+
+// Options Sentry客户端配置
+type Options struct {
+	// Sentry DSN
+	DSN string
+	// 环境名称
+	Environment string
+	// 版本号
+	Release string
+	// 服务器名称，默认为主机名
+	ServerName string
+	// 作为tag上报的字段名，其余字段作为extra上报
+	Tags []string
+	// 发送事件使用的HTTP客户端，默认超时5秒
+	HTTPClient *http.Client
+	// 待发送事件队列长度，队列满时丢弃新事件，默认100
+	QueueSize int
+}
+
+// Client 异步发送事件到Sentry的客户端
+//
+// Panic和Fatal级别的事件同步发送，保证进程退出前已上报
+type Client struct {
+	dsn    *dsn
+	opts   Options
+	tags   map[string]struct{}
+	client *http.Client
+
+	queue chan []byte
+	// flush 请求后台发送队列中的事件，发送完成后关闭传入的通道
+	flush chan chan struct{}
+	// disabledUntil 服务端限流结束的时间（UnixNano），之前的事件直接丢弃
+	disabledUntil atomic.Int64
+
+	closeOnce sync.Once
+	done      chan struct{}
+	// stopped 后台发送goroutine退出后关闭
+	stopped chan struct{}
+}
+
+// New 创建Sentry客户端
+func New(opts Options) (*Client, error) {
+	d, err := parseDSN(opts.DSN)
+	if err != nil {
+		return nil, err
+	}
+	if opts.ServerName == "" {
+		opts.ServerName, _ = os.Hostname()
+	}
+	if opts.QueueSize <= 0 {
+		opts.QueueSize = defaultQueueSize
+	}
+	httpClient := opts.HTTPClient
+	if httpClient == nil {
+		httpClient = &http.Client{Timeout: 5 * time.Second}
+	}
+
+	c := &Client{
+		dsn:     d,
+		opts:    opts,
+		tags:    make(map[string]struct{}, len(opts.Tags)),
+		client:  httpClient,
+		queue:   make(chan []byte, opts.QueueSize),
+		flush:   make(chan chan struct{}),
+		done:    make(chan struct{}),
+		stopped: make(chan struct{}),
+	}
+	for _, tag := range opts.Tags {
+		c.tags[tag] = struct{}{}
+	}
+
+	go c.run()
+	return c, nil
+}
+
+// Hook 返回上报日志的钩子，配合 logger.WithLevelHook 使用
+func (c *Client) Hook() logger.Hook {
+	return func(ent zapcore.Entry, fields []logger.Field) error {
+		if c.rateLimited() {
+			return nil
+		}
+
+		body, err := c.envelope(ent, fields)
+		if err != nil {
+			return err
+		}
+
+		if ent.Level >= zapcore.PanicLevel {
+			return c.send(body)
+		}
+
+		select {
+		case <-c.done:
+			return nil
+		default:
+		}
+		select {
+		case c.queue <- body:
+		default:
+			// 队列已满，丢弃事件，避免阻塞日志写入
+		}
+		return nil
+	}
+}
+
+// Flush 等待队列中的事件发送完成，超时返回false
+func (c *Client) Flush(timeout time.Duration) bool {
+	finished := make(chan struct{})
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case c.flush <- finished:
+	case <-c.stopped:
+		return true
+	case <-timer.C:
+		return false
+	}
+	select {
+	case <-finished:
+		return true
+	case <-timer.C:
+		return false
+	}
+}
+
+// Close 停止后台发送，队列中尚未发送的事件会被丢弃
+func (c *Client) Close() {
+	c.closeOnce.Do(func() {
+		close(c.done)
+	})
+}
+
+// run 后台发送队列中的事件
+func (c *Client) run() {
+	defer close(c.stopped)
+	for {
+		select {
+		case body := <-c.queue:
+			c.sendQueued(body)
+		case finished := <-c.flush:
+			c.drain()
+			close(finished)
+		case <-c.done:
+			return
+		}
+	}
+}
+
+// drain 发送队列中已有的事件，关闭后不再发送
+func (c *Client) drain() {
+	for {
+		select {
+		case body := <-c.queue:
+			c.sendQueued(body)
+		case <-c.done:
+			return
+		default:
+			return
+		}
+	}
+}
+
+// sendQueued 发送队列中的一个事件，限流期间丢弃
+func (c *Client) sendQueued(body []byte) {
+	if !c.rateLimited() {
+		_ = c.send(body)
+	}
+}
+
+// send 发送一个envelope并处理服务端限流
+func (c *Client) send(body []byte) error {
+	req, err := http.NewRequest(http.MethodPost, c.dsn.envelopeURL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("创建Sentry请求失败: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-sentry-envelope")
+	req.Header.Set("X-Sentry-Auth", c.dsn.authHeader())
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("发送Sentry事件失败: %w", err)
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+
+	c.updateRateLimit(resp)
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("发送Sentry事件失败: %s", resp.Status)
+	}
+	return nil
+}
+
+// rateLimited 是否处于服务端限流期间
+func (c *Client) rateLimited() bool {
+	return time.Now().UnixNano() < c.disabledUntil.Load()
+}
+
+// updateRateLimit 根据响应头更新限流时间
+//
+// 优先使用 X-Sentry-Rate-Limits（格式为 "秒数:类别;类别:范围, ..."），
+// 其次对429响应使用 Retry-After
+func (c *Client) updateRateLimit(resp *http.Response) {
+	now := time.Now()
+	var until time.Time
+
+	if limits := resp.Header.Get("X-Sentry-Rate-Limits"); limits != "" {
+		for _, limit := range strings.Split(limits, ",") {
+			parts := strings.Split(strings.TrimSpace(limit), ":")
+			seconds, err := strconv.ParseFloat(parts[0], 64)
+			if err != nil {
+				continue
+			}
+			if len(parts) > 1 && !appliesToErrors(parts[1]) {
+				continue
+			}
+			if t := now.Add(time.Duration(seconds * float64(time.Second))); t.After(until) {
+				until = t
+			}
+		}
+	} else if resp.StatusCode == http.StatusTooManyRequests {
+		until = now.Add(defaultRetryAfter)
+		if seconds, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil {
+			until = now.Add(time.Duration(seconds) * time.Second)
+		} else if t, err := http.ParseTime(resp.Header.Get("Retry-After")); err == nil {
+			until = t
+		}
+	}
+
+	if until.After(now) {
+		c.disabledUntil.Store(until.UnixNano())
+	}
+}
+
+// appliesToErrors 判断限流类别是否包含错误事件，类别为空表示所有类别
+func appliesToErrors(categories string) bool {
+	if categories == "" {
+		return true
+	}
+	for _, category := range strings.Split(categories, ";") {
+		if category == "error" || category == "default" {
+			return true
+		}
+	}
+	return false
+}
+
+// event Sentry事件
+type event struct {
+	EventID     string                 `json:"event_id"`
+	Timestamp   string                 `json:"timestamp"`
+	Platform    string                 `json:"platform"`
+	Level       string                 `json:"level"`
+	Logger      string                 `json:"logger,omitempty"`
+	Message     string                 `json:"message"`
+	Fingerprint []string               `json:"fingerprint"`
+	Environment string                 `json:"environment,omitempty"`
+	Release     string                 `json:"release,omitempty"`
+	ServerName  string                 `json:"server_name,omitempty"`
+	Tags        map[string]string      `json:"tags,omitempty"`
+	Extra       map[string]interface{} `json:"extra,omitempty"`
+}
+
+// envelope 将日志编码为Sentry envelope
+func (c *Client) envelope(ent zapcore.Entry, fields []logger.Field) ([]byte, error) {
+	enc := zapcore.NewMapObjectEncoder()
+	for _, f := range fields {
+		f.AddTo(enc)
+	}
+
+	ev := event{
+		EventID:   newEventID(),
+		Timestamp: ent.Time.UTC().Format(time.RFC3339Nano),
+		Platform:  "go",
+		Level:     sentryLevel(ent.Level),
+		Logger:    ent.LoggerName,
+		Message:   ent.Message,
+		// 以消息作为指纹，相同消息的事件归为同一个issue
+		Fingerprint: []string{ent.Message},
+		Environment: c.opts.Environment,
+		Release:     c.opts.Release,
+		ServerName:  c.opts.ServerName,
+		Extra:       make(map[string]interface{}, len(enc.Fields)+2),
+	}
+	for k, v := range enc.Fields {
+		if _, ok := c.tags[k]; ok {
+			if ev.Tags == nil {
+				ev.Tags = make(map[string]string)
+			}
+			ev.Tags[k] = fmt.Sprint(v)
+			continue
+		}
+		ev.Extra[k] = v
+	}
+	if ent.Caller.Defined {
+		ev.Extra["caller"] = ent.Caller.TrimmedPath()
+	}
+	if ent.Stack != "" {
+		ev.Extra["stacktrace"] = ent.Stack
+	}
+
+	payload, err := json.Marshal(ev)
+	if err != nil {
+		return nil, fmt.Errorf("序列化Sentry事件失败: %w", err)
+	}
+
+	header, err := json.Marshal(map[string]string{
+		"event_id": ev.EventID,
+		"sent_at":  time.Now().UTC().Format(time.RFC3339Nano),
+		"dsn":      c.dsn.raw,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	buf.Write(header)
+	buf.WriteByte('\n')
+	fmt.Fprintf(&buf, `{"type":"event","length":%d}`, len(payload))
+	buf.WriteByte('\n')
+	buf.Write(payload)
+	buf.WriteByte('\n')
+	return buf.Bytes(), nil
+}
+
+// sentryLevel 将日志级别映射为Sentry级别
+func sentryLevel(level zapcore.Level) string {
+	switch {
+	case level >= zapcore.PanicLevel:
+		return "fatal"
+	case level >= zapcore.ErrorLevel:
+		return "error"
+	case level == zapcore.WarnLevel:
+		return "warning"
+	case level == zapcore.InfoLevel:
+		return "info"
+	default:
+		return "debug"
+	}
+}
+
+// newEventID 生成32位十六进制的事件ID
+func newEventID() string {
+	var b [16]byte
+	_, _ = rand.Read(b[:])
+	return hex.EncodeToString(b[:])
+}
+
+var (
+	// 按配置缓存的客户端，配置变更重建Logger时复用，避免重复创建后台goroutine
+	clients   = make(map[string]*Client)
+	clientsMu sync.Mutex
+)
+
+func init() {
+	_ = logger.RegisterErrorReporter("sentry", fromConfig)
+}
+
+// fromConfig 根据日志配置中的 ErrorReporting.Sentry 创建上报钩子
+func fromConfig(cfg *config.Config) (logger.Hook, zapcore.LevelEnabler, error) {
+	if cfg.ErrorReporting == nil || cfg.ErrorReporting.Sentry == nil || cfg.ErrorReporting.Sentry.DSN == "" {
+		return nil, nil, nil
+	}
+	sc := cfg.ErrorReporting.Sentry
+
+	level := zapcore.ErrorLevel
+	if sc.Level != "" {
+		if err := level.UnmarshalText([]byte(sc.Level)); err != nil {
+			return nil, nil, fmt.Errorf("无效的Sentry上报级别: %s", sc.Level)
+		}
+	}
+
+	key := strings.Join([]string{sc.DSN, sc.Environment, sc.Release, strings.Join(sc.Tags, ",")}, "|")
+	clientsMu.Lock()
+	defer clientsMu.Unlock()
+	client, ok := clients[key]
+	if !ok {
+		var err error
+		client, err = New(Options{
+			DSN:         sc.DSN,
+			Environment: sc.Environment,
+			Release:     sc.Release,
+			Tags:        sc.Tags,
+		})
+		if err != nil {
+			return nil, nil, err
+		}
+		clients[key] = client
+	}
+	return client.Hook(), level, nil
+}
@@ -0,0 +1,174 @@
+package sentry
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/constructorvirgil/virlog/config"
+	"github.com/constructorvirgil/virlog/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zapcore"
+)
+
+// fakeSentry 记录收到的事件的Sentry服务端
+type fakeSentry struct {
+	mu      sync.Mutex
+	events  []event
+	auth    []string
+	handler func(w http.ResponseWriter)
+}
+
+func (f *fakeSentry) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	scanner := bufio.NewScanner(bytes.NewReader(body))
+	var lines []string
+	for scanner.Scan() {
+		lines = append(lines, scanner.Text())
+	}
+
+	f.mu.Lock()
+	if len(lines) == 3 && strings.HasSuffix(r.URL.Path, "/api/42/envelope/") {
+		var ev event
+		if err := json.Unmarshal([]byte(lines[2]), &ev); err == nil {
+			f.events = append(f.events, ev)
+		}
+	}
+	f.auth = append(f.auth, r.Header.Get("X-Sentry-Auth"))
+	handler := f.handler
+	f.mu.Unlock()
+
+	if handler != nil {
+		handler(w)
+	}
+}
+
+func (f *fakeSentry) snapshot() []event {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]event(nil), f.events...)
+}
+
+// 测试通过配置启用Sentry上报
+func TestSentryFromConfig(t *testing.T) {
+	fake := &fakeSentry{}
+	server := httptest.NewServer(fake)
+	defer server.Close()
+
+	cfg := config.DefaultConfig()
+	cfg.ErrorReporting = &config.ErrorReportingConfig{Sentry: &config.SentryConfig{
+		DSN:         strings.Replace(server.URL, "http://", "http://public@", 1) + "/42",
+		Environment: "test",
+		Tags:        []string{"tenant"},
+	}}
+
+	buf := &bytes.Buffer{}
+	log, err := logger.NewLogger(cfg, logger.WithSyncTarget(zapcore.AddSync(buf)))
+	require.NoError(t, err)
+
+	log.Info("not reported")
+	log.With(logger.String("tenant", "acme")).Error("payment failed", logger.Int("order_id", 42))
+
+	require.Eventually(t, func() bool { return len(fake.snapshot()) == 1 }, 3*time.Second, 20*time.Millisecond)
+	ev := fake.snapshot()[0]
+	assert.Equal(t, "payment failed", ev.Message)
+	assert.Equal(t, "error", ev.Level)
+	assert.Equal(t, []string{"payment failed"}, ev.Fingerprint)
+	assert.Equal(t, "test", ev.Environment)
+	assert.Equal(t, map[string]string{"tenant": "acme"}, ev.Tags)
+	assert.EqualValues(t, 42, ev.Extra["order_id"])
+	assert.Contains(t, fake.auth[0], "sentry_key=public")
+
+	// 日志本身仍然正常写入
+	assert.Contains(t, buf.String(), "payment failed")
+}
+
+// 测试服务端限流期间不再发送事件
+func TestSentryRateLimit(t *testing.T) {
+	fake := &fakeSentry{handler: func(w http.ResponseWriter) {
+		w.Header().Set("Retry-After", "60")
+		w.WriteHeader(http.StatusTooManyRequests)
+	}}
+	server := httptest.NewServer(fake)
+	defer server.Close()
+
+	client, err := New(Options{DSN: strings.Replace(server.URL, "http://", "http://public@", 1) + "/42"})
+	require.NoError(t, err)
+	defer client.Close()
+
+	hook := client.Hook()
+	ent := zapcore.Entry{Level: zapcore.ErrorLevel, Time: time.Now(), Message: "boom"}
+	require.NoError(t, hook(ent, nil))
+	require.True(t, client.Flush(time.Second))
+	assert.True(t, client.rateLimited())
+
+	require.NoError(t, hook(ent, nil))
+	require.True(t, client.Flush(time.Second))
+	assert.Len(t, fake.snapshot(), 1)
+}
+
+// 测试并发上报时Flush等待已入队的事件发送完成，关闭后立即返回
+func TestSentryFlushConcurrent(t *testing.T) {
+	fake := &fakeSentry{}
+	server := httptest.NewServer(fake)
+	defer server.Close()
+
+	client, err := New(Options{DSN: strings.Replace(server.URL, "http://", "http://public@", 1) + "/42"})
+	require.NoError(t, err)
+
+	hook := client.Hook()
+	ent := zapcore.Entry{Level: zapcore.ErrorLevel, Time: time.Now(), Message: "boom"}
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 5; j++ {
+				_ = hook(ent, nil)
+				client.Flush(time.Second)
+			}
+		}()
+	}
+	wg.Wait()
+	require.True(t, client.Flush(time.Second))
+	assert.Len(t, fake.snapshot(), 20)
+
+	client.Close()
+	assert.True(t, client.Flush(time.Second))
+}
+
+// 测试限流响应头解析
+func TestUpdateRateLimit(t *testing.T) {
+	client := &Client{}
+	resp := &http.Response{StatusCode: http.StatusOK, Header: http.Header{}}
+	resp.Header.Set("X-Sentry-Rate-Limits", "60:transaction:key")
+	client.updateRateLimit(resp)
+	assert.False(t, client.rateLimited(), "只限制transaction时不影响错误事件")
+
+	resp.Header.Set("X-Sentry-Rate-Limits", "60:transaction:key, 30:error;default:organization")
+	client.updateRateLimit(resp)
+	assert.True(t, client.rateLimited())
+}
+
+// 测试DSN解析
+func TestParseDSN(t *testing.T) {
+	d, err := parseDSN("https://abc@o1.ingest.sentry.io/prefix/123")
+	require.NoError(t, err)
+	assert.Equal(t, "abc", d.publicKey)
+	assert.Equal(t, "https://o1.ingest.sentry.io/prefix/api/123/envelope/", d.envelopeURL)
+
+	_, err = parseDSN("https://o1.ingest.sentry.io/123")
+	assert.Error(t, err)
+	_, err = parseDSN("ftp://abc@host/1")
+	assert.Error(t, err)
+	_, err = parseDSN("https://abc@host/")
+	assert.Error(t, err)
+}
@@ -0,0 +1,277 @@
+package logger
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+const (
+	// sloBurnMessage 燃烧率超过阈值时信号日志的消息
+	sloBurnMessage = "slo_burn"
+	// sloBurnResolvedMessage 燃烧率恢复到阈值以下时信号日志的消息
+	sloBurnResolvedMessage = "slo_burn_resolved"
+	// defaultSLOOperationKey 默认区分操作的字段名
+	defaultSLOOperationKey = "operation"
+	// defaultSLOObjective 默认的目标成功率
+	defaultSLOObjective = 0.999
+	// defaultSLOMinEvents 默认窗口内至少需要的日志条数
+	defaultSLOMinEvents = 10
+	// defaultSLOMaxOperations 默认最多跟踪的操作数
+	defaultSLOMaxOperations = 1000
+	// sloBuckets 每个窗口划分的桶数
+	sloBuckets = 60
+)
+
+// defaultSLOWindows 默认的窗口，参考 Google SRE 的多窗口告警：
+// 5分钟燃烧率超过14.4、1小时燃烧率超过6
+var defaultSLOWindows = []SLOWindow{
+	{Duration: 5 * time.Minute, Threshold: 14.4},
+	{Duration: time.Hour, Threshold: 6},
+}
+
+// SLOWindow 计算燃烧率的滑动窗口
+type SLOWindow struct {
+	// 窗口长度
+	Duration time.Duration
+	// 燃烧率达到该值时输出信号
+	Threshold float64
+}
+
+// SLOOptions SLO燃烧率跟踪的配置
+type SLOOptions struct {
+	// 区分操作的字段名，只统计带有该字符串字段的日志，默认 "operation"
+	OperationKey string
+	// 目标成功率，如0.999表示Error日志占比不超过0.1%，默认0.999
+	Objective float64
+	// 滑动窗口，默认5分钟（阈值14.4）和1小时（阈值6）
+	Windows []SLOWindow
+	// 窗口内日志条数少于该值时不输出信号，默认10
+	MinEvents int
+	// 最多跟踪的操作数，超出后新操作的日志不统计，默认1000
+	MaxOperations int
+	// 输出信号日志的Logger，为nil时使用默认Logger
+	Logger Logger
+}
+
+// SLOSignal 一个操作在一个窗口内的燃烧率
+type SLOSignal struct {
+	// 操作名称
+	Operation string
+	// 窗口长度
+	Window time.Duration
+	// 燃烧率，即Error日志占比与错误预算（1-目标成功率）之比
+	BurnRate float64
+	// 窗口的阈值
+	Threshold float64
+	// 窗口内的Error日志条数和日志总条数
+	Errors int64
+	Total  int64
+	// 是否超过阈值
+	Firing bool
+}
+
+// SLOTracker 按操作统计Error日志占比，燃烧率越过阈值时输出信号
+//
+// 带有 OperationKey 字段的日志计入对应操作，Error及以上级别计为失败。燃烧率在日志写入时计算，
+// 达到阈值时以Warn级别输出 slo_burn 日志，恢复时以Info级别输出 slo_burn_resolved 日志
+type SLOTracker struct {
+	opts SLOOptions
+	// now 返回当前时间，测试时替换
+	now func() time.Time
+
+	mu        sync.Mutex
+	ops       map[string][]*sloWindowState
+	callbacks []func(SLOSignal)
+}
+
+// sloWindowState 一个操作在一个窗口内的计数
+type sloWindowState struct {
+	window  SLOWindow
+	width   int64
+	buckets [sloBuckets]sloBucket
+	firing  bool
+}
+
+// sloBucket 窗口内的一个桶
+type sloBucket struct {
+	// index 桶的序号，即时间除以桶宽度
+	index  int64
+	total  int64
+	errors int64
+}
+
+// NewSLOTracker 创建SLO燃烧率跟踪器，通过 SLOBurn 选项应用到Logger
+func NewSLOTracker(opts SLOOptions) *SLOTracker {
+	if opts.OperationKey == "" {
+		opts.OperationKey = defaultSLOOperationKey
+	}
+	if opts.Objective <= 0 || opts.Objective >= 1 {
+		opts.Objective = defaultSLOObjective
+	}
+	windows := make([]SLOWindow, 0, len(opts.Windows))
+	for _, w := range opts.Windows {
+		if w.Duration > 0 && w.Threshold > 0 {
+			windows = append(windows, w)
+		}
+	}
+	if len(windows) == 0 {
+		windows = defaultSLOWindows
+	}
+	opts.Windows = windows
+	if opts.MinEvents <= 0 {
+		opts.MinEvents = defaultSLOMinEvents
+	}
+	if opts.MaxOperations <= 0 {
+		opts.MaxOperations = defaultSLOMaxOperations
+	}
+	return &SLOTracker{opts: opts, now: time.Now, ops: make(map[string][]*sloWindowState)}
+}
+
+// SLOBurn 使Logger的日志计入 t 的统计，多个Logger可以共享同一个跟踪器，t 为nil时不生效
+//
+// 只统计会输出的日志，低于Logger级别的日志不计入总数
+func SLOBurn(t *SLOTracker) Option {
+	if t == nil {
+		return func(*zapLogger) {}
+	}
+	return WithHook(t.hook)
+}
+
+// OnSignal 注册燃烧率越过阈值时的回调，可用于导出到指标或告警系统，
+// 回调在写入日志的goroutine中执行
+func (t *SLOTracker) OnSignal(callback func(SLOSignal)) {
+	if callback == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.callbacks = append(t.callbacks, callback)
+}
+
+// State 返回所有操作在各窗口内当前的燃烧率，按操作名称和窗口长度排序
+func (t *SLOTracker) State() []SLOSignal {
+	now := t.now().UnixNano()
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	var signals []SLOSignal
+	for op, states := range t.ops {
+		for _, state := range states {
+			signals = append(signals, t.signal(op, state, now))
+		}
+	}
+	sort.Slice(signals, func(i, j int) bool {
+		if signals[i].Operation != signals[j].Operation {
+			return signals[i].Operation < signals[j].Operation
+		}
+		return signals[i].Window < signals[j].Window
+	})
+	return signals
+}
+
+// hook 统计带有操作字段的日志
+func (t *SLOTracker) hook(ent zapcore.Entry, fields []Field) error {
+	op := ""
+	for _, f := range fields {
+		if f.Key == t.opts.OperationKey && f.Type == zapcore.StringType {
+			op = f.String
+		}
+	}
+	if op == "" {
+		return nil
+	}
+	t.record(op, ent.Level >= ErrorLevel)
+	return nil
+}
+
+// record 记录一条日志并输出燃烧率越过阈值的信号
+func (t *SLOTracker) record(op string, failed bool) {
+	now := t.now().UnixNano()
+	t.mu.Lock()
+	states, ok := t.ops[op]
+	if !ok {
+		if len(t.ops) >= t.opts.MaxOperations {
+			t.mu.Unlock()
+			return
+		}
+		states = make([]*sloWindowState, len(t.opts.Windows))
+		for i, w := range t.opts.Windows {
+			width := int64(w.Duration) / sloBuckets
+			if width <= 0 {
+				width = 1
+			}
+			states[i] = &sloWindowState{window: w, width: width}
+		}
+		t.ops[op] = states
+	}
+
+	var changed []SLOSignal
+	for _, state := range states {
+		index := now / state.width
+		bucket := &state.buckets[index%sloBuckets]
+		if bucket.index != index {
+			*bucket = sloBucket{index: index}
+		}
+		bucket.total++
+		if failed {
+			bucket.errors++
+		}
+
+		signal := t.signal(op, state, now)
+		if signal.Firing != state.firing {
+			state.firing = signal.Firing
+			changed = append(changed, signal)
+		}
+	}
+	callbacks := t.callbacks
+	t.mu.Unlock()
+
+	for _, signal := range changed {
+		t.emit(signal)
+		for _, callback := range callbacks {
+			callback(signal)
+		}
+	}
+}
+
+// signal 计算窗口内的燃烧率，窗口内的日志少于 MinEvents 时不视为超过阈值
+func (t *SLOTracker) signal(op string, state *sloWindowState, now int64) SLOSignal {
+	signal := SLOSignal{Operation: op, Window: state.window.Duration, Threshold: state.window.Threshold}
+	current := now / state.width
+	for _, bucket := range state.buckets {
+		if bucket.index > current-sloBuckets && bucket.index <= current {
+			signal.Total += bucket.total
+			signal.Errors += bucket.errors
+		}
+	}
+	if signal.Total > 0 {
+		signal.BurnRate = float64(signal.Errors) / float64(signal.Total) / (1 - t.opts.Objective)
+	}
+	signal.Firing = signal.Total >= int64(t.opts.MinEvents) && signal.BurnRate >= signal.Threshold
+	return signal
+}
+
+// emit 输出信号日志，信号日志使用 slo 字段记录操作名称，不会计入统计
+func (t *SLOTracker) emit(signal SLOSignal) {
+	log := t.opts.Logger
+	if log == nil {
+		log = DefaultLogger()
+	}
+	fields := []Field{
+		String("slo", signal.Operation),
+		Duration("window", signal.Window),
+		Float64("burn_rate", signal.BurnRate),
+		Float64("threshold", signal.Threshold),
+		Float64("objective", t.opts.Objective),
+		Int64("errors", signal.Errors),
+		Int64("total", signal.Total),
+	}
+	if signal.Firing {
+		log.Warn(sloBurnMessage, fields...)
+	} else {
+		log.Info(sloBurnResolvedMessage, fields...)
+	}
+}
@@ -0,0 +1,89 @@
+package logger
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/constructorvirgil/virlog/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zapcore"
+)
+
+// 测试Error占比越过阈值时输出燃烧信号，恢复后输出恢复信号
+func TestSLOBurn(t *testing.T) {
+	signalLog, signalBuf := newBufferLogger(InfoLevel)
+	tracker := NewSLOTracker(SLOOptions{
+		Objective: 0.9,
+		Windows:   []SLOWindow{{Duration: time.Minute, Threshold: 2}},
+		MinEvents: 5,
+		Logger:    signalLog,
+	})
+	now := time.Unix(1700000000, 0)
+	tracker.now = func() time.Time { return now }
+	var signals []SLOSignal
+	tracker.OnSignal(func(s SLOSignal) { signals = append(signals, s) })
+
+	cfg := config.DefaultConfig()
+	log, err := NewLogger(cfg, WithSyncTarget(zapcore.AddSync(&bytes.Buffer{})), SLOBurn(tracker))
+	require.NoError(t, err)
+	checkout := log.With(String("operation", "checkout"))
+
+	// 日志条数不足 MinEvents 时不输出信号
+	checkout.Error("失败")
+	checkout.Error("失败")
+	assert.Empty(t, signals)
+
+	// 5条中4条失败，燃烧率 0.8/0.1 = 8
+	checkout.Info("成功")
+	checkout.Error("失败")
+	checkout.Error("失败")
+	require.Len(t, signals, 1)
+	assert.True(t, signals[0].Firing)
+	assert.Equal(t, "checkout", signals[0].Operation)
+	assert.Equal(t, int64(4), signals[0].Errors)
+	assert.Equal(t, int64(5), signals[0].Total)
+	assert.InDelta(t, 8, signals[0].BurnRate, 0.001)
+
+	entry := findLogEntry(t, signalBuf.String(), sloBurnMessage)
+	require.NotNil(t, entry)
+	assert.Equal(t, "warn", entry["level"])
+	assert.Equal(t, "checkout", entry["slo"])
+	assert.Equal(t, float64(2), entry["threshold"])
+
+	// 不带操作字段的日志不计入
+	log.Error("无关")
+	assert.Equal(t, int64(5), tracker.State()[0].Total)
+
+	// 窗口滑过后旧日志不再计入，燃烧率恢复
+	now = now.Add(2 * time.Minute)
+	for i := 0; i < 5; i++ {
+		checkout.Info("成功")
+	}
+	require.Len(t, signals, 2)
+	assert.False(t, signals[1].Firing)
+	assert.Equal(t, int64(0), signals[1].Errors)
+	assert.NotNil(t, findLogEntry(t, signalBuf.String(), sloBurnResolvedMessage))
+
+	state := tracker.State()
+	require.Len(t, state, 1)
+	assert.Equal(t, time.Minute, state[0].Window)
+	assert.Equal(t, int64(5), state[0].Total)
+}
+
+// 测试默认配置和操作数上限
+func TestSLOTrackerDefaults(t *testing.T) {
+	tracker := NewSLOTracker(SLOOptions{MaxOperations: 1, Windows: []SLOWindow{{Duration: -1}}})
+	assert.Equal(t, defaultSLOOperationKey, tracker.opts.OperationKey)
+	assert.Equal(t, defaultSLOObjective, tracker.opts.Objective)
+	assert.Equal(t, defaultSLOWindows, tracker.opts.Windows)
+
+	tracker.record("a", false)
+	tracker.record("b", true)
+	state := tracker.State()
+	require.Len(t, state, 2, "每个窗口一条")
+	assert.Equal(t, "a", state[0].Operation)
+	assert.Equal(t, 5*time.Minute, state[0].Window)
+	assert.Equal(t, time.Hour, state[1].Window)
+}
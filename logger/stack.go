@@ -0,0 +1,66 @@
+package logger
+
+import (
+	"fmt"
+
+	"github.com/constructorvirgil/virlog/config"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// stacktraceDisabled StacktraceLevel 为该值时不记录调用栈
+const stacktraceDisabled = "disabled"
+
+// noStackMarker 通过 NoStack 添加的标记，字段类型为Skip，编码时被忽略
+type noStackMarker struct{}
+
+// noStackOption 不记录调用栈的zap选项
+var noStackOption = zap.AddStacktrace(zap.LevelEnablerFunc(func(zapcore.Level) bool { return false }))
+
+// NoStack 返回不记录调用栈的标记字段，用于不需要调用栈的预期错误：
+//
+//	log.Error("用户不存在", logger.NoStack(), logger.Err(err))
+//
+// 传给 With 时，派生的Logger的所有日志都不记录调用栈
+func NoStack() Field {
+	return Field{Type: zapcore.SkipType, Interface: noStackMarker{}}
+}
+
+// hasNoStack 判断字段中是否包含 NoStack 标记
+func hasNoStack(fields []Field) bool {
+	for _, f := range fields {
+		if f.Type == zapcore.SkipType {
+			if _, ok := f.Interface.(noStackMarker); ok {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// stacktraceLevel 返回记录调用栈的最低级别，StacktraceLevel 为空时为Error级别，
+// 未启用调用栈或 StacktraceLevel 为 "disabled" 时返回false
+func stacktraceLevel(cfg *config.Config) (zapcore.Level, bool, error) {
+	if !cfg.EnableStacktrace {
+		return 0, false, nil
+	}
+	switch cfg.StacktraceLevel {
+	case "":
+		return ErrorLevel, true, nil
+	case stacktraceDisabled:
+		return 0, false, nil
+	}
+	level, ok := parseLevel(cfg.StacktraceLevel)
+	if !ok {
+		return 0, false, fmt.Errorf("调用栈级别无效: %s", cfg.StacktraceLevel)
+	}
+	return level, true, nil
+}
+
+// raw 返回输出带有 fields 的日志所用的zap Logger，fields 中包含 NoStack 标记时不记录调用栈
+func (l *zapLogger) raw(fields []Field) *zap.Logger {
+	if hasNoStack(fields) {
+		return l.rawZapLogger.WithOptions(noStackOption)
+	}
+	return l.rawZapLogger
+}
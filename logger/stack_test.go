@@ -0,0 +1,85 @@
+package logger
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/constructorvirgil/virlog/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zapcore"
+)
+
+// 测试按配置的级别记录调用栈
+func TestStacktraceLevel(t *testing.T) {
+	cases := []struct {
+		level   string
+		enabled bool
+		warn    bool
+		err     bool
+	}{
+		{level: "", enabled: true, warn: false, err: true},
+		{level: "warn", enabled: true, warn: true, err: true},
+		{level: "disabled", enabled: true, warn: false, err: false},
+		{level: "warn", enabled: false, warn: false, err: false},
+	}
+	for _, c := range cases {
+		buf := &bytes.Buffer{}
+		cfg := config.DefaultConfig()
+		cfg.Format = "json"
+		cfg.EnableStacktrace = c.enabled
+		cfg.StacktraceLevel = c.level
+		log, err := NewLogger(cfg, WithSyncTarget(zapcore.AddSync(buf)))
+		require.NoError(t, err)
+
+		log.Warn("warn message")
+		log.Error("error message")
+		out := buf.String()
+
+		entry := findLogEntry(t, out, "warn message")
+		require.NotNil(t, entry)
+		assert.Equal(t, c.warn, entry["stacktrace"] != nil, "level %q enabled %v", c.level, c.enabled)
+		entry = findLogEntry(t, out, "error message")
+		require.NotNil(t, entry)
+		assert.Equal(t, c.err, entry["stacktrace"] != nil, "level %q enabled %v", c.level, c.enabled)
+	}
+
+	cfg := config.DefaultConfig()
+	cfg.StacktraceLevel = "loud"
+	_, err := NewLogger(cfg)
+	assert.Error(t, err)
+}
+
+// 测试 NoStack 字段关闭单条日志或派生Logger的调用栈
+func TestNoStack(t *testing.T) {
+	buf := &bytes.Buffer{}
+	cfg := config.DefaultConfig()
+	cfg.Format = "json"
+	cfg.EnableStacktrace = true
+	log, err := NewLogger(cfg, WithSyncTarget(zapcore.AddSync(buf)))
+	require.NoError(t, err)
+
+	log.Error("expected error", NoStack(), String("user", "alice"))
+	log.Error("unexpected error")
+	quiet := log.With(NoStack(), String("component", "cache"))
+	quiet.Error("cache miss")
+	quiet.Named("redis").Error("redis miss")
+
+	out := buf.String()
+	entry := findLogEntry(t, out, "expected error")
+	require.NotNil(t, entry)
+	assert.NotContains(t, entry, "stacktrace")
+	assert.Equal(t, "alice", entry["user"])
+	assert.Contains(t, entry["caller"], "stack_test.go")
+
+	entry = findLogEntry(t, out, "unexpected error")
+	require.NotNil(t, entry)
+	assert.Contains(t, entry, "stacktrace")
+
+	for _, msg := range []string{"cache miss", "redis miss"} {
+		entry = findLogEntry(t, out, msg)
+		require.NotNil(t, entry)
+		assert.NotContains(t, entry, "stacktrace", msg)
+		assert.Equal(t, "cache", entry["component"])
+	}
+}
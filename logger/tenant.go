@@ -0,0 +1,279 @@
+package logger
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/constructorvirgil/virlog/config"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+const (
+	// tenantKey 租户Logger输出租户ID的字段名
+	tenantKey = "tenant"
+	// defaultTenantQuotaInterval 未设置配额周期时的默认值
+	defaultTenantQuotaInterval = time.Second
+)
+
+// TenantConfig 单个租户的日志配置
+type TenantConfig struct {
+	// Fields 该租户每条日志附加的字段
+	Fields []Field
+	// Outputs 该租户独立的输出（如单独的文件或自定义输出的topic），取代基础Logger的输出，为空时与基础Logger相同
+	Outputs []config.OutputConfig
+	// Options 创建独立输出的Logger时使用的选项，如 WithHook，仅在设置了 Outputs 时生效
+	Options []Option
+	// Quota 每个 QuotaInterval 内最多输出的日志条数，超出的日志被丢弃，0 表示不限制
+	Quota int
+	// QuotaInterval 配额周期，默认 1 秒
+	QuotaInterval time.Duration
+}
+
+// TenantRegistry 按租户创建并缓存子Logger，适用于多个租户共用一个进程的SaaS服务
+//
+// 租户Logger带有 tenant 字段以及租户配置中的字段，可以使用独立的输出和配额；
+// 未注册的租户使用基础Logger加上 tenant 字段
+type TenantRegistry struct {
+	base Logger
+
+	mu      sync.RWMutex
+	configs map[string]TenantConfig
+	loggers map[string]Logger
+}
+
+// NewTenantRegistry 创建基于 base 的租户Logger注册表，base 为nil时使用默认Logger
+//
+// 租户的独立输出和配额需要 base 由 NewLogger 创建
+func NewTenantRegistry(base Logger) *TenantRegistry {
+	if base == nil {
+		base = DefaultLogger()
+	}
+	return &TenantRegistry{
+		base:    base,
+		configs: make(map[string]TenantConfig),
+		loggers: make(map[string]Logger),
+	}
+}
+
+// Register 注册租户的日志配置并创建其Logger，重复注册会替换之前的配置
+//
+// 被替换的Logger使用独立输出时，缓冲的日志会先被刷新，其输出稍后停止
+func (r *TenantRegistry) Register(tenant string, cfg TenantConfig) error {
+	if tenant == "" {
+		return fmt.Errorf("租户ID不能为空")
+	}
+	log, err := r.newLogger(tenant, cfg)
+	if err != nil {
+		return fmt.Errorf("创建租户 %s 的Logger失败: %w", tenant, err)
+	}
+
+	r.mu.Lock()
+	old := r.loggers[tenant]
+	r.configs[tenant] = cfg
+	r.loggers[tenant] = log
+	r.mu.Unlock()
+
+	if old != nil {
+		_ = old.Sync()
+		r.stopOutputs(old)
+	}
+	return nil
+}
+
+// Unregister 注销租户并刷新其Logger，租户使用独立输出时稍后停止这些输出，之后 Get 返回未注册租户的Logger
+func (r *TenantRegistry) Unregister(tenant string) {
+	r.mu.Lock()
+	old := r.loggers[tenant]
+	delete(r.configs, tenant)
+	delete(r.loggers, tenant)
+	r.mu.Unlock()
+
+	if old != nil {
+		_ = old.Sync()
+		r.stopOutputs(old)
+	}
+}
+
+// stopOutputs 租户Logger使用独立输出时，稍后停止这些输出
+func (r *TenantRegistry) stopOutputs(log Logger) {
+	if outputs := outputsOf(log); outputs != outputsOf(r.base) {
+		stopReplacedOutputs(outputs)
+	}
+}
+
+// Get 返回租户的Logger，同一租户返回同一个实例
+func (r *TenantRegistry) Get(tenant string) Logger {
+	r.mu.RLock()
+	log, ok := r.loggers[tenant]
+	r.mu.RUnlock()
+	if ok {
+		return log
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if log, ok := r.loggers[tenant]; ok {
+		return log
+	}
+	// 未注册的租户只添加字段，不会失败
+	log = r.base.With(String(tenantKey, tenant))
+	r.loggers[tenant] = log
+	return log
+}
+
+// Tenants 返回已注册的租户ID
+func (r *TenantRegistry) Tenants() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	tenants := make([]string, 0, len(r.configs))
+	for tenant := range r.configs {
+		tenants = append(tenants, tenant)
+	}
+	return tenants
+}
+
+// Sync 刷新基础Logger和所有租户Logger的缓冲区
+func (r *TenantRegistry) Sync() error {
+	r.mu.RLock()
+	loggers := make([]Logger, 0, len(r.loggers)+1)
+	loggers = append(loggers, r.base)
+	for _, log := range r.loggers {
+		loggers = append(loggers, log)
+	}
+	r.mu.RUnlock()
+
+	var errs []error
+	for _, log := range loggers {
+		if err := log.Sync(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// newLogger 按租户配置创建Logger
+func (r *TenantRegistry) newLogger(tenant string, cfg TenantConfig) (Logger, error) {
+	log := r.base
+	if len(cfg.Outputs) > 0 || cfg.Quota > 0 {
+		base, ok := r.base.(*zapLogger)
+		if !ok {
+			return nil, fmt.Errorf("租户的独立输出和配额需要基础Logger由 NewLogger 创建")
+		}
+		if len(cfg.Outputs) > 0 {
+			var err error
+			if log, err = newTenantOutputLogger(base, cfg); err != nil {
+				return nil, err
+			}
+		}
+		if cfg.Quota > 0 {
+			log = withTenantQuota(log.(*zapLogger), newTenantQuota(cfg.Quota, cfg.QuotaInterval))
+		}
+	}
+
+	fields := make([]Field, 0, len(cfg.Fields)+1)
+	fields = append(fields, String(tenantKey, tenant))
+	fields = append(fields, cfg.Fields...)
+	return log.With(fields...), nil
+}
+
+// newTenantOutputLogger 使用基础Logger的配置和租户的输出创建Logger，
+// 继承基础Logger当前的级别、With添加的字段、处理器、限流规则和按名称设置的级别
+func newTenantOutputLogger(base *zapLogger, cfg TenantConfig) (Logger, error) {
+	tenantCfg := *base.config
+	tenantCfg.Level = levelName(base.atom.Level())
+	tenantCfg.Outputs = cfg.Outputs
+
+	opts := make([]Option, 0, len(cfg.Options)+1)
+	opts = append(opts, func(l *zapLogger) {
+		l.processors = base.processors
+		l.rateLimits = base.rateLimits
+		l.globalFields = base.globalFields
+		l.minLevel = base.minLevel
+		l.named = base.named
+	})
+	opts = append(opts, cfg.Options...)
+	log, err := NewLogger(&tenantCfg, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return log.With(base.fields...), nil
+}
+
+// withTenantQuota 返回受配额 q 限制的Logger
+func withTenantQuota(log *zapLogger, q *tenantQuota) Logger {
+	clone := *log
+	clone.rawZapLogger = log.rawZapLogger.WithOptions(zap.WrapCore(func(core zapcore.Core) zapcore.Core {
+		return &tenantQuotaCore{Core: core, quota: q}
+	}))
+	return &clone
+}
+
+// tenantQuota 租户在当前周期内的日志计数
+type tenantQuota struct {
+	limit    int
+	interval time.Duration
+	now      func() time.Time
+
+	mu    sync.Mutex
+	start time.Time
+	count int
+	// dropped 尚未报告的被丢弃条数，在下一条输出的日志中报告
+	dropped int
+}
+
+// newTenantQuota 创建每个周期最多允许 limit 条日志的配额
+func newTenantQuota(limit int, interval time.Duration) *tenantQuota {
+	if interval <= 0 {
+		interval = defaultTenantQuotaInterval
+	}
+	return &tenantQuota{limit: limit, interval: interval, now: time.Now}
+}
+
+// allow 记录一条日志，超出配额时返回false，允许时返回需要报告的被丢弃条数
+func (q *tenantQuota) allow() (bool, int) {
+	now := q.now()
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if now.Sub(q.start) >= q.interval {
+		q.start = now
+		q.count = 0
+	}
+	q.count++
+	if q.count > q.limit {
+		q.dropped++
+		return false, 0
+	}
+	dropped := q.dropped
+	q.dropped = 0
+	return true, dropped
+}
+
+// tenantQuotaCore 在最外层按租户配额丢弃日志
+type tenantQuotaCore struct {
+	zapcore.Core
+	quota *tenantQuota
+}
+
+// With 实现zapcore.Core接口，派生的core共享配额
+func (c *tenantQuotaCore) With(fields []Field) zapcore.Core {
+	return &tenantQuotaCore{Core: c.Core.With(fields), quota: c.quota}
+}
+
+// Check 实现zapcore.Core接口，只有启用的级别才计入配额，超出配额后输出的第一条日志带有 suppressed_count 字段
+func (c *tenantQuotaCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if !c.Enabled(ent.Level) {
+		return ce
+	}
+	allowed, dropped := c.quota.allow()
+	if !allowed {
+		return ce
+	}
+	if dropped > 0 {
+		return c.Core.With([]Field{Int(suppressedCountKey, dropped)}).Check(ent, ce)
+	}
+	return c.Core.Check(ent, ce)
+}
@@ -0,0 +1,153 @@
+package logger
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/constructorvirgil/virlog/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zapcore"
+)
+
+// 测试租户Logger带有租户字段并被缓存，注销后回到未注册租户的Logger
+func TestTenantRegistry(t *testing.T) {
+	base, buf := newBufferLogger(InfoLevel)
+	registry := NewTenantRegistry(base.With(String("service", "api")))
+	require.NoError(t, registry.Register("acme", TenantConfig{Fields: []Field{String("plan", "pro")}}))
+	assert.Error(t, registry.Register("", TenantConfig{}))
+
+	acme := registry.Get("acme")
+	assert.Same(t, acme, registry.Get("acme"))
+	acme.Info("acme message")
+	registry.Get("globex").Info("globex message")
+
+	entry := findLogEntry(t, buf.String(), "acme message")
+	require.NotNil(t, entry)
+	assert.Equal(t, "acme", entry["tenant"])
+	assert.Equal(t, "pro", entry["plan"])
+	assert.Equal(t, "api", entry["service"])
+
+	entry = findLogEntry(t, buf.String(), "globex message")
+	require.NotNil(t, entry)
+	assert.Equal(t, "globex", entry["tenant"])
+	assert.NotContains(t, entry, "plan")
+	assert.Equal(t, []string{"acme"}, registry.Tenants())
+
+	registry.Unregister("acme")
+	assert.NotSame(t, acme, registry.Get("acme"))
+	assert.Empty(t, registry.Tenants())
+}
+
+// 测试租户使用独立的输出
+func TestTenantRegistryOutputs(t *testing.T) {
+	shared := &bytes.Buffer{}
+	tenantBuf := &bytes.Buffer{}
+	require.NoError(t, RegisterSink("tenant-shared", zapcore.AddSync(shared)))
+	require.NoError(t, RegisterSink("tenant-acme", zapcore.AddSync(tenantBuf)))
+	defer UnregisterSink("tenant-shared")
+	defer UnregisterSink("tenant-acme")
+
+	cfg := config.DefaultConfig()
+	cfg.Level = "info"
+	cfg.Output = "sink:tenant-shared"
+	base, err := NewLogger(cfg)
+	require.NoError(t, err)
+	base.SetLevel(WarnLevel)
+
+	registry := NewTenantRegistry(base.With(String("service", "api")))
+	require.NoError(t, registry.Register("acme", TenantConfig{
+		Outputs: []config.OutputConfig{{Type: "sink:tenant-acme", Format: "json"}},
+	}))
+	log := registry.Get("acme")
+	log.Info("below base level")
+	log.Warn("acme warning")
+	require.NoError(t, registry.Sync())
+
+	assert.Empty(t, shared.String())
+	entry := findLogEntry(t, tenantBuf.String(), "acme warning")
+	require.NotNil(t, entry)
+	assert.Equal(t, "acme", entry["tenant"])
+	assert.Equal(t, "api", entry["service"])
+	assert.NotContains(t, tenantBuf.String(), "below base level")
+
+	// 重新注册和注销后停止租户的独立输出，不影响基础Logger的输出
+	originalGrace := replacedOutputGrace.Load()
+	replacedOutputGrace.Store(0)
+	defer func() { replacedOutputGrace.Store(originalGrace) }()
+	replaced := outputsOf(log)
+	require.NoError(t, registry.Register("acme", TenantConfig{
+		Outputs: []config.OutputConfig{{Type: "sink:tenant-acme", Format: "json"}},
+	}))
+	assert.Eventually(t, func() bool {
+		replaced.mu.Lock()
+		defer replaced.mu.Unlock()
+		return replaced.stopped
+	}, time.Second, 10*time.Millisecond)
+	current := outputsOf(registry.Get("acme"))
+	registry.Unregister("acme")
+	assert.Eventually(t, func() bool {
+		current.mu.Lock()
+		defer current.mu.Unlock()
+		return current.stopped
+	}, time.Second, 10*time.Millisecond)
+	assert.False(t, outputsOf(base).stopped)
+
+	// 无效的输出配置在注册时返回错误
+	err = registry.Register("bad", TenantConfig{
+		Outputs: []config.OutputConfig{{Type: "sink:tenant-acme", Level: "loud"}},
+	})
+	assert.Error(t, err)
+}
+
+// 测试租户配额限制每个周期的日志条数
+func TestTenantRegistryQuota(t *testing.T) {
+	base, buf := newBufferLogger(InfoLevel)
+	registry := NewTenantRegistry(base)
+	require.NoError(t, registry.Register("acme", TenantConfig{Quota: 2, QuotaInterval: time.Minute}))
+
+	// 替换配额的时钟
+	log := registry.Get("acme").(*zapLogger)
+	now := time.Now()
+	quotaCore := findTenantQuotaCore(t, log.rawZapLogger.Core())
+	quotaCore.quota.now = func() time.Time { return now }
+	quotaCore.quota.start = now
+
+	log.Debug("disabled")
+	log.Info("first")
+	log.With(String("k", "v")).Info("second")
+	log.Info("dropped 1")
+	log.Error("dropped 2")
+	registry.Get("globex").Info("other tenant")
+
+	out := buf.String()
+	assert.Contains(t, out, "first")
+	assert.Contains(t, out, "second")
+	assert.NotContains(t, out, "dropped")
+	assert.Contains(t, out, "other tenant")
+
+	now = now.Add(time.Minute)
+	log.Info("next period")
+	entry := findLogEntry(t, buf.String(), "next period")
+	require.NotNil(t, entry)
+	assert.Equal(t, float64(2), entry[suppressedCountKey])
+
+	// 基础Logger不是由 NewLogger 创建时不支持配额
+	custom := NewTenantRegistry(nopLogger{Logger: base})
+	assert.Error(t, custom.Register("acme", TenantConfig{Quota: 1}))
+	assert.NoError(t, custom.Register("acme", TenantConfig{}))
+}
+
+// findTenantQuotaCore 返回Logger的租户配额core
+func findTenantQuotaCore(t *testing.T, core zapcore.Core) *tenantQuotaCore {
+	t.Helper()
+	c, ok := core.(*tenantQuotaCore)
+	require.True(t, ok, "最外层不是租户配额core: %T", core)
+	return c
+}
+
+// nopLogger 包装Logger，使其不是 *zapLogger
+type nopLogger struct {
+	Logger
+}
@@ -0,0 +1,119 @@
+package vconfig
+
+import (
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// defaultApprovalTimeout 等待确认配置变更的默认超时时间
+const defaultApprovalTimeout = time.Minute
+
+// pendingChange 等待确认的配置变更
+type pendingChange struct {
+	event fsnotify.Event
+	items []ConfigChangedItem
+	// apply 应用变更并触发 OnChange 回调
+	apply func()
+	timer *time.Timer
+}
+
+// WithApproval 开启变更确认：配置源中检测到的变更先暂存而不应用，OnPendingChange 注册的回调收到待应用的变更后，
+// 需要在 timeout 内调用 Approve 应用或 Reject 放弃，超时视为拒绝；timeout 不大于0时使用1分钟
+//
+// 适用于部分配置变更需要多个实例协调后再应用的服务。UpdateFunc 和 Rollback 主动修改的配置不需要确认
+func WithApproval[T any](timeout time.Duration) ConfigOption[T] {
+	return func(c *Config[T]) {
+		if timeout <= 0 {
+			timeout = defaultApprovalTimeout
+		}
+		c.approvalTimeout = timeout
+	}
+}
+
+// OnPendingChange 添加收到待确认变更时的回调函数，回调中或之后需要调用 Approve 或 Reject
+func (c *Config[T]) OnPendingChange(callback OnConfigChangeCallback) {
+	c.callbackMu.Lock()
+	defer c.callbackMu.Unlock()
+	c.pendingCallbacks = append(c.pendingCallbacks, callback)
+}
+
+// PendingChanges 返回等待确认的变更项，没有待确认的变更时返回nil
+func (c *Config[T]) PendingChanges() []ConfigChangedItem {
+	c.pendingMu.Lock()
+	defer c.pendingMu.Unlock()
+	if c.pending == nil {
+		return nil
+	}
+	return c.pending.items
+}
+
+// Approve 应用等待确认的变更并触发 OnChange 回调，没有待确认的变更时返回 ErrNoPendingChange
+func (c *Config[T]) Approve() error {
+	p := c.takePending(nil)
+	if p == nil {
+		return ErrNoPendingChange
+	}
+	p.apply()
+	return nil
+}
+
+// Reject 放弃等待确认的变更，当前配置保持不变，没有待确认的变更时返回 ErrNoPendingChange
+//
+// 配置源中的配置再次变化时会重新等待确认
+func (c *Config[T]) Reject(reason string) error {
+	p := c.takePending(nil)
+	if p == nil {
+		return ErrNoPendingChange
+	}
+	getInternalLogger().Infow("配置变更被拒绝", "source", p.event.Name, "changes", len(p.items), "reason", reason)
+	return nil
+}
+
+// stageChange 开启变更确认时暂存配置源中的新配置并通知 OnPendingChange 回调，返回false表示未开启变更确认
+//
+// 已有待确认的变更时以新的变更替换并重新计时，新配置与当前配置相同时只丢弃已有的待确认变更
+func (c *Config[T]) stageChange(data T, event fsnotify.Event, apply func()) bool {
+	if c.approvalTimeout <= 0 {
+		return false
+	}
+
+	items := findConfigChanges(c.GetData(), data, "")
+	c.pendingMu.Lock()
+	if c.pending != nil {
+		c.pending.timer.Stop()
+		c.pending = nil
+	}
+	if len(items) == 0 {
+		c.pendingMu.Unlock()
+		return true
+	}
+	p := &pendingChange{event: event, items: items, apply: apply}
+	p.timer = time.AfterFunc(c.approvalTimeout, func() {
+		if c.takePending(p) != nil {
+			getInternalLogger().Infow("配置变更等待确认超时，已放弃", "source", event.Name, "changes", len(items))
+		}
+	})
+	c.pending = p
+	c.pendingMu.Unlock()
+
+	// 回调中可能调用 Approve 触发 OnChange 回调，在锁外执行
+	c.callbackMu.RLock()
+	callbacks := append([]OnConfigChangeCallback(nil), c.pendingCallbacks...)
+	c.callbackMu.RUnlock()
+	c.runCallbacks(callbacks, event, items)
+	return true
+}
+
+// takePending 取出并清除等待确认的变更，p 不为nil时只在当前待确认的变更为 p 时取出
+func (c *Config[T]) takePending(p *pendingChange) *pendingChange {
+	c.pendingMu.Lock()
+	defer c.pendingMu.Unlock()
+	if c.pending == nil || (p != nil && c.pending != p) {
+		return nil
+	}
+	taken := c.pending
+	taken.timer.Stop()
+	c.pending = nil
+	return taken
+}
@@ -0,0 +1,120 @@
+package vconfig
+
+import (
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// waitPending 等待收到待确认的变更
+func waitPending(t *testing.T, pending <-chan []ConfigChangedItem) []ConfigChangedItem {
+	t.Helper()
+	select {
+	case items := <-pending:
+		return items
+	case <-time.After(3 * time.Second):
+		t.Fatal("等待待确认的配置变更超时")
+		return nil
+	}
+}
+
+// newApprovalConfig 创建开启变更确认的配置，返回收到的待确认变更和已应用变更
+func newApprovalConfig(t *testing.T, configFile string, timeout time.Duration) (*Config[AppConfig], <-chan []ConfigChangedItem, <-chan int) {
+	cfg, err := NewConfig(newDefaultConfig(),
+		WithConfigFile[AppConfig](configFile),
+		WithDebounceTime[AppConfig](10*time.Millisecond),
+		WithApproval[AppConfig](timeout))
+	require.NoError(t, err)
+	t.Cleanup(cfg.Close)
+
+	pending := make(chan []ConfigChangedItem, 10)
+	cfg.OnPendingChange(func(e fsnotify.Event, changedItems []ConfigChangedItem) {
+		assert.Equal(t, filepath.Clean(configFile), e.Name)
+		pending <- changedItems
+	})
+	applied := make(chan int, 10)
+	cfg.OnChange(func(e fsnotify.Event, changedItems []ConfigChangedItem) {
+		applied <- cfg.GetData().Server.Port
+	})
+	return cfg, pending, applied
+}
+
+// 测试确认后才应用配置文件中的变更
+func TestApprovalApprove(t *testing.T) {
+	configFile := filepath.Join(t.TempDir(), "app.yaml")
+	cfg, pending, applied := newApprovalConfig(t, configFile, time.Minute)
+
+	require.NoError(t, os.WriteFile(configFile, []byte("server:\n  port: 7001\n"), 0644))
+	items := waitPending(t, pending)
+	require.Len(t, items, 1)
+	assert.Equal(t, "server.port", items[0].Path)
+	assert.Equal(t, 8080, items[0].OldValue)
+	assert.Equal(t, 7001, items[0].NewValue)
+	assert.Equal(t, items, cfg.PendingChanges())
+
+	// 确认前配置保持不变
+	assert.Equal(t, 8080, cfg.GetData().Server.Port)
+	assert.Empty(t, applied)
+
+	require.NoError(t, cfg.Approve())
+	assert.Equal(t, 7001, cfg.GetData().Server.Port)
+	waitPort(t, applied, 7001)
+	assert.Nil(t, cfg.PendingChanges())
+	assert.ErrorIs(t, cfg.Approve(), ErrNoPendingChange)
+}
+
+// 测试拒绝和超时未确认的变更不会应用
+func TestApprovalRejectAndTimeout(t *testing.T) {
+	configFile := filepath.Join(t.TempDir(), "app.yaml")
+	cfg, pending, applied := newApprovalConfig(t, configFile, 200*time.Millisecond)
+
+	require.NoError(t, os.WriteFile(configFile, []byte("server:\n  port: 7001\n"), 0644))
+	waitPending(t, pending)
+	require.NoError(t, cfg.Reject("需要与其他服务协调"))
+	assert.ErrorIs(t, cfg.Reject("again"), ErrNoPendingChange)
+	assert.Nil(t, cfg.PendingChanges())
+	assert.Equal(t, 8080, cfg.GetData().Server.Port)
+
+	require.NoError(t, os.WriteFile(configFile, []byte("server:\n  port: 7002\n"), 0644))
+	waitPending(t, pending)
+	assert.Eventually(t, func() bool {
+		return cfg.PendingChanges() == nil
+	}, 3*time.Second, 20*time.Millisecond)
+	assert.ErrorIs(t, cfg.Approve(), ErrNoPendingChange)
+	assert.Equal(t, 8080, cfg.GetData().Server.Port)
+	assert.Empty(t, applied)
+}
+
+// 测试在回调中直接确认远程配置源的变更
+func TestApprovalRemoteSource(t *testing.T) {
+	source := &fakeHTTPSource{}
+	source.set("server:\n  port: 9000\n")
+	server := httptest.NewServer(source)
+	defer server.Close()
+
+	cfg, err := NewConfig(newDefaultConfig(),
+		WithHTTPSource[AppConfig](server.URL, 20*time.Millisecond, nil),
+		WithApproval[AppConfig](0))
+	require.NoError(t, err)
+	defer cfg.Close()
+	assert.Equal(t, 9000, cfg.GetData().Server.Port)
+
+	applied := make(chan int, 10)
+	cfg.OnChange(func(e fsnotify.Event, changedItems []ConfigChangedItem) {
+		applied <- cfg.GetData().Server.Port
+	})
+	cfg.OnPendingChange(func(e fsnotify.Event, changedItems []ConfigChangedItem) {
+		assert.Equal(t, server.URL, e.Name)
+		assert.NoError(t, cfg.Approve())
+	})
+
+	source.set("server:\n  port: 9100\n")
+	waitPort(t, applied, 9100)
+	assert.Equal(t, 9100, cfg.GetData().Server.Port)
+}
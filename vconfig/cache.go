@@ -0,0 +1,224 @@
+package vconfig
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// localCacheRetryInterval 使用本地缓存启动后重新连接配置源的间隔
+var localCacheRetryInterval = 5 * time.Second
+
+// localCache 远程配置源的本地缓存，保存最近一次成功加载的配置
+type localCache struct {
+	path string
+	ttl  time.Duration
+
+	mu sync.Mutex
+	// written 最近一次写入的内容，内容未变化时不重复写入
+	written []byte
+	// stop 关闭时停止重新连接配置源
+	stop     chan struct{}
+	stopOnce sync.Once
+	// wg 等待重新连接配置源的goroutine退出
+	wg sync.WaitGroup
+}
+
+// WithLocalCache 为ETCD、Nacos和HTTP配置源启用本地缓存，每次成功加载配置后以 WithConfigType 指定的格式写入 path
+//
+// 启动时无法从配置源读取配置时使用缓存中的配置，此时 Health 中的 Stale 为true，并在后台定期重新连接配置源，
+// 成功后加载最新配置并开始监听变更。ttl 大于0时超过 ttl 的缓存视为过期，不再使用
+func WithLocalCache[T any](path string, ttl time.Duration) ConfigOption[T] {
+	return func(c *Config[T]) {
+		c.localCache = &localCache{path: path, ttl: ttl, stop: make(chan struct{})}
+	}
+}
+
+// save 写入缓存，内容未变化时跳过
+func (lc *localCache) save(content []byte) error {
+	lc.mu.Lock()
+	defer lc.mu.Unlock()
+	if bytes.Equal(content, lc.written) {
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(lc.path), 0755); err != nil {
+		return fmt.Errorf("创建缓存目录失败: %w", err)
+	}
+	// 配置中可能包含密码等敏感信息，只允许当前用户读写
+	if err := atomicWriteFile(lc.path, content, 0600); err != nil {
+		return err
+	}
+	lc.written = content
+	return nil
+}
+
+// load 读取未过期的缓存，返回缓存内容和写入时间
+func (lc *localCache) load() ([]byte, time.Time, error) {
+	info, err := os.Stat(lc.path)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	if lc.ttl > 0 && time.Since(info.ModTime()) > lc.ttl {
+		return nil, time.Time{}, fmt.Errorf("本地缓存已过期: %s", info.ModTime().Format(time.RFC3339))
+	}
+	content, err := os.ReadFile(lc.path)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	lc.mu.Lock()
+	lc.written = content
+	lc.mu.Unlock()
+	return content, info.ModTime(), nil
+}
+
+// close 停止重新连接配置源
+func (lc *localCache) close() {
+	lc.stopOnce.Do(func() { close(lc.stop) })
+}
+
+// wait 等待重新连接配置源的goroutine退出
+func (lc *localCache) wait() {
+	lc.wg.Wait()
+}
+
+// saveLocalCache 将当前配置写入本地缓存
+func (c *Config[T]) saveLocalCache() {
+	if c.localCache == nil {
+		return
+	}
+	content, err := marshalConfig(c.GetData(), c.configType)
+	if err == nil {
+		err = c.localCache.save(content)
+	}
+	if err != nil {
+		getInternalLogger().Errorw("写入本地配置缓存失败", "path", c.localCache.path, "error", err)
+	}
+}
+
+// initWithLocalCache 从配置源加载失败时使用本地缓存中的配置，返回缓存的写入时间
+//
+// 配置源客户端创建失败（如配置错误）或没有可用的缓存时返回 cause
+func (c *Config[T]) initWithLocalCache(cause error) (time.Time, error) {
+	if c.localCache == nil || (c.etcdClient == nil && c.nacosClient == nil && c.httpSource == nil) {
+		return time.Time{}, cause
+	}
+	content, cacheTime, err := c.localCache.load()
+	if err != nil {
+		return time.Time{}, errors.Join(cause, fmt.Errorf("读取本地配置缓存失败: %w", err))
+	}
+
+	var data T
+	if err := unmarshalConfig(content, &data, c.configType); err != nil {
+		return time.Time{}, errors.Join(cause, fmt.Errorf("解析本地配置缓存失败: %w", err))
+	}
+	if err := applyDefaults(&data); err != nil {
+		return time.Time{}, err
+	}
+	if err := c.runOnLoad(&data); err != nil {
+		return time.Time{}, err
+	}
+	c.setData(data)
+	getInternalLogger().Errorw("无法从配置源加载配置，使用本地缓存", "source", c.sourceName(), "path", c.localCache.path,
+		"cache_time", cacheTime, "error", cause)
+	return cacheTime, nil
+}
+
+// reconnectSource 定期重新从配置源加载配置，成功后开始监听变更
+func (c *Config[T]) reconnectSource() {
+	c.localCache.wg.Add(1)
+	go func() {
+		defer c.localCache.wg.Done()
+		ticker := time.NewTicker(localCacheRetryInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-c.localCache.stop:
+				return
+			case <-ticker.C:
+			}
+			if c.loadFromSource() {
+				return
+			}
+		}
+	}()
+}
+
+// loadFromSource 从配置源读取配置并开始监听变更，配置源仍不可用时返回false
+func (c *Config[T]) loadFromSource() bool {
+	data, name, ok := c.fetchFromSource()
+	if !ok {
+		return false
+	}
+	// 回调可能关闭配置，在锁外应用新配置
+	if data != nil {
+		c.setBaseline(c.GetData())
+		c.applyRemoteData(*data, name)
+	}
+	return true
+}
+
+// fetchFromSource 读取配置源中的配置并开始监听变更，配置不存在或无法解析时 data 为nil
+//
+// 配置不存在时继续使用缓存中的配置，不再视为过期
+func (c *Config[T]) fetchFromSource() (data *T, name string, ok bool) {
+	c.closedMu.RLock()
+	defer c.closedMu.RUnlock()
+	if c.closed {
+		return nil, "", true
+	}
+
+	var (
+		content []byte
+		err     error
+	)
+	switch {
+	case c.etcdClient != nil:
+		name = c.etcdConfig.Key
+		var exists bool
+		data = new(T)
+		exists, err = loadConfigFromETCD(c.etcdClient, data, c.configType)
+		c.health.recordSource(err)
+		if err != nil {
+			return nil, name, false
+		}
+		if !exists {
+			data = nil
+			c.health.recordLoad()
+		}
+		c.watchETCD()
+		return data, name, true
+	case c.nacosClient != nil:
+		name = c.nacosConfig.Group + "/" + c.nacosConfig.DataID
+		content, err = c.nacosClient.get()
+	case c.httpSource != nil:
+		name = c.httpConfig.URL
+		content, _, err = c.httpSource.fetch()
+	default:
+		return nil, "", true
+	}
+	c.health.recordSource(err)
+	if err != nil {
+		return nil, name, false
+	}
+
+	if content == nil {
+		c.health.recordLoad()
+	} else {
+		data = new(T)
+		if err := unmarshalConfig(content, data, c.configType); err != nil {
+			getInternalLogger().Errorw("解析配置失败", "key", name, "config_type", c.configType, "error", err)
+			c.recordLoadError(err)
+			data = nil
+		}
+	}
+	if c.nacosClient != nil {
+		c.watchNacos(content)
+	} else {
+		c.watchHTTP(content)
+	}
+	return data, name, true
+}
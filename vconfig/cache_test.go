@@ -0,0 +1,108 @@
+package vconfig
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// 测试配置源不可用时使用本地缓存启动，恢复后加载最新配置
+func TestLocalCache(t *testing.T) {
+	retryInterval := localCacheRetryInterval
+	localCacheRetryInterval = 20 * time.Millisecond
+	defer func() { localCacheRetryInterval = retryInterval }()
+
+	source := &fakeHTTPSource{}
+	source.set("app:\n  name: remote\nserver:\n  port: 9000\n")
+	var down atomic.Bool
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if down.Load() {
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+			return
+		}
+		source.ServeHTTP(w, r)
+	}))
+	defer server.Close()
+
+	cachePath := filepath.Join(t.TempDir(), "cache", "app.yaml")
+	newCfg := func(ttl time.Duration) (*Config[AppConfig], error) {
+		return NewConfig(newDefaultConfig(),
+			WithHTTPSource[AppConfig](server.URL, time.Hour, nil),
+			WithLocalCache[AppConfig](cachePath, ttl))
+	}
+
+	// 成功加载后写入缓存
+	cfg, err := newCfg(time.Hour)
+	require.NoError(t, err)
+	assert.False(t, cfg.Health().Stale)
+	cfg.Close()
+	content, err := os.ReadFile(cachePath)
+	require.NoError(t, err)
+	assert.Contains(t, string(content), "port: 9000")
+	info, err := os.Stat(cachePath)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+
+	// 配置源不可用时使用缓存
+	down.Store(true)
+	source.set("app:\n  name: remote\nserver:\n  port: 9100\n")
+	cfg, err = newCfg(time.Hour)
+	require.NoError(t, err)
+	defer cfg.Close()
+	assert.Equal(t, 9000, cfg.GetData().Server.Port)
+	health := cfg.Health()
+	assert.True(t, health.Stale)
+	assert.False(t, health.Healthy)
+	assert.Equal(t, info.ModTime(), health.CacheTime)
+	assert.Contains(t, health.LastError, "503")
+
+	// 配置源恢复后加载最新配置并更新缓存
+	down.Store(false)
+	require.Eventually(t, func() bool {
+		return !cfg.Health().Stale
+	}, 3*time.Second, 10*time.Millisecond)
+	assert.Equal(t, 9100, cfg.GetData().Server.Port)
+	assert.True(t, cfg.Health().Healthy)
+	assert.True(t, cfg.Health().CacheTime.IsZero())
+	content, err = os.ReadFile(cachePath)
+	require.NoError(t, err)
+	assert.Contains(t, string(content), "port: 9100")
+}
+
+// 测试缓存不存在或过期时返回配置源的错误
+func TestLocalCacheUnavailable(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	defer server.Close()
+
+	cachePath := filepath.Join(t.TempDir(), "app.yaml")
+	_, err := NewConfig(newDefaultConfig(),
+		WithHTTPSource[AppConfig](server.URL, time.Hour, nil),
+		WithLocalCache[AppConfig](cachePath, time.Hour))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "404")
+
+	require.NoError(t, os.WriteFile(cachePath, []byte("server:\n  port: 9000\n"), 0600))
+	old := time.Now().Add(-2 * time.Hour)
+	require.NoError(t, os.Chtimes(cachePath, old, old))
+	_, err = NewConfig(newDefaultConfig(),
+		WithHTTPSource[AppConfig](server.URL, time.Hour, nil),
+		WithLocalCache[AppConfig](cachePath, time.Hour))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "过期")
+
+	// ttl 为0时缓存不过期
+	cfg, err := NewConfig(newDefaultConfig(),
+		WithHTTPSource[AppConfig](server.URL, time.Hour, nil),
+		WithLocalCache[AppConfig](cachePath, 0))
+	require.NoError(t, err)
+	defer cfg.Close()
+	assert.Equal(t, 9000, cfg.GetData().Server.Port)
+	assert.True(t, cfg.Health().Stale)
+}
@@ -0,0 +1,85 @@
+package vconfig
+
+import (
+	"errors"
+	"fmt"
+	"runtime/debug"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// ErrCallbackPanic 配置变更回调函数panic
+var ErrCallbackPanic = errors.New("配置变更回调函数panic")
+
+// OnConfigErrorCallback 监听配置期间发生错误时的回调函数类型
+type OnConfigErrorCallback func(err error)
+
+// OnError 添加监听配置期间发生错误时的回调函数，包括配置源中的新配置无法解析、处理或校验，
+// 以及变更回调函数panic（错误包装了 ErrCallbackPanic）
+//
+// 回调函数panic时会被恢复，配置继续监听，其余回调函数照常执行
+func (c *Config[T]) OnError(callback OnConfigErrorCallback) {
+	c.callbackMu.Lock()
+	defer c.callbackMu.Unlock()
+	c.errorCallbacks = append(c.errorCallbacks, callback)
+}
+
+// notifyChange 依次执行 OnChange 回调函数
+//
+// 回调函数在锁外执行，回调中可以添加回调函数
+func (c *Config[T]) notifyChange(e fsnotify.Event, changedItems []ConfigChangedItem) {
+	c.callbackMu.RLock()
+	callbacks := append([]OnConfigChangeCallback(nil), c.changeCallbacks...)
+	c.callbackMu.RUnlock()
+	c.runCallbacks(callbacks, e, changedItems)
+}
+
+// runCallbacks 依次执行回调函数，回调函数panic时恢复并通过 OnError 报告，继续执行其余回调函数
+func (c *Config[T]) runCallbacks(callbacks []OnConfigChangeCallback, e fsnotify.Event, changedItems []ConfigChangedItem) {
+	for _, callback := range callbacks {
+		if callback != nil {
+			c.runCallback(callback, e, changedItems)
+		}
+	}
+}
+
+// runCallback 执行一个回调函数并恢复其中的panic
+func (c *Config[T]) runCallback(callback OnConfigChangeCallback, e fsnotify.Event, changedItems []ConfigChangedItem) {
+	c.callbacksRunning.Add(1)
+	defer c.callbacksRunning.Add(-1)
+	defer func() {
+		if r := recover(); r != nil {
+			getInternalLogger().Errorw("配置变更回调函数panic", "source", e.Name, "panic", r, "stack", string(debug.Stack()))
+			c.reportError(fmt.Errorf("%w: %v", ErrCallbackPanic, r))
+		}
+	}()
+	callback(e, changedItems)
+}
+
+// recordLoadError 记录配置源中的新配置加载失败并通过 OnError 报告
+func (c *Config[T]) recordLoadError(err error) {
+	c.health.recordLoadError(err)
+	c.reportError(err)
+}
+
+// reportError 通过 OnError 回调函数报告错误，错误回调函数panic时只记录日志
+func (c *Config[T]) reportError(err error) {
+	c.callbackMu.RLock()
+	callbacks := append([]OnConfigErrorCallback(nil), c.errorCallbacks...)
+	c.callbackMu.RUnlock()
+	for _, callback := range callbacks {
+		if callback == nil {
+			continue
+		}
+		func() {
+			c.callbacksRunning.Add(1)
+			defer c.callbacksRunning.Add(-1)
+			defer func() {
+				if r := recover(); r != nil {
+					getInternalLogger().Errorw("配置错误回调函数panic", "error", err, "panic", r)
+				}
+			}()
+			callback(err)
+		}()
+	}
+}
@@ -0,0 +1,89 @@
+package vconfig
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// 测试回调函数panic后继续监听并通过 OnError 报告
+func TestCallbackPanic(t *testing.T) {
+	dir := t.TempDir()
+	configFile := filepath.Join(dir, "app.yaml")
+	cfg, changes := newWatchedConfig(t, configFile)
+
+	errs := make(chan error, 10)
+	cfg.OnError(func(err error) {
+		errs <- err
+	})
+	// 在已注册的回调之后panic，之前的回调照常执行
+	cfg.OnChange(func(e fsnotify.Event, changedItems []ConfigChangedItem) {
+		panic("boom")
+	})
+
+	for _, port := range []int{7201, 7202} {
+		require.NoError(t, os.WriteFile(configFile, []byte("server:\n  port: "+strconv.Itoa(port)+"\n"), 0644))
+		waitPort(t, changes, port)
+		select {
+		case err := <-errs:
+			assert.ErrorIs(t, err, ErrCallbackPanic)
+			assert.ErrorContains(t, err, "boom")
+		case <-time.After(3 * time.Second):
+			t.Fatal("未收到回调panic的错误")
+		}
+	}
+
+	// 配置文件无效时同样通过 OnError 报告
+	require.NoError(t, os.WriteFile(configFile, []byte("server: [\n"), 0644))
+	select {
+	case err := <-errs:
+		assert.False(t, errors.Is(err, ErrCallbackPanic))
+	case <-time.After(3 * time.Second):
+		t.Fatal("未收到配置加载失败的错误")
+	}
+	assert.Equal(t, 7202, cfg.GetData().Server.Port)
+}
+
+// 测试在回调函数中添加回调函数
+func TestCallbackRegisterInCallback(t *testing.T) {
+	dir := t.TempDir()
+	configFile := filepath.Join(dir, "app.yaml")
+	cfg, err := NewConfig(newDefaultConfig(), WithConfigFile[AppConfig](configFile))
+	require.NoError(t, err)
+	defer cfg.Close()
+
+	var added int
+	cfg.OnChange(func(e fsnotify.Event, changedItems []ConfigChangedItem) {
+		cfg.OnChange(func(e fsnotify.Event, changedItems []ConfigChangedItem) {
+			added++
+		})
+	})
+
+	done := make(chan error, 1)
+	go func() {
+		done <- cfg.UpdateFunc(func(data *AppConfig) error {
+			data.Server.Port = 7300
+			return nil
+		})
+	}()
+	select {
+	case err := <-done:
+		require.NoError(t, err)
+	case <-time.After(3 * time.Second):
+		t.Fatal("在回调中添加回调函数时死锁")
+	}
+	assert.Equal(t, 0, added, "本次通知不执行新添加的回调")
+
+	require.NoError(t, cfg.UpdateFunc(func(data *AppConfig) error {
+		data.Server.Port = 7301
+		return nil
+	}))
+	assert.Equal(t, 1, added)
+}
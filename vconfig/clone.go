@@ -0,0 +1,131 @@
+package vconfig
+
+import (
+	"reflect"
+	"time"
+	"unsafe"
+)
+
+// Cloner 配置类型可以实现该接口自定义复制方式，保存变更前的配置和历史版本时调用
+//
+// 未实现时按反射深拷贝：指针、map、切片和接口中的值都会复制，未导出字段同样保留，
+// time.Time 按值复制，函数和channel与原值共享
+type Cloner[T any] interface {
+	Clone() T
+}
+
+// cloneConfig 深拷贝配置数据
+func cloneConfig[T any](src T) T {
+	if c, ok := any(src).(Cloner[T]); ok {
+		if v := reflect.ValueOf(src); v.Kind() != reflect.Ptr || !v.IsNil() {
+			return c.Clone()
+		}
+	}
+	if c, ok := any(&src).(Cloner[T]); ok {
+		return c.Clone()
+	}
+
+	in := reflect.ValueOf(&src).Elem()
+	out := reflect.New(in.Type()).Elem()
+	copier := deepCopier{visited: make(map[visitedPtr]reflect.Value)}
+	copier.copy(out, in)
+	return out.Interface().(T)
+}
+
+// visitedPtr 已复制的指针，类型不同的指针可以指向同一地址（如结构体和它的第一个字段）
+type visitedPtr struct {
+	ptr uintptr
+	typ reflect.Type
+}
+
+// deepCopier 反射深拷贝，记录已复制的指针以保持共享关系并避免循环引用
+type deepCopier struct {
+	visited map[visitedPtr]reflect.Value
+}
+
+// timeType time.Time 的类型，其中的 *time.Location 不能复制
+var timeType = reflect.TypeOf(time.Time{})
+
+// copy 将src深拷贝到dst，dst 必须可以设置
+func (d *deepCopier) copy(dst, src reflect.Value) {
+	switch src.Kind() {
+	case reflect.Ptr:
+		if src.IsNil() {
+			return
+		}
+		key := visitedPtr{ptr: src.Pointer(), typ: src.Type()}
+		if p, ok := d.visited[key]; ok {
+			dst.Set(p)
+			return
+		}
+		p := reflect.New(src.Type().Elem())
+		d.visited[key] = p
+		d.copy(p.Elem(), src.Elem())
+		dst.Set(p)
+
+	case reflect.Interface:
+		if src.IsNil() {
+			return
+		}
+		elem := src.Elem()
+		v := reflect.New(elem.Type()).Elem()
+		d.copy(v, elem)
+		dst.Set(v)
+
+	case reflect.Map:
+		if src.IsNil() {
+			return
+		}
+		m := reflect.MakeMapWithSize(src.Type(), src.Len())
+		iter := src.MapRange()
+		for iter.Next() {
+			k := reflect.New(src.Type().Key()).Elem()
+			d.copy(k, iter.Key())
+			v := reflect.New(src.Type().Elem()).Elem()
+			d.copy(v, iter.Value())
+			m.SetMapIndex(k, v)
+		}
+		dst.Set(m)
+
+	case reflect.Slice:
+		if src.IsNil() {
+			return
+		}
+		s := reflect.MakeSlice(src.Type(), src.Len(), src.Len())
+		for i := 0; i < src.Len(); i++ {
+			d.copy(s.Index(i), src.Index(i))
+		}
+		dst.Set(s)
+
+	case reflect.Array:
+		for i := 0; i < src.Len(); i++ {
+			d.copy(dst.Index(i), src.Index(i))
+		}
+
+	case reflect.Struct:
+		dst.Set(src)
+		if src.Type() == timeType {
+			return
+		}
+		if !src.CanAddr() {
+			// map中的值不能取地址，复制到可取地址的变量后才能读取未导出字段
+			tmp := reflect.New(src.Type()).Elem()
+			tmp.Set(src)
+			src = tmp
+		}
+		for i := 0; i < src.NumField(); i++ {
+			d.copy(settable(dst.Field(i)), settable(src.Field(i)))
+		}
+
+	default:
+		dst.Set(src)
+	}
+}
+
+// settable 返回可以读取和设置的字段，用于复制未导出字段
+func settable(v reflect.Value) reflect.Value {
+	if v.CanSet() {
+		return v
+	}
+	return reflect.NewAt(v.Type(), unsafe.Pointer(v.UnsafeAddr())).Elem()
+}
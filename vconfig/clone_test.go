@@ -0,0 +1,116 @@
+package vconfig
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type cloneNode struct {
+	Name string
+	Next *cloneNode
+}
+
+type cloneConfigData struct {
+	Timeout  time.Duration            `json:"timeout"`
+	Started  time.Time                `json:"started"`
+	Labels   map[string]string        `json:"labels"`
+	Nested   map[string][]int         `json:"nested"`
+	Limit    *int                     `json:"limit"`
+	Alias    *int                     `json:"-"`
+	Items    []cloneNode              `json:"items"`
+	Extra    interface{}              `json:"extra"`
+	Handlers map[string]func() string `json:"-"`
+	Ring     *cloneNode               `json:"-"`
+	Fixed    [2]*int                  `json:"fixed"`
+	secret   string
+	cache    map[string]int
+}
+
+// 测试深拷贝保留各类字段且与原值互不影响
+func TestCloneConfig(t *testing.T) {
+	limit := 10
+	ring := &cloneNode{Name: "a"}
+	ring.Next = &cloneNode{Name: "b", Next: ring}
+	loc := time.FixedZone("CST", 8*3600)
+	src := cloneConfigData{
+		Timeout:  1500 * time.Millisecond,
+		Started:  time.Date(2024, 1, 2, 3, 4, 5, 6, loc),
+		Labels:   map[string]string{"team": "core"},
+		Nested:   map[string][]int{"a": {1, 2}},
+		Limit:    &limit,
+		Alias:    &limit,
+		Items:    []cloneNode{{Name: "x", Next: &cloneNode{Name: "y"}}},
+		Extra:    map[string]interface{}{"k": []interface{}{"v"}},
+		Handlers: map[string]func() string{"ping": func() string { return "pong" }},
+		Ring:     ring,
+		Fixed:    [2]*int{&limit, nil},
+		secret:   "s3cret",
+		cache:    map[string]int{"hits": 1},
+	}
+
+	dst := cloneConfig(src)
+	assert.Equal(t, src.Timeout, dst.Timeout)
+	assert.True(t, src.Started.Equal(dst.Started))
+	assert.Equal(t, loc, dst.Started.Location())
+	assert.Equal(t, src.Labels, dst.Labels)
+	assert.Equal(t, src.Nested, dst.Nested)
+	assert.Equal(t, 10, *dst.Limit)
+	assert.Equal(t, "y", dst.Items[0].Next.Name)
+	assert.Equal(t, src.Extra, dst.Extra)
+	assert.Equal(t, "pong", dst.Handlers["ping"]())
+	assert.Equal(t, "s3cret", dst.secret)
+	assert.Equal(t, map[string]int{"hits": 1}, dst.cache)
+
+	// 共享的指针复制后仍然共享，循环引用被保留
+	assert.Same(t, dst.Limit, dst.Alias)
+	assert.Same(t, dst.Limit, dst.Fixed[0])
+	assert.Same(t, dst.Ring, dst.Ring.Next.Next)
+
+	// 修改副本不影响原值
+	*dst.Limit = 20
+	dst.Labels["team"] = "infra"
+	dst.Nested["a"][0] = 100
+	dst.Items[0].Next.Name = "z"
+	dst.Extra.(map[string]interface{})["k"].([]interface{})[0] = "changed"
+	dst.cache["hits"] = 2
+	dst.Ring.Name = "changed"
+	assert.Equal(t, 10, limit)
+	assert.Equal(t, "core", src.Labels["team"])
+	assert.Equal(t, 1, src.Nested["a"][0])
+	assert.Equal(t, "y", src.Items[0].Next.Name)
+	assert.Equal(t, "v", src.Extra.(map[string]interface{})["k"].([]interface{})[0])
+	assert.Equal(t, 1, src.cache["hits"])
+	assert.Equal(t, "a", src.Ring.Name)
+
+	// nil值保持为nil
+	empty := cloneConfig(cloneConfigData{})
+	assert.Nil(t, empty.Labels)
+	assert.Nil(t, empty.Limit)
+	assert.Nil(t, empty.Items)
+
+	// 指针类型的配置
+	p := cloneConfig(&src)
+	require.NotSame(t, &src, p)
+	assert.Equal(t, "s3cret", p.secret)
+	assert.Nil(t, cloneConfig[*cloneConfigData](nil))
+}
+
+// cloneCustom 实现了 Cloner 的配置
+type cloneCustom struct {
+	Value  int
+	cloned bool
+}
+
+func (c *cloneCustom) Clone() cloneCustom {
+	return cloneCustom{Value: c.Value, cloned: true}
+}
+
+// 测试配置类型实现 Cloner 时使用自定义复制
+func TestCloneConfigCloner(t *testing.T) {
+	dst := cloneConfig(cloneCustom{Value: 3})
+	assert.Equal(t, 3, dst.Value)
+	assert.True(t, dst.cloned)
+}
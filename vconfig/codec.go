@@ -0,0 +1,248 @@
+package vconfig
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"github.com/hashicorp/hcl"
+	"github.com/hashicorp/hcl/hcl/printer"
+	"github.com/spf13/viper"
+	"gopkg.in/ini.v1"
+	"gopkg.in/yaml.v3"
+)
+
+// marshalConfig 按配置类型序列化配置，未知类型使用JSON
+//
+// time.Duration、time.Time、net.IP 和 url.URL 保存为字符串，如 "10s"、"2024-06-01T10:00:00Z"
+func marshalConfig(data interface{}, configType ConfigType) ([]byte, error) {
+	if settings, ok, err := typedSettings(data, configType); err != nil {
+		return nil, err
+	} else if ok {
+		data = settings
+	}
+
+	switch configType {
+	case YAML:
+		return yaml.Marshal(data)
+	case TOML:
+		var buf bytes.Buffer
+		if err := toml.NewEncoder(&buf).Encode(data); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	case INI, HCL:
+		settings, err := settingsOf(data)
+		if err != nil {
+			return nil, err
+		}
+		if configType == INI {
+			return encodeINI(settings)
+		}
+		return encodeHCL(settings)
+	case JSON:
+		return json.Marshal(data)
+	default:
+		return json.Marshal(data)
+	}
+}
+
+// unmarshalConfig 按配置类型反序列化配置，未知类型使用JSON
+//
+// 反序列化到结构体时使用 decodeHook，time.Duration 等类型可以从字符串解码
+func unmarshalConfig(configBytes []byte, data interface{}, configType ConfigType) error {
+	if _, ok := data.(*map[string]interface{}); !ok && configType != INI && configType != HCL {
+		return unmarshalTyped(configBytes, data, configType)
+	}
+
+	switch configType {
+	case YAML:
+		return yaml.Unmarshal(configBytes, data)
+	case TOML:
+		return toml.Unmarshal(configBytes, data)
+	case INI, HCL:
+		settings, err := readSettingsBytes(configBytes, configType)
+		if err != nil {
+			return err
+		}
+		v := viper.New()
+		if err := v.MergeConfigMap(settings); err != nil {
+			return err
+		}
+		return v.Unmarshal(data, viperDecodeHook())
+	case JSON:
+		return json.Unmarshal(configBytes, data)
+	default:
+		return json.Unmarshal(configBytes, data)
+	}
+}
+
+// toSettingsMap 将配置结构体转换为以配置键为key的嵌套map
+func toSettingsMap(data interface{}, configType ConfigType) (map[string]interface{}, error) {
+	configBytes, err := marshalConfig(data, configType)
+	if err != nil {
+		return nil, fmt.Errorf("序列化配置失败: %w", err)
+	}
+	settings := make(map[string]interface{})
+	if err := unmarshalConfig(configBytes, &settings, configType); err != nil {
+		return nil, fmt.Errorf("反序列化配置失败: %w", err)
+	}
+	return settings, nil
+}
+
+// readSettingsBytes 使用viper解析配置内容，返回以配置键为key的嵌套map
+//
+// INI中节外的配置项提升到顶层；HCL中只出现一次的块展开为map，而不是只有一个元素的列表
+func readSettingsBytes(content []byte, configType ConfigType) (map[string]interface{}, error) {
+	v := viper.New()
+	v.SetConfigType(string(configType))
+	if err := v.ReadConfig(bytes.NewReader(content)); err != nil {
+		return nil, err
+	}
+	settings := v.AllSettings()
+	switch configType {
+	case INI:
+		// viper将节外的配置项放在小写的默认节中
+		defaultSection := strings.ToLower(ini.DefaultSection)
+		if top, ok := settings[defaultSection].(map[string]interface{}); ok {
+			delete(settings, defaultSection)
+			for k, val := range top {
+				if _, exists := settings[k]; !exists {
+					settings[k] = val
+				}
+			}
+		}
+	case HCL:
+		for k, val := range settings {
+			settings[k] = unwrapHCLBlocks(val)
+		}
+	}
+	return settings, nil
+}
+
+// unwrapHCLBlocks 将只有一个元素的块列表展开为map
+func unwrapHCLBlocks(v interface{}) interface{} {
+	switch val := v.(type) {
+	case []map[string]interface{}:
+		if len(val) == 1 {
+			return unwrapHCLBlocks(val[0])
+		}
+		items := make([]interface{}, len(val))
+		for i, item := range val {
+			items[i] = unwrapHCLBlocks(item)
+		}
+		return items
+	case map[string]interface{}:
+		for k, item := range val {
+			val[k] = unwrapHCLBlocks(item)
+		}
+		return val
+	case []interface{}:
+		if len(val) == 1 {
+			if m, ok := val[0].(map[string]interface{}); ok {
+				return unwrapHCLBlocks(m)
+			}
+		}
+		for i, item := range val {
+			val[i] = unwrapHCLBlocks(item)
+		}
+		return val
+	default:
+		return v
+	}
+}
+
+// settingsOf 将配置转换为嵌套map，结构体按 yaml tag 命名
+func settingsOf(data interface{}) (map[string]interface{}, error) {
+	if settings, ok := data.(map[string]interface{}); ok {
+		return settings, nil
+	}
+	content, err := yaml.Marshal(data)
+	if err != nil {
+		return nil, err
+	}
+	settings := make(map[string]interface{})
+	if err := yaml.Unmarshal(content, &settings); err != nil {
+		return nil, err
+	}
+	return settings, nil
+}
+
+// encodeHCL 将配置序列化为HCL，嵌套的map输出为块
+func encodeHCL(settings map[string]interface{}) ([]byte, error) {
+	content, err := json.Marshal(settings)
+	if err != nil {
+		return nil, err
+	}
+	file, err := hcl.ParseBytes(content)
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	if err := printer.Fprint(&buf, file.Node); err != nil {
+		return nil, err
+	}
+	buf.WriteByte('\n')
+	return buf.Bytes(), nil
+}
+
+// encodeINI 将配置序列化为INI，嵌套的map输出为以点号连接的节，列表输出为逗号分隔的值
+func encodeINI(settings map[string]interface{}) ([]byte, error) {
+	flat := make(map[string]string)
+	flattenINI(flat, settings, "")
+	keys := make([]string, 0, len(flat))
+	for k := range flat {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	file := ini.Empty()
+	for _, k := range keys {
+		section, key := "", k
+		if i := strings.LastIndex(k, "."); i >= 0 {
+			section, key = k[:i], k[i+1:]
+		}
+		if _, err := file.Section(section).NewKey(key, flat[k]); err != nil {
+			return nil, err
+		}
+	}
+	var buf bytes.Buffer
+	if _, err := file.WriteTo(&buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// flattenINI 将嵌套map展开为以点号连接的配置键
+func flattenINI(flat map[string]string, settings map[string]interface{}, prefix string) {
+	for k, v := range settings {
+		key := prefix + k
+		switch val := v.(type) {
+		case map[string]interface{}:
+			flattenINI(flat, val, key+".")
+		case []interface{}:
+			items := make([]string, len(val))
+			for i, item := range val {
+				items[i] = fmt.Sprint(item)
+			}
+			flat[key] = strings.Join(items, ",")
+		case nil:
+			flat[key] = ""
+		default:
+			flat[key] = fmt.Sprint(val)
+		}
+	}
+}
+
+// structTagName 返回配置类型对应的struct tag名，INI和HCL使用 yaml tag
+func structTagName(configType ConfigType) string {
+	switch configType {
+	case INI, HCL:
+		return string(YAML)
+	default:
+		return string(configType)
+	}
+}
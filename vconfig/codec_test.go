@@ -0,0 +1,90 @@
+package vconfig
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/constructorvirgil/virlog/test/testutils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type formatConfig struct {
+	Name    string        `yaml:"name"`
+	Timeout time.Duration `yaml:"timeout"`
+	Server  struct {
+		Host  string   `yaml:"host"`
+		Port  int      `yaml:"port"`
+		Debug bool     `yaml:"debug"`
+		Tags  []string `yaml:"tags"`
+	} `yaml:"server"`
+}
+
+func newFormatConfig() formatConfig {
+	var c formatConfig
+	c.Name = "demo"
+	c.Timeout = 5 * time.Second
+	c.Server.Host = "localhost"
+	c.Server.Port = 8080
+	c.Server.Tags = []string{"a", "b"}
+	return c
+}
+
+// 测试INI和HCL的序列化和反序列化
+func TestINIAndHCLCodec(t *testing.T) {
+	for _, configType := range []ConfigType{INI, HCL} {
+		t.Run(string(configType), func(t *testing.T) {
+			content, err := marshalConfig(newFormatConfig(), configType)
+			require.NoError(t, err)
+
+			var decoded formatConfig
+			require.NoError(t, unmarshalConfig(content, &decoded, configType))
+			assert.Equal(t, newFormatConfig(), decoded)
+		})
+	}
+
+	content, err := marshalConfig(newFormatConfig(), INI)
+	require.NoError(t, err)
+	assert.Contains(t, string(content), "name")
+	assert.Contains(t, string(content), "[server]")
+	assert.Contains(t, string(content), "tags")
+}
+
+// 测试使用INI和HCL配置文件，扩展名推断类型并保存
+func TestINIAndHCLConfigFile(t *testing.T) {
+	files := map[ConfigType]string{
+		INI: "name = app\ntimeout = 10s\n\n[server]\nhost = 0.0.0.0\nport = 9000\ndebug = true\ntags = x,y\n",
+		HCL: "name = \"app\"\ntimeout = \"10s\"\n\nserver {\n  host = \"0.0.0.0\"\n  port = 9000\n  debug = true\n  tags = [\"x\", \"y\"]\n}\n",
+	}
+	for configType, text := range files {
+		t.Run(string(configType), func(t *testing.T) {
+			configFile := testutils.RandomTempFilename("test_format", "."+string(configType))
+			defer testutils.CleanTempFile(t, configFile)
+			require.NoError(t, os.WriteFile(configFile, []byte(text), 0644))
+
+			cfg, err := NewConfig(newFormatConfig(),
+				WithConfigFile[formatConfig](configFile),
+				WithConfigType[formatConfig](""))
+			require.NoError(t, err)
+			defer cfg.Close()
+
+			data := cfg.GetData()
+			assert.Equal(t, "app", data.Name)
+			assert.Equal(t, 10*time.Second, data.Timeout)
+			assert.Equal(t, "0.0.0.0", data.Server.Host)
+			assert.Equal(t, 9000, data.Server.Port)
+			assert.True(t, data.Server.Debug)
+			assert.Equal(t, []string{"x", "y"}, data.Server.Tags)
+
+			cfg.data.Server.Port = 9100
+			require.NoError(t, cfg.SaveConfig())
+			content, err := os.ReadFile(configFile)
+			require.NoError(t, err)
+			var saved formatConfig
+			require.NoError(t, unmarshalConfig(content, &saved, configType))
+			assert.Equal(t, 9100, saved.Server.Port)
+			assert.Equal(t, "app", saved.Name)
+		})
+	}
+}
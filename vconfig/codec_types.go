@@ -0,0 +1,312 @@
+package vconfig
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/url"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"github.com/mitchellh/mapstructure"
+	"github.com/spf13/viper"
+	"gopkg.in/yaml.v3"
+)
+
+var (
+	ipType     = reflect.TypeOf(net.IP{})
+	urlType    = reflect.TypeOf(url.URL{})
+	urlPtrType = reflect.TypeOf(&url.URL{})
+)
+
+// decodeHook 解码配置时将字符串转换为 time.Duration（如 "10s"）、time.Time（RFC3339）、net.IP 和 url.URL，
+// 其他切片类型的字符串按逗号分隔
+func decodeHook() mapstructure.DecodeHookFunc {
+	return mapstructure.ComposeDecodeHookFunc(
+		mapstructure.StringToTimeDurationHookFunc(),
+		mapstructure.StringToTimeHookFunc(time.RFC3339Nano),
+		stringToIPHookFunc(),
+		stringToURLHookFunc(),
+		mapstructure.StringToSliceHookFunc(","),
+	)
+}
+
+// stringToIPHookFunc 将字符串解析为 net.IP，空字符串解析为nil
+func stringToIPHookFunc() mapstructure.DecodeHookFuncType {
+	return func(f reflect.Type, t reflect.Type, data interface{}) (interface{}, error) {
+		if f.Kind() != reflect.String || t != ipType {
+			return data, nil
+		}
+		if data.(string) == "" {
+			return net.IP(nil), nil
+		}
+		ip := net.ParseIP(data.(string))
+		if ip == nil {
+			return nil, fmt.Errorf("无效的IP地址: %s", data)
+		}
+		return ip, nil
+	}
+}
+
+// stringToURLHookFunc 将字符串解析为 url.URL 或 *url.URL
+func stringToURLHookFunc() mapstructure.DecodeHookFuncType {
+	return func(f reflect.Type, t reflect.Type, data interface{}) (interface{}, error) {
+		if f.Kind() != reflect.String || (t != urlType && t != urlPtrType) {
+			return data, nil
+		}
+		u, err := url.Parse(data.(string))
+		if err != nil {
+			return nil, err
+		}
+		if t == urlType {
+			return *u, nil
+		}
+		return u, nil
+	}
+}
+
+// decodeSettings 使用 decodeHook 将配置map解码到结构体，配置键按 tagName tag 匹配字段
+func decodeSettings(settings map[string]interface{}, data interface{}, tagName string) error {
+	decoder, err := mapstructure.NewDecoder(&mapstructure.DecoderConfig{
+		DecodeHook: decodeHook(),
+		Result:     data,
+		TagName:    tagName,
+		Squash:     true,
+	})
+	if err != nil {
+		return err
+	}
+	return decoder.Decode(settings)
+}
+
+// unmarshalTyped 先将 JSON、YAML、TOML 配置解析为map，再使用 decodeHook 解码到结构体，
+// 使 "10s" 这样的字符串可以解码为 time.Duration 等类型
+func unmarshalTyped(configBytes []byte, data interface{}, configType ConfigType) error {
+	settings := make(map[string]interface{})
+	switch configType {
+	case YAML:
+		if err := yaml.Unmarshal(configBytes, &settings); err != nil {
+			return err
+		}
+	case TOML:
+		if err := toml.Unmarshal(configBytes, &settings); err != nil {
+			return err
+		}
+	default:
+		decoder := json.NewDecoder(bytes.NewReader(configBytes))
+		decoder.UseNumber()
+		if err := decoder.Decode(&settings); err != nil {
+			return err
+		}
+	}
+	return decodeSettings(settings, data, structTagName(configType))
+}
+
+// typedSettings 将包含 time.Duration 等类型的配置结构体转换为这些类型的值为字符串的配置map，
+// 结构体不包含这些类型时返回false
+func typedSettings(data interface{}, configType ConfigType) (map[string]interface{}, bool, error) {
+	if _, ok := data.(map[string]interface{}); ok || data == nil || !hasTextTypes(reflect.TypeOf(data), map[reflect.Type]bool{}) {
+		return nil, false, nil
+	}
+	settings, err := structSettings(data, configType)
+	if err != nil {
+		return nil, false, err
+	}
+	return settings, true, nil
+}
+
+// structSettings 按配置文件格式将配置数据转换为map，与写入配置文件时使用相同的struct tag，
+// time.Duration 等类型转换为字符串
+func structSettings(data interface{}, configType ConfigType) (map[string]interface{}, error) {
+	if settings, ok := data.(map[string]interface{}); ok {
+		return settings, nil
+	}
+
+	var (
+		settings map[string]interface{}
+		err      error
+	)
+	switch configType {
+	case INI, HCL:
+		settings, err = settingsOf(data)
+	case YAML:
+		var content []byte
+		if content, err = yaml.Marshal(data); err == nil {
+			err = yaml.Unmarshal(content, &settings)
+		}
+	case TOML:
+		var buf bytes.Buffer
+		if err = toml.NewEncoder(&buf).Encode(data); err == nil {
+			err = toml.Unmarshal(buf.Bytes(), &settings)
+		}
+	default:
+		var content []byte
+		if content, err = json.Marshal(data); err == nil {
+			decoder := json.NewDecoder(bytes.NewReader(content))
+			decoder.UseNumber()
+			err = decoder.Decode(&settings)
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	normalizeSettings(settings, data, structTagName(configType))
+	return settings, nil
+}
+
+// viperDecodeHook 解码viper中的配置时使用 decodeHook
+func viperDecodeHook() viper.DecoderConfigOption {
+	return viper.DecodeHook(decodeHook())
+}
+
+// hasTextTypes 判断类型中是否包含需要按字符串保存的 time.Duration、time.Time、net.IP 或 url.URL
+func hasTextTypes(typ reflect.Type, visited map[reflect.Type]bool) bool {
+	for typ.Kind() == reflect.Ptr {
+		if typ == urlPtrType {
+			return true
+		}
+		typ = typ.Elem()
+	}
+	switch typ {
+	case durationType, timeType, ipType, urlType:
+		return true
+	}
+	if visited[typ] {
+		return false
+	}
+	visited[typ] = true
+
+	switch typ.Kind() {
+	case reflect.Struct:
+		for i := 0; i < typ.NumField(); i++ {
+			if typ.Field(i).IsExported() && hasTextTypes(typ.Field(i).Type, visited) {
+				return true
+			}
+		}
+	case reflect.Map, reflect.Slice, reflect.Array:
+		return hasTextTypes(typ.Elem(), visited)
+	}
+	return false
+}
+
+// textValue 返回 time.Duration（如 "10s"）、time.Time（RFC3339）、net.IP 和 url.URL 保存到配置中的字符串
+func textValue(v reflect.Value) (string, bool) {
+	switch v.Type() {
+	case durationType:
+		return time.Duration(v.Int()).String(), true
+	case timeType:
+		return v.Interface().(time.Time).Format(time.RFC3339Nano), true
+	case ipType:
+		if v.Len() == 0 {
+			return "", true
+		}
+		return v.Interface().(net.IP).String(), true
+	case urlType:
+		u := v.Interface().(url.URL)
+		return u.String(), true
+	}
+	return "", false
+}
+
+// normalizeSettings 将配置map中 time.Duration、time.Time、net.IP 和 url.URL 字段的值替换为字符串，
+// data 为序列化得到该map的配置结构体，配置键按 tagName tag、mapstructure tag 或字段名（不区分大小写）对应字段
+func normalizeSettings(settings map[string]interface{}, data interface{}, tagName string) {
+	normalizeValue(settings, reflect.ValueOf(data), tagName)
+}
+
+// normalizeValue 返回替换后的配置值，setting 为 v 序列化后的值
+func normalizeValue(setting interface{}, v reflect.Value, tagName string) interface{} {
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return setting
+		}
+		v = v.Elem()
+	}
+	if text, ok := textValue(v); ok {
+		return text
+	}
+
+	switch v.Kind() {
+	case reflect.Struct:
+		if m, ok := setting.(map[string]interface{}); ok {
+			normalizeStruct(m, v, tagName)
+		}
+	case reflect.Map:
+		m, ok := setting.(map[string]interface{})
+		if !ok {
+			return setting
+		}
+		iter := v.MapRange()
+		for iter.Next() {
+			if key, ok := findSettingKey(m, fmt.Sprint(iter.Key().Interface())); ok {
+				m[key] = normalizeValue(m[key], iter.Value(), tagName)
+			}
+		}
+	case reflect.Slice, reflect.Array:
+		list, ok := setting.([]interface{})
+		if !ok || len(list) != v.Len() {
+			return setting
+		}
+		for i := range list {
+			list[i] = normalizeValue(list[i], v.Index(i), tagName)
+		}
+	}
+	return setting
+}
+
+// normalizeStruct 替换结构体各字段对应的配置值，内联的嵌入结构体的字段在同一层级
+func normalizeStruct(m map[string]interface{}, v reflect.Value, tagName string) {
+	typ := v.Type()
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		name, inline := fieldSettingKey(field, tagName)
+		if name == "-" {
+			continue
+		}
+		if inline {
+			normalizeValue(m, v.Field(i), tagName)
+			continue
+		}
+		if key, ok := findSettingKey(m, name); ok {
+			m[key] = normalizeValue(m[key], v.Field(i), tagName)
+		}
+	}
+}
+
+// fieldSettingKey 返回字段对应的配置键，以及字段是否内联到上一层级
+func fieldSettingKey(field reflect.StructField, tagName string) (string, bool) {
+	for _, name := range []string{tagName, "mapstructure"} {
+		tag := strings.Split(field.Tag.Get(name), ",")
+		for _, opt := range tag[1:] {
+			if opt == "inline" || opt == "squash" {
+				return "", true
+			}
+		}
+		if tag[0] != "" {
+			return tag[0], false
+		}
+	}
+	if field.Anonymous && field.Type.Kind() == reflect.Struct {
+		return "", true
+	}
+	return field.Name, false
+}
+
+// findSettingKey 在配置map中查找与 name 相同（不区分大小写）的键
+func findSettingKey(m map[string]interface{}, name string) (string, bool) {
+	if _, ok := m[name]; ok {
+		return name, true
+	}
+	for key := range m {
+		if strings.EqualFold(key, name) {
+			return key, true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,128 @@
+package vconfig
+
+import (
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TypedConfig 包含需要按字符串保存的字段的配置
+type TypedConfig struct {
+	Timeout   time.Duration   `json:"timeout" yaml:"timeout" toml:"timeout"`
+	StartAt   time.Time       `json:"start_at" yaml:"start_at" toml:"start_at" mapstructure:"start_at"`
+	BindIP    net.IP          `json:"bind_ip" yaml:"bind_ip" toml:"bind_ip" mapstructure:"bind_ip"`
+	Endpoint  url.URL         `json:"endpoint" yaml:"endpoint" toml:"endpoint"`
+	Proxy     *url.URL        `json:"proxy" yaml:"proxy" toml:"proxy"`
+	Intervals []time.Duration `json:"intervals" yaml:"intervals" toml:"intervals"`
+	Upstreams []TypedUpstream `json:"upstreams" yaml:"upstreams" toml:"upstreams"`
+}
+
+// TypedUpstream 列表中的配置项
+type TypedUpstream struct {
+	Addr    net.IP        `json:"addr" yaml:"addr" toml:"addr"`
+	Timeout time.Duration `json:"timeout" yaml:"timeout" toml:"timeout"`
+}
+
+// newTypedConfig 创建各字段均有值的配置
+func newTypedConfig(t *testing.T) TypedConfig {
+	endpoint, err := url.Parse("https://api.example.com/v1?region=cn")
+	require.NoError(t, err)
+	proxy, err := url.Parse("http://proxy.local:3128")
+	require.NoError(t, err)
+	return TypedConfig{
+		Timeout:   10 * time.Second,
+		StartAt:   time.Date(2024, 6, 1, 10, 0, 0, 500, time.UTC),
+		BindIP:    net.ParseIP("10.0.0.1"),
+		Endpoint:  *endpoint,
+		Proxy:     proxy,
+		Intervals: []time.Duration{time.Second, time.Minute},
+		Upstreams: []TypedUpstream{{Addr: net.ParseIP("::1"), Timeout: 1500 * time.Millisecond}},
+	}
+}
+
+// 测试 time.Duration、time.Time、net.IP 和 url.URL 在各格式的配置文件中保存为字符串并能重新加载
+func TestTypedFieldsFileRoundTrip(t *testing.T) {
+	for _, configType := range []ConfigType{YAML, JSON, TOML} {
+		t.Run(string(configType), func(t *testing.T) {
+			configFile := filepath.Join(t.TempDir(), "app."+string(configType))
+			want := newTypedConfig(t)
+
+			cfg, err := NewConfig(TypedConfig{},
+				WithConfigFile[TypedConfig](configFile),
+				WithConfigType[TypedConfig](configType))
+			require.NoError(t, err)
+			require.NoError(t, cfg.UpdateFunc(func(data *TypedConfig) error {
+				*data = want
+				return nil
+			}))
+			cfg.Close()
+
+			content, err := os.ReadFile(configFile)
+			require.NoError(t, err)
+			assert.Contains(t, string(content), "10s")
+			assert.Contains(t, string(content), "1.5s")
+			assert.Contains(t, string(content), "2024-06-01T10:00:00.0000005Z")
+			assert.Contains(t, string(content), "10.0.0.1")
+			assert.Contains(t, string(content), "https://api.example.com/v1?region=cn")
+
+			loaded, err := NewConfig(TypedConfig{},
+				WithConfigFile[TypedConfig](configFile),
+				WithConfigType[TypedConfig](configType))
+			require.NoError(t, err)
+			defer loaded.Close()
+			assertTypedConfig(t, want, loaded.GetData())
+		})
+	}
+}
+
+// 测试远程配置源使用的序列化和反序列化
+func TestTypedFieldsMarshalRoundTrip(t *testing.T) {
+	for _, configType := range []ConfigType{YAML, JSON, TOML, INI, HCL} {
+		t.Run(string(configType), func(t *testing.T) {
+			want := newTypedConfig(t)
+			if configType == INI {
+				// INI不支持结构体列表
+				want.Upstreams = nil
+			}
+			content, err := marshalConfig(want, configType)
+			require.NoError(t, err)
+			assert.Contains(t, string(content), "10s")
+
+			var got TypedConfig
+			require.NoError(t, unmarshalConfig(content, &got, configType))
+			assertTypedConfig(t, want, got)
+		})
+	}
+
+	// 手写的配置也可以使用字符串
+	var got TypedConfig
+	require.NoError(t, unmarshalConfig([]byte(`{"timeout":"2m","bind_ip":"127.0.0.1","proxy":"socks5://127.0.0.1:1080"}`), &got, JSON))
+	assert.Equal(t, 2*time.Minute, got.Timeout)
+	assert.Equal(t, "127.0.0.1", got.BindIP.String())
+	assert.Equal(t, "socks5://127.0.0.1:1080", got.Proxy.String())
+
+	assert.Error(t, unmarshalConfig([]byte(`{"start_at":"yesterday"}`), &got, JSON))
+}
+
+// assertTypedConfig 比较两个配置
+func assertTypedConfig(t *testing.T, want, got TypedConfig) {
+	t.Helper()
+	assert.Equal(t, want.Timeout, got.Timeout)
+	assert.True(t, want.StartAt.Equal(got.StartAt), "start_at: %s", got.StartAt)
+	assert.True(t, want.BindIP.Equal(got.BindIP), "bind_ip: %s", got.BindIP)
+	assert.Equal(t, want.Endpoint.String(), got.Endpoint.String())
+	require.NotNil(t, got.Proxy)
+	assert.Equal(t, want.Proxy.String(), got.Proxy.String())
+	assert.Equal(t, want.Intervals, got.Intervals)
+	require.Len(t, got.Upstreams, len(want.Upstreams))
+	for i := range want.Upstreams {
+		assert.True(t, want.Upstreams[i].Addr.Equal(got.Upstreams[i].Addr))
+		assert.Equal(t, want.Upstreams[i].Timeout, got.Upstreams[i].Timeout)
+	}
+}
@@ -0,0 +1,196 @@
+package vconfig
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// CryptoProvider 配置文件加解密接口
+type CryptoProvider interface {
+	// Decrypt 解密配置文件内容
+	Decrypt(ciphertext []byte) ([]byte, error)
+	// Encrypt 加密配置文件内容
+	Encrypt(plaintext []byte) ([]byte, error)
+}
+
+// aesGCMHeader AES-GCM加密文件的头部标识
+const aesGCMHeader = "vconfig:aesgcm:v1:"
+
+// ErrNotEncrypted 配置文件不是预期的加密格式
+var ErrNotEncrypted = errors.New("配置文件不是加密格式")
+
+// aesGCMProvider 使用AES-GCM加解密配置文件
+type aesGCMProvider struct {
+	aead cipher.AEAD
+}
+
+// NewAESGCMProvider 创建AES-GCM加解密器，key长度必须为16、24或32字节
+//
+// 加密后的文件为单行文本：头部标识 + base64(nonce + 密文)，便于提交到git
+func NewAESGCMProvider(key []byte) (CryptoProvider, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("创建AES加密器失败: %w", err)
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("创建GCM加密器失败: %w", err)
+	}
+	return &aesGCMProvider{aead: aead}, nil
+}
+
+// Encrypt 实现CryptoProvider接口
+func (p *aesGCMProvider) Encrypt(plaintext []byte) ([]byte, error) {
+	nonce := make([]byte, p.aead.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, fmt.Errorf("生成nonce失败: %w", err)
+	}
+	sealed := p.aead.Seal(nonce, nonce, plaintext, nil)
+
+	out := make([]byte, 0, len(aesGCMHeader)+base64.StdEncoding.EncodedLen(len(sealed))+1)
+	out = append(out, aesGCMHeader...)
+	out = base64.StdEncoding.AppendEncode(out, sealed)
+	out = append(out, '\n')
+	return out, nil
+}
+
+// Decrypt 实现CryptoProvider接口
+func (p *aesGCMProvider) Decrypt(ciphertext []byte) ([]byte, error) {
+	text := bytes.TrimSpace(ciphertext)
+	if !bytes.HasPrefix(text, []byte(aesGCMHeader)) {
+		return nil, ErrNotEncrypted
+	}
+
+	sealed, err := base64.StdEncoding.DecodeString(string(text[len(aesGCMHeader):]))
+	if err != nil {
+		return nil, fmt.Errorf("解码密文失败: %w", err)
+	}
+
+	nonceSize := p.aead.NonceSize()
+	if len(sealed) < nonceSize {
+		return nil, fmt.Errorf("密文长度不足")
+	}
+
+	plaintext, err := p.aead.Open(nil, sealed[:nonceSize], sealed[nonceSize:], nil)
+	if err != nil {
+		return nil, fmt.Errorf("解密失败: %w", err)
+	}
+	return plaintext, nil
+}
+
+// execProvider 通过外部命令加解密配置文件，内容经标准输入传入、从标准输出读取
+type execProvider struct {
+	decryptCmd []string
+	encryptCmd []string
+}
+
+// NewExecProvider 创建调用外部命令的加解密器
+//
+// decryptCmd 和 encryptCmd 为命令及其参数，命令从标准输入读取内容并将结果写到标准输出，
+// encryptCmd 为空时 Encrypt 返回错误，即配置文件只读
+func NewExecProvider(decryptCmd, encryptCmd []string) (CryptoProvider, error) {
+	if len(decryptCmd) == 0 {
+		return nil, fmt.Errorf("解密命令不能为空")
+	}
+	return &execProvider{decryptCmd: decryptCmd, encryptCmd: encryptCmd}, nil
+}
+
+// NewAgeProvider 创建使用age命令行工具的加解密器
+//
+// identityFile 为解密使用的私钥文件，recipients 为加密时的接收者公钥
+func NewAgeProvider(identityFile string, recipients ...string) (CryptoProvider, error) {
+	encryptCmd := []string{"age", "--encrypt", "--armor"}
+	for _, r := range recipients {
+		encryptCmd = append(encryptCmd, "--recipient", r)
+	}
+	if len(recipients) == 0 {
+		encryptCmd = nil
+	}
+	return NewExecProvider([]string{"age", "--decrypt", "--identity", identityFile}, encryptCmd)
+}
+
+// sopsProvider 使用sops命令行工具加解密配置文件
+type sopsProvider struct {
+	command string
+	format  string
+}
+
+// NewSOPSProvider 创建使用sops命令行工具的加解密器，configType 为配置文件格式
+//
+// 密钥来源（age、KMS、PGP等）由sops自身的配置（.sops.yaml 或环境变量）决定
+func NewSOPSProvider(configType ConfigType) (CryptoProvider, error) {
+	format := string(configType)
+	if format == "" {
+		format = string(YAML)
+	}
+	return &sopsProvider{command: "sops", format: format}, nil
+}
+
+// Decrypt 实现CryptoProvider接口
+func (p *sopsProvider) Decrypt(ciphertext []byte) ([]byte, error) {
+	return p.run("--decrypt", ciphertext)
+}
+
+// Encrypt 实现CryptoProvider接口
+func (p *sopsProvider) Encrypt(plaintext []byte) ([]byte, error) {
+	return p.run("--encrypt", plaintext)
+}
+
+// run 将内容写入仅当前用户可读写的临时文件后交给sops处理，处理完成后删除临时文件
+//
+// 不使用 /dev/stdin，Windows和部分沙箱环境中不存在该文件
+func (p *sopsProvider) run(action string, input []byte) ([]byte, error) {
+	f, err := os.CreateTemp("", "vconfig-sops-*."+p.format)
+	if err != nil {
+		return nil, fmt.Errorf("创建临时文件失败: %w", err)
+	}
+	defer os.Remove(f.Name())
+	_, err = f.Write(input)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return nil, fmt.Errorf("写入临时文件失败: %w", err)
+	}
+	return runFilter(p.args(action, f.Name()), nil)
+}
+
+// args 返回处理 path 文件的sops命令及参数
+func (p *sopsProvider) args(action, path string) []string {
+	return []string{p.command, action, "--input-type", p.format, "--output-type", p.format, path}
+}
+
+// Decrypt 实现CryptoProvider接口
+func (p *execProvider) Decrypt(ciphertext []byte) ([]byte, error) {
+	return runFilter(p.decryptCmd, ciphertext)
+}
+
+// Encrypt 实现CryptoProvider接口
+func (p *execProvider) Encrypt(plaintext []byte) ([]byte, error) {
+	if len(p.encryptCmd) == 0 {
+		return nil, fmt.Errorf("未配置加密命令")
+	}
+	return runFilter(p.encryptCmd, plaintext)
+}
+
+// runFilter 运行外部命令，将input写入标准输入并返回标准输出
+func runFilter(command []string, input []byte) ([]byte, error) {
+	cmd := exec.Command(command[0], command[1:]...)
+	cmd.Stdin = bytes.NewReader(input)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("执行 %s 失败: %w: %s", command[0], err, strings.TrimSpace(stderr.String()))
+	}
+	return stdout.Bytes(), nil
+}
@@ -0,0 +1,133 @@
+package vconfig
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/constructorvirgil/virlog/test/testutils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// 测试AES-GCM加密的配置文件
+func TestEncryptedConfig(t *testing.T) {
+	testCases := []struct {
+		name       string
+		configType ConfigType
+		extension  string
+	}{
+		{"YAML配置", YAML, ".yaml"},
+		{"JSON配置", JSON, ".json"},
+		{"TOML配置", TOML, ".toml"},
+	}
+
+	provider, err := NewAESGCMProvider([]byte("0123456789abcdef0123456789abcdef"))
+	require.NoError(t, err)
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			configFile := testutils.RandomTempFilename("test_encrypted_config", tc.extension)
+			defer testutils.CleanTempFile(t, configFile)
+
+			cfg, err := NewConfig(newDefaultConfig(),
+				WithConfigFile[AppConfig](configFile),
+				WithConfigType[AppConfig](tc.configType),
+				WithDecryption[AppConfig](provider))
+			require.NoError(t, err)
+
+			// 默认配置文件应以密文形式写入
+			content, err := os.ReadFile(configFile)
+			require.NoError(t, err)
+			assert.NotContains(t, string(content), "postgres://")
+
+			// 修改并保存后重新加载
+			cfg.data.Database.DSN = "postgres://secret@db:5432/app"
+			require.NoError(t, cfg.SaveConfig())
+			cfg.Close()
+
+			content, err = os.ReadFile(configFile)
+			require.NoError(t, err)
+			assert.NotContains(t, string(content), "secret@db")
+
+			newCfg, err := NewConfig(AppConfig{},
+				WithConfigFile[AppConfig](configFile),
+				WithConfigType[AppConfig](tc.configType),
+				WithDecryption[AppConfig](provider))
+			require.NoError(t, err)
+			defer newCfg.Close()
+			assert.Equal(t, "postgres://secret@db:5432/app", newCfg.GetData().Database.DSN)
+			assert.Equal(t, 8080, newCfg.GetData().Server.Port)
+
+			// 错误的密钥无法加载
+			wrong, err := NewAESGCMProvider([]byte("fedcba9876543210fedcba9876543210"))
+			require.NoError(t, err)
+			_, err = NewConfig(AppConfig{},
+				WithConfigFile[AppConfig](configFile),
+				WithConfigType[AppConfig](tc.configType),
+				WithDecryption[AppConfig](wrong))
+			assert.Error(t, err)
+		})
+	}
+}
+
+// 测试外部命令加解密
+func TestExecProvider(t *testing.T) {
+	if _, err := exec.LookPath("base64"); err != nil {
+		t.Skip("未找到base64命令")
+	}
+
+	provider, err := NewExecProvider([]string{"base64", "-d"}, []string{"base64"})
+	require.NoError(t, err)
+
+	encrypted, err := provider.Encrypt([]byte("server:\n  port: 9000\n"))
+	require.NoError(t, err)
+	plain, err := provider.Decrypt(encrypted)
+	require.NoError(t, err)
+	assert.Equal(t, "server:\n  port: 9000\n", string(plain))
+
+	readOnly, err := NewExecProvider([]string{"base64", "-d"}, nil)
+	require.NoError(t, err)
+	_, err = readOnly.Encrypt([]byte("x"))
+	assert.Error(t, err)
+
+	_, err = NewAESGCMProvider([]byte("short"))
+	assert.Error(t, err)
+}
+
+// 测试sops加解密器通过临时文件传递内容，不依赖 /dev/stdin
+func TestSOPSProvider(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("测试使用shell脚本模拟sops命令")
+	}
+	// 模拟的sops命令输出参数和最后一个参数指向的文件内容
+	script := filepath.Join(t.TempDir(), "sops")
+	require.NoError(t, os.WriteFile(script, []byte("#!/bin/sh\necho \"$@\"\nfor last; do :; done\ncat \"$last\"\n"), 0755))
+
+	provider, err := NewSOPSProvider(JSON)
+	require.NoError(t, err)
+	provider.(*sopsProvider).command = script
+
+	for action, run := range map[string]func([]byte) ([]byte, error){
+		"--decrypt": provider.Decrypt,
+		"--encrypt": provider.Encrypt,
+	} {
+		out, err := run([]byte(`{"port": 9000}`))
+		require.NoError(t, err)
+		args, content, _ := strings.Cut(string(out), "\n")
+		fields := strings.Fields(args)
+		require.Len(t, fields, 6)
+		assert.Equal(t, []string{action, "--input-type", "json", "--output-type", "json"}, fields[:5])
+		assert.NotEqual(t, "/dev/stdin", fields[5])
+		assert.Equal(t, `{"port": 9000}`, content)
+		assert.NoFileExists(t, fields[5], "处理完成后应删除临时文件")
+	}
+
+	provider, err = NewSOPSProvider("")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"sops", "--decrypt", "--input-type", "yaml", "--output-type", "yaml", "app.yaml"},
+		provider.(*sopsProvider).args("--decrypt", "app.yaml"))
+}
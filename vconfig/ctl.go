@@ -0,0 +1,262 @@
+package vconfig
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+)
+
+// ErrSchemaNotFound 未注册指定名称的配置结构体
+var ErrSchemaNotFound = errors.New("未注册的配置结构体")
+
+// schema 注册的配置结构体，通过闭包擦除类型参数
+type schema struct {
+	// defaults 返回填充了default tag的默认配置
+	defaults func() interface{}
+	// decode 以默认配置为基础解析配置内容并校验
+	decode func(content []byte, configType ConfigType) (interface{}, error)
+}
+
+var (
+	// schemas 通过 Register 注册的配置结构体
+	schemas = make(map[string]schema)
+	// schemasMu 保护schemas的读写锁
+	schemasMu sync.RWMutex
+)
+
+// Register 以 name 注册配置结构体及其默认配置，供 vconfigctl 的 init、validate 和 diff 使用
+//
+// 重复注册同一名称时覆盖之前的注册
+func Register[T any](name string, defaultConfig T) {
+	s := schema{
+		defaults: func() interface{} {
+			data := cloneConfig(defaultConfig)
+			_ = applyDefaults(&data)
+			return data
+		},
+		decode: func(content []byte, configType ConfigType) (interface{}, error) {
+			data := cloneConfig(defaultConfig)
+			if err := unmarshalConfig(content, &data, configType); err != nil {
+				return nil, fmt.Errorf("解析配置失败: %w", err)
+			}
+			if err := applyDefaults(&data); err != nil {
+				return nil, err
+			}
+			if err := validateConfig(&data); err != nil {
+				return nil, err
+			}
+			return data, nil
+		},
+	}
+
+	schemasMu.Lock()
+	defer schemasMu.Unlock()
+	schemas[name] = s
+}
+
+// Registered 返回已注册的配置结构体名称，按字典序排列
+func Registered() []string {
+	schemasMu.RLock()
+	defer schemasMu.RUnlock()
+	names := make([]string, 0, len(schemas))
+	for name := range schemas {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// lookupSchema 查找注册的配置结构体，name 为空且只注册了一个时使用该结构体
+func lookupSchema(name string) (schema, error) {
+	schemasMu.RLock()
+	defer schemasMu.RUnlock()
+	if name == "" && len(schemas) == 1 {
+		for _, s := range schemas {
+			return s, nil
+		}
+	}
+	s, ok := schemas[name]
+	if !ok {
+		return schema{}, fmt.Errorf("%w: %q", ErrSchemaNotFound, name)
+	}
+	return s, nil
+}
+
+// InitConfigFile 将注册的默认配置写入文件，格式由扩展名决定（默认YAML），文件已存在且 force 为false时返回错误
+func InitConfigFile(name, filename string, force bool) error {
+	s, err := lookupSchema(name)
+	if err != nil {
+		return err
+	}
+	if _, err := os.Stat(filename); err == nil && !force {
+		return fmt.Errorf("配置文件已存在: %s", filename)
+	}
+
+	content, err := marshalConfig(s.defaults(), configTypeOf(filename, YAML))
+	if err != nil {
+		return fmt.Errorf("序列化配置失败: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(filename), 0755); err != nil {
+		return fmt.Errorf("创建配置目录失败: %w", err)
+	}
+	return atomicWriteFile(filename, content, 0644)
+}
+
+// ValidateConfigFile 校验配置文件
+//
+// name 为空且没有注册配置结构体时只检查文件能否解析；否则按注册的结构体解析，
+// 填充default tag并在结构体实现了 Validator 时调用 Validate
+func ValidateConfigFile(name, filename string) error {
+	content, err := os.ReadFile(filename)
+	if err != nil {
+		return fmt.Errorf("读取配置文件失败: %w", err)
+	}
+	configType := configTypeOf(filename, YAML)
+
+	if name == "" && len(Registered()) == 0 {
+		settings := make(map[string]interface{})
+		if err := unmarshalConfig(content, &settings, configType); err != nil {
+			return fmt.Errorf("解析配置失败: %w", err)
+		}
+		return nil
+	}
+
+	s, err := lookupSchema(name)
+	if err != nil {
+		return err
+	}
+	_, err = s.decode(content, configType)
+	return err
+}
+
+// DiffConfigFiles 比较两个配置文件，返回按路径排序的变更项
+//
+// name 不为空时按注册的结构体解析（缺失的字段使用默认值），否则按原始键值比较
+func DiffConfigFiles(name, oldFile, newFile string) ([]ConfigChangedItem, error) {
+	var s *schema
+	if name != "" {
+		found, err := lookupSchema(name)
+		if err != nil {
+			return nil, err
+		}
+		s = &found
+	}
+
+	load := func(filename string) (interface{}, error) {
+		content, err := os.ReadFile(filename)
+		if err != nil {
+			return nil, fmt.Errorf("读取配置文件失败: %w", err)
+		}
+		configType := configTypeOf(filename, YAML)
+		if s != nil {
+			data, err := s.decode(content, configType)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", filename, err)
+			}
+			return data, nil
+		}
+		settings := make(map[string]interface{})
+		if err := unmarshalConfig(content, &settings, configType); err != nil {
+			return nil, fmt.Errorf("%s: 解析配置失败: %w", filename, err)
+		}
+		return settings, nil
+	}
+
+	oldData, err := load(oldFile)
+	if err != nil {
+		return nil, err
+	}
+	newData, err := load(newFile)
+	if err != nil {
+		return nil, err
+	}
+
+	changes := findConfigChanges(oldData, newData, "")
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Path < changes[j].Path })
+	return changes, nil
+}
+
+// RunCtl 执行 vconfigctl 命令并返回退出码，用于构建包含自定义配置结构体的命令行工具：
+// 在 main 中调用 Register 注册配置结构体后调用 RunCtl(os.Args[1:], os.Stdout, os.Stderr)
+//
+// 支持的子命令：
+//
+//	init [-schema name] [-force] file      写入默认配置
+//	validate [-schema name] file           校验配置文件
+//	diff [-schema name] old new            输出配置变更，存在差异时退出码为1
+func RunCtl(args []string, stdout, stderr io.Writer) int {
+	if len(args) == 0 {
+		ctlUsage(stderr)
+		return 2
+	}
+
+	fs := flag.NewFlagSet("vconfigctl "+args[0], flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	name := fs.String("schema", "", "注册的配置结构体名称")
+	force := false
+	if args[0] == "init" {
+		fs.BoolVar(&force, "force", false, "覆盖已存在的配置文件")
+	}
+	if err := fs.Parse(args[1:]); err != nil {
+		return 2
+	}
+
+	switch args[0] {
+	case "init":
+		if fs.NArg() != 1 {
+			ctlUsage(stderr)
+			return 2
+		}
+		if err := InitConfigFile(*name, fs.Arg(0), force); err != nil {
+			fmt.Fprintln(stderr, err)
+			return 1
+		}
+		fmt.Fprintf(stdout, "已写入默认配置: %s\n", fs.Arg(0))
+	case "validate":
+		if fs.NArg() != 1 {
+			ctlUsage(stderr)
+			return 2
+		}
+		if err := ValidateConfigFile(*name, fs.Arg(0)); err != nil {
+			fmt.Fprintf(stderr, "%s: %v\n", fs.Arg(0), err)
+			return 1
+		}
+		fmt.Fprintf(stdout, "%s: 配置有效\n", fs.Arg(0))
+	case "diff":
+		if fs.NArg() != 2 {
+			ctlUsage(stderr)
+			return 2
+		}
+		changes, err := DiffConfigFiles(*name, fs.Arg(0), fs.Arg(1))
+		if err != nil {
+			fmt.Fprintln(stderr, err)
+			return 1
+		}
+		for _, change := range changes {
+			fmt.Fprintf(stdout, "%s: %v -> %v\n", change.Path, change.OldValue, change.NewValue)
+		}
+		if len(changes) > 0 {
+			return 1
+		}
+	default:
+		ctlUsage(stderr)
+		return 2
+	}
+	return 0
+}
+
+// ctlUsage 输出 vconfigctl 的用法
+func ctlUsage(w io.Writer) {
+	fmt.Fprintln(w, "用法:")
+	fmt.Fprintln(w, "  vconfigctl init [-schema name] [-force] file")
+	fmt.Fprintln(w, "  vconfigctl validate [-schema name] file")
+	fmt.Fprintln(w, "  vconfigctl diff [-schema name] old new")
+	if names := Registered(); len(names) > 0 {
+		fmt.Fprintf(w, "已注册的配置结构体: %v\n", names)
+	}
+}
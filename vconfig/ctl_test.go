@@ -0,0 +1,66 @@
+package vconfig
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// 测试生成默认配置、校验和比较配置文件
+func TestCtlCommands(t *testing.T) {
+	Register("ctl-app", validatedConfig{AppConfig: newDefaultConfig()})
+	assert.Contains(t, Registered(), "ctl-app")
+
+	dir := t.TempDir()
+	configFile := filepath.Join(dir, "conf", "app.yaml")
+	var stdout, stderr bytes.Buffer
+
+	// init
+	code := RunCtl([]string{"init", "-schema", "ctl-app", configFile}, &stdout, &stderr)
+	require.Equal(t, 0, code, stderr.String())
+	content, err := os.ReadFile(configFile)
+	require.NoError(t, err)
+	assert.Contains(t, string(content), "port: 8080")
+	assert.Equal(t, 1, RunCtl([]string{"init", "-schema", "ctl-app", configFile}, &stdout, &stderr), "文件已存在时不覆盖")
+	assert.Equal(t, 0, RunCtl([]string{"init", "-schema", "ctl-app", "-force", configFile}, &stdout, &stderr))
+	assert.ErrorIs(t, InitConfigFile("missing", configFile, true), ErrSchemaNotFound)
+
+	// validate
+	assert.Equal(t, 0, RunCtl([]string{"validate", "-schema", "ctl-app", configFile}, &stdout, &stderr))
+	invalid := filepath.Join(dir, "invalid.yaml")
+	require.NoError(t, os.WriteFile(invalid, []byte("server:\n  port: 70000\n"), 0644))
+	stderr.Reset()
+	assert.Equal(t, 1, RunCtl([]string{"validate", "-schema", "ctl-app", invalid}, &stdout, &stderr))
+	assert.Contains(t, stderr.String(), "端口超出范围")
+	broken := filepath.Join(dir, "broken.json")
+	require.NoError(t, os.WriteFile(broken, []byte("{"), 0644))
+	assert.Error(t, ValidateConfigFile("ctl-app", broken))
+
+	// diff
+	newFile := filepath.Join(dir, "new.yaml")
+	require.NoError(t, os.WriteFile(newFile, []byte("app:\n  name: 新应用\nserver:\n  port: 9000\n"), 0644))
+	changes, err := DiffConfigFiles("ctl-app", configFile, newFile)
+	require.NoError(t, err)
+	require.Len(t, changes, 2)
+	assert.Equal(t, "app.name", changes[0].Path)
+	assert.Equal(t, "server.port", changes[1].Path)
+	assert.Equal(t, 8080, changes[1].OldValue)
+	assert.Equal(t, 9000, changes[1].NewValue)
+
+	// 不指定结构体时按原始键值比较，缺失的键视为删除
+	changes, err = DiffConfigFiles("", configFile, newFile)
+	require.NoError(t, err)
+	assert.Greater(t, len(changes), 2)
+
+	stdout.Reset()
+	assert.Equal(t, 1, RunCtl([]string{"diff", "-schema", "ctl-app", configFile, newFile}, &stdout, &stderr))
+	assert.Contains(t, stdout.String(), "server.port: 8080 -> 9000")
+	assert.Equal(t, 0, RunCtl([]string{"diff", configFile, configFile}, &stdout, &stderr))
+
+	assert.Equal(t, 2, RunCtl(nil, &stdout, &stderr))
+	assert.Equal(t, 2, RunCtl([]string{"unknown"}, &stdout, &stderr))
+}
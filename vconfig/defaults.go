@@ -0,0 +1,187 @@
+package vconfig
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// defaultTagName 声明字段默认值的struct tag
+//
+//	type ServerConfig struct {
+//		Port    int           `yaml:"port" default:"8080"`
+//		Timeout time.Duration `yaml:"timeout" default:"30s"`
+//		Hosts   []string      `yaml:"hosts" default:"a.local,b.local"`
+//		Labels  map[string]string `yaml:"labels" default:"{env: dev}"`
+//	}
+//
+// 字段为零值时使用默认值填充，嵌套结构体会递归处理；切片和map的默认值可以写成
+// YAML/JSON形式（以 [ 或 { 开头），也可以写成逗号分隔的 a,b 或 k:v,k2:v2
+const defaultTagName = "default"
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// applyDefaults 使用default tag填充结构体中的零值字段，ptr 必须是结构体指针
+func applyDefaults(ptr interface{}) error {
+	v := reflect.ValueOf(ptr)
+	if v.Kind() != reflect.Ptr || v.IsNil() {
+		return nil
+	}
+	return fillDefaults(v.Elem(), "")
+}
+
+// fillDefaults 递归填充结构体字段的默认值
+func fillDefaults(v reflect.Value, path string) error {
+	switch v.Kind() {
+	case reflect.Ptr:
+		if v.IsNil() {
+			return nil
+		}
+		return fillDefaults(v.Elem(), path)
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			if err := fillDefaults(v.Index(i), fmt.Sprintf("%s[%d]", path, i)); err != nil {
+				return err
+			}
+		}
+		return nil
+	case reflect.Struct:
+	default:
+		return nil
+	}
+
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		fv := v.Field(i)
+		fieldPath := field.Name
+		if path != "" {
+			fieldPath = path + "." + field.Name
+		}
+
+		if tag, ok := field.Tag.Lookup(defaultTagName); ok && fv.IsZero() {
+			if err := setDefault(fv, tag); err != nil {
+				return fmt.Errorf("字段 %s 的默认值 %q 无效: %w", fieldPath, tag, err)
+			}
+		}
+		if err := fillDefaults(fv, fieldPath); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// setDefault 将default tag的文本解析后写入字段
+func setDefault(fv reflect.Value, tag string) error {
+	if fv.Type() == durationType {
+		d, err := time.ParseDuration(tag)
+		if err != nil {
+			return err
+		}
+		fv.SetInt(int64(d))
+		return nil
+	}
+
+	switch fv.Kind() {
+	case reflect.String:
+		fv.SetString(tag)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(tag)
+		if err != nil {
+			return err
+		}
+		fv.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(tag, 0, fv.Type().Bits())
+		if err != nil {
+			return err
+		}
+		fv.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(tag, 0, fv.Type().Bits())
+		if err != nil {
+			return err
+		}
+		fv.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(tag, fv.Type().Bits())
+		if err != nil {
+			return err
+		}
+		fv.SetFloat(f)
+	case reflect.Ptr:
+		elem := reflect.New(fv.Type().Elem())
+		if err := setDefault(elem.Elem(), tag); err != nil {
+			return err
+		}
+		fv.Set(elem)
+	case reflect.Slice:
+		return setCollectionDefault(fv, tag, "[", func(target reflect.Value) error {
+			for _, item := range splitDefaultList(tag) {
+				elem := reflect.New(fv.Type().Elem()).Elem()
+				if err := setDefault(elem, item); err != nil {
+					return err
+				}
+				target.Set(reflect.Append(target, elem))
+			}
+			return nil
+		})
+	case reflect.Map:
+		return setCollectionDefault(fv, tag, "{", func(target reflect.Value) error {
+			target.Set(reflect.MakeMap(fv.Type()))
+			for _, item := range splitDefaultList(tag) {
+				k, val, ok := strings.Cut(item, ":")
+				if !ok {
+					return fmt.Errorf("map默认值应为 k:v 形式: %s", item)
+				}
+				key := reflect.New(fv.Type().Key()).Elem()
+				if err := setDefault(key, strings.TrimSpace(k)); err != nil {
+					return err
+				}
+				elem := reflect.New(fv.Type().Elem()).Elem()
+				if err := setDefault(elem, strings.TrimSpace(val)); err != nil {
+					return err
+				}
+				target.SetMapIndex(key, elem)
+			}
+			return nil
+		})
+	case reflect.Struct:
+		return yaml.Unmarshal([]byte(tag), fv.Addr().Interface())
+	default:
+		return fmt.Errorf("不支持的字段类型 %s", fv.Type())
+	}
+	return nil
+}
+
+// setCollectionDefault 设置切片或map的默认值，tag以open开头时按YAML解析，否则调用split按逗号分隔解析
+func setCollectionDefault(fv reflect.Value, tag, open string, split func(target reflect.Value) error) error {
+	target := reflect.New(fv.Type()).Elem()
+	if strings.HasPrefix(strings.TrimSpace(tag), open) {
+		if err := yaml.Unmarshal([]byte(tag), target.Addr().Interface()); err != nil {
+			return err
+		}
+	} else if err := split(target); err != nil {
+		return err
+	}
+	fv.Set(target)
+	return nil
+}
+
+// splitDefaultList 按逗号分隔默认值，忽略空项
+func splitDefaultList(tag string) []string {
+	var items []string
+	for _, item := range strings.Split(tag, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
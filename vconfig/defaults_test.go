@@ -0,0 +1,75 @@
+package vconfig
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/constructorvirgil/virlog/test/testutils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type defaultsServer struct {
+	Host    string        `yaml:"host" default:"0.0.0.0"`
+	Port    int           `yaml:"port" default:"8080"`
+	Timeout time.Duration `yaml:"timeout" default:"30s"`
+	Debug   *bool         `yaml:"debug" default:"true"`
+}
+
+type defaultsConfig struct {
+	Server   defaultsServer    `yaml:"server"`
+	Replicas []defaultsServer  `yaml:"replicas"`
+	Hosts    []string          `yaml:"hosts" default:"a.local, b.local"`
+	Ports    []int             `yaml:"ports" default:"[80, 443]"`
+	Labels   map[string]string `yaml:"labels" default:"env:dev,team:infra"`
+	Weights  map[string]int    `yaml:"weights" default:"{a: 1, b: 2}"`
+	Ratio    float64           `yaml:"ratio" default:"0.5"`
+}
+
+// 测试使用default tag填充零值字段
+func TestApplyDefaults(t *testing.T) {
+	var cfg defaultsConfig
+	cfg.Server.Port = 9000
+	cfg.Replicas = []defaultsServer{{Host: "replica.local"}}
+
+	require.NoError(t, applyDefaults(&cfg))
+
+	assert.Equal(t, "0.0.0.0", cfg.Server.Host)
+	assert.Equal(t, 9000, cfg.Server.Port, "非零值字段不应被覆盖")
+	assert.Equal(t, 30*time.Second, cfg.Server.Timeout)
+	require.NotNil(t, cfg.Server.Debug)
+	assert.True(t, *cfg.Server.Debug)
+	assert.Equal(t, "replica.local", cfg.Replicas[0].Host)
+	assert.Equal(t, 8080, cfg.Replicas[0].Port, "切片中的结构体也应填充默认值")
+	assert.Equal(t, []string{"a.local", "b.local"}, cfg.Hosts)
+	assert.Equal(t, []int{80, 443}, cfg.Ports)
+	assert.Equal(t, map[string]string{"env": "dev", "team": "infra"}, cfg.Labels)
+	assert.Equal(t, map[string]int{"a": 1, "b": 2}, cfg.Weights)
+	assert.Equal(t, 0.5, cfg.Ratio)
+
+	var invalid struct {
+		Port int `default:"http"`
+	}
+	assert.Error(t, applyDefaults(&invalid))
+}
+
+// 测试配置文件中缺失的字段使用default tag填充
+func TestConfigDefaultsFromTags(t *testing.T) {
+	configFile := testutils.RandomTempFilename("test_defaults", ".yaml")
+	defer testutils.CleanTempFile(t, configFile)
+
+	require.NoError(t, os.WriteFile(configFile, []byte("server:\n  host: example.com\n"), 0600))
+
+	cfg, err := NewConfig(defaultsConfig{},
+		WithConfigFile[defaultsConfig](configFile),
+		WithConfigType[defaultsConfig](YAML))
+	require.NoError(t, err)
+	defer cfg.Close()
+
+	data := cfg.GetData()
+	assert.Equal(t, "example.com", data.Server.Host)
+	assert.Equal(t, 8080, data.Server.Port)
+	assert.Equal(t, 30*time.Second, data.Server.Timeout)
+	assert.Equal(t, []string{"a.local", "b.local"}, data.Hosts)
+}
@@ -0,0 +1,131 @@
+package vconfig
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// getenv 读取环境变量，进程中未设置时使用 .env 文件中的值
+func (c *Config[T]) getenv(key string) string {
+	if value, ok := os.LookupEnv(key); ok {
+		return value
+	}
+	return c.dotenv[key]
+}
+
+// loadDotenv 读取 WithDotenv 指定的 .env 文件
+func (c *Config[T]) loadDotenv() error {
+	for _, path := range c.dotenvFiles {
+		content, err := os.ReadFile(path)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("读取.env文件失败: %w", err)
+		}
+		vars, err := parseDotenv(content)
+		if err != nil {
+			return fmt.Errorf("解析.env文件 %s 失败: %w", path, err)
+		}
+		if c.dotenv == nil {
+			c.dotenv = make(map[string]string, len(vars))
+		}
+		for k, v := range vars {
+			c.dotenv[k] = v
+		}
+	}
+	return nil
+}
+
+// parseDotenv 解析 .env 文件内容
+//
+// 支持 # 注释、export 前缀、单引号（原样保留）和双引号（支持 \n、\t、\"、\\ 转义，可以跨行）包围的值，
+// 未加引号的值中 " #" 之后的内容视为注释
+func parseDotenv(content []byte) (map[string]string, error) {
+	vars := make(map[string]string)
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		line = strings.TrimSpace(strings.TrimPrefix(line, "export "))
+
+		key, value, ok := strings.Cut(line, "=")
+		key = strings.TrimSpace(key)
+		if !ok || key == "" || strings.ContainsAny(key, " \t") {
+			return nil, fmt.Errorf("第%d行格式无效: %s", lineNo, line)
+		}
+		value = strings.TrimSpace(value)
+
+		switch {
+		case strings.HasPrefix(value, `"`):
+			// 双引号的值可以跨行，读取到未转义的结束引号为止
+			raw := value[1:]
+			for closingQuote(raw) < 0 {
+				if !scanner.Scan() {
+					return nil, fmt.Errorf("第%d行的双引号未闭合", lineNo)
+				}
+				lineNo++
+				raw += "\n" + scanner.Text()
+			}
+			value = unescapeDotenv(raw[:closingQuote(raw)])
+		case strings.HasPrefix(value, "'"):
+			end := strings.Index(value[1:], "'")
+			if end < 0 {
+				return nil, fmt.Errorf("第%d行的单引号未闭合", lineNo)
+			}
+			value = value[1 : end+1]
+		default:
+			if i := strings.Index(value, " #"); i >= 0 {
+				value = strings.TrimSpace(value[:i])
+			}
+		}
+		vars[key] = value
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return vars, nil
+}
+
+// closingQuote 返回第一个未转义的双引号的位置，不存在时返回-1
+func closingQuote(s string) int {
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case '"':
+			return i
+		}
+	}
+	return -1
+}
+
+// unescapeDotenv 处理双引号值中的转义字符
+func unescapeDotenv(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '\\' || i+1 == len(s) {
+			b.WriteByte(s[i])
+			continue
+		}
+		i++
+		switch s[i] {
+		case 'n':
+			b.WriteByte('\n')
+		case 't':
+			b.WriteByte('\t')
+		case 'r':
+			b.WriteByte('\r')
+		default:
+			b.WriteByte(s[i])
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,68 @@
+package vconfig
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/constructorvirgil/virlog/test/testutils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// 测试解析.env文件
+func TestParseDotenv(t *testing.T) {
+	vars, err := parseDotenv([]byte(`
+# 注释
+APP_NAME=demo
+export APP_HOST = example.com # 行尾注释
+APP_HASH=a#b
+APP_SINGLE='raw \n value'
+APP_DOUBLE="line1\nline2 \"quoted\""
+APP_MULTI="first
+second"
+APP_EMPTY=
+`))
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{
+		"APP_NAME":   "demo",
+		"APP_HOST":   "example.com",
+		"APP_HASH":   "a#b",
+		"APP_SINGLE": `raw \n value`,
+		"APP_DOUBLE": "line1\nline2 \"quoted\"",
+		"APP_MULTI":  "first\nsecond",
+		"APP_EMPTY":  "",
+	}, vars)
+
+	_, err = parseDotenv([]byte("INVALID LINE"))
+	assert.Error(t, err)
+	_, err = parseDotenv([]byte(`APP_NAME="unterminated`))
+	assert.Error(t, err)
+}
+
+// 测试.env文件中的变量按环境变量前缀覆盖配置
+func TestWithDotenv(t *testing.T) {
+	configFile := testutils.RandomTempFilename("test_dotenv_config", ".yaml")
+	defer testutils.CleanTempFile(t, configFile)
+
+	dir := t.TempDir()
+	base := filepath.Join(dir, ".env")
+	local := filepath.Join(dir, ".env.local")
+	require.NoError(t, os.WriteFile(base, []byte("APP_SERVER_PORT=7000\nAPP_SERVER_HOST=dotenv-host\nAPP_LOG_LEVEL=warn\n"), 0644))
+	require.NoError(t, os.WriteFile(local, []byte("APP_SERVER_PORT=7001\n"), 0644))
+	t.Setenv("APP_SERVER_HOST", "env-host")
+
+	cfg, err := NewConfig(newDefaultConfig(),
+		WithConfigFile[AppConfig](configFile),
+		WithEnvPrefix[AppConfig]("APP"),
+		WithDotenv[AppConfig](base, local, filepath.Join(dir, "missing.env")))
+	require.NoError(t, err)
+	defer cfg.Close()
+
+	// 后面的文件优先，进程的环境变量优先于.env文件
+	assert.Equal(t, 7001, cfg.GetData().Server.Port)
+	assert.Equal(t, "env-host", cfg.GetData().Server.Host)
+	assert.Equal(t, "warn", cfg.GetData().Log.Level)
+	_, set := os.LookupEnv("APP_LOG_LEVEL")
+	assert.False(t, set, "不应修改进程的环境变量")
+}
@@ -0,0 +1,155 @@
+package vconfig
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+// DriftCallback 定期检查发现配置与配置源不一致时的回调函数
+type DriftCallback func(items []ConfigChangedItem)
+
+// driftCheck 定期检查配置漂移的设置
+type driftCheck struct {
+	interval time.Duration
+	callback DriftCallback
+	stop     chan struct{}
+	stopOnce sync.Once
+}
+
+// WithDriftCheck 每隔 interval 调用一次 CheckDrift，发现不一致时输出诊断日志并调用 callback（可以为nil）
+//
+// 用于发现丢失的文件变更事件、被手动修改的配置等，检查结果不会应用到当前配置
+func WithDriftCheck[T any](interval time.Duration, callback DriftCallback) ConfigOption[T] {
+	return func(c *Config[T]) {
+		if interval <= 0 {
+			return
+		}
+		c.driftCheck = &driftCheck{interval: interval, callback: callback, stop: make(chan struct{})}
+	}
+}
+
+// CheckDrift 重新读取配置文件或配置源中的配置，返回其与当前配置的差异，差异不会应用到当前配置
+//
+// 配置文件同样经过 $include、环境配置文件、环境变量和命令行参数的处理，与重新加载得到的配置一致；
+// 配置源中的配置不存在或无法解析时返回错误
+func (c *Config[T]) CheckDrift() ([]ConfigChangedItem, error) {
+	c.closedMu.RLock()
+	defer c.closedMu.RUnlock()
+	if c.closed {
+		return nil, ErrClosed
+	}
+
+	source, err := c.readSource()
+	if err != nil {
+		return nil, err
+	}
+	return findConfigChanges(c.GetData(), source, ""), nil
+}
+
+// readSource 读取配置文件或配置源中的配置，填充默认值并执行加载处理函数
+func (c *Config[T]) readSource() (T, error) {
+	var (
+		data    T
+		content []byte
+		err     error
+	)
+	switch {
+	case c.configFile != "":
+		raw, err := c.readConfigFile()
+		if err != nil {
+			return data, err
+		}
+		settings, _, _, err := c.loadSettings(raw)
+		if err != nil {
+			return data, err
+		}
+		// 以当前的viper配置为基础，与重新加载时的合并方式一致
+		v := viper.New()
+		for k, val := range c.v.AllSettings() {
+			v.Set(k, val)
+		}
+		for k, val := range settings {
+			v.Set(k, val)
+		}
+		c.applyOverrides(v)
+		return c.decodeViper(v)
+	case c.etcdClient != nil:
+		exists, err := loadConfigFromETCD(c.etcdClient, &data, c.configType)
+		if err != nil {
+			return data, fmt.Errorf("从ETCD加载配置失败: %w", err)
+		}
+		if !exists {
+			return data, fmt.Errorf("ETCD中不存在配置: %s", c.etcdConfig.Key)
+		}
+	case c.nacosClient != nil:
+		content, err = c.nacosClient.get()
+		if err != nil {
+			return data, err
+		}
+		if content == nil {
+			return data, fmt.Errorf("Nacos中不存在配置: %s", c.nacosConfig.DataID)
+		}
+	case c.httpSource != nil:
+		content, err = c.httpSource.get()
+		if err != nil {
+			return data, err
+		}
+	}
+	if content != nil {
+		if err := unmarshalConfig(content, &data, c.configType); err != nil {
+			return data, fmt.Errorf("解析配置失败: %w", err)
+		}
+	}
+	if err := applyDefaults(&data); err != nil {
+		return data, err
+	}
+	if err := c.runOnLoad(&data); err != nil {
+		return data, err
+	}
+	return data, nil
+}
+
+// watchDrift 按 WithDriftCheck 设置的间隔检查配置漂移
+func (c *Config[T]) watchDrift() {
+	dc := c.driftCheck
+	go func() {
+		ticker := time.NewTicker(dc.interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-dc.stop:
+				return
+			case <-ticker.C:
+			}
+
+			items, err := c.CheckDrift()
+			if errors.Is(err, ErrClosed) {
+				return
+			}
+			if err != nil {
+				getInternalLogger().Errorw("检查配置漂移失败", "source", c.sourceName(), "error", err)
+				continue
+			}
+			if len(items) == 0 {
+				continue
+			}
+			paths := make([]string, len(items))
+			for i, item := range items {
+				paths[i] = item.Path
+			}
+			getInternalLogger().Errorw("当前配置与配置源不一致", "source", c.sourceName(), "paths", paths)
+			if dc.callback != nil {
+				dc.callback(items)
+			}
+		}
+	}()
+}
+
+// close 停止检查配置漂移
+func (dc *driftCheck) close() {
+	dc.stopOnce.Do(func() { close(dc.stop) })
+}
@@ -0,0 +1,77 @@
+package vconfig
+
+import (
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// 测试检查配置文件与当前配置的差异，环境变量覆盖的配置不视为漂移
+func TestCheckDrift(t *testing.T) {
+	t.Setenv("DRIFT_SERVER_HOST", "0.0.0.0")
+	configFile := filepath.Join(t.TempDir(), "app.yaml")
+	require.NoError(t, os.WriteFile(configFile, []byte("server:\n  host: example.com\n  port: 9000\n"), 0644))
+
+	cfg, err := NewConfig(newDefaultConfig(),
+		WithConfigFile[AppConfig](configFile),
+		WithEnvPrefix[AppConfig]("DRIFT"))
+	require.NoError(t, err)
+	defer cfg.Close()
+	require.Equal(t, "0.0.0.0", cfg.GetData().Server.Host)
+
+	items, err := cfg.CheckDrift()
+	require.NoError(t, err)
+	assert.Empty(t, items)
+
+	// 模拟丢失的变更事件
+	cfg.data.Server.Port = 9100
+	items, err = cfg.CheckDrift()
+	require.NoError(t, err)
+	require.Len(t, items, 1)
+	assert.Equal(t, "server.port", items[0].Path)
+	assert.Equal(t, 9100, items[0].OldValue)
+	assert.Equal(t, 9000, items[0].NewValue)
+	// 差异不会应用到当前配置
+	assert.Equal(t, 9100, cfg.GetData().Server.Port)
+
+	cfg.Close()
+	_, err = cfg.CheckDrift()
+	assert.ErrorIs(t, err, ErrClosed)
+}
+
+// 测试定期检查HTTP配置源的配置漂移
+func TestDriftCheck(t *testing.T) {
+	source := &fakeHTTPSource{}
+	source.set("server:\n  port: 9000\n")
+	server := httptest.NewServer(source)
+	defer server.Close()
+
+	drifted := make(chan []ConfigChangedItem, 10)
+	cfg, err := NewConfig(newDefaultConfig(),
+		WithHTTPSource[AppConfig](server.URL, time.Hour, nil),
+		WithDriftCheck[AppConfig](20*time.Millisecond, func(items []ConfigChangedItem) {
+			drifted <- items
+		}))
+	require.NoError(t, err)
+	defer cfg.Close()
+
+	time.Sleep(60 * time.Millisecond)
+	assert.Empty(t, drifted)
+
+	// 轮询间隔很长，配置源的修改只能通过漂移检查发现
+	source.set("server:\n  port: 9100\n")
+	select {
+	case items := <-drifted:
+		require.Len(t, items, 1)
+		assert.Equal(t, "server.port", items[0].Path)
+		assert.Equal(t, 9100, items[0].NewValue)
+	case <-time.After(3 * time.Second):
+		t.Fatal("等待配置漂移超时")
+	}
+	assert.Equal(t, 9000, cfg.GetData().Server.Port)
+}
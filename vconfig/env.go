@@ -0,0 +1,127 @@
+package vconfig
+
+import (
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+)
+
+// EnvBinding 一个可以覆盖配置的环境变量
+type EnvBinding struct {
+	// Name 环境变量名，如 APP_HTTP_PORT
+	Name string
+	// Key 配置键，如 http.port
+	Key string
+	// Path 结构体字段路径，如 HTTP.Port
+	Path string
+	// Type 字段的Go类型，如 int、time.Duration、[]string
+	Type string
+	// Default default tag 声明的默认值，未声明时为空
+	Default string
+}
+
+// EnvBindings 返回使用 WithEnvPrefix(prefix) 时配置结构体 T 支持的全部环境变量，按字段顺序排列
+//
+// 配置键取自字段的 yaml tag，未设置时为小写的字段名。map字段和元素为结构体的切片
+// 无法通过环境变量覆盖，不会列出；切片可以用逗号分隔的值覆盖
+func EnvBindings[T any](prefix string) []EnvBinding {
+	var bindings []EnvBinding
+	t := reflect.TypeOf((*T)(nil)).Elem()
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.Kind() == reflect.Struct {
+		collectEnvBindings(t, prefix, "", "", &bindings)
+	}
+	return bindings
+}
+
+// EnvVarName 返回配置键在前缀 prefix 下对应的环境变量名
+func EnvVarName(prefix, key string) string {
+	return fmt.Sprintf("%s_%s", prefix, strings.ToUpper(strings.ReplaceAll(key, ".", "_")))
+}
+
+// collectEnvBindings 递归收集结构体字段对应的环境变量
+func collectEnvBindings(t reflect.Type, prefix, key, path string, bindings *[]EnvBinding) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() && !field.Anonymous {
+			continue
+		}
+		name, inline, skip := yamlFieldName(field)
+		if skip {
+			continue
+		}
+		fieldKey, fieldPath := key, path
+		if !inline {
+			fieldKey = joinPath(key, name)
+			fieldPath = joinPath(path, field.Name)
+		}
+
+		ft := field.Type
+		if ft.Kind() == reflect.Ptr && ft.Elem().Kind() == reflect.Struct {
+			ft = ft.Elem()
+		}
+		switch {
+		case ft.Kind() == reflect.Struct && ft != timeType:
+			collectEnvBindings(ft, prefix, fieldKey, fieldPath, bindings)
+			continue
+		case ft.Kind() == reflect.Map,
+			ft.Kind() == reflect.Slice && ft.Elem().Kind() == reflect.Struct:
+			continue
+		}
+
+		*bindings = append(*bindings, EnvBinding{
+			Name:    EnvVarName(prefix, fieldKey),
+			Key:     fieldKey,
+			Path:    fieldPath,
+			Type:    field.Type.String(),
+			Default: field.Tag.Get(defaultTagName),
+		})
+	}
+}
+
+// yamlFieldName 返回字段在yaml中的名称，inline 表示字段内联到上一级，skip 表示字段被忽略
+func yamlFieldName(field reflect.StructField) (name string, inline, skip bool) {
+	tag := field.Tag.Get("yaml")
+	if tag == "-" {
+		return "", false, true
+	}
+	parts := strings.Split(tag, ",")
+	for _, opt := range parts[1:] {
+		if opt == "inline" {
+			return "", true, false
+		}
+	}
+	if parts[0] != "" {
+		return strings.ToLower(parts[0]), false, false
+	}
+	return strings.ToLower(field.Name), false, false
+}
+
+// joinPath 使用点号拼接配置键或字段路径
+func joinPath(parent, name string) string {
+	if parent == "" {
+		return name
+	}
+	return parent + "." + name
+}
+
+// WriteEnvTable 将环境变量列表以Markdown表格的形式写入 w，可用于生成部署文档
+func WriteEnvTable(w io.Writer, bindings []EnvBinding) error {
+	var b strings.Builder
+	b.WriteString("| 环境变量 | 配置键 | 字段 | 类型 | 默认值 |\n")
+	b.WriteString("| --- | --- | --- | --- | --- |\n")
+	for _, binding := range bindings {
+		fmt.Fprintf(&b, "| %s | %s | %s | %s | %s |\n",
+			binding.Name, binding.Key, binding.Path, binding.Type, escapeTableCell(binding.Default))
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// escapeTableCell 转义表格单元格中的竖线
+func escapeTableCell(s string) string {
+	return strings.ReplaceAll(s, "|", `\|`)
+}
@@ -0,0 +1,63 @@
+package vconfig
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type envEmbedded struct {
+	Region string `yaml:"region" default:"cn|east"`
+}
+
+type envConfig struct {
+	envEmbedded `yaml:",inline"`
+	Server      defaultsServer    `yaml:"server"`
+	Cache       *defaultsServer   `yaml:"cache"`
+	Replicas    []defaultsServer  `yaml:"replicas"`
+	Hosts       []string          `yaml:"hosts" default:"a.local,b.local"`
+	Labels      map[string]string `yaml:"labels"`
+	Name        string
+	Ignored     string `yaml:"-"`
+	secret      string
+}
+
+// 测试列出配置结构体支持的环境变量
+func TestEnvBindings(t *testing.T) {
+	bindings := EnvBindings[envConfig]("APP")
+
+	names := make([]string, 0, len(bindings))
+	for _, b := range bindings {
+		names = append(names, b.Name)
+	}
+	assert.Equal(t, []string{
+		"APP_REGION",
+		"APP_SERVER_HOST", "APP_SERVER_PORT", "APP_SERVER_TIMEOUT", "APP_SERVER_DEBUG",
+		"APP_CACHE_HOST", "APP_CACHE_PORT", "APP_CACHE_TIMEOUT", "APP_CACHE_DEBUG",
+		"APP_HOSTS",
+		"APP_NAME",
+	}, names)
+
+	assert.Equal(t, EnvBinding{
+		Name:    "APP_SERVER_TIMEOUT",
+		Key:     "server.timeout",
+		Path:    "Server.Timeout",
+		Type:    "time.Duration",
+		Default: "30s",
+	}, bindings[3])
+	assert.Equal(t, "Region", bindings[0].Path)
+	assert.Equal(t, "[]string", bindings[9].Type)
+
+	var buf bytes.Buffer
+	require.NoError(t, WriteEnvTable(&buf, bindings[:1]))
+	assert.Equal(t, "| 环境变量 | 配置键 | 字段 | 类型 | 默认值 |\n"+
+		"| --- | --- | --- | --- | --- |\n"+
+		"| APP_REGION | region | Region | string | cn\\|east |\n", buf.String())
+
+	// 列出的环境变量与 WithEnvPrefix 实际读取的一致
+	for _, b := range EnvBindings[AppConfig]("APP") {
+		assert.Equal(t, EnvVarName("APP", b.Key), b.Name)
+	}
+}
@@ -1,16 +1,15 @@
 package vconfig
 
 import (
-	"bytes"
 	"context"
 	"crypto/tls"
-	"encoding/json"
+	"crypto/x509"
 	"fmt"
+	"os"
+	"sync/atomic"
 	"time"
 
-	"github.com/BurntSushi/toml"
 	clientv3 "go.etcd.io/etcd/client/v3"
-	"gopkg.in/yaml.v3"
 )
 
 // ETCDConfig ETCD配置
@@ -27,13 +26,23 @@ type ETCDConfig struct {
 	Password string
 	// TLS配置
 	TLS *TLSConfig
+	// 是否使用前缀模式，前缀模式下Key作为前缀，每个子key对应一个配置项，
+	// 如 /config/app/server/port 对应配置路径 server.port
+	Prefix bool
 }
 
 // TLSConfig TLS配置
 type TLSConfig struct {
-	CertFile      string
-	KeyFile       string
+	// 客户端证书和私钥，服务端要求双向TLS时设置，两者需要同时设置
+	CertFile string
+	KeyFile  string
+	// 校验服务端证书的CA证书，未设置时使用系统CA
 	TrustedCAFile string
+	// 校验服务端证书时使用的主机名，未设置时使用连接地址中的主机名，
+	// 通过IP或负载均衡地址连接时设置为证书中的名称
+	ServerName string
+	// 不校验服务端证书，仅用于测试环境
+	InsecureSkipVerify bool
 }
 
 // DefaultETCDConfig 返回默认的ETCD配置
@@ -51,8 +60,23 @@ type etcdClient struct {
 	config *ETCDConfig
 	ctx    context.Context
 	cancel context.CancelFunc
+	// 最近一次读取或写入的配置版本（ModRevision）
+	revision atomic.Int64
+	// 最近一次读取、写入或监听到的配置key的ModRevision，key不存在时为0，Update据此比较并交换
+	modRevision atomic.Int64
+	// 最近一次读取、写入或监听到的配置内容，key不存在或前缀模式下为nil
+	content atomic.Pointer[[]byte]
+	// 监听已处理到的ETCD版本，重新建立监听时从下一个版本开始，避免遗漏中断期间的变更
+	watchRevision atomic.Int64
 }
 
+const (
+	// etcdWatchRetryMin 监听中断后重新建立监听的最小等待时间
+	etcdWatchRetryMin = 100 * time.Millisecond
+	// etcdWatchRetryMax 监听中断后重新建立监听的最大等待时间
+	etcdWatchRetryMax = 10 * time.Second
+)
+
 // newETCDClient 创建ETCD客户端
 func newETCDClient(config *ETCDConfig) (*etcdClient, error) {
 	// 创建context
@@ -106,71 +130,187 @@ func (e *etcdClient) get() ([]byte, error) {
 	if err != nil {
 		return nil, fmt.Errorf("从ETCD获取配置失败: %w", err)
 	}
+	e.watchRevision.Store(resp.Header.Revision)
 
 	if len(resp.Kvs) == 0 {
+		e.revision.Store(resp.Header.Revision)
+		e.modRevision.Store(0)
+		e.content.Store(nil)
 		return nil, nil
 	}
 
+	e.revision.Store(resp.Kvs[0].ModRevision)
+	e.modRevision.Store(resp.Kvs[0].ModRevision)
+	e.content.Store(&resp.Kvs[0].Value)
 	return resp.Kvs[0].Value, nil
 }
 
-// put 将配置保存到ETCD
+// put 仅当配置key的ModRevision仍为最近一次读取或监听到的版本时保存配置，
+// 期间被其他客户端修改时返回 ErrConflict
 func (e *etcdClient) put(data []byte) error {
-	_, err := e.client.Put(e.ctx, e.config.Key, string(data))
+	resp, err := e.client.Txn(e.ctx).
+		If(clientv3.Compare(clientv3.ModRevision(e.config.Key), "=", e.modRevision.Load())).
+		Then(clientv3.OpPut(e.config.Key, string(data))).
+		Commit()
 	if err != nil {
 		return fmt.Errorf("保存配置到ETCD失败: %w", err)
 	}
+	if !resp.Succeeded {
+		return ErrConflict
+	}
+	e.revision.Store(resp.Header.Revision)
+	e.modRevision.Store(resp.Header.Revision)
+	e.content.Store(&data)
 	return nil
 }
 
-// watch 监听ETCD配置变更
-func (e *etcdClient) watch(callback func([]byte)) {
-	watchChan := e.client.Watch(e.ctx, e.config.Key)
+// watch 监听ETCD配置变更，每次收到监听响应时以响应中的错误调用report
+//
+// 监听因版本被压缩、失去leader等原因中断后会从上次处理的版本继续监听；
+// 要继续的版本已被压缩时重新读取配置，配置有变化时以最新内容调用callback
+func (e *etcdClient) watch(report func(error), callback func([]byte)) {
+	resync := func() error {
+		last := e.modRevision.Load()
+		data, err := e.get()
+		if err != nil {
+			return err
+		}
+		if data != nil && e.modRevision.Load() != last {
+			callback(data)
+		}
+		return nil
+	}
+	e.watchLoop(e.config.Key, nil, report, resync, func(resp clientv3.WatchResponse) {
+		for _, ev := range resp.Events {
+			switch ev.Type {
+			case clientv3.EventTypePut:
+				e.revision.Store(ev.Kv.ModRevision)
+				e.modRevision.Store(ev.Kv.ModRevision)
+				e.content.Store(&ev.Kv.Value)
+				callback(ev.Kv.Value)
+			case clientv3.EventTypeDelete:
+				e.modRevision.Store(0)
+				e.content.Store(nil)
+			}
+		}
+	})
+}
+
+// watchLoop 在后台监听key，每个响应调用一次handle，直到客户端关闭
+//
+// 监听中断后按退避间隔从 watchRevision 的下一个版本重新建立监听；
+// 监听要求连接的节点有leader，节点与集群失联时切换到其他节点。
+// 要继续的版本已被压缩时先调用resync读取最新配置，失败时稍后重试
+func (e *etcdClient) watchLoop(key string, opts []clientv3.OpOption, report func(error), resync func() error, handle func(resp clientv3.WatchResponse)) {
 	go func() {
-		for resp := range watchChan {
-			for _, ev := range resp.Events {
-				if ev.Type == clientv3.EventTypePut {
-					callback(ev.Kv.Value)
+		backoff := etcdWatchRetryMin
+		needResync := false
+		for {
+			if needResync {
+				if err := resync(); err != nil {
+					report(err)
+					getInternalLogger().Errorw("重新读取ETCD配置失败", "key", key, "error", err)
+					if !e.sleep(&backoff) {
+						return
+					}
+					continue
 				}
+				needResync = false
+			}
+
+			watchOpts := append([]clientv3.OpOption{}, opts...)
+			if rev := e.watchRevision.Load(); rev > 0 {
+				watchOpts = append(watchOpts, clientv3.WithRev(rev+1))
+			}
+			watchChan := e.client.Watch(clientv3.WithRequireLeader(e.ctx), key, watchOpts...)
+			for resp := range watchChan {
+				if resp.CompactRevision != 0 {
+					// 要继续的版本已被压缩，中间的变更无法获取
+					getInternalLogger().Errorw("ETCD监听的版本已被压缩，重新读取配置", "key", key,
+						"revision", e.watchRevision.Load()+1, "compact_revision", resp.CompactRevision)
+					needResync = true
+					break
+				}
+				if err := resp.Err(); err != nil {
+					report(err)
+					continue
+				}
+				report(nil)
+				backoff = etcdWatchRetryMin
+				if len(resp.Events) > 0 {
+					handle(resp)
+				}
+				if resp.Header.Revision > e.watchRevision.Load() {
+					e.watchRevision.Store(resp.Header.Revision)
+				}
+			}
+			if e.ctx.Err() != nil {
+				return
+			}
+			getInternalLogger().Infow("ETCD监听已中断，重新建立监听", "key", key, "revision", e.watchRevision.Load()+1)
+			if !e.sleep(&backoff) {
+				return
 			}
 		}
 	}()
 }
 
+// sleep 等待退避间隔并将间隔加倍，客户端关闭时返回false
+func (e *etcdClient) sleep(backoff *time.Duration) bool {
+	timer := time.NewTimer(*backoff)
+	defer timer.Stop()
+	select {
+	case <-e.ctx.Done():
+		return false
+	case <-timer.C:
+	}
+	if *backoff *= 2; *backoff > etcdWatchRetryMax {
+		*backoff = etcdWatchRetryMax
+	}
+	return true
+}
+
 // loadTLSConfig 加载TLS配置
 func loadTLSConfig(config *TLSConfig) (*tls.Config, error) {
-	cert, err := tls.LoadX509KeyPair(config.CertFile, config.KeyFile)
-	if err != nil {
-		return nil, fmt.Errorf("加载证书失败: %w", err)
+	tlsConfig := &tls.Config{
+		ServerName:         config.ServerName,
+		InsecureSkipVerify: config.InsecureSkipVerify,
+		MinVersion:         tls.VersionTLS12,
 	}
 
-	return &tls.Config{
-		Certificates: []tls.Certificate{cert},
-		MinVersion:   tls.VersionTLS12,
-	}, nil
+	if config.CertFile != "" || config.KeyFile != "" {
+		if config.CertFile == "" || config.KeyFile == "" {
+			return nil, fmt.Errorf("客户端证书和私钥需要同时设置")
+		}
+		cert, err := tls.LoadX509KeyPair(config.CertFile, config.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("加载证书失败: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	if config.TrustedCAFile != "" {
+		caBytes, err := os.ReadFile(config.TrustedCAFile)
+		if err != nil {
+			return nil, fmt.Errorf("读取CA证书失败: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(caBytes) {
+			return nil, fmt.Errorf("CA证书中没有有效的PEM证书: %s", config.TrustedCAFile)
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	return tlsConfig, nil
 }
 
 // saveConfigToETCD 保存配置到ETCD
 func saveConfigToETCD[T any](client *etcdClient, data T, configType ConfigType) error {
-	var (
-		configBytes []byte
-		err         error
-	)
-
-	// 根据配置类型选择序列化方式
-	switch configType {
-	case JSON:
-		configBytes, err = json.Marshal(data)
-	case YAML:
-		configBytes, err = yaml.Marshal(data)
-	case TOML:
-		var buf bytes.Buffer
-		err = toml.NewEncoder(&buf).Encode(data)
-		configBytes = buf.Bytes()
-	default: // 默认使用 JSON
-		configBytes, err = json.Marshal(data)
+	if client.config.Prefix {
+		return savePrefixConfigToETCD(client, data, configType)
 	}
 
+	configBytes, err := marshalConfig(data, configType)
 	if err != nil {
 		return fmt.Errorf("序列化配置失败: %w", err)
 	}
@@ -181,6 +321,10 @@ func saveConfigToETCD[T any](client *etcdClient, data T, configType ConfigType)
 
 // loadConfigFromETCD 从ETCD加载配置
 func loadConfigFromETCD[T any](client *etcdClient, data *T, configType ConfigType) (exists bool, err error) {
+	if client.config.Prefix {
+		return loadPrefixConfigFromETCD(client, data, configType)
+	}
+
 	// 从ETCD获取配置
 	configBytes, err := client.get()
 	if err != nil {
@@ -192,17 +336,7 @@ func loadConfigFromETCD[T any](client *etcdClient, data *T, configType ConfigTyp
 		return false, nil
 	}
 
-	// 根据配置类型选择反序列化方式
-	switch configType {
-	case YAML:
-		err = yaml.Unmarshal(configBytes, data)
-	case TOML:
-		err = toml.Unmarshal(configBytes, data)
-	default: // 默认使用 JSON
-		err = json.Unmarshal(configBytes, data)
-	}
-
-	if err != nil {
+	if err := unmarshalConfig(configBytes, data, configType); err != nil {
 		return false, fmt.Errorf("反序列化配置失败: %w", err)
 	}
 
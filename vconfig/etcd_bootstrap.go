@@ -0,0 +1,87 @@
+package vconfig
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"go.etcd.io/etcd/client/v3/concurrency"
+)
+
+const (
+	// etcdBootstrapLockTTL 前缀模式写入默认配置时锁的租约时间（秒），持有锁的实例异常退出后锁在租约过期后释放
+	etcdBootstrapLockTTL = 10
+	// etcdBootstrapLockTimeout 等待其他实例写入默认配置的最长时间
+	etcdBootstrapLockTimeout = 30 * time.Second
+)
+
+// bootstrapLockKey 返回前缀模式写入默认配置时使用的锁前缀，位于配置前缀之外，不会被当作配置项读取或监听
+func (e *etcdClient) bootstrapLockKey() string {
+	return strings.TrimSuffix(e.config.Key, "/") + ".bootstrap-lock"
+}
+
+// bootstrapETCD 在ETCD中不存在配置时写入默认配置 data，保证多个实例同时启动时只有一个实例写入
+//
+// 单key模式使用仅在key不存在时写入的事务，前缀模式使用基于租约的分布式锁并在持有锁后再次检查配置是否存在。
+// 其他实例已写入配置时读取其写入的配置到 data
+func bootstrapETCD[T any](client *etcdClient, data *T, configType ConfigType) error {
+	if client.config.Prefix {
+		return bootstrapPrefixETCD(client, data, configType)
+	}
+
+	// 读取到key不存在后 modRevision 为0，put 仅在key仍不存在时写入
+	err := saveConfigToETCD(client, *data, configType)
+	if !errors.Is(err, ErrConflict) {
+		return err
+	}
+	return reloadBootstrapped(client, data, configType)
+}
+
+// bootstrapPrefixETCD 持有分布式锁写入前缀模式的默认配置
+func bootstrapPrefixETCD[T any](client *etcdClient, data *T, configType ConfigType) error {
+	session, err := concurrency.NewSession(client.client,
+		concurrency.WithContext(client.ctx), concurrency.WithTTL(etcdBootstrapLockTTL))
+	if err != nil {
+		return fmt.Errorf("创建ETCD会话失败: %w", err)
+	}
+	defer session.Close()
+
+	mu := concurrency.NewMutex(session, client.bootstrapLockKey())
+	ctx, cancel := context.WithTimeout(client.ctx, etcdBootstrapLockTimeout)
+	defer cancel()
+	if err := mu.Lock(ctx); err != nil {
+		return fmt.Errorf("获取ETCD默认配置锁失败: %w", err)
+	}
+	defer func() {
+		_ = mu.Unlock(client.ctx)
+	}()
+
+	// 等待锁期间其他实例可能已写入配置
+	kvs, err := client.getPrefix()
+	if err != nil {
+		return err
+	}
+	if len(kvs) > 0 {
+		return reloadBootstrapped(client, data, configType)
+	}
+	return saveConfigToETCD(client, *data, configType)
+}
+
+// reloadBootstrapped 读取其他实例写入的配置并应用默认值
+func reloadBootstrapped[T any](client *etcdClient, data *T, configType ConfigType) error {
+	var loaded T
+	exists, err := loadConfigFromETCD(client, &loaded, configType)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return fmt.Errorf("其他实例写入的默认配置已被删除")
+	}
+	if err := applyDefaults(&loaded); err != nil {
+		return err
+	}
+	*data = loaded
+	return nil
+}
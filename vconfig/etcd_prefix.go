@@ -0,0 +1,250 @@
+package vconfig
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	clientv3 "go.etcd.io/etcd/client/v3"
+	"gopkg.in/yaml.v3"
+)
+
+// etcdMaxTxnOps 单个ETCD事务允许的最大操作数（ETCD默认限制为128）
+const etcdMaxTxnOps = 128
+
+// prefixKey 返回前缀模式下配置路径对应的ETCD key
+func (e *etcdClient) prefixKey(path string) string {
+	return strings.TrimSuffix(e.config.Key, "/") + "/" + strings.ReplaceAll(path, ".", "/")
+}
+
+// keyPath 返回前缀模式下ETCD key对应的配置路径，如 /config/app/server/port -> server.port
+func (e *etcdClient) keyPath(key string) string {
+	rel := strings.TrimPrefix(key, strings.TrimSuffix(e.config.Key, "/")+"/")
+	return strings.ReplaceAll(strings.Trim(rel, "/"), "/", ".")
+}
+
+// getPrefix 获取前缀下所有的key和值
+func (e *etcdClient) getPrefix() (map[string]string, error) {
+	prefix := strings.TrimSuffix(e.config.Key, "/") + "/"
+	resp, err := e.client.Get(e.ctx, prefix, clientv3.WithPrefix())
+	if err != nil {
+		return nil, fmt.Errorf("从ETCD获取配置失败: %w", err)
+	}
+
+	e.revision.Store(resp.Header.Revision)
+	e.watchRevision.Store(resp.Header.Revision)
+	kvs := make(map[string]string, len(resp.Kvs))
+	for _, kv := range resp.Kvs {
+		kvs[string(kv.Key)] = string(kv.Value)
+	}
+	return kvs, nil
+}
+
+// putAll 批量写入多个key，每个事务最多包含 etcdMaxTxnOps 个操作
+func (e *etcdClient) putAll(kvs map[string]string) error {
+	keys := make([]string, 0, len(kvs))
+	for k := range kvs {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for start := 0; start < len(keys); start += etcdMaxTxnOps {
+		end := start + etcdMaxTxnOps
+		if end > len(keys) {
+			end = len(keys)
+		}
+		ops := make([]clientv3.Op, 0, end-start)
+		for _, k := range keys[start:end] {
+			ops = append(ops, clientv3.OpPut(k, kvs[k]))
+		}
+		resp, err := e.client.Txn(e.ctx).Then(ops...).Commit()
+		if err != nil {
+			return fmt.Errorf("保存配置到ETCD失败: %w", err)
+		}
+		e.revision.Store(resp.Header.Revision)
+	}
+	return nil
+}
+
+// watchPrefix 监听前缀下所有key的变更，每批事件回调一次，参数为本批次中第一个变更的key；
+// 每次收到监听响应时以响应中的错误调用report。监听中断后的处理同 watch，
+// 要继续的版本已被压缩时以前缀本身调用callback重新加载全部配置
+func (e *etcdClient) watchPrefix(report func(error), callback func(key string)) {
+	prefix := strings.TrimSuffix(e.config.Key, "/") + "/"
+	resync := func() error {
+		before := e.watchRevision.Load()
+		callback(prefix)
+		// 重新加载成功时 getPrefix 会更新监听版本
+		if e.watchRevision.Load() == before {
+			return fmt.Errorf("重新加载前缀 %s 下的配置失败", prefix)
+		}
+		return nil
+	}
+	e.watchLoop(prefix, []clientv3.OpOption{clientv3.WithPrefix()}, report, resync, func(resp clientv3.WatchResponse) {
+		e.revision.Store(resp.Header.Revision)
+		callback(string(resp.Events[0].Kv.Key))
+	})
+}
+
+// savePrefixConfigToETCD 将配置拆分为单独的配置项写入ETCD
+func savePrefixConfigToETCD[T any](client *etcdClient, data T, configType ConfigType) error {
+	settings, err := toSettingsMap(data, configType)
+	if err != nil {
+		return err
+	}
+
+	kvs := make(map[string]string)
+	if err := flattenSettings(settings, "", func(path string, value interface{}) error {
+		raw, err := formatPrefixValue(value)
+		if err != nil {
+			return fmt.Errorf("序列化配置项 %s 失败: %w", path, err)
+		}
+		kvs[client.prefixKey(path)] = raw
+		return nil
+	}); err != nil {
+		return err
+	}
+
+	return client.putAll(kvs)
+}
+
+// loadPrefixConfigFromETCD 读取前缀下的所有配置项并组装为配置结构体
+func loadPrefixConfigFromETCD[T any](client *etcdClient, data *T, configType ConfigType) (bool, error) {
+	kvs, err := client.getPrefix()
+	if err != nil {
+		return false, err
+	}
+	if len(kvs) == 0 {
+		return false, nil
+	}
+
+	if err := assemblePrefixConfig(client, kvs, data, configType); err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// assemblePrefixConfig 将前缀下的配置项组装到配置结构体中
+func assemblePrefixConfig[T any](client *etcdClient, kvs map[string]string, data *T, configType ConfigType) error {
+	// 以当前数据为基础，ETCD中不存在的配置项保持原值
+	settings, err := toSettingsMap(*data, configType)
+	if err != nil {
+		return err
+	}
+
+	dataType := reflect.TypeOf(data).Elem()
+	for key, raw := range kvs {
+		path := client.keyPath(key)
+		if path == "" {
+			continue
+		}
+		parts := strings.Split(path, ".")
+		setSettingsValue(settings, parts, parsePrefixValue(raw, fieldKindAt(dataType, parts, configType)))
+	}
+
+	configBytes, err := marshalConfig(settings, configType)
+	if err != nil {
+		return fmt.Errorf("序列化配置失败: %w", err)
+	}
+
+	var newData T
+	if err := unmarshalConfig(configBytes, &newData, configType); err != nil {
+		return fmt.Errorf("反序列化配置失败: %w", err)
+	}
+	*data = newData
+
+	return nil
+}
+
+// flattenSettings 遍历嵌套map中的所有叶子节点
+func flattenSettings(settings map[string]interface{}, prefix string, fn func(path string, value interface{}) error) error {
+	for k, v := range settings {
+		path := k
+		if prefix != "" {
+			path = prefix + "." + k
+		}
+		if nested, ok := v.(map[string]interface{}); ok && len(nested) > 0 {
+			if err := flattenSettings(nested, path, fn); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := fn(path, v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// setSettingsValue 在嵌套map中按路径设置值，中间节点不存在时自动创建
+func setSettingsValue(settings map[string]interface{}, parts []string, value interface{}) {
+	current := settings
+	for _, part := range parts[:len(parts)-1] {
+		next, ok := current[part].(map[string]interface{})
+		if !ok {
+			next = make(map[string]interface{})
+			current[part] = next
+		}
+		current = next
+	}
+	current[parts[len(parts)-1]] = value
+}
+
+// formatPrefixValue 将单个配置项格式化为ETCD中保存的文本，字符串保存原文，其他类型保存为JSON
+func formatPrefixValue(value interface{}) (string, error) {
+	if s, ok := value.(string); ok {
+		return s, nil
+	}
+	b, err := json.Marshal(value)
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+// parsePrefixValue 解析ETCD中单个配置项的文本，目标字段为字符串时保留原文
+func parsePrefixValue(raw string, kind reflect.Kind) interface{} {
+	if kind == reflect.String {
+		return raw
+	}
+	var value interface{}
+	if err := yaml.Unmarshal([]byte(raw), &value); err != nil || value == nil {
+		return raw
+	}
+	return value
+}
+
+// fieldKindAt 查找配置路径对应字段的类型，找不到时返回 reflect.Invalid
+func fieldKindAt(t reflect.Type, parts []string, configType ConfigType) reflect.Kind {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if len(parts) == 0 {
+		return t.Kind()
+	}
+
+	switch t.Kind() {
+	case reflect.Struct:
+		tagName := structTagName(configType)
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			if !field.IsExported() {
+				continue
+			}
+			name := strings.Split(field.Tag.Get(tagName), ",")[0]
+			if name == "-" {
+				continue
+			}
+			if name == parts[0] || (name == "" && strings.EqualFold(field.Name, parts[0])) {
+				return fieldKindAt(field.Type, parts[1:], configType)
+			}
+		}
+	case reflect.Map:
+		return fieldKindAt(t.Elem(), parts[1:], configType)
+	}
+
+	return reflect.Invalid
+}
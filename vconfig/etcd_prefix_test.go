@@ -0,0 +1,68 @@
+package vconfig
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// 测试前缀模式下配置项的拆分与组装
+func TestPrefixConfigAssembly(t *testing.T) {
+	testCases := []struct {
+		name       string
+		configType ConfigType
+	}{
+		{"YAML配置", YAML},
+		{"JSON配置", JSON},
+		{"TOML配置", TOML},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			client := &etcdClient{config: &ETCDConfig{Key: "/config/app/", Prefix: true}}
+
+			// 拆分默认配置
+			settings, err := toSettingsMap(newDefaultConfig(), tc.configType)
+			require.NoError(t, err)
+
+			kvs := make(map[string]string)
+			require.NoError(t, flattenSettings(settings, "", func(path string, value interface{}) error {
+				raw, err := formatPrefixValue(value)
+				kvs[client.prefixKey(path)] = raw
+				return err
+			}))
+			assert.Equal(t, "8080", kvs["/config/app/server/port"])
+			assert.Equal(t, "10", kvs["/config/app/database/max_conns"])
+			assert.Equal(t, "localhost", kvs["/config/app/server/host"])
+
+			// 运维单独修改某些配置项，数字形式的字符串保持为字符串
+			kvs["/config/app/server/port"] = "9000"
+			kvs["/config/app/app/version"] = "2"
+
+			var data AppConfig
+			require.NoError(t, assemblePrefixConfig(client, kvs, &data, tc.configType))
+			assert.Equal(t, 9000, data.Server.Port)
+			assert.Equal(t, "2", data.App.Version)
+			assert.Equal(t, newDefaultConfig().App.Name, data.App.Name)
+			assert.Equal(t, 10, data.Database.MaxConns)
+
+			// 只存在部分配置项时，其余配置项保持原值
+			partial := newDefaultConfig()
+			require.NoError(t, assemblePrefixConfig(client, map[string]string{
+				"/config/app/log/level": "debug",
+			}, &partial, tc.configType))
+			assert.Equal(t, "debug", partial.Log.Level)
+			assert.Equal(t, 8080, partial.Server.Port)
+		})
+	}
+}
+
+// 测试ETCD key与配置路径的转换
+func TestPrefixKeyPath(t *testing.T) {
+	client := &etcdClient{config: &ETCDConfig{Key: "/config/app", Prefix: true}}
+
+	assert.Equal(t, "/config/app/server/port", client.prefixKey("server.port"))
+	assert.Equal(t, "server.port", client.keyPath("/config/app/server/port"))
+	assert.Equal(t, "", client.keyPath("/config/app/"))
+}
@@ -0,0 +1,186 @@
+package vconfig
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testCert 测试使用的证书和私钥
+type testCert struct {
+	cert    *x509.Certificate
+	key     *ecdsa.PrivateKey
+	certPEM []byte
+	keyPEM  []byte
+}
+
+// newTestCert 创建证书，parent 为nil时创建自签名的CA证书
+func newTestCert(t *testing.T, parent *testCert, name string, usage x509.ExtKeyUsage) *testCert {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	serial, err := rand.Int(rand.Reader, big.NewInt(1<<62))
+	require.NoError(t, err)
+	tmpl := &x509.Certificate{
+		SerialNumber: serial,
+		Subject:      pkix.Name{CommonName: name},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		DNSNames:     []string{name},
+	}
+	signer, signerKey := tmpl, key
+	if parent == nil {
+		tmpl.IsCA = true
+		tmpl.BasicConstraintsValid = true
+		tmpl.KeyUsage |= x509.KeyUsageCertSign
+	} else {
+		tmpl.ExtKeyUsage = []x509.ExtKeyUsage{usage}
+		signer, signerKey = parent.cert, parent.key
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, signer, &key.PublicKey, signerKey)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+	return &testCert{
+		cert:    cert,
+		key:     key,
+		certPEM: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		keyPEM:  pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}),
+	}
+}
+
+// writeTestCert 将证书和私钥写入目录，返回文件路径
+func writeTestCert(t *testing.T, dir, name string, c *testCert) (certFile, keyFile string) {
+	t.Helper()
+	certFile = filepath.Join(dir, name+".pem")
+	keyFile = filepath.Join(dir, name+"-key.pem")
+	require.NoError(t, os.WriteFile(certFile, c.certPEM, 0600))
+	require.NoError(t, os.WriteFile(keyFile, c.keyPEM, 0600))
+	return certFile, keyFile
+}
+
+// startTLSServer 启动要求客户端证书的TLS服务，返回监听地址
+func startTLSServer(t *testing.T, ca, server *testCert) string {
+	t.Helper()
+	pool := x509.NewCertPool()
+	pool.AddCert(ca.cert)
+	serverCert, err := tls.X509KeyPair(server.certPEM, server.keyPEM)
+	require.NoError(t, err)
+
+	lis, err := tls.Listen("tcp", "127.0.0.1:0", &tls.Config{
+		Certificates: []tls.Certificate{serverCert},
+		ClientCAs:    pool,
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+		MinVersion:   tls.VersionTLS12,
+	})
+	require.NoError(t, err)
+	t.Cleanup(func() { lis.Close() })
+
+	go func() {
+		for {
+			conn, err := lis.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				_ = conn.(*tls.Conn).Handshake()
+				_, _ = conn.Write([]byte("ok"))
+			}()
+		}
+	}()
+	return lis.Addr().String()
+}
+
+// dialTLS 使用TLS配置连接服务并读取响应，握手或校验失败时返回错误
+func dialTLS(addr string, config *tls.Config) error {
+	conn, err := tls.DialWithDialer(&net.Dialer{Timeout: time.Second}, "tcp", addr, config)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	buf := make([]byte, 2)
+	_, err = conn.Read(buf)
+	return err
+}
+
+// 测试加载双向TLS配置，包括CA证书、服务端名称覆盖和跳过校验
+func TestLoadTLSConfigMutual(t *testing.T) {
+	dir := t.TempDir()
+	ca := newTestCert(t, nil, "test-ca", 0)
+	server := newTestCert(t, ca, "etcd.local", x509.ExtKeyUsageServerAuth)
+	client := newTestCert(t, ca, "client", x509.ExtKeyUsageClientAuth)
+	caFile, _ := writeTestCert(t, dir, "ca", ca)
+	certFile, keyFile := writeTestCert(t, dir, "client", client)
+	addr := startTLSServer(t, ca, server)
+
+	// 证书中的名称为 etcd.local，通过IP连接时需要覆盖服务端名称
+	config, err := loadTLSConfig(&TLSConfig{
+		CertFile:      certFile,
+		KeyFile:       keyFile,
+		TrustedCAFile: caFile,
+		ServerName:    "etcd.local",
+	})
+	require.NoError(t, err)
+	assert.NoError(t, dialTLS(addr, config))
+
+	config, err = loadTLSConfig(&TLSConfig{CertFile: certFile, KeyFile: keyFile, TrustedCAFile: caFile})
+	require.NoError(t, err)
+	assert.Error(t, dialTLS(addr, config), "服务端名称与证书不匹配")
+
+	// 没有客户端证书时服务端拒绝连接
+	config, err = loadTLSConfig(&TLSConfig{TrustedCAFile: caFile, ServerName: "etcd.local"})
+	require.NoError(t, err)
+	assert.Error(t, dialTLS(addr, config))
+
+	// 未设置CA时使用系统CA，无法校验测试CA签发的证书
+	config, err = loadTLSConfig(&TLSConfig{CertFile: certFile, KeyFile: keyFile, ServerName: "etcd.local"})
+	require.NoError(t, err)
+	assert.Error(t, dialTLS(addr, config))
+
+	config, err = loadTLSConfig(&TLSConfig{CertFile: certFile, KeyFile: keyFile, InsecureSkipVerify: true})
+	require.NoError(t, err)
+	assert.NoError(t, dialTLS(addr, config))
+}
+
+// 测试无效的TLS配置
+func TestLoadTLSConfigErrors(t *testing.T) {
+	dir := t.TempDir()
+	ca := newTestCert(t, nil, "test-ca", 0)
+	certFile, keyFile := writeTestCert(t, dir, "ca", ca)
+
+	_, err := loadTLSConfig(&TLSConfig{CertFile: certFile})
+	assert.ErrorContains(t, err, "同时设置")
+
+	_, err = loadTLSConfig(&TLSConfig{TrustedCAFile: keyFile})
+	assert.ErrorContains(t, err, "没有有效的PEM证书")
+
+	_, err = loadTLSConfig(&TLSConfig{TrustedCAFile: filepath.Join(dir, "missing.pem")})
+	assert.ErrorContains(t, err, "读取CA证书失败")
+
+	// 创建客户端时返回TLS配置错误
+	_, err = newETCDClient(&ETCDConfig{
+		Endpoints:   []string{"127.0.0.1:2379"},
+		DialTimeout: time.Second,
+		TLS:         &TLSConfig{TrustedCAFile: keyFile},
+	})
+	assert.ErrorContains(t, err, "加载TLS配置失败")
+}
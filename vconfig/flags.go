@@ -0,0 +1,117 @@
+package vconfig
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"strings"
+
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+	"gopkg.in/yaml.v3"
+)
+
+// flagNameReplacer 将配置键转换为命令行参数名，如 "database.max_conns" -> "database-max-conns"
+var flagNameReplacer = strings.NewReplacer(".", "-", "_", "-")
+
+// FlagName 返回配置键对应的命令行参数名
+func FlagName(key string) string {
+	return flagNameReplacer.Replace(key)
+}
+
+// RegisterFlags 根据配置结构体为每个配置项注册一个命令行参数
+//
+// 参数名由配置键转换而来（见 FlagName），默认值取自 defaultConfig，
+// 通常与 WithFlags 配合使用
+func RegisterFlags[T any](fs *pflag.FlagSet, defaultConfig T) error {
+	configBytes, err := yaml.Marshal(defaultConfig)
+	if err != nil {
+		return fmt.Errorf("序列化配置失败: %w", err)
+	}
+
+	tempViper := viper.New()
+	tempViper.SetConfigType(string(YAML))
+	if err := tempViper.ReadConfig(bytes.NewBuffer(configBytes)); err != nil {
+		return fmt.Errorf("读取配置失败: %w", err)
+	}
+
+	for _, key := range tempViper.AllKeys() {
+		name := FlagName(key)
+		if fs.Lookup(name) != nil {
+			continue
+		}
+		usage := fmt.Sprintf("配置项 %s", key)
+		switch val := tempViper.Get(key).(type) {
+		case int:
+			fs.Int(name, val, usage)
+		case int64:
+			fs.Int64(name, val, usage)
+		case float64:
+			fs.Float64(name, val, usage)
+		case bool:
+			fs.Bool(name, val, usage)
+		case []interface{}:
+			items := make([]string, 0, len(val))
+			for _, item := range val {
+				items = append(items, fmt.Sprint(item))
+			}
+			fs.StringSlice(name, items, usage)
+		default:
+			fs.String(name, tempViper.GetString(key), usage)
+		}
+	}
+
+	return nil
+}
+
+// applyFlagOverrides 使用显式设置过的命令行参数覆盖配置
+func (c *Config[T]) applyFlagOverrides(v *viper.Viper, applied overrides) {
+	if c.flagSet == nil && c.goFlagSet == nil {
+		return
+	}
+
+	for _, key := range v.AllKeys() {
+		base := v.Get(key)
+		if c.flagSet != nil {
+			if f := lookupFlag(c.flagSet, key); f != nil && f.Changed {
+				if sv, ok := f.Value.(pflag.SliceValue); ok {
+					v.Set(key, sv.GetSlice())
+				} else {
+					c.setFromString(v, key, f.Value.String())
+				}
+				applied.record(key, OriginFlag, base, v.Get(key))
+				continue
+			}
+		}
+		if c.goFlagSet != nil {
+			if value, ok := lookupGoFlag(c.goFlagSet, key); ok {
+				c.setFromString(v, key, value)
+				applied.record(key, OriginFlag, base, v.Get(key))
+			}
+		}
+	}
+}
+
+// lookupFlag 按配置键查找命令行参数，同时支持 "server.port" 与 "server-port" 两种写法
+func lookupFlag(fs *pflag.FlagSet, key string) *pflag.Flag {
+	if f := fs.Lookup(FlagName(key)); f != nil {
+		return f
+	}
+	return fs.Lookup(key)
+}
+
+// lookupGoFlag 查找显式设置过的标准库命令行参数
+func lookupGoFlag(fs *flag.FlagSet, key string) (string, bool) {
+	var (
+		value string
+		found bool
+	)
+	name := FlagName(key)
+	fs.Visit(func(f *flag.Flag) {
+		if f.Name == name || f.Name == key {
+			value = f.Value.String()
+			found = true
+		}
+	})
+	return value, found
+}
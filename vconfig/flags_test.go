@@ -0,0 +1,72 @@
+package vconfig
+
+import (
+	"flag"
+	"os"
+	"testing"
+
+	"github.com/constructorvirgil/virlog/test/testutils"
+	"github.com/spf13/pflag"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// 测试命令行参数优先级：flag > env > file > default
+func TestFlagsOverride(t *testing.T) {
+	configFile := testutils.RandomTempFilename("test_flags_config", ".yaml")
+	defer testutils.CleanTempFile(t, configFile)
+
+	os.Setenv("APP_SERVER_PORT", "5000")
+	defer os.Unsetenv("APP_SERVER_PORT")
+	os.Setenv("APP_SERVER_HOST", "env-host")
+	defer os.Unsetenv("APP_SERVER_HOST")
+
+	fs := pflag.NewFlagSet("test", pflag.ContinueOnError)
+	require.NoError(t, RegisterFlags(fs, newDefaultConfig()))
+	require.NotNil(t, fs.Lookup("database-max-conns"))
+	require.NoError(t, fs.Parse([]string{"--server-port=9000", "--log-level=debug"}))
+
+	cfg, err := NewConfig(newDefaultConfig(),
+		WithConfigFile[AppConfig](configFile),
+		WithEnvPrefix[AppConfig]("APP"),
+		WithFlags[AppConfig](fs))
+	require.NoError(t, err)
+	defer cfg.Close()
+
+	// 显式设置的命令行参数优先
+	assert.Equal(t, 9000, cfg.GetData().Server.Port)
+	assert.Equal(t, "debug", cfg.GetData().Log.Level)
+	// 未设置的参数不覆盖环境变量
+	assert.Equal(t, "env-host", cfg.GetData().Server.Host)
+	// 未设置的参数也不覆盖默认值
+	assert.Equal(t, 10, cfg.GetData().Database.MaxConns)
+
+	// 已有配置文件时命令行参数依然优先
+	newCfg, err := NewConfig(AppConfig{},
+		WithConfigFile[AppConfig](configFile),
+		WithFlags[AppConfig](fs))
+	require.NoError(t, err)
+	defer newCfg.Close()
+	assert.Equal(t, 9000, newCfg.GetData().Server.Port)
+}
+
+// 测试标准库flag包的命令行参数
+func TestGoFlagsOverride(t *testing.T) {
+	configFile := testutils.RandomTempFilename("test_goflags_config", ".json")
+	defer testutils.CleanTempFile(t, configFile)
+
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.Int("server.port", 0, "server port")
+	fs.String("app-name", "", "app name")
+	require.NoError(t, fs.Parse([]string{"-server.port=7000"}))
+
+	cfg, err := NewConfig(newDefaultConfig(),
+		WithConfigFile[AppConfig](configFile),
+		WithGoFlags[AppConfig](fs))
+	require.NoError(t, err)
+	defer cfg.Close()
+
+	assert.Equal(t, 7000, cfg.GetData().Server.Port)
+	// 未显式设置的参数不生效
+	assert.Equal(t, newDefaultConfig().App.Name, cfg.GetData().App.Name)
+}
@@ -0,0 +1,142 @@
+package vconfig
+
+import (
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc/connectivity"
+)
+
+// HealthStatus 配置的健康状态，用于在动态配置长时间未能更新时告警
+type HealthStatus struct {
+	// Healthy 配置源可以连接且最近没有失败
+	Healthy bool `json:"healthy"`
+	// Source 配置源名称：file、etcd、nacos 或 http
+	Source string `json:"source"`
+	// Connected 是否能连接配置源，配置文件始终为true
+	Connected bool `json:"connected"`
+	// Revision 当前配置在配置源中的版本，含义同 ConfigVersion.Revision
+	Revision string `json:"revision"`
+	// LastLoadTime 最近一次成功加载配置的时间
+	LastLoadTime time.Time `json:"last_load_time"`
+	// ConsecutiveFailures 连续失败的次数，包括连接配置源失败以及解析、处理配置失败，成功加载后清零
+	ConsecutiveFailures int `json:"consecutive_failures"`
+	// LastError 最近一次失败的原因
+	LastError string `json:"last_error,omitempty"`
+	// LastErrorTime 最近一次失败的时间
+	LastErrorTime time.Time `json:"last_error_time,omitempty"`
+	// Stale 启动时无法从配置源加载，当前使用 WithLocalCache 的本地缓存，重新从配置源加载后恢复为false
+	Stale bool `json:"stale"`
+	// CacheTime Stale 为true时，使用的缓存的写入时间
+	CacheTime time.Time `json:"cache_time,omitempty"`
+}
+
+// healthState 记录配置加载和配置源连接的结果
+type healthState struct {
+	mu           sync.Mutex
+	lastLoad     time.Time
+	failures     int
+	loadFailed   bool
+	disconnected bool
+	lastErr      error
+	lastErrTime  time.Time
+	stale        bool
+	cacheTime    time.Time
+}
+
+// recordLoad 记录一次成功加载
+func (h *healthState) recordLoad() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.lastLoad = time.Now()
+	h.failures = 0
+	h.loadFailed = false
+	h.disconnected = false
+	h.stale = false
+}
+
+// recordStale 记录使用了写入时间为 cacheTime 的本地缓存，err 为从配置源加载失败的原因
+func (h *healthState) recordStale(cacheTime time.Time, err error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.stale = true
+	h.cacheTime = cacheTime
+	h.disconnected = true
+	h.failures++
+	h.lastErr = err
+	h.lastErrTime = time.Now()
+}
+
+// recordLoadError 记录一次加载失败，配置源中的配置无效时当前配置不再更新
+func (h *healthState) recordLoadError(err error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.failures++
+	h.loadFailed = true
+	h.lastErr = err
+	h.lastErrTime = time.Now()
+}
+
+// recordSource 记录一次与配置源的通信结果，err 为nil时表示连接正常
+//
+// 通信恢复后，只有在没有未解决的加载失败时才清零失败次数
+func (h *healthState) recordSource(err error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if err == nil {
+		h.disconnected = false
+		if !h.loadFailed {
+			h.failures = 0
+		}
+		return
+	}
+	h.disconnected = true
+	h.failures++
+	h.lastErr = err
+	h.lastErrTime = time.Now()
+}
+
+// Health 返回配置的健康状态
+func (c *Config[T]) Health() HealthStatus {
+	c.health.mu.Lock()
+	status := HealthStatus{
+		Source:              c.sourceName(),
+		Connected:           !c.health.disconnected,
+		LastLoadTime:        c.health.lastLoad,
+		ConsecutiveFailures: c.health.failures,
+		LastErrorTime:       c.health.lastErrTime,
+		Stale:               c.health.stale,
+	}
+	if c.health.stale {
+		status.CacheTime = c.health.cacheTime
+	}
+	if c.health.lastErr != nil {
+		status.LastError = c.health.lastErr.Error()
+	}
+	c.health.mu.Unlock()
+
+	if c.etcdClient != nil && status.Connected {
+		// 监听正常但连接已断开时，以gRPC连接状态为准
+		switch c.etcdClient.client.ActiveConnection().GetState() {
+		case connectivity.TransientFailure, connectivity.Shutdown:
+			status.Connected = false
+		}
+	}
+	status.Revision = c.sourceRevision()
+	status.Healthy = status.Connected && status.ConsecutiveFailures == 0 && !status.Stale
+	return status
+}
+
+// HealthHandler 返回以JSON输出 Health 的http.Handler，健康时状态码为200，否则为503，可用于就绪探针
+func (c *Config[T]) HealthHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		status := c.Health()
+		w.Header().Set("Content-Type", "application/json")
+		if !status.Healthy {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+		_ = json.NewEncoder(w).Encode(status)
+	})
+}
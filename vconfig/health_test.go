@@ -0,0 +1,81 @@
+package vconfig
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// 测试配置源断开和配置无效时的健康状态
+func TestHealth(t *testing.T) {
+	// 0: 正常，1: 返回500，2: 返回无效配置
+	var mode atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch mode.Load() {
+		case 1:
+			w.WriteHeader(http.StatusInternalServerError)
+		case 2:
+			w.Write([]byte("app: [invalid\n"))
+		default:
+			w.Write([]byte("app:\n  name: remote\n"))
+		}
+	}))
+	defer server.Close()
+
+	cfg, err := NewConfig(newDefaultConfig(),
+		WithHTTPSource[AppConfig](server.URL+"/app.yaml", 10*time.Millisecond, nil))
+	require.NoError(t, err)
+	defer cfg.Close()
+
+	status := cfg.Health()
+	assert.True(t, status.Healthy)
+	assert.True(t, status.Connected)
+	assert.Equal(t, "http", status.Source)
+	assert.False(t, status.LastLoadTime.IsZero())
+	assert.Equal(t, 0, status.ConsecutiveFailures)
+
+	handler := cfg.HealthHandler()
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+
+	// 配置源不可用
+	mode.Store(1)
+	require.Eventually(t, func() bool {
+		return cfg.Health().ConsecutiveFailures >= 2
+	}, 3*time.Second, 10*time.Millisecond)
+	status = cfg.Health()
+	assert.False(t, status.Healthy)
+	assert.False(t, status.Connected)
+	assert.Contains(t, status.LastError, "500")
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+	var body HealthStatus
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+	assert.False(t, body.Healthy)
+	assert.Equal(t, "http", body.Source)
+
+	// 配置源可以连接但配置无效，失败次数不清零
+	mode.Store(2)
+	require.Eventually(t, func() bool {
+		s := cfg.Health()
+		return s.Connected && s.ConsecutiveFailures > 0 && !s.Healthy
+	}, 3*time.Second, 10*time.Millisecond)
+	assert.Equal(t, "remote", cfg.GetData().App.Name)
+
+	// 恢复后重新加载成功，失败次数清零
+	mode.Store(0)
+	require.Eventually(t, func() bool {
+		return cfg.Health().Healthy
+	}, 3*time.Second, 10*time.Millisecond)
+	assert.Equal(t, 0, cfg.Health().ConsecutiveFailures)
+}
@@ -6,6 +6,16 @@ import (
 	"strings"
 )
 
+// diffKeyTagName 声明切片按键比较的struct tag，值为元素中作为键的字段名
+//
+//	type Config struct {
+//		Servers []Server `yaml:"servers" diffkey:"name"`
+//	}
+//
+// 元素按键匹配，变更路径形如 servers[name=api].port；新增和删除的元素分别以
+// servers[name=api] 为路径，旧值或新值为nil。键字段按yaml、json tag或字段名匹配
+const diffKeyTagName = "diffkey"
+
 // findConfigChanges 查找两个值之间的差异，返回变更的配置项列表
 func findConfigChanges(oldData, newData interface{}, path string) []ConfigChangedItem {
 	var changes []ConfigChangedItem
@@ -89,6 +99,14 @@ func findConfigChanges(oldData, newData interface{}, path string) []ConfigChange
 			}
 			fullPath += fieldPath
 
+			// 设置了 diffkey 的切片按键比较元素
+			if key := tag.Get(diffKeyTagName); key != "" {
+				if keyed, ok := findKeyedSliceChanges(oldField, newField, fullPath, key); ok {
+					changes = append(changes, keyed...)
+					continue
+				}
+			}
+
 			// 递归比较字段值
 			if oldField.Kind() == reflect.Struct || oldField.Kind() == reflect.Map ||
 				oldField.Kind() == reflect.Slice || oldField.Kind() == reflect.Array {
@@ -233,3 +251,88 @@ func findConfigChanges(oldData, newData interface{}, path string) []ConfigChange
 
 	return changes
 }
+
+// findKeyedSliceChanges 按键比较元素为结构体的切片，元素不是结构体、找不到键字段或存在重复键时返回false
+func findKeyedSliceChanges(oldVal, newVal reflect.Value, path, key string) ([]ConfigChangedItem, bool) {
+	if oldVal.Kind() != reflect.Slice && oldVal.Kind() != reflect.Array {
+		return nil, false
+	}
+	oldItems, oldKeys, ok := indexByKey(oldVal, key)
+	if !ok {
+		return nil, false
+	}
+	newItems, newKeys, ok := indexByKey(newVal, key)
+	if !ok {
+		return nil, false
+	}
+
+	var changes []ConfigChangedItem
+	itemPath := func(k string) string {
+		return fmt.Sprintf("%s[%s=%s]", path, key, k)
+	}
+	for _, k := range oldKeys {
+		oldItem := oldItems[k]
+		newItem, exists := newItems[k]
+		if !exists {
+			changes = append(changes, ConfigChangedItem{Path: itemPath(k), OldValue: oldItem.Interface(), NewValue: nil})
+			continue
+		}
+		changes = append(changes, findConfigChanges(oldItem.Interface(), newItem.Interface(), itemPath(k))...)
+	}
+	for _, k := range newKeys {
+		if _, exists := oldItems[k]; !exists {
+			changes = append(changes, ConfigChangedItem{Path: itemPath(k), OldValue: nil, NewValue: newItems[k].Interface()})
+		}
+	}
+	return changes, true
+}
+
+// indexByKey 按键字段的值索引切片元素，返回元素和按出现顺序排列的键
+func indexByKey(v reflect.Value, key string) (map[string]reflect.Value, []string, bool) {
+	items := make(map[string]reflect.Value, v.Len())
+	keys := make([]string, 0, v.Len())
+	for i := 0; i < v.Len(); i++ {
+		item := v.Index(i)
+		elem := item
+		if elem.Kind() == reflect.Ptr {
+			if elem.IsNil() {
+				return nil, nil, false
+			}
+			elem = elem.Elem()
+		}
+		if elem.Kind() != reflect.Struct {
+			return nil, nil, false
+		}
+		field, ok := keyField(elem, key)
+		if !ok {
+			return nil, nil, false
+		}
+		k := fmt.Sprint(field.Interface())
+		if _, dup := items[k]; dup {
+			return nil, nil, false
+		}
+		items[k] = item
+		keys = append(keys, k)
+	}
+	return items, keys, true
+}
+
+// keyField 按yaml、json tag或字段名（不区分大小写）查找结构体中的键字段
+func keyField(v reflect.Value, key string) (reflect.Value, bool) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		for _, tagName := range []string{"yaml", "json"} {
+			if name, _, _ := strings.Cut(field.Tag.Get(tagName), ","); name == key {
+				return v.Field(i), true
+			}
+		}
+		if strings.EqualFold(field.Name, key) {
+			return v.Field(i), true
+		}
+	}
+	return reflect.Value{}, false
+}
@@ -0,0 +1,51 @@
+package vconfig
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type diffKeyServer struct {
+	Name string `yaml:"name"`
+	Port int    `yaml:"port"`
+}
+
+type diffKeyConfig struct {
+	Servers  []diffKeyServer  `yaml:"servers" diffkey:"name"`
+	Backends []*diffKeyServer `yaml:"backends" diffkey:"Name"`
+	Plain    []diffKeyServer  `yaml:"plain"`
+}
+
+// 测试按 diffkey 比较切片元素
+func TestFindConfigChangesDiffKey(t *testing.T) {
+	oldData := diffKeyConfig{
+		Servers: []diffKeyServer{{Name: "api", Port: 80}, {Name: "admin", Port: 81}, {Name: "old", Port: 82}},
+		Plain:   []diffKeyServer{{Name: "a", Port: 1}},
+	}
+	newData := diffKeyConfig{
+		// 顺序变化不产生变更
+		Servers: []diffKeyServer{{Name: "admin", Port: 81}, {Name: "new", Port: 83}, {Name: "api", Port: 8080}},
+		Plain:   []diffKeyServer{{Name: "a", Port: 1}, {Name: "b", Port: 2}},
+	}
+
+	changes := findConfigChanges(oldData, newData, "")
+	assert.Equal(t, []ConfigChangedItem{
+		{Path: "servers[name=api].port", OldValue: 80, NewValue: 8080},
+		{Path: "servers[name=old]", OldValue: diffKeyServer{Name: "old", Port: 82}, NewValue: nil},
+		{Path: "servers[name=new]", OldValue: nil, NewValue: diffKeyServer{Name: "new", Port: 83}},
+		{Path: "plain", OldValue: oldData.Plain, NewValue: newData.Plain},
+	}, changes)
+
+	// 元素为指针时同样按键比较
+	changes = findConfigChanges(
+		diffKeyConfig{Backends: []*diffKeyServer{{Name: "db", Port: 1}}},
+		diffKeyConfig{Backends: []*diffKeyServer{{Name: "db", Port: 2}}}, "")
+	assert.Equal(t, []ConfigChangedItem{{Path: "backends[Name=db].port", OldValue: 1, NewValue: 2}}, changes)
+
+	// 键重复时退回按下标比较
+	changes = findConfigChanges(
+		diffKeyConfig{Servers: []diffKeyServer{{Name: "a", Port: 1}, {Name: "a", Port: 2}}},
+		diffKeyConfig{Servers: []diffKeyServer{{Name: "a", Port: 1}, {Name: "a", Port: 3}}}, "")
+	assert.Equal(t, []ConfigChangedItem{{Path: "servers[1].port", OldValue: 2, NewValue: 3}}, changes)
+}
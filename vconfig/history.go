@@ -0,0 +1,136 @@
+package vconfig
+
+import (
+	"errors"
+	"os"
+	"strconv"
+	"time"
+)
+
+const (
+	// defaultHistorySize 默认保留的配置历史版本数
+	defaultHistorySize = 10
+	// historySourceUpdate、historySourceRollback 和 historySourcePublish 为通过 UpdateFunc、Rollback
+	// 和 Publish/Promote 修改配置时的来源
+	historySourceUpdate   = "update"
+	historySourceRollback = "rollback"
+	historySourcePublish  = "publish"
+)
+
+// ErrVersionNotFound 配置历史中不存在指定版本
+var ErrVersionNotFound = errors.New("配置版本不存在")
+
+// ConfigVersion 配置历史中的一个版本
+type ConfigVersion[T any] struct {
+	// 版本号，从1开始递增
+	Version int
+	// 配置数据
+	Data T
+	// 加载时间
+	LoadedAt time.Time
+	// 来源："file"、"etcd"、"nacos"、"http"，通过 UpdateFunc 修改时为 "update"，回滚时为 "rollback"，
+	// 通过 Publish 或 Promote 发布时为 "publish"
+	Source string
+	// 配置源中的版本：ETCD为ModRevision，Nacos为配置内容MD5，HTTP为ETag、Last-Modified或内容MD5，配置文件为修改时间
+	Revision string
+}
+
+// History 返回最近加载的配置版本，按版本号从旧到新排列
+func (c *Config[T]) History() []ConfigVersion[T] {
+	c.historyMu.Lock()
+	defer c.historyMu.Unlock()
+
+	history := make([]ConfigVersion[T], len(c.history))
+	for i, v := range c.history {
+		v.Data = cloneConfig(v.Data)
+		history[i] = v
+	}
+	return history
+}
+
+// Rollback 将配置恢复为历史中的指定版本并保存到配置源，同时触发变更回调
+//
+// 回滚与 UpdateFunc 一样经过校验，回滚后的配置作为新版本记录到历史中
+func (c *Config[T]) Rollback(version int) error {
+	c.historyMu.Lock()
+	var (
+		target T
+		found  bool
+	)
+	for _, v := range c.history {
+		if v.Version == version {
+			target = cloneConfig(v.Data)
+			found = true
+			break
+		}
+	}
+	c.historyMu.Unlock()
+	if !found {
+		return ErrVersionNotFound
+	}
+
+	return c.update(func(data *T) error {
+		*data = target
+		return nil
+	}, historySourceRollback)
+}
+
+// recordHistory 将当前配置记录为新版本，与最新版本相同时不记录
+func (c *Config[T]) recordHistory(source string) {
+	if c.historySize <= 0 {
+		return
+	}
+	data := cloneConfig(c.GetData())
+	revision := c.sourceRevision()
+
+	c.historyMu.Lock()
+	defer c.historyMu.Unlock()
+	if n := len(c.history); n > 0 && len(findConfigChanges(c.history[n-1].Data, data, "")) == 0 {
+		return
+	}
+
+	c.historyVersion++
+	c.history = append(c.history, ConfigVersion[T]{
+		Version:  c.historyVersion,
+		Data:     data,
+		LoadedAt: time.Now(),
+		Source:   source,
+		Revision: revision,
+	})
+	if len(c.history) > c.historySize {
+		c.history = append(c.history[:0:0], c.history[len(c.history)-c.historySize:]...)
+	}
+}
+
+// sourceName 返回配置源名称
+func (c *Config[T]) sourceName() string {
+	switch {
+	case c.etcdClient != nil:
+		return "etcd"
+	case c.nacosClient != nil:
+		return "nacos"
+	case c.httpSource != nil:
+		return "http"
+	default:
+		return "file"
+	}
+}
+
+// sourceRevision 返回配置源中当前配置的版本
+func (c *Config[T]) sourceRevision() string {
+	switch {
+	case c.etcdClient != nil:
+		return strconv.FormatInt(c.etcdClient.revision.Load(), 10)
+	case c.nacosClient != nil:
+		revision, _ := c.nacosClient.revision.Load().(string)
+		return revision
+	case c.httpSource != nil:
+		revision, _ := c.httpSource.revision.Load().(string)
+		return revision
+	case c.configFile != "":
+		if info, err := os.Stat(c.configFile); err == nil {
+			return info.ModTime().Format(time.RFC3339Nano)
+		}
+	}
+	return ""
+}
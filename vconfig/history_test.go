@@ -0,0 +1,124 @@
+package vconfig
+
+import (
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// 测试记录配置历史并回滚
+func TestHistoryAndRollback(t *testing.T) {
+	configFile := filepath.Join(t.TempDir(), "app.yaml")
+	cfg, err := NewConfig(newDefaultConfig(),
+		WithConfigFile[AppConfig](configFile),
+		WithDebounceTime[AppConfig](10*time.Millisecond))
+	require.NoError(t, err)
+	defer cfg.Close()
+
+	history := cfg.History()
+	require.Len(t, history, 1)
+	assert.Equal(t, 1, history[0].Version)
+	assert.Equal(t, "file", history[0].Source)
+	assert.NotEmpty(t, history[0].Revision)
+
+	require.NoError(t, cfg.UpdateFunc(func(data *AppConfig) error {
+		data.Server.Port = 9000
+		return nil
+	}))
+
+	// 外部修改配置文件
+	changed := make(chan struct{}, 10)
+	cfg.OnChange(func(e fsnotify.Event, changedItems []ConfigChangedItem) {
+		changed <- struct{}{}
+	})
+	require.NoError(t, os.WriteFile(configFile, []byte("server:\n  port: 9100\n"), 0644))
+	select {
+	case <-changed:
+	case <-time.After(3 * time.Second):
+		t.Fatal("等待配置变更超时")
+	}
+
+	history = cfg.History()
+	require.Len(t, history, 3)
+	assert.Equal(t, "update", history[1].Source)
+	assert.Equal(t, 9000, history[1].Data.Server.Port)
+	assert.Equal(t, "file", history[2].Source)
+	assert.Equal(t, 9100, history[2].Data.Server.Port)
+
+	// 回滚到第一个版本并保存
+	require.NoError(t, cfg.Rollback(1))
+	<-changed
+	assert.Equal(t, 8080, cfg.GetData().Server.Port)
+	assert.Equal(t, "示例应用", cfg.GetData().App.Name)
+	content, err := os.ReadFile(configFile)
+	require.NoError(t, err)
+	assert.Contains(t, string(content), "port: 8080")
+
+	history = cfg.History()
+	require.Len(t, history, 4)
+	assert.Equal(t, 4, history[3].Version)
+	assert.Equal(t, "rollback", history[3].Source)
+
+	// 修改返回的历史不影响内部记录
+	history[0].Data.Server.Port = 1
+	assert.Equal(t, 8080, cfg.History()[0].Data.Server.Port)
+
+	assert.ErrorIs(t, cfg.Rollback(99), ErrVersionNotFound)
+}
+
+// 测试历史版本数上限
+func TestHistorySize(t *testing.T) {
+	configFile := filepath.Join(t.TempDir(), "app.yaml")
+	cfg, err := NewConfig(newDefaultConfig(),
+		WithConfigFile[AppConfig](configFile),
+		WithHistorySize[AppConfig](2))
+	require.NoError(t, err)
+	defer cfg.Close()
+
+	for _, port := range []int{9001, 9002, 9003} {
+		require.NoError(t, cfg.UpdateFunc(func(data *AppConfig) error {
+			data.Server.Port = port
+			return nil
+		}))
+	}
+	// 没有变化的修改不记录
+	require.NoError(t, cfg.UpdateFunc(func(data *AppConfig) error { return nil }))
+
+	history := cfg.History()
+	require.Len(t, history, 2)
+	assert.Equal(t, 3, history[0].Version)
+	assert.Equal(t, 4, history[1].Version)
+	assert.Equal(t, 9003, history[1].Data.Server.Port)
+	assert.ErrorIs(t, cfg.Rollback(1), ErrVersionNotFound)
+
+	disabled, err := NewConfig(newDefaultConfig(),
+		WithConfigFile[AppConfig](configFile),
+		WithHistorySize[AppConfig](0))
+	require.NoError(t, err)
+	defer disabled.Close()
+	assert.Empty(t, disabled.History())
+}
+
+// 测试Nacos配置历史记录内容MD5
+func TestHistoryNacosRevision(t *testing.T) {
+	nacos := newFakeNacos()
+	server := httptest.NewServer(nacos)
+	defer server.Close()
+
+	cfg, err := NewConfig(newDefaultConfig(),
+		WithNacosConfig[AppConfig](server.URL, "", "", "app.yaml"))
+	require.NoError(t, err)
+	defer cfg.Close()
+
+	content, _ := nacos.get()
+	history := cfg.History()
+	require.Len(t, history, 1)
+	assert.Equal(t, "nacos", history[0].Source)
+	assert.Equal(t, nacosMD5([]byte(content)), history[0].Revision)
+}
@@ -0,0 +1,256 @@
+package vconfig
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// httpSourceDefaultInterval HTTP配置源默认的轮询间隔
+	httpSourceDefaultInterval = 30 * time.Second
+	// httpSourceDefaultTimeout HTTP配置源默认的请求超时时间
+	httpSourceDefaultTimeout = 10 * time.Second
+)
+
+// ErrReadOnlySource 配置源只读，不支持保存配置
+var ErrReadOnlySource = errors.New("配置源为只读")
+
+// HTTPSourceConfig HTTP(S)配置源配置
+type HTTPSourceConfig struct {
+	// 配置地址，如S3预签名URL或内部配置服务地址
+	URL string
+	// 轮询间隔，默认30秒
+	Interval time.Duration
+	// 请求头，如 Authorization
+	Headers map[string]string
+	// 请求超时时间，默认10秒
+	Timeout time.Duration
+	// HTTP客户端，为空时使用默认客户端
+	HTTPClient *http.Client
+}
+
+// httpSource 轮询HTTP地址获取配置，使用ETag和Last-Modified避免重复下载
+type httpSource struct {
+	config *HTTPSourceConfig
+	http   *http.Client
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	// 上次响应的ETag和Last-Modified，只在初始化和轮询goroutine中访问
+	etag         string
+	lastModified string
+	// 最近一次获取的配置版本：ETag、Last-Modified或内容MD5
+	revision atomic.Value
+	// 最近一次获取的配置内容
+	content atomic.Pointer[[]byte]
+	// wg 等待轮询goroutine退出
+	wg sync.WaitGroup
+}
+
+// newHTTPSource 创建HTTP配置源
+func newHTTPSource(config *HTTPSourceConfig) (*httpSource, error) {
+	if config.URL == "" {
+		return nil, fmt.Errorf("未指定HTTP配置地址")
+	}
+	if !strings.HasPrefix(config.URL, "http://") && !strings.HasPrefix(config.URL, "https://") {
+		return nil, fmt.Errorf("不支持的HTTP配置地址: %s", config.URL)
+	}
+	if config.Interval <= 0 {
+		config.Interval = httpSourceDefaultInterval
+	}
+	if config.Timeout <= 0 {
+		config.Timeout = httpSourceDefaultTimeout
+	}
+
+	httpClient := config.HTTPClient
+	if httpClient == nil {
+		httpClient = &http.Client{}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	return &httpSource{config: config, http: httpClient, ctx: ctx, cancel: cancel}, nil
+}
+
+// close 停止轮询
+func (h *httpSource) close() {
+	h.cancel()
+}
+
+// wait 等待轮询goroutine退出
+func (h *httpSource) wait() {
+	h.wg.Wait()
+}
+
+// fetch 获取配置内容，服务端返回304时 changed 为false
+func (h *httpSource) fetch() (content []byte, changed bool, err error) {
+	ctx, cancel := context.WithTimeout(h.ctx, h.config.Timeout)
+	defer cancel()
+
+	resp, err := h.request(ctx, true)
+	if err != nil {
+		return nil, false, err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusNotModified:
+		return nil, false, nil
+	case http.StatusOK:
+	default:
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return nil, false, fmt.Errorf("获取HTTP配置失败: %s: %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+
+	content, err = io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, false, fmt.Errorf("读取HTTP配置失败: %w", err)
+	}
+	h.etag = resp.Header.Get("ETag")
+	h.lastModified = resp.Header.Get("Last-Modified")
+
+	revision := h.etag
+	if revision == "" {
+		revision = h.lastModified
+	}
+	if revision == "" {
+		revision = nacosMD5(content)
+	}
+	h.revision.Store(revision)
+	h.content.Store(&content)
+	return content, true, nil
+}
+
+// get 获取配置的完整内容，不发送条件请求头，也不更新轮询使用的ETag和Last-Modified
+func (h *httpSource) get() ([]byte, error) {
+	ctx, cancel := context.WithTimeout(h.ctx, h.config.Timeout)
+	defer cancel()
+
+	resp, err := h.request(ctx, false)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return nil, fmt.Errorf("获取HTTP配置失败: %s: %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+	content, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("读取HTTP配置失败: %w", err)
+	}
+	return content, nil
+}
+
+// request 发送获取配置的请求，conditional 为true时携带上次响应的ETag和Last-Modified
+func (h *httpSource) request(ctx context.Context, conditional bool) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, h.config.URL, nil)
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range h.config.Headers {
+		req.Header.Set(k, v)
+	}
+	if conditional && h.etag != "" {
+		req.Header.Set("If-None-Match", h.etag)
+	}
+	if conditional && h.lastModified != "" {
+		req.Header.Set("If-Modified-Since", h.lastModified)
+	}
+
+	resp, err := h.http.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("获取HTTP配置失败: %w", err)
+	}
+	return resp, nil
+}
+
+// watch 按间隔轮询配置，内容变化时以新内容调用callback，每次轮询后以请求结果调用report
+func (h *httpSource) watch(initial []byte, report func(error), callback func([]byte)) {
+	h.wg.Add(1)
+	go func() {
+		defer h.wg.Done()
+		contentMD5 := nacosMD5(initial)
+		ticker := time.NewTicker(h.config.Interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-h.ctx.Done():
+				return
+			case <-ticker.C:
+			}
+
+			content, changed, err := h.fetch()
+			if h.ctx.Err() != nil {
+				return
+			}
+			report(err)
+			if err != nil {
+				getInternalLogger().Errorw("轮询HTTP配置失败", "url", h.config.URL, "error", err)
+				continue
+			}
+			// 没有ETag的服务端每次都返回完整内容，内容未变化时不重新加载
+			if !changed || nacosMD5(content) == contentMD5 {
+				continue
+			}
+			contentMD5 = nacosMD5(content)
+			callback(content)
+		}
+	}()
+}
+
+// initWithHTTP 使用HTTP配置源初始化，首次获取失败时返回错误
+func (c *Config[T]) initWithHTTP() error {
+	source, err := newHTTPSource(c.httpConfig)
+	if err != nil {
+		return fmt.Errorf("创建HTTP配置源失败: %w", err)
+	}
+	c.httpSource = source
+
+	content, _, err := source.fetch()
+	if err != nil {
+		return err
+	}
+	var data T
+	if err := unmarshalConfig(content, &data, c.configType); err != nil {
+		return fmt.Errorf("解析HTTP配置失败: %w", err)
+	}
+	c.setData(data)
+	if err := applyDefaults(&c.data); err != nil {
+		return err
+	}
+	if err := c.runOnLoad(&c.data); err != nil {
+		return err
+	}
+
+	c.watchHTTP(content)
+	return nil
+}
+
+// watchHTTP 监听HTTP配置变更
+func (c *Config[T]) watchHTTP(initial []byte) {
+	c.httpSource.watch(initial, c.health.recordSource, func(content []byte) {
+		c.closedMu.RLock()
+		if c.closed {
+			c.closedMu.RUnlock()
+			return
+		}
+		c.closedMu.RUnlock()
+
+		var newData T
+		if err := unmarshalConfig(content, &newData, c.configType); err != nil {
+			getInternalLogger().Errorw("解析HTTP配置失败", "url", c.httpConfig.URL, "config_type", c.configType, "error", err)
+			c.recordLoadError(err)
+			return
+		}
+
+		c.setBaseline(c.GetData())
+		c.applyRemoteData(newData, c.httpConfig.URL)
+	})
+}
@@ -0,0 +1,123 @@
+package vconfig
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeHTTPSource 模拟提供配置的HTTP服务，支持ETag条件请求
+type fakeHTTPSource struct {
+	mu          sync.Mutex
+	content     []byte
+	version     int
+	full        int
+	notModified int
+	auth        string
+}
+
+// set 修改服务端配置
+func (f *fakeHTTPSource) set(content string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.content = []byte(content)
+	f.version++
+}
+
+// counts 返回返回完整内容和304的次数
+func (f *fakeHTTPSource) counts() (int, int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.full, f.notModified
+}
+
+func (f *fakeHTTPSource) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.auth = r.Header.Get("Authorization")
+
+	etag := fmt.Sprintf(`"v%d"`, f.version)
+	if r.Header.Get("If-None-Match") == etag {
+		f.notModified++
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	f.full++
+	w.Header().Set("ETag", etag)
+	w.Write(f.content)
+}
+
+// 测试从HTTP地址加载配置并轮询变更
+func TestHTTPSource(t *testing.T) {
+	source := &fakeHTTPSource{}
+	source.set("app:\n  name: remote\nserver:\n  port: 9000\n")
+	server := httptest.NewServer(source)
+	defer server.Close()
+
+	cfg, err := NewConfig(newDefaultConfig(),
+		WithHTTPSource[AppConfig](server.URL+"/app.yaml", 20*time.Millisecond,
+			map[string]string{"Authorization": "Bearer token"}))
+	require.NoError(t, err)
+	defer cfg.Close()
+
+	assert.Equal(t, "remote", cfg.GetData().App.Name)
+	assert.Equal(t, 9000, cfg.GetData().Server.Port)
+
+	changed := make(chan []ConfigChangedItem, 10)
+	cfg.OnChange(func(e fsnotify.Event, changedItems []ConfigChangedItem) {
+		assert.Equal(t, server.URL+"/app.yaml", e.Name)
+		changed <- changedItems
+	})
+
+	// 内容未变化时服务端返回304，不触发回调
+	time.Sleep(100 * time.Millisecond)
+	full, notModified := source.counts()
+	assert.Equal(t, 1, full)
+	assert.Greater(t, notModified, 0)
+	assert.Empty(t, changed)
+
+	source.set("app:\n  name: remote\nserver:\n  port: 9100\n")
+	select {
+	case items := <-changed:
+		require.Len(t, items, 1)
+		assert.Equal(t, "server.port", items[0].Path)
+		assert.Equal(t, 9100, items[0].NewValue)
+	case <-time.After(3 * time.Second):
+		t.Fatal("等待配置变更超时")
+	}
+	assert.Equal(t, 9100, cfg.GetData().Server.Port)
+
+	source.mu.Lock()
+	assert.Equal(t, "Bearer token", source.auth)
+	source.mu.Unlock()
+
+	history := cfg.History()
+	require.Len(t, history, 2)
+	assert.Equal(t, "http", history[1].Source)
+	assert.Equal(t, `"v2"`, history[1].Revision)
+
+	// HTTP配置源为只读
+	assert.ErrorIs(t, cfg.UpdateFunc(func(data *AppConfig) error { return nil }), ErrReadOnlySource)
+	assert.ErrorIs(t, cfg.Update(cfg.GetData()), ErrReadOnlySource)
+}
+
+// 测试首次获取失败时返回错误
+func TestHTTPSourceInitError(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	defer server.Close()
+
+	_, err := NewConfig(newDefaultConfig(), WithHTTPSource[AppConfig](server.URL, time.Second, nil))
+	assert.Error(t, err)
+
+	_, err = NewConfig(newDefaultConfig(),
+		WithHTTPSource[AppConfig](server.URL, time.Second, nil),
+		WithConfigFile[AppConfig]("app.yaml"))
+	assert.Error(t, err)
+}
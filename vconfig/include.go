@@ -0,0 +1,230 @@
+package vconfig
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// includeKey 配置文件中引入其他配置文件的指令
+//
+//	$include: database.yaml
+//	$include: [database.yaml, redis.yaml]
+//
+// 相对路径相对于当前文件所在目录；被引入文件的配置作为基础，当前文件中的同名配置覆盖被引入的配置，
+// 多个文件按顺序合并，后者覆盖前者；被引入的文件也可以继续使用 $include
+const includeKey = "$include"
+
+// fileLayers 加载配置文件时各层的信息
+type fileLayers struct {
+	// files 通过 $include 引入的配置文件以及当前环境的配置文件
+	files []string
+	// include 当前配置文件通过 $include 引入的配置，没有 $include 时为nil
+	include *includeLayer
+	// profile 当前环境配置文件中的配置，未使用环境配置文件时为nil
+	profile *profileLayer
+}
+
+// includeLayer 当前配置文件通过 $include 引入的配置，保存配置时保留 $include 指令，不写入与引入的值相同的配置项
+type includeLayer struct {
+	// directive $include 指令的原始值
+	directive interface{}
+	// values 被引入的文件合并后的配置，按小写的点号分隔配置键展开
+	values map[string]interface{}
+}
+
+// active 返回保存时是否需要区分各层的配置项
+func (l *fileLayers) active() bool {
+	return l != nil && (l.include != nil || l.profile != nil)
+}
+
+// split 从待写入当前配置文件的配置 settings 中移除来自其他文件的配置项，
+// 返回需要写入环境配置文件的配置项
+func (l *fileLayers) split(settings map[string]interface{}) map[string]interface{} {
+	if !l.active() {
+		return nil
+	}
+	var updates map[string]interface{}
+	if l.profile != nil {
+		updates = l.profile.splitProfile(settings)
+	}
+	if l.include != nil {
+		l.include.splitIncluded(settings)
+	}
+	return updates
+}
+
+// splitIncluded 从 settings 中删除与被引入的文件中相同的配置项，并写入 $include 指令
+func (l *includeLayer) splitIncluded(settings map[string]interface{}) {
+	_ = flattenSettings(settings, "", func(path string, value interface{}) error {
+		if included, ok := l.values[strings.ToLower(path)]; ok && sameValue(value, included) {
+			deleteSettingsValue(settings, strings.Split(path, "."))
+		}
+		return nil
+	})
+	settings[includeKey] = l.directive
+}
+
+// loadSettings 解析配置文件的内容 raw 并合并其引入的文件，迁移到最新版本后合并当前环境的配置文件，
+// 返回合并后的配置、各层的信息，以及执行了迁移时迁移前的版本（未迁移时为-1）
+func (c *Config[T]) loadSettings(raw []byte) (map[string]interface{}, *fileLayers, int, error) {
+	layers := &fileLayers{}
+	settings, included, err := c.parseIncludes(c.configFile, raw, c.configType, map[string]bool{}, &layers.files)
+	if err != nil {
+		return nil, nil, -1, err
+	}
+	if included != nil {
+		layers.include = &includeLayer{directive: included.directive, values: flatSettings(included.merged)}
+		mergeSettings(included.merged, settings)
+		settings = included.merged
+	}
+	settings, from, to, err := migrateSettings(settings)
+	if err != nil {
+		return nil, nil, -1, fmt.Errorf("迁移配置文件 %s 失败: %w", c.configFile, err)
+	}
+	if layers.profile, err = c.loadProfileSettings(settings, &layers.files); err != nil {
+		return nil, nil, -1, err
+	}
+	if from == to {
+		from = -1
+	}
+	return settings, layers, from, nil
+}
+
+// readSettings 读取单个配置文件并递归处理 $include，visiting 用于检测循环引入
+func (c *Config[T]) readSettings(filename string, configType ConfigType, visiting map[string]bool, includes *[]string) (map[string]interface{}, error) {
+	fileBytes, err := c.readFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	return c.parseSettings(filename, fileBytes, configType, visiting, includes)
+}
+
+// parseSettings 解析配置文件 filename 的内容 fileBytes 并递归处理 $include
+func (c *Config[T]) parseSettings(filename string, fileBytes []byte, configType ConfigType, visiting map[string]bool, includes *[]string) (map[string]interface{}, error) {
+	settings, included, err := c.parseIncludes(filename, fileBytes, configType, visiting, includes)
+	if err != nil || included == nil {
+		return settings, err
+	}
+	mergeSettings(included.merged, settings)
+	return included.merged, nil
+}
+
+// includedSettings 配置文件通过 $include 引入的配置
+type includedSettings struct {
+	// directive $include 指令的原始值
+	directive interface{}
+	// merged 被引入的文件按顺序合并后的配置
+	merged map[string]interface{}
+}
+
+// parseIncludes 解析配置文件 filename 的内容 fileBytes，返回文件本身的配置（不含 $include）和其引入的配置，
+// 没有 $include 时引入的配置为nil
+func (c *Config[T]) parseIncludes(filename string, fileBytes []byte, configType ConfigType, visiting map[string]bool, includes *[]string) (map[string]interface{}, *includedSettings, error) {
+	absPath, err := filepath.Abs(filename)
+	if err != nil {
+		absPath = filename
+	}
+	if visiting[absPath] {
+		return nil, nil, fmt.Errorf("配置文件循环引入: %s", filename)
+	}
+	visiting[absPath] = true
+	defer delete(visiting, absPath)
+
+	settings, err := readSettingsBytes(fileBytes, configType)
+	if err != nil {
+		return nil, nil, fmt.Errorf("解析配置文件 %s 失败: %w", filename, err)
+	}
+
+	raw, ok := settings[includeKey]
+	if !ok {
+		return settings, nil, nil
+	}
+	delete(settings, includeKey)
+
+	paths, err := includePaths(raw)
+	if err != nil {
+		return nil, nil, fmt.Errorf("配置文件 %s 中的 %s 无效: %w", filename, includeKey, err)
+	}
+
+	merged := make(map[string]interface{})
+	for _, p := range paths {
+		if !filepath.IsAbs(p) {
+			p = filepath.Join(filepath.Dir(filename), p)
+		}
+		*includes = append(*includes, p)
+
+		included, err := c.readSettings(p, configTypeOf(p, configType), visiting, includes)
+		if err != nil {
+			return nil, nil, err
+		}
+		mergeSettings(merged, included)
+	}
+	return settings, &includedSettings{directive: raw, merged: merged}, nil
+}
+
+// includePaths 解析 $include 的值，支持单个路径或路径列表
+func includePaths(raw interface{}) ([]string, error) {
+	switch v := raw.(type) {
+	case string:
+		return []string{v}, nil
+	case []interface{}:
+		paths := make([]string, 0, len(v))
+		for _, item := range v {
+			s, ok := item.(string)
+			if !ok {
+				return nil, fmt.Errorf("路径必须是字符串: %v", item)
+			}
+			paths = append(paths, s)
+		}
+		return paths, nil
+	case []string:
+		return v, nil
+	default:
+		return nil, fmt.Errorf("不支持的类型 %T", raw)
+	}
+}
+
+// configTypeOf 根据文件扩展名推断配置类型，无法推断时使用默认类型
+func configTypeOf(filename string, def ConfigType) ConfigType {
+	switch strings.ToLower(strings.TrimPrefix(filepath.Ext(filename), ".")) {
+	case "json":
+		return JSON
+	case "yaml", "yml":
+		return YAML
+	case "toml":
+		return TOML
+	case "ini":
+		return INI
+	case "hcl":
+		return HCL
+	default:
+		return def
+	}
+}
+
+// mergeSettings 将src深度合并到dst中，同名的非map值由src覆盖
+func mergeSettings(dst, src map[string]interface{}) {
+	for k, v := range src {
+		srcMap, srcIsMap := v.(map[string]interface{})
+		dstMap, dstIsMap := dst[k].(map[string]interface{})
+		if srcIsMap && dstIsMap {
+			mergeSettings(dstMap, srcMap)
+			continue
+		}
+		if srcIsMap {
+			copied := make(map[string]interface{}, len(srcMap))
+			mergeSettings(copied, srcMap)
+			dst[k] = copied
+			continue
+		}
+		dst[k] = v
+	}
+}
+
+// watchIncludes 将被引入的文件加入监听，任一文件变更都会重新加载配置
+func (c *Config[T]) watchIncludes() {
+	for _, include := range c.includes {
+		c.watchFile(include)
+	}
+}
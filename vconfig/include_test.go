@@ -0,0 +1,149 @@
+package vconfig
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// 测试 $include 引入配置片段并深度合并
+func TestConfigInclude(t *testing.T) {
+	dir := t.TempDir()
+	mainFile := filepath.Join(dir, "app.yaml")
+	dbFile := filepath.Join(dir, "shared", "database.yaml")
+	require.NoError(t, os.MkdirAll(filepath.Dir(dbFile), 0755))
+
+	require.NoError(t, os.WriteFile(dbFile, []byte(`database:
+  dsn: postgres://shared@db:5432/app
+  max_conns: 50
+log:
+  level: debug
+`), 0600))
+	require.NoError(t, os.WriteFile(mainFile, []byte(`$include: shared/database.yaml
+app:
+  name: 示例应用
+server:
+  port: 9000
+log:
+  level: warn
+`), 0600))
+
+	cfg, err := NewConfig(AppConfig{},
+		WithConfigFile[AppConfig](mainFile),
+		WithConfigType[AppConfig](YAML))
+	require.NoError(t, err)
+	defer cfg.Close()
+
+	data := cfg.GetData()
+	assert.Equal(t, "postgres://shared@db:5432/app", data.Database.DSN)
+	assert.Equal(t, 9000, data.Server.Port)
+	assert.Equal(t, "warn", data.Log.Level, "主文件中的配置应覆盖被引入的配置")
+	assert.Equal(t, []string{dbFile}, cfg.includes)
+
+	// 修改被引入的文件也会触发重新加载
+	changed := make(chan struct{}, 1)
+	cfg.OnChange(func(e fsnotify.Event, changedItems []ConfigChangedItem) {
+		select {
+		case changed <- struct{}{}:
+		default:
+		}
+	})
+
+	require.NoError(t, os.WriteFile(dbFile, []byte(`database:
+  dsn: postgres://updated@db:5432/app
+`), 0600))
+
+	select {
+	case <-changed:
+	case <-time.After(3 * time.Second):
+		t.Fatal("修改被引入的文件后未触发重新加载")
+	}
+	assert.Equal(t, "postgres://updated@db:5432/app", cfg.GetData().Database.DSN)
+}
+
+// 测试多个引入文件的合并顺序以及循环引入检测
+func TestConfigIncludeList(t *testing.T) {
+	dir := t.TempDir()
+	writeFile := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.WriteFile(path, []byte(content), 0600))
+		return path
+	}
+
+	writeFile("a.yaml", "server:\n  host: a.local\n  port: 1000\n")
+	writeFile("b.json", `{"server": {"port": 2000}}`)
+	mainFile := writeFile("main.yaml", "$include: [a.yaml, b.json]\napp:\n  name: demo\n")
+
+	cfg, err := NewConfig(AppConfig{},
+		WithConfigFile[AppConfig](mainFile),
+		WithConfigType[AppConfig](YAML))
+	require.NoError(t, err)
+	data := cfg.GetData()
+	cfg.Close()
+	assert.Equal(t, "a.local", data.Server.Host)
+	assert.Equal(t, 2000, data.Server.Port, "后引入的文件应覆盖先引入的文件")
+	assert.Equal(t, "demo", data.App.Name)
+
+	writeFile("loop_a.yaml", "$include: loop_b.yaml\n")
+	writeFile("loop_b.yaml", "$include: loop_a.yaml\n")
+	_, err = NewConfig(AppConfig{},
+		WithConfigFile[AppConfig](filepath.Join(dir, "loop_a.yaml")),
+		WithConfigType[AppConfig](YAML))
+	assert.Error(t, err)
+}
+
+// 测试保存配置时保留 $include 指令，只写入与被引入的配置不同的配置项
+func TestConfigIncludeSave(t *testing.T) {
+	for _, tc := range []struct {
+		name       string
+		configType ConfigType
+		main       string
+	}{
+		{"yaml", YAML, "$include: db.yaml\napp:\n  name: 示例应用\n"},
+		{"json", JSON, `{"$include": "db.yaml", "app": {"name": "示例应用"}}`},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			dir := t.TempDir()
+			mainFile := filepath.Join(dir, "app."+string(tc.configType))
+			dbFile := filepath.Join(dir, "db.yaml")
+			require.NoError(t, os.WriteFile(dbFile, []byte("database:\n  dsn: db1\nserver:\n  port: 8080\n"), 0600))
+			require.NoError(t, os.WriteFile(mainFile, []byte(tc.main), 0600))
+
+			cfg, err := NewConfig(AppConfig{},
+				WithConfigFile[AppConfig](mainFile),
+				WithConfigType[AppConfig](tc.configType))
+			require.NoError(t, err)
+			defer cfg.Close()
+
+			require.NoError(t, cfg.UpdateFunc(func(data *AppConfig) error {
+				data.App.Version = "2.0.0"
+				data.Server.Port = 9090
+				return nil
+			}))
+
+			content, err := os.ReadFile(mainFile)
+			require.NoError(t, err)
+			assert.Contains(t, string(content), includeKey)
+			assert.NotContains(t, string(content), "db1", "与被引入的值相同的配置项不写入主文件")
+			assert.Contains(t, string(content), "9090", "修改后的值覆盖被引入的配置")
+
+			// 被引入的文件的修改在重新加载后仍然生效
+			require.NoError(t, os.WriteFile(dbFile, []byte("database:\n  dsn: db2\nserver:\n  port: 8080\n"), 0600))
+			reloaded, err := NewConfig(AppConfig{},
+				WithConfigFile[AppConfig](mainFile),
+				WithConfigType[AppConfig](tc.configType))
+			require.NoError(t, err)
+			defer reloaded.Close()
+			data := reloaded.GetData()
+			assert.Equal(t, "db2", data.Database.DSN)
+			assert.Equal(t, 9090, data.Server.Port)
+			assert.Equal(t, "示例应用", data.App.Name)
+			assert.Equal(t, "2.0.0", data.App.Version)
+		})
+	}
+}
@@ -0,0 +1,43 @@
+package vconfig
+
+import (
+	"sync"
+
+	"github.com/constructorvirgil/virlog/logger"
+)
+
+// InternalLogger 记录库内部诊断信息的接口，keysAndValues 为成对出现的键和值
+//
+// *zap.SugaredLogger 实现了该接口，默认输出到virlog的全局Logger
+type InternalLogger interface {
+	Infow(msg string, keysAndValues ...interface{})
+	Errorw(msg string, keysAndValues ...interface{})
+}
+
+var (
+	internalLogger   InternalLogger = logger.InternalLogger{Component: "vconfig"}
+	internalLoggerMu sync.RWMutex
+)
+
+// SetInternalLogger 设置记录内部诊断信息的Logger，传入nil时丢弃所有内部日志
+func SetInternalLogger(l InternalLogger) {
+	if l == nil {
+		l = nopLogger{}
+	}
+	internalLoggerMu.Lock()
+	defer internalLoggerMu.Unlock()
+	internalLogger = l
+}
+
+// getInternalLogger 获取当前的内部Logger
+func getInternalLogger() InternalLogger {
+	internalLoggerMu.RLock()
+	defer internalLoggerMu.RUnlock()
+	return internalLogger
+}
+
+// nopLogger 丢弃所有内部日志
+type nopLogger struct{}
+
+func (nopLogger) Infow(string, ...interface{})  {}
+func (nopLogger) Errorw(string, ...interface{}) {}
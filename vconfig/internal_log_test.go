@@ -0,0 +1,72 @@
+package vconfig
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/constructorvirgil/virlog/test/testutils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// recordLogger 记录内部日志的测试Logger
+type recordLogger struct {
+	mu      sync.Mutex
+	entries []string
+}
+
+func (r *recordLogger) Infow(msg string, keysAndValues ...interface{}) {
+	r.record("info", msg, keysAndValues)
+}
+
+func (r *recordLogger) Errorw(msg string, keysAndValues ...interface{}) {
+	r.record("error", msg, keysAndValues)
+}
+
+func (r *recordLogger) record(level, msg string, keysAndValues []interface{}) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.entries = append(r.entries, fmt.Sprintf("%s %s %v", level, msg, keysAndValues))
+}
+
+func (r *recordLogger) snapshot() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]string(nil), r.entries...)
+}
+
+// 测试配置重新加载失败时通过InternalLogger输出错误
+func TestSetInternalLogger(t *testing.T) {
+	rec := &recordLogger{}
+	old := getInternalLogger()
+	SetInternalLogger(rec)
+	defer SetInternalLogger(old)
+
+	configFile := testutils.RandomTempFilename("test_internal_log", ".yaml")
+	defer testutils.CleanTempFile(t, configFile)
+
+	cfg, err := NewConfig(newDefaultConfig(),
+		WithConfigFile[AppConfig](configFile),
+		WithConfigType[AppConfig](YAML))
+	require.NoError(t, err)
+	defer cfg.Close()
+
+	require.NoError(t, os.WriteFile(configFile, []byte("server: [unclosed\n"), 0644))
+
+	// 其他测试中未关闭的配置可能也会输出内部日志，只检查当前配置文件的日志
+	var entry string
+	require.Eventually(t, func() bool {
+		for _, e := range rec.snapshot() {
+			if strings.Contains(e, configFile) {
+				entry = e
+				return true
+			}
+		}
+		return false
+	}, 3*time.Second, 50*time.Millisecond)
+	assert.Contains(t, entry, "error 配置文件变更后重新加载失败")
+}
@@ -0,0 +1,126 @@
+package vconfig
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// versionKey 配置文件中记录配置结构版本的key，没有该key的配置文件为版本0
+const versionKey = "version"
+
+// MigrationFunc 将一个版本的配置转换为下一个版本，参数和返回值为配置文件解析后的内容，
+// 嵌套的配置为 map[string]any，数字的类型取决于配置格式（如JSON为float64）
+type MigrationFunc func(settings map[string]any) map[string]any
+
+// migration 注册的迁移
+type migration struct {
+	to int
+	fn MigrationFunc
+}
+
+var (
+	// migrations 按起始版本注册的迁移
+	migrations = map[int]migration{}
+	// migrationsMu 保护migrations的互斥锁
+	migrationsMu sync.RWMutex
+)
+
+// RegisterMigration 注册将版本为 fromVersion 的配置迁移到 toVersion 的函数
+//
+// 加载配置文件时，从文件中 version 的值（没有时为0）开始依次执行注册的迁移，直到没有以当前版本为起点的迁移，
+// 每一步之后 version 被设置为该步的 toVersion。配置结构体应包含 version 字段，默认值为最新版本，
+// 新生成的配置文件才不会被当作旧版本迁移：
+//
+//	vconfig.RegisterMigration(1, 2, func(m map[string]any) map[string]any {
+//		// v2 将 server.addr 拆分为 server.host 和 server.port
+//		...
+//		return m
+//	})
+//
+// version 不是整数（如 "1.2.3"）的配置文件视为没有结构版本，不执行迁移。
+// toVersion 必须大于 fromVersion，同一个 fromVersion 只能注册一次
+func RegisterMigration(fromVersion, toVersion int, migrate MigrationFunc) error {
+	if toVersion <= fromVersion {
+		return fmt.Errorf("迁移的目标版本 %d 必须大于起始版本 %d", toVersion, fromVersion)
+	}
+	if migrate == nil {
+		return fmt.Errorf("迁移函数不能为空")
+	}
+	migrationsMu.Lock()
+	defer migrationsMu.Unlock()
+	if existing, ok := migrations[fromVersion]; ok {
+		return fmt.Errorf("版本 %d 已注册迁移到版本 %d", fromVersion, existing.to)
+	}
+	migrations[fromVersion] = migration{to: toVersion, fn: migrate}
+	return nil
+}
+
+// migrateSettings 将配置迁移到最新版本，返回迁移后的配置和迁移前后的版本
+//
+// 没有注册迁移，或配置中的 version 不是整数（如 "1.2.3" 这样的应用版本号）时不迁移
+func migrateSettings(settings map[string]interface{}) (map[string]interface{}, int, int, error) {
+	migrationsMu.RLock()
+	defer migrationsMu.RUnlock()
+	if len(migrations) == 0 {
+		return settings, 0, 0, nil
+	}
+	from, ok := settingsVersion(settings)
+	if !ok {
+		return settings, 0, 0, nil
+	}
+
+	version := from
+	for {
+		step, ok := migrations[version]
+		if !ok {
+			break
+		}
+		settings = step.fn(settings)
+		if settings == nil {
+			return nil, from, version, fmt.Errorf("从版本 %d 迁移到版本 %d 返回了空配置", version, step.to)
+		}
+		settings[versionKey] = step.to
+		version = step.to
+	}
+	return settings, from, version, nil
+}
+
+// settingsVersion 返回配置中的版本，没有 version 时为0，version 不是整数时返回false
+func settingsVersion(settings map[string]interface{}) (int, bool) {
+	raw, ok := settings[versionKey]
+	if !ok || raw == nil {
+		return 0, true
+	}
+	switch v := raw.(type) {
+	case int:
+		return v, true
+	case int64:
+		return int(v), true
+	case uint64:
+		return int(v), true
+	case float64:
+		if v == float64(int(v)) {
+			return int(v), true
+		}
+	case string:
+		if n, err := strconv.Atoi(strings.TrimSpace(v)); err == nil {
+			return n, true
+		}
+	}
+	return 0, false
+}
+
+// saveMigrated 将迁移后的配置写回配置文件，配置结构体没有 version 字段时不写回，避免下次加载时重复迁移
+func (c *Config[T]) saveMigrated(from int) {
+	if _, ok := valueAtPath(c.GetData(), versionKey); !ok {
+		getInternalLogger().Errorw("配置结构体没有 version 字段，迁移后的配置未写回", "file", c.configFile)
+		return
+	}
+	if err := c.SaveConfig(); err != nil {
+		getInternalLogger().Errorw("写回迁移后的配置失败", "file", c.configFile, "error", err)
+		return
+	}
+	getInternalLogger().Infow("迁移后的配置已写回", "file", c.configFile, "from", from)
+}
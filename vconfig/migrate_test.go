@@ -0,0 +1,167 @@
+package vconfig
+
+import (
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// versionedConfig 带版本的配置，v1 将 addr 拆分为 host 和 port，v2 将 timeout 改为 timeout_ms
+type versionedConfig struct {
+	Version int `yaml:"version" json:"version"`
+	Server  struct {
+		Host string `yaml:"host" json:"host"`
+		Port int    `yaml:"port" json:"port"`
+	} `yaml:"server" json:"server"`
+	TimeoutMS int `yaml:"timeout_ms" json:"timeout_ms" mapstructure:"timeout_ms"`
+}
+
+// registerTestMigrations 注册测试用的迁移，测试结束后清除
+func registerTestMigrations(t *testing.T) {
+	t.Cleanup(func() {
+		migrationsMu.Lock()
+		migrations = map[int]migration{}
+		migrationsMu.Unlock()
+	})
+	require.NoError(t, RegisterMigration(0, 1, func(m map[string]any) map[string]any {
+		server, _ := m["server"].(map[string]interface{})
+		if addr, ok := server["addr"].(string); ok {
+			host, port, _ := net.SplitHostPort(addr)
+			server["host"] = host
+			server["port"], _ = strconv.Atoi(port)
+			delete(server, "addr")
+		}
+		return m
+	}))
+	require.NoError(t, RegisterMigration(1, 2, func(m map[string]any) map[string]any {
+		if timeout, ok := m["timeout"]; ok {
+			// YAML解析为int，JSON解析为float64
+			switch seconds := timeout.(type) {
+			case int:
+				m["timeout_ms"] = seconds * 1000
+			case float64:
+				m["timeout_ms"] = seconds * 1000
+			}
+			delete(m, "timeout")
+		}
+		return m
+	}))
+}
+
+// 测试加载旧版本配置文件时依次执行迁移
+func TestMigration(t *testing.T) {
+	registerTestMigrations(t)
+	configFile := filepath.Join(t.TempDir(), "app.yaml")
+	require.NoError(t, os.WriteFile(configFile, []byte("server:\n  addr: example.com:9000\ntimeout: 3\n"), 0644))
+
+	cfg, err := NewConfig(versionedConfig{Version: 2}, WithConfigFile[versionedConfig](configFile))
+	require.NoError(t, err)
+	defer cfg.Close()
+
+	data := cfg.GetData()
+	assert.Equal(t, 2, data.Version)
+	assert.Equal(t, "example.com", data.Server.Host)
+	assert.Equal(t, 9000, data.Server.Port)
+	assert.Equal(t, 3000, data.TimeoutMS)
+
+	// 未设置 WithMigrationRewrite 时不修改配置文件
+	content, err := os.ReadFile(configFile)
+	require.NoError(t, err)
+	assert.Contains(t, string(content), "addr: example.com:9000")
+
+	// 从中间版本开始迁移
+	require.NoError(t, os.WriteFile(configFile, []byte("version: 1\nserver:\n  host: a\n  port: 1\ntimeout: 2\n"), 0644))
+	cfg2, err := NewConfig(versionedConfig{Version: 2}, WithConfigFile[versionedConfig](configFile))
+	require.NoError(t, err)
+	defer cfg2.Close()
+	assert.Equal(t, "a", cfg2.GetData().Server.Host)
+	assert.Equal(t, 2000, cfg2.GetData().TimeoutMS)
+}
+
+// 测试迁移后写回配置文件
+func TestMigrationRewrite(t *testing.T) {
+	registerTestMigrations(t)
+	configFile := filepath.Join(t.TempDir(), "app.json")
+	require.NoError(t, os.WriteFile(configFile, []byte(`{"server": {"addr": "localhost:8080"}, "timeout": 5}`), 0644))
+
+	cfg, err := NewConfig(versionedConfig{Version: 2},
+		WithConfigFile[versionedConfig](configFile),
+		WithConfigType[versionedConfig](JSON),
+		WithMigrationRewrite[versionedConfig]())
+	require.NoError(t, err)
+	cfg.Close()
+
+	content, err := os.ReadFile(configFile)
+	require.NoError(t, err)
+	text := string(content)
+	assert.Contains(t, text, `"version": 2`)
+	assert.Contains(t, text, `"timeout_ms": 5000`)
+	assert.NotContains(t, text, "addr")
+
+	// 写回后再次加载不再迁移
+	cfg, err = NewConfig(versionedConfig{Version: 2},
+		WithConfigFile[versionedConfig](configFile),
+		WithConfigType[versionedConfig](JSON))
+	require.NoError(t, err)
+	defer cfg.Close()
+	assert.Equal(t, 8080, cfg.GetData().Server.Port)
+	assert.Equal(t, 5000, cfg.GetData().TimeoutMS)
+}
+
+// 测试注册迁移的参数校验
+func TestRegisterMigrationInvalid(t *testing.T) {
+	registerTestMigrations(t)
+	noop := func(m map[string]any) map[string]any { return m }
+
+	assert.Error(t, RegisterMigration(3, 3, noop))
+	assert.Error(t, RegisterMigration(4, 3, noop))
+	assert.Error(t, RegisterMigration(3, 4, nil))
+	assert.Error(t, RegisterMigration(1, 3, noop), "同一个起始版本只能注册一次")
+
+	_, ok := settingsVersion(map[string]interface{}{"version": "v2"})
+	assert.False(t, ok)
+}
+
+// 测试迁移函数返回空配置时加载失败
+func TestMigrationNilResult(t *testing.T) {
+	t.Cleanup(func() {
+		migrationsMu.Lock()
+		migrations = map[int]migration{}
+		migrationsMu.Unlock()
+	})
+	require.NoError(t, RegisterMigration(0, 1, func(map[string]any) map[string]any { return nil }))
+	configFile := filepath.Join(t.TempDir(), "app.yaml")
+	require.NoError(t, os.WriteFile(configFile, []byte("timeout: 1\n"), 0644))
+
+	_, err := NewConfig(versionedConfig{Version: 1}, WithConfigFile[versionedConfig](configFile))
+	assert.Error(t, err)
+}
+
+// appVersionConfig 使用 version 记录应用版本号的配置
+type appVersionConfig struct {
+	Version string `yaml:"version" json:"version"`
+	Name    string `yaml:"name" json:"name"`
+}
+
+// 测试 version 不是整数时不视为结构版本，有无注册迁移都能正常加载
+func TestMigrationStringVersion(t *testing.T) {
+	configFile := filepath.Join(t.TempDir(), "app.yaml")
+	require.NoError(t, os.WriteFile(configFile, []byte("version: 1.2.3\nname: demo\n"), 0644))
+
+	cfg, err := NewConfig(appVersionConfig{}, WithConfigFile[appVersionConfig](configFile))
+	require.NoError(t, err)
+	assert.Equal(t, "1.2.3", cfg.GetData().Version)
+	assert.Equal(t, "demo", cfg.GetData().Name)
+	cfg.Close()
+
+	registerTestMigrations(t)
+	cfg, err = NewConfig(appVersionConfig{}, WithConfigFile[appVersionConfig](configFile))
+	require.NoError(t, err)
+	defer cfg.Close()
+	assert.Equal(t, "1.2.3", cfg.GetData().Version)
+}
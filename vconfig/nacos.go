@@ -0,0 +1,442 @@
+package vconfig
+
+import (
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// nacosDefaultGroup Nacos默认分组
+	nacosDefaultGroup = "DEFAULT_GROUP"
+	// nacosLongPollTimeout 长轮询超时时间，Nacos服务端在此时间内没有变更时返回空响应
+	nacosLongPollTimeout = 30 * time.Second
+	// nacosRetryInterval 长轮询失败后的重试间隔
+	nacosRetryInterval = 2 * time.Second
+	// nacosWordSeparator 和 nacosLineSeparator 为Nacos监听协议中的分隔符
+	nacosWordSeparator = "\x02"
+	nacosLineSeparator = "\x01"
+)
+
+// errNacosCASFailed Nacos比较并交换发布失败，配置已被其他客户端修改
+var errNacosCASFailed = errors.New("nacos配置已被修改")
+
+// NacosConfig Nacos配置中心配置
+type NacosConfig struct {
+	// 服务地址，如 http://127.0.0.1:8848，未指定协议时使用http
+	ServerAddr string
+	// 命名空间ID，为空时使用public命名空间
+	Namespace string
+	// 配置分组，为空时使用 DEFAULT_GROUP
+	Group string
+	// 配置ID
+	DataID string
+	// 用户名，开启鉴权时使用
+	Username string
+	// 密码
+	Password string
+	// 请求超时时间，不包括长轮询
+	Timeout time.Duration
+	// HTTP客户端，为空时使用默认客户端
+	HTTPClient *http.Client
+}
+
+// nacosClient 通过Nacos Open API读写和监听配置
+type nacosClient struct {
+	config  *NacosConfig
+	baseURL string
+	http    *http.Client
+	ctx     context.Context
+	cancel  context.CancelFunc
+
+	tokenMu     sync.Mutex
+	accessToken string
+	tokenExpire time.Time
+
+	// 最近一次读取或发布的配置内容MD5
+	revision atomic.Value
+	// 最近一次读取或发布的配置内容，配置不存在时为nil
+	content atomic.Pointer[[]byte]
+}
+
+// newNacosClient 创建Nacos客户端
+func newNacosClient(config *NacosConfig) (*nacosClient, error) {
+	if config.ServerAddr == "" {
+		return nil, fmt.Errorf("未指定Nacos服务地址")
+	}
+	if config.DataID == "" {
+		return nil, fmt.Errorf("未指定Nacos配置ID")
+	}
+	if config.Group == "" {
+		config.Group = nacosDefaultGroup
+	}
+	if config.Timeout <= 0 {
+		config.Timeout = 5 * time.Second
+	}
+
+	baseURL := strings.TrimSuffix(config.ServerAddr, "/")
+	if !strings.Contains(baseURL, "://") {
+		baseURL = "http://" + baseURL
+	}
+	if !strings.HasSuffix(baseURL, "/nacos") {
+		baseURL += "/nacos"
+	}
+
+	httpClient := config.HTTPClient
+	if httpClient == nil {
+		httpClient = &http.Client{}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	return &nacosClient{
+		config:  config,
+		baseURL: baseURL,
+		http:    httpClient,
+		ctx:     ctx,
+		cancel:  cancel,
+	}, nil
+}
+
+// close 关闭Nacos客户端，停止长轮询
+func (n *nacosClient) close() {
+	n.cancel()
+}
+
+// configParams 返回标识当前配置的请求参数
+func (n *nacosClient) configParams() url.Values {
+	params := url.Values{}
+	params.Set("dataId", n.config.DataID)
+	params.Set("group", n.config.Group)
+	if n.config.Namespace != "" {
+		params.Set("tenant", n.config.Namespace)
+	}
+	return params
+}
+
+// get 获取配置内容，配置不存在时返回nil
+func (n *nacosClient) get() ([]byte, error) {
+	ctx, cancel := context.WithTimeout(n.ctx, n.config.Timeout)
+	defer cancel()
+
+	resp, body, err := n.do(ctx, http.MethodGet, "/v1/cs/configs", n.configParams(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("从Nacos获取配置失败: %w", err)
+	}
+	switch resp.StatusCode {
+	case http.StatusOK:
+		n.revision.Store(nacosMD5(body))
+		n.content.Store(&body)
+		return body, nil
+	case http.StatusNotFound:
+		n.revision.Store("")
+		n.content.Store(nil)
+		return nil, nil
+	default:
+		return nil, fmt.Errorf("从Nacos获取配置失败: %s: %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+}
+
+// publish 发布配置，casMD5 不为空时仅在服务端配置的MD5与其相同时发布
+func (n *nacosClient) publish(ctx context.Context, content []byte, configType ConfigType, casMD5 string) error {
+	ctx, cancel := context.WithTimeout(ctx, n.config.Timeout)
+	defer cancel()
+
+	form := n.configParams()
+	form.Set("content", string(content))
+	if configType != "" {
+		form.Set("type", string(configType))
+	}
+	if casMD5 != "" {
+		form.Set("casMd5", casMD5)
+	}
+
+	resp, body, err := n.do(ctx, http.MethodPost, "/v1/cs/configs", nil, form)
+	if err != nil {
+		return fmt.Errorf("发布配置到Nacos失败: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("发布配置到Nacos失败: %s: %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+	if strings.TrimSpace(string(body)) != "true" {
+		if casMD5 != "" {
+			return errNacosCASFailed
+		}
+		return fmt.Errorf("发布配置到Nacos失败: %s", strings.TrimSpace(string(body)))
+	}
+	n.revision.Store(nacosMD5(content))
+	n.content.Store(&content)
+	return nil
+}
+
+// watch 长轮询监听配置变更，配置变化时以新内容调用callback，每次请求后以请求结果调用report
+func (n *nacosClient) watch(initial []byte, report func(error), callback func([]byte)) {
+	go func() {
+		contentMD5 := nacosMD5(initial)
+		for {
+			changed, err := n.listen(contentMD5)
+			if n.ctx.Err() != nil {
+				return
+			}
+			report(err)
+			if err != nil {
+				getInternalLogger().Errorw("监听Nacos配置失败", "data_id", n.config.DataID, "group", n.config.Group, "error", err)
+				select {
+				case <-n.ctx.Done():
+					return
+				case <-time.After(nacosRetryInterval):
+				}
+				continue
+			}
+			if !changed {
+				continue
+			}
+
+			content, err := n.get()
+			if n.ctx.Err() != nil {
+				return
+			}
+			report(err)
+			if err != nil {
+				getInternalLogger().Errorw("获取Nacos配置失败", "data_id", n.config.DataID, "group", n.config.Group, "error", err)
+				continue
+			}
+			contentMD5 = nacosMD5(content)
+			if content != nil {
+				callback(content)
+			}
+		}
+	}()
+}
+
+// listen 发起一次长轮询，返回配置是否发生变化
+func (n *nacosClient) listen(contentMD5 string) (bool, error) {
+	line := n.config.DataID + nacosWordSeparator + n.config.Group + nacosWordSeparator + contentMD5
+	if n.config.Namespace != "" {
+		line += nacosWordSeparator + n.config.Namespace
+	}
+	form := url.Values{}
+	form.Set("Listening-Configs", line+nacosLineSeparator)
+
+	ctx, cancel := context.WithTimeout(n.ctx, nacosLongPollTimeout+n.config.Timeout)
+	defer cancel()
+
+	resp, body, err := n.do(ctx, http.MethodPost, "/v1/cs/configs/listener", nil, form,
+		"Long-Pulling-Timeout", fmt.Sprint(nacosLongPollTimeout.Milliseconds()))
+	if err != nil {
+		return false, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return false, fmt.Errorf("%s: %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+	return strings.TrimSpace(string(body)) != "", nil
+}
+
+// do 发送请求，开启鉴权时自动附加accessToken
+func (n *nacosClient) do(ctx context.Context, method, path string, query, form url.Values, headers ...string) (*http.Response, []byte, error) {
+	if query == nil {
+		query = url.Values{}
+	}
+	token, err := n.token(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	if token != "" {
+		query.Set("accessToken", token)
+	}
+
+	reqURL := n.baseURL + path
+	if len(query) > 0 {
+		reqURL += "?" + query.Encode()
+	}
+	var body io.Reader
+	if form != nil {
+		body = strings.NewReader(form.Encode())
+	}
+	req, err := http.NewRequestWithContext(ctx, method, reqURL, body)
+	if err != nil {
+		return nil, nil, err
+	}
+	if form != nil {
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	}
+	for i := 0; i+1 < len(headers); i += 2 {
+		req.Header.Set(headers[i], headers[i+1])
+	}
+
+	resp, err := n.http.Do(req)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer resp.Body.Close()
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, nil, err
+	}
+	return resp, respBody, nil
+}
+
+// token 返回鉴权token，未配置用户名时返回空字符串，token过期前会重新登录
+func (n *nacosClient) token(ctx context.Context) (string, error) {
+	if n.config.Username == "" {
+		return "", nil
+	}
+
+	n.tokenMu.Lock()
+	defer n.tokenMu.Unlock()
+	if n.accessToken != "" && time.Now().Before(n.tokenExpire) {
+		return n.accessToken, nil
+	}
+
+	form := url.Values{}
+	form.Set("username", n.config.Username)
+	form.Set("password", n.config.Password)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, n.baseURL+"/v1/auth/login", strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := n.http.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("Nacos登录失败: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("Nacos登录失败: %s", resp.Status)
+	}
+
+	var result struct {
+		AccessToken string `json:"accessToken"`
+		TokenTTL    int64  `json:"tokenTtl"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return "", fmt.Errorf("解析Nacos登录结果失败: %w", err)
+	}
+
+	// 提前刷新，避免请求过程中token过期
+	ttl := time.Duration(result.TokenTTL) * time.Second
+	n.accessToken = result.AccessToken
+	n.tokenExpire = time.Now().Add(ttl - ttl/10)
+	return n.accessToken, nil
+}
+
+// nacosMD5 计算配置内容的MD5，Nacos以此判断客户端配置是否为最新
+func nacosMD5(content []byte) string {
+	if content == nil {
+		return ""
+	}
+	sum := md5.Sum(content)
+	return hex.EncodeToString(sum[:])
+}
+
+// initWithNacos 使用Nacos初始化
+func (c *Config[T]) initWithNacos() error {
+	client, err := newNacosClient(c.nacosConfig)
+	if err != nil {
+		return fmt.Errorf("创建Nacos客户端失败: %w", err)
+	}
+	c.nacosClient = client
+
+	content, err := client.get()
+	if err != nil {
+		return err
+	}
+
+	if content != nil {
+		var data T
+		if err := unmarshalConfig(content, &data, c.configType); err != nil {
+			return fmt.Errorf("解析Nacos配置失败: %w", err)
+		}
+		c.setData(data)
+		if err := applyDefaults(&c.data); err != nil {
+			return err
+		}
+	} else {
+		// 配置不存在时发布默认配置
+		content, err = marshalConfig(c.GetData(), c.configType)
+		if err != nil {
+			return fmt.Errorf("序列化配置失败: %w", err)
+		}
+		if err := client.publish(client.ctx, content, c.configType, ""); err != nil {
+			return fmt.Errorf("发布默认配置到Nacos失败: %w", err)
+		}
+	}
+	if err := c.runOnLoad(&c.data); err != nil {
+		return err
+	}
+
+	c.watchNacos(content)
+	return nil
+}
+
+// watchNacos 监听Nacos配置变更
+func (c *Config[T]) watchNacos(initial []byte) {
+	eventName := c.nacosConfig.Group + "/" + c.nacosConfig.DataID
+	c.nacosClient.watch(initial, c.health.recordSource, func(content []byte) {
+		c.closedMu.RLock()
+		if c.closed {
+			c.closedMu.RUnlock()
+			return
+		}
+		c.closedMu.RUnlock()
+
+		var newData T
+		if err := unmarshalConfig(content, &newData, c.configType); err != nil {
+			getInternalLogger().Errorw("解析Nacos配置失败", "data_id", c.nacosConfig.DataID, "config_type", c.configType, "error", err)
+			c.recordLoadError(err)
+			return
+		}
+
+		c.setBaseline(c.GetData())
+		c.applyRemoteData(newData, eventName)
+	})
+}
+
+// updateNacos 修改配置并基于MD5比较并交换发布到Nacos
+func (c *Config[T]) updateNacos(fn func(data *T) error) (T, error) {
+	for i := 0; i < etcdMaxCASRetries; i++ {
+		content, err := c.nacosClient.get()
+		if err != nil {
+			return c.GetData(), err
+		}
+
+		current := cloneConfig(c.GetData())
+		if content != nil {
+			var latest T
+			if err := unmarshalConfig(content, &latest, c.configType); err != nil {
+				return c.GetData(), fmt.Errorf("解析Nacos配置失败: %w", err)
+			}
+			current = latest
+		}
+
+		newData, err := c.applyUpdate(current, fn)
+		if err != nil {
+			return c.GetData(), err
+		}
+		newContent, err := marshalConfig(newData, c.configType)
+		if err != nil {
+			return c.GetData(), fmt.Errorf("序列化配置失败: %w", err)
+		}
+
+		err = c.nacosClient.publish(c.nacosClient.ctx, newContent, c.configType, nacosMD5(content))
+		if errors.Is(err, errNacosCASFailed) {
+			continue
+		}
+		if err != nil {
+			return c.GetData(), err
+		}
+		c.setData(newData)
+		return newData, nil
+	}
+	return c.GetData(), ErrConflict
+}
@@ -0,0 +1,163 @@
+package vconfig
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeNacos 模拟Nacos配置中心的Open API
+type fakeNacos struct {
+	mu       sync.Mutex
+	content  []byte
+	exists   bool
+	changed  chan struct{}
+	publishs int
+}
+
+func newFakeNacos() *fakeNacos {
+	return &fakeNacos{changed: make(chan struct{})}
+}
+
+// get 返回服务端当前配置和发布次数
+func (f *fakeNacos) get() (string, int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return string(f.content), f.publishs
+}
+
+// set 在服务端修改配置并唤醒长轮询
+func (f *fakeNacos) set(content []byte) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.content = content
+	f.exists = true
+	close(f.changed)
+	f.changed = make(chan struct{})
+}
+
+func (f *fakeNacos) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.URL.Path {
+	case "/nacos/v1/cs/configs":
+		if r.Method == http.MethodGet {
+			f.mu.Lock()
+			defer f.mu.Unlock()
+			if !f.exists {
+				http.Error(w, "config data not exist", http.StatusNotFound)
+				return
+			}
+			w.Write(f.content)
+			return
+		}
+
+		r.ParseForm()
+		f.mu.Lock()
+		if casMD5 := r.PostForm.Get("casMd5"); casMD5 != "" && casMD5 != nacosMD5(f.content) {
+			f.mu.Unlock()
+			w.Write([]byte("false"))
+			return
+		}
+		f.publishs++
+		f.mu.Unlock()
+		f.set([]byte(r.PostForm.Get("content")))
+		w.Write([]byte("true"))
+	case "/nacos/v1/cs/configs/listener":
+		r.ParseForm()
+		parts := strings.Split(strings.TrimSuffix(r.PostForm.Get("Listening-Configs"), nacosLineSeparator), nacosWordSeparator)
+		for {
+			f.mu.Lock()
+			current, changed := nacosMD5(f.content), f.changed
+			f.mu.Unlock()
+			if len(parts) >= 3 && parts[2] != current {
+				w.Write([]byte(parts[0] + "%02" + parts[1] + "%01"))
+				return
+			}
+			select {
+			case <-changed:
+			case <-r.Context().Done():
+				return
+			}
+		}
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// 测试从Nacos加载、发布和监听配置
+func TestNacosConfig(t *testing.T) {
+	nacos := newFakeNacos()
+	server := httptest.NewServer(nacos)
+	defer server.Close()
+
+	// 配置不存在时发布默认配置
+	cfg, err := NewConfig(newDefaultConfig(),
+		WithNacosConfig[AppConfig](server.URL, "", "", "app.yaml"),
+		WithConfigType[AppConfig](YAML))
+	require.NoError(t, err)
+	defer cfg.Close()
+
+	content, publishs := nacos.get()
+	assert.Equal(t, 1, publishs)
+	assert.Contains(t, content, "port: 8080")
+	assert.Equal(t, 8080, cfg.GetData().Server.Port)
+
+	changes := make(chan []ConfigChangedItem, 1)
+	cfg.OnChange(func(e fsnotify.Event, changedItems []ConfigChangedItem) {
+		changes <- changedItems
+	})
+
+	// 服务端修改配置后通过长轮询收到变更
+	updated := newDefaultConfig()
+	updated.Server.Port = 9090
+	raw, err := marshalConfig(updated, YAML)
+	require.NoError(t, err)
+	nacos.set(raw)
+
+	select {
+	case items := <-changes:
+		require.Len(t, items, 1)
+		assert.Equal(t, "server.port", items[0].Path)
+		assert.Equal(t, 8080, items[0].OldValue)
+		assert.Equal(t, 9090, items[0].NewValue)
+	case <-time.After(5 * time.Second):
+		t.Fatal("未收到Nacos配置变更")
+	}
+	assert.Equal(t, 9090, cfg.GetData().Server.Port)
+
+	// UpdateFunc 基于MD5发布
+	require.NoError(t, cfg.UpdateFunc(func(c *AppConfig) error {
+		c.Server.Host = "0.0.0.0"
+		return nil
+	}))
+	content, _ = nacos.get()
+	assert.Contains(t, content, "host: 0.0.0.0")
+	assert.Equal(t, "0.0.0.0", cfg.GetData().Server.Host)
+
+	// 已存在的配置直接加载
+	other, err := NewConfig(AppConfig{},
+		WithNacosConfig[AppConfig](server.URL, "", "DEFAULT_GROUP", "app.yaml"),
+		WithConfigType[AppConfig](YAML))
+	require.NoError(t, err)
+	defer other.Close()
+	assert.Equal(t, 9090, other.GetData().Server.Port)
+	assert.Equal(t, "0.0.0.0", other.GetData().Server.Host)
+}
+
+// 测试配置源互斥
+func TestNacosConfigSources(t *testing.T) {
+	_, err := NewConfig(newDefaultConfig(),
+		WithConfigFile[AppConfig]("app.yaml"),
+		WithNacosConfig[AppConfig]("127.0.0.1:8848", "", "", "app.yaml"))
+	assert.Error(t, err)
+
+	_, err = NewConfig(newDefaultConfig(),
+		WithNacosConfig[AppConfig]("127.0.0.1:8848", "", "", ""))
+	assert.Error(t, err)
+}
@@ -1,7 +1,10 @@
 package vconfig
 
 import (
+	"flag"
 	"time"
+
+	"github.com/spf13/pflag"
 )
 
 // ConfigOption 配置选项函数
@@ -29,6 +32,19 @@ func WithEnvPrefix[T any](prefix string) ConfigOption[T] {
 	}
 }
 
+// WithDotenv 从 .env 文件读取 KEY=VALUE 形式的环境变量，未指定路径时读取当前目录的 .env
+//
+// 读取的变量与进程的环境变量一起按 WithEnvPrefix 的前缀映射覆盖配置，进程中已设置的变量优先，
+// 多个文件中的同名变量以后面的文件为准，文件不存在时忽略。不会修改进程的环境变量
+func WithDotenv[T any](paths ...string) ConfigOption[T] {
+	return func(c *Config[T]) {
+		if len(paths) == 0 {
+			paths = []string{".env"}
+		}
+		c.dotenvFiles = append(c.dotenvFiles, paths...)
+	}
+}
+
 // WithDebounceTime 设置防抖时间
 func WithDebounceTime[T any](duration time.Duration) ConfigOption[T] {
 	return func(c *Config[T]) {
@@ -74,7 +90,8 @@ func WithETCDKey[T any](key string) ConfigOption[T] {
 	}
 }
 
-// WithETCDTLS 设置ETCD的TLS配置
+// WithETCDTLS 设置ETCD的TLS配置，certFile 和 keyFile 为双向TLS使用的客户端证书，可以为空；
+// caFile 为校验服务端证书的CA证书，为空时使用系统CA。需要覆盖服务端名称时通过 WithETCDConfig 设置 TLSConfig
 func WithETCDTLS[T any](certFile, keyFile, caFile string) ConfigOption[T] {
 	return func(c *Config[T]) {
 		if c.etcdConfig == nil {
@@ -87,3 +104,137 @@ func WithETCDTLS[T any](certFile, keyFile, caFile string) ConfigOption[T] {
 		}
 	}
 }
+
+// WithFlags 绑定命令行参数，显式设置的参数优先级高于环境变量和配置文件
+func WithFlags[T any](fs *pflag.FlagSet) ConfigOption[T] {
+	return func(c *Config[T]) {
+		c.flagSet = fs
+	}
+}
+
+// WithGoFlags 绑定标准库flag包的命令行参数，语义与 WithFlags 相同
+func WithGoFlags[T any](fs *flag.FlagSet) ConfigOption[T] {
+	return func(c *Config[T]) {
+		c.goFlagSet = fs
+	}
+}
+
+// WithETCDPrefix 使用ETCD前缀模式，prefix下的每个子key对应一个配置项，
+// 如 prefix 为 /config/app 时，/config/app/server/port 对应配置路径 server.port
+func WithETCDPrefix[T any](prefix string) ConfigOption[T] {
+	return func(c *Config[T]) {
+		if c.etcdConfig == nil {
+			c.etcdConfig = DefaultETCDConfig()
+		}
+		c.etcdConfig.Key = prefix
+		c.etcdConfig.Prefix = true
+	}
+}
+
+// WithDecryption 设置配置文件加解密，加载时透明解密，保存时重新加密
+func WithDecryption[T any](provider CryptoProvider) ConfigOption[T] {
+	return func(c *Config[T]) {
+		c.crypto = provider
+	}
+}
+
+// WithMigrationRewrite 配置文件通过 RegisterMigration 注册的迁移升级到最新版本后写回配置文件，
+// 下次加载时不再迁移；配置结构体需要包含 version 字段
+func WithMigrationRewrite[T any]() ConfigOption[T] {
+	return func(c *Config[T]) {
+		c.rewriteMigrated = true
+	}
+}
+
+// WithBackup 保存配置前将原配置文件备份为 <配置文件>.bak
+func WithBackup[T any]() ConfigOption[T] {
+	return func(c *Config[T]) {
+		c.backup = true
+	}
+}
+
+// WithSaveOverrides 保存配置时同时写入来自环境变量和命令行参数的值
+//
+// 默认情况下 SaveConfig 只写入配置文件中的值和通过 Update/UpdateFunc 修改的值，
+// 避免将部署时的环境变量固化到配置文件中
+func WithSaveOverrides[T any]() ConfigOption[T] {
+	return func(c *Config[T]) {
+		c.saveOverrides = true
+	}
+}
+
+// WithStrictUnmarshal 开启严格模式，配置文件中存在不对应结构体字段的配置项（如把 level 误写为 levle）时
+// 加载失败并返回 ErrUnknownKeys，而不是忽略该配置项使用默认值
+//
+// 创建配置时返回错误；监听到配置文件变更后重新加载失败时保留当前配置，错误记录到内部日志和 Health
+func WithStrictUnmarshal[T any]() ConfigOption[T] {
+	return func(c *Config[T]) {
+		c.strictUnmarshal = true
+	}
+}
+
+// WithProfile 设置环境名称，加载配置文件后再叠加同目录下的环境配置文件，
+// 如 app.yaml 在 production 环境下叠加 app.production.yaml，环境配置文件中的配置覆盖基础配置
+//
+// 未设置时从环境变量 <前缀>_PROFILE（启用环境变量时）或 APP_PROFILE 读取
+func WithProfile[T any](profile string) ConfigOption[T] {
+	return func(c *Config[T]) {
+		c.profile = profile
+	}
+}
+
+// WithNacosConfig 使用Nacos配置中心，namespace 为空时使用public命名空间，group 为空时使用 DEFAULT_GROUP
+func WithNacosConfig[T any](serverAddr, namespace, group, dataID string) ConfigOption[T] {
+	return func(c *Config[T]) {
+		if c.nacosConfig == nil {
+			c.nacosConfig = &NacosConfig{}
+		}
+		c.nacosConfig.ServerAddr = serverAddr
+		c.nacosConfig.Namespace = namespace
+		c.nacosConfig.Group = group
+		c.nacosConfig.DataID = dataID
+	}
+}
+
+// WithNacosAuth 设置Nacos鉴权的用户名和密码
+func WithNacosAuth[T any](username, password string) ConfigOption[T] {
+	return func(c *Config[T]) {
+		if c.nacosConfig == nil {
+			c.nacosConfig = &NacosConfig{}
+		}
+		c.nacosConfig.Username = username
+		c.nacosConfig.Password = password
+	}
+}
+
+// WithHTTPSource 使用HTTP(S)地址作为只读配置源，按 interval 轮询（小于等于0时为30秒），
+// 使用ETag和Last-Modified避免重复下载，内容变化时触发变更回调；配置格式由 WithConfigType 指定
+func WithHTTPSource[T any](url string, interval time.Duration, headers map[string]string) ConfigOption[T] {
+	return func(c *Config[T]) {
+		c.httpConfig = &HTTPSourceConfig{
+			URL:      url,
+			Interval: interval,
+			Headers:  headers,
+		}
+	}
+}
+
+// WithHistorySize 设置保留的配置历史版本数，默认为10，小于等于0时不记录历史
+func WithHistorySize[T any](size int) ConfigOption[T] {
+	return func(c *Config[T]) {
+		c.historySize = size
+	}
+}
+
+// WithOnLoad 添加每次加载配置后执行的处理函数，可用于规范化配置值（如去除空白、展开相对路径、计算派生字段）
+//
+// 处理函数在填充default tag之后、配置通过 GetData 可见之前执行，多个处理函数按添加顺序执行。
+// 初始化时返回错误会使 NewConfig 失败，重新加载时返回错误会放弃本次变更并保留当前配置；
+// UpdateFunc 修改的配置同样会经过处理后再保存
+func WithOnLoad[T any](fn func(data *T) error) ConfigOption[T] {
+	return func(c *Config[T]) {
+		if fn != nil {
+			c.onLoad = append(c.onLoad, fn)
+		}
+	}
+}
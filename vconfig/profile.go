@@ -0,0 +1,165 @@
+package vconfig
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ProfileEnv 未通过 WithProfile 指定环境时，从该环境变量读取环境名称
+const ProfileEnv = "APP_PROFILE"
+
+// activeProfile 返回当前使用的环境名称
+//
+// 优先使用 WithProfile 指定的名称，其次是启用环境变量时的 <前缀>_PROFILE，最后是 APP_PROFILE
+func (c *Config[T]) activeProfile() string {
+	if c.profile != "" {
+		return c.profile
+	}
+	if c.enableEnv && c.envPrefix != "" {
+		if profile := c.getenv(c.envPrefix + "_PROFILE"); profile != "" {
+			return profile
+		}
+	}
+	return c.getenv(ProfileEnv)
+}
+
+// profileFile 返回当前环境的配置文件路径，如 app.yaml 在 production 环境下为 app.production.yaml，
+// 未指定环境时返回空字符串
+func (c *Config[T]) profileFile() string {
+	profile := c.activeProfile()
+	if profile == "" {
+		return ""
+	}
+	ext := filepath.Ext(c.configFile)
+	return strings.TrimSuffix(c.configFile, ext) + "." + profile + ext
+}
+
+// profileLayer 当前环境配置文件中的配置，保存配置时这些配置项不写入基础配置文件
+type profileLayer struct {
+	// file 环境配置文件路径
+	file string
+	// base 合并环境配置文件之前的配置，按小写的点号分隔配置键展开
+	base map[string]interface{}
+	// values 环境配置文件中的配置，按小写的点号分隔配置键展开
+	values map[string]interface{}
+}
+
+// loadProfileSettings 读取当前环境的配置文件并合并到settings中，环境配置文件不存在时返回nil
+func (c *Config[T]) loadProfileSettings(settings map[string]interface{}, includes *[]string) (*profileLayer, error) {
+	profileFile := c.profileFile()
+	if profileFile == "" {
+		return nil, nil
+	}
+	if _, err := os.Stat(profileFile); os.IsNotExist(err) {
+		return nil, nil
+	}
+
+	*includes = append(*includes, profileFile)
+	overlay, err := c.readSettings(profileFile, configTypeOf(profileFile, c.configType), map[string]bool{}, includes)
+	if err != nil {
+		return nil, err
+	}
+	layer := &profileLayer{file: profileFile, base: flatSettings(settings), values: flatSettings(overlay)}
+	mergeSettings(settings, overlay)
+	return layer, nil
+}
+
+// splitProfile 从待写入基础配置文件的配置 settings 中移除环境配置文件中的配置项：
+// 恢复为合并环境配置文件之前的值，基础配置中没有的配置项被删除；
+// 与环境配置文件中的值不同的配置项（如通过 Update 修改）作为返回值，写入环境配置文件
+func (l *profileLayer) splitProfile(settings map[string]interface{}) map[string]interface{} {
+	var updates map[string]interface{}
+	_ = flattenSettings(settings, "", func(path string, value interface{}) error {
+		key := strings.ToLower(path)
+		profileValue, ok := l.values[key]
+		if !ok {
+			return nil
+		}
+		if !sameValue(value, profileValue) {
+			if updates == nil {
+				updates = make(map[string]interface{})
+			}
+			updates[path] = value
+		}
+		parts := strings.Split(path, ".")
+		if base, ok := l.base[key]; ok {
+			setSettingsValue(settings, parts, base)
+		} else {
+			deleteSettingsValue(settings, parts)
+		}
+		return nil
+	})
+	return updates
+}
+
+// writeProfileSettings 将 updates 中的配置项写入环境配置文件，YAML文件保留其中的注释和key顺序
+func (c *Config[T]) writeProfileSettings(updates map[string]interface{}) error {
+	l := c.layers.profile
+	configType := configTypeOf(l.file, c.configType)
+	original, err := c.readFile(l.file)
+	if err != nil {
+		return err
+	}
+	settings, err := readSettingsBytes(original, configType)
+	if err != nil {
+		return fmt.Errorf("解析配置文件 %s 失败: %w", l.file, err)
+	}
+	for path, value := range updates {
+		setSettingsValue(settings, strings.Split(path, "."), value)
+	}
+
+	content, err := marshalConfig(settings, configType)
+	if err != nil {
+		return fmt.Errorf("序列化配置失败: %w", err)
+	}
+	if configType == YAML {
+		if content, err = mergeYAMLContent(original, content); err != nil {
+			return err
+		}
+	}
+	if c.crypto != nil {
+		if content, err = c.crypto.Encrypt(content); err != nil {
+			return fmt.Errorf("加密配置文件失败: %w", err)
+		}
+	}
+	if c.backup {
+		if err := backupFile(l.file); err != nil {
+			return err
+		}
+	}
+	if err := atomicWriteFile(l.file, content, 0644); err != nil {
+		return fmt.Errorf("写入配置文件 %s 失败: %w", l.file, err)
+	}
+	for path, value := range updates {
+		l.values[strings.ToLower(path)] = value
+	}
+	return nil
+}
+
+// flatSettings 将嵌套map展开为小写的点号分隔配置键
+func flatSettings(settings map[string]interface{}) map[string]interface{} {
+	flat := make(map[string]interface{})
+	_ = flattenSettings(settings, "", func(path string, value interface{}) error {
+		flat[strings.ToLower(path)] = value
+		return nil
+	})
+	return flat
+}
+
+// deleteSettingsValue 删除嵌套map中路径对应的值，删除后为空的中间节点一并删除
+func deleteSettingsValue(settings map[string]interface{}, parts []string) {
+	if len(parts) == 1 {
+		delete(settings, parts[0])
+		return
+	}
+	next, ok := settings[parts[0]].(map[string]interface{})
+	if !ok {
+		return
+	}
+	deleteSettingsValue(next, parts[1:])
+	if len(next) == 0 {
+		delete(settings, parts[0])
+	}
+}
@@ -0,0 +1,147 @@
+package vconfig
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// 测试叠加环境配置文件
+func TestConfigProfile(t *testing.T) {
+	dir := t.TempDir()
+	baseFile := filepath.Join(dir, "app.yaml")
+	prodFile := filepath.Join(dir, "app.production.yaml")
+
+	require.NoError(t, os.WriteFile(baseFile, []byte(`server:
+  host: localhost
+  port: 8080
+log:
+  level: debug
+`), 0600))
+	require.NoError(t, os.WriteFile(prodFile, []byte(`server:
+  host: 0.0.0.0
+log:
+  level: warn
+`), 0600))
+
+	cfg, err := NewConfig(AppConfig{},
+		WithConfigFile[AppConfig](baseFile),
+		WithConfigType[AppConfig](YAML),
+		WithProfile[AppConfig]("production"))
+	require.NoError(t, err)
+	defer cfg.Close()
+
+	data := cfg.GetData()
+	assert.Equal(t, "0.0.0.0", data.Server.Host)
+	assert.Equal(t, 8080, data.Server.Port, "环境配置中未设置的配置项使用基础配置")
+	assert.Equal(t, "warn", data.Log.Level)
+
+	// 修改环境配置文件也会触发重新加载
+	changed := make(chan struct{}, 1)
+	cfg.OnChange(func(fsnotify.Event, []ConfigChangedItem) {
+		select {
+		case changed <- struct{}{}:
+		default:
+		}
+	})
+	require.NoError(t, os.WriteFile(prodFile, []byte("log:\n  level: error\n"), 0600))
+	select {
+	case <-changed:
+	case <-time.After(3 * time.Second):
+		t.Fatal("修改环境配置文件后未触发重新加载")
+	}
+	assert.Equal(t, "error", cfg.GetData().Log.Level)
+}
+
+// 测试从环境变量读取环境名称
+func TestConfigProfileFromEnv(t *testing.T) {
+	dir := t.TempDir()
+	baseFile := filepath.Join(dir, "app.yaml")
+	require.NoError(t, os.WriteFile(baseFile, []byte("server:\n  port: 8080\n"), 0600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "app.staging.yaml"), []byte("server:\n  port: 8081\n"), 0600))
+
+	t.Setenv(ProfileEnv, "staging")
+	cfg, err := NewConfig(AppConfig{},
+		WithConfigFile[AppConfig](baseFile),
+		WithConfigType[AppConfig](YAML))
+	require.NoError(t, err)
+	assert.Equal(t, 8081, cfg.GetData().Server.Port)
+	cfg.Close()
+
+	// 环境配置文件不存在时只使用基础配置
+	t.Setenv(ProfileEnv, "missing")
+	cfg, err = NewConfig(AppConfig{},
+		WithConfigFile[AppConfig](baseFile),
+		WithConfigType[AppConfig](YAML))
+	require.NoError(t, err)
+	assert.Equal(t, 8080, cfg.GetData().Server.Port)
+	cfg.Close()
+}
+
+// 测试使用环境配置文件时保存配置，环境配置文件中的配置项不写入基础配置文件
+func TestConfigProfileSave(t *testing.T) {
+	for _, tc := range []struct {
+		name       string
+		configType ConfigType
+		base       string
+		prod       string
+	}{
+		{"yaml", YAML, "server:\n  host: localhost\n  port: 8080\nlog:\n  level: debug\n", "server:\n  host: prod-db\nlog:\n  level: warn\n"},
+		{"json", JSON, `{"server":{"host":"localhost","port":8080},"log":{"level":"debug"}}`, `{"server":{"host":"prod-db"},"log":{"level":"warn"}}`},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			dir := t.TempDir()
+			ext := "." + string(tc.configType)
+			baseFile := filepath.Join(dir, "app"+ext)
+			prodFile := filepath.Join(dir, "app.production"+ext)
+			require.NoError(t, os.WriteFile(baseFile, []byte(tc.base), 0600))
+			require.NoError(t, os.WriteFile(prodFile, []byte(tc.prod), 0600))
+
+			cfg, err := NewConfig(AppConfig{},
+				WithConfigFile[AppConfig](baseFile),
+				WithConfigType[AppConfig](tc.configType),
+				WithProfile[AppConfig]("production"))
+			require.NoError(t, err)
+			defer cfg.Close()
+
+			require.NoError(t, cfg.UpdateFunc(func(data *AppConfig) error {
+				data.Server.Port = 9090
+				data.Log.Level = "error"
+				return nil
+			}))
+			data := cfg.GetData()
+			assert.Equal(t, "prod-db", data.Server.Host)
+			assert.Equal(t, "error", data.Log.Level)
+
+			// 基础配置文件保留原来的值，只写入基础配置中的修改
+			base, err := NewConfig(AppConfig{},
+				WithConfigFile[AppConfig](baseFile),
+				WithConfigType[AppConfig](tc.configType))
+			require.NoError(t, err)
+			defer base.Close()
+			assert.Equal(t, "localhost", base.GetData().Server.Host)
+			assert.Equal(t, 9090, base.GetData().Server.Port)
+			assert.Equal(t, "debug", base.GetData().Log.Level)
+
+			// 修改环境配置文件中的配置项写入环境配置文件
+			reloaded, err := NewConfig(AppConfig{},
+				WithConfigFile[AppConfig](baseFile),
+				WithConfigType[AppConfig](tc.configType),
+				WithProfile[AppConfig]("production"))
+			require.NoError(t, err)
+			defer reloaded.Close()
+			assert.Equal(t, "prod-db", reloaded.GetData().Server.Host)
+			assert.Equal(t, 9090, reloaded.GetData().Server.Port)
+			assert.Equal(t, "error", reloaded.GetData().Log.Level)
+
+			prod, err := os.ReadFile(prodFile)
+			require.NoError(t, err)
+			assert.NotContains(t, string(prod), "9090")
+		})
+	}
+}
@@ -0,0 +1,98 @@
+package vconfig
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/viper"
+)
+
+// ValueOrigin 配置项当前值的来源
+type ValueOrigin string
+
+const (
+	// OriginFile 来自配置文件、默认值或远程配置源
+	OriginFile ValueOrigin = "file"
+	// OriginEnv 来自环境变量（包括 .env 文件）
+	OriginEnv ValueOrigin = "env"
+	// OriginFlag 来自命令行参数
+	OriginFlag ValueOrigin = "flag"
+	// OriginUpdate 被环境变量或命令行参数覆盖后，又通过 Update/UpdateFunc 显式修改
+	OriginUpdate ValueOrigin = "update"
+)
+
+// override 被环境变量或命令行参数覆盖的配置项
+type override struct {
+	origin ValueOrigin
+	// base 覆盖前的值
+	base interface{}
+	// value 覆盖后的值
+	value interface{}
+}
+
+// overrides 按配置键记录的覆盖
+type overrides map[string]override
+
+// record 记录配置项被覆盖，同一配置项被多次覆盖时保留最初的值
+func (o overrides) record(key string, origin ValueOrigin, base, value interface{}) {
+	if prev, ok := o[key]; ok {
+		base = prev.base
+	}
+	o[key] = override{origin: origin, base: base, value: value}
+}
+
+// Origin 返回配置项当前值的来源，key 为点号分隔的配置键，如 "server.port"
+func (c *Config[T]) Origin(key string) ValueOrigin {
+	key = strings.ToLower(key)
+	o, ok := c.overrides[key]
+	if !ok {
+		return OriginFile
+	}
+	if !sameValue(c.v.Get(key), o.value) {
+		return OriginUpdate
+	}
+	return o.origin
+}
+
+// persistentSettings 返回写入配置文件的配置，仍为环境变量或命令行参数覆盖值的配置项恢复为覆盖前的值
+//
+// 通过 WithSaveOverrides 启用时原样返回viper中的配置
+func (c *Config[T]) persistentSettings() *viper.Viper {
+	if c.saveOverrides || len(c.overrides) == 0 {
+		return c.v
+	}
+
+	v := viper.New()
+	for k, val := range c.v.AllSettings() {
+		v.Set(k, val)
+	}
+	for key, o := range c.overrides {
+		if sameValue(c.v.Get(key), o.value) {
+			v.Set(key, o.base)
+		}
+	}
+	return v
+}
+
+// persistentData 返回写入配置文件的配置数据，见 persistentSettings
+func (c *Config[T]) persistentData() (T, error) {
+	v := c.persistentSettings()
+	if v == c.v {
+		return c.GetData(), nil
+	}
+	// 按配置文件格式转换，与读取配置文件时使用相同的struct tag
+	content, err := marshalConfig(v.AllSettings(), c.configType)
+	if err != nil {
+		return c.GetData(), fmt.Errorf("序列化配置失败: %w", err)
+	}
+	data := cloneConfig(c.GetData())
+	if err := unmarshalConfig(content, &data, c.configType); err != nil {
+		return c.GetData(), fmt.Errorf("反序列化配置失败: %w", err)
+	}
+	return data, nil
+}
+
+// sameValue 比较配置值，环境变量和序列化得到的值类型可能不同（如int64与int），按字符串形式比较
+func sameValue(a, b interface{}) bool {
+	return fmt.Sprint(a) == fmt.Sprint(b)
+}
@@ -0,0 +1,99 @@
+package vconfig
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+// 测试保存配置时不写入环境变量的覆盖值，显式修改的值会写入
+func TestSaveConfigSkipsOverrides(t *testing.T) {
+	t.Setenv("SAVE_SERVER_HOST", "0.0.0.0")
+	t.Setenv("SAVE_SERVER_PORT", "9100")
+	configFile := filepath.Join(t.TempDir(), "app.yaml")
+	require.NoError(t, os.WriteFile(configFile, []byte("server:\n  host: example.com\n  port: 9000\n"), 0644))
+
+	cfg, err := NewConfig(newDefaultConfig(),
+		WithConfigFile[AppConfig](configFile),
+		WithEnvPrefix[AppConfig]("SAVE"))
+	require.NoError(t, err)
+	defer cfg.Close()
+	assert.Equal(t, "0.0.0.0", cfg.GetData().Server.Host)
+	assert.Equal(t, OriginEnv, cfg.Origin("server.host"))
+	assert.Equal(t, OriginFile, cfg.Origin("app.name"))
+
+	require.NoError(t, cfg.UpdateFunc(func(data *AppConfig) error {
+		data.Server.Port = 9200
+		data.App.Name = "saved"
+		return nil
+	}))
+	assert.Equal(t, OriginUpdate, cfg.Origin("server.port"))
+	assert.Equal(t, OriginEnv, cfg.Origin("Server.Host"))
+
+	var saved AppConfig
+	content, err := os.ReadFile(configFile)
+	require.NoError(t, err)
+	require.NoError(t, yaml.Unmarshal(content, &saved))
+	assert.Equal(t, "example.com", saved.Server.Host)
+	assert.Equal(t, 9200, saved.Server.Port)
+	assert.Equal(t, "saved", saved.App.Name)
+	// 当前配置仍使用环境变量的值
+	assert.Equal(t, "0.0.0.0", cfg.GetData().Server.Host)
+}
+
+// 测试使用 WithSaveOverrides 时写入环境变量的覆盖值
+func TestSaveConfigWithOverrides(t *testing.T) {
+	t.Setenv("SAVE_SERVER_HOST", "0.0.0.0")
+	configFile := filepath.Join(t.TempDir(), "app.json")
+	require.NoError(t, os.WriteFile(configFile, []byte(`{"server":{"host":"example.com","port":9000}}`), 0644))
+
+	for _, saveOverrides := range []bool{false, true} {
+		opts := []ConfigOption[AppConfig]{
+			WithConfigFile[AppConfig](configFile),
+			WithConfigType[AppConfig](JSON),
+			WithEnvPrefix[AppConfig]("SAVE"),
+		}
+		if saveOverrides {
+			opts = append(opts, WithSaveOverrides[AppConfig]())
+		}
+		cfg, err := NewConfig(newDefaultConfig(), opts...)
+		require.NoError(t, err)
+		require.NoError(t, cfg.SaveConfig())
+		cfg.Close()
+
+		var saved AppConfig
+		content, err := os.ReadFile(configFile)
+		require.NoError(t, err)
+		require.NoError(t, json.Unmarshal(content, &saved))
+		if saveOverrides {
+			assert.Equal(t, "0.0.0.0", saved.Server.Host)
+		} else {
+			assert.Equal(t, "example.com", saved.Server.Host)
+			assert.Equal(t, 9000, saved.Server.Port)
+		}
+	}
+}
+
+// 测试创建默认配置文件时不写入环境变量的覆盖值
+func TestDefaultConfigFileSkipsOverrides(t *testing.T) {
+	t.Setenv("SAVE_SERVER_PORT", "9100")
+	configFile := filepath.Join(t.TempDir(), "app.yaml")
+
+	cfg, err := NewConfig(newDefaultConfig(),
+		WithConfigFile[AppConfig](configFile),
+		WithEnvPrefix[AppConfig]("SAVE"))
+	require.NoError(t, err)
+	defer cfg.Close()
+	assert.Equal(t, 9100, cfg.GetData().Server.Port)
+
+	var saved AppConfig
+	content, err := os.ReadFile(configFile)
+	require.NoError(t, err)
+	require.NoError(t, yaml.Unmarshal(content, &saved))
+	assert.Equal(t, newDefaultConfig().Server.Port, saved.Server.Port)
+}
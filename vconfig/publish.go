@@ -0,0 +1,221 @@
+package vconfig
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	clientv3 "go.etcd.io/etcd/client/v3"
+)
+
+// etcdDraftSuffix 草稿配置在ETCD中的key后缀，如 /config/app 的草稿为 /config/app.draft
+const etcdDraftSuffix = ".draft"
+
+var (
+	// ErrNoDraft 配置源中没有草稿配置
+	ErrNoDraft = errors.New("没有草稿配置")
+	// ErrUnsupportedSource 当前配置源不支持该操作
+	ErrUnsupportedSource = errors.New("配置源不支持该操作")
+)
+
+// Publish 校验、序列化配置并整体替换配置源中的配置，成功后立即应用到当前实例并触发变更回调，
+// 其他实例通过监听收到变更，适用于基于 vconfig 实现的配置管理服务
+//
+// 与 UpdateFunc 不同，Publish 不会重新读取并重试：ETCD（非前缀模式）基于当前实例最近读取或监听到的 ModRevision、
+// Nacos基于配置MD5比较并交换，配置源中的配置在此之后被其他客户端修改时返回 ErrConflict，
+// 调用方应基于最新的 GetData 重新生成配置。配置文件直接写入；HTTP配置源返回 ErrReadOnlySource，
+// ETCD前缀模式返回 ErrUnsupportedSource
+func (c *Config[T]) Publish(ctx context.Context, data T) error {
+	return c.commit(historySourcePublish, func() (T, error) {
+		if c.httpSource != nil {
+			return c.GetData(), ErrReadOnlySource
+		}
+		prepared, err := c.prepareData(data)
+		if err != nil {
+			return c.GetData(), err
+		}
+		switch {
+		case c.configFile != "":
+			return c.saveFile(prepared)
+		case c.etcdClient != nil:
+			return c.publishETCD(ctx, prepared)
+		case c.nacosClient != nil:
+			return c.publishNacos(ctx, prepared)
+		default:
+			return c.GetData(), fmt.Errorf("未指定配置源")
+		}
+	})
+}
+
+// PublishDraft 校验、序列化配置并写入ETCD中的草稿（key 加 .draft 后缀），不影响正式配置，
+// 审核后通过 Promote 发布；已有草稿时覆盖。仅支持ETCD非前缀模式，其他配置源返回 ErrUnsupportedSource
+func (c *Config[T]) PublishDraft(ctx context.Context, data T) error {
+	draftKey, err := c.draftKey()
+	if err != nil {
+		return err
+	}
+	prepared, err := c.prepareData(data)
+	if err != nil {
+		return err
+	}
+	content, err := marshalConfig(prepared, c.configType)
+	if err != nil {
+		return fmt.Errorf("序列化配置失败: %w", err)
+	}
+	if _, err := c.etcdClient.client.Put(ctx, draftKey, string(content)); err != nil {
+		return fmt.Errorf("保存草稿配置到ETCD失败: %w", err)
+	}
+	return nil
+}
+
+// Draft 返回ETCD中的草稿配置，没有草稿时返回 ErrNoDraft
+func (c *Config[T]) Draft(ctx context.Context) (T, error) {
+	var data T
+	draftKey, err := c.draftKey()
+	if err != nil {
+		return data, err
+	}
+	kv, err := c.getDraft(ctx, draftKey)
+	if err != nil {
+		return data, err
+	}
+	if err := unmarshalConfig(kv.Value, &data, c.configType); err != nil {
+		return data, fmt.Errorf("反序列化草稿配置失败: %w", err)
+	}
+	return data, nil
+}
+
+// Promote 将草稿发布为正式配置并删除草稿，成功后立即应用到当前实例并触发变更回调
+//
+// 草稿在发布前重新校验；写入草稿后正式配置被修改过或草稿被替换时返回 ErrConflict，需要重新写入草稿
+func (c *Config[T]) Promote(ctx context.Context) error {
+	draftKey, err := c.draftKey()
+	if err != nil {
+		return err
+	}
+	return c.commit(historySourcePublish, func() (T, error) {
+		kv, err := c.getDraft(ctx, draftKey)
+		if err != nil {
+			return c.GetData(), err
+		}
+		var draft T
+		if err := unmarshalConfig(kv.Value, &draft, c.configType); err != nil {
+			return c.GetData(), fmt.Errorf("反序列化草稿配置失败: %w", err)
+		}
+		prepared, err := c.prepareData(draft)
+		if err != nil {
+			return c.GetData(), err
+		}
+
+		// 正式配置的版本早于草稿说明草稿写入后正式配置未被修改
+		key := c.etcdConfig.Key
+		resp, err := c.etcdClient.client.Txn(ctx).
+			If(clientv3.Compare(clientv3.ModRevision(key), "<", kv.ModRevision),
+				clientv3.Compare(clientv3.ModRevision(draftKey), "=", kv.ModRevision)).
+			Then(clientv3.OpPut(key, string(kv.Value)), clientv3.OpDelete(draftKey)).
+			Commit()
+		if err != nil {
+			return c.GetData(), fmt.Errorf("发布草稿配置失败: %w", err)
+		}
+		if !resp.Succeeded {
+			return c.GetData(), ErrConflict
+		}
+		c.storeETCDWrite(resp.Header.Revision, kv.Value)
+		c.setData(prepared)
+		return prepared, nil
+	})
+}
+
+// DiscardDraft 删除ETCD中的草稿配置，没有草稿时不返回错误
+func (c *Config[T]) DiscardDraft(ctx context.Context) error {
+	draftKey, err := c.draftKey()
+	if err != nil {
+		return err
+	}
+	if _, err := c.etcdClient.client.Delete(ctx, draftKey); err != nil {
+		return fmt.Errorf("删除草稿配置失败: %w", err)
+	}
+	return nil
+}
+
+// prepareData 对要发布的配置填充默认值、执行加载处理函数并校验
+func (c *Config[T]) prepareData(data T) (T, error) {
+	return c.applyUpdate(data, func(*T) error { return nil })
+}
+
+// publishETCD 仅当配置key的ModRevision仍为最近一次读取或监听到的版本时写入配置
+func (c *Config[T]) publishETCD(ctx context.Context, data T) (T, error) {
+	if c.etcdConfig.Prefix {
+		return c.GetData(), ErrUnsupportedSource
+	}
+	content, err := marshalConfig(data, c.configType)
+	if err != nil {
+		return c.GetData(), fmt.Errorf("序列化配置失败: %w", err)
+	}
+
+	key := c.etcdConfig.Key
+	resp, err := c.etcdClient.client.Txn(ctx).
+		If(clientv3.Compare(clientv3.ModRevision(key), "=", c.etcdClient.modRevision.Load())).
+		Then(clientv3.OpPut(key, string(content))).
+		Commit()
+	if err != nil {
+		return c.GetData(), fmt.Errorf("保存配置到ETCD失败: %w", err)
+	}
+	if !resp.Succeeded {
+		return c.GetData(), ErrConflict
+	}
+	c.storeETCDWrite(resp.Header.Revision, content)
+	c.setData(data)
+	return data, nil
+}
+
+// publishNacos 仅当配置MD5仍为最近一次读取或发布的内容的MD5时发布配置
+func (c *Config[T]) publishNacos(ctx context.Context, data T) (T, error) {
+	content, err := marshalConfig(data, c.configType)
+	if err != nil {
+		return c.GetData(), fmt.Errorf("序列化配置失败: %w", err)
+	}
+	revision, _ := c.nacosClient.revision.Load().(string)
+	err = c.nacosClient.publish(ctx, content, c.configType, revision)
+	if errors.Is(err, errNacosCASFailed) {
+		return c.GetData(), ErrConflict
+	}
+	if err != nil {
+		return c.GetData(), err
+	}
+	c.setData(data)
+	return data, nil
+}
+
+// storeETCDWrite 记录写入ETCD的配置内容及其版本
+func (c *Config[T]) storeETCDWrite(revision int64, content []byte) {
+	c.etcdClient.revision.Store(revision)
+	c.etcdClient.modRevision.Store(revision)
+	c.etcdClient.content.Store(&content)
+}
+
+// draftKey 返回草稿配置的key，不支持草稿的配置源返回 ErrUnsupportedSource
+func (c *Config[T]) draftKey() (string, error) {
+	if c.etcdClient == nil || c.etcdConfig.Prefix {
+		return "", ErrUnsupportedSource
+	}
+	return c.etcdConfig.Key + etcdDraftSuffix, nil
+}
+
+// getDraft 读取草稿配置，不存在时返回 ErrNoDraft
+func (c *Config[T]) getDraft(ctx context.Context, draftKey string) (*kvPair, error) {
+	resp, err := c.etcdClient.client.Get(ctx, draftKey)
+	if err != nil {
+		return nil, fmt.Errorf("从ETCD获取草稿配置失败: %w", err)
+	}
+	if len(resp.Kvs) == 0 {
+		return nil, ErrNoDraft
+	}
+	return &kvPair{Value: resp.Kvs[0].Value, ModRevision: resp.Kvs[0].ModRevision}, nil
+}
+
+// kvPair ETCD中的配置内容及其版本
+type kvPair struct {
+	Value       []byte
+	ModRevision int64
+}
@@ -0,0 +1,102 @@
+package vconfig
+
+import (
+	"context"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/constructorvirgil/virlog/test/testutils"
+	"github.com/fsnotify/fsnotify"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// 测试Publish校验、保存配置并触发回调
+func TestPublishFile(t *testing.T) {
+	configFile := testutils.RandomTempFilename("test_publish", ".yaml")
+	defer testutils.CleanTempFile(t, configFile)
+
+	cfg, err := NewConfig(validatedConfig{AppConfig: newDefaultConfig()},
+		WithConfigFile[validatedConfig](configFile),
+		WithConfigType[validatedConfig](YAML))
+	require.NoError(t, err)
+	defer cfg.Close()
+
+	var changed []ConfigChangedItem
+	cfg.OnChange(func(e fsnotify.Event, changedItems []ConfigChangedItem) {
+		changed = changedItems
+	})
+
+	data := cfg.GetData()
+	data.Server.Port = 9000
+	require.NoError(t, cfg.Publish(context.Background(), data))
+	assert.Equal(t, 9000, cfg.GetData().Server.Port)
+	require.Len(t, changed, 1)
+	assert.Equal(t, "server.port", changed[0].Path)
+
+	history := cfg.History()
+	assert.Equal(t, historySourcePublish, history[len(history)-1].Source)
+
+	// 校验失败时不修改配置
+	data.Server.Port = 0
+	err = cfg.Publish(context.Background(), data)
+	assert.ErrorContains(t, err, "端口超出范围")
+	assert.Equal(t, 9000, cfg.GetData().Server.Port)
+
+	reloaded, err := NewConfig(AppConfig{},
+		WithConfigFile[AppConfig](configFile),
+		WithConfigType[AppConfig](YAML))
+	require.NoError(t, err)
+	defer reloaded.Close()
+	assert.Equal(t, 9000, reloaded.GetData().Server.Port)
+
+	// 草稿仅支持ETCD
+	assert.ErrorIs(t, cfg.PublishDraft(context.Background(), data), ErrUnsupportedSource)
+	assert.ErrorIs(t, cfg.Promote(context.Background()), ErrUnsupportedSource)
+}
+
+// 测试Nacos配置在读取后被修改时Publish返回冲突
+func TestPublishNacosConflict(t *testing.T) {
+	nacos := newFakeNacos()
+	server := httptest.NewServer(nacos)
+	defer server.Close()
+
+	cfg, err := NewConfig(newDefaultConfig(),
+		WithNacosConfig[AppConfig](server.URL, "", "", "app.yaml"),
+		WithConfigType[AppConfig](YAML))
+	require.NoError(t, err)
+	defer cfg.Close()
+
+	data := cfg.GetData()
+	data.Server.Port = 9000
+	require.NoError(t, cfg.Publish(context.Background(), data))
+	content, _ := nacos.get()
+	assert.Contains(t, content, "port: 9000")
+	assert.Equal(t, 9000, cfg.GetData().Server.Port)
+
+	// 其他客户端修改配置后，基于旧版本的发布被拒绝
+	cfg.nacosClient.revision.Store(nacosMD5([]byte("stale")))
+	data.Server.Port = 9100
+	assert.ErrorIs(t, cfg.Publish(context.Background(), data), ErrConflict)
+	content, _ = nacos.get()
+	assert.Contains(t, content, "port: 9000")
+	assert.Equal(t, 9000, cfg.GetData().Server.Port)
+}
+
+// 测试HTTP配置源不支持发布
+func TestPublishReadOnly(t *testing.T) {
+	source := &fakeHTTPSource{}
+	source.set("server:\n  port: 9000\n")
+	server := httptest.NewServer(source)
+	defer server.Close()
+
+	cfg, err := NewConfig(newDefaultConfig(),
+		WithHTTPSource[AppConfig](server.URL+"/app.yaml", time.Minute, nil))
+	require.NoError(t, err)
+	defer cfg.Close()
+
+	assert.ErrorIs(t, cfg.Publish(context.Background(), cfg.GetData()), ErrReadOnlySource)
+	_, err = cfg.Draft(context.Background())
+	assert.ErrorIs(t, err, ErrUnsupportedSource)
+}
@@ -0,0 +1,183 @@
+package vconfig
+
+import (
+	"bytes"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// backupSuffix 配置文件备份的后缀
+const backupSuffix = ".bak"
+
+// atomicWriteFile 原子地写入文件：先写入同目录下的临时文件，再重命名覆盖目标文件
+//
+// 写入过程中进程崩溃或磁盘写满不会留下写了一半的配置文件；目标文件已存在时保留其权限
+func atomicWriteFile(filename string, content []byte, perm fs.FileMode) error {
+	if info, err := os.Stat(filename); err == nil {
+		perm = info.Mode().Perm()
+	}
+
+	dir, base := filepath.Split(filename)
+	if dir == "" {
+		dir = "."
+	}
+	tmp, err := os.CreateTemp(dir, "."+base+".tmp-*")
+	if err != nil {
+		return fmt.Errorf("创建临时文件失败: %w", err)
+	}
+	tmpName := tmp.Name()
+	// 重命名成功后临时文件已不存在，删除失败可以忽略
+	defer os.Remove(tmpName)
+
+	if _, err := tmp.Write(content); err != nil {
+		tmp.Close()
+		return fmt.Errorf("写入临时文件失败: %w", err)
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("同步临时文件失败: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("关闭临时文件失败: %w", err)
+	}
+	if err := os.Chmod(tmpName, perm); err != nil {
+		return fmt.Errorf("设置文件权限失败: %w", err)
+	}
+	if err := os.Rename(tmpName, filename); err != nil {
+		return fmt.Errorf("替换配置文件失败: %w", err)
+	}
+	return nil
+}
+
+// backupFile 将已存在的文件复制为 filename.bak，文件不存在时不做任何操作
+func backupFile(filename string) error {
+	content, err := os.ReadFile(filename)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("读取待备份的配置文件失败: %w", err)
+	}
+	if err := atomicWriteFile(filename+backupSuffix, content, 0644); err != nil {
+		return fmt.Errorf("备份配置文件失败: %w", err)
+	}
+	return nil
+}
+
+// mergeYAMLContent 将新的YAML内容合并到原有YAML文档中，保留原文档的注释和key顺序
+//
+// 原文档中已不存在的key被删除，新增的key追加到所在映射的末尾；原内容为空或无法解析时直接返回新内容
+func mergeYAMLContent(original, updated []byte) ([]byte, error) {
+	if len(bytes.TrimSpace(original)) == 0 {
+		return updated, nil
+	}
+
+	var dst yaml.Node
+	if err := yaml.Unmarshal(original, &dst); err != nil || dst.Kind != yaml.DocumentNode || len(dst.Content) == 0 {
+		return updated, nil
+	}
+	var src yaml.Node
+	if err := yaml.Unmarshal(updated, &src); err != nil {
+		return nil, fmt.Errorf("解析YAML失败: %w", err)
+	}
+	if src.Kind != yaml.DocumentNode || len(src.Content) == 0 {
+		return updated, nil
+	}
+
+	mergeYAMLNode(dst.Content[0], src.Content[0])
+
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(yamlIndent(original))
+	if err := enc.Encode(&dst); err != nil {
+		return nil, fmt.Errorf("序列化YAML失败: %w", err)
+	}
+	if err := enc.Close(); err != nil {
+		return nil, fmt.Errorf("序列化YAML失败: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// mergeYAMLNode 用src的值更新dst，保留dst上的注释、样式和映射key的顺序
+func mergeYAMLNode(dst, src *yaml.Node) {
+	switch {
+	case dst.Kind == yaml.MappingNode && src.Kind == yaml.MappingNode:
+		used := make([]bool, len(src.Content)/2)
+		content := make([]*yaml.Node, 0, len(src.Content))
+		for i := 0; i+1 < len(dst.Content); i += 2 {
+			j := findYAMLKey(src, dst.Content[i].Value)
+			if j < 0 {
+				continue
+			}
+			used[j/2] = true
+			mergeYAMLNode(dst.Content[i+1], src.Content[j+1])
+			content = append(content, dst.Content[i], dst.Content[i+1])
+		}
+		for j := 0; j+1 < len(src.Content); j += 2 {
+			if !used[j/2] {
+				content = append(content, src.Content[j], src.Content[j+1])
+			}
+		}
+		dst.Content = content
+	case dst.Kind == yaml.SequenceNode && src.Kind == yaml.SequenceNode:
+		n := len(dst.Content)
+		if len(src.Content) < n {
+			n = len(src.Content)
+		}
+		for i := 0; i < n; i++ {
+			mergeYAMLNode(dst.Content[i], src.Content[i])
+		}
+		dst.Content = append(dst.Content[:n], src.Content[n:]...)
+	case dst.Kind == yaml.ScalarNode && src.Kind == yaml.ScalarNode:
+		if dst.Tag != src.Tag || dst.Value != src.Value {
+			// 类型变化时原有的引号样式可能不再适用
+			if dst.Tag != src.Tag {
+				dst.Style = src.Style
+			}
+			dst.Tag = src.Tag
+			dst.Value = src.Value
+		}
+	default:
+		dst.Kind = src.Kind
+		dst.Style = src.Style
+		dst.Tag = src.Tag
+		dst.Value = src.Value
+		dst.Anchor = src.Anchor
+		dst.Alias = src.Alias
+		dst.Content = src.Content
+	}
+}
+
+// findYAMLKey 在映射节点中查找key（忽略大小写，与viper一致），返回key节点的下标，不存在时返回-1
+func findYAMLKey(mapping *yaml.Node, key string) int {
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if mapping.Content[i].Value == key {
+			return i
+		}
+	}
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if strings.EqualFold(mapping.Content[i].Value, key) {
+			return i
+		}
+	}
+	return -1
+}
+
+// yamlIndent 推断原YAML文档的缩进宽度，无法推断时使用yaml.v3的默认值4
+func yamlIndent(content []byte) int {
+	for _, line := range strings.Split(string(content), "\n") {
+		trimmed := strings.TrimLeft(line, " ")
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+		if indent := len(line) - len(trimmed); indent >= 2 {
+			return indent
+		}
+	}
+	return 4
+}
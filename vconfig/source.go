@@ -0,0 +1,136 @@
+package vconfig
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+// SourceInfo 当前配置的来源和版本，可以输出到日志或健康检查接口，确认运行的是哪个版本的配置
+type SourceInfo struct {
+	// Source 配置源名称：file、etcd、nacos 或 http
+	Source string `json:"source"`
+	// Path 配置文件路径，仅配置文件
+	Path string `json:"path,omitempty"`
+	// ModTime 读取或写入时配置文件的修改时间，仅配置文件
+	ModTime time.Time `json:"mod_time,omitempty"`
+	// Key 配置在配置源中的位置：ETCD为key（前缀模式下为前缀），Nacos为 group/dataId，HTTP为URL
+	Key string `json:"key,omitempty"`
+	// ModRevision ETCD中配置的版本，仅ETCD
+	ModRevision int64 `json:"mod_revision,omitempty"`
+	// Revision 配置源中的版本，含义同 ConfigVersion.Revision
+	Revision string `json:"revision,omitempty"`
+	// Checksum Raw 返回内容的SHA-256，没有内容时为空
+	Checksum string `json:"checksum,omitempty"`
+	// EnvHash 覆盖配置的环境变量（包括 .env 文件）和命令行参数的SHA-256，没有覆盖时为空
+	EnvHash string `json:"env_hash,omitempty"`
+}
+
+// Raw 返回最近一次从配置源读取或写入的原始内容，配置文件为解密后的主配置文件内容，
+// 不包含 $include 引入的文件和环境配置文件；ETCD前缀模式下没有单一的配置内容，返回nil
+//
+// 开启变更确认时，Raw 和 SourceInfo 反映配置源中的最新内容，可能尚未应用到 GetData
+func (c *Config[T]) Raw() []byte {
+	raw := c.rawContent()
+	if raw == nil {
+		return nil
+	}
+	return append([]byte(nil), raw...)
+}
+
+// SourceInfo 返回当前配置的来源和版本
+func (c *Config[T]) SourceInfo() SourceInfo {
+	info := SourceInfo{
+		Source:   c.sourceName(),
+		Revision: c.sourceRevision(),
+		EnvHash:  c.overridesHash(),
+	}
+	switch {
+	case c.etcdClient != nil:
+		info.Key = c.sourceKey()
+		info.ModRevision = c.etcdClient.revision.Load()
+	case c.nacosClient != nil, c.httpSource != nil:
+		info.Key = c.sourceKey()
+	case c.configFile != "":
+		info.Path = c.configFile
+		c.rawMu.RLock()
+		info.ModTime = c.rawModTime
+		c.rawMu.RUnlock()
+	}
+	if raw := c.rawContent(); raw != nil {
+		sum := sha256.Sum256(raw)
+		info.Checksum = hex.EncodeToString(sum[:])
+	}
+	return info
+}
+
+// sourceKey 返回配置在配置源中的位置：配置文件路径、ETCD的key、Nacos的 group/dataId 或HTTP的URL
+func (c *Config[T]) sourceKey() string {
+	switch {
+	case c.etcdClient != nil:
+		return c.etcdConfig.Key
+	case c.nacosClient != nil:
+		return c.nacosConfig.Group + "/" + c.nacosConfig.DataID
+	case c.httpSource != nil:
+		return c.httpConfig.URL
+	default:
+		return c.configFile
+	}
+}
+
+// rawContent 返回配置源最近一次读取或写入的内容，不复制
+func (c *Config[T]) rawContent() []byte {
+	var content *[]byte
+	switch {
+	case c.etcdClient != nil:
+		content = c.etcdClient.content.Load()
+	case c.nacosClient != nil:
+		content = c.nacosClient.content.Load()
+	case c.httpSource != nil:
+		content = c.httpSource.content.Load()
+	default:
+		c.rawMu.RLock()
+		defer c.rawMu.RUnlock()
+		return c.raw
+	}
+	if content == nil {
+		return nil
+	}
+	return *content
+}
+
+// recordRaw 记录读取或写入的配置文件内容及文件当前的修改时间
+func (c *Config[T]) recordRaw(raw []byte) {
+	var modTime time.Time
+	if info, err := os.Stat(c.configFile); err == nil {
+		modTime = info.ModTime()
+	}
+	c.rawMu.Lock()
+	defer c.rawMu.Unlock()
+	c.raw = append([]byte(nil), raw...)
+	c.rawModTime = modTime
+}
+
+// overridesHash 返回覆盖配置的环境变量和命令行参数的SHA-256，按配置键排序后计算
+func (c *Config[T]) overridesHash() string {
+	if len(c.overrides) == 0 {
+		return ""
+	}
+	keys := make([]string, 0, len(c.overrides))
+	for key := range c.overrides {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var b strings.Builder
+	for _, key := range keys {
+		o := c.overrides[key]
+		fmt.Fprintf(&b, "%s\x00%s\x00%v\n", key, o.origin, o.value)
+	}
+	sum := sha256.Sum256([]byte(b.String()))
+	return hex.EncodeToString(sum[:])
+}
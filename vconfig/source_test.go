@@ -0,0 +1,92 @@
+package vconfig
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// 测试配置文件的原始内容和来源信息
+func TestRawAndSourceInfo(t *testing.T) {
+	configFile := filepath.Join(t.TempDir(), "app.yaml")
+	content := []byte("app:\n  name: raw-test\nserver:\n  port: 9000\n")
+	require.NoError(t, os.WriteFile(configFile, content, 0644))
+	stat, err := os.Stat(configFile)
+	require.NoError(t, err)
+
+	cfg, err := NewConfig(newDefaultConfig(), WithConfigFile[AppConfig](configFile))
+	require.NoError(t, err)
+	defer cfg.Close()
+
+	assert.Equal(t, content, cfg.Raw())
+	info := cfg.SourceInfo()
+	sum := sha256.Sum256(content)
+	assert.Equal(t, "file", info.Source)
+	assert.Equal(t, configFile, info.Path)
+	assert.True(t, stat.ModTime().Equal(info.ModTime))
+	assert.Equal(t, hex.EncodeToString(sum[:]), info.Checksum)
+	assert.Empty(t, info.EnvHash)
+
+	// 返回的是副本
+	cfg.Raw()[0] = 'x'
+	assert.Equal(t, content, cfg.Raw())
+
+	// 保存后为写入的内容
+	require.NoError(t, cfg.UpdateFunc(func(data *AppConfig) error {
+		data.Server.Port = 9100
+		return nil
+	}))
+	saved, err := os.ReadFile(configFile)
+	require.NoError(t, err)
+	assert.Equal(t, saved, cfg.Raw())
+	assert.NotEqual(t, info.Checksum, cfg.SourceInfo().Checksum)
+}
+
+// 测试环境变量覆盖的哈希随覆盖值变化
+func TestSourceInfoEnvHash(t *testing.T) {
+	configFile := filepath.Join(t.TempDir(), "app.yaml")
+	require.NoError(t, os.WriteFile(configFile, []byte("server:\n  port: 9000\n"), 0644))
+
+	hashOf := func(port string) string {
+		t.Setenv("RAWENV_SERVER_PORT", port)
+		cfg, err := NewConfig(newDefaultConfig(),
+			WithConfigFile[AppConfig](configFile),
+			WithEnvPrefix[AppConfig]("RAWENV"))
+		require.NoError(t, err)
+		defer cfg.Close()
+		return cfg.SourceInfo().EnvHash
+	}
+
+	first := hashOf("9100")
+	assert.NotEmpty(t, first)
+	assert.Equal(t, first, hashOf("9100"))
+	assert.NotEqual(t, first, hashOf("9200"))
+}
+
+// 测试HTTP配置源的原始内容和来源信息
+func TestRawHTTPSource(t *testing.T) {
+	source := &fakeHTTPSource{}
+	source.set("app:\n  name: remote\n")
+	server := httptest.NewServer(source)
+	defer server.Close()
+
+	cfg, err := NewConfig(newDefaultConfig(),
+		WithHTTPSource[AppConfig](server.URL+"/app.yaml", time.Hour, nil))
+	require.NoError(t, err)
+	defer cfg.Close()
+
+	assert.Equal(t, []byte("app:\n  name: remote\n"), cfg.Raw())
+	info := cfg.SourceInfo()
+	assert.Equal(t, "http", info.Source)
+	assert.Equal(t, server.URL+"/app.yaml", info.Key)
+	assert.Equal(t, `"v1"`, info.Revision)
+	assert.Empty(t, info.Path)
+	assert.NotEmpty(t, info.Checksum)
+}
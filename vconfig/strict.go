@@ -0,0 +1,100 @@
+package vconfig
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+)
+
+// unknownKeys 返回 settings 中不对应 typ 字段的配置键（按路径排序），如 log.levle
+//
+// 配置键与字段的 tagName tag、mapstructure tag 或字段名（不区分大小写）相同时视为已知，
+// map 类型的字段接受任意键，interface{} 类型的字段不再检查其中的键
+func unknownKeys(settings map[string]interface{}, typ reflect.Type, tagName string) []string {
+	var unknown []string
+	collectUnknownKeys(settings, typ, tagName, "", &unknown)
+	sort.Strings(unknown)
+	return unknown
+}
+
+// collectUnknownKeys 递归检查配置值中的未知键
+func collectUnknownKeys(value interface{}, typ reflect.Type, tagName, path string, unknown *[]string) {
+	for typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+
+	switch typ.Kind() {
+	case reflect.Struct:
+		m, ok := value.(map[string]interface{})
+		if !ok || typ == reflect.TypeOf(time.Time{}) {
+			return
+		}
+		fields := structFieldKeys(typ, tagName)
+		for key, v := range m {
+			keyPath := key
+			if path != "" {
+				keyPath = path + "." + key
+			}
+			fieldType, ok := fields[strings.ToLower(key)]
+			if !ok {
+				*unknown = append(*unknown, keyPath)
+				continue
+			}
+			collectUnknownKeys(v, fieldType, tagName, keyPath, unknown)
+		}
+	case reflect.Map:
+		m, ok := value.(map[string]interface{})
+		if !ok {
+			return
+		}
+		for key, v := range m {
+			collectUnknownKeys(v, typ.Elem(), tagName, path+"."+key, unknown)
+		}
+	case reflect.Slice, reflect.Array:
+		list, ok := value.([]interface{})
+		if !ok {
+			return
+		}
+		for i, item := range list {
+			collectUnknownKeys(item, typ.Elem(), tagName, fmt.Sprintf("%s[%d]", path, i), unknown)
+		}
+	}
+}
+
+// structFieldKeys 返回结构体中可以出现的配置键（小写）及对应字段的类型，内联的嵌入结构体的键合并到当前层级
+func structFieldKeys(typ reflect.Type, tagName string) map[string]reflect.Type {
+	keys := make(map[string]reflect.Type, typ.NumField())
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		keys[strings.ToLower(field.Name)] = field.Type
+
+		inline := false
+		for _, name := range []string{tagName, "mapstructure"} {
+			tag := strings.Split(field.Tag.Get(name), ",")
+			if tag[0] != "" && tag[0] != "-" {
+				keys[strings.ToLower(tag[0])] = field.Type
+			}
+			for _, opt := range tag[1:] {
+				if opt == "inline" || opt == "squash" {
+					inline = true
+				}
+			}
+		}
+
+		fieldType := field.Type
+		for fieldType.Kind() == reflect.Ptr {
+			fieldType = fieldType.Elem()
+		}
+		if (inline || field.Anonymous) && fieldType.Kind() == reflect.Struct {
+			for k, t := range structFieldKeys(fieldType, tagName) {
+				keys[k] = t
+			}
+		}
+	}
+	return keys
+}
@@ -0,0 +1,84 @@
+package vconfig
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// 测试严格模式下配置文件包含未知配置项时加载失败
+func TestStrictUnmarshal(t *testing.T) {
+	configFile := filepath.Join(t.TempDir(), "app.yaml")
+	require.NoError(t, os.WriteFile(configFile, []byte("log:\n  levle: debug\nserver:\n  port: 9000\n"), 0644))
+
+	// 默认忽略未知配置项
+	cfg, err := NewConfig(newDefaultConfig(), WithConfigFile[AppConfig](configFile))
+	require.NoError(t, err)
+	assert.Equal(t, 9000, cfg.GetData().Server.Port)
+	cfg.Close()
+
+	_, err = NewConfig(newDefaultConfig(),
+		WithConfigFile[AppConfig](configFile),
+		WithStrictUnmarshal[AppConfig]())
+	require.ErrorIs(t, err, ErrUnknownKeys)
+	assert.Contains(t, err.Error(), "levle")
+}
+
+// 测试严格模式下已知的配置项正常加载，变更后出现未知配置项时保留当前配置
+func TestStrictUnmarshalReload(t *testing.T) {
+	configFile := filepath.Join(t.TempDir(), "app.yaml")
+	require.NoError(t, os.WriteFile(configFile, []byte("app:\n  name: demo\nserver:\n  port: 9000\nlog:\n  level: debug\n"), 0644))
+
+	cfg, err := NewConfig(newDefaultConfig(),
+		WithConfigFile[AppConfig](configFile),
+		WithStrictUnmarshal[AppConfig]())
+	require.NoError(t, err)
+	defer cfg.Close()
+	assert.Equal(t, "demo", cfg.GetData().App.Name)
+	assert.Equal(t, 9000, cfg.GetData().Server.Port)
+	assert.Equal(t, "debug", cfg.GetData().Log.Level)
+
+	require.NoError(t, os.WriteFile(configFile, []byte("app:\n  name: changed\n  nmae: typo\n"), 0644))
+	assert.Eventually(t, func() bool {
+		return cfg.Health().LastError != ""
+	}, 5*time.Second, 50*time.Millisecond)
+	assert.Contains(t, cfg.Health().LastError, "nmae")
+	assert.Equal(t, "demo", cfg.GetData().App.Name)
+}
+
+// 测试按struct tag、字段名、map和切片检查未知配置项
+func TestUnknownKeys(t *testing.T) {
+	type Endpoint struct {
+		URL string `yaml:"url"`
+	}
+	type Base struct {
+		Region string `yaml:"region"`
+	}
+	type Settings struct {
+		Base      `yaml:",inline"`
+		Name      string              `yaml:"name"`
+		Timeout   time.Duration       `mapstructure:"timeout_ms"`
+		Endpoints []Endpoint          `yaml:"endpoints"`
+		Labels    map[string]string   `yaml:"labels"`
+		Routes    map[string]Endpoint `yaml:"routes"`
+		Extra     interface{}         `yaml:"extra"`
+	}
+
+	settings := map[string]interface{}{
+		"name":       "demo",
+		"region":     "cn",
+		"timeout_ms": 100,
+		"endpoints":  []interface{}{map[string]interface{}{"url": "a"}, map[string]interface{}{"uri": "b"}},
+		"labels":     map[string]interface{}{"any": "value"},
+		"routes":     map[string]interface{}{"home": map[string]interface{}{"url": "/", "methd": "GET"}},
+		"extra":      map[string]interface{}{"free": true},
+		"nmae":       "typo",
+	}
+	assert.Equal(t, []string{"endpoints[1].uri", "nmae", "routes.home.methd"},
+		unknownKeys(settings, reflect.TypeOf(Settings{}), "yaml"))
+}
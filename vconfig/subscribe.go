@@ -0,0 +1,170 @@
+package vconfig
+
+import (
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// PathChangeCallback 单个配置路径变更的回调函数
+type PathChangeCallback func(oldValue, newValue interface{})
+
+// SubscribeCallback 订阅的配置路径变更的回调函数，changedItems 只包含匹配的变更项
+type SubscribeCallback func(changedItems []ConfigChangedItem)
+
+// OnChangePath 监听指定路径的配置变更，如 "server.port"
+//
+// 路径本身或其下任意子项变化时回调一次；path 指向结构体、map 等复合值时，
+// oldValue 和 newValue 为变更前后的整个复合值
+func (c *Config[T]) OnChangePath(path string, callback PathChangeCallback) {
+	if callback == nil {
+		return
+	}
+	c.OnChange(func(_ fsnotify.Event, changedItems []ConfigChangedItem) {
+		for _, item := range changedItems {
+			if item.Path == path {
+				callback(item.OldValue, item.NewValue)
+				return
+			}
+		}
+		for _, item := range changedItems {
+			if isSubPath(item.Path, path) {
+				oldValue, _ := valueAtPath(c.baseline(), path)
+				newValue, _ := valueAtPath(c.GetData(), path)
+				callback(oldValue, newValue)
+				return
+			}
+		}
+	})
+}
+
+// Subscribe 订阅匹配 pattern 的配置变更，如 "database.*"
+//
+// pattern 按点号分段，"*" 匹配任意一段；pattern 匹配变更项路径本身或其任一上级路径即视为匹配，
+// 因此 "database" 和 "database.*" 都会收到 database 下所有配置项的变更
+func (c *Config[T]) Subscribe(pattern string, callback SubscribeCallback) {
+	if callback == nil {
+		return
+	}
+	patternParts := splitPath(pattern)
+	c.OnChange(func(_ fsnotify.Event, changedItems []ConfigChangedItem) {
+		var matched []ConfigChangedItem
+		for _, item := range changedItems {
+			if matchPathPattern(patternParts, splitPath(item.Path)) {
+				matched = append(matched, item)
+			}
+		}
+		if len(matched) > 0 {
+			callback(matched)
+		}
+	})
+}
+
+// isSubPath 判断 path 是否为 parent 的子路径
+func isSubPath(path, parent string) bool {
+	if parent == "" {
+		return true
+	}
+	return strings.HasPrefix(path, parent+".") || strings.HasPrefix(path, parent+"[")
+}
+
+// splitPath 将配置路径拆分为段，数组下标作为单独的段，如 "servers[0].host" -> [servers [0] host]
+func splitPath(path string) []string {
+	var parts []string
+	for _, part := range strings.Split(path, ".") {
+		for len(part) > 0 {
+			idx := strings.Index(part[1:], "[")
+			if idx < 0 {
+				parts = append(parts, part)
+				break
+			}
+			parts = append(parts, part[:idx+1])
+			part = part[idx+1:]
+		}
+	}
+	return parts
+}
+
+// matchPathPattern 判断pattern是否匹配路径本身或其任一上级路径
+func matchPathPattern(pattern, path []string) bool {
+	if len(pattern) > len(path) {
+		return false
+	}
+	for i, p := range pattern {
+		if p != "*" && p != path[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// valueAtPath 按配置路径获取值，结构体字段使用yaml或json tag中的名称匹配
+func valueAtPath(data interface{}, path string) (interface{}, bool) {
+	val := reflect.ValueOf(data)
+	for _, part := range splitPath(path) {
+		for val.Kind() == reflect.Ptr || val.Kind() == reflect.Interface {
+			if val.IsNil() {
+				return nil, false
+			}
+			val = val.Elem()
+		}
+
+		switch val.Kind() {
+		case reflect.Struct:
+			field, ok := structFieldByPath(val, part)
+			if !ok {
+				return nil, false
+			}
+			val = field
+		case reflect.Map:
+			key := reflect.ValueOf(part)
+			if !key.Type().ConvertibleTo(val.Type().Key()) {
+				return nil, false
+			}
+			val = val.MapIndex(key.Convert(val.Type().Key()))
+			if !val.IsValid() {
+				return nil, false
+			}
+		case reflect.Slice, reflect.Array:
+			idx, err := strconv.Atoi(strings.TrimSuffix(strings.TrimPrefix(part, "["), "]"))
+			if err != nil || idx < 0 || idx >= val.Len() {
+				return nil, false
+			}
+			val = val.Index(idx)
+		default:
+			return nil, false
+		}
+	}
+
+	if !val.IsValid() || !val.CanInterface() {
+		return nil, false
+	}
+	return val.Interface(), true
+}
+
+// structFieldByPath 按照与 findConfigChanges 相同的规则查找结构体字段
+func structFieldByPath(val reflect.Value, name string) (reflect.Value, bool) {
+	t := val.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		if fieldPathName(field) == name {
+			return val.Field(i), true
+		}
+	}
+	return reflect.Value{}, false
+}
+
+// fieldPathName 返回结构体字段在配置路径中的名称，优先使用yaml tag，其次json tag，最后为字段名
+func fieldPathName(field reflect.StructField) string {
+	for _, key := range []string{"yaml", "json"} {
+		if tag := field.Tag.Get(key); tag != "" && tag != "-" {
+			return strings.Split(tag, ",")[0]
+		}
+	}
+	return field.Name
+}
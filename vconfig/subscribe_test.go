@@ -0,0 +1,113 @@
+package vconfig
+
+import (
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/constructorvirgil/virlog/test/testutils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// 测试按路径订阅配置变更
+func TestOnChangePathAndSubscribe(t *testing.T) {
+	configFile := testutils.RandomTempFilename("test_subscribe", ".yaml")
+	defer testutils.CleanTempFile(t, configFile)
+
+	cfg, err := NewConfig(newDefaultConfig(),
+		WithConfigFile[AppConfig](configFile),
+		WithConfigType[AppConfig](YAML),
+		WithDebounceTime[AppConfig](0))
+	require.NoError(t, err)
+	defer cfg.Close()
+
+	var (
+		mu          sync.Mutex
+		portChanges [][2]interface{}
+		server      [][2]interface{}
+		hostCalls   int
+		dbChanges   []ConfigChangedItem
+	)
+	cfg.OnChangePath("server.port", func(oldValue, newValue interface{}) {
+		mu.Lock()
+		defer mu.Unlock()
+		portChanges = append(portChanges, [2]interface{}{oldValue, newValue})
+	})
+	cfg.OnChangePath("server", func(oldValue, newValue interface{}) {
+		mu.Lock()
+		defer mu.Unlock()
+		server = append(server, [2]interface{}{oldValue, newValue})
+	})
+	cfg.OnChangePath("server.host", func(oldValue, newValue interface{}) {
+		mu.Lock()
+		defer mu.Unlock()
+		hostCalls++
+	})
+	cfg.Subscribe("database.*", func(changedItems []ConfigChangedItem) {
+		mu.Lock()
+		defer mu.Unlock()
+		dbChanges = append(dbChanges, changedItems...)
+	})
+
+	content, err := os.ReadFile(configFile)
+	require.NoError(t, err)
+	updated := strings.Replace(string(content), "port: 8080", "port: 9090", 1)
+	updated = strings.Replace(updated, "localhost:5432", "db:5432", 1)
+	require.NoError(t, os.WriteFile(configFile, []byte(updated), 0644))
+
+	require.Eventually(t, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return len(portChanges) > 0 && len(dbChanges) > 0
+	}, 3*time.Second, 20*time.Millisecond)
+
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Equal(t, [2]interface{}{8080, 9090}, portChanges[0])
+	assert.Equal(t, 0, hostCalls, "未变化的路径不应回调")
+	require.Len(t, dbChanges, 1)
+	assert.Equal(t, "database.dsn", dbChanges[0].Path)
+	require.Len(t, server, 1)
+	assert.Equal(t, 9090, server[0][1].(struct {
+		Host string `json:"host" yaml:"host" toml:"host"`
+		Port int    `json:"port" yaml:"port" toml:"port"`
+	}).Port)
+}
+
+// 测试路径匹配
+func TestPathPatternMatching(t *testing.T) {
+	assert.Equal(t, []string{"servers", "[0]", "host"}, splitPath("servers[0].host"))
+	assert.Equal(t, []string{"a", "b"}, splitPath("a.b"))
+	assert.Empty(t, splitPath(""))
+
+	testCases := []struct {
+		pattern string
+		path    string
+		match   bool
+	}{
+		{"database.*", "database.dsn", true},
+		{"database.*", "database.pool.size", true},
+		{"database", "database.dsn", true},
+		{"database.*", "database", false},
+		{"*.port", "server.port", true},
+		{"*.port", "server.host", false},
+		{"servers.*.host", "servers[1].host", true},
+		{"server.port", "server.portal", false},
+	}
+	for _, tc := range testCases {
+		assert.Equal(t, tc.match, matchPathPattern(splitPath(tc.pattern), splitPath(tc.path)), "%s ~ %s", tc.pattern, tc.path)
+	}
+
+	cfg := newDefaultConfig()
+	value, ok := valueAtPath(cfg, "database.max_conns")
+	assert.True(t, ok)
+	assert.Equal(t, 10, value)
+	_, ok = valueAtPath(cfg, "database.missing")
+	assert.False(t, ok)
+	value, ok = valueAtPath(map[string][]int{"ports": {80, 443}}, "ports[1]")
+	assert.True(t, ok)
+	assert.Equal(t, 443, value)
+}
@@ -0,0 +1,212 @@
+package vconfig
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	clientv3 "go.etcd.io/etcd/client/v3"
+)
+
+// etcdMaxCASRetries UpdateFunc 在ETCD中比较并交换失败时的最大重试次数
+const etcdMaxCASRetries = 5
+
+var (
+	// ErrConflict 配置在读取后被其他客户端修改，UpdateFunc 重试次数用尽或 Publish、Promote 比较版本失败
+	ErrConflict = errors.New("配置已被其他客户端修改")
+	// ErrClosed 配置已关闭
+	ErrClosed = errors.New("配置已关闭")
+	// ErrUnknownKeys 严格模式下配置文件包含不对应结构体字段的配置项
+	ErrUnknownKeys = errors.New("配置包含未知的配置项")
+	// ErrNoPendingChange 没有等待确认的配置变更
+	ErrNoPendingChange = errors.New("没有等待确认的配置变更")
+)
+
+// Validator 配置校验接口，配置结构体实现该接口时 UpdateFunc 会在保存前调用 Validate
+type Validator interface {
+	Validate() error
+}
+
+// UpdateFunc 在锁内对配置执行修改函数，校验通过后保存并触发变更回调
+//
+// fn 接收当前配置的副本，返回错误时放弃本次修改；多个 UpdateFunc 串行执行，
+// 避免 GetData + Update 读取、修改、写回之间被其他修改覆盖。
+// 使用ETCD（非前缀模式）时基于 ModRevision、使用Nacos时基于配置MD5比较并交换，
+// 期间被其他客户端修改会重新读取并再次执行 fn，多次冲突后返回 ErrConflict；
+// HTTP配置源为只读，返回 ErrReadOnlySource
+func (c *Config[T]) UpdateFunc(fn func(data *T) error) error {
+	return c.update(fn, historySourceUpdate)
+}
+
+// update 执行 UpdateFunc，source 为记录到配置历史中的来源
+func (c *Config[T]) update(fn func(data *T) error, source string) error {
+	return c.commit(source, func() (T, error) {
+		switch {
+		case c.configFile != "":
+			return c.updateFile(fn)
+		case c.etcdClient != nil:
+			return c.updateETCD(fn)
+		case c.nacosClient != nil:
+			return c.updateNacos(fn)
+		case c.httpSource != nil:
+			return c.GetData(), ErrReadOnlySource
+		default:
+			return c.GetData(), fmt.Errorf("未指定配置源")
+		}
+	})
+}
+
+// commit 串行执行 write 将配置写入配置源，成功后应用其返回的配置并触发变更回调，source 为记录到配置历史中的来源
+func (c *Config[T]) commit(source string, write func() (T, error)) error {
+	c.updateMu.Lock()
+	defer c.updateMu.Unlock()
+
+	c.closedMu.RLock()
+	closed := c.closed
+	c.closedMu.RUnlock()
+	if closed {
+		return ErrClosed
+	}
+
+	oldData := c.GetData()
+	newData, err := write()
+	if err != nil {
+		return err
+	}
+	// 主动修改的配置直接生效，等待确认的变更已被覆盖
+	c.takePending(nil)
+
+	// 保存后配置源的监听也会收到这次变更，更新对比基准和防抖时间，避免重复触发回调
+	changedItems := findConfigChanges(oldData, newData, "")
+	baseline := cloneConfig(newData)
+	c.baselineMu.Lock()
+	c.oldData = baseline
+	c.lastModTime = time.Now()
+	c.baselineMu.Unlock()
+	c.health.recordLoad()
+	c.recordHistory(source)
+	if len(changedItems) == 0 {
+		return nil
+	}
+
+	event := fsnotify.Event{Name: c.sourceKey(), Op: fsnotify.Write}
+	c.notifyChange(event, changedItems)
+	return nil
+}
+
+// applyUpdate 对配置副本执行修改函数和加载处理函数并校验
+func (c *Config[T]) applyUpdate(data T, fn func(data *T) error) (T, error) {
+	updated := cloneConfig(data)
+	if err := fn(&updated); err != nil {
+		return data, err
+	}
+	if err := applyDefaults(&updated); err != nil {
+		return data, err
+	}
+	if err := c.runOnLoad(&updated); err != nil {
+		return data, err
+	}
+	if err := validateConfig(&updated); err != nil {
+		return data, err
+	}
+	return updated, nil
+}
+
+// validateConfig 配置结构体或其指针实现了 Validator 时进行校验
+func validateConfig[T any](data *T) error {
+	var v interface{} = data
+	if validator, ok := v.(Validator); ok {
+		if err := validator.Validate(); err != nil {
+			return fmt.Errorf("配置校验失败: %w", err)
+		}
+		return nil
+	}
+	v = *data
+	if validator, ok := v.(Validator); ok {
+		if err := validator.Validate(); err != nil {
+			return fmt.Errorf("配置校验失败: %w", err)
+		}
+	}
+	return nil
+}
+
+// updateFile 修改配置并写入配置文件，写入失败时恢复原配置
+func (c *Config[T]) updateFile(fn func(data *T) error) (T, error) {
+	newData, err := c.applyUpdate(c.GetData(), fn)
+	if err != nil {
+		return c.GetData(), err
+	}
+	return c.saveFile(newData)
+}
+
+// saveFile 将配置写入配置文件，写入失败时恢复原配置
+func (c *Config[T]) saveFile(newData T) (T, error) {
+	oldData := c.GetData()
+	c.setData(newData)
+	if err := c.SaveConfig(); err != nil {
+		c.setData(oldData)
+		return oldData, err
+	}
+	return newData, nil
+}
+
+// updateETCD 修改配置并写入ETCD
+func (c *Config[T]) updateETCD(fn func(data *T) error) (T, error) {
+	if c.etcdConfig.Prefix {
+		// 前缀模式下配置分散在多个key中，直接写入
+		newData, err := c.applyUpdate(c.GetData(), fn)
+		if err != nil {
+			return c.GetData(), err
+		}
+		if err := savePrefixConfigToETCD(c.etcdClient, newData, c.configType); err != nil {
+			return c.GetData(), err
+		}
+		c.setData(newData)
+		return newData, nil
+	}
+
+	for i := 0; i < etcdMaxCASRetries; i++ {
+		resp, err := c.etcdClient.client.Get(c.etcdClient.ctx, c.etcdConfig.Key)
+		if err != nil {
+			return c.GetData(), fmt.Errorf("从ETCD获取配置失败: %w", err)
+		}
+
+		// 以ETCD中的最新配置为基础修改，key不存在时使用当前配置
+		current := cloneConfig(c.GetData())
+		var modRevision int64
+		if len(resp.Kvs) > 0 {
+			modRevision = resp.Kvs[0].ModRevision
+			var latest T
+			if err := unmarshalConfig(resp.Kvs[0].Value, &latest, c.configType); err != nil {
+				return c.GetData(), fmt.Errorf("反序列化配置失败: %w", err)
+			}
+			current = latest
+		}
+
+		newData, err := c.applyUpdate(current, fn)
+		if err != nil {
+			return c.GetData(), err
+		}
+		configBytes, err := marshalConfig(newData, c.configType)
+		if err != nil {
+			return c.GetData(), fmt.Errorf("序列化配置失败: %w", err)
+		}
+
+		txnResp, err := c.etcdClient.client.Txn(c.etcdClient.ctx).
+			If(clientv3.Compare(clientv3.ModRevision(c.etcdConfig.Key), "=", modRevision)).
+			Then(clientv3.OpPut(c.etcdConfig.Key, string(configBytes))).
+			Commit()
+		if err != nil {
+			return c.GetData(), fmt.Errorf("保存配置到ETCD失败: %w", err)
+		}
+		if txnResp.Succeeded {
+			c.etcdClient.revision.Store(txnResp.Header.Revision)
+			c.etcdClient.modRevision.Store(txnResp.Header.Revision)
+			c.etcdClient.content.Store(&configBytes)
+			c.setData(newData)
+			return newData, nil
+		}
+	}
+	return c.GetData(), ErrConflict
+}
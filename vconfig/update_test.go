@@ -0,0 +1,162 @@
+package vconfig
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/constructorvirgil/virlog/test/testutils"
+	"github.com/fsnotify/fsnotify"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// validatedConfig 实现了Validator接口的配置
+type validatedConfig struct {
+	AppConfig `yaml:",inline"`
+}
+
+func (c *validatedConfig) Validate() error {
+	if c.Server.Port <= 0 || c.Server.Port > 65535 {
+		return errors.New("端口超出范围")
+	}
+	return nil
+}
+
+// 测试UpdateFunc修改、保存配置并触发回调
+func TestUpdateFunc(t *testing.T) {
+	configFile := testutils.RandomTempFilename("test_update_func", ".yaml")
+	defer testutils.CleanTempFile(t, configFile)
+
+	cfg, err := NewConfig(newDefaultConfig(),
+		WithConfigFile[AppConfig](configFile),
+		WithConfigType[AppConfig](YAML))
+	require.NoError(t, err)
+	defer cfg.Close()
+
+	var changed []ConfigChangedItem
+	cfg.OnChange(func(e fsnotify.Event, changedItems []ConfigChangedItem) {
+		changed = changedItems
+	})
+
+	require.NoError(t, cfg.UpdateFunc(func(data *AppConfig) error {
+		data.Server.Port = 9000
+		data.Database.DSN = "postgres://updated"
+		return nil
+	}))
+	assert.Equal(t, 9000, cfg.GetData().Server.Port)
+	assert.Len(t, changed, 2, "应同步触发回调")
+
+	// 修改函数返回错误时放弃修改
+	err = cfg.UpdateFunc(func(data *AppConfig) error {
+		data.Server.Port = 1
+		return errors.New("abort")
+	})
+	assert.EqualError(t, err, "abort")
+	assert.Equal(t, 9000, cfg.GetData().Server.Port)
+
+	// 已保存到文件
+	reloaded, err := NewConfig(AppConfig{},
+		WithConfigFile[AppConfig](configFile),
+		WithConfigType[AppConfig](YAML))
+	require.NoError(t, err)
+	defer reloaded.Close()
+	assert.Equal(t, 9000, reloaded.GetData().Server.Port)
+	assert.Equal(t, "postgres://updated", reloaded.GetData().Database.DSN)
+}
+
+// 测试并发UpdateFunc不会丢失修改
+func TestUpdateFuncConcurrent(t *testing.T) {
+	configFile := testutils.RandomTempFilename("test_update_func_concurrent", ".yaml")
+	defer testutils.CleanTempFile(t, configFile)
+
+	cfg, err := NewConfig(newDefaultConfig(),
+		WithConfigFile[AppConfig](configFile),
+		WithConfigType[AppConfig](YAML))
+	require.NoError(t, err)
+	defer cfg.Close()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			assert.NoError(t, cfg.UpdateFunc(func(data *AppConfig) error {
+				data.Database.MaxConns++
+				return nil
+			}))
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, 30, cfg.GetData().Database.MaxConns)
+}
+
+// 测试文件监听重新加载配置期间执行UpdateFunc，配合 -race 检查对比基准和防抖时间的并发访问
+func TestUpdateFuncWhileWatching(t *testing.T) {
+	configFile := filepath.Join(t.TempDir(), "app.yaml")
+	require.NoError(t, os.WriteFile(configFile, []byte("server:\n  port: 7000\n"), 0644))
+	cfg, err := NewConfig(newDefaultConfig(),
+		WithConfigFile[AppConfig](configFile),
+		WithDebounceTime[AppConfig](time.Millisecond))
+	require.NoError(t, err)
+	defer cfg.Close()
+
+	changes := make(chan struct{}, 100)
+	cfg.OnChange(func(fsnotify.Event, []ConfigChangedItem) {
+		select {
+		case changes <- struct{}{}:
+		default:
+		}
+	})
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 5; i++ {
+			content := fmt.Sprintf("server:\n  port: %d\n", 7100+i)
+			assert.NoError(t, os.WriteFile(configFile, []byte(content), 0644))
+			time.Sleep(120 * time.Millisecond)
+		}
+	}()
+	for i := 0; i < 20; i++ {
+		assert.NoError(t, cfg.UpdateFunc(func(data *AppConfig) error {
+			data.Database.DSN = fmt.Sprintf("postgres://update-%d", i)
+			return nil
+		}))
+		time.Sleep(30 * time.Millisecond)
+	}
+	wg.Wait()
+
+	select {
+	case <-changes:
+	case <-time.After(3 * time.Second):
+		t.Fatal("等待配置变更超时")
+	}
+}
+
+// 测试UpdateFunc在保存前校验配置
+func TestUpdateFuncValidate(t *testing.T) {
+	configFile := testutils.RandomTempFilename("test_update_func_validate", ".yaml")
+	defer testutils.CleanTempFile(t, configFile)
+
+	cfg, err := NewConfig(validatedConfig{AppConfig: newDefaultConfig()},
+		WithConfigFile[validatedConfig](configFile),
+		WithConfigType[validatedConfig](YAML))
+	require.NoError(t, err)
+	defer cfg.Close()
+
+	err = cfg.UpdateFunc(func(data *validatedConfig) error {
+		data.Server.Port = 70000
+		return nil
+	})
+	assert.ErrorContains(t, err, "端口超出范围")
+	assert.Equal(t, 8080, cfg.GetData().Server.Port)
+
+	cfg.Close()
+	assert.ErrorIs(t, cfg.UpdateFunc(func(*validatedConfig) error { return nil }), ErrClosed)
+}
@@ -3,17 +3,20 @@ package vconfig
 import (
 	"bytes"
 	"encoding/json"
-	"errors"
+	"flag"
 	"fmt"
 	"os"
 	"path/filepath"
+	"reflect"
 	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/BurntSushi/toml"
 	"github.com/fsnotify/fsnotify"
+	"github.com/spf13/pflag"
 	"github.com/spf13/viper"
 	"gopkg.in/yaml.v3"
 )
@@ -28,6 +31,10 @@ const (
 	YAML ConfigType = "yaml"
 	// TOML toml格式配置文件
 	TOML ConfigType = "toml"
+	// INI ini格式配置文件，嵌套配置写为 [app.server] 形式的节，列表写为逗号分隔的值
+	INI ConfigType = "ini"
+	// HCL hcl格式配置文件（HCL1），结构体按 yaml tag 命名
+	HCL ConfigType = "hcl"
 )
 
 // ConfigChangedItem 配置变更项
@@ -59,22 +66,103 @@ type Config[T any] struct {
 	enableEnv bool
 	// 环境变量前缀
 	envPrefix string
+	// 通过 WithDotenv 指定的 .env 文件
+	dotenvFiles []string
+	// 从 .env 文件读取的环境变量，优先级低于进程的环境变量
+	dotenv map[string]string
+	// 命令行参数集合
+	flagSet *pflag.FlagSet
+	// 标准库命令行参数集合
+	goFlagSet *flag.FlagSet
+	// 配置文件加解密
+	crypto CryptoProvider
+	// 保存配置前是否备份原配置文件
+	backup bool
+	// 被环境变量或命令行参数覆盖的配置项
+	overrides overrides
+	// 保存配置时是否写入环境变量和命令行参数的覆盖值
+	saveOverrides bool
+	// 配置文件包含不对应结构体字段的配置项时是否加载失败
+	strictUnmarshal bool
+	// 配置文件监听器
+	watcher *fsnotify.Watcher
+	// 被监听的文件及其符号链接解析后的实际路径
+	watchedFiles map[string]string
+	// 最近一次自身写入的配置文件内容，用于忽略自身保存产生的事件
+	written []byte
+	// 保护watchedFiles和written的互斥锁
+	watchMu sync.Mutex
+	// 通过 $include 引入的配置文件以及当前环境的配置文件
+	includes []string
+	// 最近一次加载配置文件时各层的配置，保存时用于区分属于当前配置文件的配置项
+	layers *fileLayers
+	// 环境名称，如 production，用于加载 app.production.yaml
+	profile string
 	// 配置文件变更回调函数列表
 	changeCallbacks []OnConfigChangeCallback
+	// 收到待确认变更时的回调函数列表
+	pendingCallbacks []OnConfigChangeCallback
+	// 监听配置期间发生错误时的回调函数列表
+	errorCallbacks []OnConfigErrorCallback
 	// 保护回调函数列表的互斥锁
 	callbackMu sync.RWMutex
+	// 正在执行的回调函数数量，回调函数中调用 Close 时不等待后台goroutine退出
+	callbacksRunning atomic.Int32
 	// 上次修改时间，用于防止短时间内重复触发回调
 	lastModTime time.Time
+	// 保护oldData和lastModTime的互斥锁
+	baselineMu sync.Mutex
 	// 防抖时间
 	debounceTime time.Duration
 	// 是否已关闭
 	closed bool
 	// 保护closed字段的互斥锁
 	closedMu sync.RWMutex
+	// 保护data的读写锁，重新连接配置源和轮询HTTP配置的goroutine会在后台更新配置
+	dataMu sync.RWMutex
+	// 串行执行 UpdateFunc 的互斥锁
+	updateMu sync.Mutex
+	// 等待确认配置变更的超时时间，大于0时配置源中的变更需要确认后才应用
+	approvalTimeout time.Duration
+	// 等待确认的配置变更
+	pending *pendingChange
+	// 保护pending的互斥锁
+	pendingMu sync.Mutex
+	// 保留的配置历史版本数
+	historySize int
+	// 配置历史，按版本号从旧到新排列
+	history []ConfigVersion[T]
+	// 最新的历史版本号
+	historyVersion int
+	// 保护配置历史的互斥锁
+	historyMu sync.Mutex
+	// 配置文件迁移到最新版本后是否写回
+	rewriteMigrated bool
+	// 每次加载配置后执行的处理函数
+	onLoad []func(data *T) error
+	// 配置加载和配置源连接的健康状态
+	health healthState
+	// 最近一次读取或写入的配置文件内容（解密后）及其修改时间
+	raw        []byte
+	rawModTime time.Time
+	// 保护raw和rawModTime的互斥锁
+	rawMu sync.RWMutex
 	// ETCD配置
 	etcdConfig *ETCDConfig
 	// ETCD客户端
 	etcdClient *etcdClient
+	// Nacos配置
+	nacosConfig *NacosConfig
+	// Nacos客户端
+	nacosClient *nacosClient
+	// HTTP配置源配置
+	httpConfig *HTTPSourceConfig
+	// HTTP配置源
+	httpSource *httpSource
+	// 远程配置源的本地缓存
+	localCache *localCache
+	// 定期检查配置漂移的设置
+	driftCheck *driftCheck
 }
 
 // OnChange 添加配置文件变更回调函数
@@ -94,96 +182,43 @@ func (c *Config[T]) triggerCallbacks(e fsnotify.Event) {
 	}
 	c.closedMu.RUnlock()
 
+	// 查找配置变更项，多个被监听的文件同时变化（如ConfigMap切换）时后续的重新加载没有变化，不触发回调
+	data := c.GetData()
+	c.baselineMu.Lock()
+	changedItems := findConfigChanges(c.oldData, data, "")
+	if len(changedItems) == 0 {
+		c.baselineMu.Unlock()
+		return
+	}
+
 	now := time.Now()
-	// 防抖：如果与上次修改时间间隔小于设定的防抖时间，则忽略
+	// 防抖：如果与上次修改时间间隔小于设定的防抖时间，则忽略，
+	// 此时保留对比基准，被忽略的变更会在下次触发时一并通知
 	if now.Sub(c.lastModTime) < c.debounceTime {
+		c.baselineMu.Unlock()
 		return
 	}
 	c.lastModTime = now
+	// 以本次通知的配置作为下次对比的基准
+	c.oldData = cloneConfig(data)
+	c.baselineMu.Unlock()
 
-	// 查找配置变更项
-	changedItems := findConfigChanges(c.oldData, c.data, "")
-
-	c.callbackMu.RLock()
-	defer c.callbackMu.RUnlock()
-	for _, callback := range c.changeCallbacks {
-		if callback != nil {
-			callback(e, changedItems)
-		}
-	}
+	c.notifyChange(e, changedItems)
 }
 
-// 克隆配置数据
-func cloneConfig[T any](src T) T {
-	var dst T
-	data, err := json.Marshal(src)
-	if err != nil {
-		return dst
-	}
-	json.Unmarshal(data, &dst)
-	return dst
+// setBaseline 以 data 作为下次查找配置变更的对比基准
+func (c *Config[T]) setBaseline(data T) {
+	baseline := cloneConfig(data)
+	c.baselineMu.Lock()
+	c.oldData = baseline
+	c.baselineMu.Unlock()
 }
 
-// 重新加载配置
-func (c *Config[T]) reload() error {
-	// 检查配置是否已关闭
-	c.closedMu.RLock()
-	if c.closed {
-		c.closedMu.RUnlock()
-		return errors.New("配置已关闭")
-	}
-	c.closedMu.RUnlock()
-
-	// 确保文件存在
-	if _, err := os.Stat(c.configFile); os.IsNotExist(err) {
-		return fmt.Errorf("配置文件不存在: %w", err)
-	}
-
-	// 在重载前保存当前配置用于比较
-	c.oldData = cloneConfig(c.data)
-
-	// 重新读取配置文件内容
-	fileBytes, err := os.ReadFile(c.configFile)
-	if err != nil {
-		return fmt.Errorf("读取配置文件失败: %w", err)
-	}
-
-	// 创建新的viper实例读取配置
-	v := viper.New()
-	v.SetConfigType(string(c.configType))
-
-	// 从字节流读取配置
-	if err := v.ReadConfig(bytes.NewBuffer(fileBytes)); err != nil {
-		return fmt.Errorf("解析配置文件失败: %w", err)
-	}
-
-	// 应用环境变量配置
-	if c.enableEnv {
-		v.SetEnvPrefix(c.envPrefix)
-		v.AutomaticEnv()
-		v.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
-
-		// 绑定所有键到环境变量
-		for _, key := range v.AllKeys() {
-			bindKey := strings.ToUpper(strings.ReplaceAll(key, ".", "_"))
-			if err := v.BindEnv(key, c.envPrefix+"_"+bindKey); err != nil {
-				return fmt.Errorf("绑定环境变量失败: %w", err)
-			}
-		}
-	}
-
-	// 将读取的配置应用到当前的viper实例
-	allSettings := v.AllSettings()
-	for k, val := range allSettings {
-		c.v.Set(k, val)
-	}
-
-	// 将配置解析到结构体
-	if err := c.v.Unmarshal(&c.data); err != nil {
-		return fmt.Errorf("解析配置到结构体失败: %w", err)
-	}
-
-	return nil
+// baseline 返回查找配置变更的对比基准
+func (c *Config[T]) baseline() T {
+	c.baselineMu.Lock()
+	defer c.baselineMu.Unlock()
+	return c.oldData
 }
 
 // 监听配置文件变更
@@ -191,9 +226,10 @@ func (c *Config[T]) watchConfig() {
 	// 创建文件监听器
 	watcher, err := fsnotify.NewWatcher()
 	if err != nil {
-		fmt.Printf("创建文件监听器失败: %v\n", err)
+		getInternalLogger().Errorw("创建文件监听器失败", "file", c.configFile, "error", err)
 		return
 	}
+	c.watcher = watcher
 
 	// 在后台运行监听
 	go func() {
@@ -203,7 +239,7 @@ func (c *Config[T]) watchConfig() {
 				if !ok {
 					return
 				}
-				if event.Op&fsnotify.Write == fsnotify.Write {
+				if filename, changed := c.changedFile(event); changed {
 					// 检查配置是否已关闭
 					c.closedMu.RLock()
 					if c.closed {
@@ -216,27 +252,45 @@ func (c *Config[T]) watchConfig() {
 					time.Sleep(100 * time.Millisecond)
 
 					// 重新加载配置
+					applied := c.GetData()
 					if err := c.loadFromFile(); err != nil {
-						fmt.Printf("配置文件变更后重新加载失败: %v\n", err)
+						getInternalLogger().Errorw("配置文件变更后重新加载失败", "file", c.configFile, "error", err)
+						c.recordLoadError(err)
 						continue
 					}
-
-					// 触发回调
-					c.triggerCallbacks(event)
+					c.health.recordLoad()
+					// 被引入的文件可能发生变化
+					c.watchIncludes()
+
+					// 开启变更确认时恢复当前配置，确认后再应用
+					e := fsnotify.Event{Name: filename, Op: event.Op}
+					loaded := c.GetData()
+					c.setData(applied)
+					if c.stageChange(loaded, e, func() { c.applyFileData(loaded, e) }) {
+						continue
+					}
+					c.applyFileData(loaded, e)
 				}
 			case err, ok := <-watcher.Errors:
 				if !ok {
 					return
 				}
-				fmt.Printf("文件监听错误: %v\n", err)
+				getInternalLogger().Errorw("文件监听错误", "file", c.configFile, "error", err)
+				c.health.recordSource(err)
 			}
 		}
 	}()
 
 	// 开始监听配置文件
-	if err := watcher.Add(c.configFile); err != nil {
-		fmt.Printf("添加文件监听失败: %v\n", err)
-	}
+	c.watchFile(c.configFile)
+	c.watchIncludes()
+}
+
+// applyFileData 应用重新加载的配置文件并触发回调
+func (c *Config[T]) applyFileData(data T, e fsnotify.Event) {
+	c.setData(data)
+	c.recordHistory(c.sourceName())
+	c.triggerCallbacks(e)
 }
 
 // NewConfig 创建一个新的配置实例
@@ -247,6 +301,7 @@ func NewConfig[T any](defaultConfig T, options ...ConfigOption[T]) (*Config[T],
 		v:            viper.New(),
 		configType:   YAML,                   // 默认YAML格式
 		debounceTime: 500 * time.Millisecond, // 默认防抖时间500ms
+		historySize:  defaultHistorySize,
 		lastModTime:  time.Time{},
 	}
 
@@ -255,26 +310,66 @@ func NewConfig[T any](defaultConfig T, options ...ConfigOption[T]) (*Config[T],
 		option(config)
 	}
 
+	// 使用default tag填充默认配置中的零值字段
+	if err := applyDefaults(&config.data); err != nil {
+		return nil, err
+	}
+
+	if err := config.loadDotenv(); err != nil {
+		return nil, err
+	}
+
 	// 检查配置源
 	if config.configFile != "" && config.etcdConfig != nil {
 		return nil, fmt.Errorf("不能同时使用配置文件和ETCD")
 	}
 
-	if config.configFile == "" && config.etcdConfig == nil {
-		return nil, fmt.Errorf("必须指定配置文件或ETCD配置")
+	if config.nacosConfig != nil && (config.configFile != "" || config.etcdConfig != nil) {
+		return nil, fmt.Errorf("不能同时使用Nacos和配置文件或ETCD")
+	}
+
+	if config.httpConfig != nil && (config.configFile != "" || config.etcdConfig != nil || config.nacosConfig != nil) {
+		return nil, fmt.Errorf("不能同时使用HTTP配置源和其他配置源")
+	}
+
+	if config.configFile == "" && config.etcdConfig == nil && config.nacosConfig == nil && config.httpConfig == nil {
+		return nil, fmt.Errorf("必须指定配置文件、ETCD、Nacos或HTTP配置源")
 	}
 
 	// 根据配置源初始化
-	if config.configFile != "" {
+	var err error
+	switch {
+	case config.configFile != "":
 		// 使用配置文件
 		if err := config.initWithFile(); err != nil {
 			return nil, err
 		}
-	} else {
+	case config.etcdConfig != nil:
 		// 使用ETCD
-		if err := config.initWithETCD(); err != nil {
-			return nil, err
+		err = config.initWithETCD()
+	case config.nacosConfig != nil:
+		// 使用Nacos
+		err = config.initWithNacos()
+	default:
+		// 使用HTTP配置源
+		err = config.initWithHTTP()
+	}
+	if err != nil {
+		// 远程配置源不可用时使用本地缓存
+		cacheTime, cacheErr := config.initWithLocalCache(err)
+		if cacheErr != nil {
+			return nil, cacheErr
 		}
+		config.health.recordLoad()
+		config.health.recordStale(cacheTime, err)
+		config.reconnectSource()
+	} else {
+		config.health.recordLoad()
+		config.saveLocalCache()
+	}
+	config.recordHistory(config.sourceName())
+	if config.driftCheck != nil {
+		config.watchDrift()
 	}
 
 	return config, nil
@@ -301,6 +396,10 @@ func (c *Config[T]) initWithFile() error {
 				c.configType = YAML
 			case "toml":
 				c.configType = TOML
+			case "ini":
+				c.configType = INI
+			case "hcl":
+				c.configType = HCL
 			default:
 				return fmt.Errorf("不支持的配置文件类型: %s", ext)
 			}
@@ -325,45 +424,26 @@ func (c *Config[T]) initWithFile() error {
 	}
 
 	// 首先将默认配置加载到viper中
-	if err := c.bindStruct(c.data); err != nil {
+	if err := c.bindStruct(c.GetData()); err != nil {
 		return fmt.Errorf("绑定默认配置失败: %w", err)
 	}
 
-	// 设置环境变量覆盖
-	if c.enableEnv {
-		// 获取所有配置键
-		allKeys := c.v.AllKeys()
-		for _, key := range allKeys {
-			// 构造环境变量名
-			envKey := fmt.Sprintf("%s_%s", c.envPrefix, strings.ToUpper(strings.ReplaceAll(key, ".", "_")))
-			// 检查环境变量是否存在
-			if envVal := os.Getenv(envKey); envVal != "" {
-				// 根据配置值的类型进行转换
-				switch c.v.Get(key).(type) {
-				case int, int32, int64:
-					if val, err := strconv.ParseInt(envVal, 10, 64); err == nil {
-						c.v.Set(key, val)
-					}
-				case float32, float64:
-					if val, err := strconv.ParseFloat(envVal, 64); err == nil {
-						c.v.Set(key, val)
-					}
-				case bool:
-					if val, err := strconv.ParseBool(envVal); err == nil {
-						c.v.Set(key, val)
-					}
-				default:
-					c.v.Set(key, envVal)
-				}
-			}
-		}
-	}
+	// 应用环境变量和命令行参数覆盖
+	c.overrides = c.applyOverrides(c.v)
 
 	// 如果配置文件不存在，则创建
 	if !configExists {
-		if err := c.v.WriteConfigAs(c.configFile); err != nil {
+		if err := c.writeSettings(); err != nil {
 			return fmt.Errorf("创建默认配置文件失败: %w", err)
 		}
+		// 环境配置文件已存在时仍需叠加
+		if c.profileFile() != "" {
+			if err := c.loadFromFile(); err != nil {
+				return err
+			}
+		} else if err := c.decodeSettings(); err != nil {
+			return err
+		}
 	} else {
 		// 配置文件存在，加载已有配置
 		if err := c.loadFromFile(); err != nil {
@@ -371,10 +451,8 @@ func (c *Config[T]) initWithFile() error {
 		}
 	}
 
-	// 将配置解析到结构体
-	if err := c.v.Unmarshal(&c.data); err != nil {
-		return fmt.Errorf("解析配置到结构体失败: %w", err)
-	}
+	// 以初始化加载的配置作为变更对比的基准
+	c.setBaseline(c.GetData())
 
 	// 监听配置文件变更
 	c.watchConfig()
@@ -396,14 +474,19 @@ func (c *Config[T]) initWithETCD() error {
 	if err != nil {
 		return fmt.Errorf("从ETCD加载配置失败: %w", err)
 	}
+	if err := applyDefaults(&c.data); err != nil {
+		return err
+	}
 
-	// 如果配置不存在，则保存默认配置到ETCD
+	// 如果配置不存在，则保存默认配置到ETCD，其他实例先写入时使用其写入的配置
 	if !exists {
-		err := saveConfigToETCD(c.etcdClient, c.data, c.configType)
-		if err != nil {
+		if err := bootstrapETCD(c.etcdClient, &c.data, c.configType); err != nil {
 			return fmt.Errorf("保存默认配置到ETCD失败: %w", err)
 		}
 	}
+	if err := c.runOnLoad(&c.data); err != nil {
+		return err
+	}
 
 	// 监听ETCD配置变更
 	c.watchETCD()
@@ -413,7 +496,12 @@ func (c *Config[T]) initWithETCD() error {
 
 // watchETCD 监听ETCD配置变更
 func (c *Config[T]) watchETCD() {
-	c.etcdClient.watch(func(data []byte) {
+	if c.etcdConfig.Prefix {
+		c.watchETCDPrefix()
+		return
+	}
+
+	c.etcdClient.watch(c.health.recordSource, func(data []byte) {
 		// 检查配置是否已关闭
 		c.closedMu.RLock()
 		if c.closed {
@@ -422,81 +510,225 @@ func (c *Config[T]) watchETCD() {
 		}
 		c.closedMu.RUnlock()
 
-		// 保存旧配置
-		c.oldData = cloneConfig(c.data)
+		// 根据配置类型解析新配置，未知类型使用 YAML
+		configType := c.configType
+		switch configType {
+		case JSON, YAML, TOML, INI, HCL:
+		default:
+			configType = YAML
+		}
+		var newData T
+		if err := unmarshalConfig(data, &newData, configType); err != nil {
+			getInternalLogger().Errorw("解析ETCD配置失败", "key", c.etcdConfig.Key, "config_type", c.configType, "error", err)
+			c.recordLoadError(err)
+			return
+		}
 
-		// 根据配置类型解析新配置
-		var (
-			newData T
-			err     error
-		)
+		// 解析成功后保存旧配置，解析失败时保留对比基准
+		c.setBaseline(c.GetData())
+		c.applyRemoteData(newData, c.etcdConfig.Key)
+	})
+}
 
-		switch c.configType {
-		case JSON:
-			err = json.Unmarshal(data, &newData)
-		case YAML:
-			err = yaml.Unmarshal(data, &newData)
-		case TOML:
-			err = toml.Unmarshal(data, &newData)
-		default: // 默认使用 YAML
-			err = yaml.Unmarshal(data, &newData)
+// watchETCDPrefix 前缀模式下监听ETCD配置变更，任一配置项变化都会重新组装整个配置
+func (c *Config[T]) watchETCDPrefix() {
+	c.etcdClient.watchPrefix(c.health.recordSource, func(key string) {
+		// 检查配置是否已关闭
+		c.closedMu.RLock()
+		if c.closed {
+			c.closedMu.RUnlock()
+			return
 		}
+		c.closedMu.RUnlock()
 
-		if err != nil {
-			fmt.Printf("解析ETCD配置失败: configType=%s, data=%v, err=%v\n", c.configType, string(data), err)
+		newData := cloneConfig(c.GetData())
+		if _, err := loadPrefixConfigFromETCD(c.etcdClient, &newData, c.configType); err != nil {
+			getInternalLogger().Errorw("解析ETCD配置失败", "key", key, "error", err)
+			c.recordLoadError(err)
 			return
 		}
 
-		// 更新配置
-		c.data = newData
+		// 解析成功后保存旧配置，解析失败时保留对比基准
+		c.setBaseline(c.GetData())
+		c.applyRemoteData(newData, key)
+	})
+}
 
-		// 查找配置变更项
-		changedItems := findConfigChanges(c.oldData, c.data, "")
+// applyRemoteData 更新配置数据并触发回调
+func (c *Config[T]) applyRemoteData(newData T, eventName string) {
+	// ETCD中缺失的字段使用default tag填充
+	if err := applyDefaults(&newData); err != nil {
+		getInternalLogger().Errorw("填充默认配置失败", "key", eventName, "error", err)
+		c.recordLoadError(err)
+		return
+	}
+	if err := c.runOnLoad(&newData); err != nil {
+		getInternalLogger().Errorw("配置加载处理失败，忽略本次变更", "key", eventName, "error", err)
+		c.recordLoadError(err)
+		return
+	}
+	c.health.recordLoad()
 
-		// 触发回调
-		c.callbackMu.RLock()
-		defer c.callbackMu.RUnlock()
-		for _, callback := range c.changeCallbacks {
-			if callback != nil {
-				callback(fsnotify.Event{
-					Name: c.etcdConfig.Key,
-					Op:   fsnotify.Write,
-				}, changedItems)
-			}
+	e := fsnotify.Event{Name: eventName, Op: fsnotify.Write}
+	if c.stageChange(newData, e, func() { c.commitRemoteData(newData, e) }) {
+		return
+	}
+	c.commitRemoteData(newData, e)
+}
+
+// commitRemoteData 更新配置数据并触发回调
+func (c *Config[T]) commitRemoteData(newData T, e fsnotify.Event) {
+	// 开启变更确认时，确认前配置可能被 UpdateFunc 修改，以应用时的配置为对比基准
+	if c.approvalTimeout > 0 {
+		c.setBaseline(c.GetData())
+	}
+
+	// 更新配置
+	c.setData(newData)
+	c.saveLocalCache()
+
+	// 查找配置变更项，没有变化时（如 UpdateFunc 写入后收到的自身变更）不触发回调
+	changedItems := findConfigChanges(c.baseline(), newData, "")
+	if len(changedItems) == 0 {
+		return
+	}
+	c.recordHistory(c.sourceName())
+
+	// 触发回调
+	c.notifyChange(e, changedItems)
+}
+
+// runOnLoad 依次执行 WithOnLoad 注册的处理函数
+func (c *Config[T]) runOnLoad(data *T) error {
+	for _, fn := range c.onLoad {
+		if err := fn(data); err != nil {
+			return fmt.Errorf("配置加载处理失败: %w", err)
 		}
-	})
+	}
+	return nil
 }
 
 // loadFromFile 从文件加载配置
 func (c *Config[T]) loadFromFile() error {
-	fileBytes, err := os.ReadFile(c.configFile)
+	raw, err := c.readConfigFile()
 	if err != nil {
-		return fmt.Errorf("读取配置文件失败: %w", err)
+		return err
+	}
+	settings, layers, migratedFrom, err := c.loadSettings(raw)
+	if err != nil {
+		return err
 	}
 
-	// 创建临时viper实例读取配置
-	tempViper := viper.New()
-	tempViper.SetConfigType(string(c.configType))
-
-	// 从字节流读取配置
-	if err := tempViper.ReadConfig(bytes.NewBuffer(fileBytes)); err != nil {
-		return fmt.Errorf("解析配置文件失败: %w", err)
+	// 在当前viper配置的副本上合并和解析，全部成功后才替换当前的配置，
+	// 写入到一半的文件等无效内容不会影响当前的viper配置和结构体
+	v := viper.New()
+	for k, val := range c.v.AllSettings() {
+		v.Set(k, val)
+	}
+	for k, val := range settings {
+		v.Set(k, val)
+	}
+	// 环境变量和命令行参数的优先级高于配置文件
+	applied := c.applyOverrides(v)
+	data, err := c.decodeViper(v)
+	if err != nil {
+		return err
 	}
 
-	// 将读取的配置应用到当前的viper实例
-	allSettings := tempViper.AllSettings()
-	for k, val := range allSettings {
+	for k, val := range v.AllSettings() {
 		c.v.Set(k, val)
 	}
-
-	// 将配置解析到结构体
-	if err := c.v.Unmarshal(&c.data); err != nil {
-		return fmt.Errorf("解析配置到结构体失败: %w", err)
+	c.overrides = applied
+	c.includes = layers.files
+	c.layers = layers
+	c.setData(data)
+	c.recordRaw(raw)
+	if migratedFrom >= 0 {
+		getInternalLogger().Infow("配置文件已迁移", "file", c.configFile, "from", migratedFrom)
+		if c.rewriteMigrated {
+			c.saveMigrated(migratedFrom)
+		}
 	}
+	return nil
+}
 
+// decodeSettings 将viper中的配置解析到结构体，填充默认值并执行加载处理函数，
+// 失败时保留当前配置
+func (c *Config[T]) decodeSettings() error {
+	data, err := c.decodeViper(c.v)
+	if err != nil {
+		return err
+	}
+	c.setData(data)
 	return nil
 }
 
+// decodeViper 以当前配置为基础解析 v 中的配置，填充默认值并执行加载处理函数
+func (c *Config[T]) decodeViper(v *viper.Viper) (T, error) {
+	data := cloneConfig(c.GetData())
+	if c.strictUnmarshal {
+		if keys := unknownKeys(v.AllSettings(), reflect.TypeOf(data), structTagName(c.configType)); len(keys) > 0 {
+			return data, fmt.Errorf("%w: %s", ErrUnknownKeys, strings.Join(keys, ", "))
+		}
+	}
+	if err := v.Unmarshal(&data, viperDecodeHook()); err != nil {
+		return data, fmt.Errorf("解析配置到结构体失败: %w", err)
+	}
+	if err := applyDefaults(&data); err != nil {
+		return data, err
+	}
+	if err := c.runOnLoad(&data); err != nil {
+		return data, err
+	}
+	return data, nil
+}
+
+// applyOverrides 按 环境变量 < 命令行参数 的顺序覆盖viper中的配置，返回被覆盖的配置项
+func (c *Config[T]) applyOverrides(v *viper.Viper) overrides {
+	applied := overrides{}
+	if c.enableEnv {
+		c.applyEnvOverrides(v, applied)
+	}
+	c.applyFlagOverrides(v, applied)
+	return applied
+}
+
+// applyEnvOverrides 使用环境变量覆盖配置
+func (c *Config[T]) applyEnvOverrides(v *viper.Viper, applied overrides) {
+	// 获取所有配置键
+	allKeys := v.AllKeys()
+	for _, key := range allKeys {
+		// 构造环境变量名
+		envKey := EnvVarName(c.envPrefix, key)
+		// 检查环境变量是否存在
+		if envVal := c.getenv(envKey); envVal != "" {
+			base := v.Get(key)
+			c.setFromString(v, key, envVal)
+			applied.record(key, OriginEnv, base, v.Get(key))
+		}
+	}
+}
+
+// setFromString 根据配置值的当前类型转换字符串后写入viper
+func (c *Config[T]) setFromString(v *viper.Viper, key, value string) {
+	switch v.Get(key).(type) {
+	case int, int32, int64:
+		if val, err := strconv.ParseInt(value, 10, 64); err == nil {
+			v.Set(key, val)
+		}
+	case float32, float64:
+		if val, err := strconv.ParseFloat(value, 64); err == nil {
+			v.Set(key, val)
+		}
+	case bool:
+		if val, err := strconv.ParseBool(value); err == nil {
+			v.Set(key, val)
+		}
+	default:
+		v.Set(key, value)
+	}
+}
+
 // bindStruct 将结构体绑定到配置
 func (c *Config[T]) bindStruct(data T) error {
 	// 根据配置类型选择正确的序列化方式
@@ -514,6 +746,8 @@ func (c *Config[T]) bindStruct(data T) error {
 		var buf bytes.Buffer
 		err = toml.NewEncoder(&buf).Encode(data)
 		configBytes = buf.Bytes()
+	case INI, HCL:
+		configBytes, err = marshalConfig(data, c.configType)
 	default:
 		return fmt.Errorf("不支持的配置类型: %s", c.configType)
 	}
@@ -522,17 +756,16 @@ func (c *Config[T]) bindStruct(data T) error {
 		return fmt.Errorf("序列化配置失败: %w", err)
 	}
 
-	// 创建临时的 viper 实例
-	tempViper := viper.New()
-	tempViper.SetConfigType(string(c.configType))
-
 	// 从序列化数据读取
-	if err := tempViper.ReadConfig(bytes.NewBuffer(configBytes)); err != nil {
+	settings, err := readSettingsBytes(configBytes, c.configType)
+	if err != nil {
 		return fmt.Errorf("读取配置失败: %w", err)
 	}
 
+	// time.Duration 等类型按字符串保存
+	normalizeSettings(settings, data, structTagName(c.configType))
+
 	// 获取所有设置并应用到主 viper 实例
-	settings := tempViper.AllSettings()
 	for k, v := range settings {
 		c.v.Set(k, v)
 	}
@@ -541,36 +774,150 @@ func (c *Config[T]) bindStruct(data T) error {
 }
 
 // SaveConfig 保存配置到文件
+//
+// 来自环境变量和命令行参数的值不会写入配置文件，这些配置项保留覆盖前的值，
+// 覆盖后又通过 Update/UpdateFunc 修改的值会写入；使用 WithSaveOverrides 时写入全部当前值。
+// 来自环境配置文件的配置项保留基础配置中的值，修改后的值写入环境配置文件；
+// 配置文件中的 $include 指令被保留，与被引入的配置相同的配置项不写入
 func (c *Config[T]) SaveConfig() error {
 	// 先将当前结构体绑定到viper
-	if err := c.bindStruct(c.data); err != nil {
+	if err := c.bindStruct(c.GetData()); err != nil {
 		return fmt.Errorf("绑定结构体到配置失败: %w", err)
 	}
+	data, err := c.persistentData()
+	if err != nil {
+		return err
+	}
+	// time.Duration 等类型按字符串保存
+	var payload interface{} = data
+	if settings, ok, err := typedSettings(data, c.configType); err != nil {
+		return fmt.Errorf("序列化配置失败: %w", err)
+	} else if ok {
+		payload = settings
+	}
+
+	// 使用环境配置文件时，来自环境配置文件的配置项不写入当前配置文件
+	var profileUpdates map[string]interface{}
+	if c.layers.active() && c.configType != YAML {
+		settings, err := structSettings(payload, c.configType)
+		if err != nil {
+			return fmt.Errorf("序列化配置失败: %w", err)
+		}
+		profileUpdates = c.layers.split(settings)
+		payload = settings
+	}
 
 	// 根据配置类型选择正确的写入方式
-	var err error
+	var content []byte
 	switch c.configType {
 	case YAML:
-		err = c.v.WriteConfigAs(c.configFile)
+		settings := c.persistentSettings().AllSettings()
+		profileUpdates = c.layers.split(settings)
+		content, err = c.marshalYAMLPreserved(settings)
+		if err != nil {
+			return err
+		}
 	case JSON:
-		jsonBytes, e := json.MarshalIndent(c.data, "", "  ")
-		if e != nil {
-			return fmt.Errorf("序列化JSON失败: %w", e)
+		content, err = json.MarshalIndent(payload, "", "  ")
+		if err != nil {
+			return fmt.Errorf("序列化JSON失败: %w", err)
 		}
-		err = os.WriteFile(c.configFile, jsonBytes, 0644)
 	case TOML:
 		// 使用专门的TOML编码器
 		var buf bytes.Buffer
-		err = toml.NewEncoder(&buf).Encode(c.data)
-		err = os.WriteFile(c.configFile, buf.Bytes(), 0644)
+		if err := toml.NewEncoder(&buf).Encode(payload); err != nil {
+			return fmt.Errorf("序列化TOML失败: %w", err)
+		}
+		content = buf.Bytes()
+	case INI, HCL:
+		content, err = marshalConfig(payload, c.configType)
+		if err != nil {
+			return fmt.Errorf("序列化%s失败: %w", strings.ToUpper(string(c.configType)), err)
+		}
 	default:
-		err = fmt.Errorf("不支持的配置类型: %s", c.configType)
+		return fmt.Errorf("不支持的配置类型: %s", c.configType)
+	}
+
+	if err := c.writeConfigFile(content); err != nil {
+		return fmt.Errorf("写入配置文件失败: %w", err)
+	}
+	if len(profileUpdates) > 0 {
+		if err := c.writeProfileSettings(profileUpdates); err != nil {
+			return fmt.Errorf("写入环境配置文件失败: %w", err)
+		}
 	}
 
+	return nil
+}
+
+// writeSettings 将viper中的全部配置写入配置文件，不写入环境变量和命令行参数的覆盖值
+func (c *Config[T]) writeSettings() error {
+	content, err := marshalConfig(c.persistentSettings().AllSettings(), c.configType)
 	if err != nil {
+		return fmt.Errorf("序列化配置失败: %w", err)
+	}
+	if err := c.writeConfigFile(content); err != nil {
 		return fmt.Errorf("写入配置文件失败: %w", err)
 	}
+	return nil
+}
+
+// marshalYAMLPreserved 序列化配置 settings，配置文件已存在时保留其中的注释和key顺序
+func (c *Config[T]) marshalYAMLPreserved(settings map[string]interface{}) ([]byte, error) {
+	content, err := marshalConfig(settings, YAML)
+	if err != nil {
+		return nil, fmt.Errorf("序列化配置失败: %w", err)
+	}
+
+	original, err := c.readConfigFile()
+	if err != nil {
+		// 原文件不存在或无法读取时直接写入新内容
+		return content, nil
+	}
+	return mergeYAMLContent(original, content)
+}
+
+// readConfigFile 读取配置文件内容，配置了加解密时返回解密后的明文
+func (c *Config[T]) readConfigFile() ([]byte, error) {
+	return c.readFile(c.configFile)
+}
+
+// readFile 读取文件内容，配置了加解密时返回解密后的明文
+func (c *Config[T]) readFile(filename string) ([]byte, error) {
+	fileBytes, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("读取配置文件失败: %w", err)
+	}
+	if c.crypto == nil {
+		return fileBytes, nil
+	}
+	plain, err := c.crypto.Decrypt(fileBytes)
+	if err != nil {
+		return nil, fmt.Errorf("解密配置文件失败: %w", err)
+	}
+	return plain, nil
+}
 
+// writeConfigFile 写入配置文件内容，配置了加解密时先加密
+func (c *Config[T]) writeConfigFile(content []byte) error {
+	plain := content
+	if c.crypto != nil {
+		encrypted, err := c.crypto.Encrypt(content)
+		if err != nil {
+			return fmt.Errorf("加密配置文件失败: %w", err)
+		}
+		content = encrypted
+	}
+	if c.backup {
+		if err := backupFile(c.configFile); err != nil {
+			return err
+		}
+	}
+	c.markWritten(content)
+	if err := atomicWriteFile(c.configFile, content, 0644); err != nil {
+		return err
+	}
+	c.recordRaw(plain)
 	return nil
 }
 
@@ -581,16 +928,36 @@ func (c *Config[T]) GetViper() *viper.Viper {
 
 // GetData 获取配置数据
 func (c *Config[T]) GetData() T {
+	c.dataMu.RLock()
+	defer c.dataMu.RUnlock()
 	return c.data
 }
 
+// setData 替换配置数据
+func (c *Config[T]) setData(data T) {
+	c.dataMu.Lock()
+	c.data = data
+	c.dataMu.Unlock()
+}
+
 // Update 更新配置数据并保存
+//
+// 使用ETCD（非前缀模式）时仅当配置在最近一次读取或收到变更通知后未被其他客户端修改时写入，
+// 否则返回 ErrConflict，此时可以等待变更通知后重试，或改用 UpdateFunc
 func (c *Config[T]) Update(data T) error {
 	// 根据配置源保存
 	if c.configFile != "" {
 		return c.SaveConfig()
 	} else if c.etcdClient != nil {
 		return saveConfigToETCD(c.etcdClient, data, c.configType)
+	} else if c.nacosClient != nil {
+		content, err := marshalConfig(data, c.configType)
+		if err != nil {
+			return fmt.Errorf("序列化配置失败: %w", err)
+		}
+		return c.nacosClient.publish(c.nacosClient.ctx, content, c.configType, "")
+	} else if c.httpSource != nil {
+		return ErrReadOnlySource
 	}
 
 	return fmt.Errorf("未指定配置源")
@@ -606,16 +973,54 @@ func (c *Config[T]) Close() {
 	// 清空回调函数列表
 	c.callbackMu.Lock()
 	c.changeCallbacks = nil
+	c.pendingCallbacks = nil
+	c.errorCallbacks = nil
 	c.callbackMu.Unlock()
 
+	// 放弃等待确认的变更
+	c.takePending(nil)
+
+	// 停止文件监听
+	if c.watcher != nil {
+		c.watcher.Close()
+		c.watcher = nil
+	}
+
 	// 关闭ETCD客户端
 	if c.etcdClient != nil {
 		c.etcdClient.close()
 		c.etcdClient = nil
 	}
 
+	// 停止Nacos长轮询
+	if c.nacosClient != nil {
+		c.nacosClient.close()
+		c.nacosClient = nil
+	}
+
+	// 停止HTTP配置轮询并等待轮询goroutine退出，在回调函数中调用时不等待，避免等待自身
+	if c.httpSource != nil {
+		c.httpSource.close()
+		if c.callbacksRunning.Load() == 0 {
+			c.httpSource.wait()
+		}
+		c.httpSource = nil
+	}
+
+	// 停止重新连接配置源和检查配置漂移，等待重新连接的goroutine退出，
+	// 在回调函数中调用时不等待，避免等待自身
+	if c.localCache != nil {
+		c.localCache.close()
+		if c.callbacksRunning.Load() == 0 {
+			c.localCache.wait()
+		}
+	}
+	if c.driftCheck != nil {
+		c.driftCheck.close()
+	}
+
 	// 释放其他资源
 	c.v = nil
-	c.data = *new(T)
-	c.oldData = *new(T)
+	c.setData(*new(T))
+	c.setBaseline(*new(T))
 }
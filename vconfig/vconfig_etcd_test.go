@@ -2,6 +2,7 @@ package vconfig
 
 import (
 	"context"
+	"sync"
 	"testing"
 	"time"
 
@@ -9,6 +10,7 @@ import (
 	"github.com/fsnotify/fsnotify"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	clientv3 "go.etcd.io/etcd/client/v3"
 	"gopkg.in/yaml.v3"
 )
 
@@ -376,3 +378,232 @@ func TestETCDConfigChangeCallbackWithDifferentFormats(t *testing.T) {
 		})
 	}
 }
+
+// 测试ETCD前缀模式
+func TestETCDPrefixConfig(t *testing.T) {
+	const prefix = "/test/prefix/config"
+
+	// 清理ETCD中的配置
+	etcdConfig := DefaultETCDConfig()
+	etcdConfig.Key = prefix
+	etcdConfig.Prefix = true
+	skipWithoutETCD(t, etcdConfig)
+	client, err := newETCDClient(etcdConfig)
+	require.NoError(t, err)
+	_, err = client.client.Delete(context.Background(), prefix+"/", clientv3.WithPrefix())
+	require.NoError(t, err)
+	defer client.close()
+
+	// 创建配置实例，默认配置会拆分为单独的key写入ETCD
+	cfg, err := NewConfig(newDefaultConfig(), WithETCDPrefix[AppConfig](prefix))
+	require.NoError(t, err)
+	defer cfg.Close()
+
+	resp, err := client.client.Get(context.Background(), prefix+"/server/port")
+	require.NoError(t, err)
+	require.Len(t, resp.Kvs, 1)
+	assert.Equal(t, "8080", string(resp.Kvs[0].Value))
+
+	changedCh := make(chan []ConfigChangedItem, 1)
+	cfg.OnChange(func(e fsnotify.Event, changedItems []ConfigChangedItem) {
+		changedCh <- changedItems
+	})
+
+	// 单独修改一个配置项
+	_, err = client.client.Put(context.Background(), prefix+"/server/port", "9100")
+	require.NoError(t, err)
+
+	select {
+	case changedItems := <-changedCh:
+		require.Len(t, changedItems, 1)
+		assert.Equal(t, "server.port", changedItems[0].Path)
+		assert.Equal(t, 8080, changedItems[0].OldValue)
+		assert.Equal(t, 9100, changedItems[0].NewValue)
+	case <-time.After(5 * time.Second):
+		t.Fatal("等待配置变更回调超时")
+	}
+
+	assert.Equal(t, 9100, cfg.GetData().Server.Port)
+}
+
+// 测试Update基于ModRevision比较并交换，不会覆盖其他客户端的修改
+func TestETCDPutConflict(t *testing.T) {
+	etcdConfig := DefaultETCDConfig()
+	etcdConfig.Key = "/test/config_cas"
+	skipWithoutETCD(t, etcdConfig)
+
+	a, err := newETCDClient(etcdConfig)
+	require.NoError(t, err)
+	defer a.close()
+	b, err := newETCDClient(etcdConfig)
+	require.NoError(t, err)
+	defer b.close()
+	_, err = a.client.Delete(context.Background(), etcdConfig.Key)
+	require.NoError(t, err)
+
+	_, err = a.get()
+	require.NoError(t, err)
+	_, err = b.get()
+	require.NoError(t, err)
+
+	require.NoError(t, a.put([]byte("port: 1")))
+	assert.ErrorIs(t, b.put([]byte("port: 2")), ErrConflict)
+
+	// 重新读取后可以写入
+	data, err := b.get()
+	require.NoError(t, err)
+	assert.Equal(t, "port: 1", string(data))
+	require.NoError(t, b.put([]byte("port: 2")))
+	assert.ErrorIs(t, a.put([]byte("port: 3")), ErrConflict)
+}
+
+// 测试监听的版本已被压缩时重新读取配置并继续监听
+func TestETCDWatchResumeAfterCompaction(t *testing.T) {
+	etcdConfig := DefaultETCDConfig()
+	etcdConfig.Key = "/test/config_compact"
+	skipWithoutETCD(t, etcdConfig)
+
+	client, err := newETCDClient(etcdConfig)
+	require.NoError(t, err)
+	defer client.close()
+	ctx := context.Background()
+
+	_, err = client.client.Put(ctx, etcdConfig.Key, "v1")
+	require.NoError(t, err)
+	_, err = client.get()
+	require.NoError(t, err)
+	stale := client.watchRevision.Load()
+
+	// 监听开始前配置已变更，且变更所在的版本已被压缩
+	_, err = client.client.Put(ctx, etcdConfig.Key, "v2")
+	require.NoError(t, err)
+	resp, err := client.client.Put(ctx, etcdConfig.Key, "v3")
+	require.NoError(t, err)
+	_, err = client.client.Compact(ctx, resp.Header.Revision)
+	require.NoError(t, err)
+	client.watchRevision.Store(stale)
+
+	values := make(chan string, 10)
+	client.watch(func(error) {}, func(data []byte) {
+		values <- string(data)
+	})
+
+	select {
+	case v := <-values:
+		assert.Equal(t, "v3", v)
+	case <-time.After(5 * time.Second):
+		t.Fatal("等待重新读取配置超时")
+	}
+
+	_, err = client.client.Put(ctx, etcdConfig.Key, "v4")
+	require.NoError(t, err)
+	select {
+	case v := <-values:
+		assert.Equal(t, "v4", v)
+	case <-time.After(5 * time.Second):
+		t.Fatal("等待配置变更超时")
+	}
+}
+
+// 测试多个实例同时启动时只有一个实例写入默认配置，其他实例读取其写入的配置
+func TestETCDBootstrapConcurrent(t *testing.T) {
+	for _, prefix := range []bool{false, true} {
+		etcdConfig := DefaultETCDConfig()
+		etcdConfig.Key = "/test/config_bootstrap"
+		etcdConfig.Prefix = prefix
+		skipWithoutETCD(t, etcdConfig)
+
+		client, err := newETCDClient(etcdConfig)
+		require.NoError(t, err)
+		_, err = client.client.Delete(context.Background(), etcdConfig.Key, clientv3.WithPrefix())
+		require.NoError(t, err)
+		client.close()
+
+		const replicas = 5
+		ports := make(chan int, replicas)
+		var wg sync.WaitGroup
+		for i := 0; i < replicas; i++ {
+			wg.Add(1)
+			go func(port int) {
+				defer wg.Done()
+				defaults := newDefaultConfig()
+				defaults.Server.Port = port
+				cfg, err := NewConfig(defaults, WithETCDConfig[AppConfig](etcdConfig))
+				if !assert.NoError(t, err) {
+					return
+				}
+				defer cfg.Close()
+				ports <- cfg.GetData().Server.Port
+			}(9000 + i)
+		}
+		wg.Wait()
+		close(ports)
+
+		// 所有实例使用同一个实例写入的端口
+		first := -1
+		for port := range ports {
+			if first == -1 {
+				first = port
+			}
+			assert.Equal(t, first, port, "prefix=%v", prefix)
+		}
+	}
+}
+
+// skipWithoutETCD 在连接超时时间内无法访问ETCD时跳过测试
+func skipWithoutETCD(t *testing.T, etcdConfig *ETCDConfig) {
+	client, err := newETCDClient(etcdConfig)
+	if err != nil {
+		t.Skipf("ETCD不可用，跳过测试: %v", err)
+	}
+	defer client.close()
+	ctx, cancel := context.WithTimeout(context.Background(), etcdConfig.DialTimeout)
+	defer cancel()
+	if _, err := client.client.Status(ctx, etcdConfig.Endpoints[0]); err != nil {
+		t.Skipf("ETCD不可用，跳过测试: %v", err)
+	}
+}
+
+// 测试ETCD草稿写入、读取、发布和冲突
+func TestETCDPublishDraft(t *testing.T) {
+	etcdConfig := DefaultETCDConfig()
+	etcdConfig.Key = "/test/publish/config"
+	skipWithoutETCD(t, etcdConfig)
+
+	client, err := newETCDClient(etcdConfig)
+	require.NoError(t, err)
+	_, err = client.client.Delete(context.Background(), etcdConfig.Key, clientv3.WithPrefix())
+	require.NoError(t, err)
+	client.close()
+
+	cfg, err := NewConfig(newDefaultConfig(), WithETCDConfig[AppConfig](etcdConfig))
+	require.NoError(t, err)
+	defer cfg.Close()
+	ctx := context.Background()
+
+	_, err = cfg.Draft(ctx)
+	assert.ErrorIs(t, err, ErrNoDraft)
+
+	draft := cfg.GetData()
+	draft.Server.Port = 9000
+	require.NoError(t, cfg.PublishDraft(ctx, draft))
+	got, err := cfg.Draft(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 9000, got.Server.Port)
+	assert.Equal(t, 8080, cfg.GetData().Server.Port, "草稿不影响正式配置")
+
+	require.NoError(t, cfg.Promote(ctx))
+	assert.Equal(t, 9000, cfg.GetData().Server.Port)
+	_, err = cfg.Draft(ctx)
+	assert.ErrorIs(t, err, ErrNoDraft)
+
+	// 写入草稿后正式配置被修改时拒绝发布草稿
+	draft.Server.Port = 9100
+	require.NoError(t, cfg.PublishDraft(ctx, draft))
+	current := cfg.GetData()
+	current.Server.Host = "0.0.0.0"
+	require.NoError(t, cfg.Publish(ctx, current))
+	assert.ErrorIs(t, cfg.Promote(ctx), ErrConflict)
+	require.NoError(t, cfg.DiscardDraft(ctx))
+	assert.Equal(t, 9000, cfg.GetData().Server.Port)
+}
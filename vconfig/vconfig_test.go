@@ -2,7 +2,10 @@ package vconfig
 
 import (
 	"encoding/json"
+	"errors"
 	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
@@ -418,3 +421,59 @@ log:
 
 	assert.Empty(t, expectedPaths, "有预期的变更未被检测到: %v", expectedPaths)
 }
+
+// 测试加载和重新加载配置后执行处理函数，处理失败时保留当前配置
+func TestWithOnLoad(t *testing.T) {
+	dir := t.TempDir()
+	configFile := filepath.Join(dir, "app.yaml")
+	require.NoError(t, os.WriteFile(configFile, []byte("app:\n  name: \"  demo  \"\nserver:\n  port: 8081\n"), 0644))
+
+	cfg, err := NewConfig(newDefaultConfig(),
+		WithConfigFile[AppConfig](configFile),
+		WithDebounceTime[AppConfig](10*time.Millisecond),
+		WithOnLoad(func(data *AppConfig) error {
+			data.App.Name = strings.TrimSpace(data.App.Name)
+			return nil
+		}),
+		WithOnLoad(func(data *AppConfig) error {
+			if data.Server.Port == 0 {
+				return errors.New("端口不能为0")
+			}
+			return nil
+		}))
+	require.NoError(t, err)
+	defer cfg.Close()
+	assert.Equal(t, "demo", cfg.GetData().App.Name)
+
+	changes := make(chan int, 10)
+	cfg.OnChange(func(e fsnotify.Event, changedItems []ConfigChangedItem) {
+		changes <- cfg.GetData().Server.Port
+	})
+
+	// 处理失败时放弃本次变更
+	require.NoError(t, os.WriteFile(configFile, []byte("app:\n  name: \" bad \"\nserver:\n  port: 0\n"), 0644))
+	time.Sleep(300 * time.Millisecond)
+	assert.Equal(t, 8081, cfg.GetData().Server.Port)
+	assert.Equal(t, "demo", cfg.GetData().App.Name)
+
+	require.NoError(t, os.WriteFile(configFile, []byte("app:\n  name: \" next \"\nserver:\n  port: 8082\n"), 0644))
+	waitPort(t, changes, 8082)
+	assert.Equal(t, "next", cfg.GetData().App.Name)
+
+	// UpdateFunc 修改的配置同样经过处理
+	assert.ErrorContains(t, cfg.UpdateFunc(func(data *AppConfig) error {
+		data.Server.Port = 0
+		return nil
+	}), "端口不能为0")
+	require.NoError(t, cfg.UpdateFunc(func(data *AppConfig) error {
+		data.App.Name = "  updated "
+		return nil
+	}))
+	assert.Equal(t, "updated", cfg.GetData().App.Name)
+
+	// 初始化时处理失败返回错误
+	_, err = NewConfig(newDefaultConfig(),
+		WithConfigFile[AppConfig](filepath.Join(dir, "other.yaml")),
+		WithOnLoad(func(*AppConfig) error { return errors.New("拒绝") }))
+	assert.ErrorContains(t, err, "拒绝")
+}
@@ -0,0 +1,71 @@
+package vconfig
+
+import (
+	"sync"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// ViewChangeCallback 子配置变更的回调函数，changedItems 中的路径相对于子配置
+type ViewChangeCallback[S any] func(oldValue, newValue S, changedItems []ConfigChangedItem)
+
+// ConfigView 由选择函数从配置中取出的只读子配置
+type ConfigView[S any] struct {
+	get func() S
+
+	mu sync.Mutex
+	// last 最近一次通知的子配置，用于比较变化
+	last      S
+	callbacks []ViewChangeCallback[S]
+}
+
+// View 返回 selector 选取的子配置，其 OnChange 回调只在子配置变化时触发
+//
+//	db := vconfig.View(cfg, func(c AppConfig) DatabaseConfig { return c.Database })
+//	db.OnChange(func(oldValue, newValue DatabaseConfig, _ []vconfig.ConfigChangedItem) {
+//		pool.Reconfigure(newValue)
+//	})
+//
+// selector 应只读取配置，不应修改其中的map、切片或指针
+func View[T, S any](cfg *Config[T], selector func(T) S) *ConfigView[S] {
+	v := &ConfigView[S]{
+		get:  func() S { return selector(cfg.GetData()) },
+		last: cloneConfig(selector(cfg.GetData())),
+	}
+	cfg.OnChange(func(fsnotify.Event, []ConfigChangedItem) {
+		v.notify()
+	})
+	return v
+}
+
+// Get 返回当前的子配置
+func (v *ConfigView[S]) Get() S {
+	return v.get()
+}
+
+// OnChange 添加子配置变更回调函数
+func (v *ConfigView[S]) OnChange(callback ViewChangeCallback[S]) {
+	if callback == nil {
+		return
+	}
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.callbacks = append(v.callbacks, callback)
+}
+
+// notify 配置变化后比较子配置，有变化时触发回调
+func (v *ConfigView[S]) notify() {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	current := v.get()
+	changedItems := findConfigChanges(v.last, current, "")
+	if len(changedItems) == 0 {
+		return
+	}
+	old := v.last
+	v.last = cloneConfig(current)
+	for _, callback := range v.callbacks {
+		callback(old, current, changedItems)
+	}
+}
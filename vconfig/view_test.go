@@ -0,0 +1,54 @@
+package vconfig
+
+import (
+	"testing"
+
+	"github.com/constructorvirgil/virlog/test/testutils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// 测试子配置只在选取的部分变化时回调
+func TestView(t *testing.T) {
+	configFile := testutils.RandomTempFilename("test_view", ".yaml")
+	defer testutils.CleanTempFile(t, configFile)
+
+	cfg, err := NewConfig(newDefaultConfig(),
+		WithConfigFile[AppConfig](configFile),
+		WithDebounceTime[AppConfig](0))
+	require.NoError(t, err)
+	defer cfg.Close()
+
+	type serverConfig = struct {
+		Host string `json:"host" yaml:"host" toml:"host"`
+		Port int    `json:"port" yaml:"port" toml:"port"`
+	}
+	server := View(cfg, func(c AppConfig) serverConfig { return c.Server })
+	assert.Equal(t, 8080, server.Get().Port)
+
+	type change struct {
+		old, new serverConfig
+		items    []ConfigChangedItem
+	}
+	var changes []change
+	server.OnChange(func(oldValue, newValue serverConfig, changedItems []ConfigChangedItem) {
+		changes = append(changes, change{oldValue, newValue, changedItems})
+	})
+
+	// 其他部分变化时不回调
+	require.NoError(t, cfg.UpdateFunc(func(data *AppConfig) error {
+		data.Log.Level = "debug"
+		return nil
+	}))
+	assert.Empty(t, changes)
+
+	require.NoError(t, cfg.UpdateFunc(func(data *AppConfig) error {
+		data.Server.Port = 9090
+		return nil
+	}))
+	require.Len(t, changes, 1)
+	assert.Equal(t, 8080, changes[0].old.Port)
+	assert.Equal(t, 9090, changes[0].new.Port)
+	assert.Equal(t, []ConfigChangedItem{{Path: "port", OldValue: 8080, NewValue: 9090}}, changes[0].items)
+	assert.Equal(t, 9090, server.Get().Port)
+}
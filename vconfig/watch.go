@@ -0,0 +1,79 @@
+package vconfig
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// watchFile 监听文件所在的目录
+//
+// 编辑器通过重命名保存文件（vim、VSCode）以及Kubernetes ConfigMap通过切换符号链接更新文件时，
+// 原文件的inode会被替换，直接监听文件会丢失后续变更，因此改为监听目录并按文件名过滤事件
+func (c *Config[T]) watchFile(filename string) {
+	if c.watcher == nil {
+		return
+	}
+	filename = filepath.Clean(filename)
+	realPath, _ := filepath.EvalSymlinks(filename)
+
+	c.watchMu.Lock()
+	defer c.watchMu.Unlock()
+	if _, ok := c.watchedFiles[filename]; ok {
+		return
+	}
+	if err := c.watcher.Add(filepath.Dir(filename)); err != nil {
+		getInternalLogger().Errorw("添加目录监听失败", "file", filename, "error", err)
+		return
+	}
+	if c.watchedFiles == nil {
+		c.watchedFiles = make(map[string]string)
+	}
+	c.watchedFiles[filename] = realPath
+}
+
+// changedFile 返回目录事件对应的被监听文件，事件与被监听文件无关时返回false
+//
+// 文件被写入或创建（重命名覆盖），或者符号链接指向了新的文件时视为发生变化；
+// 文件被删除或重命名时保留当前配置，等待新文件创建
+func (c *Config[T]) changedFile(event fsnotify.Event) (string, bool) {
+	name := filepath.Clean(event.Name)
+
+	c.watchMu.Lock()
+	defer c.watchMu.Unlock()
+	// 符号链接切换时多个被监听的文件同时变化，全部更新后只重新加载一次，优先返回主配置文件
+	changed := ""
+	for filename, realPath := range c.watchedFiles {
+		current, _ := filepath.EvalSymlinks(filename)
+		switch {
+		case name == filename && event.Op&(fsnotify.Write|fsnotify.Create) != 0:
+		case current != "" && current != realPath:
+		case name == filename && event.Op&(fsnotify.Remove|fsnotify.Rename) != 0:
+			getInternalLogger().Infow("配置文件已被删除或重命名，保留当前配置", "file", filename)
+			continue
+		default:
+			continue
+		}
+		c.watchedFiles[filename] = current
+
+		// 忽略自身保存配置产生的事件
+		if filename == filepath.Clean(c.configFile) && c.written != nil {
+			if content, err := os.ReadFile(filename); err == nil && bytes.Equal(content, c.written) {
+				continue
+			}
+		}
+		if changed == "" || filename == filepath.Clean(c.configFile) {
+			changed = filename
+		}
+	}
+	return changed, changed != ""
+}
+
+// markWritten 记录即将写入的配置文件内容，需在写入文件之前调用，避免事件先于记录到达
+func (c *Config[T]) markWritten(content []byte) {
+	c.watchMu.Lock()
+	defer c.watchMu.Unlock()
+	c.written = content
+}
@@ -0,0 +1,220 @@
+package vconfig
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// waitPort 等待配置变更回调中的端口变为期望值
+func waitPort(t *testing.T, changes <-chan int, port int) {
+	t.Helper()
+	timeout := time.After(3 * time.Second)
+	for {
+		select {
+		case got := <-changes:
+			if got == port {
+				return
+			}
+		case <-timeout:
+			t.Fatalf("等待端口变为 %d 超时", port)
+		}
+	}
+}
+
+// newWatchedConfig 创建配置并在回调中上报端口
+func newWatchedConfig(t *testing.T, configFile string) (*Config[AppConfig], <-chan int) {
+	cfg, err := NewConfig(newDefaultConfig(),
+		WithConfigFile[AppConfig](configFile),
+		WithDebounceTime[AppConfig](10*time.Millisecond))
+	require.NoError(t, err)
+	t.Cleanup(cfg.Close)
+
+	changes := make(chan int, 10)
+	cfg.OnChange(func(e fsnotify.Event, changedItems []ConfigChangedItem) {
+		assert.Equal(t, filepath.Clean(configFile), e.Name)
+		changes <- cfg.GetData().Server.Port
+	})
+	return cfg, changes
+}
+
+// 测试编辑器通过重命名保存配置文件
+func TestWatchRenameSave(t *testing.T) {
+	dir := t.TempDir()
+	configFile := filepath.Join(dir, "app.yaml")
+	_, changes := newWatchedConfig(t, configFile)
+
+	for _, port := range []int{7001, 7002} {
+		tmp := filepath.Join(dir, ".app.yaml.swp")
+		require.NoError(t, os.WriteFile(tmp, []byte("server:\n  port: "+strconv.Itoa(port)+"\n"), 0644))
+		require.NoError(t, os.Rename(tmp, configFile))
+		waitPort(t, changes, port)
+	}
+}
+
+// 测试删除后重新创建配置文件
+func TestWatchRemoveAndCreate(t *testing.T) {
+	dir := t.TempDir()
+	configFile := filepath.Join(dir, "app.yaml")
+	cfg, changes := newWatchedConfig(t, configFile)
+
+	require.NoError(t, os.Remove(configFile))
+	time.Sleep(200 * time.Millisecond)
+	assert.Equal(t, 8080, cfg.GetData().Server.Port)
+
+	require.NoError(t, os.WriteFile(configFile, []byte("server:\n  port: 7100\n"), 0644))
+	waitPort(t, changes, 7100)
+}
+
+// 测试Kubernetes ConfigMap的符号链接切换
+func TestWatchSymlinkSwap(t *testing.T) {
+	dir := t.TempDir()
+
+	// 目录结构与ConfigMap挂载相同：app.yaml -> ..data/app.yaml，..data -> ..v1
+	writeVersion := func(version string, port int) {
+		versionDir := filepath.Join(dir, version)
+		require.NoError(t, os.Mkdir(versionDir, 0755))
+		require.NoError(t, os.WriteFile(filepath.Join(versionDir, "app.yaml"), []byte("server:\n  port: "+strconv.Itoa(port)+"\n"), 0644))
+		require.NoError(t, os.Symlink(version, filepath.Join(dir, "..data_tmp")))
+		require.NoError(t, os.Rename(filepath.Join(dir, "..data_tmp"), filepath.Join(dir, "..data")))
+	}
+	writeVersion("..v1", 7200)
+	configFile := filepath.Join(dir, "app.yaml")
+	require.NoError(t, os.Symlink(filepath.Join("..data", "app.yaml"), configFile))
+
+	cfg, changes := newWatchedConfig(t, configFile)
+	assert.Equal(t, 7200, cfg.GetData().Server.Port)
+
+	writeVersion("..v2", 7201)
+	require.NoError(t, os.RemoveAll(filepath.Join(dir, "..v1")))
+	waitPort(t, changes, 7201)
+}
+
+// 测试保存配置后仍能监听到外部修改，且自身保存不触发回调
+func TestWatchAfterSave(t *testing.T) {
+	dir := t.TempDir()
+	configFile := filepath.Join(dir, "app.yaml")
+	cfg, changes := newWatchedConfig(t, configFile)
+
+	cfg.data.App.Name = "saved"
+	require.NoError(t, cfg.SaveConfig())
+	select {
+	case <-changes:
+		t.Fatal("自身保存不应触发回调")
+	case <-time.After(300 * time.Millisecond):
+	}
+
+	require.NoError(t, os.WriteFile(configFile, []byte("server:\n  port: 7300\n"), 0644))
+	waitPort(t, changes, 7300)
+}
+
+// 测试ConfigMap切换时主配置文件和被引入的文件同时变化，只触发一次回调且旧值为上次加载的配置
+func TestWatchSymlinkSwapWithInclude(t *testing.T) {
+	dir := t.TempDir()
+	writeVersion := func(version string, port int, host string) {
+		versionDir := filepath.Join(dir, version)
+		require.NoError(t, os.Mkdir(versionDir, 0755))
+		require.NoError(t, os.WriteFile(filepath.Join(versionDir, "app.yaml"), []byte("$include: server.yaml\nserver:\n  port: "+strconv.Itoa(port)+"\n"), 0644))
+		require.NoError(t, os.WriteFile(filepath.Join(versionDir, "server.yaml"), []byte("server:\n  host: "+host+"\n"), 0644))
+		require.NoError(t, os.Symlink(version, filepath.Join(dir, "..data_tmp")))
+		require.NoError(t, os.Rename(filepath.Join(dir, "..data_tmp"), filepath.Join(dir, "..data")))
+	}
+	writeVersion("..v1", 7400, "v1.local")
+	configFile := filepath.Join(dir, "app.yaml")
+	require.NoError(t, os.Symlink(filepath.Join("..data", "app.yaml"), configFile))
+	require.NoError(t, os.Symlink(filepath.Join("..data", "server.yaml"), filepath.Join(dir, "server.yaml")))
+
+	cfg, err := NewConfig(newDefaultConfig(),
+		WithConfigFile[AppConfig](configFile),
+		WithDebounceTime[AppConfig](10*time.Millisecond))
+	require.NoError(t, err)
+	defer cfg.Close()
+	assert.Equal(t, "v1.local", cfg.GetData().Server.Host)
+
+	events := make(chan []ConfigChangedItem, 10)
+	cfg.OnChange(func(e fsnotify.Event, changedItems []ConfigChangedItem) {
+		assert.Equal(t, filepath.Clean(configFile), e.Name)
+		events <- changedItems
+	})
+
+	writeVersion("..v2", 7401, "v2.local")
+	require.NoError(t, os.RemoveAll(filepath.Join(dir, "..v1")))
+
+	select {
+	case items := <-events:
+		changes := make(map[string]ConfigChangedItem)
+		for _, item := range items {
+			changes[item.Path] = item
+		}
+		assert.Equal(t, 7400, changes["server.port"].OldValue)
+		assert.Equal(t, 7401, changes["server.port"].NewValue)
+		assert.Equal(t, "v1.local", changes["server.host"].OldValue)
+		assert.Equal(t, "v2.local", changes["server.host"].NewValue)
+	case <-time.After(3 * time.Second):
+		t.Fatal("等待配置变更超时")
+	}
+
+	// 内容未变化的重新加载不触发回调
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "..v2", "server.yaml"), []byte("server:\n  host: v2.local\n"), 0644))
+	select {
+	case items := <-events:
+		t.Fatalf("不应重复触发回调: %v", items)
+	case <-time.After(500 * time.Millisecond):
+	}
+}
+
+// 测试配置文件暂时无效时保留当前的viper配置和结构体，通过 OnError 报告，修复后正常加载
+func TestWatchInvalidReload(t *testing.T) {
+	configFile := filepath.Join(t.TempDir(), "app.yaml")
+	require.NoError(t, os.WriteFile(configFile, []byte("app:\n  name: demo\nserver:\n  port: 7101\n"), 0644))
+	cfg, err := NewConfig(newDefaultConfig(),
+		WithConfigFile[AppConfig](configFile),
+		WithDebounceTime[AppConfig](10*time.Millisecond),
+		WithOnLoad[AppConfig](func(data *AppConfig) error {
+			if data.Server.Port < 0 {
+				return fmt.Errorf("端口无效: %d", data.Server.Port)
+			}
+			return nil
+		}))
+	require.NoError(t, err)
+	defer cfg.Close()
+
+	errs := make(chan error, 10)
+	cfg.OnError(func(err error) {
+		errs <- err
+	})
+	changes := make(chan int, 10)
+	cfg.OnChange(func(e fsnotify.Event, changedItems []ConfigChangedItem) {
+		changes <- cfg.GetData().Server.Port
+	})
+
+	invalid := map[string]string{
+		// 写入到一半的YAML
+		"app:\n  name: half\nserver:\n  port: [7102\n": "解析配置文件",
+		// 可以解析但处理函数拒绝
+		"app:\n  name: changed\nserver:\n  port: -1\n": "端口无效",
+	}
+	for content, message := range invalid {
+		require.NoError(t, os.WriteFile(configFile, []byte(content), 0644))
+		select {
+		case err := <-errs:
+			assert.ErrorContains(t, err, message)
+		case <-time.After(3 * time.Second):
+			t.Fatalf("未收到配置加载失败的错误: %s", message)
+		}
+		assert.Equal(t, 7101, cfg.GetData().Server.Port)
+		assert.Equal(t, "demo", cfg.GetData().App.Name)
+		assert.Equal(t, "demo", cfg.GetViper().GetString("app.name"), "加载失败时viper配置不变")
+	}
+
+	require.NoError(t, os.WriteFile(configFile, []byte("app:\n  name: fixed\nserver:\n  port: 7103\n"), 0644))
+	waitPort(t, changes, 7103)
+	assert.Equal(t, "fixed", cfg.GetViper().GetString("app.name"))
+}